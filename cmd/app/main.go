@@ -4,33 +4,131 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
+	"maps"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
 
+	"go-examples/internal/buildinfo"
+	"go-examples/internal/logsetup"
 	"go-examples/pkg/calculator"
 	"go-examples/pkg/logger"
 )
 
+// stateSaveInterval is how often -state-file is saved while running
+const stateSaveInterval = 30 * time.Second
+
+// historySize is how many calculations the history command lists
+const historySize = 20
+
+// undoDepth is how many changes the undo command can revert
+const undoDepth = 50
+
+// trigPlaces is how many decimal places the results of sin, cos and tan
+// are shown with, so that sin 30 shows 0.5
+const trigPlaces = 10
+
 func main() {
+	logSystem := flag.String("log-system", logsetup.SystemZap, "Logging system to use ("+strings.Join(logger.Systems(), ", ")+")")
+	logLevel := flag.String("log-level", "debug", "Log level (debug, info, warn, error) or per-module spec")
+	logOutput := flag.String("log-output", "stderr", "Log destination: stdout, stderr or a file path")
+	auditFile := flag.String("audit", "", "Append an audit trail of the calculations to this file")
+	stateFile := flag.String("state-file", "", "Restore the calculator state from this file and save it back while running and on exit")
+	replayFile := flag.String("replay", "", "Re-execute the calculations in this audit trail, print the report and exit")
+	floatMode := flag.Bool("float", false, "Calculate with decimal numbers, such as divide 7 2 giving 3.5")
+	showVersion := flag.Bool("version", false, "Print version information and exit")
+	verbose := flag.Bool("verbose", false, "With -version, print every build detail on its own line")
+	flag.Parse()
+
+	if *showVersion {
+		buildinfo.Print(os.Stdout, "app", *verbose)
+		os.Exit(0)
+	}
+
 	// Initialize logger
-	log, err := logger.NewDevelopment()
+	log, cleanup, err := logsetup.Setup(logsetup.Config{
+		System: *logSystem,
+		Level:  *logLevel,
+		Output: *logOutput,
+		Text:   true,
+	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
 		os.Exit(1)
 	}
+	defer cleanup()
+
+	if *replayFile != "" {
+		ok, err := replay(*replayFile, log)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Replay failed: %v\n", err)
+		}
+		if err != nil || !ok {
+			cleanup()
+			os.Exit(1)
+		}
+		return
+	}
+
 	log.Info("Starting calculator application")
 
 	// Create calculator instance with logger
-	calc := calculator.NewCalculator(log)
+	opts := []calculator.Option{calculator.WithHistory(historySize), calculator.WithUndo(undoDepth)}
+	if *auditFile != "" {
+		f, err := os.OpenFile(*auditFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open audit file: %v\n", err)
+			cleanup()
+			os.Exit(1)
+		}
+		defer f.Close()
+		audit, err := logger.NewAudit(f)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to initialize audit logger: %v\n", err)
+			cleanup()
+			os.Exit(1)
+		}
+		opts = append(opts, calculator.WithAudit(audit))
+	}
+	var store calculator.StateStore
+	if *stateFile != "" {
+		store = calculator.NewFileStore(*stateFile)
+		opts = append(opts, calculator.WithAutoSave(store, stateSaveInterval))
+	}
+	calc := calculator.NewCalculator(log, opts...)
+	defer func() {
+		if err := calc.Close(); err != nil {
+			log.Errorf("Saving state failed: %v", err)
+		}
+	}()
+	if store != nil {
+		if err := calc.LoadState(store); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load state: %v\n", err)
+			cleanup()
+			os.Exit(1)
+		}
+	}
 	fmt.Println("Simple Calculator")
 	fmt.Println("=================")
-	fmt.Println("Available operations: add, subtract, quit")
-	fmt.Println("Example usage: add 5 3")
+	var names []string
+	for _, op := range calculator.Describe() {
+		names = append(names, op.Name)
+	}
+	fmt.Println("Available operations: " + strings.Join(names, ", ") + ", avg, explain, sin, cos, tan, deg, rad, bin, oct, hex, tobase, frombase, m+, m-, mr, mc, vars, undo, redo, history, quit")
+	if *floatMode {
+		fmt.Println("Example usage: divide 7.5 2")
+	} else {
+		fmt.Println("Example usage: add 5 3, x = add 5 3, or add 1/3 1/6 for fractions")
+	}
 	fmt.Println()
 
 	scanner := bufio.NewScanner(os.Stdin)
+	angleUnit := calculator.Degrees
 
 	for {
 		fmt.Print("> ")
@@ -46,43 +144,192 @@ func main() {
 			fmt.Println("Goodbye!")
 			break
 		}
+		if input == "history" {
+			printHistory(calc.History())
+			continue
+		}
+		if input == "undo" || input == "redo" {
+			step := calc.Undo
+			if input == "redo" {
+				step = calc.Redo
+			}
+			if result, stepErr := step(); stepErr != nil {
+				fmt.Printf("Error: %s\n", stepErr)
+			} else {
+				fmt.Printf("Result: %d\n", result)
+			}
+			continue
+		}
+		if input == "vars" {
+			printVars(calc.Vars())
+			continue
+		}
+		if input == "deg" || input == "rad" {
+			angleUnit = calculator.Degrees
+			if input == "rad" {
+				angleUnit = calculator.Radians
+			}
+			log.Debugf("Angle unit set to %s", input)
+			fmt.Printf("Angle unit: %s\n", input)
+			continue
+		}
+		if name, value, ok, assignErr := processAssignment(input, calc, log); ok {
+			if assignErr != nil {
+				log.Warnf("Assignment error: %v", assignErr)
+				fmt.Printf("Error: %s\n", assignErr)
+			} else {
+				fmt.Printf("%s = %d\n", name, value)
+			}
+			continue
+		}
+		if memory, ok, memErr := processMemoryCommand(input, calc, log); ok {
+			if memErr != nil {
+				log.Warnf("Memory command error: %v", memErr)
+				fmt.Printf("Error: %s\n", memErr)
+			} else {
+				fmt.Printf("Memory: %d\n", memory)
+			}
+			continue
+		}
+
+		if value, steps, ok, explainErr := processExplainCommand(input, calc, log); ok {
+			printSteps(steps)
+			if explainErr != nil {
+				log.Warnf("Explain error: %v", explainErr)
+				fmt.Printf("Error: %s\n", explainErr)
+			} else {
+				fmt.Printf("Result: %d\n", value)
+			}
+			continue
+		}
 
-		result, err := processCommand(input, calc, log)
+		var result any
+		if converted, ok, convErr := processBaseCommand(input, calc, log); ok {
+			result, err = converted, convErr
+		} else if value, ok, trigErr := processTrigCommand(input, angleUnit, calc, log); ok {
+			result, err = calculator.RoundTo(value, trigPlaces), trigErr
+		} else if strings.Contains(input, "/") {
+			result, err = processFractionCommand(input, calc, log)
+		} else if *floatMode || isFloatOperation(input) {
+			result, err = processFloatCommand(input, calc, log)
+		} else {
+			result, err = processCommand(input, calc, log)
+		}
 		if err != nil {
 			log.Warnf("Command processing error: %v", err)
 			fmt.Printf("Error: %s\n", err)
 			continue
 		}
 
-		log.Infof("Successful calculation, result: %d", result)
-		fmt.Printf("Result: %d\n", result)
+		log.Infof("Successful calculation, result: %v", result)
+		fmt.Printf("Result: %v\n", result)
 	}
 
 	if err := scanner.Err(); err != nil {
 		log.Errorf("Scanner error: %v", err)
 		fmt.Fprintf(os.Stderr, "Reading input: %s\n", err)
+		_ = calc.Close()
+		cleanup()
 		os.Exit(1)
 	}
 
 	log.Info("Application shutting down")
 }
 
+// replay re-executes the calculations of the audit trail at path on a
+// fresh calculator, prints the report and reports whether every entry
+// matched
+func replay(path string, log logger.Logger) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	report, err := calculator.Replay(f, calculator.NewCalculator(log))
+	if err != nil {
+		return false, err
+	}
+	fmt.Print(report)
+	return report.OK(), nil
+}
+
+// printHistory prints the calculations in history, oldest first
+func printHistory(history []calculator.Entry) {
+	if len(history) == 0 {
+		fmt.Println("No calculations yet")
+		return
+	}
+	for i, e := range history {
+		fmt.Printf("%d. %s %s %d %d = %d\n", i+1, e.Time.Format(time.TimeOnly), e.Operation, e.A, e.B, e.Result)
+	}
+}
+
+// printSteps prints the steps of an explained calculation, numbered
+func printSteps(steps []calculator.Step) {
+	for i, step := range steps {
+		fmt.Printf("%d. %s\n", i+1, step.Description)
+	}
+}
+
+// printVars prints the variables, sorted by name
+func printVars(vars map[string]int) {
+	if len(vars) == 0 {
+		fmt.Println("No variables yet")
+		return
+	}
+	for _, name := range slices.Sorted(maps.Keys(vars)) {
+		fmt.Printf("%s = %d\n", name, vars[name])
+	}
+}
+
+// processAssignment performs assignments such as x = add 2 3, binding
+// the result of the integer command on the right to the variable on the
+// left, or x = 5 and y = x. It reports whether input is one.
+func processAssignment(input string, calc *calculator.Calculator, log logger.Logger) (string, int, bool, error) {
+	parts := strings.Fields(input)
+	if len(parts) < 3 || parts[1] != "=" {
+		return "", 0, false, nil
+	}
+	name := parts[0]
+	var value int
+	var err error
+	if len(parts) == 3 {
+		value, err = parseOperand(parts[2], calc)
+	} else {
+		value, err = processCommand(strings.Join(parts[2:], " "), calc, log)
+	}
+	if err != nil {
+		return name, 0, true, err
+	}
+	log.Debugf("Assigning %d to %s", value, name)
+	return name, value, true, calc.SetVar(name, value)
+}
+
 func processCommand(input string, calc *calculator.Calculator, log logger.Logger) (int, error) {
 	// Split the input into command and arguments
 	parts := strings.Fields(input)
+	command := ""
+	if len(parts) > 0 {
+		command = strings.ToLower(parts[0])
+	}
+	if op, ok := calculator.LookupOperation(command); ok && op.Arity == calculator.ArityUnary {
+		return processUnaryCommand(command, parts, calc, log)
+	}
+	if command == "avg" {
+		return processAverageCommand(parts, calc, log)
+	}
 	if len(parts) < 3 {
 		return 0, fmt.Errorf("invalid input, expected format: <operation> <number1> <number2>")
 	}
 
-	command := strings.ToLower(parts[0])
-
 	// Parse the numbers
-	a, err := strconv.Atoi(parts[1])
+	a, err := parseOperand(parts[1], calc)
 	if err != nil {
 		return 0, fmt.Errorf("first number is invalid: %v", err)
 	}
 
-	b, err := strconv.Atoi(parts[2])
+	b, err := parseOperand(parts[2], calc)
 	if err != nil {
 		return 0, fmt.Errorf("second number is invalid: %v", err)
 	}
@@ -90,12 +337,271 @@ func processCommand(input string, calc *calculator.Calculator, log logger.Logger
 	// Perform the operation
 	log.Debugf("Processing command: %s with arguments %d and %d", command, a, b)
 
+	return calc.Compute(command, a, b)
+}
+
+// processUnaryCommand performs the unary operation command on the
+// number in parts, such as abs -5
+func processUnaryCommand(command string, parts []string, calc *calculator.Calculator, log logger.Logger) (int, error) {
+	if len(parts) < 2 {
+		return 0, fmt.Errorf("invalid input, expected format: %s <number>", command)
+	}
+	a, err := parseOperand(parts[1], calc)
+	if err != nil {
+		return 0, fmt.Errorf("number is invalid: %v", err)
+	}
+	log.Debugf("Processing command: %s with argument %d", command, a)
+	return calc.Compute(command, a, 0)
+}
+
+// processAverageCommand averages the two or more numbers in parts, such
+// as avg 1 2 4, rounding halves away from zero
+func processAverageCommand(parts []string, calc *calculator.Calculator, log logger.Logger) (int, error) {
+	if len(parts) < 3 {
+		return 0, fmt.Errorf("invalid input, expected format: avg <number1> <number2> [<number3> ...]")
+	}
+	values := make([]int, len(parts)-1)
+	for i, part := range parts[1:] {
+		value, err := parseOperand(part, calc)
+		if err != nil {
+			return 0, fmt.Errorf("number %d is invalid: %v", i+1, err)
+		}
+		values[i] = value
+	}
+	log.Debugf("Processing command: avg with arguments %v", values)
+	return calc.Average(values...)
+}
+
+// parseOperand parses the integer s, or returns the memory register of
+// calc for mr, as in add mr 5, or the value of the variable s
+func parseOperand(s string, calc *calculator.Calculator) (int, error) {
+	if strings.EqualFold(s, "mr") {
+		return calc.MemRecall(), nil
+	}
+	n, err := strconv.Atoi(s)
+	if err == nil {
+		return n, nil
+	}
+	if value, ok := calc.GetVar(s); ok {
+		return value, nil
+	}
+	if c := s[0]; c == '_' || unicode.IsLetter(rune(c)) {
+		return 0, &calculator.UnknownVariableError{Name: s}
+	}
+	return 0, err
+}
+
+// processExplainCommand performs explain with an operation and its
+// operands, as in explain divide 7 2, or with an expression, as in
+// explain 2 + 3 * 4. It returns the result and the steps taken, which
+// go up to the failure on error, and reports whether input is an
+// explain command.
+func processExplainCommand(input string, calc *calculator.Calculator, log logger.Logger) (int, []calculator.Step, bool, error) {
+	parts := strings.Fields(input)
+	if len(parts) == 0 || !strings.EqualFold(parts[0], "explain") {
+		return 0, nil, false, nil
+	}
+	if len(parts) < 2 {
+		return 0, nil, true, fmt.Errorf("invalid input, expected format: explain <operation> <number1> [<number2>] or explain <expression>")
+	}
+	command := strings.ToLower(parts[1])
+	op, ok := calculator.LookupOperation(command)
+	if !ok {
+		expr := strings.TrimSpace(strings.TrimSpace(input)[len(parts[0]):])
+		log.Debugf("Processing command: explain %s", expr)
+		result, steps, err := calc.ExplainExpr(expr)
+		return result, steps, true, err
+	}
+	operands := 2
+	if op.Arity == calculator.ArityUnary {
+		operands = 1
+	}
+	if len(parts) != 2+operands {
+		return 0, nil, true, fmt.Errorf("invalid input, expected %d numbers after explain %s", operands, command)
+	}
+	var a, b int
+	var err error
+	if a, err = parseOperand(parts[2], calc); err != nil {
+		return 0, nil, true, fmt.Errorf("first number is invalid: %v", err)
+	}
+	if operands == 2 {
+		if b, err = parseOperand(parts[3], calc); err != nil {
+			return 0, nil, true, fmt.Errorf("second number is invalid: %v", err)
+		}
+	}
+	log.Debugf("Processing command: explain %s with arguments %d and %d", command, a, b)
+	result, steps, err := calc.Explain(command, a, b)
+	return result.Value, steps, true, err
+}
+
+// processMemoryCommand performs the memory commands: m+ and m- with a
+// number, mr and mc. It returns the memory value after the command and
+// reports whether input is one of them.
+func processMemoryCommand(input string, calc *calculator.Calculator, log logger.Logger) (int, bool, error) {
+	parts := strings.Fields(input)
+	if len(parts) == 0 {
+		return 0, false, nil
+	}
+	command := strings.ToLower(parts[0])
 	switch command {
-	case "add":
-		return calc.Add(a, b), nil
-	case "subtract":
-		return calc.Subtract(a, b), nil
+	case "mr":
+		return calc.MemRecall(), true, nil
+	case "mc":
+		calc.MemClear()
+		return 0, true, nil
+	case "m+", "m-":
 	default:
-		return 0, fmt.Errorf("unknown operation: %s, supported operations are 'add' and 'subtract'", command)
+		return 0, false, nil
+	}
+	if len(parts) < 2 {
+		return 0, true, fmt.Errorf("invalid input, expected format: %s <number>", command)
+	}
+	v, err := parseOperand(parts[1], calc)
+	if err != nil {
+		return 0, true, fmt.Errorf("number is invalid: %v", err)
 	}
+	log.Debugf("Processing command: %s with argument %d", command, v)
+	if command == "m+" {
+		memory, err := calc.MemAdd(v)
+		return memory, true, err
+	}
+	memory, err := calc.MemSubtract(v)
+	return memory, true, err
+}
+
+// baseCommands are the bases of the commands converting a number to
+// them, such as hex 255
+var baseCommands = map[string]int{"bin": 2, "oct": 8, "hex": 16}
+
+// processBaseCommand performs the base conversion commands: bin, oct and
+// hex with a number, tobase with a number and a base, and frombase with
+// digits and their base, as in frombase ff 16. It reports whether input
+// is one of them.
+func processBaseCommand(input string, calc *calculator.Calculator, log logger.Logger) (string, bool, error) {
+	parts := strings.Fields(input)
+	if len(parts) == 0 {
+		return "", false, nil
+	}
+	command := strings.ToLower(parts[0])
+	base, fixed := baseCommands[command]
+	if !fixed && command != "tobase" && command != "frombase" {
+		return "", false, nil
+	}
+	if len(parts) < 2 || !fixed && len(parts) < 3 {
+		if fixed {
+			return "", true, fmt.Errorf("invalid input, expected format: %s <number>", command)
+		}
+		return "", true, fmt.Errorf("invalid input, expected format: %s <number> <base>", command)
+	}
+	if !fixed {
+		var err error
+		if base, err = strconv.Atoi(parts[2]); err != nil {
+			return "", true, fmt.Errorf("base is invalid: %v", err)
+		}
+	}
+	log.Debugf("Processing command: %s %s in base %d", command, parts[1], base)
+
+	if command == "frombase" {
+		n, err := calc.FromBase(parts[1], base)
+		return strconv.Itoa(n), true, err
+	}
+	n, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", true, fmt.Errorf("number is invalid: %v", err)
+	}
+	s, err := calc.ToBase(n, base)
+	return s, true, err
+}
+
+// processTrigCommand performs the trigonometric commands sin, cos and
+// tan on an angle in unit, such as sin 30 in degrees. It reports whether
+// input is one of them.
+func processTrigCommand(input string, unit calculator.AngleUnit, calc *calculator.Calculator, log logger.Logger) (float64, bool, error) {
+	parts := strings.Fields(input)
+	if len(parts) == 0 {
+		return 0, false, nil
+	}
+	command := strings.ToLower(parts[0])
+	if command != "sin" && command != "cos" && command != "tan" {
+		return 0, false, nil
+	}
+	if len(parts) < 2 {
+		return 0, true, fmt.Errorf("invalid input, expected format: %s <angle>", command)
+	}
+	x, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return 0, true, fmt.Errorf("angle is invalid: %v", err)
+	}
+	log.Debugf("Processing command: %s with argument %g", command, x)
+	result, err := calc.ComputeTrig(command, x, unit)
+	return result, true, err
+}
+
+// processFractionCommand is processCommand with fraction operands, such
+// as 1/3, and result, used when an operand has a slash
+func processFractionCommand(input string, calc *calculator.Calculator, log logger.Logger) (calculator.Fraction, error) {
+	parts := strings.Fields(input)
+	if len(parts) < 3 {
+		return calculator.Fraction{}, fmt.Errorf("invalid input, expected format: <operation> <fraction1> <fraction2>")
+	}
+
+	command := strings.ToLower(parts[0])
+
+	a, err := calculator.ParseFraction(parts[1])
+	if err != nil {
+		return calculator.Fraction{}, fmt.Errorf("first number is invalid: %v", err)
+	}
+
+	b, err := calculator.ParseFraction(parts[2])
+	if err != nil {
+		return calculator.Fraction{}, fmt.Errorf("second number is invalid: %v", err)
+	}
+
+	log.Debugf("Processing command: %s with arguments %s and %s", command, a, b)
+
+	return calc.ComputeFraction(command, a, b)
+}
+
+// isFloatOperation reports whether the command of input is an operation
+// with float operands only, such as ln, performed in float mode or not
+func isFloatOperation(input string) bool {
+	parts := strings.Fields(input)
+	if len(parts) == 0 {
+		return false
+	}
+	op, ok := calculator.LookupOperation(strings.ToLower(parts[0]))
+	return ok && op.OperandType == calculator.OperandFloat
+}
+
+// processFloatCommand is processCommand with float operands and result
+func processFloatCommand(input string, calc *calculator.Calculator, log logger.Logger) (float64, error) {
+	parts := strings.Fields(input)
+	command := ""
+	if len(parts) > 0 {
+		command = strings.ToLower(parts[0])
+	}
+	op, _ := calculator.LookupOperation(command)
+	if op.Arity == calculator.ArityUnary && len(parts) == 2 {
+		parts = append(parts, "0")
+	}
+	if len(parts) < 3 {
+		if op.Arity == calculator.ArityUnary {
+			return 0, fmt.Errorf("invalid input, expected format: %s <number>", command)
+		}
+		return 0, fmt.Errorf("invalid input, expected format: <operation> <number1> <number2>")
+	}
+
+	a, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("first number is invalid: %v", err)
+	}
+
+	b, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return 0, fmt.Errorf("second number is invalid: %v", err)
+	}
+
+	log.Debugf("Processing command: %s with arguments %g and %g", command, a, b)
+
+	return calc.ComputeFloat(command, a, b)
 }
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/logger"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// TestProcessExplainCommand tests explain with operations and with
+// expressions, including those whose first letter is one of explain's
+func TestProcessExplainCommand(t *testing.T) {
+	log, _ := logger.NewObserved(zapcore.DebugLevel)
+	calc := calculator.NewCalculator(log)
+	if err := calc.SetVar("a", 4); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input string
+		want  int
+		steps []string
+	}{
+		{"explain divide 7 2", 3, []string{"checked divisor 2 for zero", "7 / 2 truncates toward zero to 3", "7 / 2 leaves the remainder 1"}},
+		{"explain abs -4", 4, []string{"abs -4 = 4"}},
+		{"explain a * 2", 8, []string{"4 * 2 = 8"}},
+		{"  EXPLAIN  2 + 3 * 4", 14, []string{"3 * 4 = 12", "2 + 12 = 14"}},
+	}
+	for _, tt := range tests {
+		got, steps, ok, err := processExplainCommand(tt.input, calc, log)
+		if !ok || err != nil || got != tt.want {
+			t.Errorf("%q = %d, %t, %v, want %d", tt.input, got, ok, err, tt.want)
+			continue
+		}
+		var descriptions []string
+		for _, step := range steps {
+			descriptions = append(descriptions, step.Description)
+		}
+		if !reflect.DeepEqual(descriptions, tt.steps) {
+			t.Errorf("%q steps = %q, want %q", tt.input, descriptions, tt.steps)
+		}
+	}
+
+	if _, steps, ok, err := processExplainCommand("explain 1 + 2 / 0", calc, log); !ok || !errors.Is(err, calculator.ErrDivisionByZero) || len(steps) != 0 {
+		t.Errorf("explain 1 + 2 / 0 = %q, %t, %v, want %v", steps, ok, err, calculator.ErrDivisionByZero)
+	}
+	for _, input := range []string{"explain", "explain pow 2"} {
+		if _, _, ok, err := processExplainCommand(input, calc, log); !ok || err == nil {
+			t.Errorf("%q = %t, %v, want an error", input, ok, err)
+		}
+	}
+	if _, _, ok, _ := processExplainCommand("add 1 2", calc, log); ok {
+		t.Error("add 1 2 taken for an explain command")
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go-examples/internal/i18n"
+	"go-examples/pkg/calcclient"
+	"go-examples/pkg/logger"
+	"go-examples/pkg/ratelimit"
+	"sync"
+	"time"
+)
+
+// benchResult summarizes a benchmark run
+type benchResult struct {
+	requests   int
+	failed     int
+	elapsed    time.Duration
+	totalTime  time.Duration // sum of request latencies
+	maxLatency time.Duration
+}
+
+// runBench sends config.BenchRequests additions from
+// config.BenchConcurrency workers, paced to config.BenchRate requests per
+// second when it is positive, and prints a summary
+func runBench(client *calcclient.Client, config Configuration, tr *i18n.Translator, log logger.Logger) {
+	fmt.Println(tr.Translate("client.bench_start", config.BenchRequests, config.BenchConcurrency))
+	var limiter *ratelimit.Limiter
+	if config.BenchRate > 0 {
+		fmt.Println(tr.Translate("client.bench_rate", config.BenchRate))
+		limiter = ratelimit.New(config.BenchRate, 1)
+	}
+
+	work := make(chan int)
+	go func() {
+		defer close(work)
+		for i := 0; i < config.BenchRequests; i++ {
+			work <- i
+		}
+	}()
+
+	var mu sync.Mutex
+	result := benchResult{}
+	start := time.Now()
+	var wg sync.WaitGroup
+	for w := 0; w < config.BenchConcurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				ctx := context.Background()
+				if limiter != nil {
+					if err := limiter.Wait(ctx); err != nil {
+						log.Errorf("Rate limiter failed: %v", err)
+						continue
+					}
+				}
+				sent := time.Now()
+				_, err := client.Calculate(ctx, "add", i, 1)
+				latency := time.Since(sent)
+				if err != nil {
+					log.Debugf("Request %d failed: %v", i, err)
+				}
+
+				mu.Lock()
+				result.requests++
+				if err != nil {
+					result.failed++
+				}
+				result.totalTime += latency
+				if latency > result.maxLatency {
+					result.maxLatency = latency
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	result.elapsed = time.Since(start)
+
+	fmt.Println(tr.Translate("client.bench_done", result.requests, result.failed, result.elapsed.Round(time.Millisecond),
+		float64(result.requests)/result.elapsed.Seconds()))
+	if result.requests > 0 {
+		fmt.Println(tr.Translate("client.bench_latency", (result.totalTime / time.Duration(result.requests)).Round(time.Microsecond),
+			result.maxLatency.Round(time.Microsecond)))
+	}
+	stats := client.Stats()
+	fmt.Println(tr.Translate("client.bench_conns", stats.ConnsOpened, stats.ConnsReused, stats.DNSLookups))
+}
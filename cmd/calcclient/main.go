@@ -3,13 +3,18 @@ package main
 
 import (
 	"bufio"
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"io"
-	"net/http"
+	"go-examples/internal/buildinfo"
+	"go-examples/internal/i18n"
+	"go-examples/internal/logsetup"
+	"go-examples/pkg/calcclient"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/logger"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -19,37 +24,132 @@ import (
 type Configuration struct {
 	ServerURL string
 	Timeout   time.Duration
-}
+	LogSystem string // "zap" or "slog"
+	LogLevel  string
+	LogOutput string // "stdout", "stderr" or a file path
+	Lang      string // language tag for messages, such as "de"
 
-// CalculationRequest represents a calculation API request
-type CalculationRequest struct {
-	Operation string `json:"operation"`
-	A         int    `json:"a"`
-	B         int    `json:"b"`
-}
+	BreakerThreshold int           // consecutive transport failures that open the breaker; 0 disables it
+	BreakerCooldown  time.Duration // how long the open breaker fails requests fast
+
+	MaxConns int  // connections to the service; 0 is unlimited
+	H2C      bool // send HTTP/2 without TLS
+
+	BenchRequests    int     // requests sent in bench mode; 0 runs the REPL
+	BenchConcurrency int     // bench mode workers
+	BenchRate        float64 // bench mode requests per second; 0 is unlimited
+
+	Fallback string // "local" computes locally when the service is unreachable
+	Verbose  bool   // print the API version negotiated with the service
+	APIKey   string // bearer token for services requiring API keys
 
-// CalculationResponse represents a calculation API response
-type CalculationResponse struct {
-	Result  int    `json:"result"`
-	Success bool   `json:"success"`
-	Error   string `json:"error,omitempty"`
+	Record       string // transcript file the session is appended to
+	Replay       string // transcript file to replay instead of starting the REPL
+	ReplayTiming bool   // replay at the recorded pace
 }
 
+// FallbackLocal is the -fallback value computing calculations with this
+// build's calculator when the service cannot be reached
+const FallbackLocal = "local"
+
 func main() {
 	// Parse configuration from command line flags
 	config := parseFlags()
 
-	// Check if the service is available
-	if !checkServiceHealth(config) {
-		fmt.Println("Error: Calculator service is not available")
+	// Initialize logger; diagnostics stay out of the REPL's stdout
+	log, cleanup, err := logsetup.Setup(logsetup.Config{
+		System: config.LogSystem,
+		Level:  config.LogLevel,
+		Output: config.LogOutput,
+		Text:   true,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer cleanup()
+
+	// Local messages and the service's error messages use the same language
+	tr := i18n.New(log).Translator(config.Lang)
+	clientOpts := []calcclient.Option{
+		calcclient.WithTimeout(config.Timeout),
+		calcclient.WithLanguage(tr.Lang()),
+		calcclient.WithMaxConnsPerHost(config.MaxConns),
+	}
+	if config.BenchRequests > 0 {
+		// Keep a pooled connection per worker instead of churning through
+		// ephemeral ports
+		clientOpts = append(clientOpts, calcclient.WithMaxIdleConnsPerHost(config.BenchConcurrency))
+	}
+	if config.H2C {
+		clientOpts = append(clientOpts, calcclient.WithForceHTTP2())
+	}
+	if config.BreakerThreshold > 0 {
+		breaker := calcclient.NewBreaker(
+			calcclient.WithFailureThreshold(config.BreakerThreshold),
+			calcclient.WithOpenDuration(config.BreakerCooldown),
+			calcclient.WithStateChange(func(from, to calcclient.State) {
+				log.Warnf("Circuit breaker %s -> %s", from, to)
+			}))
+		clientOpts = append(clientOpts, calcclient.WithCircuitBreaker(breaker))
+	}
+	if config.Fallback == FallbackLocal {
+		clientOpts = append(clientOpts, calcclient.WithLocalFallback(log))
+	}
+	if config.APIKey != "" {
+		clientOpts = append(clientOpts, calcclient.WithAPIKey(config.APIKey))
+	}
+	if config.Replay != "" {
+		client := calcclient.New(config.ServerURL, clientOpts...)
+		ok, err := replay(client, config)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, tr.Translate("client.replay_failed", err))
+		}
+		cleanup()
+		if err != nil || !ok {
+			os.Exit(1)
+		}
+		return
+	}
+	if config.Record != "" {
+		recorder, closeTranscript, err := openTranscript(config.Record)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, tr.Translate("client.record_failed", err))
+			cleanup()
+			os.Exit(1)
+		}
+		defer func() {
+			if err := closeTranscript(); err != nil {
+				log.Errorf("Recording the session to %s failed: %v", config.Record, err)
+			}
+		}()
+		clientOpts = append(clientOpts, calcclient.WithTranscript(recorder))
+	}
+	client := calcclient.New(config.ServerURL, clientOpts...)
+
+	// Check if the service is available; with a local fallback the REPL
+	// works without it
+	if !checkServiceHealth(client, tr, log) && config.Fallback != FallbackLocal {
+		fmt.Println(tr.Translate("client.service_unavailable"))
+		cleanup()
 		os.Exit(1)
 	}
 
-	fmt.Println("Calculator Client")
+	operations := fetchOperations(client, log)
+	if config.Verbose && client.APIVersion() != 0 {
+		fmt.Println(tr.Translate("client.api_version", client.APIVersion()))
+	}
+
+	if config.BenchRequests > 0 {
+		runBench(client, config, tr, log)
+		return
+	}
+
+	fmt.Println(tr.Translate("client.title"))
 	fmt.Println("================")
-	fmt.Printf("Connected to: %s\n", config.ServerURL)
-	fmt.Println("Available operations: add, subtract, multiply, divide, quit")
-	fmt.Println("Example usage: add 5 3")
+	fmt.Println(tr.Translate("client.connected", config.ServerURL))
+	fmt.Println(tr.Translate("client.operations", strings.Join(operations, ", ")))
+	fmt.Println(tr.Translate("client.example"))
 	fmt.Println()
 
 	scanner := bufio.NewScanner(os.Stdin)
@@ -61,24 +161,34 @@ func main() {
 		}
 
 		input := scanner.Text()
-		fmt.Printf("Executing: %s\n", input)
+		fmt.Println(tr.Translate("client.executing", input))
 
 		if input == "quit" || input == "exit" || input == "q" {
-			fmt.Println("Goodbye!")
+			fmt.Println(tr.Translate("client.goodbye"))
 			break
 		}
 
-		result, err := processCommand(input, config)
+		result, source, err := processCommand(input, client, operations, tr)
+		if errors.Is(err, calcclient.ErrCircuitOpen) {
+			fmt.Println(tr.Translate("client.circuit_open", config.BreakerCooldown))
+			continue
+		}
 		if err != nil {
-			fmt.Printf("Error: %s\n", err)
+			log.Debugf("Command %q failed: %v", input, err)
+			fmt.Println(tr.Translate("client.error", err))
 			continue
 		}
 
-		fmt.Printf("Result: %d\n", result)
+		if source == calcclient.SourceLocal {
+			fmt.Println(tr.Translate("client.result_local", result))
+			continue
+		}
+		fmt.Println(tr.Translate("client.result", result))
 	}
 
 	if err := scanner.Err(); err != nil {
-		fmt.Fprintf(os.Stderr, "Reading input: %s\n", err)
+		fmt.Fprintln(os.Stderr, tr.Translate("client.read_failed", err))
+		cleanup()
 		os.Exit(1)
 	}
 }
@@ -87,136 +197,129 @@ func main() {
 func parseFlags() Configuration {
 	serverURL := flag.String("server", "http://localhost:8080", "Calculator service URL")
 	timeout := flag.Int("timeout", 5, "Request timeout in seconds")
+	logSystem := flag.String("log-system", logsetup.SystemZap, "Logging system to use ("+strings.Join(logger.Systems(), ", ")+")")
+	logLevel := flag.String("log-level", "warn", "Log level (debug, info, warn, error)")
+	logOutput := flag.String("log-output", "stderr", "Log destination: stdout, stderr or a file path")
+	lang := flag.String("lang", "", "Message language, such as de or fr (default from LC_ALL, LC_MESSAGES or LANG)")
+	bench := flag.Int("bench", 0, "Send this many requests concurrently and report throughput instead of starting the REPL")
+	concurrency := flag.Int("concurrency", 10, "Number of bench mode workers")
+	rate := flag.Float64("rate", 0, "Bench mode requests per second (0 for unlimited)")
+	breakerThreshold := flag.Int("breaker-threshold", calcclient.DefaultFailureThreshold, "Consecutive connection failures after which requests fail fast (0 disables the circuit breaker)")
+	breakerCooldown := flag.Duration("breaker-cooldown", calcclient.DefaultOpenDuration, "How long requests fail fast before the service is probed again")
+	maxConns := flag.Int("max-conns", 0, "Maximum connections to the service (0 for unlimited)")
+	h2c := flag.Bool("h2c", false, "Send requests over HTTP/2 without TLS; the service must run with -h2c")
+	apiKey := flag.String("api-key", "", "API key for services that require one (default from CALC_API_KEY)")
+	record := flag.String("record", "", "Append every request and response of the session to this JSON lines transcript; API keys are redacted")
+	replayFile := flag.String("replay", "", "Re-send the requests of this transcript, print how the responses differ from the recorded ones and exit")
+	replayTiming := flag.Bool("replay-timing", false, "With -replay, send the requests at their recorded pace")
+	fallback := flag.String("fallback", "", "Set to local to compute with the built-in calculator when the service cannot be reached")
+	showVersion := flag.Bool("version", false, "Print version information and exit")
+	verbose := flag.Bool("verbose", false, "Print the negotiated API version; with -version, print every build detail on its own line")
 	flag.Parse()
 
-	return Configuration{
+	if *fallback != "" && *fallback != FallbackLocal {
+		fmt.Fprintf(os.Stderr, "Invalid -fallback %q: the only fallback is %s\n", *fallback, FallbackLocal)
+		os.Exit(2)
+	}
+
+	if *showVersion {
+		buildinfo.Print(os.Stdout, "calcclient", *verbose)
+		os.Exit(0)
+	}
+
+	config := Configuration{
 		ServerURL: *serverURL,
 		Timeout:   time.Duration(*timeout) * time.Second,
+		LogSystem: *logSystem,
+		LogLevel:  *logLevel,
+		LogOutput: *logOutput,
+		Lang:      *lang,
+
+		BreakerThreshold: *breakerThreshold,
+		BreakerCooldown:  *breakerCooldown,
+
+		MaxConns: *maxConns,
+		H2C:      *h2c,
+
+		BenchRequests:    *bench,
+		BenchConcurrency: *concurrency,
+		BenchRate:        *rate,
+
+		Fallback: *fallback,
+		Verbose:  *verbose,
+		APIKey:   *apiKey,
+
+		Record:       *record,
+		Replay:       *replayFile,
+		ReplayTiming: *replayTiming,
+	}
+	if config.APIKey == "" {
+		config.APIKey = os.Getenv("CALC_API_KEY")
 	}
+	if config.Lang == "" {
+		config.Lang = i18n.EnvLanguage(os.Getenv)
+	}
+	return config
 }
 
 // checkServiceHealth verifies if the calculator service is available
-func checkServiceHealth(config Configuration) bool {
-	client := &http.Client{
-		Timeout: config.Timeout,
+func checkServiceHealth(client *calcclient.Client, tr *i18n.Translator, log logger.Logger) bool {
+	if err := client.Health(context.Background()); err != nil {
+		log.Debugf("Health check failed: %v", err)
+		fmt.Println(tr.Translate("client.health_check_failed", err))
+		return false
 	}
+	log.Debug("Health check passed")
+	return true
+}
 
-	resp, err := client.Get(fmt.Sprintf("%s/health", config.ServerURL))
+// fetchOperations returns the names and aliases of the operations the
+// service accepts, or those of this build's calculator when the service
+// cannot list them
+func fetchOperations(client *calcclient.Client, log logger.Logger) []string {
+	var names []string
+	ops, err := client.Operations(context.Background())
 	if err != nil {
-		fmt.Printf("Health check failed: %v\n", err)
-		return false
-	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			fmt.Printf("Error closing response body: %v\n", err)
+		log.Debugf("Listing operations failed, using the built-in list: %v", err)
+		for _, op := range calculator.Describe() {
+			names = append(names, op.Name)
+			names = append(names, op.Aliases...)
 		}
-	}()
-
-	if resp.StatusCode != http.StatusOK {
-		fmt.Printf("Health check failed with status: %s\n", resp.Status)
-		return false
+		return names
 	}
-
-	var healthResp map[string]bool
-	if err := json.NewDecoder(resp.Body).Decode(&healthResp); err != nil {
-		fmt.Printf("Failed to parse health response: %v\n", err)
-		return false
+	for _, op := range ops {
+		names = append(names, op.Name)
+		names = append(names, op.Aliases...)
 	}
-
-	return healthResp["status"]
+	return names
 }
 
-// processCommand processes the user command and calls the API
-func processCommand(input string, config Configuration) (int, error) {
+// processCommand processes the user command and calls the API, returning
+// where the result was computed
+func processCommand(input string, client *calcclient.Client, operations []string, tr *i18n.Translator) (int, calcclient.Source, error) {
 	// Split the input into command and arguments
 	parts := strings.Fields(input)
 	if len(parts) < 3 {
-		return 0, fmt.Errorf("invalid input, expected format: <operation> <number1> <number2>")
+		return 0, "", errors.New(tr.Translate("client.invalid_input"))
 	}
 
 	operation := strings.ToLower(parts[0])
 	
 	// Validate operation
-	switch operation {
-	case "add", "subtract", "multiply", "divide":
-		// Valid operations
-	default:
-		return 0, fmt.Errorf("unknown operation: %s, supported operations are add, subtract, multiply, and divide", operation)
+	if !slices.Contains(operations, operation) {
+		return 0, "", errors.New(tr.Translate("client.unknown_operation", operation, strings.Join(operations, ", ")))
 	}
 
 	// Parse the numbers
 	a, err := strconv.Atoi(parts[1])
 	if err != nil {
-		return 0, fmt.Errorf("first number is invalid: %v", err)
+		return 0, "", errors.New(tr.Translate("client.invalid_first", err))
 	}
 
 	b, err := strconv.Atoi(parts[2])
 	if err != nil {
-		return 0, fmt.Errorf("second number is invalid: %v", err)
-	}
-
-	// Prepare the API request
-	reqBody := CalculationRequest{
-		Operation: operation,
-		A:         a,
-		B:         b,
+		return 0, "", errors.New(tr.Translate("client.invalid_second", err))
 	}
 
-	return callCalculateAPI(reqBody, config)
+	return client.CalculateWithSource(calcclient.WithCommand(context.Background(), input), operation, a, b)
 }
-
-// callCalculateAPI calls the calculate API endpoint
-func callCalculateAPI(req CalculationRequest, config Configuration) (int, error) {
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: config.Timeout,
-	}
-
-	// Convert request to JSON
-	jsonData, err := json.Marshal(req)
-	if err != nil {
-		return 0, fmt.Errorf("failed to marshal request: %v", err)
-	}
-
-	// Create HTTP request
-	httpReq, err := http.NewRequest("POST", fmt.Sprintf("%s/calculate", config.ServerURL), bytes.NewBuffer(jsonData))
-	if err != nil {
-		return 0, fmt.Errorf("failed to create request: %v", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	// Send the request
-	resp, err := client.Do(httpReq)
-	if err != nil {
-		return 0, fmt.Errorf("request failed: %v", err)
-	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			fmt.Printf("Error closing response body: %v\n", err)
-		}
-	}()
-
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return 0, fmt.Errorf("failed to read response: %v", err)
-	}
-
-	// Check for non-200 status code
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
-	}
-
-	// Parse the response
-	var calcResp CalculationResponse
-	if err := json.Unmarshal(body, &calcResp); err != nil {
-		return 0, fmt.Errorf("failed to parse response: %v", err)
-	}
-
-	// Check for API errors
-	if !calcResp.Success {
-		return 0, fmt.Errorf("API error: %s", calcResp.Error)
-	}
-
-	return calcResp.Result, nil
-}
\ No newline at end of file
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go-examples/pkg/calcclient"
+	"os"
+)
+
+// openTranscript opens the transcript at path for appending, returning
+// the writer for the client and a function closing the file that reports
+// any error recording to it
+func openTranscript(path string) (*calcclient.TranscriptWriter, func() error, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, nil, err
+	}
+	recorder := calcclient.NewTranscriptWriter(f)
+	return recorder, func() error {
+		if err := recorder.Err(); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}, nil
+}
+
+// replay re-sends the requests of the transcript config.Replay with
+// client and prints the report, returning whether every response matched
+// the recorded one
+func replay(client *calcclient.Client, config Configuration) (bool, error) {
+	f, err := os.Open(config.Replay)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	var opts []calcclient.ReplayOption
+	if config.ReplayTiming {
+		opts = append(opts, calcclient.WithReplayTiming())
+	}
+	report, err := client.Replay(context.Background(), f, opts...)
+	if err != nil {
+		return false, err
+	}
+	fmt.Print(report)
+	return report.OK(), nil
+}
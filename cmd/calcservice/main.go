@@ -2,326 +2,485 @@
 package main
 
 import (
-	"encoding/json"
+	"cmp"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"go-examples/internal/buildinfo"
+	"go-examples/internal/i18n"
+	"go-examples/internal/logsetup"
+	"go-examples/internal/svcconfig"
+	"go-examples/pkg/calcserver"
 	"go-examples/pkg/calculator"
+	"go-examples/pkg/httpmw"
 	"go-examples/pkg/logger"
+	"go-examples/pkg/metrics"
+	"go-examples/pkg/metrics/prommetrics"
 	"go-examples/pkg/slogger"
+	"log/slog"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strings"
 	"syscall"
 	"time"
 
-	"github.com/gorilla/mux"
-	"go.uber.org/zap/zapcore"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-// LoggerInterface defines a common interface for both logging systems
-type LoggerInterface interface {
-	Info(args ...interface{})
-	Error(args ...interface{})
-	Debug(args ...interface{})
-	Warn(args ...interface{})
-	Fatal(args ...interface{})
-	
-	Infof(template string, args ...interface{})
-	Errorf(template string, args ...interface{})
-	Warnf(template string, args ...interface{})
-	Fatalf(template string, args ...interface{})
+// Configuration holds all the server configuration
+type Configuration struct {
+	Port          int
+	LogLevel      string
+	LogSystem     string        // "zap" or "slog"
+	LogOutput     string        // "stdout", "stderr" or a file path; empty uses the system's default
+	Env           string        // deployment environment reported on log lines
+	DrainTimeout  time.Duration // how long shutdown waits for in-flight requests
+	CaptureBytes  int           // body bytes logged per request at debug; 0 disables capture
+	RedactFields  []string      // JSON fields hidden in captured bodies
+	MetricsAddr   string        // address serving Prometheus metrics; empty disables metrics
+	H2C           bool          // accept HTTP/2 without TLS
+	ListenFD      int           // descriptor of an inherited listener; 0 listens on Port
+	ConfigFile    string        // JSON file with settings reloaded on SIGHUP; empty uses flags only
+	ShadowURL     string        // base URL of a service calculations are mirrored to; empty disables mirroring
+	ShadowPercent int           // percentage of calculations mirrored to ShadowURL
+	MaxTenants    int           // calculators kept, one per tenant; 0 shares one calculator
+	TenantTTL     time.Duration // how long an idle tenant's calculator is kept
+	TenantState   string        // directory tenant state is saved in; empty keeps it in memory only
+	Strict        bool          // reject inexact results with PRECISION_LOSS instead of truncating them
+	MaxExprOps    int           // operations an expression on /evaluate may have; 0 leaves them unbounded
+	MaxExprValue  int           // magnitude of the values an expression may reach; 0 bounds them only by int
+	EvalTimeout   time.Duration // how long an expression is evaluated for; 0 leaves it to the request
+	MaxTraceSteps int           // operations reported by a trace; 0 reports all of them
+	MaxBodyBytes  int64         // size of the request bodies read; 0 reads bodies of any size
+	SelfTest      bool          // run the self-test and exit instead of serving
+	Warmup        bool          // run the self-test before serving, exiting if it fails
 }
 
-// SlogAdapter adapts the slogger to our common interface
-type SlogAdapter struct {
-	logger slogger.Logger
-}
+func main() {
+	// Buffer slog output until the configured logger exists, so warnings
+	// raised while parsing the configuration reach the real sink
+	startup := slogger.NewReplayHandler(100)
+	slog.SetDefault(slog.New(startup))
 
-// Info logs an informational message
-func (s *SlogAdapter) Info(args ...interface{}) {
-	// Convert args to a message string and any key-value pairs
-	if len(args) > 0 {
-		msg, ok := args[0].(string)
-		if ok {
-			s.logger.Info(msg, args[1:]...)
-		} else {
-			s.logger.Info("info", args...)
+	// Parse configuration from command line flags, and let the
+	// configuration file, if any, override them
+	config := parseFlags()
+	flagLevel := config.LogLevel
+	var file svcconfig.Config
+	if config.ConfigFile != "" {
+		var err error
+		if file, err = svcconfig.Load(config.ConfigFile); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
+		config = withFile(config, file)
 	}
-}
 
-// Error logs an error message
-func (s *SlogAdapter) Error(args ...interface{}) {
-	if len(args) > 0 {
-		msg, ok := args[0].(string)
-		if ok {
-			s.logger.Error(msg, args[1:]...)
-		} else {
-			s.logger.Error("error", args...)
-		}
+	// Record metrics in a Prometheus registry when they are served
+	var registry *prometheus.Registry
+	var provider metrics.Provider
+	if config.MetricsAddr != "" {
+		registry = prometheus.NewRegistry()
+		provider = prommetrics.New(registry, prommetrics.WithNamespace("calcservice"))
 	}
-}
 
-// Debug logs a debug message (maps to Info in slogger)
-func (s *SlogAdapter) Debug(args ...interface{}) {
-	// slogger doesn't have a Debug method, so use Info
-	s.Info(args...)
-}
+	// Initialize logger
+	log, cleanup, err := logsetup.Setup(logsetup.Config{
+		System:  config.LogSystem,
+		Level:   config.LogLevel,
+		Output:  config.LogOutput,
+		Service: "calcservice",
+		Version: buildinfo.Get().Version,
+		Env:     config.Env,
+		Metrics: provider,
+	})
+	if err != nil {
+		startup.Redirect(slog.NewTextHandler(os.Stderr, nil))
+		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer cleanup()
+	structured, isSlog := logsetup.Slog(log)
+	if isSlog {
+		startup.Redirect(structured.Handler())
+	} else {
+		// zap has no slog handler; keep the lines on stderr
+		startup.Redirect(slog.NewTextHandler(os.Stderr, nil))
+	}
+	log.Infof("Starting calculator microservice: %s", buildinfo.Get().Line("calcservice"))
+	log.Infof("Using %s logging system", config.LogSystem)
 
-// Warn logs a warning message (maps to Info in slogger)
-func (s *SlogAdapter) Warn(args ...interface{}) {
-	// slogger doesn't have a Warn method, so use Info
-	s.Info(args...)
-}
+	if config.SelfTest {
+		_, code := selfTest(context.Background(), config, file, log, os.Stdout)
+		cleanup()
+		os.Exit(code)
+	}
+
+	server, apply := newServer(config, file, flagLevel, log, provider)
 
-// Fatal logs a fatal error message and exits the program
-func (s *SlogAdapter) Fatal(args ...interface{}) {
-	if len(args) > 0 {
-		msg, ok := args[0].(string)
-		if ok {
-			s.logger.Fatal(msg, args[1:]...)
-		} else {
-			s.logger.Fatal("fatal", args...)
+	// Check the service before it takes traffic; readiness flips once it
+	// serves
+	if config.Warmup {
+		report := server.SelfTest(context.Background(), selfTestOptions(config, file)...)
+		if !report.OK() {
+			log.Errorf("Warm-up failed: %s", report)
+			cleanup()
+			os.Exit(1)
 		}
+		log.Infof("Warm-up passed %d checks", len(report.Results))
 	}
-}
 
-// Infof logs an informational message with formatting
-func (s *SlogAdapter) Infof(template string, args ...interface{}) {
-	// slogger doesn't have formatted methods, so we'll format it ourselves
-	s.logger.Info(fmt.Sprintf(template, args...))
-}
+	// A Fatal from any component drains the server like SIGTERM does
+	// before the process exits with the Fatal's code
+	slogger.OnFatalTimeout = config.DrainTimeout + time.Second
+	server.ShutdownOnFatal()
 
-// Errorf logs an error message with formatting
-func (s *SlogAdapter) Errorf(template string, args ...interface{}) {
-	s.logger.Error(fmt.Sprintf(template, args...))
-}
+	// Start server on the inherited listener, if any
+	listener, err := listen(config)
+	if err != nil {
+		log.Fatalf("Failed to listen: %v", err)
+	}
+	log.Infof("Server starting on %s", listener.Addr())
 
-// Warnf logs a warning message with formatting
-func (s *SlogAdapter) Warnf(template string, args ...interface{}) {
-	// slogger doesn't have Warn, so use Info
-	s.logger.Info("WARN: " + fmt.Sprintf(template, args...))
-}
+	// Start the server in a goroutine
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			log.Fatalf("Server failed: %v", err)
+		}
+	}()
 
-// Fatalf logs a fatal error message with formatting and exits the program
-func (s *SlogAdapter) Fatalf(template string, args ...interface{}) {
-	s.logger.Fatal(fmt.Sprintf(template, args...))
-}
+	// Serve metrics on their own address, away from the API
+	var metricsServer *http.Server
+	if registry != nil {
+		log.Infof("Metrics available on %s/metrics", config.MetricsAddr)
+		metricsServer = serveMetrics(config.MetricsAddr, registry, log)
+	}
 
-// calculatorLoggerAdapter adapts our common interface to the calculator's logger interface
-type calculatorLoggerAdapter struct {
-	log LoggerInterface
+	// Set up signal handling for graceful shutdown and, on Unix, for
+	// handing the listener off to a new process on SIGUSR2
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	restart := make(chan os.Signal, 1)
+	if len(handoffSignals) > 0 {
+		signal.Notify(restart, handoffSignals...)
+	}
+	reload := make(chan os.Signal, 1)
+	if len(reloadSignals) > 0 {
+		signal.Notify(reload, reloadSignals...)
+	}
+
+	// Wait for interrupt signal, or a handoff, then drain in-flight requests
+	for waiting := true; waiting; {
+		select {
+		case <-stop:
+			log.Info("Shutting down server...")
+			waiting = false
+		case <-reload:
+			if config.ConfigFile == "" {
+				log.Warn("Nothing to reload without -config")
+				break
+			}
+			file = reloadConfig(file, config.ConfigFile, apply, log)
+		case <-restart:
+			log.Info("Handing the listener off to a new process...")
+			// The new process serves metrics on the same address
+			if metricsServer != nil {
+				_ = metricsServer.Close()
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), handoffTimeout)
+			err := server.Handoff(ctx, nil)
+			cancel()
+			if err == nil {
+				waiting = false
+				break
+			}
+			log.Errorf("Handoff failed, still serving: %v", err)
+			if metricsServer != nil {
+				metricsServer = serveMetrics(config.MetricsAddr, registry, log)
+			}
+		}
+	}
+	if err := server.Shutdown(context.Background()); err != nil {
+		cleanup()
+		os.Exit(1)
+	}
 }
 
-func (a *calculatorLoggerAdapter) Debug(args ...interface{})              { a.log.Debug(args...) }
-func (a *calculatorLoggerAdapter) Info(args ...interface{})               { a.log.Info(args...) }
-func (a *calculatorLoggerAdapter) Warn(args ...interface{})               { a.log.Warn(args...) }
-func (a *calculatorLoggerAdapter) Error(args ...interface{})              { a.log.Error(args...) }
-func (a *calculatorLoggerAdapter) Fatal(args ...interface{})              { a.log.Fatal(args...) }
-func (a *calculatorLoggerAdapter) Debugf(template string, args ...interface{})   { a.log.Infof(template, args...) }
-func (a *calculatorLoggerAdapter) Infof(template string, args ...interface{})    { a.log.Infof(template, args...) }
-func (a *calculatorLoggerAdapter) Warnf(template string, args ...interface{})    { a.log.Infof(template, args...) }
-func (a *calculatorLoggerAdapter) Errorf(template string, args ...interface{})   { a.log.Errorf(template, args...) }
-func (a *calculatorLoggerAdapter) Fatalf(template string, args ...interface{})   { a.log.Fatal(fmt.Sprintf(template, args...)) }
-func (a *calculatorLoggerAdapter) With(_ ...interface{}) logger.Logger { return a }
+// newServer creates the calculator and the server with the middleware
+// of config and the configuration file, returning it with the appliers
+// a reload of the file uses. flagLevel is the log level spec to return
+// to when the file stops setting one.
+func newServer(config Configuration, file svcconfig.Config, flagLevel string, log logger.Logger, provider metrics.Provider) (*calcserver.Server, svcconfig.Appliers) {
+	structured, isSlog := logsetup.Slog(log)
+
+	// Create calculator instance with a named logger, so per-module level
+	// specs can target the calculator and the server
+	calcLogger := logger.Named(log, "calculator")
+	serverLogger := logger.Named(log, "server")
+	var calcOpts []calculator.Option
+	if config.Strict {
+		log.Info("Strict mode: rejecting results that are not exact")
+		calcOpts = append(calcOpts, calculator.WithStrict())
+	}
+	// Bound the expressions of /evaluate, so that one request cannot keep
+	// the service busy
+	calcOpts = append(calcOpts,
+		calculator.WithMaxOperations(config.MaxExprOps),
+		calculator.WithMaxMagnitude(config.MaxExprValue),
+	)
+	calc := calculator.NewCalculator(calcLogger, calcOpts...)
+
+	// Set up the API server; in slog mode, turn panics into logged 500
+	// responses, tag every request with an ID, log an access line and,
+	// when enabled, the bodies, and log through the request-scoped logger
+	opts := []calcserver.Option{
+		calcserver.WithDrainTimeout(config.DrainTimeout),
+		calcserver.WithEvaluationTimeout(config.EvalTimeout),
+		calcserver.WithMaxTraceSteps(config.MaxTraceSteps),
+		calcserver.WithMaxBodyBytes(config.MaxBodyBytes),
+	}
+	if provider != nil {
+		opts = append(opts, calcserver.WithMetrics(provider))
+	}
+	if config.H2C {
+		opts = append(opts, calcserver.WithUnencryptedHTTP2())
+	}
+	if config.ShadowURL != "" {
+		// parseFlags has checked the URL
+		if target, err := url.Parse(config.ShadowURL); err == nil {
+			log.Infof("Mirroring %d%% of calculations to %s", config.ShadowPercent, target.Redacted())
+			opts = append(opts, calcserver.WithShadow(target, config.ShadowPercent))
+		}
+	}
+	if config.MaxTenants > 0 {
+		poolOpts := []calcserver.PoolOption{
+			calcserver.WithMaxTenants(config.MaxTenants),
+			calcserver.WithTenantTTL(config.TenantTTL),
+			calcserver.WithCalculatorOptions(calcOpts...),
+		}
+		if config.TenantState != "" {
+			poolOpts = append(poolOpts, calcserver.WithTenantStore(func(tenant string) calculator.StateStore {
+				// Tenant names are safe as file names
+				return calculator.NewFileStore(filepath.Join(config.TenantState, cmp.Or(tenant, "default")+".json"))
+			}))
+		}
+		log.Infof("Keeping a calculator per tenant, up to %d", config.MaxTenants)
+		opts = append(opts, calcserver.WithCalculatorPool(calcserver.NewCalculatorPool(calcLogger, poolOpts...)))
+	}
+	if config.CaptureBytes > 0 && !isSlog {
+		log.Warn("Body capture requires -log-system slog; ignoring -capture-bodies")
+	}
+	if isSlog {
+		opts = append(opts,
+			calcserver.WithMiddleware(
+				httpmw.Recovery(structured),
+				httpmw.RequestID(),
+				httpmw.Logging(structured, slogger.WithSkipPaths("/health", "/ready")),
+				httpmw.CaptureBodies(structured, config.CaptureBytes, httpmw.WithRedactFields(config.RedactFields...)),
+			),
+			calcserver.WithRequestLogger(func(r *http.Request) logger.Logger {
+				return logger.Named(logsetup.WrapSlog(slogger.FromContext(r.Context())), "server")
+			}),
+		)
+	}
+	// Apply CORS, rate limiting and API keys from the configuration file
+	// through switches, so a reload can replace them while serving
+	cors := httpmw.NewSwitch(corsMiddleware(file.CORSOrigins))
+	limit := httpmw.NewSwitch(rateLimitMiddleware(file.RateLimit))
+	auth := httpmw.NewSwitch(authMiddleware(file.APIKeys))
+	opts = append(opts, calcserver.WithMiddleware(cors.Middleware, limit.Middleware, auth.Middleware))
+	apply := svcconfig.Appliers{
+		LogLevel: func(spec string) error {
+			ls, err := logger.ParseLevelSpec(cmp.Or(spec, flagLevel))
+			if err != nil {
+				return err
+			}
+			return logger.SetLevelSpec(log, ls)
+		},
+		RateLimit:   func(rl svcconfig.RateLimit) { limit.Replace(rateLimitMiddleware(rl)) },
+		CORSOrigins: func(origins []string) { cors.Replace(corsMiddleware(origins)) },
+		APIKeys:     func(keys []string) { auth.Replace(authMiddleware(keys)) },
+	}
 
-// Configuration holds all the server configuration
-type Configuration struct {
-	Port      int
-	LogLevel  string
-	LogSystem string // "zap" or "slog"
+	// Render error messages in the language of the Accept-Language header
+	opts = append(opts, calcserver.WithMiddleware(i18n.Middleware(i18n.New(serverLogger))))
+	server := calcserver.New(calc, serverLogger, opts...)
+	return server, apply
 }
 
-// CalculationRequest represents a calculation API request
-type CalculationRequest struct {
-	Operation string `json:"operation"`
-	A         int    `json:"a"`
-	B         int    `json:"b"`
+// handoffTimeout bounds how long the new process may take to serve the
+// handed-off listener
+const handoffTimeout = 30 * time.Second
+
+// listen returns the listener inherited from a handoff or socket
+// activation, or the one given with -listen-fd, or else listens on the
+// configured port. A handoff comes first, as the new process gets the
+// same arguments, and so the same -listen-fd, as the old one.
+func listen(config Configuration) (net.Listener, error) {
+	if l, err := calcserver.InheritedListener(); l != nil || err != nil {
+		return l, err
+	}
+	if config.ListenFD > 0 {
+		return calcserver.ListenerFromFD(config.ListenFD)
+	}
+	return net.Listen("tcp", fmt.Sprintf(":%d", config.Port))
 }
 
-// CalculationResponse represents a calculation API response
-type CalculationResponse struct {
-	Result  int    `json:"result"`
-	Success bool   `json:"success"`
-	Error   string `json:"error,omitempty"`
+// withFile returns config with the settings of the configuration file
+// that are set replacing the flags
+func withFile(config Configuration, file svcconfig.Config) Configuration {
+	if file.Port != 0 {
+		config.Port = file.Port
+	}
+	if file.MetricsAddr != "" {
+		config.MetricsAddr = file.MetricsAddr
+	}
+	if file.LogLevel != "" {
+		config.LogLevel = file.LogLevel
+	}
+	return config
 }
 
-func main() {
-	// Parse configuration from command line flags
-	config := parseFlags()
-
-	// Initialize logger
-	log, err := setupLogger(config)
+// reloadConfig re-reads the configuration file and applies the settings
+// that changed, logging which were applied and which need a restart. If
+// the file is invalid, current stays in effect.
+func reloadConfig(current svcconfig.Config, path string, apply svcconfig.Appliers, log logger.Logger) svcconfig.Config {
+	next, result, err := svcconfig.Reload(current, path, apply)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
-		os.Exit(1)
+		log.Errorf("Config reload failed, keeping the running config: %v", err)
+		return current
 	}
-	log.Info("Starting calculator microservice")
-	log.Infof("Using %s logging system", config.LogSystem)
+	if len(result.Applied) == 0 && len(result.Restart) == 0 {
+		log.Info("Config reloaded, nothing changed")
+	}
+	if len(result.Applied) > 0 {
+		log.Infof("Config reloaded, applied: %s", strings.Join(result.Applied, ", "))
+	}
+	if len(result.Restart) > 0 {
+		log.Warnf("Config changes need a restart: %s", strings.Join(result.Restart, ", "))
+	}
+	return next
+}
 
-	// Create calculator instance with logger
-	var calcLogger logger.Logger
-	if zapLogger, ok := log.(logger.Logger); ok {
-		// If it's the original logger, use it directly
-		calcLogger = zapLogger
-	} else {
-		// If it's the slog adapter, create a simple adapter for the calculator
-		// The calculator expects the original logger interface
-		calcLogger = &calculatorLoggerAdapter{log: log}
+// corsMiddleware allows origins, or returns nil when there are none
+func corsMiddleware(origins []string) httpmw.Middleware {
+	if len(origins) == 0 {
+		return nil
 	}
-	calc := calculator.NewCalculator(calcLogger)
+	return httpmw.CORS(httpmw.CORSConfig{AllowedOrigins: origins})
+}
 
-	// Set up API routes
-	router := mux.NewRouter()
-	router.HandleFunc("/calculate", createCalculateHandler(calc, log)).Methods("POST")
-	router.HandleFunc("/health", healthCheckHandler).Methods("GET")
+// rateLimitMiddleware limits each client to rl, or returns nil when
+// rate limiting is disabled
+func rateLimitMiddleware(rl svcconfig.RateLimit) httpmw.Middleware {
+	if rl.PerSecond <= 0 {
+		return nil
+	}
+	return httpmw.RateLimit(rl.PerSecond, rl.Burst)
+}
 
-	// Start server
-	serverAddr := fmt.Sprintf(":%d", config.Port)
-	log.Infof("Server starting on %s", serverAddr)
-	
-	// Create a server with graceful shutdown and security settings
-	server := &http.Server{
-		Addr:              serverAddr,
-		Handler:           router,
-		ReadHeaderTimeout: 5 * time.Second, // Prevent Slowloris attacks
+// authMiddleware requires one of keys as bearer token outside the health
+// endpoints, or returns nil when there are none
+func authMiddleware(keys []string) httpmw.Middleware {
+	if len(keys) == 0 {
+		return nil
 	}
+	return httpmw.Auth(httpmw.BearerToken(keys...), "/health", "/health/detail", "/ready")
+}
 
-	// Start the server in a goroutine
+// serveMetrics serves the metrics of registry on /metrics at addr
+func serveMetrics(addr string, registry *prometheus.Registry, log logger.Logger) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
 	go func() {
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed: %v", err)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Metrics server failed: %v", err)
 		}
 	}()
-
-	// Set up signal handling for graceful shutdown
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
-
-	// Wait for interrupt signal
-	<-stop
-	log.Info("Shutting down server...")
-	log.Info("Server stopped")
+	return srv
 }
 
 // parseFlags parses command line flags and returns configuration
 func parseFlags() Configuration {
 	port := flag.Int("port", 8080, "Server port")
-	logLevel := flag.String("log-level", "info", "Log level (debug, info, warn, error)")
-	logSystem := flag.String("log-system", "zap", "Logging system to use (zap or slog)")
+	logLevel := flag.String("log-level", "info", "Log level (debug, info, warn, error) or per-module spec like \"info,calculator=debug\"")
+	logSystem := flag.String("log-system", logsetup.SystemZap, "Logging system to use ("+strings.Join(logger.Systems(), ", ")+")")
+	logOutput := flag.String("log-output", "", "Log destination: stdout, stderr or a file path (default stdout for zap, stderr for slog)")
+	env := flag.String("env", "development", "Deployment environment reported in logs")
+	drainTimeout := flag.Duration("drain-timeout", calcserver.DefaultDrainTimeout, "Time to wait for in-flight requests on shutdown")
+	captureBytes := flag.Int("capture-bodies", 0, "Log up to this many bytes of request and response bodies at debug level (slog only; 0 disables)")
+	redactFields := flag.String("capture-redact", strings.Join(httpmw.DefaultRedactFields, ","), "Comma-separated JSON fields hidden in captured bodies")
+	metricsAddr := flag.String("metrics-addr", "", "Address serving Prometheus metrics on /metrics, such as :9090 (empty disables metrics)")
+	listenFD := flag.Int("listen-fd", 0, "Serve the listening socket open on this descriptor instead of listening on -port")
+	configFile := flag.String("config", "", "JSON file with settings overriding the flags; SIGHUP reloads log_level, rate_limit, cors_origins and api_keys from it")
+	h2c := flag.Bool("h2c", false, "Accept HTTP/2 without TLS (h2c) next to HTTP/1")
+	shadowURL := flag.String("shadow-url", "", "Base URL of a service, such as a new version, to mirror calculations to and compare results with (empty disables mirroring)")
+	shadowPercent := flag.Int("shadow-percent", 10, "Percentage of calculations mirrored to -shadow-url")
+	maxTenants := flag.Int("max-tenants", 0, "Give each tenant, told by its API key or X-Tenant header, its own calculator, keeping up to this many (0 shares one calculator)")
+	tenantTTL := flag.Duration("tenant-ttl", calcserver.DefaultTenantTTL, "With -max-tenants, how long the calculator of an idle tenant is kept")
+	tenantState := flag.String("tenant-state", "", "With -max-tenants, directory each tenant's calculator state is loaded from and saved to on eviction and shutdown")
+	strict := flag.Bool("strict", false, "Answer PRECISION_LOSS instead of a truncated or wrapped result, for divisions with a remainder and overflows; requests may also ask for it with \"strict\": true")
+	maxExprOps := flag.Int("max-expr-operations", 1000, "Reject expressions on /evaluate with more operations than this with LIMIT_EXCEEDED (0 leaves them unbounded)")
+	maxExprValue := flag.Int("max-expr-magnitude", 1<<53, "Stop expressions on /evaluate with LIMIT_EXCEEDED once a value exceeds this in absolute value (0 bounds values only by 64 bits)")
+	evalTimeout := flag.Duration("evaluate-timeout", 2*time.Second, "Stop expressions on /evaluate with TIMEOUT after this long (0 evaluates until the client goes away)")
+	maxTraceSteps := flag.Int("max-trace-steps", calcserver.DefaultMaxTraceSteps, "Report up to this many operations in the trace of a request to /evaluate or /calculate/batch with \"trace\": true (0 reports all of them)")
+	maxBodyBytes := flag.Int64("max-body-bytes", calcserver.DefaultMaxBodyBytes, "Answer PAYLOAD_TOO_LARGE to requests with a body longer than this many bytes (0 reads bodies of any size)")
+	selfTest := flag.Bool("self-test", false, "Build the server, check every operation, the health endpoints, the configuration and the metrics address, print a summary and exit 0 if all pass or 1")
+	warmup := flag.Bool("warmup", false, "Run the -self-test checks before serving, so the service is only ready once they pass")
+	showVersion := flag.Bool("version", false, "Print version information and exit")
+	verbose := flag.Bool("verbose", false, "With -version, print every build detail on its own line")
 	flag.Parse()
 
-	return Configuration{
-		Port:      *port,
-		LogLevel:  *logLevel,
-		LogSystem: strings.ToLower(*logSystem),
+	if *showVersion {
+		buildinfo.Print(os.Stdout, "calcservice", *verbose)
+		os.Exit(0)
 	}
-}
 
-// setupLogger creates and configures the logger based on the configuration
-func setupLogger(config Configuration) (LoggerInterface, error) {
-	switch config.LogSystem {
-	case "slog":
-		// Initialize structured logger (slogger)
-		slog := slogger.InitLogging()
-		return &SlogAdapter{logger: slog}, nil
-		
-	case "zap", "":
-		// Initialize zap logger (original logger)
-		var zapLevel zapcore.Level
-		
-		switch config.LogLevel {
-		case "debug":
-			zapLevel = zapcore.DebugLevel
-		case "info":
-			zapLevel = zapcore.InfoLevel
-		case "warn":
-			zapLevel = zapcore.WarnLevel
-		case "error":
-			zapLevel = zapcore.ErrorLevel
-		default:
-			zapLevel = zapcore.InfoLevel
+	if *shadowURL != "" {
+		if err := validateShadow(*shadowURL, *shadowPercent); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
 		}
-		
-		// Using NewCustom which doesn't return error
-		return logger.NewCustom(zapLevel, true), nil
-		
-	default:
-		return nil, fmt.Errorf("unknown log system: %s, supported systems are 'zap' and 'slog'", config.LogSystem)
 	}
-}
-
-// createCalculateHandler returns an HTTP handler for calculator operations
-func createCalculateHandler(calc *calculator.Calculator, log LoggerInterface) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		// Parse request
-		var req CalculationRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			sendErrorResponse(w, "Invalid request format", http.StatusBadRequest, log)
-			return
-		}
-
-		log.Infof("Calculation request: %+v", req)
-
-		// Process calculation
-		var result int
-
-		switch req.Operation {
-		case "add":
-			result = calc.Add(req.A, req.B)
-		case "subtract":
-			result = calc.Subtract(req.A, req.B)
-		case "multiply":
-			result = calc.Multiply(req.A, req.B)
-		case "divide":
-			if req.B == 0 {
-				sendErrorResponse(w, "Division by zero", http.StatusBadRequest, log)
-				return
-			}
-			result = calc.Divide(req.A, req.B)
-		default:
-			sendErrorResponse(w, "Unknown operation: "+req.Operation, http.StatusBadRequest, log)
-			return
-		}
 
-		// Send successful response
-		resp := CalculationResponse{
-			Result:  result,
-			Success: true,
-		}
-
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		if err := json.NewEncoder(w).Encode(resp); err != nil {
-			log.Errorf("Failed to encode response: %v", err)
-		}
+	if *drainTimeout <= 0 {
+		slog.Warn("drain timeout must be positive, using the default",
+			"drain_timeout", *drainTimeout, "default", calcserver.DefaultDrainTimeout)
+		*drainTimeout = calcserver.DefaultDrainTimeout
 	}
-}
 
-// healthCheckHandler handles health check requests
-func healthCheckHandler(w http.ResponseWriter, _ *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(map[string]bool{"status": true}); err != nil {
-		// This would rarely happen, but we should handle it
-		w.WriteHeader(http.StatusInternalServerError)
+	return Configuration{
+		Port:          *port,
+		LogLevel:      *logLevel,
+		LogSystem:     strings.ToLower(*logSystem),
+		LogOutput:     *logOutput,
+		Env:           *env,
+		DrainTimeout:  *drainTimeout,
+		CaptureBytes:  *captureBytes,
+		RedactFields:  strings.Split(*redactFields, ","),
+		MetricsAddr:   *metricsAddr,
+		H2C:           *h2c,
+		ListenFD:      *listenFD,
+		ConfigFile:    *configFile,
+		ShadowURL:     *shadowURL,
+		ShadowPercent: *shadowPercent,
+		MaxTenants:    *maxTenants,
+		TenantTTL:     *tenantTTL,
+		TenantState:   *tenantState,
+		Strict:        *strict,
+		MaxExprOps:    *maxExprOps,
+		MaxExprValue:  *maxExprValue,
+		EvalTimeout:   *evalTimeout,
+		MaxTraceSteps: *maxTraceSteps,
+		MaxBodyBytes:  *maxBodyBytes,
+		SelfTest:      *selfTest,
+		Warmup:        *warmup,
 	}
 }
-
-// sendErrorResponse sends an error response with the given message and status code
-func sendErrorResponse(w http.ResponseWriter, message string, statusCode int, log LoggerInterface) {
-	log.Warnf("Error response: %s (code: %d)", message, statusCode)
-	resp := CalculationResponse{
-		Success: false,
-		Error:   message,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		log.Errorf("Failed to encode error response: %v", err)
-		// In case we can't encode the JSON response, send a plain text error
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-	}
-}
\ No newline at end of file
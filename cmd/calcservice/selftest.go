@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-examples/internal/svcconfig"
+	"go-examples/pkg/calcserver"
+	"go-examples/pkg/logger"
+	"io"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// selfTest builds the service as main does, without serving it, runs the
+// self-test on it and writes the summary to out. It returns the report
+// and the exit status: 0 if every check passed, 1 otherwise.
+func selfTest(ctx context.Context, config Configuration, file svcconfig.Config, log logger.Logger, out io.Writer) (calcserver.SelfTestReport, int) {
+	server, _ := newServer(config, file, config.LogLevel, log, nil)
+	report := server.SelfTest(ctx, selfTestOptions(config, file)...)
+	fmt.Fprint(out, report)
+	if !report.OK() {
+		return report, 1
+	}
+	return report, 0
+}
+
+// selfTestOptions returns the self-test checks of the configuration and
+// of the addresses the service needs, and the credentials its requests
+// pass the auth middleware with. Dependencies register their checks with
+// the server's HealthChecker, which the self-test runs too.
+func selfTestOptions(config Configuration, file svcconfig.Config) []calcserver.SelfTestOption {
+	opts := []calcserver.SelfTestOption{
+		calcserver.WithSelfTestCheck("config", func(context.Context) error {
+			return validateConfig(config, file)
+		}),
+	}
+	if len(file.APIKeys) > 0 {
+		opts = append(opts, calcserver.WithSelfTestHeader("Authorization", "Bearer "+file.APIKeys[0]))
+	}
+	if config.MetricsAddr != "" {
+		opts = append(opts, calcserver.WithSelfTestCheck("metrics address", func(context.Context) error {
+			l, err := net.Listen("tcp", config.MetricsAddr)
+			if err != nil {
+				return err
+			}
+			return l.Close()
+		}))
+	}
+	return opts
+}
+
+// validateConfig reports every invalid setting of config and file
+func validateConfig(config Configuration, file svcconfig.Config) error {
+	var errs []string
+	if config.Port < 0 || config.Port > 65535 {
+		errs = append(errs, fmt.Sprintf("port %d out of range", config.Port))
+	}
+	if !slices.Contains(logger.Systems(), config.LogSystem) {
+		errs = append(errs, fmt.Sprintf("unknown log system %q, want one of %s", config.LogSystem, strings.Join(logger.Systems(), ", ")))
+	}
+	if _, err := logger.ParseLevelSpec(config.LogLevel); err != nil {
+		errs = append(errs, fmt.Sprintf("invalid log level: %v", err))
+	}
+	if config.DrainTimeout <= 0 {
+		errs = append(errs, fmt.Sprintf("drain timeout %s must be positive", config.DrainTimeout))
+	}
+	if config.CaptureBytes < 0 {
+		errs = append(errs, fmt.Sprintf("capture bytes %d must not be negative", config.CaptureBytes))
+	}
+	if config.MaxExprOps < 0 {
+		errs = append(errs, fmt.Sprintf("max expression operations %d must not be negative", config.MaxExprOps))
+	}
+	if config.MaxExprValue < 0 {
+		errs = append(errs, fmt.Sprintf("max expression magnitude %d must not be negative", config.MaxExprValue))
+	}
+	if config.EvalTimeout < 0 {
+		errs = append(errs, fmt.Sprintf("evaluate timeout %s must not be negative", config.EvalTimeout))
+	}
+	if config.MaxTraceSteps < 0 {
+		errs = append(errs, fmt.Sprintf("max trace steps %d must not be negative", config.MaxTraceSteps))
+	}
+	if config.MaxBodyBytes < 0 {
+		errs = append(errs, fmt.Sprintf("max body bytes %d must not be negative", config.MaxBodyBytes))
+	}
+	if config.ShadowURL != "" {
+		if err := validateShadow(config.ShadowURL, config.ShadowPercent); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if err := file.Validate(); err != nil {
+		errs = append(errs, fmt.Sprintf("config file: %v", err))
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// validateShadow checks the -shadow-url and -shadow-percent flags
+func validateShadow(rawURL string, percent int) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid shadow URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("invalid shadow URL %q: want http://host or https://host", u.Redacted())
+	}
+	if percent < 0 || percent > 100 {
+		return fmt.Errorf("shadow percent %d must be between 0 and 100", percent)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"go-examples/internal/svcconfig"
+	"go-examples/pkg/calcserver"
+	"go-examples/pkg/logger"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// goodConfig is a configuration the service runs with
+func goodConfig() (Configuration, svcconfig.Config) {
+	config := Configuration{
+		Port:         8080,
+		LogLevel:     "info",
+		LogSystem:    "zap",
+		DrainTimeout: calcserver.DefaultDrainTimeout,
+		MaxExprOps:   1000,
+		MaxExprValue: 1 << 53,
+	}
+	file := svcconfig.Config{
+		RateLimit: svcconfig.RateLimit{PerSecond: 100, Burst: 100},
+		APIKeys:   []string{"secret"},
+	}
+	return config, file
+}
+
+// TestSelfTest tests that a good configuration passes every check
+func TestSelfTest(t *testing.T) {
+	log, _ := logger.NewObserved(zapcore.DebugLevel)
+	config, file := goodConfig()
+	var out strings.Builder
+	report, code := selfTest(context.Background(), config, file, log, &out)
+	if code != 0 || !report.OK() {
+		t.Fatalf("self-test exited %d:\n%s", code, out.String())
+	}
+	if !strings.HasPrefix(out.String(), "self-test: ") || !strings.Contains(out.String(), "ok   calculate add") {
+		t.Errorf("summary = %q", out.String())
+	}
+}
+
+// TestSelfTestBroken tests that an invalid configuration and a metrics
+// address in use fail the self-test, and nothing else
+func TestSelfTestBroken(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+
+	log, _ := logger.NewObserved(zapcore.DebugLevel)
+	config, file := goodConfig()
+	config.LogLevel = "loud"
+	config.MetricsAddr = busy.Addr().String()
+	config.MaxExprOps = -1
+	file.RateLimit.PerSecond = -1
+
+	var out strings.Builder
+	report, code := selfTest(context.Background(), config, file, log, &out)
+	if code != 1 {
+		t.Errorf("self-test exited %d, want 1", code)
+	}
+	if want := []string{"config", "metrics address"}; !reflect.DeepEqual(report.Failed(), want) {
+		t.Errorf("failed checks = %v, want %v:\n%s", report.Failed(), want, out.String())
+	}
+	for _, want := range []string{"invalid log level", "max expression operations", "rate_limit.per_second"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("summary misses %q:\n%s", want, out.String())
+		}
+	}
+}
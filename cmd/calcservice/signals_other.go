@@ -0,0 +1,9 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// handoffSignals and reloadSignals are empty, as listener handoff needs
+// Unix and Windows has no SIGHUP to send
+var handoffSignals, reloadSignals []os.Signal
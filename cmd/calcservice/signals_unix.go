@@ -0,0 +1,15 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+var (
+	// handoffSignals start a listener handoff to a new process
+	handoffSignals = []os.Signal{syscall.SIGUSR2}
+	// reloadSignals reload the configuration file
+	reloadSignals = []os.Signal{syscall.SIGHUP}
+)
@@ -0,0 +1,153 @@
+package goodstructure
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+)
+
+// Environment variable names, after the prefix passed to LoadFromEnv
+const (
+	EnvDatabaseURL = "DATABASE_URL"
+	EnvAPIKey      = "API_KEY"
+	EnvDebug       = "DEBUG"
+)
+
+// Fields of a Configuration that were loaded rather than left unset
+const (
+	setDatabaseURL fieldSet = 1 << iota
+	setAPIKey
+	setDebug
+)
+
+// fieldSet records which fields a loader set, so that Merge can tell an
+// explicit "false" or "" apart from a missing value
+type fieldSet uint8
+
+// fileConfig is the JSON form of a Configuration; pointers tell missing
+// keys apart from zero values
+type fileConfig struct {
+	DatabaseURL *string `json:"database_url"`
+	APIKey      *string `json:"api_key"`
+	Debug       *bool   `json:"debug"`
+}
+
+// LoadFromEnv reads the configuration from the environment variables
+// prefix_DATABASE_URL, prefix_API_KEY and prefix_DEBUG, or the names
+// without prefix if it is empty. Unset variables leave their field
+// unset for Merge.
+func LoadFromEnv(prefix string) (Configuration, error) {
+	name := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "_" + key
+	}
+
+	var config Configuration
+	if v, ok := os.LookupEnv(name(EnvDatabaseURL)); ok {
+		config.DatabaseURL = v
+		config.set |= setDatabaseURL
+	}
+	if v, ok := os.LookupEnv(name(EnvAPIKey)); ok {
+		config.APIKey = v
+		config.set |= setAPIKey
+	}
+	if v, ok := os.LookupEnv(name(EnvDebug)); ok {
+		debug, err := strconv.ParseBool(v)
+		if err != nil {
+			return Configuration{}, fmt.Errorf("invalid %s %q: want true or false", name(EnvDebug), v)
+		}
+		config.Debug = debug
+		config.set |= setDebug
+	}
+	return config, nil
+}
+
+// LoadFromFile reads the configuration from the JSON file at path, such
+// as {"database_url": "postgres://db:5432/app", "api_key": "...",
+// "debug": false}. Missing keys leave their field unset for Merge;
+// unknown keys are an error.
+func LoadFromFile(path string) (Configuration, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Configuration{}, fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+
+	var file fileConfig
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return Configuration{}, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	var config Configuration
+	if file.DatabaseURL != nil {
+		config.DatabaseURL = *file.DatabaseURL
+		config.set |= setDatabaseURL
+	}
+	if file.APIKey != nil {
+		config.APIKey = *file.APIKey
+		config.set |= setAPIKey
+	}
+	if file.Debug != nil {
+		config.Debug = *file.Debug
+		config.set |= setDebug
+	}
+	return config, nil
+}
+
+// Merge returns config with the fields of overrides applied on top: the
+// fields a loader set, even to a zero value, and the non-zero fields of
+// a configuration built in code. Layer sources from lowest to highest
+// precedence:
+//
+//	config := NewConfiguration().Merge(fromFile).Merge(fromEnv)
+func (config Configuration) Merge(overrides Configuration) Configuration {
+	if overrides.set&setDatabaseURL != 0 || overrides.DatabaseURL != "" {
+		config.DatabaseURL = overrides.DatabaseURL
+		config.set |= setDatabaseURL
+	}
+	if overrides.set&setAPIKey != 0 || overrides.APIKey != "" {
+		config.APIKey = overrides.APIKey
+		config.set |= setAPIKey
+	}
+	if overrides.set&setDebug != 0 || overrides.Debug {
+		config.Debug = overrides.Debug
+		config.set |= setDebug
+	}
+	return config
+}
+
+// Validate reports every problem with the configuration in one error.
+// DatabaseURL must be an absolute URL with a host, such as
+// postgres://db:5432/app, and APIKey must be set. Messages never include
+// the secrets themselves.
+func (config Configuration) Validate() error {
+	var errs []error
+	if config.DatabaseURL == "" {
+		errs = append(errs, errors.New("database URL is required"))
+	} else if u, err := url.Parse(config.DatabaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+		errs = append(errs, fmt.Errorf("database URL %s is invalid: want scheme://host[:port]/name", maskSensitiveData(config.DatabaseURL)))
+	}
+	if config.APIKey == "" {
+		errs = append(errs, errors.New("API key is required"))
+	}
+	return errors.Join(errs...)
+}
+
+// String describes the configuration with its secrets masked, so it is
+// safe to print and log
+func (config Configuration) String() string {
+	return fmt.Sprintf("{DatabaseURL:%s APIKey:%s Debug:%v}",
+		maskSensitiveData(config.DatabaseURL), maskSensitiveData(config.APIKey), config.Debug)
+}
+
+// GoString masks the secrets for %#v too
+func (config Configuration) GoString() string {
+	return "goodstructure.Configuration" + config.String()
+}
@@ -0,0 +1,135 @@
+package goodstructure_test
+
+import (
+	"fmt"
+	goodstructure "go-examples/examples/good_structure"
+	"strings"
+	"testing"
+)
+
+// TestLoadFromEnv tests reading prefixed variables and rejecting an
+// invalid boolean
+func TestLoadFromEnv(t *testing.T) {
+	t.Setenv("APP_DATABASE_URL", "postgres://db.internal:5432/app")
+	t.Setenv("APP_API_KEY", "env-key-0123456789")
+	t.Setenv("APP_DEBUG", "false")
+
+	config, err := goodstructure.LoadFromEnv("APP")
+	if err != nil {
+		t.Fatalf("LoadFromEnv failed: %v", err)
+	}
+	if config.DatabaseURL != "postgres://db.internal:5432/app" || config.APIKey != "env-key-0123456789" || config.Debug {
+		t.Errorf("LoadFromEnv = %v", config)
+	}
+
+	// An explicit false overrides the default Debug of true
+	if merged := goodstructure.NewConfiguration().Merge(config); merged.Debug {
+		t.Error("APP_DEBUG=false did not override the default")
+	}
+
+	t.Setenv("APP_DEBUG", "maybe")
+	if _, err := goodstructure.LoadFromEnv("APP"); err == nil || !strings.Contains(err.Error(), "APP_DEBUG") {
+		t.Errorf("LoadFromEnv error = %v, want invalid APP_DEBUG", err)
+	}
+}
+
+// TestLoadFromFile tests parsing a file and rejecting malformed JSON,
+// unknown keys and missing files
+func TestLoadFromFile(t *testing.T) {
+	config, err := goodstructure.LoadFromFile("testdata/config.json")
+	if err != nil {
+		t.Fatalf("LoadFromFile failed: %v", err)
+	}
+	if config.DatabaseURL != "postgres://db.internal:5432/app" || config.APIKey != "file-key-0123456789" || config.Debug {
+		t.Errorf("LoadFromFile = %v", config)
+	}
+
+	for _, path := range []string{"testdata/malformed.json", "testdata/unknown.json", "testdata/missing.json"} {
+		if _, err := goodstructure.LoadFromFile(path); err == nil {
+			t.Errorf("LoadFromFile(%s) succeeded", path)
+		}
+	}
+}
+
+// TestMerge tests that later sources win and unset fields keep earlier
+// values
+func TestMerge(t *testing.T) {
+	t.Setenv("APP_API_KEY", "env-key-0123456789")
+	fromFile, err := goodstructure.LoadFromFile("testdata/config.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromEnv, err := goodstructure.LoadFromEnv("APP")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := goodstructure.NewConfiguration().Merge(fromFile).Merge(fromEnv)
+	if config.DatabaseURL != "postgres://db.internal:5432/app" || config.APIKey != "env-key-0123456789" || config.Debug {
+		t.Errorf("merged configuration = %v", config)
+	}
+
+	inCode := goodstructure.Configuration{APIKey: "code-key-0123456789"}
+	if got := config.Merge(inCode); got.APIKey != inCode.APIKey || got.DatabaseURL != config.DatabaseURL {
+		t.Errorf("Merge of a configuration built in code = %v", got)
+	}
+}
+
+// TestValidate tests that every problem is reported in one error
+// without leaking secrets
+func TestValidate(t *testing.T) {
+	valid := goodstructure.Configuration{DatabaseURL: "postgres://db.internal:5432/app", APIKey: "key"}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate failed: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		config goodstructure.Configuration
+		want   []string
+	}{
+		{"empty", goodstructure.Configuration{}, []string{"database URL is required", "API key is required"}},
+		{"no scheme", goodstructure.Configuration{DatabaseURL: "db.internal:5432/app", APIKey: "key"}, []string{"database URL"}},
+		{"no host", goodstructure.Configuration{DatabaseURL: "postgres:///app", APIKey: "key"}, []string{"database URL"}},
+		{"both", goodstructure.Configuration{DatabaseURL: "secret-password-in-path"}, []string{"database URL", "API key is required"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config.Validate()
+			if err == nil {
+				t.Fatal("Validate succeeded")
+			}
+			for _, want := range tc.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate error %q does not mention %q", err, want)
+				}
+			}
+			if strings.Contains(err.Error(), "password") {
+				t.Errorf("Validate error %q leaks the database URL", err)
+			}
+		})
+	}
+}
+
+// TestStringMasksSecrets tests the masking of short and long secrets
+// wherever the configuration is printed
+func TestStringMasksSecrets(t *testing.T) {
+	tests := []struct {
+		name   string
+		config goodstructure.Configuration
+		want   string
+	}{
+		{"short", goodstructure.Configuration{DatabaseURL: "db://x", APIKey: "abcde"}, "{DatabaseURL:*** APIKey:*** Debug:false}"},
+		{"long", goodstructure.Configuration{DatabaseURL: "postgres://db.internal:5432/app", APIKey: "sk-0123456789abcdef", Debug: true}, "{DatabaseURL:pos...app APIKey:sk-...def Debug:true}"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, verb := range []string{"%v", "%+v", "%s", "%#v"} {
+				got := fmt.Sprintf(verb, tc.config)
+				if !strings.HasSuffix(got, tc.want) {
+					t.Errorf("%s = %q, want %q", verb, got, tc.want)
+				}
+			}
+		})
+	}
+}
@@ -1,5 +1,17 @@
 // Package goodstructure demonstrates proper Go package organization.
 // Note that the package name matches the last component of the directory path.
+//
+// It is also a small configuration package, layering defaults, a JSON
+// file and the environment:
+//
+//	fromFile, err := goodstructure.LoadFromFile("config.json")
+//	...
+//	fromEnv, err := goodstructure.LoadFromEnv("APP")
+//	...
+//	config := goodstructure.NewConfiguration().Merge(fromFile).Merge(fromEnv)
+//	if err := config.Validate(); err != nil {
+//		...
+//	}
 package goodstructure
 
 import (
@@ -11,6 +23,8 @@ type Configuration struct {
 	DatabaseURL string
 	APIKey      string
 	Debug       bool
+
+	set fieldSet // fields set by a loader or Merge
 }
 
 // defaultConfig is package-private (not exported)
@@ -27,13 +41,15 @@ func NewConfiguration() Configuration {
 func PrintInfo(config Configuration) {
 	fmt.Println("Configuration:")
 	fmt.Printf("  Database: %s\n", maskSensitiveData(config.DatabaseURL))
+	fmt.Printf("  API key: %s\n", maskSensitiveData(config.APIKey))
 	fmt.Printf("  Debug: %v\n", config.Debug)
 }
 
 // maskSensitiveData is package-private (not exported)
 // This encapsulation keeps implementation details hidden
+// Secrets too short to hide most of them are masked entirely
 func maskSensitiveData(data string) string {
-	if len(data) < 5 {
+	if len(data) < 12 {
 		return "***"
 	}
 	return data[:3] + "..." + data[len(data)-3:]
@@ -52,7 +68,8 @@ ADVANTAGES OF THIS STRUCTURE:
    - Usage is intuitive: goodstructure.NewConfiguration()
 
 3. Maintainability:
-   - Adding new functionality is straightforward - just add more files to this directory
+   - Adding new functionality is straightforward - just add more files to this directory,
+     as config.go does for loading and validation
    - All implementation details stay encapsulated in the package
    - Clear separation between public API and private implementation
 
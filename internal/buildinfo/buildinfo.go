@@ -0,0 +1,118 @@
+// Package buildinfo exposes version information about the running binary.
+package buildinfo
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// Build metadata set at build time, for example:
+//
+//	go build -ldflags "-X go-examples/internal/buildinfo.Version=v1.2.3 \
+//	    -X go-examples/internal/buildinfo.Commit=$(git rev-parse HEAD)"
+//
+// Values left unset are filled in by Get from the module and VCS data
+// the Go toolchain embeds in the binary.
+var (
+	Version   = "dev"
+	Commit    = ""
+	Date      = "" // build or commit time, RFC 3339
+	GoVersion = ""
+)
+
+// ReadBuildInfo is a variable that points to debug.ReadBuildInfo to
+// allow tests to control the embedded build information.
+var ReadBuildInfo = debug.ReadBuildInfo
+
+// Info describes the running binary
+type Info struct {
+	Version   string
+	Commit    string
+	Date      string
+	GoVersion string
+}
+
+// Get returns the build metadata. Fields not set with -ldflags come from
+// ReadBuildInfo: the main module version, and the vcs.revision and
+// vcs.time settings. GoVersion falls back to runtime.Version. Fields
+// that remain unknown are empty, except Version, which stays "dev".
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, Date: Date, GoVersion: GoVersion}
+	if bi, ok := ReadBuildInfo(); ok {
+		if info.Version == "dev" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			info.Version = bi.Main.Version
+		}
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = s.Value
+			case s.Key == "vcs.time" && info.Date == "":
+				info.Date = s.Value
+			}
+		}
+		if info.GoVersion == "" {
+			info.GoVersion = bi.GoVersion
+		}
+	}
+	if info.GoVersion == "" {
+		info.GoVersion = runtime.Version()
+	}
+	return info
+}
+
+// Line returns a one-line description of the build of the program name,
+// such as "calcservice v1.2.3 (commit 1a2b3c4, built 2024-05-01T10:00:00Z, go1.24.1)"
+func (i Info) Line(name string) string {
+	details := []string{}
+	if i.Commit != "" {
+		details = append(details, "commit "+shortCommit(i.Commit))
+	}
+	if i.Date != "" {
+		details = append(details, "built "+i.Date)
+	}
+	details = append(details, i.GoVersion)
+	return fmt.Sprintf("%s %s (%s)", name, i.Version, strings.Join(details, ", "))
+}
+
+// Verbose returns a multi-line description of the build of the program
+// name, with one field per line and "unknown" for missing fields
+func (i Info) Verbose(name string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", name)
+	fmt.Fprintf(&b, "  version: %s\n", i.Version)
+	fmt.Fprintf(&b, "  commit:  %s\n", orUnknown(i.Commit))
+	fmt.Fprintf(&b, "  built:   %s\n", orUnknown(i.Date))
+	fmt.Fprintf(&b, "  go:      %s\n", orUnknown(i.GoVersion))
+	return b.String()
+}
+
+// Print writes the build description of the program name to w, in the
+// Verbose form when verbose is set and the Line form otherwise. It backs
+// the -version flag of each binary.
+func Print(w io.Writer, name string, verbose bool) {
+	info := Get()
+	if verbose {
+		fmt.Fprint(w, info.Verbose(name))
+		return
+	}
+	fmt.Fprintln(w, info.Line(name))
+}
+
+// shortCommit abbreviates a commit hash to the length git uses by default
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+// orUnknown returns s, or "unknown" when s is empty
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
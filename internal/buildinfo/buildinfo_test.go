@@ -0,0 +1,101 @@
+package buildinfo_test
+
+import (
+	"bytes"
+	"go-examples/internal/buildinfo"
+	"runtime"
+	"runtime/debug"
+	"testing"
+)
+
+// setVars replaces the ldflags variables and ReadBuildInfo for one test
+func setVars(t *testing.T, version, commit, date, goVersion string, bi *debug.BuildInfo) {
+	t.Helper()
+	origVersion, origCommit, origDate, origGo := buildinfo.Version, buildinfo.Commit, buildinfo.Date, buildinfo.GoVersion
+	origRead := buildinfo.ReadBuildInfo
+	t.Cleanup(func() {
+		buildinfo.Version, buildinfo.Commit, buildinfo.Date, buildinfo.GoVersion = origVersion, origCommit, origDate, origGo
+		buildinfo.ReadBuildInfo = origRead
+	})
+	buildinfo.Version, buildinfo.Commit, buildinfo.Date, buildinfo.GoVersion = version, commit, date, goVersion
+	buildinfo.ReadBuildInfo = func() (*debug.BuildInfo, bool) { return bi, bi != nil }
+}
+
+// TestPrintInjected tests both output forms with values set by ldflags
+func TestPrintInjected(t *testing.T) {
+	setVars(t, "v1.2.3", "1a2b3c4d5e6f", "2024-05-01T10:00:00Z", "go1.24.1", nil)
+
+	var buf bytes.Buffer
+	buildinfo.Print(&buf, "calcservice", false)
+	want := "calcservice v1.2.3 (commit 1a2b3c4, built 2024-05-01T10:00:00Z, go1.24.1)\n"
+	if buf.String() != want {
+		t.Errorf("one-line form = %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	buildinfo.Print(&buf, "calcservice", true)
+	want = "calcservice\n" +
+		"  version: v1.2.3\n" +
+		"  commit:  1a2b3c4d5e6f\n" +
+		"  built:   2024-05-01T10:00:00Z\n" +
+		"  go:      go1.24.1\n"
+	if buf.String() != want {
+		t.Errorf("verbose form = %q, want %q", buf.String(), want)
+	}
+}
+
+// TestGetFallback tests filling unset values from the embedded build info
+func TestGetFallback(t *testing.T) {
+	setVars(t, "dev", "", "", "", &debug.BuildInfo{
+		GoVersion: "go1.24.1",
+		Main:      debug.Module{Version: "v0.9.0"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "abcdef0123456789"},
+			{Key: "vcs.time", Value: "2024-04-30T08:00:00Z"},
+		},
+	})
+
+	want := buildinfo.Info{Version: "v0.9.0", Commit: "abcdef0123456789", Date: "2024-04-30T08:00:00Z", GoVersion: "go1.24.1"}
+	if got := buildinfo.Get(); got != want {
+		t.Errorf("Get() = %+v, want %+v", got, want)
+	}
+}
+
+// TestGetPrefersLdflags tests that ldflags values win over build info
+func TestGetPrefersLdflags(t *testing.T) {
+	setVars(t, "v2.0.0", "fedcba", "", "", &debug.BuildInfo{
+		GoVersion: "go1.24.1",
+		Main:      debug.Module{Version: "v0.9.0"},
+		Settings:  []debug.BuildSetting{{Key: "vcs.revision", Value: "abcdef"}},
+	})
+
+	got := buildinfo.Get()
+	if got.Version != "v2.0.0" || got.Commit != "fedcba" {
+		t.Errorf("Get() = %+v, want the ldflags version and commit", got)
+	}
+}
+
+// TestGetWithoutBuildInfo tests the defaults when nothing is embedded,
+// including the (devel) version of binaries built from a checkout
+func TestGetWithoutBuildInfo(t *testing.T) {
+	for name, bi := range map[string]*debug.BuildInfo{
+		"missing": nil,
+		"devel":   {Main: debug.Module{Version: "(devel)"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			setVars(t, "dev", "", "", "", bi)
+
+			got := buildinfo.Get()
+			want := buildinfo.Info{Version: "dev", GoVersion: runtime.Version()}
+			if got != want {
+				t.Errorf("Get() = %+v, want %+v", got, want)
+			}
+			if line := got.Line("app"); line != "app dev ("+runtime.Version()+")" {
+				t.Errorf("Line() = %q", line)
+			}
+			if verbose := got.Verbose("app"); !bytes.Contains([]byte(verbose), []byte("commit:  unknown")) {
+				t.Errorf("Verbose() = %q, want unknown commit", verbose)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+// Package conformance checks that every protocol surface of the
+// calculator service performs the same operations with the same results
+// and reports errors with the statuses in api.ErrorStatusFor. The cases
+// are generated from calculator.Describe and api.Codes, so a new
+// operation or error code is checked on every surface without touching
+// the tests, and a new error code without a case fails them.
+//
+// Each surface implements Surface; HTTPSurface drives the calcserver
+// handler. A gRPC surface is checked by adding its Surface to the test.
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"go-examples/pkg/api"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/logger"
+)
+
+// Outcome is what a surface answered to a calculation
+type Outcome struct {
+	Result int
+	Code   string // api error code, empty on success
+	Status string // protocol status, such as "400" or "INVALID_ARGUMENT"
+}
+
+// Surface is a protocol through which the service performs calculations
+type Surface interface {
+	// Name identifies the surface in test failures
+	Name() string
+	// Calculate sends req. Errors are for failures to talk to the
+	// service; errors it reports are part of the Outcome.
+	Calculate(ctx context.Context, req api.CalculationRequest) (Outcome, error)
+	// Status returns the status the surface should report for status.Code
+	Status(status api.ErrorStatus) string
+}
+
+// Case is a calculation and the outcome every surface should answer
+type Case struct {
+	Name      string
+	Operation string
+	A, B      int
+	Strict    bool   // whether to ask for strict mode
+	Result    int    // expected result, if Code is empty
+	Code      string // expected api error code
+}
+
+// operands are the inputs every operation is checked on
+var operands = [][2]int{{12, 4}, {-7, 3}, {0, 5}}
+
+// ErrorCases triggers each api error code through every surface. Codes
+// that requests cannot trigger are listed in Untriggerable instead.
+var ErrorCases = map[string]Case{
+	api.CodeInvalidRequest:   {Name: "missing operation", Operation: "", A: 1, B: 2},
+	api.CodeUnknownOperation: {Name: "unknown operation", Operation: "modulo", A: 1, B: 2},
+	api.CodeDivisionByZero:   {Name: "division by zero", Operation: "divide", A: 1, B: 0},
+	api.CodePrecisionLoss:    {Name: "strict division with a remainder", Operation: "divide", A: 7, B: 2, Strict: true},
+	api.CodeOverflow:         {Name: "power overflow", Operation: "pow", A: 2, B: 63},
+	api.CodeNegativeInput:    {Name: "negative exponent", Operation: "pow", A: 2, B: -1},
+}
+
+// Untriggerable lists the api error codes no valid or invalid request
+// produces, with the reason
+var Untriggerable = map[string]string{
+	api.CodeLimitExceeded:   "reported only for expressions on /evaluate",
+	api.CodeTimeout:         "reported only for requests outliving their deadline",
+	api.CodeCancelled:       "reported only for requests their client gave up on",
+	api.CodePayloadTooLarge: "reported only for HTTP bodies above the server's limit",
+	api.CodeInternal:        "reported only for failures of the server itself",
+}
+
+// Cases returns a case for every integer operation and alias of the
+// calculator, except random operations, on each of the operands, with the results of the calculator
+// itself, followed by the error cases sorted by code
+func Cases() []Case {
+	calc := calculator.NewCalculator(nopLogger{})
+	var cases []Case
+	for _, op := range calculator.Describe() {
+		if op.OperandType == calculator.OperandFloat || op.Random {
+			continue
+		}
+		for _, name := range append([]string{op.Name}, op.Aliases...) {
+			for _, ab := range operands {
+				c := Case{Name: fmt.Sprintf("%s %d %d", name, ab[0], ab[1]), Operation: name, A: ab[0], B: ab[1]}
+				result, err := calc.Compute(name, c.A, c.B)
+				if err != nil {
+					c.Code = api.FromCalculatorError(err).Code
+				}
+				c.Result = result
+				cases = append(cases, c)
+			}
+		}
+	}
+	for _, code := range api.Codes() {
+		if c, ok := ErrorCases[code]; ok {
+			c.Code = code
+			cases = append(cases, c)
+		}
+	}
+	return cases
+}
+
+// Request returns the request of c
+func (c Case) Request() api.CalculationRequest {
+	return api.CalculationRequest{Operation: c.Operation, A: c.A, B: c.B, Strict: c.Strict}
+}
+
+// nopLogger discards the calculator's logs while computing expectations
+type nopLogger struct{}
+
+func (nopLogger) Debug(...interface{})                {}
+func (nopLogger) Info(...interface{})                 {}
+func (nopLogger) Warn(...interface{})                 {}
+func (nopLogger) Error(...interface{})                {}
+func (nopLogger) Fatal(...interface{})                {}
+func (nopLogger) Debugf(string, ...interface{})       {}
+func (nopLogger) Infof(string, ...interface{})        {}
+func (nopLogger) Warnf(string, ...interface{})        {}
+func (nopLogger) Errorf(string, ...interface{})       {}
+func (nopLogger) Fatalf(string, ...interface{})       {}
+func (l nopLogger) With(...interface{}) logger.Logger { return l }
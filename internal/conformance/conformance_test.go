@@ -0,0 +1,72 @@
+package conformance_test
+
+import (
+	"context"
+	"go-examples/internal/conformance"
+	"go-examples/pkg/api"
+	"go-examples/pkg/calcserver"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/logger"
+	"slices"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// surfaces returns every protocol surface of a fresh service
+func surfaces(t *testing.T) []conformance.Surface {
+	t.Helper()
+	log, _ := logger.NewObserved(zapcore.ErrorLevel)
+	server := calcserver.New(calculator.NewCalculator(log), log)
+	return []conformance.Surface{
+		conformance.HTTPSurface{Handler: server.Handler()},
+	}
+}
+
+// TestErrorCodesCovered tests that every api error code is triggered by
+// a case or explained as untriggerable, so new codes need a case
+func TestErrorCodesCovered(t *testing.T) {
+	for _, code := range api.Codes() {
+		_, triggered := conformance.ErrorCases[code]
+		_, untriggerable := conformance.Untriggerable[code]
+		if triggered == untriggerable {
+			t.Errorf("error code %s needs exactly one of a case in ErrorCases or an entry in Untriggerable", code)
+		}
+	}
+	for code := range conformance.ErrorCases {
+		if !slices.Contains(api.Codes(), code) {
+			t.Errorf("ErrorCases has a case for unknown code %s", code)
+		}
+	}
+}
+
+// TestSurfaces tests that every surface answers every case with the
+// calculator's result or the expected error code and protocol status,
+// and that the surfaces agree with each other
+func TestSurfaces(t *testing.T) {
+	all := surfaces(t)
+	for _, c := range conformance.Cases() {
+		t.Run(c.Name, func(t *testing.T) {
+			var first *conformance.Outcome
+			for _, s := range all {
+				got, err := s.Calculate(context.Background(), c.Request())
+				if err != nil {
+					t.Fatalf("%s: %v", s.Name(), err)
+				}
+				want := conformance.Outcome{Result: c.Result, Code: c.Code, Status: s.Status(api.ErrorStatus{})}
+				if c.Code != "" {
+					want = conformance.Outcome{Code: c.Code, Status: s.Status(api.ErrorStatusFor(c.Code))}
+				}
+				if got != want {
+					t.Errorf("%s: %s(%d, %d) = %+v, want %+v", s.Name(), c.Operation, c.A, c.B, got, want)
+				}
+				if first != nil && (got.Result != first.Result || got.Code != first.Code) {
+					t.Errorf("%s answered %+v, %s answered %+v", s.Name(), got, all[0].Name(), *first)
+				}
+				if first == nil {
+					first = &got
+				}
+			}
+		})
+	}
+}
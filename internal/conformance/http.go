@@ -0,0 +1,45 @@
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-examples/pkg/api"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+)
+
+// HTTPSurface performs calculations through an HTTP handler, such as
+// that of calcserver.Server, without a network
+type HTTPSurface struct {
+	Handler http.Handler
+}
+
+// Name returns "http"
+func (HTTPSurface) Name() string { return "http" }
+
+// Calculate posts req to /calculate
+func (s HTTPSurface) Calculate(ctx context.Context, req api.CalculationRequest) (Outcome, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return Outcome{}, err
+	}
+	rec := httptest.NewRecorder()
+	s.Handler.ServeHTTP(rec, httptest.NewRequest("POST", "/calculate", bytes.NewReader(body)).WithContext(ctx))
+
+	var resp api.CalculationResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		return Outcome{}, fmt.Errorf("invalid response %q: %w", rec.Body.String(), err)
+	}
+	return Outcome{Result: resp.Result, Code: resp.Code, Status: strconv.Itoa(rec.Code)}, nil
+}
+
+// Status returns the HTTP status of status, or "200" for success
+func (HTTPSurface) Status(status api.ErrorStatus) string {
+	if status.Code == "" {
+		return strconv.Itoa(http.StatusOK)
+	}
+	return strconv.Itoa(status.HTTPStatus)
+}
@@ -0,0 +1,277 @@
+package e2e_test
+
+import (
+	"context"
+	"errors"
+	"go-examples/internal/e2e"
+	"go-examples/pkg/api"
+	"go-examples/pkg/calcclient"
+	"go-examples/pkg/calcserver"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/logger"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// TestOperations tests every operation through the client
+func TestOperations(t *testing.T) {
+	client := e2e.StartServer(t).Client(t)
+
+	tests := []struct {
+		operation string
+		a, b      int
+		want      int
+	}{
+		{"add", 5, 3, 8},
+		{"subtract", 10, 4, 6},
+		{"multiply", 6, 7, 42},
+		{"divide", 20, 5, 4},
+		{"mod", 17, 5, 2},
+		{"pow", 2, 10, 1024},
+	}
+	for _, tc := range tests {
+		t.Run(tc.operation, func(t *testing.T) {
+			got, err := client.Calculate(context.Background(), tc.operation, tc.a, tc.b)
+			if err != nil {
+				t.Fatalf("Calculate failed: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Calculate = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestErrorCodes tests that every API error code reaches the client as
+// the matching *api.APIError
+func TestErrorCodes(t *testing.T) {
+	// Stands in for a handler bug, which is the only way to get INTERNAL
+	internal := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("fail") != "" {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	// Stands in for a request outliving its deadline or its client
+	expire := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := r.Context(), context.CancelFunc(func() {})
+			switch r.URL.Query().Get("ctx") {
+			case "expired":
+				ctx, cancel = context.WithDeadline(ctx, time.Now().Add(-time.Second))
+			case "cancelled":
+				ctx, cancel = context.WithCancel(ctx)
+				cancel()
+			}
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+	log, _ := logger.NewObserved(zapcore.ErrorLevel)
+	limited := calcserver.NewCalculatorPool(log, calcserver.WithCalculatorOptions(calculator.WithMaxOperations(2)))
+	h := e2e.StartServer(t, calcserver.WithMiddleware(internal, expire), calcserver.WithCalculatorPool(limited))
+	client := h.Client(t)
+
+	tests := map[string]func() error{
+		api.CodeDivisionByZero: func() error {
+			_, err := client.Calculate(context.Background(), "divide", 1, 0)
+			return err
+		},
+		api.CodeUnknownOperation: func() error {
+			_, err := client.Calculate(context.Background(), "modulo", 1, 2)
+			return err
+		},
+		api.CodePrecisionLoss: func() error {
+			// The client has no strict option, so post the request
+			return post(t, h.URL+"/calculate", `{"operation":"divide","a":7,"b":2,"strict":true}`)
+		},
+		api.CodeOverflow: func() error {
+			_, err := client.Calculate(context.Background(), "pow", 2, 63)
+			return err
+		},
+		api.CodeNegativeInput: func() error {
+			_, err := client.Calculate(context.Background(), "pow", 2, -1)
+			return err
+		},
+		api.CodeInvalidRequest: func() error {
+			// The client always sends valid JSON, so post a broken body
+			return post(t, h.URL+"/calculate", `{"operation":`)
+		},
+		api.CodeLimitExceeded: func() error {
+			// The client has no evaluate method, so post the request
+			return post(t, h.URL+"/evaluate", `{"expression":"1 + 2 + 3 + 4"}`)
+		},
+		api.CodeTimeout: func() error {
+			return post(t, h.URL+"/evaluate?ctx=expired", `{"expression":"1 + 2"}`)
+		},
+		api.CodeCancelled: func() error {
+			return post(t, h.URL+"/evaluate?ctx=cancelled", `{"expression":"1 + 2"}`)
+		},
+		api.CodePayloadTooLarge: func() error {
+			pad := strings.Repeat(" ", calcserver.DefaultMaxBodyBytes)
+			return post(t, h.URL+"/calculate", `{"operation":"add","a":1,"b":2}`+pad)
+		},
+		api.CodeInternal: func() error {
+			return post(t, h.URL+"/calculate?fail=1", `{}`)
+		},
+	}
+	for _, code := range api.Codes() {
+		t.Run(code, func(t *testing.T) {
+			run, ok := tests[code]
+			if !ok {
+				t.Fatalf("no scenario for error code %s", code)
+			}
+			var apiErr *api.APIError
+			if err := run(); !errors.As(err, &apiErr) {
+				t.Fatalf("expected *api.APIError, got %v", err)
+			}
+			if apiErr.Code != code || apiErr.HTTPStatus != api.StatusFor(code) {
+				t.Errorf("got %s with status %d, want %s with status %d",
+					apiErr.Code, apiErr.HTTPStatus, code, api.StatusFor(code))
+			}
+		})
+	}
+}
+
+// TestLocalizedErrors tests that the client's language selects the
+// language of error messages but not their codes
+func TestLocalizedErrors(t *testing.T) {
+	h := e2e.StartServer(t)
+
+	tests := []struct {
+		lang string
+		want string
+	}{
+		{"", "Division by zero"},
+		{"de-DE", "Division durch null"},
+		{"fr", "Division par zéro"},
+		{"it", "Division by zero"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.lang, func(t *testing.T) {
+			_, err := h.Client(t, calcclient.WithLanguage(tc.lang)).Calculate(context.Background(), "divide", 1, 0)
+			var apiErr *api.APIError
+			if !errors.As(err, &apiErr) || !errors.Is(apiErr, api.ErrDivisionByZero) {
+				t.Fatalf("expected DIVISION_BY_ZERO, got %v", err)
+			}
+			if apiErr.Message != tc.want {
+				t.Errorf("message = %q, want %q", apiErr.Message, tc.want)
+			}
+		})
+	}
+}
+
+// post sends body to url and parses a failed response into an APIError
+func post(t *testing.T, url, body string) error {
+	t.Helper()
+	resp, err := http.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	return api.ParseError(resp.StatusCode, data)
+}
+
+// TestRequestIDPropagation tests that an ID sent by the client is used in
+// the server's logs and returned in error responses
+func TestRequestIDPropagation(t *testing.T) {
+	h := e2e.StartServer(t)
+	client := h.Client(t)
+
+	ctx := calcclient.WithRequestID(context.Background(), "e2e-req-1")
+	_, err := client.Calculate(ctx, "divide", 1, 0)
+	var apiErr *api.APIError
+	if !errors.As(err, &apiErr) || apiErr.RequestID != "e2e-req-1" {
+		t.Fatalf("expected an APIError for request e2e-req-1, got %v", err)
+	}
+	if entries := h.Logs.FilterField("request_id", "e2e-req-1"); len(entries) == 0 {
+		t.Error("expected server log entries carrying the client's request ID")
+	}
+
+	// Without an ID from the client the server assigns one
+	_, err = client.Calculate(context.Background(), "modulo", 1, 2)
+	if !errors.As(err, &apiErr) || apiErr.RequestID == "" {
+		t.Fatalf("expected an APIError with a generated request ID, got %v", err)
+	}
+	if entries := h.Logs.FilterField("request_id", apiErr.RequestID); len(entries) == 0 {
+		t.Errorf("expected server log entries carrying request ID %s", apiErr.RequestID)
+	}
+}
+
+// TestShutdownTransitions tests health and readiness while the server
+// drains an in-flight request
+func TestShutdownTransitions(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	h := e2e.StartServer(t, calcserver.WithMiddleware(holdRoute("/slow", started, release)))
+	client := h.Client(t)
+
+	if err := client.Health(context.Background()); err != nil {
+		t.Fatalf("Health before shutdown failed: %v", err)
+	}
+
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(h.URL + "/slow")
+		if err != nil {
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+	<-started
+
+	done := make(chan error, 1)
+	go func() { done <- h.Server.Shutdown(context.Background()) }()
+	for h.Server.Ready() {
+		runtime.Gosched()
+	}
+	rec := httptest.NewRecorder()
+	h.Server.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/ready", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected /ready to return 503 while draining, got %d", rec.Code)
+	}
+	close(release)
+
+	if err := <-done; err != nil {
+		t.Errorf("expected a clean drain, got %v", err)
+	}
+	if got := <-status; got != http.StatusOK {
+		t.Errorf("expected the in-flight request to complete, got status %d", got)
+	}
+	if err := client.Health(context.Background()); err == nil {
+		t.Error("expected Health to fail after shutdown")
+	}
+}
+
+// holdRoute returns middleware that holds requests to path until release is closed
+func holdRoute(path string, started chan<- struct{}, release <-chan struct{}) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != path {
+				next.ServeHTTP(w, r)
+				return
+			}
+			close(started)
+			<-release
+			w.WriteHeader(http.StatusOK)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+// Package e2e runs the calculator service and its client SDK together
+// over loopback. Its harness is meant to be reused by feature tests that
+// need the full stack.
+package e2e
+
+import (
+	"context"
+	"go-examples/internal/i18n"
+	"go-examples/pkg/calcclient"
+	"go-examples/pkg/calcserver"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/logger"
+	"go-examples/pkg/slogger"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// readyTimeout bounds how long StartServer polls /ready
+const readyTimeout = 5 * time.Second
+
+// Harness is a calcserver listening on a loopback port
+type Harness struct {
+	Server *calcserver.Server
+	URL    string           // base URL, such as http://127.0.0.1:41234
+	Logs   *logger.Observed // entries logged by the server and calculator
+}
+
+// StartServer starts a calcserver on a random loopback port and waits
+// until /ready reports it ready. Requests go through the slogger and
+// i18n middleware, and the server logs through a recorded logger whose
+// per-request entries carry the request_id field. opts are applied after
+// the harness's own, so their middleware runs inside the request ID
+// middleware. The server is shut down when the test ends.
+func StartServer(t testing.TB, opts ...calcserver.Option) *Harness {
+	t.Helper()
+	log, observed := logger.NewObserved(zapcore.DebugLevel)
+	base := []calcserver.Option{
+		calcserver.WithMiddleware(
+			slogger.Middleware(slogger.New(slogger.WithWriter(io.Discard))),
+			i18n.Middleware(i18n.New(log)),
+		),
+		calcserver.WithRequestLogger(func(r *http.Request) logger.Logger {
+			if rl, ok := slogger.ResponseLoggerFrom(r.Context()); ok {
+				return log.With("request_id", rl.RequestID())
+			}
+			return log
+		}),
+	}
+	server := calcserver.New(calculator.NewCalculator(log), log, append(base, opts...)...)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go func() {
+		if err := server.Serve(l); err != nil {
+			t.Errorf("Serve failed: %v", err)
+		}
+	}()
+	t.Cleanup(func() {
+		if server.Ready() {
+			_ = server.Shutdown(context.Background())
+		}
+	})
+
+	h := &Harness{Server: server, URL: "http://" + l.Addr().String(), Logs: observed}
+	h.waitReady(t)
+	return h
+}
+
+// Client returns a calcclient.Client for the harness's server
+func (h *Harness) Client(t testing.TB, opts ...calcclient.Option) *calcclient.Client {
+	t.Helper()
+	return calcclient.New(h.URL, opts...)
+}
+
+// waitReady polls /ready until it returns 200. The listener is open
+// before Serve runs, so each request waits in the accept queue rather
+// than failing.
+func (h *Harness) waitReady(t testing.TB) {
+	t.Helper()
+	client := &http.Client{Timeout: readyTimeout}
+	deadline := time.Now().Add(readyTimeout)
+	for time.Now().Before(deadline) {
+		resp, err := client.Get(h.URL + "/ready")
+		if err != nil {
+			t.Fatalf("readiness check failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			return
+		}
+	}
+	t.Fatalf("server at %s not ready after %s", h.URL, readyTimeout)
+}
@@ -0,0 +1,125 @@
+package i18n_test
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"go-examples/internal/i18n"
+	"go-examples/pkg/api"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/logger"
+	"go-examples/pkg/validate"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// request is a request with every field kind the validation rules check
+type request struct {
+	Operation string
+	A         int
+	B         *int
+}
+
+func (r request) ValidationFields() validate.Fields {
+	return validate.Fields{
+		"operation": func() any { return r.Operation },
+		"a":         func() any { return r.A },
+		"b":         func() any { return r.B },
+	}
+}
+
+// goldenErrors returns an error of every code, and one failing every
+// validation rule
+func goldenErrors() []*api.APIError {
+	one := 1
+	fields := validate.Struct(request{Operation: "pow", A: 5000, B: &one}).
+		Require("operation").
+		OneOf("operation", "add", "subtract").
+		Range("a", -1000, 1000).
+		MutuallyExclusive("a", "b").
+		Errors()
+	return []*api.APIError{
+		api.MalformedRequest(),
+		api.InvalidFields(fields),
+		api.InvalidFields(validate.Struct(request{}).Require("operation").Errors()),
+		api.UnknownOperation("modulo"),
+		api.FromCalculatorError(calculator.ErrDivisionByZero),
+		api.FromCalculatorError(calculator.ErrOverflow),
+		api.FromCalculatorError(calculator.ErrNegativeInput),
+		api.FromCalculatorError(calculator.ErrNotFinite),
+		api.FromCalculatorError(calculator.ErrEmptyInput),
+		api.FromCalculatorError(&calculator.UnknownVariableError{Name: "x"}),
+		api.FromCalculatorError(&calculator.VariableError{Name: "1x", Reason: "starts with a digit"}),
+		api.FromCalculatorError(&calculator.UndoError{Action: "redo"}),
+		api.FromCalculatorError(&calculator.RegistrationError{Name: "add", Reason: "already registered"}),
+		api.FromCalculatorError(&calculator.DomainError{Name: "logarithm base", Value: 1, Domain: "positive and not 1"}),
+		api.FromCalculatorError(&calculator.RangeError{Name: "shift amount", Value: 64, Min: 0, Max: 63}),
+		api.FromCalculatorError(&calculator.InvalidNumberError{Number: "12a", Reason: "not a decimal integer"}),
+		api.FromCalculatorError(&calculator.SyntaxError{Expr: "2 + * 3", Pos: 5, Msg: `expected a number or "(", found "*"`}),
+		api.FromCalculatorError(&calculator.LimitError{Name: "operation count", Value: 1001, Max: 1000}),
+		api.FromCalculatorError(&calculator.OperationError{Operation: "add", A: 1, B: 2, Err: context.DeadlineExceeded}),
+		api.FromCalculatorError(context.Canceled),
+		api.PayloadTooLarge(1 << 20),
+		api.FromCalculatorError(&calculator.PrecisionLossError{Operation: "divide", A: 7, B: 2, Result: 3, Remainder: 1, Float: 3.5}),
+		api.FromCalculatorError(fmt.Errorf("unexpected")),
+	}
+}
+
+// TestGoldenErrors tests the API error messages of every code in each
+// language against testdata/errors.<lang>.golden. Run with -update to
+// rewrite the files after changing a catalog.
+func TestGoldenErrors(t *testing.T) {
+	covered := map[string]bool{}
+	for _, e := range goldenErrors() {
+		covered[e.Code] = true
+	}
+	for _, code := range api.Codes() {
+		if !covered[code] {
+			t.Errorf("no golden error for code %s", code)
+		}
+	}
+
+	log, observed := logger.NewObserved(zapcore.DebugLevel)
+	b := i18n.New(log)
+	for _, lang := range b.Languages() {
+		t.Run(lang, func(t *testing.T) {
+			var out strings.Builder
+			for _, e := range goldenErrors() {
+				l := e.Localize(b.Translator(lang))
+				if l.Code != e.Code {
+					t.Errorf("Localize changed code %s to %s", e.Code, l.Code)
+				}
+				fmt.Fprintf(&out, "%s: %s\n", l.Code, l.Message)
+				for _, f := range l.Fields {
+					fmt.Fprintf(&out, "  %s (%s): %s\n", f.Field, f.Code, f.Message)
+				}
+				if lang == i18n.DefaultLanguage && l.Message != e.Message {
+					t.Errorf("English catalog renders %q, constructor says %q", l.Message, e.Message)
+				}
+			}
+
+			path := filepath.Join("testdata", "errors."+lang+".golden")
+			if *update {
+				if err := os.WriteFile(path, []byte(out.String()), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != string(want) {
+				t.Errorf("messages differ from %s:\n%s\nwant:\n%s", path, out.String(), want)
+			}
+		})
+	}
+	if observed.Len() != 0 {
+		t.Errorf("expected no missing translations, got %+v", observed.All())
+	}
+}
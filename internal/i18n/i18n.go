@@ -0,0 +1,250 @@
+// Package i18n translates user-facing messages. Messages live in JSON
+// catalogs, one per language, mapping message keys to fmt format
+// strings:
+//
+//	{"error.division_by_zero": "Division durch null"}
+//
+// The English catalog is the reference: a key missing from another
+// catalog falls back to English, and the first miss of each key is
+// logged at Warn. Error codes and field names are never translated.
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"go-examples/pkg/logger"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultLanguage is the language of the reference catalog
+const DefaultLanguage = "en"
+
+//go:embed catalogs/*.json
+var catalogFS embed.FS
+
+// Bundle holds the catalogs of every supported language. It is safe for
+// concurrent use.
+type Bundle struct {
+	catalogs map[string]map[string]string
+	log      logger.Logger
+	warned   sync.Map // lang + "/" + key of reported misses
+}
+
+// New returns a Bundle with the catalogs embedded in the binary that
+// reports missing translations to log
+func New(log logger.Logger) *Bundle {
+	sub, err := fs.Sub(catalogFS, "catalogs")
+	if err != nil {
+		panic(err)
+	}
+	b, err := Load(sub, log)
+	if err != nil {
+		panic(fmt.Sprintf("i18n: embedded catalogs: %v", err))
+	}
+	return b
+}
+
+// Load returns a Bundle with the catalogs in the root of fsys, named
+// <lang>.json. The English catalog is required.
+func Load(fsys fs.FS, log logger.Logger) (*Bundle, error) {
+	names, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, err
+	}
+	b := &Bundle{catalogs: map[string]map[string]string{}, log: log}
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return nil, fmt.Errorf("invalid catalog %s: %w", name, err)
+		}
+		b.catalogs[strings.ToLower(strings.TrimSuffix(path.Base(name), ".json"))] = messages
+	}
+	if _, ok := b.catalogs[DefaultLanguage]; !ok {
+		return nil, fmt.Errorf("missing %s catalog", DefaultLanguage)
+	}
+	return b, nil
+}
+
+// Languages returns the supported languages, sorted
+func (b *Bundle) Languages() []string {
+	langs := make([]string, 0, len(b.catalogs))
+	for lang := range b.catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Keys returns the message keys of the English catalog, sorted
+func (b *Bundle) Keys() []string {
+	keys := make([]string, 0, len(b.catalogs[DefaultLanguage]))
+	for key := range b.catalogs[DefaultLanguage] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Match returns the first supported language among tags, such as "de"
+// for "de-CH", or DefaultLanguage when none is supported
+func (b *Bundle) Match(tags ...string) string {
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.ReplaceAll(tag, "_", "-"))
+		if _, ok := b.catalogs[tag]; ok {
+			return tag
+		}
+		if base, _, found := strings.Cut(tag, "-"); found {
+			if _, ok := b.catalogs[base]; ok {
+				return base
+			}
+		}
+	}
+	return DefaultLanguage
+}
+
+// Translator returns the Translator for the supported language matching
+// lang
+func (b *Bundle) Translator(lang string) *Translator {
+	return &Translator{bundle: b, lang: b.Match(lang)}
+}
+
+// Translator renders messages in one language
+type Translator struct {
+	bundle *Bundle
+	lang   string
+}
+
+// Lang returns the language of t
+func (t *Translator) Lang() string {
+	return t.lang
+}
+
+// Translate formats the message for key with args. Keys missing from
+// the language fall back to English; keys missing from English too are
+// returned as is.
+func (t *Translator) Translate(key string, args ...any) string {
+	format, ok := t.bundle.catalogs[t.lang][key]
+	if !ok {
+		t.bundle.warnMissing(t.lang, key)
+		if format, ok = t.bundle.catalogs[DefaultLanguage][key]; !ok {
+			return key
+		}
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// warnMissing logs the first miss of key in lang
+func (b *Bundle) warnMissing(lang, key string) {
+	if _, seen := b.warned.LoadOrStore(lang+"/"+key, true); seen {
+		return
+	}
+	if lang == DefaultLanguage {
+		b.log.Warnf("Missing translation for %q in the %s catalog", key, lang)
+		return
+	}
+	b.log.Warnf("Missing translation for %q in the %s catalog, using %s", key, lang, DefaultLanguage)
+}
+
+// ParseAcceptLanguage returns the language tags of an Accept-Language
+// header ordered by preference. Tags with q=0, a wildcard or an invalid
+// q-value are dropped; tags of equal weight keep their order.
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var entries []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if params = strings.TrimSpace(params); params != "" {
+			value, ok := strings.CutPrefix(params, "q=")
+			if !ok {
+				continue
+			}
+			var err error
+			if q, err = strconv.ParseFloat(strings.TrimSpace(value), 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		if q > 0 {
+			entries = append(entries, weighted{tag, q})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].q > entries[j].q })
+	tags := make([]string, len(entries))
+	for i, e := range entries {
+		tags[i] = e.tag
+	}
+	return tags
+}
+
+// LocaleLanguage converts a POSIX locale such as "de_DE.UTF-8" to a
+// language tag such as "de-DE". It returns "" for the C and POSIX
+// locales.
+func LocaleLanguage(locale string) string {
+	locale, _, _ = strings.Cut(locale, ".")
+	locale, _, _ = strings.Cut(locale, "@")
+	if locale == "C" || locale == "POSIX" {
+		return ""
+	}
+	return strings.ReplaceAll(locale, "_", "-")
+}
+
+// EnvLanguage returns the language tag of the first locale set among
+// LC_ALL, LC_MESSAGES and LANG, looked up with getenv, or ""
+func EnvLanguage(getenv func(string) string) string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if locale := getenv(name); locale != "" {
+			return LocaleLanguage(locale)
+		}
+	}
+	return ""
+}
+
+// translatorKey is the context key for the Translator set by Middleware
+type translatorKey struct{}
+
+// NewContext returns a copy of ctx carrying t
+func NewContext(ctx context.Context, t *Translator) context.Context {
+	return context.WithValue(ctx, translatorKey{}, t)
+}
+
+// FromContext returns the Translator stored in ctx, or nil
+func FromContext(ctx context.Context) *Translator {
+	t, _ := ctx.Value(translatorKey{}).(*Translator)
+	return t
+}
+
+// Middleware returns middleware that picks the Translator for the
+// Accept-Language header of each request, stores it in the request
+// context for FromContext, and reports the choice in Content-Language
+func Middleware(b *Bundle) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t := b.Translator(b.Match(ParseAcceptLanguage(r.Header.Get("Accept-Language"))...))
+			w.Header().Set("Content-Language", t.Lang())
+			w.Header().Add("Vary", "Accept-Language")
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), t)))
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package i18n_test
+
+import (
+	"go-examples/internal/i18n"
+	"go-examples/pkg/logger"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"testing"
+	"testing/fstest"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// TestParseAcceptLanguage tests header parsing with q-values
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{"", []string{}},
+		{"de", []string{"de"}},
+		{"de-CH, fr;q=0.8, en;q=0.9", []string{"de-CH", "en", "fr"}},
+		{"fr;q=0.5,de;q=0.5,en", []string{"en", "fr", "de"}},
+		{"de;q=0, fr", []string{"fr"}},
+		{"*, fr;q=0.1", []string{"fr"}},
+		{"de;q=abc, fr;q=2, en;level=1, it ; q=0.3", []string{"it"}},
+		{" , ,en-GB", []string{"en-GB"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.header, func(t *testing.T) {
+			if got := i18n.ParseAcceptLanguage(tc.header); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("ParseAcceptLanguage(%q) = %q, want %q", tc.header, got, tc.want)
+			}
+		})
+	}
+}
+
+// TestMatch tests the choice of a supported language
+func TestMatch(t *testing.T) {
+	log, _ := logger.NewObserved(zapcore.DebugLevel)
+	b := i18n.New(log)
+	tests := []struct {
+		tags []string
+		want string
+	}{
+		{nil, "en"},
+		{[]string{"de"}, "de"},
+		{[]string{"FR-ca"}, "fr"},
+		{[]string{"de_AT"}, "de"},
+		{[]string{"it", "ja", "fr"}, "fr"},
+		{[]string{"it"}, "en"},
+	}
+	for _, tc := range tests {
+		if got := b.Match(tc.tags...); got != tc.want {
+			t.Errorf("Match(%q) = %q, want %q", tc.tags, got, tc.want)
+		}
+	}
+}
+
+// TestCatalogsComplete tests that every embedded catalog translates every
+// English key with the same format verbs
+func TestCatalogsComplete(t *testing.T) {
+	log, observed := logger.NewObserved(zapcore.DebugLevel)
+	b := i18n.New(log)
+	if got := b.Languages(); !reflect.DeepEqual(got, []string{"de", "en", "fr"}) {
+		t.Errorf("Languages() = %q", got)
+	}
+	verbs := regexp.MustCompile(`%[a-z]`)
+	en := b.Translator("en")
+	for _, lang := range b.Languages() {
+		tr := b.Translator(lang)
+		for _, key := range b.Keys() {
+			want := verbs.FindAllString(en.Translate(key), -1)
+			if got := verbs.FindAllString(tr.Translate(key), -1); !reflect.DeepEqual(got, want) {
+				t.Errorf("%s %s has verbs %q, want %q", lang, key, got, want)
+			}
+		}
+	}
+	if observed.Len() != 0 {
+		t.Errorf("expected no missing translations, got %+v", observed.All())
+	}
+}
+
+// TestFallback tests that missing translations fall back to English and
+// are logged once per key
+func TestFallback(t *testing.T) {
+	fsys := fstest.MapFS{
+		"en.json": {Data: []byte(`{"greeting": "Hello, %s", "farewell": "Goodbye"}`)},
+		"de.json": {Data: []byte(`{"greeting": "Hallo, %s"}`)},
+	}
+	log, observed := logger.NewObserved(zapcore.DebugLevel)
+	b, err := i18n.Load(fsys, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	de := b.Translator("de-DE")
+
+	if got := de.Translate("greeting", "Anna"); got != "Hallo, Anna" {
+		t.Errorf("greeting = %q", got)
+	}
+	for i := 0; i < 3; i++ {
+		if got := de.Translate("farewell"); got != "Goodbye" {
+			t.Errorf("farewell = %q, want the English message", got)
+		}
+		if got := b.Translator("de").Translate("unknown.key"); got != "unknown.key" {
+			t.Errorf("unknown key = %q, want the key", got)
+		}
+	}
+	if got := b.Translator("en").Translate("unknown.key"); got != "unknown.key" {
+		t.Errorf("unknown key = %q, want the key", got)
+	}
+
+	warnings := observed.FilterLevel(zapcore.WarnLevel)
+	if len(warnings) != 3 || observed.Len() != 3 {
+		t.Fatalf("expected one warning per language and key, got %+v", observed.All())
+	}
+	if want := `Missing translation for "farewell" in the de catalog, using en`; warnings[0].Message != want {
+		t.Errorf("warning = %q, want %q", warnings[0].Message, want)
+	}
+}
+
+// TestLoadErrors tests catalogs that cannot be used
+func TestLoadErrors(t *testing.T) {
+	log, _ := logger.NewObserved(zapcore.DebugLevel)
+	tests := map[string]fstest.MapFS{
+		"no English":   {"de.json": {Data: []byte(`{}`)}},
+		"invalid JSON": {"en.json": {Data: []byte(`{"a": 1}`)}},
+	}
+	for name, fsys := range tests {
+		if _, err := i18n.Load(fsys, log); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// TestEnvLanguage tests reading the language from locale variables
+func TestEnvLanguage(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want string
+	}{
+		{map[string]string{}, ""},
+		{map[string]string{"LANG": "de_DE.UTF-8"}, "de-DE"},
+		{map[string]string{"LANG": "de_DE.UTF-8", "LC_MESSAGES": "fr_FR"}, "fr-FR"},
+		{map[string]string{"LANG": "fr_FR@euro", "LC_ALL": "C"}, ""},
+		{map[string]string{"LANG": "POSIX"}, ""},
+	}
+	for _, tc := range tests {
+		getenv := func(name string) string { return tc.env[name] }
+		if got := i18n.EnvLanguage(getenv); got != tc.want {
+			t.Errorf("EnvLanguage(%v) = %q, want %q", tc.env, got, tc.want)
+		}
+	}
+}
+
+// TestMiddleware tests that the request context carries the negotiated
+// Translator
+func TestMiddleware(t *testing.T) {
+	log, _ := logger.NewObserved(zapcore.DebugLevel)
+	var got string
+	handler := i18n.Middleware(i18n.New(log))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = i18n.FromContext(r.Context()).Translate("error.division_by_zero")
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Language", "it, fr;q=0.9, de;q=0.8")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got != "Division par zéro" {
+		t.Errorf("message = %q, want the French one", got)
+	}
+	if lang := rec.Header().Get("Content-Language"); lang != "fr" {
+		t.Errorf("Content-Language = %q, want fr", lang)
+	}
+	if vary := rec.Header().Get("Vary"); vary != "Accept-Language" {
+		t.Errorf("Vary = %q", vary)
+	}
+	if i18n.FromContext(req.Context()) != nil {
+		t.Error("expected no Translator outside the middleware")
+	}
+}
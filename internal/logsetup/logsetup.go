@@ -0,0 +1,157 @@
+// Package logsetup builds the logger of each binary from its flags, so
+// they all select the logging system, level and output the same way.
+package logsetup
+
+import (
+	"fmt"
+	"go-examples/pkg/logger"
+	"go-examples/pkg/metrics"
+	"go-examples/pkg/slogger"
+	"io"
+	"log/slog"
+	"os"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Logging systems registered with logger.Register: zap by pkg/logger,
+// slog by this package
+const (
+	SystemZap  = "zap"
+	SystemSlog = "slog"
+)
+
+func init() {
+	logger.Register(SystemSlog, newSlog)
+}
+
+// Config selects how Setup builds a logger. The zero value logs info and
+// above through zap, as JSON, to stdout.
+type Config struct {
+	// System is the name of a registered implementation, such as
+	// SystemZap or SystemSlog, case-insensitive. Empty means zap.
+	System string
+	// Level is a level name such as "debug", or a per-module spec such as
+	// "info,calculator=debug". Empty means info. slog applies only the
+	// spec's default level.
+	Level string
+	// Output is "stdout", "stderr" or a file path, which is appended to.
+	// Empty means the system's default: stdout for zap and stderr for
+	// slog.
+	Output string
+	// Text selects human-readable output instead of the system's default
+	// encoding: zap's console encoder instead of JSON. slog always
+	// writes text.
+	Text bool
+	// Service, Version and Env, when Service is set, are attached to
+	// every zap entry with logger.WithServiceInfo.
+	Service, Version, Env string
+	// Metrics, when set, counts the entries written by level in
+	// log_entries_total, for both systems
+	Metrics metrics.Provider
+}
+
+// Setup builds the logger described by cfg through logger.New. The
+// returned cleanup func syncs the logger and closes the output file, if
+// any; call it before the program exits. Fatal goes through slogger.Exit
+// for every system, so callbacks registered with slogger.RegisterOnFatal
+// always run.
+func Setup(cfg Config) (logger.Logger, func(), error) {
+	spec, err := logger.ParseLevelSpec(orDefault(cfg.Level, "info"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid log level: %w", err)
+	}
+
+	var out io.Writer
+	closeOut := func() error { return nil }
+	if cfg.Output != "" {
+		if out, closeOut, err = openOutput(cfg.Output); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	log, err := logger.New(orDefault(cfg.System, SystemZap), logger.Config{
+		Level:    spec,
+		Output:   out,
+		Text:     cfg.Text,
+		Service:  cfg.Service,
+		Version:  cfg.Version,
+		Env:      cfg.Env,
+		Metrics:  cfg.Metrics,
+		ExitFunc: slogger.Exit,
+	})
+	if err != nil {
+		_ = closeOut()
+		return nil, nil, err
+	}
+
+	cleanup := func() {
+		// Syncing a terminal fails on some platforms; there is nothing to do about it
+		_ = logger.Sync(log)
+		_ = closeOut()
+	}
+	return log, cleanup, nil
+}
+
+// newSlog builds a slog logger writing text, to stderr unless cfg.Output
+// is set. Fatal exits through slogger.Exit, whatever cfg.ExitFunc is.
+func newSlog(cfg logger.Config) (logger.Logger, error) {
+	out := cfg.Output
+	if out == nil {
+		out = os.Stderr
+	}
+	level := slogLevel(cfg.Level.Default)
+	opts := []slogger.Option{slogger.WithLevel(level), slogger.WithWriter(out)}
+	var counted *slog.LevelVar
+	if cfg.Metrics != nil {
+		counted = new(slog.LevelVar)
+		counted.Set(level)
+		opts = append(opts, slogger.WithAdditionalHandler(newCountingHandler(cfg.Metrics, counted)))
+	}
+	l := slogger.New(opts...)
+	return &slogLogger{log: l, unnamed: l, counted: counted}, nil
+}
+
+// openOutput resolves an Output value to a writer and the func that
+// closes it
+func openOutput(output string) (io.Writer, func() error, error) {
+	switch output {
+	case "stdout":
+		return os.Stdout, os.Stdout.Sync, nil
+	case "stderr":
+		return os.Stderr, os.Stderr.Sync, nil
+	}
+	f, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid log output: %w", err)
+	}
+	return f, func() error {
+		if err := f.Sync(); err != nil {
+			_ = f.Close()
+			return err
+		}
+		return f.Close()
+	}, nil
+}
+
+// slogLevel converts a zap level into the equivalent slog level
+func slogLevel(level zapcore.Level) slog.Level {
+	switch {
+	case level <= zapcore.DebugLevel:
+		return slog.LevelDebug
+	case level == zapcore.InfoLevel:
+		return slog.LevelInfo
+	case level == zapcore.WarnLevel:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+// orDefault returns s, or def when s is empty
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
@@ -0,0 +1,347 @@
+package logsetup_test
+
+import (
+	"fmt"
+	"go-examples/internal/logsetup"
+	"go-examples/pkg/logger"
+	"go-examples/pkg/metrics/prommetrics"
+	"go-examples/pkg/slogger"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap/zapcore"
+)
+
+// redirect points *f at a temporary file for the rest of the test and
+// returns a func reading what was written to it
+func redirect(t *testing.T, f **os.File) func() string {
+	t.Helper()
+	tmp, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := *f
+	*f = tmp
+	t.Cleanup(func() {
+		*f = orig
+		tmp.Close()
+	})
+	return func() string {
+		data, err := os.ReadFile(tmp.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+}
+
+// TestSetupCombinations tests every system with every kind of level and
+// output, checking which messages reach the output
+func TestSetupCombinations(t *testing.T) {
+	levels := []struct {
+		level string
+		want  map[string]bool // message -> logged, per system
+		slog  map[string]bool
+	}{
+		{
+			level: "debug",
+			want:  map[string]bool{"root debug": true, "root info": true, "root error": true, "calc debug": true},
+		},
+		{
+			level: "warn",
+			want:  map[string]bool{"root debug": false, "root info": false, "root error": true, "calc debug": false},
+		},
+		{
+			// slog applies only the spec's default level
+			level: "warn,calculator=debug",
+			want:  map[string]bool{"root debug": false, "root info": false, "root error": true, "calc debug": true},
+			slog:  map[string]bool{"root debug": false, "root info": false, "root error": true, "calc debug": false},
+		},
+	}
+
+	for _, system := range []string{logsetup.SystemZap, logsetup.SystemSlog} {
+		for _, output := range []string{"stdout", "stderr", "file", ""} {
+			for _, lv := range levels {
+				name := system + "/" + output + "/" + lv.level
+				t.Run(name, func(t *testing.T) {
+					cfg := logsetup.Config{System: system, Level: lv.level}
+					var read func() string
+					switch output {
+					case "stdout":
+						cfg.Output = output
+						read = redirect(t, &os.Stdout)
+					case "stderr":
+						cfg.Output = output
+						read = redirect(t, &os.Stderr)
+					case "file":
+						cfg.Output = filepath.Join(t.TempDir(), "app.log")
+						read = func() string {
+							data, err := os.ReadFile(cfg.Output)
+							if err != nil {
+								t.Fatal(err)
+							}
+							return string(data)
+						}
+					case "":
+						// zap defaults to stdout and slog to stderr
+						if system == logsetup.SystemZap {
+							read = redirect(t, &os.Stdout)
+						} else {
+							read = redirect(t, &os.Stderr)
+						}
+					}
+
+					log, cleanup, err := logsetup.Setup(cfg)
+					if err != nil {
+						t.Fatalf("Setup failed: %v", err)
+					}
+					log.Debug("root debug")
+					log.Info("root info")
+					log.Error("root error")
+					logger.Named(log, "calculator").Debug("calc debug")
+					cleanup()
+
+					got := read()
+					want := lv.want
+					if system == logsetup.SystemSlog && lv.slog != nil {
+						want = lv.slog
+					}
+					for msg, logged := range want {
+						if strings.Contains(got, msg) != logged {
+							t.Errorf("message %q logged = %v, want %v; output:\n%s", msg, !logged, logged, got)
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+// TestSetupFileAppends tests that a file output keeps earlier content
+func TestSetupFileAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	for _, msg := range []string{"first run", "second run"} {
+		log, cleanup, err := logsetup.Setup(logsetup.Config{Output: path})
+		if err != nil {
+			t.Fatal(err)
+		}
+		log.Info(msg)
+		cleanup()
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "first run") || !strings.Contains(string(data), "second run") {
+		t.Errorf("expected both runs in the file, got:\n%s", data)
+	}
+}
+
+// TestSetupInvalid tests the errors for each invalid setting
+func TestSetupInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     logsetup.Config
+		wantErr string
+	}{
+		{"unknown system", logsetup.Config{System: "log4j"}, "unknown log system: log4j"},
+		{"unknown level", logsetup.Config{Level: "loud"}, "invalid log level"},
+		{"bad module level", logsetup.Config{System: "slog", Level: "info,calculator=loud"}, "invalid log level"},
+		{"unwritable output", logsetup.Config{Output: filepath.Join(t.TempDir(), "missing", "app.log")}, "invalid log output"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			log, cleanup, err := logsetup.Setup(tc.cfg)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+			if log != nil || cleanup != nil {
+				t.Error("expected no logger or cleanup on error")
+			}
+		})
+	}
+}
+
+// TestSlog tests access to the slogger behind a slog logger
+func TestSlog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	log, cleanup, err := logsetup.Setup(logsetup.Config{System: "SLOG", Output: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	if _, ok := logsetup.Slog(log); !ok {
+		t.Error("expected Slog to unwrap a slog logger")
+	}
+
+	zap, zapCleanup, err := logsetup.Setup(logsetup.Config{Output: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zapCleanup()
+	if _, ok := logsetup.Slog(zap); ok {
+		t.Error("expected Slog to reject a zap logger")
+	}
+}
+
+// TestWrapSlog tests message and field handling of the slog adapter
+func TestWrapSlog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	log := logsetup.WrapSlog(slogger.New(slogger.WithWriter(f)))
+
+	named := logger.Named(logger.Named(log, "server"), "http").With("request_id", "req-1")
+	named.Info("handled", "status", 200)
+	log.Warnf("retry %d", 3)
+	log.Info(42)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got:\n%s", data)
+	}
+	for i, want := range [][]string{
+		{"msg=handled", "logger=server.http", "request_id=req-1", "status=200"},
+		{"level=WARN", `msg="retry 3"`},
+		{"msg=info", "!BADKEY=42"},
+	} {
+		for _, w := range want {
+			if !strings.Contains(lines[i], w) {
+				t.Errorf("line %d: expected %q in %s", i, w, lines[i])
+			}
+		}
+	}
+	if strings.Count(lines[0], "logger=") != 1 {
+		t.Errorf("expected one logger attribute, got %s", lines[0])
+	}
+}
+
+// TestSetupMetrics tests that both systems count the entries they write
+// by level
+func TestSetupMetrics(t *testing.T) {
+	for _, system := range []string{logsetup.SystemZap, logsetup.SystemSlog} {
+		t.Run(system, func(t *testing.T) {
+			reg := prometheus.NewRegistry()
+			log, cleanup, err := logsetup.Setup(logsetup.Config{
+				System:  system,
+				Level:   "info",
+				Output:  filepath.Join(t.TempDir(), "log"),
+				Metrics: prommetrics.New(reg),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer cleanup()
+
+			log.Debug("filtered")
+			log.Info("one")
+			log.Infof("two")
+			log.Error("three")
+
+			want := `
+# HELP log_entries_total Log entries written, by level.
+# TYPE log_entries_total counter
+log_entries_total{level="error"} 1
+log_entries_total{level="info"} 2
+`
+			if err := testutil.GatherAndCompare(reg, strings.NewReader(want)); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// TestSetLevelSpec tests changing the level of both systems at runtime,
+// including the entries counted in metrics
+func TestSetLevelSpec(t *testing.T) {
+	for _, system := range []string{logsetup.SystemZap, logsetup.SystemSlog} {
+		t.Run(system, func(t *testing.T) {
+			reg := prometheus.NewRegistry()
+			path := filepath.Join(t.TempDir(), "log")
+			log, cleanup, err := logsetup.Setup(logsetup.Config{System: system, Level: "info", Output: path, Metrics: prommetrics.New(reg)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer cleanup()
+			named := logger.Named(log, "server")
+
+			named.Debug("before")
+			if logger.LevelEnabled(named, zapcore.DebugLevel) {
+				t.Error("debug level enabled at info level")
+			}
+			spec, _ := logger.ParseLevelSpec("debug")
+			if err := logger.SetLevelSpec(log, spec); err != nil {
+				t.Fatal(err)
+			}
+			named.Debug("after")
+			if !logger.LevelEnabled(named, zapcore.DebugLevel) {
+				t.Error("debug level disabled after enabling it")
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(string(data), "before") || !strings.Contains(string(data), "after") {
+				t.Errorf("level was not changed, got:\n%s", data)
+			}
+			want := `
+# HELP log_entries_total Log entries written, by level.
+# TYPE log_entries_total counter
+log_entries_total{level="debug"} 1
+`
+			if err := testutil.GatherAndCompare(reg, strings.NewReader(want)); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// inhouse is a third logging system, registered the way an in-house
+// implementation would be, that writes plain lines
+type inhouse struct {
+	logger.Logger
+	out io.Writer
+}
+
+func init() {
+	logger.Register("inhouse", func(cfg logger.Config) (logger.Logger, error) {
+		return inhouse{Logger: logger.NewCustom(cfg.Level.Default, false), out: cfg.Output}, nil
+	})
+}
+
+func (l inhouse) Info(args ...interface{}) {
+	fmt.Fprintln(l.out, append([]interface{}{"inhouse:"}, args...)...)
+}
+
+// TestSetupRegisteredSystem tests that Setup builds any registered
+// system and lists them all for an unknown one
+func TestSetupRegisteredSystem(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	log, cleanup, err := logsetup.Setup(logsetup.Config{System: "InHouse", Output: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.Info("hello")
+	cleanup()
+	if data, _ := os.ReadFile(path); string(data) != "inhouse: hello\n" {
+		t.Errorf("got %q from the registered system", data)
+	}
+
+	_, _, err = logsetup.Setup(logsetup.Config{System: "log4j"})
+	if err == nil || !strings.Contains(err.Error(), "inhouse, slog, zap") {
+		t.Errorf("expected the registered systems in %v", err)
+	}
+}
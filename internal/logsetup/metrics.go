@@ -0,0 +1,35 @@
+package logsetup
+
+import (
+	"context"
+	"go-examples/pkg/metrics"
+	"log/slog"
+	"strings"
+)
+
+// countingHandler counts the records at or above level in
+// log_entries_total, using the lowercase level names zap uses. It writes
+// nothing and is added next to the handler that does.
+type countingHandler struct {
+	entries metrics.Counter
+	level   slog.Leveler
+}
+
+func newCountingHandler(p metrics.Provider, level slog.Leveler) slog.Handler {
+	return countingHandler{
+		entries: p.Counter("log_entries_total", "Log entries written, by level.", "level"),
+		level:   level,
+	}
+}
+
+func (h countingHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h countingHandler) Handle(_ context.Context, r slog.Record) error {
+	h.entries.Add(1, strings.ToLower(r.Level.String()))
+	return nil
+}
+
+func (h countingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h countingHandler) WithGroup(string) slog.Handler      { return h }
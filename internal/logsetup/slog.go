@@ -0,0 +1,121 @@
+package logsetup
+
+import (
+	"context"
+	"fmt"
+	"go-examples/pkg/logger"
+	"go-examples/pkg/slogger"
+	"log/slog"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// slogLogger implements logger.Logger on top of a slogger.Logger. The
+// first argument of the unformatted methods is the message when it is a
+// string, and the rest are key-value pairs.
+type slogLogger struct {
+	log     slogger.Logger
+	unnamed slogger.Logger // log without the "logger" attribute
+	name    string         // dotted name set by Named
+	counted *slog.LevelVar // level of the handler counting entries, if any
+}
+
+// WrapSlog returns a logger.Logger that logs through l
+func WrapSlog(l slogger.Logger) logger.Logger {
+	return &slogLogger{log: l, unnamed: l}
+}
+
+// Slog returns the slogger.Logger behind a logger created by Setup with
+// SystemSlog or by WrapSlog, for APIs that need it such as
+// slogger.Middleware
+func Slog(l logger.Logger) (slogger.Logger, bool) {
+	s, ok := l.(*slogLogger)
+	if !ok {
+		return slogger.Logger{}, false
+	}
+	return s.log, true
+}
+
+// split separates the message from the key-value pairs, using fallback
+// as the message when the first argument is not a string
+func split(fallback string, args []interface{}) (string, []interface{}) {
+	if len(args) == 0 {
+		return fallback, nil
+	}
+	if msg, ok := args[0].(string); ok {
+		return msg, args[1:]
+	}
+	return fallback, args
+}
+
+func (s *slogLogger) Debug(args ...interface{}) {
+	msg, kv := split("debug", args)
+	s.log.Debug(msg, kv...)
+}
+
+func (s *slogLogger) Info(args ...interface{}) {
+	msg, kv := split("info", args)
+	s.log.Info(msg, kv...)
+}
+
+func (s *slogLogger) Warn(args ...interface{}) {
+	msg, kv := split("warn", args)
+	s.log.Warn(msg, kv...)
+}
+
+func (s *slogLogger) Error(args ...interface{}) {
+	msg, kv := split("error", args)
+	s.log.Error(msg, kv...)
+}
+
+func (s *slogLogger) Fatal(args ...interface{}) {
+	msg, kv := split("fatal", args)
+	s.log.Fatal(msg, kv...)
+}
+
+func (s *slogLogger) Debugf(template string, args ...interface{}) { s.log.Debugf(template, args...) }
+func (s *slogLogger) Infof(template string, args ...interface{})  { s.log.Infof(template, args...) }
+func (s *slogLogger) Warnf(template string, args ...interface{})  { s.log.Warnf(template, args...) }
+func (s *slogLogger) Errorf(template string, args ...interface{}) { s.log.Errorf(template, args...) }
+
+func (s *slogLogger) Fatalf(template string, args ...interface{}) {
+	s.log.Fatal(fmt.Sprintf(template, args...))
+}
+
+func (s *slogLogger) With(args ...interface{}) logger.Logger {
+	return newNamed(s.unnamed.With(args...), s.name, s.counted)
+}
+
+// Named appends name to the logger name and logs it under the "logger"
+// key, as zap does. Per-module levels do not apply to slog.
+func (s *slogLogger) Named(name string) logger.Logger {
+	if s.name != "" {
+		name = s.name + "." + name
+	}
+	return newNamed(s.unnamed, name, s.counted)
+}
+
+// LevelEnabled reports whether the handler writes entries at level
+func (s *slogLogger) LevelEnabled(level zapcore.Level) bool {
+	return s.log.Handler().Enabled(context.Background(), slogLevel(level))
+}
+
+// SetLevelSpec sets the minimum level of the logger, and of the loggers
+// derived from it, to the spec's default; per-module levels do not apply
+// to slog
+func (s *slogLogger) SetLevelSpec(ls logger.LevelSpec) error {
+	level := slogLevel(ls.Default)
+	s.unnamed.SetLevel(level)
+	if s.counted != nil {
+		s.counted.Set(level)
+	}
+	return nil
+}
+
+// newNamed returns a slogLogger for unnamed that logs name, if any
+func newNamed(unnamed slogger.Logger, name string, counted *slog.LevelVar) *slogLogger {
+	if name == "" {
+		return &slogLogger{log: unnamed, unnamed: unnamed, counted: counted}
+	}
+	return &slogLogger{log: unnamed.With("logger", name), unnamed: unnamed, name: name, counted: counted}
+}
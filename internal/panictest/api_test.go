@@ -0,0 +1,93 @@
+package panictest_test
+
+import (
+	"encoding/json"
+	"errors"
+	"go-examples/internal/i18n"
+	"go-examples/internal/panictest"
+	"go-examples/pkg/api"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/logger"
+	"go-examples/pkg/validate"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// TestAPI calls every exported function of pkg/api with adversarial
+// arguments
+func TestAPI(t *testing.T) {
+	log, _ := logger.NewObserved(zapcore.DebugLevel)
+	zero := &api.APIError{}
+	fields := api.InvalidFields([]validate.FieldError{{Field: "a", Code: validate.CodeOutOfRange}})
+	var req api.CalculationRequest
+	var floatReq api.FloatCalculationRequest
+	var bigReq api.BigCalculationRequest
+	var decimalReq api.DecimalCalculationRequest
+
+	funcs := panictest.Funcs{
+		"New":                 api.New,
+		"InvalidRequest":      api.InvalidRequest,
+		"MalformedRequest":    api.MalformedRequest,
+		"InvalidFields":       api.InvalidFields,
+		"FromDecodeError":     api.FromDecodeError,
+		"UnknownOperation":    api.UnknownOperation,
+		"DivisionByZero":      api.DivisionByZero,
+		"PrecisionLoss":       api.PrecisionLoss,
+		"Overflow":            api.Overflow,
+		"NegativeInput":       api.NegativeInput,
+		"NotFinite":           api.NotFinite,
+		"InvalidNumber":       api.InvalidNumber,
+		"InvalidExpression":   api.InvalidExpression,
+		"EmptyInput":          api.EmptyInput,
+		"OutOfRange":          api.OutOfRange,
+		"UnknownVariable":     api.UnknownVariable,
+		"InvalidVariable":     api.InvalidVariable,
+		"NoUndo":              api.NoUndo,
+		"InvalidRegistration": api.InvalidRegistration,
+		"OutOfDomain":         api.OutOfDomain,
+		"LimitExceeded":       api.LimitExceeded,
+		"Timeout":             api.Timeout,
+		"Cancelled":           api.Cancelled,
+		"PayloadTooLarge":     api.PayloadTooLarge,
+		"Internal":            api.Internal,
+		"Codes":               api.Codes,
+		"StatusFor":           api.StatusFor,
+		"ErrorStatusFor":      api.ErrorStatusFor,
+		"FromCalculatorError": api.FromCalculatorError,
+		"ParseError":          api.ParseError,
+		"MediaType":           api.MediaType,
+		"ParseVersion":        api.ParseVersion,
+
+		"APIError.Localize":      fields.Localize,
+		"APIError.Error":         zero.Error,
+		"APIError.Is":            zero.Is,
+		"APIError.MarshalJSON":   fields.MarshalJSON,
+		"APIError.UnmarshalJSON": zero.UnmarshalJSON,
+
+		"CalculationRequest.ValidationFields":        req.ValidationFields,
+		"FloatCalculationRequest.ValidationFields":   floatReq.ValidationFields,
+		"BigCalculationRequest.ValidationFields":     bigReq.ValidationFields,
+		"DecimalCalculationRequest.ValidationFields": decimalReq.ValidationFields,
+		"EvaluationRequest.ValidationFields":         api.EvaluationRequest{}.ValidationFields,
+		"BatchCalculation.ValidationFields":          api.BatchCalculation{}.ValidationFields,
+	}
+	panictest.Complete(t, "../../pkg/api", funcs)
+	panictest.Check(t, funcs,
+		i18n.New(log).Translator("de"),
+		api.DivisionByZero(),
+		validate.FieldError{Field: "a", Code: validate.CodeOneOf},
+		errors.New("boom"),
+		&json.UnmarshalTypeError{Value: "number 1e99", Field: "a"},
+		decodeError(`{"a":9999999999999999999999}`),
+		decodeError(`{"operation":1}`),
+		&calculator.UnknownOperationError{},
+		calculator.ErrDivisionByZero,
+	)
+}
+
+// decodeError returns the error decoding body as a CalculationRequest
+func decodeError(body string) error {
+	var req api.CalculationRequest
+	return json.Unmarshal([]byte(body), &req)
+}
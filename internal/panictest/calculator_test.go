@@ -0,0 +1,342 @@
+package panictest_test
+
+import (
+	"context"
+	"encoding/json"
+	"go-examples/internal/panictest"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/logger"
+	"math/rand/v2"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// TestCalculator calls every exported function of pkg/calculator with
+// adversarial arguments
+func TestCalculator(t *testing.T) {
+	log, _ := logger.NewObserved(zapcore.DebugLevel)
+	calc := calculator.NewCalculator(nil, calculator.WithAudit(log))
+	t.Cleanup(func() { calc.Close() })
+	store := calculator.NewFileStore(filepath.Join(t.TempDir(), "state.json"))
+	memory := calculator.NewMemoryStore()
+	unknown := &calculator.UnknownOperationError{}
+	loss := &calculator.PrecisionLossError{}
+	opErr := &calculator.OperationError{}
+	ints := calculator.NewGeneric[int64](log)
+	floats := calculator.NewGeneric[float64](log)
+	big := calculator.NewBigCalculator(log)
+	acc := calculator.NewAccumulator(log, 0)
+	counts := &calculator.CountingObserver{}
+	trace := calculator.NewTrace(2)
+	invalid := &calculator.InvalidNumberError{}
+	syntax := &calculator.SyntaxError{}
+	rangeErr := &calculator.RangeError{}
+	unknownVar := &calculator.UnknownVariableError{}
+	varErr := &calculator.VariableError{}
+	undoErr := &calculator.UndoError{}
+	registrationErr := &calculator.RegistrationError{}
+	domainErr := &calculator.DomainError{}
+	limitErr := &calculator.LimitError{}
+	registry := calculator.NewRegistry()
+	chain := calc.Start(1)
+	var fraction calculator.Fraction
+	var decimal calculator.Decimal
+	var outcome calculator.ReplayOutcome
+	var report calculator.ReplayReport
+
+	funcs := panictest.Funcs{
+		"Add":               calculator.Add,
+		"Subtract":          calculator.Subtract,
+		"Multiply":          calculator.Multiply,
+		"AddChecked":        calculator.AddChecked,
+		"SubtractChecked":   calculator.SubtractChecked,
+		"MultiplyChecked":   calculator.MultiplyChecked,
+		"Divide":            calculator.Divide,
+		"PercentChange":     calculator.PercentChange,
+		"Ratio":             calculator.Ratio,
+		"Mod":               calculator.Mod,
+		"Pow":               calculator.Pow,
+		"Abs":               calculator.Abs,
+		"Negate":            calculator.Negate,
+		"And":               calculator.And,
+		"Or":                calculator.Or,
+		"Xor":               calculator.Xor,
+		"Not":               calculator.Not,
+		"ShiftLeft":         calculator.ShiftLeft,
+		"ShiftRight":        calculator.ShiftRight,
+		"ToBase":            calculator.ToBase,
+		"FromBase":          calculator.FromBase,
+		"IsPrime":           calculator.IsPrime,
+		"Factorize":         calculator.Factorize,
+		"Fibonacci":         calculator.Fibonacci,
+		"FibonacciSlice":    calculator.FibonacciSlice,
+		"NewFraction":       calculator.NewFraction,
+		"ParseFraction":     calculator.ParseFraction,
+		"NewDecimal":        calculator.NewDecimal,
+		"ParseDecimal":      calculator.ParseDecimal,
+		"SumSlice":          calculator.SumSlice,
+		"Mean":              calculator.Mean,
+		"Min":               calculator.Min,
+		"Max":               calculator.Max,
+		"Average":           calculator.Average,
+		"AverageFloat":      calculator.AverageFloat,
+		"Median":            calculator.Median,
+		"Variance":          calculator.Variance,
+		"StdDev":            calculator.StdDev,
+		"NewCalculator":     newCalculator(t),
+		"WithClock":         calculator.WithClock,
+		"WithAudit":         calculator.WithAudit,
+		"WithStrict":        calculator.WithStrict,
+		"WithAutoSave":      calculator.WithAutoSave,
+		"WithMigration":     calculator.WithMigration,
+		"WithHistory":       calculator.WithHistory,
+		"WithMaxVars":       calculator.WithMaxVars,
+		"WithMaxOperations": calculator.WithMaxOperations,
+		"WithMaxMagnitude":  calculator.WithMaxMagnitude,
+		"WithUndo":          calculator.WithUndo,
+		"WithAngleUnit":     calculator.WithAngleUnit,
+		"WithRandSource":    calculator.WithRandSource,
+		"RandomInt":         calculator.RandomInt,
+		"RoundTo":           calculator.RoundTo,
+		"WithRegistry":      calculator.WithRegistry,
+		"NewRegistry":       calculator.NewRegistry,
+		"NewTrace":          calculator.NewTrace,
+		"RegisterOperation": calculator.RegisterOperation,
+		"Operations":        calculator.Operations,
+		"NewFileStore":      calculator.NewFileStore,
+		"NewMemoryStore":    calculator.NewMemoryStore,
+		"Describe":          calculator.Describe,
+		"LookupOperation":   calculator.LookupOperation,
+		"Errors":            calculator.Errors,
+		"Replay":            calculator.Replay,
+		"NewGeneric":        calculator.NewGeneric[int8],
+		"NewBigCalculator":  calculator.NewBigCalculator,
+		"NewAccumulator":    calculator.NewAccumulator,
+
+		"Calculator.Add":              calc.Add,
+		"Calculator.Subtract":         calc.Subtract,
+		"Calculator.Multiply":         calc.Multiply,
+		"Calculator.AddChecked":       calc.AddChecked,
+		"Calculator.SubtractChecked":  calc.SubtractChecked,
+		"Calculator.MultiplyChecked":  calc.MultiplyChecked,
+		"Calculator.Divide":           calc.Divide,
+		"Calculator.Mod":              calc.Mod,
+		"Calculator.Pow":              calc.Pow,
+		"Calculator.PowCtx":           calc.PowCtx,
+		"Calculator.Abs":              calc.Abs,
+		"Calculator.Negate":           calc.Negate,
+		"Calculator.And":              calc.And,
+		"Calculator.Or":               calc.Or,
+		"Calculator.Xor":              calc.Xor,
+		"Calculator.Not":              calc.Not,
+		"Calculator.ShiftLeft":        calc.ShiftLeft,
+		"Calculator.ShiftRight":       calc.ShiftRight,
+		"Calculator.ToBase":           calc.ToBase,
+		"Calculator.FromBase":         calc.FromBase,
+		"Calculator.IsPrime":          calc.IsPrime,
+		"Calculator.Factorize":        calc.Factorize,
+		"Calculator.FactorizeCtx":     calc.FactorizeCtx,
+		"Calculator.Fibonacci":        calc.Fibonacci,
+		"Calculator.FibonacciSlice":   calc.FibonacciSlice,
+		"Calculator.SumSlice":         calc.SumSlice,
+		"Calculator.Mean":             calc.Mean,
+		"Calculator.Min":              calc.Min,
+		"Calculator.Max":              calc.Max,
+		"Calculator.Median":           calc.Median,
+		"Calculator.Variance":         calc.Variance,
+		"Calculator.StdDev":           calc.StdDev,
+		"Calculator.MemAdd":           calc.MemAdd,
+		"Calculator.MemSubtract":      calc.MemSubtract,
+		"Calculator.MemRecall":        calc.MemRecall,
+		"Calculator.MemClear":         calc.MemClear,
+		"Calculator.Compute":          calc.Compute,
+		"Calculator.ComputeStrict":    calc.ComputeStrict,
+		"Calculator.ComputeCtx":       calc.ComputeCtx,
+		"Calculator.ComputeStrictCtx": calc.ComputeStrictCtx,
+		"Calculator.PercentChange":    calc.PercentChange,
+		"Calculator.Ratio":            calc.Ratio,
+		"Calculator.Average":          calc.Average,
+		"Calculator.AverageFloat":     calc.AverageFloat,
+		"Calculator.ComputeFloat":     calc.ComputeFloat,
+		"Calculator.ComputeBig":       calc.ComputeBig,
+		"Calculator.ComputeFraction":  calc.ComputeFraction,
+		"Calculator.ComputeDecimal":   calc.ComputeDecimal,
+		"Calculator.AddFloat":         calc.AddFloat,
+		"Calculator.SubtractFloat":    calc.SubtractFloat,
+		"Calculator.MultiplyFloat":    calc.MultiplyFloat,
+		"Calculator.DivideFloat":      calc.DivideFloat,
+		"Calculator.ModFloat":         calc.ModFloat,
+		"Calculator.PowFloat":         calc.PowFloat,
+		"Calculator.Log":              calc.Log,
+		"Calculator.Ln":               calc.Ln,
+		"Calculator.Exp":              calc.Exp,
+		"Calculator.RandomInt":        calc.RandomInt,
+		"Calculator.Sin":              calc.Sin,
+		"Calculator.Cos":              calc.Cos,
+		"Calculator.Tan":              calc.Tan,
+		"Calculator.ComputeTrig":      calc.ComputeTrig,
+		"Calculator.SaveState":        calc.SaveState,
+		"Calculator.LoadState":        calc.LoadState,
+		"Calculator.Close":            calc.Close,
+		"Calculator.Evaluate":         calc.Evaluate,
+		"Calculator.EvaluateCtx":      calc.EvaluateCtx,
+		"Calculator.Explain":          calc.Explain,
+		"Calculator.ExplainExpr":      calc.ExplainExpr,
+		"Calculator.Start":            calc.Start,
+		"Calculator.CalculateAll":     calc.CalculateAll,
+		"Calculator.History":          calc.History,
+		"Calculator.ClearHistory":     calc.ClearHistory,
+		"Calculator.SetVar":           calc.SetVar,
+		"Calculator.GetVar":           calc.GetVar,
+		"Calculator.Vars":             calc.Vars,
+		"Calculator.Undo":             calc.Undo,
+		"Calculator.Redo":             calc.Redo,
+		"Calculator.Apply":            calc.Apply,
+		"Calculator.Describe":         calc.Describe,
+		"Calculator.LookupOperation":  calc.LookupOperation,
+		"Calculator.WithLogger":       calc.WithLogger,
+		"Calculator.With":             calc.With,
+
+		"Calculator.ComputeFloatResultCtx":       calc.ComputeFloatResultCtx,
+		"Calculator.ComputeStrictFloatResultCtx": calc.ComputeStrictFloatResultCtx,
+		"Calculator.ExplainResult":               calc.ExplainResult,
+
+		"BigCalculator.Add":      big.Add,
+		"BigCalculator.Subtract": big.Subtract,
+		"BigCalculator.Multiply": big.Multiply,
+		"BigCalculator.Divide":   big.Divide,
+		"BigCalculator.Mod":      big.Mod,
+
+		"Accumulator.Add":              acc.Add,
+		"Accumulator.Subtract":         acc.Subtract,
+		"Accumulator.Value":            acc.Value,
+		"Accumulator.Reset":            acc.Reset,
+		"Calculator.AddObserver":       calc.AddObserver,
+		"CountingObserver.OnOperation": counts.OnOperation,
+		"CountingObserver.OnValues":    counts.OnValues,
+		"CountingObserver.Count":       counts.Count,
+		"CountingObserver.Failures":    counts.Failures,
+		"CountingObserver.Counts":      counts.Counts,
+		"Calculator.WithObserver":      calc.WithObserver,
+		"Trace.OnOperation":            trace.OnOperation,
+		"Trace.Steps":                  trace.Steps,
+
+		"Chain.Add":      chain.Add,
+		"Chain.Subtract": chain.Subtract,
+		"Chain.Multiply": chain.Multiply,
+		"Chain.Divide":   chain.Divide,
+		"Chain.Mod":      chain.Mod,
+		"Chain.Pow":      chain.Pow,
+		"Chain.Result":   chain.Result,
+
+		"Fraction.Num":      fraction.Num,
+		"Fraction.Den":      fraction.Den,
+		"Fraction.String":   fraction.String,
+		"Fraction.Float64":  fraction.Float64,
+		"Fraction.Add":      fraction.Add,
+		"Fraction.Subtract": fraction.Subtract,
+		"Fraction.Multiply": fraction.Multiply,
+		"Fraction.Divide":   fraction.Divide,
+
+		"Decimal.Units":    decimal.Units,
+		"Decimal.Scale":    decimal.Scale,
+		"Decimal.String":   decimal.String,
+		"Decimal.Rescale":  decimal.Rescale,
+		"Decimal.Add":      decimal.Add,
+		"Decimal.Subtract": decimal.Subtract,
+		"Decimal.Multiply": decimal.Multiply,
+		"Decimal.Divide":   decimal.Divide,
+
+		"Generic.Add":      ints.Add,
+		"Generic.Subtract": ints.Subtract,
+		"Generic.Multiply": ints.Multiply,
+		"Generic.Divide":   ints.Divide,
+
+		"UnknownOperationError.Error": unknown.Error,
+		"UnknownOperationError.Is":    unknown.Is,
+		"InvalidNumberError.Error":    invalid.Error,
+		"InvalidNumberError.Is":       invalid.Is,
+		"SyntaxError.Error":           syntax.Error,
+		"SyntaxError.Is":              syntax.Is,
+		"RangeError.Error":            rangeErr.Error,
+		"RangeError.Is":               rangeErr.Is,
+		"UnknownVariableError.Error":  unknownVar.Error,
+		"UnknownVariableError.Is":     unknownVar.Is,
+		"VariableError.Error":         varErr.Error,
+		"VariableError.Is":            varErr.Is,
+		"UndoError.Error":             undoErr.Error,
+		"UndoError.Is":                undoErr.Is,
+		"RegistrationError.Error":     registrationErr.Error,
+		"RegistrationError.Is":        registrationErr.Is,
+		"DomainError.Error":           domainErr.Error,
+		"DomainError.Is":              domainErr.Is,
+		"LimitError.Error":            limitErr.Error,
+		"LimitError.Is":               limitErr.Is,
+		"Registry.Register":           registry.Register,
+		"Registry.Operations":         registry.Operations,
+		"PrecisionLossError.Error":    loss.Error,
+		"PrecisionLossError.Is":       loss.Is,
+		"OperationError.Error":        opErr.Error,
+		"OperationError.Unwrap":       opErr.Unwrap,
+		"FileStore.Load":              store.Load,
+		"FileStore.Save":              store.Save,
+		"MemoryStore.Load":            memory.Load,
+		"MemoryStore.Save":            memory.Save,
+		"MemoryStore.Saves":           memory.Saves,
+		"ReplayOutcome.String":        outcome.String,
+		"ReplayReport.OK":             report.OK,
+		"ReplayReport.String":         report.String,
+	}
+	panictest.Complete(t, "../../pkg/calculator", funcs)
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	panictest.Check(t, funcs,
+		log,
+		calc,
+		calculator.NewMemoryStore(),
+		calculator.NewFileStore(filepath.Join(t.TempDir(), "other.json")),
+		calculator.WithAudit(log),
+		calculator.WithClock(nil),
+		calculator.WithAutoSave(nil, 0),
+		calculator.WithMigration(0, nil),
+		calculator.WithHistory(-1),
+		calculator.WithMaxVars(-1),
+		calculator.WithMaxOperations(2),
+		calculator.WithMaxMagnitude(-1),
+		calculator.WithUndo(2),
+		calculator.WithRegistry(nil),
+		cancelled,
+		counts,
+		calculator.WithAngleUnit(calculator.Degrees),
+		calculator.WithRandSource(rand.NewPCG(1, 2)),
+		rand.NewPCG(3, 4),
+		calculator.Migration(func(doc map[string]json.RawMessage) error {
+			clear(doc)
+			return nil
+		}),
+		strings.NewReader(`{"msg":"Calculation","operation":"divide","a":1,"b":0,"error":"boom"}`),
+		strings.NewReader(`{"msg":"Calculation","operation":"add","a":9999999999999999999999,"b":1,"result":1}`),
+		strings.NewReader(strings.Repeat("{", 1<<16)),
+		calculator.ErrDivisionByZero,
+	)
+	// Generic is registered above for int64; check it for floats too
+	panictest.Check(t, panictest.Funcs{
+		"Generic[float64].Add":      floats.Add,
+		"Generic[float64].Subtract": floats.Subtract,
+		"Generic[float64].Multiply": floats.Multiply,
+		"Generic[float64].Divide":   floats.Divide,
+	})
+}
+
+// newCalculator returns NewCalculator, closing the calculators it
+// creates when t ends
+func newCalculator(t *testing.T) func(logger.Logger, ...calculator.Option) *calculator.Calculator {
+	return func(log logger.Logger, opts ...calculator.Option) *calculator.Calculator {
+		calc := calculator.NewCalculator(log, opts...)
+		t.Cleanup(func() { calc.Close() })
+		return calc
+	}
+}
@@ -0,0 +1,306 @@
+// Package panictest checks that functions do not panic, by calling them
+// through reflection with adversarial arguments: zero values, nil, the
+// extremes of each numeric type, empty and malformed strings and bytes.
+//
+// Go cannot list the functions of a package at run time, so tests
+// register them by name in a Funcs map and Exported, which parses the
+// package source, makes sure none is missing:
+//
+//	funcs := panictest.Funcs{
+//		"Add":            calculator.Add,
+//		"Calculator.Add": calculator.NewCalculator(nil).Add,
+//	}
+//	panictest.Complete(t, "../../pkg/calculator", funcs)
+//	panictest.Check(t, funcs)
+//
+// Methods are registered as method values bound to a receiver. Values
+// passed to Check are used, besides the generated ones, for every
+// parameter they are assignable to, such as implementations of an
+// interface or valid options.
+package panictest
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Funcs maps names, "Func" or "Type.Method" as listed by Exported, to
+// the functions to call
+type Funcs map[string]any
+
+// maxCalls bounds the argument combinations tried per function. Above
+// it, each parameter is varied on its own with the others at their first
+// value.
+const maxCalls = 4096
+
+// Check calls every function in funcs with the combinations of the
+// adversarial values of its parameters and of extra, failing t with the
+// first call of each function that panics
+func Check(t testing.TB, funcs Funcs, extra ...any) {
+	t.Helper()
+	names := make([]string, 0, len(funcs))
+	for name := range funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fn := reflect.ValueOf(funcs[name])
+		if fn.Kind() != reflect.Func || fn.IsNil() {
+			t.Errorf("%s: not a function", name)
+			continue
+		}
+		var first string
+		panics := 0
+		for _, args := range combinations(fn.Type(), extra) {
+			if p := call(fn, args); p != nil {
+				if panics == 0 {
+					first = fmt.Sprintf("%s(%s) panicked: %v", name, describe(args), p)
+				}
+				panics++
+			}
+		}
+		if panics > 0 {
+			t.Errorf("%s, as did %d more calls", first, panics-1)
+		}
+	}
+}
+
+// call calls fn with args and returns what it panicked with, if anything
+func call(fn reflect.Value, args []reflect.Value) (panicked any) {
+	defer func() {
+		panicked = recover()
+	}()
+	if fn.Type().IsVariadic() {
+		fn.CallSlice(args)
+	} else {
+		fn.Call(args)
+	}
+	return nil
+}
+
+// combinations returns the argument lists to call a function of type ft
+// with
+func combinations(ft reflect.Type, extra []any) [][]reflect.Value {
+	values := make([][]reflect.Value, ft.NumIn())
+	total := 1
+	for i := range values {
+		values[i] = Values(ft.In(i), extra...)
+		if total <= maxCalls {
+			total *= len(values[i])
+		}
+	}
+
+	if total > maxCalls {
+		var calls [][]reflect.Value
+		for i := range values {
+			for _, v := range values[i] {
+				args := make([]reflect.Value, len(values))
+				for j := range values {
+					args[j] = values[j][0]
+				}
+				args[i] = v
+				calls = append(calls, args)
+			}
+		}
+		return calls
+	}
+
+	calls := [][]reflect.Value{{}}
+	for i := range values {
+		next := make([][]reflect.Value, 0, len(calls)*len(values[i]))
+		for _, prefix := range calls {
+			for _, v := range values[i] {
+				next = append(next, append(prefix[:len(prefix):len(prefix)], v))
+			}
+		}
+		calls = next
+	}
+	return calls
+}
+
+// Values returns adversarial values of type t, followed by those of extra
+// assignable to it
+func Values(t reflect.Type, extra ...any) []reflect.Value {
+	var values []reflect.Value
+	add := func(vs ...any) {
+		for _, v := range vs {
+			values = append(values, reflect.ValueOf(v).Convert(t))
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		add(false, true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		bits := t.Bits()
+		add(int64(0), int64(1), int64(-1), int64(2), int64(-2),
+			int64(1)<<(bits-1)-1, int64(-1)<<(bits-1))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		add(uint64(0), uint64(1), uint64(math.MaxUint64)>>(64-t.Bits()))
+	case reflect.Float32, reflect.Float64:
+		add(0.0, -1.0, math.Inf(1), math.Inf(-1), math.NaN())
+		if t.Kind() == reflect.Float64 {
+			add(math.MaxFloat64, -math.MaxFloat64, math.SmallestNonzeroFloat64)
+		}
+	case reflect.String:
+		for _, s := range Strings {
+			add(s)
+		}
+	case reflect.Slice:
+		values = append(values, reflect.Zero(t), reflect.MakeSlice(t, 0, 0))
+		if t.Elem().Kind() == reflect.Uint8 {
+			for _, s := range Strings {
+				values = append(values, reflect.ValueOf([]byte(s)).Convert(t))
+			}
+			for _, s := range JSON {
+				values = append(values, reflect.ValueOf([]byte(s)).Convert(t))
+			}
+			break
+		}
+		for _, elem := range Values(t.Elem(), extra...) {
+			one := reflect.MakeSlice(t, 1, 1)
+			one.Index(0).Set(elem)
+			values = append(values, one)
+		}
+	case reflect.Map:
+		values = append(values, reflect.Zero(t), reflect.MakeMap(t))
+	case reflect.Pointer:
+		values = append(values, reflect.Zero(t), reflect.New(t.Elem()))
+	case reflect.Array, reflect.Struct:
+		values = append(values, reflect.Zero(t))
+	default: // interfaces, funcs and channels
+		values = append(values, reflect.Zero(t))
+	}
+
+	if t.Kind() == reflect.Slice {
+		return values // extra values are used for the elements
+	}
+	for _, x := range extra {
+		v := reflect.ValueOf(x)
+		if v.IsValid() && v.Type().AssignableTo(t) {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// Strings are the adversarial strings, also used as bytes
+var Strings = []string{
+	"",
+	" ",
+	"\x00",
+	"\xff\xfe",
+	"add",
+	"9999999999999999999999",
+	"-9223372036854775808",
+	strings.Repeat("x", 1<<16),
+	"%s%d%v%!",
+	"../../etc/passwd",
+}
+
+// JSON are adversarial bytes for decoders, besides Strings
+var JSON = []string{
+	"null",
+	"{",
+	"[]",
+	`{"code":1}`,
+	`{"a":9999999999999999999999}`,
+	`{"fields":[{"field":null}]}`,
+	strings.Repeat("[", 10000),
+}
+
+// Exported parses the Go package in dir, without its tests, and returns
+// its exported functions and the exported methods of its exported types,
+// as "Func" and "Type.Method", sorted
+func Exported(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || !fn.Name.IsExported() {
+				continue
+			}
+			if fn.Recv == nil {
+				names = append(names, fn.Name.Name)
+				continue
+			}
+			if recv := receiverName(fn.Recv.List[0].Type); ast.IsExported(recv) {
+				names = append(names, recv+"."+fn.Name.Name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// receiverName returns the type name of a receiver, such as T for *T
+func receiverName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(e.X)
+	case *ast.IndexExpr:
+		return receiverName(e.X)
+	case *ast.IndexListExpr:
+		return receiverName(e.X)
+	case *ast.Ident:
+		return e.Name
+	}
+	return ""
+}
+
+// Complete fails t for every function Exported lists in dir that funcs
+// lacks, and for every name in funcs it does not list
+func Complete(t testing.TB, dir string, funcs Funcs) {
+	t.Helper()
+	names, err := Exported(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listed := make(map[string]bool, len(names))
+	for _, name := range names {
+		listed[name] = true
+		if _, ok := funcs[name]; !ok {
+			t.Errorf("%s in %s is not checked for panics", name, dir)
+		}
+	}
+	for name := range funcs {
+		if !listed[name] {
+			t.Errorf("%s is not an exported function of %s", name, dir)
+		}
+	}
+}
+
+// describe formats args for failures, shortening long values
+func describe(args []reflect.Value) string {
+	parts := make([]string, len(args))
+	for i, a := range args {
+		s := fmt.Sprintf("%#v", a.Interface())
+		if len(s) > 40 {
+			s = s[:37] + "..."
+		}
+		parts[i] = s
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,133 @@
+// Package svcconfig loads the configuration file of calcservice and
+// reloads it at runtime, applying the settings that can change while
+// the service runs and reporting those that need a restart.
+package svcconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-examples/pkg/logger"
+	"os"
+	"slices"
+)
+
+// RateLimit configures per-client rate limiting. A zero PerSecond
+// disables it.
+type RateLimit struct {
+	PerSecond float64 `json:"per_second"`
+	Burst     int     `json:"burst"`
+}
+
+// Config is the content of the configuration file. Settings left out
+// keep the values given on the command line.
+type Config struct {
+	// Port and MetricsAddr take effect on restart only
+	Port        int    `json:"port,omitempty"`
+	MetricsAddr string `json:"metrics_addr,omitempty"`
+
+	// LogLevel is a level spec such as "info,calculator=debug"
+	LogLevel    string    `json:"log_level,omitempty"`
+	RateLimit   RateLimit `json:"rate_limit"`
+	CORSOrigins []string  `json:"cors_origins,omitempty"`
+	// APIKeys are accepted as bearer tokens; empty disables
+	// authentication
+	APIKeys []string `json:"api_keys,omitempty"`
+}
+
+// Load reads and validates the JSON configuration file at path. Unknown
+// fields are errors, so typos are not silently ignored.
+func Load(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read config: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var cfg Config
+	if err := dec.Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Validate reports the first invalid setting of c
+func (c Config) Validate() error {
+	if c.Port < 0 || c.Port > 65535 {
+		return fmt.Errorf("port %d out of range", c.Port)
+	}
+	if c.LogLevel != "" {
+		if _, err := logger.ParseLevelSpec(c.LogLevel); err != nil {
+			return fmt.Errorf("invalid log_level: %w", err)
+		}
+	}
+	if c.RateLimit.PerSecond < 0 {
+		return errors.New("rate_limit.per_second must not be negative")
+	}
+	if c.RateLimit.PerSecond > 0 && c.RateLimit.Burst < 1 {
+		return errors.New("rate_limit.burst must be at least 1")
+	}
+	return nil
+}
+
+// Appliers change the running service to new settings. Only LogLevel
+// may fail, and it is called first, so a failure leaves everything
+// unchanged.
+type Appliers struct {
+	LogLevel    func(spec string) error
+	RateLimit   func(RateLimit)
+	CORSOrigins func(origins []string)
+	APIKeys     func(keys []string)
+}
+
+// Result lists the settings a reload changed by name, as in the file
+type Result struct {
+	Applied []string
+	Restart []string // changed, but only applied on restart
+}
+
+// Reload loads path and applies the settings that differ from current
+// through apply. It returns the configuration now in effect, in which
+// settings needing a restart keep their current values, so later reloads
+// report them again. When the file is invalid or an applier fails,
+// nothing is applied and current is returned with the error.
+func Reload(current Config, path string, apply Appliers) (Config, Result, error) {
+	next, err := Load(path)
+	if err != nil {
+		return current, Result{}, err
+	}
+
+	var result Result
+	if next.Port != current.Port {
+		result.Restart = append(result.Restart, "port")
+		next.Port = current.Port
+	}
+	if next.MetricsAddr != current.MetricsAddr {
+		result.Restart = append(result.Restart, "metrics_addr")
+		next.MetricsAddr = current.MetricsAddr
+	}
+
+	if next.LogLevel != current.LogLevel {
+		if err := apply.LogLevel(next.LogLevel); err != nil {
+			return current, Result{}, fmt.Errorf("failed to apply log_level: %w", err)
+		}
+		result.Applied = append(result.Applied, "log_level")
+	}
+	if next.RateLimit != current.RateLimit {
+		apply.RateLimit(next.RateLimit)
+		result.Applied = append(result.Applied, "rate_limit")
+	}
+	if !slices.Equal(next.CORSOrigins, current.CORSOrigins) {
+		apply.CORSOrigins(next.CORSOrigins)
+		result.Applied = append(result.Applied, "cors_origins")
+	}
+	if !slices.Equal(next.APIKeys, current.APIKeys) {
+		apply.APIKeys(next.APIKeys)
+		result.Applied = append(result.Applied, "api_keys")
+	}
+	return next, result, nil
+}
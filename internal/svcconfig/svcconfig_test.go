@@ -0,0 +1,114 @@
+package svcconfig_test
+
+import (
+	"errors"
+	"go-examples/internal/svcconfig"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// recorder collects the settings passed to its appliers
+type recorder struct {
+	applied  map[string]any
+	levelErr error
+}
+
+func newRecorder() *recorder {
+	return &recorder{applied: map[string]any{}}
+}
+
+func (r *recorder) appliers() svcconfig.Appliers {
+	return svcconfig.Appliers{
+		LogLevel: func(spec string) error {
+			if r.levelErr != nil {
+				return r.levelErr
+			}
+			r.applied["log_level"] = spec
+			return nil
+		},
+		RateLimit:   func(rl svcconfig.RateLimit) { r.applied["rate_limit"] = rl },
+		CORSOrigins: func(origins []string) { r.applied["cors_origins"] = origins },
+		APIKeys:     func(keys []string) { r.applied["api_keys"] = keys },
+	}
+}
+
+// load loads a fixture from testdata
+func load(t *testing.T, name string) svcconfig.Config {
+	t.Helper()
+	cfg, err := svcconfig.Load(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+// TestReload tests that changed hot-reloadable settings are applied and
+// that changed restart-only settings are reported and kept
+func TestReload(t *testing.T) {
+	old := load(t, "old.json")
+	rec := newRecorder()
+
+	cfg, result, err := svcconfig.Reload(old, filepath.Join("testdata", "new.json"), rec.appliers())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"log_level", "rate_limit", "cors_origins"}; !reflect.DeepEqual(result.Applied, want) {
+		t.Errorf("applied %v, want %v", result.Applied, want)
+	}
+	if want := []string{"port"}; !reflect.DeepEqual(result.Restart, want) {
+		t.Errorf("restart %v, want %v", result.Restart, want)
+	}
+	want := map[string]any{
+		"log_level":    "info,calculator=debug",
+		"rate_limit":   svcconfig.RateLimit{PerSecond: 5, Burst: 10},
+		"cors_origins": []string{"https://app.example.com", "https://admin.example.com"},
+	}
+	if !reflect.DeepEqual(rec.applied, want) {
+		t.Errorf("appliers got %v, want %v", rec.applied, want)
+	}
+	if cfg.Port != old.Port || cfg.LogLevel != "info,calculator=debug" {
+		t.Errorf("config in effect %+v, want the old port and the new level", cfg)
+	}
+
+	// Reloading the same file again still reports the pending restart
+	_, result, err = svcconfig.Reload(cfg, filepath.Join("testdata", "new.json"), newRecorder().appliers())
+	if err != nil || len(result.Applied) != 0 || !reflect.DeepEqual(result.Restart, []string{"port"}) {
+		t.Errorf("second reload = %+v, %v", result, err)
+	}
+}
+
+// TestReloadRollback tests that nothing is applied when the new file is
+// invalid or an applier fails
+func TestReloadRollback(t *testing.T) {
+	old := load(t, "old.json")
+	tests := []struct {
+		name     string
+		file     string
+		levelErr error
+		wantErr  string
+	}{
+		{"invalid value", "invalid.json", nil, "burst"},
+		{"unknown field", "unknown.json", nil, "unknown field"},
+		{"missing file", "missing.json", nil, "failed to read"},
+		{"failing applier", "new.json", errors.New("not supported"), "log_level"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := newRecorder()
+			rec.levelErr = tc.levelErr
+
+			cfg, result, err := svcconfig.Reload(old, filepath.Join("testdata", tc.file), rec.appliers())
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("error = %v, want one mentioning %q", err, tc.wantErr)
+			}
+			if len(rec.applied) != 0 || len(result.Applied) != 0 {
+				t.Errorf("applied %v after a failed reload", rec.applied)
+			}
+			if !reflect.DeepEqual(cfg, old) {
+				t.Errorf("config in effect %+v, want the old one", cfg)
+			}
+		})
+	}
+}
@@ -0,0 +1,517 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/validate"
+	"math"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Error codes reported in the code field of error responses
+const (
+	CodeInvalidRequest   = "INVALID_REQUEST"
+	CodeUnknownOperation = "UNKNOWN_OPERATION"
+	CodeDivisionByZero   = "DIVISION_BY_ZERO"
+	CodePrecisionLoss    = "PRECISION_LOSS"
+	CodeOverflow         = "OVERFLOW"
+	CodeNegativeInput    = "NEGATIVE_INPUT"
+	CodeLimitExceeded    = "LIMIT_EXCEEDED"
+	CodeTimeout          = "TIMEOUT"
+	CodeCancelled        = "CANCELLED"
+	CodePayloadTooLarge  = "PAYLOAD_TOO_LARGE"
+	CodeInternal         = "INTERNAL"
+)
+
+// StatusClientClosedRequest is the non-standard HTTP status, used by
+// nginx, of a request its client gave up on before the response
+const StatusClientClosedRequest = 499
+
+// ErrorStatus is the status every protocol reports an error code with.
+// GRPCStatus is the canonical name of a gRPC status code, as accepted by
+// codes.Code's UnmarshalJSON.
+type ErrorStatus struct {
+	Code       string
+	HTTPStatus int
+	GRPCStatus string
+}
+
+// errorStatuses maps every error code to its status in each protocol
+var errorStatuses = map[string]ErrorStatus{
+	CodeInvalidRequest:   {CodeInvalidRequest, http.StatusBadRequest, "INVALID_ARGUMENT"},
+	CodeUnknownOperation: {CodeUnknownOperation, http.StatusBadRequest, "INVALID_ARGUMENT"},
+	CodeDivisionByZero:   {CodeDivisionByZero, http.StatusBadRequest, "INVALID_ARGUMENT"},
+	CodePrecisionLoss:    {CodePrecisionLoss, http.StatusBadRequest, "OUT_OF_RANGE"},
+	CodeOverflow:         {CodeOverflow, http.StatusUnprocessableEntity, "OUT_OF_RANGE"},
+	CodeNegativeInput:    {CodeNegativeInput, http.StatusBadRequest, "INVALID_ARGUMENT"},
+	CodeLimitExceeded:    {CodeLimitExceeded, http.StatusUnprocessableEntity, "RESOURCE_EXHAUSTED"},
+	CodeTimeout:          {CodeTimeout, http.StatusRequestTimeout, "DEADLINE_EXCEEDED"},
+	CodeCancelled:        {CodeCancelled, StatusClientClosedRequest, "CANCELLED"},
+	CodePayloadTooLarge:  {CodePayloadTooLarge, http.StatusRequestEntityTooLarge, "RESOURCE_EXHAUSTED"},
+	CodeInternal:         {CodeInternal, http.StatusInternalServerError, "INTERNAL"},
+}
+
+// Sentinels for matching with errors.Is, which compares codes only
+var (
+	ErrInvalidRequest   = &APIError{Code: CodeInvalidRequest}
+	ErrUnknownOperation = &APIError{Code: CodeUnknownOperation}
+	ErrDivisionByZero   = &APIError{Code: CodeDivisionByZero}
+	ErrPrecisionLoss    = &APIError{Code: CodePrecisionLoss}
+	ErrOverflow         = &APIError{Code: CodeOverflow}
+	ErrNegativeInput    = &APIError{Code: CodeNegativeInput}
+	ErrLimitExceeded    = &APIError{Code: CodeLimitExceeded}
+	ErrTimeout          = &APIError{Code: CodeTimeout}
+	ErrCancelled        = &APIError{Code: CodeCancelled}
+	ErrPayloadTooLarge  = &APIError{Code: CodePayloadTooLarge}
+	ErrInternal         = &APIError{Code: CodeInternal}
+)
+
+// Message keys of the errors built by the constructors below, for
+// translation catalogs. Field messages use "validate." + rule code.
+const (
+	msgInvalidRequestFormat = "error.invalid_request_format"
+	msgInvalidFields        = "error.invalid_fields"
+	msgUnknownOperation     = "error.unknown_operation"
+	msgDivisionByZero       = "error.division_by_zero"
+	msgPrecisionLoss        = "error.precision_loss"
+	msgOverflow             = "error.overflow"
+	msgNegativeInput        = "error.negative_input"
+	msgNotFinite            = "error.not_finite"
+	msgInvalidNumber        = "error.invalid_number"
+	msgInvalidExpression    = "error.invalid_expression"
+	msgEmptyInput           = "error.empty_input"
+	msgOutOfRange           = "error.out_of_range"
+	msgUnknownVariable      = "error.unknown_variable"
+	msgInvalidVariable      = "error.invalid_variable"
+	msgNoUndo               = "error.no_undo"
+	msgInvalidRegistration  = "error.invalid_registration"
+	msgOutOfDomain          = "error.out_of_domain"
+	msgLimitExceeded        = "error.limit_exceeded"
+	msgTimeout              = "error.timeout"
+	msgCancelled            = "error.cancelled"
+	msgPayloadTooLarge      = "error.payload_too_large"
+	msgInternal             = "error.internal"
+)
+
+// Translator renders the message for key with fmt-style args in some
+// language
+type Translator interface {
+	Translate(key string, args ...any) string
+}
+
+// APIError is an error reported by the calculator API. The server renders
+// it as the response body and status; the client SDK parses it back.
+type APIError struct {
+	Code       string                // one of the Code constants
+	Message    string                // human-readable description
+	HTTPStatus int                   // response status; not part of the body
+	RequestID  string                // ID of the failed request, when known
+	Fields     []validate.FieldError // invalid request fields, if any
+	Precision  *PrecisionLossDetail  // how to recover from CodePrecisionLoss
+	Trace      []TraceStep           // operations up to the failure, when asked for
+
+	key  string // message key for Localize, if any
+	args []any  // arguments of the message
+}
+
+// New returns an APIError with the HTTP status registered for code.
+// Unknown codes get status 500.
+func New(code, message string) *APIError {
+	return &APIError{Code: code, Message: message, HTTPStatus: StatusFor(code)}
+}
+
+// newKeyed returns an APIError whose message can be translated
+func newKeyed(code, key, message string, args ...any) *APIError {
+	e := New(code, message)
+	e.key, e.args = key, args
+	return e
+}
+
+// InvalidRequest reports an invalid request with a message that is not
+// translated
+func InvalidRequest(message string) *APIError {
+	return New(CodeInvalidRequest, message)
+}
+
+// MalformedRequest reports a request body that cannot be decoded
+func MalformedRequest() *APIError {
+	return newKeyed(CodeInvalidRequest, msgInvalidRequestFormat, "Invalid request format")
+}
+
+// FromDecodeError converts an error reading or decoding a request body.
+// A body cut off by http.MaxBytesReader is reported as too large.
+// Integers that do not fit their field, such as 9999999999999999999999
+// for an int, are reported as out of range for that field; other errors
+// as a malformed request.
+func FromDecodeError(err error) *APIError {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return PayloadTooLarge(maxErr.Limit)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Type != nil && typeErr.Field != "" && isInteger(strings.TrimPrefix(typeErr.Value, "number ")) {
+		switch typeErr.Type.Kind() {
+		case reflect.Int:
+			return InvalidFields([]validate.FieldError{outOfRange(typeErr.Field, math.MinInt, math.MaxInt)})
+		case reflect.Int64:
+			return InvalidFields([]validate.FieldError{outOfRange(typeErr.Field, math.MinInt64, math.MaxInt64)})
+		case reflect.Int32:
+			return InvalidFields([]validate.FieldError{outOfRange(typeErr.Field, math.MinInt32, math.MaxInt32)})
+		}
+	}
+	return MalformedRequest()
+}
+
+// isInteger reports whether s is an integer literal, as opposed to a
+// fraction or exponent, which are malformed whatever their value
+func isInteger(s string) bool {
+	s = strings.TrimPrefix(s, "-")
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+// outOfRange is the FieldError of validate.Validator.Range for field
+func outOfRange(field string, min, max int64) validate.FieldError {
+	return validate.FieldError{
+		Field:   field,
+		Code:    validate.CodeOutOfRange,
+		Message: fmt.Sprintf(validate.Messages[validate.CodeOutOfRange], min, max),
+		Args:    []any{min, max},
+	}
+}
+
+// InvalidFields reports request fields that failed validation
+func InvalidFields(errs []validate.FieldError) *APIError {
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Error()
+	}
+	e := newKeyed(CodeInvalidRequest, msgInvalidFields, "Invalid request: "+strings.Join(msgs, "; "))
+	e.Fields = errs
+	return e
+}
+
+// UnknownOperation reports an operation the service does not support
+func UnknownOperation(operation string) *APIError {
+	return newKeyed(CodeUnknownOperation, msgUnknownOperation, "Unknown operation: "+operation, operation)
+}
+
+// DivisionByZero reports a division with a zero divisor
+func DivisionByZero() *APIError {
+	return newKeyed(CodeDivisionByZero, msgDivisionByZero, "Division by zero")
+}
+
+// PrecisionLoss reports a result that strict mode rejects as not exact,
+// with detail for clients to recover
+func PrecisionLoss(detail PrecisionLossDetail) *APIError {
+	e := newKeyed(CodePrecisionLoss, msgPrecisionLoss,
+		fmt.Sprintf("Precision loss: the integer result %d is not exact", detail.Result), detail.Result)
+	e.Precision = &detail
+	return e
+}
+
+// Overflow reports a result that does not fit in an int
+func Overflow() *APIError {
+	return newKeyed(CodeOverflow, msgOverflow, "Integer overflow: the result does not fit in 64 bits")
+}
+
+// NegativeInput reports a negative operand an operation does not accept
+func NegativeInput() *APIError {
+	return newKeyed(CodeNegativeInput, msgNegativeInput, "Negative input: the operation needs an operand of 0 or more")
+}
+
+// NotFinite reports a float calculation whose result is not a number,
+// such as a fractional power of a negative number
+func NotFinite() *APIError {
+	return newKeyed(CodeInvalidRequest, msgNotFinite, "Not a finite number: the result is undefined")
+}
+
+// InvalidNumber reports an operand of a big calculation that is not a
+// decimal integer
+func InvalidNumber(number string) *APIError {
+	return newKeyed(CodeInvalidRequest, msgInvalidNumber, "Invalid number: "+number, number)
+}
+
+// InvalidExpression reports a malformed expression, with the 1-based
+// position of the problem and its description
+func InvalidExpression(pos int, reason string) *APIError {
+	return newKeyed(CodeInvalidRequest, msgInvalidExpression, fmt.Sprintf("Invalid expression at position %d: %s", pos, reason), pos, reason)
+}
+
+// EmptyInput reports an aggregate, such as a sum, of no numbers
+func EmptyInput() *APIError {
+	return newKeyed(CodeInvalidRequest, msgEmptyInput, "Empty input: at least one number is needed")
+}
+
+// OutOfRange reports an operand, described by name, outside the range
+// low to high an operation accepts
+func OutOfRange(name string, value, low, high int) *APIError {
+	return newKeyed(CodeInvalidRequest, msgOutOfRange, fmt.Sprintf("Out of range: %s %d must be between %d and %d", name, value, low, high), name, value, low, high)
+}
+
+// UnknownVariable reports a variable that is not set
+func UnknownVariable(name string) *APIError {
+	return newKeyed(CodeInvalidRequest, msgUnknownVariable, "Unknown variable: "+name, name)
+}
+
+// InvalidVariable reports a variable that cannot be set, with the reason
+func InvalidVariable(name, reason string) *APIError {
+	return newKeyed(CodeInvalidRequest, msgInvalidVariable, fmt.Sprintf("Invalid variable %s: %s", name, reason), name, reason)
+}
+
+// NoUndo reports an undo or redo, named by action, with no change left
+// to revert or perform again
+func NoUndo(action string) *APIError {
+	return newKeyed(CodeInvalidRequest, msgNoUndo, "Nothing to "+action, action)
+}
+
+// InvalidRegistration reports an operation that cannot be registered
+// under name, with the reason
+func InvalidRegistration(name, reason string) *APIError {
+	return newKeyed(CodeInvalidRequest, msgInvalidRegistration, fmt.Sprintf("Cannot register operation %s: %s", name, reason), name, reason)
+}
+
+// OutOfDomain reports an operand of a float function outside the values
+// it is defined for, such as a logarithm base of 1
+func OutOfDomain(name string, value float64, domain string) *APIError {
+	return newKeyed(CodeInvalidRequest, msgOutOfDomain, fmt.Sprintf("Out of domain: %s %g must be %s", name, value, domain), name, value, domain)
+}
+
+// LimitExceeded reports an expression beyond a limit of the evaluator,
+// described by name, such as its operation count
+func LimitExceeded(name string, value, limit int) *APIError {
+	return newKeyed(CodeLimitExceeded, msgLimitExceeded, fmt.Sprintf("Limit exceeded: %s %d is above %d", name, value, limit), name, value, limit)
+}
+
+// Timeout reports a calculation stopped by the deadline of its request
+func Timeout() *APIError {
+	return newKeyed(CodeTimeout, msgTimeout, "Timeout: the calculation did not finish in time")
+}
+
+// Cancelled reports a calculation stopped because its request was
+// cancelled, such as by the client going away
+func Cancelled() *APIError {
+	return newKeyed(CodeCancelled, msgCancelled, "Cancelled: the request was cancelled before the calculation finished")
+}
+
+// PayloadTooLarge reports a request body longer than the limit bytes
+// the server reads
+func PayloadTooLarge(limit int64) *APIError {
+	return newKeyed(CodePayloadTooLarge, msgPayloadTooLarge, fmt.Sprintf("Payload too large: the request body exceeds %d bytes", limit), limit)
+}
+
+// Internal reports an unexpected server failure with a message that is
+// not translated
+func Internal(message string) *APIError {
+	return New(CodeInternal, message)
+}
+
+// Codes returns every error code, sorted
+func Codes() []string {
+	codes := make([]string, 0, len(errorStatuses))
+	for code := range errorStatuses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// StatusFor returns the HTTP status for code, or 500 for unknown codes
+func StatusFor(code string) int {
+	return ErrorStatusFor(code).HTTPStatus
+}
+
+// ErrorStatusFor returns the status of code in every protocol. Unknown
+// codes get those of CodeInternal.
+func ErrorStatusFor(code string) ErrorStatus {
+	if status, ok := errorStatuses[code]; ok {
+		return status
+	}
+	status := errorStatuses[CodeInternal]
+	status.Code = code
+	return status
+}
+
+// FromCalculatorError translates an error returned by pkg/calculator.
+// Every error in calculator.Errors must have a case here, as do the
+// context errors of the Ctx methods, bare or wrapped in an
+// *OperationError; errors without one become internal errors.
+func FromCalculatorError(err error) *APIError {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return Timeout()
+	case errors.Is(err, context.Canceled):
+		return Cancelled()
+	case errors.Is(err, calculator.ErrDivisionByZero):
+		return DivisionByZero()
+	case errors.Is(err, calculator.ErrUnknownOperation):
+		var unknown *calculator.UnknownOperationError
+		if errors.As(err, &unknown) {
+			return UnknownOperation(unknown.Name)
+		}
+		return newKeyed(CodeUnknownOperation, msgUnknownOperation, "Unknown operation: ", "")
+	case errors.Is(err, calculator.ErrPrecisionLoss):
+		var loss *calculator.PrecisionLossError
+		if errors.As(err, &loss) {
+			return PrecisionLoss(PrecisionLossDetail{Result: loss.Result, Remainder: loss.Remainder, Overflow: loss.Overflow, Float: loss.Float})
+		}
+		return PrecisionLoss(PrecisionLossDetail{})
+	case errors.Is(err, calculator.ErrOverflow):
+		return Overflow()
+	case errors.Is(err, calculator.ErrNegativeInput):
+		return NegativeInput()
+	case errors.Is(err, calculator.ErrNotFinite):
+		return NotFinite()
+	case errors.Is(err, calculator.ErrInvalidNumber):
+		var invalid *calculator.InvalidNumberError
+		if errors.As(err, &invalid) {
+			return InvalidNumber(invalid.Number)
+		}
+		return InvalidNumber("")
+	case errors.Is(err, calculator.ErrSyntax):
+		var syntax *calculator.SyntaxError
+		if errors.As(err, &syntax) {
+			return InvalidExpression(syntax.Pos, syntax.Msg)
+		}
+		return InvalidExpression(0, "")
+	case errors.Is(err, calculator.ErrEmptyInput):
+		return EmptyInput()
+	case errors.Is(err, calculator.ErrOutOfRange):
+		var rangeErr *calculator.RangeError
+		if errors.As(err, &rangeErr) {
+			return OutOfRange(rangeErr.Name, rangeErr.Value, rangeErr.Min, rangeErr.Max)
+		}
+		return OutOfRange("operand", 0, 0, 0)
+	case errors.Is(err, calculator.ErrUnknownVariable):
+		var unknown *calculator.UnknownVariableError
+		if errors.As(err, &unknown) {
+			return UnknownVariable(unknown.Name)
+		}
+		return UnknownVariable("")
+	case errors.Is(err, calculator.ErrInvalidVariable):
+		var invalid *calculator.VariableError
+		if errors.As(err, &invalid) {
+			return InvalidVariable(invalid.Name, invalid.Reason)
+		}
+		return InvalidVariable("", "")
+	case errors.Is(err, calculator.ErrNoUndo):
+		var undo *calculator.UndoError
+		if errors.As(err, &undo) {
+			return NoUndo(undo.Action)
+		}
+		return NoUndo("undo")
+	case errors.Is(err, calculator.ErrRegistration):
+		var registration *calculator.RegistrationError
+		if errors.As(err, &registration) {
+			return InvalidRegistration(registration.Name, registration.Reason)
+		}
+		return InvalidRegistration("", "")
+	case errors.Is(err, calculator.ErrDomain):
+		var domain *calculator.DomainError
+		if errors.As(err, &domain) {
+			return OutOfDomain(domain.Name, domain.Value, domain.Domain)
+		}
+		return OutOfDomain("", 0, "")
+	case errors.Is(err, calculator.ErrLimit):
+		var limit *calculator.LimitError
+		if errors.As(err, &limit) {
+			return LimitExceeded(limit.Name, limit.Value, limit.Max)
+		}
+		return LimitExceeded("", 0, 0)
+	default:
+		return newKeyed(CodeInternal, msgInternal, "internal error")
+	}
+}
+
+// Localize returns a copy of e with its message and field messages
+// rendered by t. Messages built by New, InvalidRequest and Internal, and
+// errors parsed from a response, are kept as they are, as are all
+// messages when t is nil.
+func (e *APIError) Localize(t Translator) *APIError {
+	l := *e
+	if t == nil {
+		return &l
+	}
+	if len(e.Fields) > 0 {
+		l.Fields = make([]validate.FieldError, len(e.Fields))
+		for i, f := range e.Fields {
+			if _, ok := validate.Messages[f.Code]; ok {
+				f.Message = t.Translate("validate."+f.Code, f.Args...)
+			}
+			l.Fields[i] = f
+		}
+	}
+	switch {
+	case e.key == msgInvalidFields:
+		msgs := make([]string, len(l.Fields))
+		for i, f := range l.Fields {
+			msgs[i] = f.Error()
+		}
+		l.Message = t.Translate(e.key, strings.Join(msgs, "; "))
+	case e.key != "":
+		l.Message = t.Translate(e.key, e.args...)
+	}
+	return &l
+}
+
+func (e *APIError) Error() string {
+	if e.RequestID != "" {
+		return fmt.Sprintf("%s: %s (request %s)", e.Code, e.Message, e.RequestID)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Is reports whether target is an *APIError with the same code
+func (e *APIError) Is(target error) bool {
+	t, ok := target.(*APIError)
+	return ok && t.Code == e.Code
+}
+
+// MarshalJSON encodes e in the error response format
+func (e *APIError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(CalculationResponse{
+		Success:   false,
+		Error:     e.Message,
+		Code:      e.Code,
+		RequestID: e.RequestID,
+		Fields:    e.Fields,
+		Precision: e.Precision,
+		Trace:     e.Trace,
+	})
+}
+
+// UnmarshalJSON decodes an error response body. HTTPStatus is set from
+// the code; ParseError sets it from the actual response instead.
+func (e *APIError) UnmarshalJSON(data []byte) error {
+	var resp CalculationResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return err
+	}
+	*e = APIError{Code: resp.Code, Message: resp.Error, HTTPStatus: StatusFor(resp.Code), RequestID: resp.RequestID, Fields: resp.Fields, Precision: resp.Precision, Trace: resp.Trace}
+	return nil
+}
+
+// ParseError builds an APIError from an error response. Bodies that are
+// not in the error format, or that lack a code, get a code derived from
+// status and the raw body as message.
+func ParseError(status int, body []byte) *APIError {
+	var e APIError
+	if err := json.Unmarshal(body, &e); err != nil || e.Code == "" {
+		code := CodeInvalidRequest
+		if status >= http.StatusInternalServerError {
+			code = CodeInternal
+		}
+		message := e.Message
+		if message == "" {
+			message = string(body)
+		}
+		e = APIError{Code: code, Message: message, RequestID: e.RequestID}
+	}
+	e.HTTPStatus = status
+	return &e
+}
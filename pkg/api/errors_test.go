@@ -0,0 +1,214 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-examples/pkg/api"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/validate"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestCodesRoundTrip tests that every code survives JSON encoding
+func TestCodesRoundTrip(t *testing.T) {
+	for _, code := range api.Codes() {
+		t.Run(code, func(t *testing.T) {
+			want := api.New(code, "something failed")
+			want.RequestID = "req-1"
+
+			data, err := json.Marshal(want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got api.APIError
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, *want) {
+				t.Errorf("round trip = %+v, want %+v", got, *want)
+			}
+
+			parsed := api.ParseError(want.HTTPStatus, data)
+			if !reflect.DeepEqual(*parsed, *want) {
+				t.Errorf("ParseError = %+v, want %+v", *parsed, *want)
+			}
+		})
+	}
+}
+
+// TestInvalidFieldsRoundTrip tests that field errors survive JSON encoding
+func TestInvalidFieldsRoundTrip(t *testing.T) {
+	want := api.InvalidFields([]validate.FieldError{
+		{Field: "operation", Code: validate.CodeRequired, Message: "is required"},
+		{Field: "a", Code: validate.CodeOutOfRange, Message: "must be between 0 and 10"},
+	})
+	if want.Message != "Invalid request: operation is required; a must be between 0 and 10" {
+		t.Errorf("unexpected message %q", want.Message)
+	}
+
+	data, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The message key stays on the server, so compare what the body carries
+	got := api.ParseError(http.StatusBadRequest, data)
+	if got.Code != want.Code || got.Message != want.Message || got.HTTPStatus != want.HTTPStatus || !reflect.DeepEqual(got.Fields, want.Fields) {
+		t.Errorf("round trip = %+v, want %+v", *got, *want)
+	}
+	if !errors.Is(got, api.ErrInvalidRequest) {
+		t.Errorf("expected INVALID_REQUEST, got %s", got.Code)
+	}
+}
+
+// TestStatusFor tests the HTTP status mapping of each code
+func TestStatusFor(t *testing.T) {
+	tests := map[string]int{
+		api.CodeInvalidRequest:   http.StatusBadRequest,
+		api.CodeUnknownOperation: http.StatusBadRequest,
+		api.CodeDivisionByZero:   http.StatusBadRequest,
+		api.CodePrecisionLoss:    http.StatusBadRequest,
+		api.CodeOverflow:         http.StatusUnprocessableEntity,
+		api.CodeNegativeInput:    http.StatusBadRequest,
+		api.CodeLimitExceeded:    http.StatusUnprocessableEntity,
+		api.CodeTimeout:          http.StatusRequestTimeout,
+		api.CodeCancelled:        api.StatusClientClosedRequest,
+		api.CodePayloadTooLarge:  http.StatusRequestEntityTooLarge,
+		api.CodeInternal:         http.StatusInternalServerError,
+		"NOT_A_CODE":             http.StatusInternalServerError,
+	}
+	for code, want := range tests {
+		if got := api.StatusFor(code); got != want {
+			t.Errorf("StatusFor(%q) = %d, want %d", code, got, want)
+		}
+	}
+	if len(api.Codes()) != len(tests)-1 {
+		t.Errorf("Codes() = %v, missing from this table", api.Codes())
+	}
+}
+
+// TestErrorStatusFor tests that every code has a status in every
+// protocol and unknown codes get the internal error's
+func TestErrorStatusFor(t *testing.T) {
+	for _, code := range api.Codes() {
+		status := api.ErrorStatusFor(code)
+		if status.Code != code || status.HTTPStatus == 0 || status.GRPCStatus == "" {
+			t.Errorf("ErrorStatusFor(%q) = %+v", code, status)
+		}
+	}
+	got := api.ErrorStatusFor("NOT_A_CODE")
+	if got.HTTPStatus != http.StatusInternalServerError || got.GRPCStatus != "INTERNAL" {
+		t.Errorf("ErrorStatusFor(NOT_A_CODE) = %+v, want the internal error's", got)
+	}
+}
+
+// TestFromCalculatorErrorExhaustive tests that every calculator error has
+// its own mapping rather than falling through to INTERNAL
+func TestFromCalculatorErrorExhaustive(t *testing.T) {
+	for _, calcErr := range calculator.Errors() {
+		wrapped := fmt.Errorf("divide: %w", calcErr)
+		if got := api.FromCalculatorError(wrapped); got.Code == api.CodeInternal {
+			t.Errorf("calculator error %q has no API error mapping", calcErr)
+		}
+	}
+	if got := api.FromCalculatorError(errors.New("unexpected")); !errors.Is(got, api.ErrInternal) {
+		t.Errorf("unmapped error = %v, want INTERNAL", got)
+	}
+}
+
+// TestFromCalculatorErrorContext tests that the context errors of the
+// Ctx methods, bare or wrapped by the calculator, report a timeout or a
+// cancellation rather than an internal error
+func TestFromCalculatorErrorContext(t *testing.T) {
+	tests := []struct {
+		err  error
+		want *api.APIError
+	}{
+		{context.DeadlineExceeded, api.ErrTimeout},
+		{context.Canceled, api.ErrCancelled},
+		{&calculator.OperationError{Operation: "add", A: 1, B: 2, Err: context.DeadlineExceeded}, api.ErrTimeout},
+		{fmt.Errorf("compute: %w", context.Canceled), api.ErrCancelled},
+	}
+	for _, tc := range tests {
+		got := api.FromCalculatorError(tc.err)
+		if !errors.Is(got, tc.want) || got.HTTPStatus != api.StatusFor(tc.want.Code) {
+			t.Errorf("FromCalculatorError(%v) = %s with status %d, want %s", tc.err, got.Code, got.HTTPStatus, tc.want.Code)
+		}
+	}
+	if got := api.FromCalculatorError(&calculator.LimitError{Name: "operation count", Value: 3, Max: 2}); !errors.Is(got, api.ErrLimitExceeded) {
+		t.Errorf("FromCalculatorError(LimitError) = %s, want %s", got.Code, api.CodeLimitExceeded)
+	}
+}
+
+// TestErrorsIs tests matching against the sentinels by code
+func TestErrorsIs(t *testing.T) {
+	err := fmt.Errorf("calculate: %w", api.DivisionByZero())
+	if !errors.Is(err, api.ErrDivisionByZero) {
+		t.Errorf("errors.Is(%v, ErrDivisionByZero) = false", err)
+	}
+	if errors.Is(err, api.ErrInvalidRequest) {
+		t.Errorf("errors.Is(%v, ErrInvalidRequest) = true", err)
+	}
+}
+
+// TestParseErrorFallback tests bodies that are not in the error format
+func TestParseErrorFallback(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{name: "client error", status: http.StatusNotFound, body: "404 page not found", want: api.CodeInvalidRequest},
+		{name: "server error", status: http.StatusBadGateway, body: "bad gateway", want: api.CodeInternal},
+		{name: "missing code", status: http.StatusInternalServerError, body: `{"success":false,"error":"internal server error"}`, want: api.CodeInternal},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := api.ParseError(tc.status, []byte(tc.body))
+			if got.Code != tc.want || got.HTTPStatus != tc.status {
+				t.Errorf("ParseError = %+v, want code %s and status %d", *got, tc.want, tc.status)
+			}
+			if got.Message == "" {
+				t.Error("ParseError dropped the message")
+			}
+		})
+	}
+}
+
+// TestFromDecodeError tests the errors reported for request bodies that
+// cannot be decoded
+func TestFromDecodeError(t *testing.T) {
+	decode := func(body string) error {
+		var req api.CalculationRequest
+		return json.Unmarshal([]byte(body), &req)
+	}
+	err := api.FromDecodeError(decode(`{"operation":"add","a":9999999999999999999999,"b":1}`))
+	if len(err.Fields) != 1 || err.Fields[0].Field != "a" || err.Fields[0].Code != validate.CodeOutOfRange {
+		t.Errorf("overflowing a = %+v, want a out of range", err)
+	}
+	for _, body := range []string{`{"operation":1}`, `{`, `{"a":1.5}`, `{"b":-1e99}`} {
+		if err := api.FromDecodeError(decode(body)); len(err.Fields) != 0 || err.Message != api.MalformedRequest().Message {
+			t.Errorf("%s = %+v, want a malformed request", body, err)
+		}
+	}
+
+	// A body cut off by MaxBytesReader is too large, whether read whole
+	// or decoded from the stream
+	limited := func() io.Reader {
+		return http.MaxBytesReader(httptest.NewRecorder(), io.NopCloser(strings.NewReader(`{"operation":"add","a":1,"b":2}`)), 10)
+	}
+	_, readErr := io.ReadAll(limited())
+	var req api.CalculationRequest
+	for _, err := range []error{readErr, json.NewDecoder(limited()).Decode(&req)} {
+		if got := api.FromDecodeError(err); !errors.Is(got, api.ErrPayloadTooLarge) || got.HTTPStatus != http.StatusRequestEntityTooLarge || got.Message != "Payload too large: the request body exceeds 10 bytes" {
+			t.Errorf("FromDecodeError(%v) = %+v, want PAYLOAD_TOO_LARGE", err, got)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+// Package api defines the wire types shared by the calculator service and
+// its client SDK.
+//
+// No exported function or method panics on arguments, including zero
+// values and nil; each either returns an error or is total. Methods need
+// a non-nil receiver. internal/panictest checks this on every function.
+package api
+
+import "go-examples/pkg/validate"
+
+// Calculation modes, sent in the mode field of a calculation request.
+// Requests without a mode are in ModeInt.
+const (
+	ModeInt     = "int"
+	ModeFloat   = "float"   // see FloatCalculationRequest
+	ModeBig     = "big"     // see BigCalculationRequest
+	ModeDecimal = "decimal" // see DecimalCalculationRequest
+)
+
+// CalculationRequest represents a calculation API request
+type CalculationRequest struct {
+	Operation string `json:"operation"`
+	A         int    `json:"a"`
+	B         int    `json:"b"`
+	Strict    bool   `json:"strict,omitempty"`  // reject results that are not exact
+	Explain   bool   `json:"explain,omitempty"` // add the steps to the response
+	Mode      string `json:"mode,omitempty"`    // ModeInt or empty
+}
+
+// ValidationFields lists the fields for validate.Struct
+func (r CalculationRequest) ValidationFields() validate.Fields {
+	return validate.Fields{
+		"operation": func() any { return r.Operation },
+		"a":         func() any { return r.A },
+		"b":         func() any { return r.B },
+		"mode":      func() any { return r.Mode },
+	}
+}
+
+// FloatCalculationRequest is a calculation request in ModeFloat, with
+// float operands. Strict mode does not apply to float calculations.
+type FloatCalculationRequest struct {
+	Operation string  `json:"operation"`
+	A         float64 `json:"a"`
+	B         float64 `json:"b"`
+	Mode      string  `json:"mode"`              // ModeFloat
+	Explain   bool    `json:"explain,omitempty"` // not supported: rejected as INVALID_REQUEST
+}
+
+// ValidationFields lists the fields for validate.Struct
+func (r FloatCalculationRequest) ValidationFields() validate.Fields {
+	return validate.Fields{
+		"operation": func() any { return r.Operation },
+		"a":         func() any { return r.A },
+		"b":         func() any { return r.B },
+		"mode":      func() any { return r.Mode },
+		"explain":   func() any { return r.Explain },
+	}
+}
+
+// BigCalculationRequest is a calculation request in ModeBig, with
+// integers of any size as decimal strings. Strict mode does not apply,
+// as big results are exact.
+type BigCalculationRequest struct {
+	Operation string `json:"operation"`
+	A         string `json:"a"`
+	B         string `json:"b"`
+	Mode      string `json:"mode"`              // ModeBig
+	Explain   bool   `json:"explain,omitempty"` // not supported: rejected as INVALID_REQUEST
+}
+
+// ValidationFields lists the fields for validate.Struct
+func (r BigCalculationRequest) ValidationFields() validate.Fields {
+	return validate.Fields{
+		"operation": func() any { return r.Operation },
+		"a":         func() any { return r.A },
+		"b":         func() any { return r.B },
+		"mode":      func() any { return r.Mode },
+		"explain":   func() any { return r.Explain },
+	}
+}
+
+// DecimalCalculationRequest is a calculation request in ModeDecimal,
+// with fixed-point decimal numbers as strings, such as "19.99". Results
+// have the larger number of fractional digits of the operands, rounded
+// half to even. Strict mode does not apply.
+type DecimalCalculationRequest struct {
+	Operation string `json:"operation"`
+	A         string `json:"a"`
+	B         string `json:"b"`
+	Mode      string `json:"mode"`              // ModeDecimal
+	Explain   bool   `json:"explain,omitempty"` // not supported: rejected as INVALID_REQUEST
+}
+
+// ValidationFields lists the fields for validate.Struct
+func (r DecimalCalculationRequest) ValidationFields() validate.Fields {
+	return validate.Fields{
+		"operation": func() any { return r.Operation },
+		"a":         func() any { return r.A },
+		"b":         func() any { return r.B },
+		"mode":      func() any { return r.Mode },
+		"explain":   func() any { return r.Explain },
+	}
+}
+
+// CalculationResponse represents a calculation API response. Failed
+// calculations set Success to false and describe the failure in Error,
+// Code, RequestID and, for invalid fields, Fields, or for a precision
+// loss, Precision; see APIError. Operations with fractional results,
+// such as pctchange, truncate Result toward zero and set FloatResult to
+// the full result. Successful requests with Explain set Steps. Failed
+// evaluations asked for a trace set Trace.
+type CalculationResponse struct {
+	Result      int                   `json:"result"`
+	FloatResult *float64              `json:"float_result,omitempty"`
+	Success     bool                  `json:"success"`
+	Error       string                `json:"error,omitempty"`
+	Code        string                `json:"code,omitempty"`
+	RequestID   string                `json:"request_id,omitempty"`
+	Fields      []validate.FieldError `json:"fields,omitempty"`
+	Precision   *PrecisionLossDetail  `json:"precision,omitempty"`
+	Steps       []Step                `json:"steps,omitempty"`
+	Trace       []TraceStep           `json:"trace,omitempty"`
+}
+
+// FloatCalculationResponse is the response to a FloatCalculationRequest.
+// Failed calculations are answered with a CalculationResponse, as in
+// ModeInt.
+type FloatCalculationResponse struct {
+	Result  float64 `json:"result"`
+	Success bool    `json:"success"`
+	Mode    string  `json:"mode"` // ModeFloat
+}
+
+// BigCalculationResponse is the response to a BigCalculationRequest,
+// with the result as a decimal string. Failed calculations are answered
+// with a CalculationResponse, as in ModeInt.
+type BigCalculationResponse struct {
+	Result  string `json:"result"`
+	Success bool   `json:"success"`
+	Mode    string `json:"mode"` // ModeBig
+}
+
+// DecimalCalculationResponse is the response to a
+// DecimalCalculationRequest, with the result as a decimal string with
+// all of its fractional digits, such as "39.98". Failed calculations are
+// answered with a CalculationResponse, as in ModeInt.
+type DecimalCalculationResponse struct {
+	Result  string `json:"result"`
+	Success bool   `json:"success"`
+	Mode    string `json:"mode"` // ModeDecimal
+}
+
+// EvaluationRequest is a request of POST /evaluate, for the value of
+// an integer arithmetic expression, as accepted by
+// calculator.Evaluate, such as "2 + 3 * (4 - 1)"
+type EvaluationRequest struct {
+	Expression string `json:"expression"`
+	Trace      bool   `json:"trace,omitempty"` // add the operations performed to the response
+}
+
+// ValidationFields lists the fields for validate.Struct
+func (r EvaluationRequest) ValidationFields() validate.Fields {
+	return validate.Fields{
+		"expression": func() any { return r.Expression },
+	}
+}
+
+// EvaluationResponse is the response to an EvaluationRequest. Failed
+// evaluations are answered with a CalculationResponse, as calculations
+// are, whose Trace ends at the failed operation. Requests with Trace
+// set Trace.
+type EvaluationResponse struct {
+	Result  int         `json:"result"`
+	Success bool        `json:"success"`
+	Trace   []TraceStep `json:"trace,omitempty"`
+}
+
+// BatchRequest is a request of POST /calculate/batch, for the integer
+// calculations of Calculations, performed in order
+type BatchRequest struct {
+	Calculations []BatchCalculation `json:"calculations"`
+	Trace        bool               `json:"trace,omitempty"` // add the calculations performed to the response
+}
+
+// BatchCalculation is a calculation of a BatchRequest
+type BatchCalculation struct {
+	Operation string `json:"operation"`
+	A         int    `json:"a"`
+	B         int    `json:"b"`
+}
+
+// ValidationFields lists the fields for validate.Struct
+func (r BatchCalculation) ValidationFields() validate.Fields {
+	return validate.Fields{
+		"operation": func() any { return r.Operation },
+		"a":         func() any { return r.A },
+		"b":         func() any { return r.B },
+	}
+}
+
+// BatchResponse is the response to a BatchRequest, with a result for
+// each calculation at the same index. A failed calculation sets the
+// Code and Error of its own result only. Requests with Trace set Trace.
+type BatchResponse struct {
+	Results []BatchResult `json:"results"`
+	Success bool          `json:"success"`
+	Trace   []TraceStep   `json:"trace,omitempty"`
+}
+
+// BatchResult is the outcome of a BatchCalculation
+type BatchResult struct {
+	Result int    `json:"result"`
+	Code   string `json:"code,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// TraceStep is an operation of a traced evaluation or batch, as
+// recorded by calculator.Trace, in the order performed: a reduction of
+// an expression, or a calculation of a batch. A trace longer than the
+// service allows ends with a marker, with no operation and the number
+// of operations left out in Truncated.
+type TraceStep struct {
+	Index      int     `json:"index"` // position in the trace, which is that in the batch for batches
+	Operation  string  `json:"operation,omitempty"`
+	A          int     `json:"a"`
+	B          int     `json:"b"`
+	Result     int     `json:"result"`
+	Code       string  `json:"code,omitempty"` // error code of a failed operation
+	DurationMS float64 `json:"duration_ms"`
+	Truncated  int     `json:"truncated,omitempty"`
+}
+
+// PrecisionLossDetail describes the result strict mode rejected with
+// CodePrecisionLoss, for clients to recover: from the remainder of a
+// division, or by computing in floating point
+type PrecisionLossDetail struct {
+	Result    int     `json:"result"`              // the truncated or wrapped result outside strict mode
+	Remainder int     `json:"remainder,omitempty"` // of a division: a = Result*b + Remainder
+	Overflow  bool    `json:"overflow,omitempty"`  // whether the exact result overflows int
+	Float     float64 `json:"float"`               // the result in floating point
+}
+
+// Step is a step of an explained calculation, as described by
+// calculator.Step
+type Step struct {
+	Description string `json:"description"`
+	Value       int    `json:"value"` // the value the step gave
+}
+
+// OperationsResponse is the response of GET /operations. Operations
+// are sorted by name.
+type OperationsResponse struct {
+	Operations []OperationInfo `json:"operations"`
+}
+
+// OperationInfo describes an operation accepted by POST /calculate.
+// Arity is "unary", "binary" or "variadic" and OperandType "int",
+// "float" or "string".
+type OperationInfo struct {
+	Name        string   `json:"name"`
+	Aliases     []string `json:"aliases"`
+	Arity       string   `json:"arity"`
+	OperandType string   `json:"operand_type"`
+	Description string   `json:"description"`
+}
+
+// Health statuses reported by GET /health/detail
+const (
+	// HealthHealthy means every check passed
+	HealthHealthy = "healthy"
+	// HealthDegraded means a non-critical check failed; the service
+	// still answers calculations
+	HealthDegraded = "degraded"
+	// HealthUnhealthy means a critical check failed
+	HealthUnhealthy = "unhealthy"
+)
+
+// HealthReport is the response of GET /health/detail. Checks are sorted
+// by name.
+type HealthReport struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// CheckResult is the outcome of one dependency check. Status is
+// HealthHealthy, or HealthUnhealthy with the reason in Error.
+type CheckResult struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
@@ -0,0 +1,43 @@
+package api
+
+import "strconv"
+
+// API versions. A client asks for a version with the Accept header and
+// the service names the one it answered with in VersionHeader; services
+// that do not send the header speak Version1.
+const (
+	Version1 = 1
+	Version2 = 2
+)
+
+// VersionHeader is the response header naming the API version of the
+// body
+const VersionHeader = "API-Version"
+
+// Media types asking for each API version in the Accept header
+const (
+	MediaTypeV1 = "application/json"
+	MediaTypeV2 = "application/vnd.calc.v2+json"
+)
+
+// MediaType returns the media type asking for version, or MediaTypeV1
+// for unknown versions
+func MediaType(version int) string {
+	if version == Version2 {
+		return MediaTypeV2
+	}
+	return MediaTypeV1
+}
+
+// ParseVersion parses the value of VersionHeader. An empty value is
+// Version1; values naming no known version are not ok.
+func ParseVersion(header string) (version int, ok bool) {
+	if header == "" {
+		return Version1, true
+	}
+	v, err := strconv.Atoi(header)
+	if err != nil || (v != Version1 && v != Version2) {
+		return 0, false
+	}
+	return v, true
+}
@@ -0,0 +1,246 @@
+package calcclient
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without contacting the service while the
+// circuit breaker is open, or half-open with every probe in flight
+var ErrCircuitOpen = errors.New("calcclient: circuit breaker is open")
+
+// Defaults used by NewBreaker
+const (
+	DefaultFailureThreshold = 5
+	DefaultOpenDuration     = 10 * time.Second
+	DefaultHalfOpenProbes   = 1
+)
+
+// State is the state of a Breaker
+type State int
+
+// Breaker states
+const (
+	// StateClosed lets every call through
+	StateClosed State = iota
+	// StateOpen fails every call with ErrCircuitOpen
+	StateOpen
+	// StateHalfOpen lets a limited number of probe calls through
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// BreakerOption configures a Breaker created by NewBreaker
+type BreakerOption func(*Breaker)
+
+// WithFailureThreshold opens the breaker after n consecutive transport
+// failures
+func WithFailureThreshold(n int) BreakerOption {
+	return func(b *Breaker) {
+		b.threshold = n
+	}
+}
+
+// WithOpenDuration sets how long the breaker stays open before letting
+// probes through
+func WithOpenDuration(d time.Duration) BreakerOption {
+	return func(b *Breaker) {
+		b.openFor = d
+	}
+}
+
+// WithHalfOpenProbes sets how many probes may be in flight while
+// half-open, and how many must succeed to close the breaker again
+func WithHalfOpenProbes(n int) BreakerOption {
+	return func(b *Breaker) {
+		b.probes = n
+	}
+}
+
+// WithStateChange calls fn after every state transition, for logging or
+// metrics. fn runs on the goroutine of the call that caused the
+// transition, without the breaker's lock held; transitions caused by
+// concurrent calls may be reported out of order.
+func WithStateChange(fn func(from, to State)) BreakerOption {
+	return func(b *Breaker) {
+		b.onChange = fn
+	}
+}
+
+// WithBreakerClock replaces time.Now, so tests can drive the cooldown
+func WithBreakerClock(now func() time.Time) BreakerOption {
+	return func(b *Breaker) {
+		b.now = now
+	}
+}
+
+// Breaker is a circuit breaker for calls to the service. After a number
+// of consecutive transport failures it opens and fails calls fast with
+// ErrCircuitOpen. Once the open duration has passed it turns half-open
+// and lets probes through: as many successful probes as allowed in
+// flight close it, and a failed one opens it again. It is safe for
+// concurrent use and may be shared by several Clients.
+type Breaker struct {
+	threshold int
+	openFor   time.Duration
+	probes    int
+	onChange  func(from, to State)
+	now       func() time.Time
+
+	mu         sync.Mutex
+	state      State
+	generation uint64 // incremented on every transition
+	failures   int    // consecutive failures while closed
+	openedAt   time.Time
+	inFlight   int // probes in flight while half-open
+	successes  int // successful probes while half-open
+}
+
+// NewBreaker returns a closed Breaker
+func NewBreaker(opts ...BreakerOption) *Breaker {
+	b := &Breaker{
+		threshold: DefaultFailureThreshold,
+		openFor:   DefaultOpenDuration,
+		probes:    DefaultHalfOpenProbes,
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	if b.threshold < 1 {
+		b.threshold = 1
+	}
+	if b.probes < 1 {
+		b.probes = 1
+	}
+	return b
+}
+
+// State returns the current state. An open breaker whose open duration
+// has passed reports StateHalfOpen.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	from := b.state
+	b.advance()
+	to := b.state
+	b.mu.Unlock()
+	b.notify(from, to)
+	return to
+}
+
+// outcome is how a call admitted by allow ended
+type outcome int
+
+const (
+	succeeded outcome = iota
+	failed
+	ignored // neither, such as a call cancelled by the caller
+)
+
+// allow admits a call, or returns ErrCircuitOpen. The returned func must
+// be called once with the outcome of an admitted call.
+func (b *Breaker) allow() (func(outcome), error) {
+	b.mu.Lock()
+	from := b.state
+	b.advance()
+	switch b.state {
+	case StateOpen:
+		b.mu.Unlock()
+		b.notify(from, StateOpen)
+		return nil, ErrCircuitOpen
+	case StateHalfOpen:
+		if b.inFlight >= b.probes {
+			b.mu.Unlock()
+			b.notify(from, StateHalfOpen)
+			return nil, ErrCircuitOpen
+		}
+		b.inFlight++
+	}
+	to, generation := b.state, b.generation
+	b.mu.Unlock()
+	b.notify(from, to)
+
+	var once sync.Once
+	return func(o outcome) {
+		once.Do(func() { b.done(generation, o) })
+	}, nil
+}
+
+// done records the outcome of a call admitted in generation. Outcomes of
+// calls admitted before the last transition are ignored.
+func (b *Breaker) done(generation uint64, o outcome) {
+	b.mu.Lock()
+	from := b.state
+	if generation != b.generation {
+		b.mu.Unlock()
+		return
+	}
+	switch b.state {
+	case StateClosed:
+		switch o {
+		case succeeded:
+			b.failures = 0
+		case failed:
+			b.failures++
+			if b.failures >= b.threshold {
+				b.open()
+			}
+		}
+	case StateHalfOpen:
+		b.inFlight--
+		switch o {
+		case succeeded:
+			b.successes++
+			if b.successes >= b.probes {
+				b.transition(StateClosed)
+			}
+		case failed:
+			b.open()
+		}
+	}
+	to := b.state
+	b.mu.Unlock()
+	b.notify(from, to)
+}
+
+// advance turns an open breaker half-open once its open duration has
+// passed. b.mu must be held.
+func (b *Breaker) advance() {
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.openFor {
+		b.transition(StateHalfOpen)
+	}
+}
+
+// open moves to StateOpen. b.mu must be held.
+func (b *Breaker) open() {
+	b.openedAt = b.now()
+	b.transition(StateOpen)
+}
+
+// transition moves to state and resets the counters. b.mu must be held.
+func (b *Breaker) transition(state State) {
+	b.state = state
+	b.generation++
+	b.failures = 0
+	b.inFlight = 0
+	b.successes = 0
+}
+
+// notify reports a transition to the state change callback, if any
+func (b *Breaker) notify(from, to State) {
+	if from != to && b.onChange != nil {
+		b.onChange(from, to)
+	}
+}
@@ -0,0 +1,297 @@
+package calcclient_test
+
+import (
+	"context"
+	"errors"
+	"go-examples/pkg/calcclient"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// scriptedServer answers calculations until down is set, and then drops
+// connections without a response, like a service that has gone away
+type scriptedServer struct {
+	down  atomic.Bool
+	hits  atomic.Int32
+	block atomic.Pointer[chan struct{}] // when set, requests wait for it to close
+	URL   string
+}
+
+func newScriptedServer(t *testing.T) *scriptedServer {
+	t.Helper()
+	s := &scriptedServer{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.hits.Add(1)
+		if block := s.block.Load(); block != nil {
+			<-*block
+		}
+		if s.down.Load() {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		if r.URL.Path == "/calculate" {
+			_, _ = w.Write([]byte(`{"result":2,"success":true}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":true}`))
+	}))
+	t.Cleanup(srv.Close)
+	s.URL = srv.URL
+	return s
+}
+
+// fakeClock is a settable clock for WithBreakerClock
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// transitions records state changes reported by WithStateChange
+type transitions struct {
+	mu  sync.Mutex
+	got []string
+}
+
+func (tr *transitions) record(from, to calcclient.State) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.got = append(tr.got, from.String()+"->"+to.String())
+}
+
+func (tr *transitions) String() string {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	return strings.Join(tr.got, " ")
+}
+
+// newBreakerClient returns a Client for srv with a breaker opening after
+// three failures for ten seconds of clock time
+func newBreakerClient(srv *scriptedServer, clock *fakeClock, tr *transitions, probes int) (*calcclient.Client, *calcclient.Breaker) {
+	b := calcclient.NewBreaker(
+		calcclient.WithFailureThreshold(3),
+		calcclient.WithOpenDuration(10*time.Second),
+		calcclient.WithHalfOpenProbes(probes),
+		calcclient.WithBreakerClock(clock.Now),
+		calcclient.WithStateChange(tr.record),
+	)
+	return calcclient.New(srv.URL, calcclient.WithCircuitBreaker(b)), b
+}
+
+// TestBreakerOpensAndRecovers tests that the breaker opens after the
+// threshold, fails fast during the cooldown and closes after a probe
+// succeeds
+func TestBreakerOpensAndRecovers(t *testing.T) {
+	srv := newScriptedServer(t)
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	tr := &transitions{}
+	client, b := newBreakerClient(srv, clock, tr, 1)
+	ctx := context.Background()
+
+	srv.down.Store(true)
+	for i := 0; i < 3; i++ {
+		if _, err := client.Calculate(ctx, "add", 1, 1); err == nil || errors.Is(err, calcclient.ErrCircuitOpen) {
+			t.Fatalf("call %d: got %v, want a transport error", i, err)
+		}
+	}
+	if _, err := client.Calculate(ctx, "add", 1, 1); !errors.Is(err, calcclient.ErrCircuitOpen) {
+		t.Fatalf("got %v, want ErrCircuitOpen", err)
+	}
+	if err := client.Health(ctx); !errors.Is(err, calcclient.ErrCircuitOpen) {
+		t.Fatalf("Health got %v, want ErrCircuitOpen", err)
+	}
+	if hits := srv.hits.Load(); hits != 3 {
+		t.Errorf("server saw %d requests, want 3", hits)
+	}
+
+	// Still open just before the cooldown ends
+	clock.Advance(10*time.Second - time.Millisecond)
+	if b.State() != calcclient.StateOpen {
+		t.Fatalf("state = %s before the cooldown ended, want open", b.State())
+	}
+
+	srv.down.Store(false)
+	clock.Advance(time.Millisecond)
+	if got, err := client.Calculate(ctx, "add", 1, 1); err != nil || got != 2 {
+		t.Fatalf("probe got %d, %v", got, err)
+	}
+	if b.State() != calcclient.StateClosed {
+		t.Errorf("state = %s after a successful probe, want closed", b.State())
+	}
+	if want := "closed->open open->half-open half-open->closed"; tr.String() != want {
+		t.Errorf("transitions = %q, want %q", tr.String(), want)
+	}
+}
+
+// TestBreakerProbeFails tests that a failed probe reopens the breaker
+// for another full cooldown
+func TestBreakerProbeFails(t *testing.T) {
+	srv := newScriptedServer(t)
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	tr := &transitions{}
+	client, b := newBreakerClient(srv, clock, tr, 1)
+	ctx := context.Background()
+
+	srv.down.Store(true)
+	for i := 0; i < 3; i++ {
+		_, _ = client.Calculate(ctx, "add", 1, 1)
+	}
+	clock.Advance(10 * time.Second)
+	if _, err := client.Calculate(ctx, "add", 1, 1); err == nil || errors.Is(err, calcclient.ErrCircuitOpen) {
+		t.Fatalf("probe got %v, want a transport error", err)
+	}
+	if b.State() != calcclient.StateOpen {
+		t.Fatalf("state = %s after a failed probe, want open", b.State())
+	}
+
+	clock.Advance(5 * time.Second)
+	if _, err := client.Calculate(ctx, "add", 1, 1); !errors.Is(err, calcclient.ErrCircuitOpen) {
+		t.Errorf("got %v during the second cooldown, want ErrCircuitOpen", err)
+	}
+	if want := "closed->open open->half-open half-open->open"; tr.String() != want {
+		t.Errorf("transitions = %q, want %q", tr.String(), want)
+	}
+}
+
+// TestBreakerCountsTransportFailuresOnly tests that API errors and
+// cancelled calls do not count, and that a success resets the count
+func TestBreakerCountsTransportFailuresOnly(t *testing.T) {
+	client, b := newBreakerClient(newScriptedServer(t), &fakeClock{}, &transitions{}, 1)
+	fail := newScriptedServer(t)
+	fail.down.Store(true)
+	failing := calcclient.New(fail.URL, calcclient.WithCircuitBreaker(b))
+
+	ctx := context.Background()
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	for i := 0; i < 2; i++ {
+		_, _ = failing.Calculate(ctx, "add", 1, 1)
+	}
+	for i := 0; i < 5; i++ {
+		_, _ = failing.Calculate(cancelled, "add", 1, 1)
+	}
+	if b.State() != calcclient.StateClosed {
+		t.Fatalf("state = %s after two failures and cancelled calls, want closed", b.State())
+	}
+
+	// A success resets the consecutive count
+	if _, err := client.Calculate(ctx, "add", 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		_, _ = failing.Calculate(ctx, "add", 1, 1)
+	}
+	if b.State() != calcclient.StateClosed {
+		t.Errorf("state = %s, want closed after the count was reset", b.State())
+	}
+}
+
+// TestBreakerHalfOpenProbeLimit tests that only the configured number of
+// probes reach the service while half-open
+func TestBreakerHalfOpenProbeLimit(t *testing.T) {
+	srv := newScriptedServer(t)
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	client, b := newBreakerClient(srv, clock, &transitions{}, 2)
+	ctx := context.Background()
+
+	srv.down.Store(true)
+	for i := 0; i < 3; i++ {
+		_, _ = client.Calculate(ctx, "add", 1, 1)
+	}
+	srv.down.Store(false)
+	srv.hits.Store(0)
+	block := make(chan struct{})
+	srv.block.Store(&block)
+	clock.Advance(10 * time.Second)
+
+	const callers = 20
+	errs := make(chan error, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.Calculate(ctx, "add", 1, 1)
+			errs <- err
+		}()
+	}
+
+	// The rejected callers return while the probes wait on the server
+	for i := 0; i < callers-2; i++ {
+		if err := <-errs; !errors.Is(err, calcclient.ErrCircuitOpen) {
+			t.Errorf("got %v, want ErrCircuitOpen", err)
+		}
+	}
+	close(block)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("probe failed: %v", err)
+		}
+	}
+	if hits := srv.hits.Load(); hits != 2 {
+		t.Errorf("server saw %d probes, want 2", hits)
+	}
+	if b.State() != calcclient.StateClosed {
+		t.Errorf("state = %s after both probes succeeded, want closed", b.State())
+	}
+}
+
+// TestBreakerConcurrent hammers the breaker from many goroutines while the
+// service flaps and the clock moves, for the race detector
+func TestBreakerConcurrent(t *testing.T) {
+	srv := newScriptedServer(t)
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	tr := &transitions{}
+	client, b := newBreakerClient(srv, clock, tr, 2)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				_, _ = client.Calculate(ctx, "add", 1, 1)
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		srv.down.Store(i%2 == 0)
+		clock.Advance(5 * time.Second)
+		_ = b.State()
+		time.Sleep(time.Millisecond)
+	}
+	srv.down.Store(false)
+	wg.Wait()
+
+	// Once the service is back, the breaker settles to closed
+	clock.Advance(time.Minute)
+	for i := 0; i < 3 && b.State() != calcclient.StateClosed; i++ {
+		_, _ = client.Calculate(ctx, "add", 1, 1)
+	}
+	if b.State() != calcclient.StateClosed {
+		t.Errorf("state = %s after recovery, want closed (transitions %s)", b.State(), tr.String())
+	}
+}
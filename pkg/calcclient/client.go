@@ -0,0 +1,485 @@
+// Package calcclient is a Go client for the calculator service API.
+package calcclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-examples/internal/buildinfo"
+	"go-examples/pkg/api"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/logger"
+	"go-examples/pkg/slogger"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTimeout bounds each request unless WithTimeout or WithHTTPClient
+// says otherwise
+const DefaultTimeout = 5 * time.Second
+
+// Client calls a calculator service. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	http       *http.Client
+	transport  *http.Transport // owned by the Client; unused with WithHTTPClient
+	userAgent  string
+	language   string
+	apiKey     string
+	breaker    *Breaker
+	local      *calculator.Calculator // computes calculations the service cannot be reached for
+	transcript *TranscriptWriter      // records every exchange, if set
+
+	v1Only  atomic.Bool  // the service answered 406 to a v2 request
+	version atomic.Int32 // API version of the last response
+
+	connsOpened atomic.Uint64
+	connsReused atomic.Uint64
+	dnsLookups  atomic.Uint64
+}
+
+// Option configures a Client created by New
+type Option func(*Client)
+
+// WithTimeout sets the timeout of each request
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.http.Timeout = d
+	}
+}
+
+// WithHTTPClient sends requests through hc instead of a client owned by
+// the Client. The transport options, such as WithMaxConnsPerHost, then
+// have no effect.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.http = hc
+	}
+}
+
+// WithMaxConnsPerHost limits the connections to the service, counting
+// those dialing, active and idle. Requests over the limit wait for a
+// connection. Zero, the default, means no limit.
+func WithMaxConnsPerHost(n int) Option {
+	return func(c *Client) {
+		c.transport.MaxConnsPerHost = n
+	}
+}
+
+// WithMaxIdleConnsPerHost sets how many idle connections to the service
+// are kept for reuse. The net/http default of 2 makes concurrent callers
+// open and close a connection for most requests; set it to the expected
+// concurrency. A negative n disables pooling.
+func WithMaxIdleConnsPerHost(n int) Option {
+	return func(c *Client) {
+		if n < 0 {
+			c.transport.DisableKeepAlives = true
+			return
+		}
+		c.transport.MaxIdleConnsPerHost = n
+		if c.transport.MaxIdleConns != 0 && c.transport.MaxIdleConns < n {
+			c.transport.MaxIdleConns = n
+		}
+	}
+}
+
+// WithIdleConnTimeout sets how long an idle connection is kept before it
+// is closed
+func WithIdleConnTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.transport.IdleConnTimeout = d
+	}
+}
+
+// WithDisableCompression stops the Client from asking for gzip-encoded
+// responses, which saves CPU for the small responses of the service
+func WithDisableCompression() Option {
+	return func(c *Client) {
+		c.transport.DisableCompression = true
+	}
+}
+
+// WithForceHTTP2 sends requests over HTTP/2: over TLS for https URLs, and
+// as unencrypted HTTP/2 (h2c) with prior knowledge for http URLs. The
+// service must accept h2c, as calcserver.WithUnencryptedHTTP2 does;
+// requests to one that only speaks HTTP/1 fail.
+func WithForceHTTP2() Option {
+	return func(c *Client) {
+		var protocols http.Protocols
+		protocols.SetHTTP2(true)
+		protocols.SetUnencryptedHTTP2(true)
+		c.transport.Protocols = &protocols
+		c.transport.ForceAttemptHTTP2 = true
+	}
+}
+
+// WithUserAgent sets the User-Agent header of every request. The default
+// is calcclient/<version>.
+func WithUserAgent(ua string) Option {
+	return func(c *Client) {
+		c.userAgent = ua
+	}
+}
+
+// WithLanguage asks the service for error messages in lang, a language
+// tag such as "de" sent in the Accept-Language header. Error codes are
+// the same in every language.
+func WithLanguage(lang string) Option {
+	return func(c *Client) {
+		c.language = lang
+	}
+}
+
+// WithAPIKey authenticates every request with key as a bearer token, as
+// services started with API keys require
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// WithCircuitBreaker sends every request through b, so that after
+// repeated transport failures requests fail fast with ErrCircuitOpen
+// instead of reaching a service that is down. Responses with any HTTP
+// status, including errors, count as successes.
+func WithCircuitBreaker(b *Breaker) Option {
+	return func(c *Client) {
+		c.breaker = b
+	}
+}
+
+// WithLocalFallback computes calculations with this build's calculator,
+// logging to log, when the service cannot be reached, including while the
+// circuit breaker is open. Errors reported by the service are never
+// replaced, and operations the local calculator does not know return the
+// transport error. CalculateWithSource tells which results are local.
+func WithLocalFallback(log logger.Logger) Option {
+	return func(c *Client) {
+		c.local = calculator.NewCalculator(log)
+	}
+}
+
+// New creates a Client for the service at baseURL, such as
+// "http://localhost:8080"
+func New(baseURL string, opts ...Option) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	c := &Client{
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		http:      &http.Client{Timeout: DefaultTimeout, Transport: transport},
+		transport: transport,
+		userAgent: "calcclient/" + buildinfo.Get().Version,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Stats counts the connection activity of a Client
+type Stats struct {
+	ConnsOpened uint64 // requests sent on a new connection
+	ConnsReused uint64 // requests sent on a pooled connection
+	DNSLookups  uint64 // host name lookups
+}
+
+// trace returns a ClientTrace updating the Stats counters. A new one is
+// needed per request, as httptrace.WithClientTrace chains the hooks of a
+// trace already in the context into it.
+func (c *Client) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				c.connsReused.Add(1)
+			} else {
+				c.connsOpened.Add(1)
+			}
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			c.dnsLookups.Add(1)
+		},
+	}
+}
+
+// Stats returns the connection counters since the Client was created
+func (c *Client) Stats() Stats {
+	return Stats{
+		ConnsOpened: c.connsOpened.Load(),
+		ConnsReused: c.connsReused.Load(),
+		DNSLookups:  c.dnsLookups.Load(),
+	}
+}
+
+// requestIDKey is the context key for the ID set by WithRequestID
+type requestIDKey struct{}
+
+// WithRequestID returns a context that makes requests sent with it carry
+// id in the X-Request-ID header, so the service logs and reports that ID
+// instead of generating one
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// Source tells where a calculation was performed
+type Source string
+
+const (
+	SourceService Source = "service"
+	SourceLocal   Source = "local" // see WithLocalFallback
+)
+
+// Calculate performs operation on a and b. Errors reported by the service
+// are returned as *api.APIError, so callers can match them with
+// errors.Is against the api sentinels.
+func (c *Client) Calculate(ctx context.Context, operation string, a, b int) (int, error) {
+	result, _, err := c.CalculateWithSource(ctx, operation, a, b)
+	return result, err
+}
+
+// CalculateWithSource is Calculate, also returning where the result was
+// computed. Errors of local calculations are *api.APIError too.
+func (c *Client) CalculateWithSource(ctx context.Context, operation string, a, b int) (int, Source, error) {
+	body, err := json.Marshal(api.CalculationRequest{Operation: operation, A: a, B: b})
+	if err != nil {
+		return 0, SourceService, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	var resp api.CalculationResponse
+	if err := c.do(ctx, "POST", "/calculate", body, &resp); err != nil {
+		if c.local == nil || !isTransportError(ctx, err) {
+			return 0, SourceService, err
+		}
+		if _, ok := calculator.LookupOperation(operation); !ok {
+			return 0, SourceService, err
+		}
+		result, lerr := c.local.Compute(operation, a, b)
+		if lerr != nil {
+			return 0, SourceLocal, api.FromCalculatorError(lerr)
+		}
+		return result, SourceLocal, nil
+	}
+	if !resp.Success {
+		return 0, SourceService, &api.APIError{Code: resp.Code, Message: resp.Error, HTTPStatus: http.StatusOK, RequestID: resp.RequestID}
+	}
+	return resp.Result, SourceService, nil
+}
+
+// CalculateFloat performs operation on a and b in float mode. Errors are
+// returned as by Calculate, and the local fallback, if any, computes in
+// float mode too. NaN and infinite operands fail to marshal, as JSON has
+// no numbers for them.
+func (c *Client) CalculateFloat(ctx context.Context, operation string, a, b float64) (float64, error) {
+	body, err := json.Marshal(api.FloatCalculationRequest{Operation: operation, A: a, B: b, Mode: api.ModeFloat})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	var resp api.FloatCalculationResponse
+	if err := c.do(ctx, "POST", "/calculate", body, &resp); err != nil {
+		if c.local == nil || !isTransportError(ctx, err) {
+			return 0, err
+		}
+		if _, ok := calculator.LookupOperation(operation); !ok {
+			return 0, err
+		}
+		result, lerr := c.local.ComputeFloat(operation, a, b)
+		if lerr != nil {
+			return 0, api.FromCalculatorError(lerr)
+		}
+		return result, nil
+	}
+	return resp.Result, nil
+}
+
+// CalculateBig performs operation on the decimal integers a and b in
+// big mode, for values of any size. Errors are returned as by Calculate,
+// and the local fallback, if any, computes in big mode too.
+func (c *Client) CalculateBig(ctx context.Context, operation string, a, b string) (string, error) {
+	body, err := json.Marshal(api.BigCalculationRequest{Operation: operation, A: a, B: b, Mode: api.ModeBig})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	var resp api.BigCalculationResponse
+	if err := c.do(ctx, "POST", "/calculate", body, &resp); err != nil {
+		if c.local == nil || !isTransportError(ctx, err) {
+			return "", err
+		}
+		if _, ok := calculator.LookupOperation(operation); !ok {
+			return "", err
+		}
+		result, lerr := c.local.ComputeBig(operation, a, b)
+		if lerr != nil {
+			return "", api.FromCalculatorError(lerr)
+		}
+		return result, nil
+	}
+	return resp.Result, nil
+}
+
+// isTransportError reports whether err means the service could not be
+// reached, as opposed to an answer from it or the caller giving up
+func isTransportError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) || errors.Is(err, ErrCircuitOpen)
+}
+
+// healthResponse is the response of GET /health
+type healthResponse struct {
+	Status bool `json:"status"`
+}
+
+// Health returns nil when the service reports itself healthy
+func (c *Client) Health(ctx context.Context) error {
+	var resp healthResponse
+	if err := c.do(ctx, "GET", "/health", nil, &resp); err != nil {
+		return err
+	}
+	if !resp.Status {
+		return fmt.Errorf("service reported unhealthy status")
+	}
+	return nil
+}
+
+// Operations lists the operations the service accepts, sorted by name
+func (c *Client) Operations(ctx context.Context) ([]api.OperationInfo, error) {
+	var resp api.OperationsResponse
+	if err := c.do(ctx, "GET", "/operations", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Operations, nil
+}
+
+// APIVersion returns the API version the service answered the last
+// request with, or 0 before the first response. The Client asks for
+// api.Version2 and falls back to api.Version1 when the service answers
+// 406 Not Acceptable; services that do not send api.VersionHeader speak
+// api.Version1.
+func (c *Client) APIVersion() int {
+	return int(c.version.Load())
+}
+
+// do sends a request, negotiating the API version, and decodes a
+// successful JSON response into out, checking it against the schema of
+// that version
+func (c *Client) do(ctx context.Context, method, path string, body []byte, out interface{}) error {
+	resp, data, err := c.exchange(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+
+	header := resp.Header.Get(api.VersionHeader)
+	served, ok := api.ParseVersion(header)
+	if !ok {
+		return &SchemaError{Field: api.VersionHeader + " header", Reason: fmt.Sprintf("names unknown version %q", header)}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return api.ParseError(resp.StatusCode, data)
+	}
+	return decodeStrict(data, out, served)
+}
+
+// exchange sends a request, negotiating the API version, and reads the
+// response, recording both in the transcript, if any
+func (c *Client) exchange(ctx context.Context, method, path string, body []byte) (resp *http.Response, data []byte, err error) {
+	if c.transcript != nil {
+		start := time.Now()
+		defer func() {
+			c.transcript.Record(newExchange(ctx, start, method, path, body, resp, data, err))
+		}()
+	}
+
+	version := api.Version2
+	if c.v1Only.Load() {
+		version = api.Version1
+	}
+	resp, data, err = c.roundTrip(ctx, method, path, body, version)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode == http.StatusNotAcceptable && version == api.Version2 {
+		c.v1Only.Store(true)
+		if resp, data, err = c.roundTrip(ctx, method, path, body, api.Version1); err != nil {
+			return nil, nil, err
+		}
+	}
+	if served, ok := api.ParseVersion(resp.Header.Get(api.VersionHeader)); ok {
+		c.version.Store(int32(served))
+	}
+	return resp, data, nil
+}
+
+// roundTrip sends a request asking for version and reads the response
+func (c *Client) roundTrip(ctx context.Context, method, path string, body []byte, version int) (*http.Response, []byte, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, c.trace()), method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("Accept", api.MediaType(version))
+	if c.language != "" {
+		req.Header.Set("Accept-Language", c.language)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+		req.Header.Set(slogger.RequestIDHeader, id)
+	}
+
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return resp, data, nil
+}
+
+// send sends req through the circuit breaker, if any. Failures caused by
+// the caller cancelling ctx are not held against the service.
+func (c *Client) send(ctx context.Context, req *http.Request) (*http.Response, error) {
+	if c.breaker == nil {
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("request failed: %w", err)
+		}
+		return resp, nil
+	}
+
+	done, err := c.breaker.allow()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	switch {
+	case err == nil:
+		done(succeeded)
+		return resp, nil
+	case ctx.Err() != nil:
+		done(ignored)
+	default:
+		done(failed)
+	}
+	return nil, fmt.Errorf("request failed: %w", err)
+}
@@ -0,0 +1,157 @@
+package calcclient_test
+
+import (
+	"context"
+	"errors"
+	"go-examples/internal/buildinfo"
+	"go-examples/pkg/api"
+	"go-examples/pkg/calcclient"
+	"go-examples/pkg/calcserver"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/calculator/proptest"
+	"go-examples/pkg/logger"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// newClient starts a calcserver and returns a Client for it
+func newClient(t *testing.T) *calcclient.Client {
+	t.Helper()
+	log, _ := logger.NewObserved(zapcore.DebugLevel)
+	srv := httptest.NewServer(calcserver.New(calculator.NewCalculator(log), log).Handler())
+	t.Cleanup(srv.Close)
+	return calcclient.New(srv.URL)
+}
+
+// TestCalculate tests results and typed errors returned by the service
+func TestCalculate(t *testing.T) {
+	client := newClient(t)
+	tests := []struct {
+		name      string
+		operation string
+		a, b      int
+		want      int
+		wantErr   error
+	}{
+		{name: "add", operation: "add", a: 5, b: 3, want: 8},
+		{name: "divide", operation: "divide", a: 10, b: 2, want: 5},
+		{name: "divide by zero", operation: "divide", a: 10, b: 0, wantErr: api.ErrDivisionByZero},
+		{name: "unknown operation", operation: "modulo", a: 1, b: 1, wantErr: api.ErrUnknownOperation},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := client.Calculate(context.Background(), tc.operation, tc.a, tc.b)
+			if tc.wantErr != nil {
+				var apiErr *api.APIError
+				if !errors.As(err, &apiErr) || !errors.Is(err, tc.wantErr) {
+					t.Fatalf("Calculate error = %v, want %v", err, tc.wantErr)
+				}
+				if apiErr.HTTPStatus != http.StatusBadRequest {
+					t.Errorf("HTTPStatus = %d, want %d", apiErr.HTTPStatus, http.StatusBadRequest)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Calculate failed: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Calculate = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestCalculateFloat tests float mode results and errors, and the local
+// fallback in float mode
+func TestCalculateFloat(t *testing.T) {
+	ctx := context.Background()
+	client := newClient(t)
+	if got, err := client.CalculateFloat(ctx, "divide", 7, 2); err != nil || got != 3.5 {
+		t.Errorf("CalculateFloat(divide, 7, 2) = %g, %v, want 3.5", got, err)
+	}
+	if _, err := client.CalculateFloat(ctx, "divide", 7, 0); !errors.Is(err, api.ErrDivisionByZero) {
+		t.Errorf("CalculateFloat(divide, 7, 0) error = %v, want DIVISION_BY_ZERO", err)
+	}
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	log, _ := logger.NewObserved(zapcore.DebugLevel)
+	local := calcclient.New(srv.URL, calcclient.WithLocalFallback(log))
+	if got, err := local.CalculateFloat(ctx, "pow", 2, 0.5); err != nil || !proptest.AlmostEqual(got, math.Sqrt2, 1e-12) {
+		t.Errorf("local CalculateFloat(pow, 2, 0.5) = %g, %v, want %g", got, err, math.Sqrt2)
+	}
+}
+
+// TestCalculateBig tests big mode results and errors
+func TestCalculateBig(t *testing.T) {
+	ctx := context.Background()
+	client := newClient(t)
+	if got, err := client.CalculateBig(ctx, "add", "18446744073709551615", "1"); err != nil || got != "18446744073709551616" {
+		t.Errorf("CalculateBig(add, 2^64-1, 1) = %s, %v, want 18446744073709551616", got, err)
+	}
+	if _, err := client.CalculateBig(ctx, "divide", "1", "x"); !errors.Is(err, api.ErrInvalidRequest) {
+		t.Errorf("CalculateBig(divide, 1, x) error = %v, want INVALID_REQUEST", err)
+	}
+}
+
+// TestOperations tests that the client lists every operation of the
+// calculator
+func TestOperations(t *testing.T) {
+	ops, err := newClient(t).Operations(context.Background())
+	if err != nil {
+		t.Fatalf("Operations failed: %v", err)
+	}
+	want := calculator.Describe()
+	if len(ops) != len(want) {
+		t.Fatalf("Operations returned %d operations, want %d", len(ops), len(want))
+	}
+	for i, op := range ops {
+		if op.Name != want[i].Name || op.Arity != string(want[i].Arity) || op.Description == "" {
+			t.Errorf("Operations[%d] = %+v, want %s", i, op, want[i].Name)
+		}
+	}
+}
+
+// TestHealth tests the health check against a running and a failing service
+func TestHealth(t *testing.T) {
+	if err := newClient(t).Health(context.Background()); err != nil {
+		t.Errorf("Health failed: %v", err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	err := calcclient.New(srv.URL).Health(context.Background())
+	if !errors.Is(err, api.ErrInternal) {
+		t.Errorf("Health error = %v, want INTERNAL", err)
+	}
+}
+
+// TestUserAgent tests the default and custom User-Agent headers
+func TestUserAgent(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("User-Agent")
+		_, _ = w.Write([]byte(`{"status":true}`))
+	}))
+	defer srv.Close()
+
+	if err := calcclient.New(srv.URL).Health(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := "calcclient/" + buildinfo.Get().Version; got != want {
+		t.Errorf("User-Agent = %q, want %q", got, want)
+	}
+
+	if err := calcclient.New(srv.URL, calcclient.WithUserAgent("batch-job/1.0")).Health(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got != "batch-job/1.0" {
+		t.Errorf("User-Agent = %q, want batch-job/1.0", got)
+	}
+}
@@ -0,0 +1,101 @@
+package calcclient_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"go-examples/pkg/api"
+	"go-examples/pkg/calcclient"
+	"go-examples/pkg/calcserver"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/logger"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// serverOnly adds a "power" operation, which the local calculator does
+// not have, in front of the calcserver handler next
+func serverOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req api.CalculationRequest
+		if r.URL.Path == "/calculate" && json.Unmarshal(body, &req) == nil && req.Operation == "power" {
+			result := 1
+			for i := 0; i < req.B; i++ {
+				result *= req.A
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(api.CalculationResponse{Result: result, Success: true})
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// TestLocalFallback tests that once the service is gone, calculations
+// the local calculator knows are computed locally and others fail
+func TestLocalFallback(t *testing.T) {
+	log, _ := logger.NewObserved(zapcore.DebugLevel)
+	srv := httptest.NewServer(serverOnly(calcserver.New(calculator.NewCalculator(log), log).Handler()))
+	defer srv.Close()
+	client := calcclient.New(srv.URL, calcclient.WithLocalFallback(log))
+	ctx := context.Background()
+
+	for _, op := range []string{"add", "power"} {
+		if _, source, err := client.CalculateWithSource(ctx, op, 2, 3); err != nil || source != calcclient.SourceService {
+			t.Fatalf("%s before shutdown = %s, %v, want a result from the service", op, source, err)
+		}
+	}
+
+	srv.Close()
+	result, source, err := client.CalculateWithSource(ctx, "add", 2, 3)
+	if err != nil || result != 5 || source != calcclient.SourceLocal {
+		t.Errorf("add after shutdown = %d, %s, %v, want 5 computed locally", result, source, err)
+	}
+	var urlErr *url.Error
+	if _, _, err := client.CalculateWithSource(ctx, "power", 2, 3); !errors.As(err, &urlErr) {
+		t.Errorf("power after shutdown error = %v, want the transport error", err)
+	}
+	if _, source, err := client.CalculateWithSource(ctx, "divide", 1, 0); !errors.Is(err, api.ErrDivisionByZero) || source != calcclient.SourceLocal {
+		t.Errorf("divide by zero after shutdown = %s, %v, want a local ErrDivisionByZero", source, err)
+	}
+}
+
+// TestLocalFallbackAPIErrors tests that errors reported by the service
+// are returned instead of being computed locally
+func TestLocalFallbackAPIErrors(t *testing.T) {
+	log, _ := logger.NewObserved(zapcore.DebugLevel)
+	srv := httptest.NewServer(calcserver.New(calculator.NewCalculator(log), log).Handler())
+	defer srv.Close()
+	client := calcclient.New(srv.URL, calcclient.WithLocalFallback(log))
+
+	_, source, err := client.CalculateWithSource(context.Background(), "divide", 1, 0)
+	var apiErr *api.APIError
+	if !errors.As(err, &apiErr) || !errors.Is(err, api.ErrDivisionByZero) || source != calcclient.SourceService {
+		t.Errorf("divide by zero = %s, %v, want the service's error", source, err)
+	}
+}
+
+// TestLocalFallbackCircuitOpen tests that calculations are computed
+// locally while the circuit breaker fails them fast
+func TestLocalFallbackCircuitOpen(t *testing.T) {
+	log, _ := logger.NewObserved(zapcore.DebugLevel)
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	client := calcclient.New(srv.URL,
+		calcclient.WithCircuitBreaker(calcclient.NewBreaker(calcclient.WithFailureThreshold(1))),
+		calcclient.WithLocalFallback(log))
+
+	for i := 0; i < 3; i++ {
+		if result, source, err := client.CalculateWithSource(context.Background(), "multiply", 4, 5); err != nil || result != 20 || source != calcclient.SourceLocal {
+			t.Errorf("call %d = %d, %s, %v, want 20 computed locally", i, result, source, err)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package calcclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrSchemaMismatch is matched, with errors.Is, by every *SchemaError
+var ErrSchemaMismatch = errors.New("calcclient: response does not match the API schema")
+
+// SchemaError reports a successful response whose body does not match
+// the schema of its API version, or that names a version the Client
+// does not know
+type SchemaError struct {
+	Version int    // API version of the response; 0 when unknown
+	Field   string // path of the offending field, such as "operations[1].name"
+	Reason  string // such as "missing" or "unknown field"
+}
+
+func (e *SchemaError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("%v: v%d response %s", ErrSchemaMismatch, e.Version, e.Reason)
+	}
+	return fmt.Sprintf("%v: v%d response field %s: %s", ErrSchemaMismatch, e.Version, e.Field, e.Reason)
+}
+
+// Unwrap returns ErrSchemaMismatch
+func (e *SchemaError) Unwrap() error {
+	return ErrSchemaMismatch
+}
+
+// decodeStrict decodes the body of a version response into out, which
+// must hold a pointer to a struct of pkg/api. Fields unknown to that
+// struct, and fields without omitempty that are missing, are schema
+// mismatches. Both API versions share the types of pkg/api.
+func decodeStrict(data []byte, out any, version int) error {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	if err := checkSchema(reflect.TypeOf(out).Elem(), doc, ""); err != nil {
+		err.Version = version
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(out); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return &SchemaError{Version: version, Field: typeErr.Field, Reason: fmt.Sprintf("%s where %s was expected", typeErr.Value, typeErr.Type)}
+		}
+		return &SchemaError{Version: version, Reason: err.Error()}
+	}
+	return nil
+}
+
+// checkSchema compares doc, a decoded JSON value at path, with the
+// fields of t
+func checkSchema(t reflect.Type, doc any, path string) *SchemaError {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := doc.(map[string]any)
+		if !ok {
+			return &SchemaError{Field: path, Reason: "is not an object"}
+		}
+		known := make(map[string]bool)
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, required, ok := jsonField(f)
+			if !ok {
+				continue
+			}
+			known[name] = true
+			value, present := obj[name]
+			if !present {
+				if required {
+					return &SchemaError{Field: join(path, name), Reason: "missing"}
+				}
+				continue
+			}
+			if err := checkSchema(f.Type, value, join(path, name)); err != nil {
+				return err
+			}
+		}
+		for name := range obj {
+			if !known[name] {
+				return &SchemaError{Field: join(path, name), Reason: "unknown field"}
+			}
+		}
+	case reflect.Slice:
+		arr, _ := doc.([]any) // null decodes to an empty slice
+		for i, elem := range arr {
+			if err := checkSchema(t.Elem(), elem, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// jsonField returns the JSON name of f and whether it is required, or
+// false when f is not encoded
+func jsonField(f reflect.StructField) (name string, required, ok bool) {
+	if !f.IsExported() {
+		return "", false, false
+	}
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = f.Name
+	}
+	return name, !strings.Contains(opts, "omitempty"), true
+}
+
+// join appends name to path
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,284 @@
+package calcclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redacted replaces the values of secretHeaders in transcripts
+const redacted = "REDACTED"
+
+// secretHeaders are the request headers whose values never reach a
+// transcript
+var secretHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "X-Api-Key"}
+
+// Exchange is a request sent by a Client and its response, or the error
+// that kept it from one, as recorded in a transcript. Bodies that are not
+// JSON are recorded as JSON strings.
+type Exchange struct {
+	Time       time.Time       `json:"time"`
+	DurationMS float64         `json:"duration_ms"`
+	Command    string          `json:"command,omitempty"` // see WithCommand
+	Method     string          `json:"method"`
+	Path       string          `json:"path"`
+	Header     http.Header     `json:"header,omitempty"` // with secrets redacted
+	Request    json.RawMessage `json:"request,omitempty"`
+	Status     int             `json:"status,omitempty"`
+	Response   json.RawMessage `json:"response,omitempty"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// commandKey is the context key for the command set by WithCommand
+type commandKey struct{}
+
+// WithCommand returns a context that makes the exchanges of requests
+// sent with it record command, such as the input line of a REPL that led
+// to them
+func WithCommand(ctx context.Context, command string) context.Context {
+	return context.WithValue(ctx, commandKey{}, command)
+}
+
+// newExchange records a request sent at start and its outcome
+func newExchange(ctx context.Context, start time.Time, method, path string, body []byte, resp *http.Response, data []byte, err error) Exchange {
+	e := Exchange{
+		Time:       start,
+		DurationMS: float64(time.Since(start).Microseconds()) / 1000,
+		Method:     method,
+		Path:       path,
+		Request:    rawBody(body),
+	}
+	e.Command, _ = ctx.Value(commandKey{}).(string)
+	if err != nil {
+		e.Error = err.Error()
+		return e
+	}
+	e.Header = redact(resp.Request.Header)
+	e.Status = resp.StatusCode
+	e.Response = rawBody(data)
+	return e
+}
+
+// redact returns a copy of h with the values of secretHeaders replaced
+func redact(h http.Header) http.Header {
+	h = h.Clone()
+	for _, key := range secretHeaders {
+		if _, ok := h[key]; ok {
+			h.Set(key, redacted)
+		}
+	}
+	return h
+}
+
+// rawBody returns body as compact JSON, or as a JSON string when it is
+// not JSON
+func rawBody(body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+	var b bytes.Buffer
+	if json.Compact(&b, body) == nil {
+		return b.Bytes()
+	}
+	quoted, _ := json.Marshal(string(body))
+	return quoted
+}
+
+// requestBody returns the body recorded by rawBody
+func requestBody(raw json.RawMessage) []byte {
+	if len(raw) == 0 {
+		return nil
+	}
+	var s string
+	if raw[0] == '"' && json.Unmarshal(raw, &s) == nil {
+		return []byte(s)
+	}
+	return raw
+}
+
+// TranscriptWriter appends exchanges to a writer as JSON lines. It is
+// safe for concurrent use.
+type TranscriptWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// NewTranscriptWriter returns a TranscriptWriter appending to w
+func NewTranscriptWriter(w io.Writer) *TranscriptWriter {
+	return &TranscriptWriter{enc: json.NewEncoder(w)}
+}
+
+// Record appends e. After a failed write, nothing more is written; Err
+// returns the failure.
+func (t *TranscriptWriter) Record(e Exchange) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.err == nil {
+		t.err = t.enc.Encode(e)
+	}
+}
+
+// Err returns the first error writing the transcript
+func (t *TranscriptWriter) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
+
+// WithTranscript records every request and its response to t, with the
+// values of secret headers such as Authorization replaced by REDACTED
+func WithTranscript(t *TranscriptWriter) Option {
+	return func(c *Client) {
+		c.transcript = t
+	}
+}
+
+// ReplayOption configures Replay
+type ReplayOption func(*replayConfig)
+
+// replayConfig is the configuration of a Replay
+type replayConfig struct {
+	timing bool
+}
+
+// WithReplayTiming sends each request as long after the first as it was
+// recorded, instead of as soon as the previous one is answered
+func WithReplayTiming() ReplayOption {
+	return func(r *replayConfig) {
+		r.timing = true
+	}
+}
+
+// ReplayDiff is a recorded exchange whose replay got a different
+// response
+type ReplayDiff struct {
+	Line     int // 1-based line number in the transcript
+	Recorded Exchange
+	Replayed Exchange
+}
+
+// ReplayReport summarizes a Replay
+type ReplayReport struct {
+	Replayed int // exchanges re-sent
+	Diffs    []ReplayDiff
+}
+
+// OK reports whether every replayed request got its recorded response
+func (r ReplayReport) OK() bool {
+	return len(r.Diffs) == 0
+}
+
+func (r ReplayReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "replayed %d requests: %d differ\n", r.Replayed, len(r.Diffs))
+	for _, d := range r.Diffs {
+		command := ""
+		if d.Recorded.Command != "" {
+			command = " (" + d.Recorded.Command + ")"
+		}
+		fmt.Fprintf(&b, "line %d: %s %s%s recorded %s, replayed %s\n", d.Line, d.Recorded.Method, d.Recorded.Path, command, describeOutcome(d.Recorded), describeOutcome(d.Replayed))
+	}
+	return b.String()
+}
+
+// Replay re-sends the requests of a transcript written by
+// TranscriptWriter, in order, and compares the responses with the
+// recorded ones. Request IDs in response bodies are ignored, as are the
+// messages of transport errors, which name the address of the service.
+// Headers are not replayed; c sends its own, including its credentials.
+func (c *Client) Replay(ctx context.Context, r io.Reader, opts ...ReplayOption) (ReplayReport, error) {
+	cfg := replayConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var report ReplayReport
+	var first time.Time
+	start := time.Now()
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var recorded Exchange
+		if err := json.Unmarshal(scanner.Bytes(), &recorded); err != nil {
+			return report, fmt.Errorf("transcript line %d: %w", line, err)
+		}
+
+		if cfg.timing {
+			if first.IsZero() {
+				first = recorded.Time
+			}
+			if err := sleep(ctx, time.Until(start.Add(recorded.Time.Sub(first)))); err != nil {
+				return report, err
+			}
+		}
+
+		sent, cctx, body := time.Now(), WithCommand(ctx, recorded.Command), requestBody(recorded.Request)
+		resp, data, err := c.exchange(cctx, recorded.Method, recorded.Path, body)
+		replayed := newExchange(cctx, sent, recorded.Method, recorded.Path, body, resp, data, err)
+		report.Replayed++
+		if !sameOutcome(recorded, replayed) {
+			report.Diffs = append(report.Diffs, ReplayDiff{Line: line, Recorded: recorded, Replayed: replayed})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return report, fmt.Errorf("failed to read transcript: %w", err)
+	}
+	return report, nil
+}
+
+// sleep waits for d, or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// sameOutcome reports whether two exchanges got the same response, or
+// both failed
+func sameOutcome(a, b Exchange) bool {
+	if a.Error != "" || b.Error != "" {
+		return a.Error != "" && b.Error != ""
+	}
+	return a.Status == b.Status && normalize(a.Response) == normalize(b.Response)
+}
+
+// normalize returns body without its request ID, with sorted keys
+func normalize(body json.RawMessage) string {
+	var doc any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return string(body)
+	}
+	if obj, ok := doc.(map[string]any); ok {
+		delete(obj, "request_id")
+	}
+	b, _ := json.Marshal(doc)
+	return string(b)
+}
+
+// describeOutcome describes the response of e for reports
+func describeOutcome(e Exchange) string {
+	if e.Error != "" {
+		return "error " + strconv.Quote(e.Error)
+	}
+	return fmt.Sprintf("%d %s", e.Status, normalize(e.Response))
+}
@@ -0,0 +1,131 @@
+package calcclient_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-examples/pkg/api"
+	"go-examples/pkg/calcclient"
+	"go-examples/pkg/httpmw"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// addingServer adds the operands of every calculation, off by one when a
+// is offByOneAt, and fails divisions by zero with a request ID naming
+// the server. It requires the bearer token key.
+func addingServer(t *testing.T, name, key string, offByOneAt int) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"status":true}`))
+	})
+	mux.HandleFunc("POST /calculate", func(w http.ResponseWriter, r *http.Request) {
+		var req api.CalculationRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.B == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, `{"code":%q,"error":"division by zero","request_id":%q}`, api.CodeDivisionByZero, name)
+			return
+		}
+		result := req.A + req.B
+		if req.A == offByOneAt {
+			result++
+		}
+		_ = json.NewEncoder(w).Encode(api.CalculationResponse{Result: result, Success: true})
+	})
+	srv := httptest.NewServer(httpmw.Auth(httpmw.BearerToken(key))(mux))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// TestTranscriptReplay tests that a session recorded against one server
+// and replayed against another reports the one differing result, and
+// that the API key never reaches the transcript
+func TestTranscriptReplay(t *testing.T) {
+	const key = "s3cret-key"
+	var transcript bytes.Buffer
+	recorder := calcclient.NewTranscriptWriter(&transcript)
+	client := calcclient.New(addingServer(t, "first", key, -1).URL,
+		calcclient.WithAPIKey(key), calcclient.WithTranscript(recorder))
+
+	ctx := context.Background()
+	if err := client.Health(ctx); err != nil {
+		t.Fatalf("Health failed: %v", err)
+	}
+	for _, cmd := range []struct{ a, b int }{{1, 2}, {5, 0}, {7, 3}} {
+		_, _ = client.Calculate(calcclient.WithCommand(ctx, fmt.Sprintf("add %d %d", cmd.a, cmd.b)), "add", cmd.a, cmd.b)
+	}
+	if err := recorder.Err(); err != nil {
+		t.Fatalf("recording failed: %v", err)
+	}
+
+	if strings.Contains(transcript.String(), key) {
+		t.Errorf("transcript contains the API key:\n%s", transcript.String())
+	}
+	var first calcclient.Exchange
+	if err := json.Unmarshal(bytes.SplitN(transcript.Bytes(), []byte("\n"), 2)[0], &first); err != nil {
+		t.Fatal(err)
+	}
+	if got := first.Header.Get("Authorization"); got != "REDACTED" {
+		t.Errorf("recorded Authorization = %q, want REDACTED", got)
+	}
+
+	second := calcclient.New(addingServer(t, "second", "other-key", 7).URL, calcclient.WithAPIKey("other-key"))
+	report, err := second.Replay(ctx, &transcript)
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if report.Replayed != 4 || len(report.Diffs) != 1 {
+		t.Fatalf("report = %s, want 4 replayed and 1 diff", report)
+	}
+	want := `line 4: POST /calculate (add 7 3) recorded 200 {"result":10,"success":true}, replayed 200 {"result":11,"success":true}`
+	if !strings.Contains(report.String(), want) {
+		t.Errorf("report = %s, want it to contain %s", report, want)
+	}
+
+	// A service that cannot be reached differs from every recorded response
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	transcript.Reset()
+	recorder = calcclient.NewTranscriptWriter(&transcript)
+	_, _ = calcclient.New(addingServer(t, "third", key, -1).URL, calcclient.WithAPIKey(key), calcclient.WithTranscript(recorder)).Calculate(ctx, "add", 1, 1)
+	report, err = calcclient.New(down.URL).Replay(ctx, &transcript)
+	if err != nil || report.OK() || !strings.Contains(report.String(), "replayed error") {
+		t.Errorf("Replay against a stopped server = %s, %v, want a diff", report, err)
+	}
+}
+
+// TestReplayTiming tests that WithReplayTiming keeps the recorded gaps
+// between requests
+func TestReplayTiming(t *testing.T) {
+	srv := addingServer(t, "timed", "key", -1)
+	start := time.Now()
+	var transcript bytes.Buffer
+	recorder := calcclient.NewTranscriptWriter(&transcript)
+	for _, offset := range []time.Duration{0, 80 * time.Millisecond} {
+		recorder.Record(calcclient.Exchange{Time: start.Add(offset), Method: "GET", Path: "/health", Status: http.StatusOK, Response: json.RawMessage(`{"status":true}`)})
+	}
+
+	client := calcclient.New(srv.URL, calcclient.WithAPIKey("key"))
+	began := time.Now()
+	report, err := client.Replay(context.Background(), bytes.NewReader(transcript.Bytes()), calcclient.WithReplayTiming())
+	if err != nil || !report.OK() {
+		t.Fatalf("Replay = %s, %v", report, err)
+	}
+	if elapsed := time.Since(began); elapsed < 80*time.Millisecond {
+		t.Errorf("replay took %s, want at least the recorded 80ms", elapsed)
+	}
+
+	began = time.Now()
+	if _, err := client.Replay(context.Background(), bytes.NewReader(transcript.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(began); elapsed >= 80*time.Millisecond {
+		t.Errorf("replay without timing took %s", elapsed)
+	}
+}
@@ -0,0 +1,157 @@
+package calcclient_test
+
+import (
+	"context"
+	"go-examples/pkg/calcclient"
+	"go-examples/pkg/calcserver"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/logger"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// countingServer is an httptest server counting the connections accepted
+type countingServer struct {
+	*httptest.Server
+	conns atomic.Int32
+}
+
+func newCountingServer(t *testing.T, handler http.Handler) *countingServer {
+	t.Helper()
+	s := &countingServer{}
+	s.Server = httptest.NewUnstartedServer(handler)
+	s.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			s.conns.Add(1)
+		}
+	}
+	s.Start()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// okHandler answers every request as a successful calculation
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+	_, _ = w.Write([]byte(`{"result":2,"success":true}`))
+})
+
+// TestStatsPooling tests connection reuse with pooling on and a new
+// connection per request with pooling off, as seen by httptrace and by
+// the server
+func TestStatsPooling(t *testing.T) {
+	tests := []struct {
+		name       string
+		idle       int
+		wantOpened uint64
+		wantReused uint64
+	}{
+		{name: "pooled", idle: 4, wantOpened: 1, wantReused: 9},
+		{name: "not pooled", idle: -1, wantOpened: 10, wantReused: 0},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := newCountingServer(t, okHandler)
+			client := calcclient.New(srv.URL, calcclient.WithMaxIdleConnsPerHost(tc.idle))
+
+			// A trace of the caller's own sees the same connections
+			var traced atomic.Uint64
+			ctx := httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{
+				GotConn: func(info httptrace.GotConnInfo) {
+					if info.Reused {
+						traced.Add(1)
+					}
+				},
+			})
+			for i := 0; i < 10; i++ {
+				if _, err := client.Calculate(ctx, "add", 1, 1); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			stats := client.Stats()
+			if stats.ConnsOpened != tc.wantOpened || stats.ConnsReused != tc.wantReused {
+				t.Errorf("Stats = %+v, want %d opened and %d reused", stats, tc.wantOpened, tc.wantReused)
+			}
+			if traced.Load() != tc.wantReused {
+				t.Errorf("caller's trace saw %d reused, want %d", traced.Load(), tc.wantReused)
+			}
+			if got := srv.conns.Load(); uint64(got) != tc.wantOpened {
+				t.Errorf("server accepted %d connections, want %d", got, tc.wantOpened)
+			}
+			if stats.DNSLookups != 0 {
+				t.Errorf("DNSLookups = %d for an IP address, want 0", stats.DNSLookups)
+			}
+		})
+	}
+}
+
+// TestMaxConnsPerHost tests that concurrent requests share the limited
+// connections
+func TestMaxConnsPerHost(t *testing.T) {
+	srv := newCountingServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+		okHandler(w, r)
+	}))
+	client := calcclient.New(srv.URL, calcclient.WithMaxConnsPerHost(2), calcclient.WithMaxIdleConnsPerHost(2))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Calculate(context.Background(), "add", 1, 1); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := srv.conns.Load(); got > 2 {
+		t.Errorf("server accepted %d connections, want at most 2", got)
+	}
+	if stats := client.Stats(); stats.ConnsOpened+stats.ConnsReused != 20 {
+		t.Errorf("Stats = %+v, want 20 requests", stats)
+	}
+}
+
+// TestForceHTTP2 tests h2c requests against a calcserver that accepts
+// them, multiplexed on one connection
+func TestForceHTTP2(t *testing.T) {
+	var protoMajor atomic.Int32
+	record := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			protoMajor.Store(int32(r.ProtoMajor))
+			next.ServeHTTP(w, r)
+		})
+	}
+	log, _ := logger.NewObserved(zapcore.DebugLevel)
+	s := calcserver.New(calculator.NewCalculator(log), log,
+		calcserver.WithUnencryptedHTTP2(), calcserver.WithMiddleware(record))
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = s.Serve(l) }()
+	t.Cleanup(func() { _ = s.Shutdown(context.Background()) })
+
+	client := calcclient.New("http://"+l.Addr().String(), calcclient.WithForceHTTP2())
+	for i := 0; i < 3; i++ {
+		if got, err := client.Calculate(context.Background(), "add", 2, 3); err != nil || got != 5 {
+			t.Fatalf("Calculate = %d, %v", got, err)
+		}
+	}
+	if protoMajor.Load() != 2 {
+		t.Errorf("server saw HTTP/%d, want HTTP/2", protoMajor.Load())
+	}
+	if stats := client.Stats(); stats.ConnsOpened != 1 {
+		t.Errorf("Stats = %+v, want a single connection", stats)
+	}
+}
@@ -0,0 +1,127 @@
+package calcclient_test
+
+import (
+	"context"
+	"errors"
+	"go-examples/pkg/api"
+	"go-examples/pkg/calcclient"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// versionedServer answers calculations with body, and with the version
+// header naming version when it is not empty. With v1Only, it answers
+// 406 to requests asking for v2. It records the Accept headers received.
+func versionedServer(t *testing.T, version, body string, v1Only bool) (*calcclient.Client, *[]string) {
+	t.Helper()
+	var accepts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accepts = append(accepts, r.Header.Get("Accept"))
+		if v1Only && r.Header.Get("Accept") == api.MediaTypeV2 {
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+		if version != "" {
+			w.Header().Set(api.VersionHeader, version)
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return calcclient.New(srv.URL), &accepts
+}
+
+// TestVersionNegotiation tests the version negotiated with v2, v1-only
+// and unversioned services, and the Accept headers sent to them
+func TestVersionNegotiation(t *testing.T) {
+	const ok = `{"result":2,"success":true}`
+	tests := []struct {
+		name        string
+		version     string
+		v1Only      bool
+		want        int
+		wantAccepts []string
+	}{
+		{name: "v2", version: "2", want: api.Version2,
+			wantAccepts: []string{api.MediaTypeV2, api.MediaTypeV2}},
+		{name: "v1 only", version: "1", v1Only: true, want: api.Version1,
+			wantAccepts: []string{api.MediaTypeV2, api.MediaTypeV1, api.MediaTypeV1}},
+		{name: "no version header", want: api.Version1,
+			wantAccepts: []string{api.MediaTypeV2, api.MediaTypeV2}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client, accepts := versionedServer(t, tc.version, ok, tc.v1Only)
+			if v := client.APIVersion(); v != 0 {
+				t.Errorf("APIVersion before any request = %d, want 0", v)
+			}
+			for i := 0; i < 2; i++ {
+				if got, err := client.Calculate(context.Background(), "add", 1, 1); err != nil || got != 2 {
+					t.Fatalf("Calculate = %d, %v, want 2", got, err)
+				}
+			}
+			if v := client.APIVersion(); v != tc.want {
+				t.Errorf("APIVersion = %d, want %d", v, tc.want)
+			}
+			if len(*accepts) != len(tc.wantAccepts) {
+				t.Fatalf("Accept headers = %q, want %q", *accepts, tc.wantAccepts)
+			}
+			for i, a := range *accepts {
+				if a != tc.wantAccepts[i] {
+					t.Errorf("Accept header %d = %q, want %q", i, a, tc.wantAccepts[i])
+				}
+			}
+		})
+	}
+
+	// The calculator service predates versioning and speaks v1
+	client := newClient(t)
+	if err := client.Health(context.Background()); err != nil {
+		t.Fatalf("Health failed: %v", err)
+	}
+	if v := client.APIVersion(); v != api.Version1 {
+		t.Errorf("APIVersion with calcserver = %d, want %d", v, api.Version1)
+	}
+}
+
+// TestSchemaMismatch tests the SchemaError returned for responses that
+// do not match their schema
+func TestSchemaMismatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		version   string
+		body      string
+		wantField string
+	}{
+		{name: "unknown field", version: "2", body: `{"result":2,"success":true,"extra":1}`, wantField: "extra"},
+		{name: "missing field", version: "2", body: `{"result":2}`, wantField: "success"},
+		{name: "wrong type", version: "1", body: `{"result":"2","success":true}`, wantField: "result"},
+		{name: "not an object", version: "1", body: `[1]`},
+		{name: "unknown version", version: "3", body: `{"result":2,"success":true}`, wantField: api.VersionHeader + " header"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client, _ := versionedServer(t, tc.version, tc.body, false)
+			_, err := client.Calculate(context.Background(), "add", 1, 1)
+			var schemaErr *calcclient.SchemaError
+			if !errors.As(err, &schemaErr) || !errors.Is(err, calcclient.ErrSchemaMismatch) {
+				t.Fatalf("Calculate error = %v, want a SchemaError", err)
+			}
+			if schemaErr.Field != tc.wantField {
+				t.Errorf("Field = %q, want %q (%v)", schemaErr.Field, tc.wantField, err)
+			}
+		})
+	}
+
+	// Nested fields are named by their path
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set(api.VersionHeader, "2")
+		_, _ = w.Write([]byte(`{"operations":[{"name":"add","aliases":[],"arity":"binary","operand_type":"int","description":""},{"aliases":[]}]}`))
+	}))
+	defer srv.Close()
+	_, err := calcclient.New(srv.URL).Operations(context.Background())
+	var schemaErr *calcclient.SchemaError
+	if !errors.As(err, &schemaErr) || schemaErr.Field != "operations[1].name" || schemaErr.Version != api.Version2 {
+		t.Errorf("Operations error = %#v, want operations[1].name missing in v2", err)
+	}
+}
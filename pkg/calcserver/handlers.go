@@ -0,0 +1,572 @@
+package calcserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-examples/internal/i18n"
+	"go-examples/pkg/api"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/logger"
+	"go-examples/pkg/slogger"
+	"go-examples/pkg/validate"
+	"io"
+	"net/http"
+	"time"
+)
+
+// logFor returns the logger to use while handling r
+func (s *Server) logFor(r *http.Request) logger.Logger {
+	if s.requestLogger != nil {
+		return s.requestLogger(r)
+	}
+	return s.log
+}
+
+// operationNames lists the names and aliases calc accepts
+func operationNames(calc *calculator.Calculator) []string {
+	var names []string
+	for _, op := range calc.Describe() {
+		names = append(names, op.Name)
+		names = append(names, op.Aliases...)
+	}
+	return names
+}
+
+// validateCalculation checks req, an api.CalculationRequest,
+// api.FloatCalculationRequest, api.BigCalculationRequest or
+// api.DecimalCalculationRequest for operation, one of those of calc,
+// before dispatch. An unsupported operation keeps its own error code,
+// with the field error attached.
+func validateCalculation(req validate.Validatable, operation string, calc *calculator.Calculator) *api.APIError {
+	errs := validate.Struct(req).
+		Require("operation").
+		OneOf("operation", operationNames(calc)...).
+		OneOf("mode", api.ModeInt, api.ModeFloat, api.ModeBig, api.ModeDecimal).
+		Errors()
+	if len(errs) == 0 {
+		return nil
+	}
+	if len(errs) == 1 && errs[0].Field == "operation" && errs[0].Code == validate.CodeOneOf {
+		apiErr := api.UnknownOperation(operation)
+		apiErr.Fields = errs
+		return apiErr
+	}
+	return api.InvalidFields(errs)
+}
+
+// rejectExplain reports req, an api.FloatCalculationRequest,
+// api.BigCalculationRequest or api.DecimalCalculationRequest, when it
+// asks for the steps of its calculation, which only int calculations
+// have
+func rejectExplain(req validate.Validatable) *api.APIError {
+	if errs := validate.Struct(req).MutuallyExclusive("mode", "explain").Errors(); len(errs) > 0 {
+		return api.InvalidFields(errs)
+	}
+	return nil
+}
+
+// body returns the body of r, failing reads past the limit of
+// WithMaxBodyBytes with an *http.MaxBytesError
+func (s *Server) body(w http.ResponseWriter, r *http.Request) io.Reader {
+	if s.maxBody <= 0 {
+		return r.Body
+	}
+	return http.MaxBytesReader(w, r.Body, s.maxBody)
+}
+
+// handleCalculate performs a calculator operation, in float, big or
+// decimal mode for requests with the mode api.ModeFloat, api.ModeBig or
+// api.ModeDecimal
+func (s *Server) handleCalculate(w http.ResponseWriter, r *http.Request) {
+	log := s.logFor(r)
+
+	// Parse request
+	body, err := io.ReadAll(s.body(w, r))
+	if err != nil {
+		sendError(w, r, api.FromDecodeError(err), log)
+		return
+	}
+	var mode struct {
+		Mode string `json:"mode"`
+	}
+	if json.Unmarshal(body, &mode) == nil {
+		switch mode.Mode {
+		case api.ModeFloat:
+			s.calculateFloat(w, r, body, log)
+			return
+		case api.ModeBig:
+			s.calculateBig(w, r, body, log)
+			return
+		case api.ModeDecimal:
+			s.calculateDecimal(w, r, body, log)
+			return
+		}
+	}
+	var req api.CalculationRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		sendError(w, r, api.FromDecodeError(err), log)
+		return
+	}
+
+	log.Infof("Calculation request: %+v", req)
+	// Process calculation on the tenant's calculator, which has its own
+	// operations
+	calc, release, apiErr := s.calculator(w, r)
+	if apiErr != nil {
+		sendError(w, r, apiErr, log)
+		return
+	}
+	defer release()
+	if apiErr := validateCalculation(req, req.Operation, calc); apiErr != nil {
+		sendError(w, r, apiErr, log)
+		return
+	}
+
+	// Long operations stop when the client goes away
+	compute := calc.ComputeFloatResultCtx
+	if req.Strict {
+		compute = calc.ComputeStrictFloatResultCtx
+	}
+	result, full, err := compute(r.Context(), req.Operation, req.A, req.B)
+	if err != nil {
+		sendError(w, r, api.FromCalculatorError(err), log)
+		return
+	}
+
+	// Aliases count under the operation's name
+	op, _ := calc.LookupOperation(req.Operation)
+	s.calculations.Add(1, op.Name)
+
+	// Send successful response
+	resp := api.CalculationResponse{
+		Result:  result,
+		Success: true,
+	}
+	if op.FloatResult {
+		resp.FloatResult = &full
+	}
+	if req.Explain {
+		for _, step := range calc.ExplainResult(req.Operation, req.A, req.B, result, nil) {
+			resp.Steps = append(resp.Steps, api.Step{Description: step.Description, Value: step.Value})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Errorf("Failed to encode response: %v", err)
+	}
+}
+
+// calculateFloat performs the float calculation of body, a request with
+// the mode api.ModeFloat
+func (s *Server) calculateFloat(w http.ResponseWriter, r *http.Request, body []byte, log logger.Logger) {
+	var req api.FloatCalculationRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		sendError(w, r, api.FromDecodeError(err), log)
+		return
+	}
+
+	log.Infof("Float calculation request: %+v", req)
+	calc, release, apiErr := s.calculator(w, r)
+	if apiErr != nil {
+		sendError(w, r, apiErr, log)
+		return
+	}
+	defer release()
+	if apiErr := validateCalculation(req, req.Operation, calc); apiErr != nil {
+		sendError(w, r, apiErr, log)
+		return
+	}
+	if apiErr := rejectExplain(req); apiErr != nil {
+		sendError(w, r, apiErr, log)
+		return
+	}
+
+	result, err := calc.ComputeFloat(req.Operation, req.A, req.B)
+	if err != nil {
+		sendError(w, r, api.FromCalculatorError(err), log)
+		return
+	}
+
+	op, _ := calc.LookupOperation(req.Operation)
+	s.calculations.Add(1, op.Name)
+
+	resp := api.FloatCalculationResponse{
+		Result:  result,
+		Success: true,
+		Mode:    api.ModeFloat,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Errorf("Failed to encode response: %v", err)
+	}
+}
+
+// calculateBig performs the arbitrary-precision calculation of body, a
+// request with the mode api.ModeBig
+func (s *Server) calculateBig(w http.ResponseWriter, r *http.Request, body []byte, log logger.Logger) {
+	var req api.BigCalculationRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		sendError(w, r, api.FromDecodeError(err), log)
+		return
+	}
+
+	log.Infof("Big calculation request: %s", req.Operation)
+	calc, release, apiErr := s.calculator(w, r)
+	if apiErr != nil {
+		sendError(w, r, apiErr, log)
+		return
+	}
+	defer release()
+	if apiErr := validateCalculation(req, req.Operation, calc); apiErr != nil {
+		sendError(w, r, apiErr, log)
+		return
+	}
+	if apiErr := rejectExplain(req); apiErr != nil {
+		sendError(w, r, apiErr, log)
+		return
+	}
+
+	result, err := calc.ComputeBig(req.Operation, req.A, req.B)
+	if err != nil {
+		sendError(w, r, api.FromCalculatorError(err), log)
+		return
+	}
+
+	op, _ := calc.LookupOperation(req.Operation)
+	s.calculations.Add(1, op.Name)
+
+	resp := api.BigCalculationResponse{
+		Result:  result,
+		Success: true,
+		Mode:    api.ModeBig,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Errorf("Failed to encode response: %v", err)
+	}
+}
+
+// calculateDecimal performs the fixed-point calculation of body, a
+// request with the mode api.ModeDecimal
+func (s *Server) calculateDecimal(w http.ResponseWriter, r *http.Request, body []byte, log logger.Logger) {
+	var req api.DecimalCalculationRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		sendError(w, r, api.FromDecodeError(err), log)
+		return
+	}
+
+	log.Infof("Decimal calculation request: %+v", req)
+	calc, release, apiErr := s.calculator(w, r)
+	if apiErr != nil {
+		sendError(w, r, apiErr, log)
+		return
+	}
+	defer release()
+	if apiErr := validateCalculation(req, req.Operation, calc); apiErr != nil {
+		sendError(w, r, apiErr, log)
+		return
+	}
+	if apiErr := rejectExplain(req); apiErr != nil {
+		sendError(w, r, apiErr, log)
+		return
+	}
+	a, err := calculator.ParseDecimal(req.A)
+	if err != nil {
+		sendError(w, r, api.FromCalculatorError(err), log)
+		return
+	}
+	b, err := calculator.ParseDecimal(req.B)
+	if err != nil {
+		sendError(w, r, api.FromCalculatorError(err), log)
+		return
+	}
+
+	result, err := calc.ComputeDecimal(req.Operation, a, b)
+	if err != nil {
+		sendError(w, r, api.FromCalculatorError(err), log)
+		return
+	}
+
+	op, _ := calc.LookupOperation(req.Operation)
+	s.calculations.Add(1, op.Name)
+
+	resp := api.DecimalCalculationResponse{
+		Result:  result.String(),
+		Success: true,
+		Mode:    api.ModeDecimal,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Errorf("Failed to encode response: %v", err)
+	}
+}
+
+// calculator returns the calculator for r, from the pool of
+// WithCalculatorPool if any, and a function to call once done with it.
+// The calculator logs the request ID as request_id: the one set on the
+// response by the request ID middleware, or else a valid one sent by the
+// client.
+func (s *Server) calculator(w http.ResponseWriter, r *http.Request) (*calculator.Calculator, func(), *api.APIError) {
+	calc, release := s.calc, func() {}
+	if s.pool != nil {
+		tenant, ok := Tenant(r)
+		if !ok {
+			return nil, nil, api.InvalidRequest(errInvalidTenant.Error())
+		}
+		calc, release = s.pool.Acquire(tenant)
+	}
+	id := w.Header().Get(slogger.RequestIDHeader)
+	if id == "" {
+		id = r.Header.Get(slogger.RequestIDHeader)
+	}
+	if slogger.ValidRequestID(id) {
+		calc = calc.With("request_id", id)
+	}
+	return calc, release, nil
+}
+
+// handleEvaluate evaluates an integer arithmetic expression on the
+// tenant's calculator, within the limits of its options and the
+// evaluation timeout
+func (s *Server) handleEvaluate(w http.ResponseWriter, r *http.Request) {
+	log := s.logFor(r)
+
+	var req api.EvaluationRequest
+	if err := json.NewDecoder(s.body(w, r)).Decode(&req); err != nil {
+		sendError(w, r, api.FromDecodeError(err), log)
+		return
+	}
+	if errs := validate.Struct(req).Require("expression").Errors(); len(errs) > 0 {
+		sendError(w, r, api.InvalidFields(errs), log)
+		return
+	}
+
+	log.Infof("Evaluation request: %q", req.Expression)
+	calc, release, apiErr := s.calculator(w, r)
+	if apiErr != nil {
+		sendError(w, r, apiErr, log)
+		return
+	}
+	defer release()
+
+	// The trace observes a copy of the calculator, so it records the
+	// operations of this request only
+	var trace *calculator.Trace
+	if req.Trace {
+		trace = calculator.NewTrace(s.maxTrace)
+		calc = calc.WithObserver(trace)
+	}
+
+	// Long expressions stop at the timeout or when the client goes away
+	ctx := r.Context()
+	if s.evalTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.evalTimeout)
+		defer cancel()
+	}
+	result, err := calc.EvaluateCtx(ctx, req.Expression)
+	if err != nil {
+		apiErr := api.FromCalculatorError(err)
+		apiErr.Trace = traceSteps(trace)
+		sendError(w, r, apiErr, log)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(api.EvaluationResponse{Result: result, Success: true, Trace: traceSteps(trace)}); err != nil {
+		log.Errorf("Failed to encode response: %v", err)
+	}
+}
+
+// handleBatch performs the integer calculations of a batch in order on
+// the tenant's calculator. Each calculation is validated before any is
+// performed, so a failed one is one the calculator rejected and has
+// its own step in the trace.
+func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request) {
+	log := s.logFor(r)
+
+	var req api.BatchRequest
+	if err := json.NewDecoder(s.body(w, r)).Decode(&req); err != nil {
+		sendError(w, r, api.FromDecodeError(err), log)
+		return
+	}
+
+	log.Infof("Batch request: %d calculations", len(req.Calculations))
+	calc, release, apiErr := s.calculator(w, r)
+	if apiErr != nil {
+		sendError(w, r, apiErr, log)
+		return
+	}
+	defer release()
+	names := operationNames(calc)
+	var errs []validate.FieldError
+	calls := make([]calculator.Call, len(req.Calculations))
+	for i, c := range req.Calculations {
+		for _, e := range validate.Struct(c).Require("operation").OneOf("operation", names...).Errors() {
+			e.Field = fmt.Sprintf("calculations[%d].%s", i, e.Field)
+			errs = append(errs, e)
+		}
+		calls[i] = calculator.Call{Operation: c.Operation, A: c.A, B: c.B}
+	}
+	if len(errs) > 0 {
+		sendError(w, r, api.InvalidFields(errs), log)
+		return
+	}
+
+	var trace *calculator.Trace
+	if req.Trace {
+		trace = calculator.NewTrace(s.maxTrace)
+		calc = calc.WithObserver(trace)
+	}
+	t := i18n.FromContext(r.Context())
+	resp := api.BatchResponse{Results: make([]api.BatchResult, len(calls)), Success: true}
+	for i, result := range calc.CalculateAll(calls) {
+		resp.Results[i].Result = result.Value
+		if result.Err != nil {
+			apiErr := api.FromCalculatorError(result.Err)
+			if t != nil {
+				apiErr = apiErr.Localize(t)
+			}
+			resp.Results[i].Code, resp.Results[i].Error = apiErr.Code, apiErr.Message
+			continue
+		}
+		op, _ := calc.LookupOperation(calls[i].Operation)
+		s.calculations.Add(1, op.Name)
+	}
+	resp.Trace = traceSteps(trace)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Errorf("Failed to encode response: %v", err)
+	}
+}
+
+// traceSteps returns the steps recorded by trace for a response, nil
+// if trace is
+func traceSteps(trace *calculator.Trace) []api.TraceStep {
+	if trace == nil {
+		return nil
+	}
+	recorded := trace.Steps()
+	steps := make([]api.TraceStep, len(recorded))
+	for i, step := range recorded {
+		steps[i] = api.TraceStep{
+			Index:      i,
+			Operation:  step.Operation,
+			A:          step.A,
+			B:          step.B,
+			Result:     step.Result,
+			DurationMS: float64(step.Duration) / float64(time.Millisecond),
+			Truncated:  step.Truncated,
+		}
+		if step.Err != nil {
+			steps[i].Code = api.FromCalculatorError(step.Err).Code
+		}
+	}
+	return steps
+}
+
+// handleOperations lists the operations accepted by /calculate, those of
+// the tenant's calculator
+func (s *Server) handleOperations(w http.ResponseWriter, r *http.Request) {
+	calc, release, apiErr := s.calculator(w, r)
+	if apiErr != nil {
+		sendError(w, r, apiErr, s.logFor(r))
+		return
+	}
+	defer release()
+	ops := calc.Describe()
+	resp := api.OperationsResponse{Operations: make([]api.OperationInfo, len(ops))}
+	for i, op := range ops {
+		resp.Operations[i] = api.OperationInfo{
+			Name:        op.Name,
+			Aliases:     op.Aliases,
+			Arity:       string(op.Arity),
+			OperandType: string(op.OperandType),
+			Description: op.Description,
+		}
+		if resp.Operations[i].Aliases == nil {
+			resp.Operations[i].Aliases = []string{}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		s.log.Errorf("Failed to encode operations response: %v", err)
+	}
+}
+
+// handleHealth reports that the process is alive. It runs no checks, so
+// it stays cheap enough for liveness probes.
+func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(map[string]bool{"status": true}); err != nil {
+		s.log.Errorf("Failed to encode health response: %v", err)
+	}
+}
+
+// handleHealthDetail runs the registered dependency checks and reports
+// each of them, answering 503 when the service is unhealthy
+func (s *Server) handleHealthDetail(w http.ResponseWriter, r *http.Request) {
+	report := s.health.Run(r.Context())
+	if report.Status != api.HealthHealthy {
+		s.logFor(r).Warnf("Health checks report %s", report.Status)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if report.Status == api.HealthUnhealthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		s.log.Errorf("Failed to encode health report: %v", err)
+	}
+}
+
+// handleReady reports whether the server accepts traffic, turning 503
+// while it drains so load balancers stop routing to it
+func (s *Server) handleReady(w http.ResponseWriter, _ *http.Request) {
+	ready := s.Ready()
+	w.Header().Set("Content-Type", "application/json")
+	if ready {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(map[string]bool{"ready": ready}); err != nil {
+		s.log.Errorf("Failed to encode readiness response: %v", err)
+	}
+}
+
+// sendError renders apiErr as the error response, tagged with the
+// request ID set by middleware, if any, and in the language picked by
+// i18n.Middleware. The log line keeps the English message.
+func sendError(w http.ResponseWriter, r *http.Request, apiErr *api.APIError, log logger.Logger) {
+	apiErr.RequestID = w.Header().Get(slogger.RequestIDHeader)
+	log.Warnf("Error response: %s (code: %d)", apiErr.Message, apiErr.HTTPStatus)
+	if t := i18n.FromContext(r.Context()); t != nil {
+		apiErr = apiErr.Localize(t)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(apiErr.HTTPStatus)
+	if err := json.NewEncoder(w).Encode(apiErr); err != nil {
+		log.Errorf("Failed to encode error response: %v", err)
+		// In case we can't encode the JSON response, send a plain text error
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
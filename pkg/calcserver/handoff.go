@@ -0,0 +1,150 @@
+package calcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Environment variables passed by Handoff to the new process
+const (
+	// ListenFDEnv holds the descriptor of the inherited listener
+	ListenFDEnv = "CALCSERVER_LISTEN_FD"
+	// ReadyFDEnv holds the descriptor of the pipe the new process closes
+	// once it serves, telling the old one to drain
+	ReadyFDEnv = "CALCSERVER_READY_FD"
+)
+
+// listenFDsStart is the first descriptor passed by systemd socket
+// activation
+const listenFDsStart = 3
+
+// ErrHandoffUnsupported is returned by the listener handoff functions on
+// platforms without descriptor inheritance
+var ErrHandoffUnsupported = errors.New("calcserver: listener handoff is only supported on Unix")
+
+// InheritedListener returns the listener passed by Handoff in
+// ListenFDEnv or by systemd socket activation in LISTEN_FDS, of which
+// only the first is used. It returns nil without error when there is
+// none. The variables are cleared so child processes do not reuse them.
+func InheritedListener() (net.Listener, error) {
+	if v := os.Getenv(ListenFDEnv); v != "" {
+		_ = os.Unsetenv(ListenFDEnv)
+		fd, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", ListenFDEnv, v, err)
+		}
+		return ListenerFromFD(fd)
+	}
+
+	n := os.Getenv("LISTEN_FDS")
+	if n == "" {
+		return nil, nil
+	}
+	// LISTEN_PID names the process the descriptors are meant for
+	if pid := os.Getenv("LISTEN_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+	_ = os.Unsetenv("LISTEN_FDS")
+	_ = os.Unsetenv("LISTEN_PID")
+	count, err := strconv.Atoi(n)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LISTEN_FDS %q: %w", n, err)
+	}
+	if count < 1 {
+		return nil, nil
+	}
+	return ListenerFromFD(listenFDsStart)
+}
+
+// handoffListener wraps the listener given to Serve. After stopAccepting
+// closes it, Accept waits for Close instead of failing, so http.Server
+// keeps its connections until Shutdown rather than returning from Serve.
+type handoffListener struct {
+	net.Listener
+	stopped   chan struct{}
+	stopOnce  sync.Once
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newHandoffListener(l net.Listener) *handoffListener {
+	return &handoffListener{Listener: l, stopped: make(chan struct{}), closed: make(chan struct{})}
+}
+
+func (l *handoffListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		select {
+		case <-l.stopped:
+			<-l.closed
+			return nil, net.ErrClosed
+		default:
+		}
+	}
+	return c, err
+}
+
+// stop closes this process's descriptor of the listener; a process it
+// was handed off to keeps accepting on its own
+func (l *handoffListener) stop() {
+	l.stopOnce.Do(func() {
+		close(l.stopped)
+		_ = l.Listener.Close()
+	})
+}
+
+func (l *handoffListener) Close() error {
+	l.closeOnce.Do(func() { close(l.closed) })
+	l.stop()
+	return nil
+}
+
+// trackConn records which connections have not been served a request
+// yet, as http.Server drops the first request of those once Shutdown has
+// been called. It is the http.Server ConnState hook.
+func (s *Server) trackConn(c net.Conn, state http.ConnState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch state {
+	case http.StateNew:
+		s.unserved[c] = struct{}{}
+	case http.StateIdle, http.StateHijacked, http.StateClosed:
+		delete(s.unserved, c)
+	}
+}
+
+// stopAccepting stops this process accepting connections and waits until
+// the ones it accepted were served a request, or ctx is done, so that
+// Shutdown drops none of them
+func (s *Server) stopAccepting(ctx context.Context) error {
+	s.mu.Lock()
+	l := s.accepting
+	s.mu.Unlock()
+	if l == nil {
+		return nil
+	}
+	l.stop()
+
+	tick := time.NewTicker(5 * time.Millisecond)
+	defer tick.Stop()
+	for {
+		s.mu.Lock()
+		n := len(s.unserved)
+		s.mu.Unlock()
+		if n == 0 {
+			return nil
+		}
+		select {
+		case <-tick.C:
+		case <-ctx.Done():
+			return fmt.Errorf("%d connections not served: %w", n, ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !unix
+
+package calcserver
+
+import (
+	"context"
+	"net"
+	"os/exec"
+)
+
+// ListenerFromFD returns ErrHandoffUnsupported
+func ListenerFromFD(fd int) (net.Listener, error) {
+	return nil, ErrHandoffUnsupported
+}
+
+// Handoff returns ErrHandoffUnsupported
+func (s *Server) Handoff(ctx context.Context, cmd *exec.Cmd) error {
+	return ErrHandoffUnsupported
+}
+
+// notifyParent does nothing, as no parent can have handed off a listener
+func notifyParent() {}
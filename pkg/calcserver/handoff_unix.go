@@ -0,0 +1,154 @@
+//go:build unix
+
+package calcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// ListenerFromFD returns a listener for the socket open on fd, such as
+// one given with -listen-fd
+func ListenerFromFD(fd int) (net.Listener, error) {
+	f := os.NewFile(uintptr(fd), "listener")
+	if f == nil {
+		return nil, fmt.Errorf("invalid listener descriptor %d", fd)
+	}
+	defer f.Close()
+	l, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("descriptor %d is not a listener: %w", fd, err)
+	}
+	return l, nil
+}
+
+// Handoff starts cmd with the listener being served and waits until the
+// new process serves it too, then stops accepting on it and waits for
+// the connections already accepted to be served a request; the caller
+// then drains this one with Shutdown. A nil cmd re-executes the running binary with the same
+// arguments. The new process picks the listener up with
+// InheritedListener, and its Serve reports readiness. If it exits or ctx
+// ends before it is ready, it is killed, Handoff returns an error and
+// the server keeps serving.
+func (s *Server) Handoff(ctx context.Context, cmd *exec.Cmd) error {
+	s.mu.Lock()
+	l := s.listener
+	s.mu.Unlock()
+	if l == nil {
+		return errors.New("calcserver: handoff before Serve")
+	}
+	filer, ok := l.(interface{ File() (*os.File, error) })
+	if !ok {
+		return fmt.Errorf("calcserver: cannot hand off a %T", l)
+	}
+	lf, err := filer.File()
+	if err != nil {
+		return fmt.Errorf("failed to get listener descriptor: %w", err)
+	}
+	defer lf.Close()
+
+	ready, readyW, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("failed to create readiness pipe: %w", err)
+	}
+	defer ready.Close()
+
+	if cmd == nil {
+		exe, err := os.Executable()
+		if err != nil {
+			readyW.Close()
+			return fmt.Errorf("failed to find executable: %w", err)
+		}
+		cmd = exec.Command(exe, os.Args[1:]...)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	}
+	// ExtraFiles start at descriptor 3
+	listenFD := 3 + len(cmd.ExtraFiles)
+	cmd.ExtraFiles = append(cmd.ExtraFiles, lf, readyW)
+	env := cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	cmd.Env = append(withoutHandoffEnv(env),
+		ListenFDEnv+"="+strconv.Itoa(listenFD),
+		ReadyFDEnv+"="+strconv.Itoa(listenFD+1))
+
+	err = cmd.Start()
+	readyW.Close() // the child holds its own copy
+	if err != nil {
+		return fmt.Errorf("failed to start new process: %w", err)
+	}
+	s.log.Infof("Started new process %d, waiting for it to serve", cmd.Process.Pid)
+
+	// The child closes the pipe once ready; a read then returns EOF after
+	// the byte it wrote, or EOF alone if it died first
+	got := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1)
+		n, err := ready.Read(buf)
+		if n == 1 {
+			err = nil
+		} else if err == io.EOF {
+			err = errors.New("new process exited before serving")
+		}
+		got <- err
+	}()
+	select {
+	case err := <-got:
+		if err != nil {
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+			return fmt.Errorf("handoff failed: %w", err)
+		}
+	case <-ctx.Done():
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return fmt.Errorf("handoff failed: %w", ctx.Err())
+	}
+
+	// The new process outlives this one, which does not wait for it
+	s.log.Infof("Process %d is serving the listener", cmd.Process.Pid)
+	if err := s.stopAccepting(ctx); err != nil {
+		s.log.Warnf("Stopped accepting with requests pending: %v", err)
+	}
+	return nil
+}
+
+// notifyParent tells the process that started this one with Handoff
+// that the listener is served, by writing a byte to the pipe in
+// ReadyFDEnv and closing it
+func notifyParent() {
+	v := os.Getenv(ReadyFDEnv)
+	if v == "" {
+		return
+	}
+	_ = os.Unsetenv(ReadyFDEnv)
+	fd, err := strconv.Atoi(v)
+	if err != nil {
+		return
+	}
+	if f := os.NewFile(uintptr(fd), "ready"); f != nil {
+		_, _ = f.Write([]byte{1})
+		_ = f.Close()
+	}
+}
+
+// withoutHandoffEnv drops the handoff variables of an earlier handoff
+// from env
+func withoutHandoffEnv(env []string) []string {
+	out := make([]string, 0, len(env))
+	for _, kv := range env {
+		if strings.HasPrefix(kv, ListenFDEnv+"=") || strings.HasPrefix(kv, ReadyFDEnv+"=") {
+			continue
+		}
+		out = append(out, kv)
+	}
+	return out
+}
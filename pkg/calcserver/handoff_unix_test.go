@@ -0,0 +1,193 @@
+//go:build unix
+
+package calcserver_test
+
+import (
+	"bytes"
+	"context"
+	"go-examples/pkg/calcserver"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// handoffHelperEnv makes TestHandoffHelper act as the new process
+const handoffHelperEnv = "CALCSERVER_HANDOFF_HELPER"
+
+// servedBy tags responses with the ID of the serving process
+func servedBy(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Served-By", strconv.Itoa(os.Getpid()))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// TestHandoffHelper is not a test: started by TestHandoff, it serves the
+// inherited listener until killed
+func TestHandoffHelper(t *testing.T) {
+	if os.Getenv(handoffHelperEnv) != "1" {
+		t.Skip("helper process for TestHandoff")
+	}
+	l, err := calcserver.InheritedListener()
+	if err != nil || l == nil {
+		t.Fatalf("no inherited listener: %v", err)
+	}
+	s, _ := newServer(t, calcserver.WithMiddleware(servedBy))
+	if err := s.Serve(l); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// helperCommand returns the command running TestHandoffHelper
+func helperCommand() *exec.Cmd {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHandoffHelper$")
+	cmd.Env = append(os.Environ(), handoffHelperEnv+"=1")
+	return cmd
+}
+
+// TestHandoff tests that a looping client sees no failed request while
+// the listener moves to a new process and the old one drains
+func TestHandoff(t *testing.T) {
+	s, _ := newServer(t, calcserver.WithMiddleware(servedBy))
+	url := serve(t, s)
+
+	// New connections for every request, so both processes accept some
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}, Timeout: 5 * time.Second}
+	stop := make(chan struct{})
+	var mu sync.Mutex
+	var failures []error
+	servers := map[string]int{}
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				resp, err := client.Post(url+"/calculate", "application/json", bytes.NewBufferString(`{"operation":"add","a":1,"b":2}`))
+				mu.Lock()
+				if err != nil {
+					failures = append(failures, err)
+				} else {
+					if resp.StatusCode != http.StatusOK {
+						failures = append(failures, &statusError{resp.StatusCode})
+					}
+					servers[resp.Header.Get("X-Served-By")]++
+					resp.Body.Close()
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	// served waits for a request served by the process pid, for up to
+	// 10 seconds, and reports whether there was one
+	served := func(pid string) bool {
+		for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+			mu.Lock()
+			n := servers[pid]
+			mu.Unlock()
+			if n > 0 {
+				return true
+			}
+		}
+		return false
+	}
+
+	parent := strconv.Itoa(os.Getpid())
+	if !served(parent) {
+		close(stop)
+		wg.Wait()
+		t.Fatalf("no request served before the handoff: %v", failures)
+	}
+	cmd := helperCommand()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.Handoff(ctx, cmd); err != nil {
+		close(stop)
+		wg.Wait()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown after handoff: %v", err)
+	}
+	child := strconv.Itoa(cmd.Process.Pid)
+	childServed := served(child)
+	close(stop)
+	wg.Wait()
+
+	if len(failures) > 0 {
+		t.Errorf("%d requests failed across the handoff, first: %v", len(failures), failures[0])
+	}
+	if !childServed {
+		t.Errorf("requests served per process %v, want both this one and %s", servers, child)
+	}
+}
+
+// statusError reports an unexpected response status
+type statusError struct{ code int }
+
+func (e *statusError) Error() string { return "unexpected status " + strconv.Itoa(e.code) }
+
+// TestHandoffChildFails tests that the server keeps serving when the new
+// process exits before it is ready
+func TestHandoffChildFails(t *testing.T) {
+	s, _ := newServer(t)
+	url := serve(t, s)
+
+	if err := s.Handoff(context.Background(), exec.Command("true")); err == nil {
+		t.Fatal("expected an error from a process that never serves")
+	}
+	resp, err := http.Get(url + "/health")
+	if err != nil || resp.StatusCode != http.StatusOK || !s.Ready() {
+		t.Fatalf("server stopped serving after a failed handoff: %v", err)
+	}
+	resp.Body.Close()
+}
+
+// TestInheritedListener tests picking up a listener from ListenFDEnv,
+// and ignoring socket activation meant for another process
+func TestInheritedListener(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	f, err := l.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	t.Setenv(calcserver.ListenFDEnv, strconv.Itoa(int(f.Fd())))
+	inherited, err := calcserver.InheritedListener()
+	if err != nil || inherited == nil {
+		t.Fatalf("InheritedListener = %v, %v", inherited, err)
+	}
+	defer inherited.Close()
+	if inherited.Addr().String() != l.Addr().String() {
+		t.Errorf("inherited %s, want %s", inherited.Addr(), l.Addr())
+	}
+	if os.Getenv(calcserver.ListenFDEnv) != "" {
+		t.Error("ListenFDEnv was not cleared")
+	}
+
+	t.Setenv("LISTEN_FDS", "1")
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	if l, err := calcserver.InheritedListener(); l != nil || err != nil {
+		t.Errorf("used descriptors meant for another process: %v, %v", l, err)
+	}
+}
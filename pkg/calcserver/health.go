@@ -0,0 +1,142 @@
+package calcserver
+
+import (
+	"context"
+	"fmt"
+	"go-examples/pkg/api"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultCheckTimeout bounds a health check registered without
+// WithCheckTimeout
+const DefaultCheckTimeout = 2 * time.Second
+
+// CheckFunc reports whether a dependency works. It should return once
+// ctx is done; a check that does not is reported as timed out anyway.
+type CheckFunc func(ctx context.Context) error
+
+// CheckOption configures a check added with HealthChecker.Register
+type CheckOption func(*check)
+
+// Critical marks a check whose failure makes the service unhealthy
+// rather than degraded
+func Critical() CheckOption {
+	return func(c *check) {
+		c.critical = true
+	}
+}
+
+// WithCheckTimeout sets how long the check may run. The default is
+// DefaultCheckTimeout.
+func WithCheckTimeout(d time.Duration) CheckOption {
+	return func(c *check) {
+		c.timeout = d
+	}
+}
+
+// check is a registered health check
+type check struct {
+	name     string
+	fn       CheckFunc
+	critical bool
+	timeout  time.Duration
+}
+
+// HealthChecker is a registry of named dependency checks, run by
+// GET /health/detail. It is safe for concurrent use, so components can
+// register their checks while the server runs.
+type HealthChecker struct {
+	mu     sync.RWMutex
+	checks map[string]check
+}
+
+// NewHealthChecker returns a HealthChecker without checks
+func NewHealthChecker() *HealthChecker {
+	return &HealthChecker{checks: map[string]check{}}
+}
+
+// Register adds a check under name, replacing any check of that name
+func (h *HealthChecker) Register(name string, fn CheckFunc, opts ...CheckOption) {
+	c := check{name: name, fn: fn, timeout: DefaultCheckTimeout}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks[name] = c
+}
+
+// Run runs every check concurrently, each bounded by its timeout and by
+// ctx, and aggregates the results: unhealthy when a critical check
+// fails, degraded when only others fail, healthy otherwise
+func (h *HealthChecker) Run(ctx context.Context) api.HealthReport {
+	h.mu.RLock()
+	checks := make([]check, 0, len(h.checks))
+	for _, c := range h.checks {
+		checks = append(checks, c)
+	}
+	h.mu.RUnlock()
+	sort.Slice(checks, func(i, j int) bool { return checks[i].name < checks[j].name })
+
+	report := api.HealthReport{Status: api.HealthHealthy, Checks: make([]api.CheckResult, len(checks))}
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Checks[i] = c.run(ctx)
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status == api.HealthHealthy {
+			continue
+		}
+		if result.Critical {
+			report.Status = api.HealthUnhealthy
+			break
+		}
+		report.Status = api.HealthDegraded
+	}
+	return report
+}
+
+// run runs the check and reports its outcome. The check function runs on
+// its own goroutine, so one ignoring ctx cannot hold up the report.
+func (c check) run(ctx context.Context) api.CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("check panicked: %v", r)
+			}
+		}()
+		done <- c.fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("check timed out after %s: %w", c.timeout, ctx.Err())
+	}
+
+	result := api.CheckResult{
+		Name:      c.name,
+		Status:    api.HealthHealthy,
+		Critical:  c.critical,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = api.HealthUnhealthy
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,156 @@
+package calcserver_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go-examples/pkg/api"
+	"go-examples/pkg/calcserver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Checks used by the tests below
+var (
+	passing = func(context.Context) error { return nil }
+	failing = func(context.Context) error { return errors.New("connection refused") }
+	// hanging ignores its context, like a check stuck in a blocking call
+	hanging = func(context.Context) error { time.Sleep(time.Second); return nil }
+)
+
+// getDetail requests /health/detail and decodes the report
+func getDetail(t *testing.T, s *calcserver.Server) (int, api.HealthReport) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/health/detail", nil))
+	var report api.HealthReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON response %q: %v", rec.Body.String(), err)
+	}
+	return rec.Code, report
+}
+
+// TestHealthDetail tests the aggregated status and the status code for
+// combinations of passing and failing checks
+func TestHealthDetail(t *testing.T) {
+	type reg struct {
+		name     string
+		fn       calcserver.CheckFunc
+		critical bool
+	}
+	tests := []struct {
+		name       string
+		checks     []reg
+		wantStatus string
+		wantCode   int
+	}{
+		{name: "no checks", wantStatus: api.HealthHealthy, wantCode: http.StatusOK},
+		{
+			name:       "all passing",
+			checks:     []reg{{"store", passing, true}, {"webhooks", passing, false}},
+			wantStatus: api.HealthHealthy,
+			wantCode:   http.StatusOK,
+		},
+		{
+			name:       "non-critical failing",
+			checks:     []reg{{"store", passing, true}, {"webhooks", failing, false}},
+			wantStatus: api.HealthDegraded,
+			wantCode:   http.StatusOK,
+		},
+		{
+			name:       "critical failing",
+			checks:     []reg{{"store", failing, true}, {"webhooks", failing, false}},
+			wantStatus: api.HealthUnhealthy,
+			wantCode:   http.StatusServiceUnavailable,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, _ := newServer(t)
+			for _, c := range tc.checks {
+				var opts []calcserver.CheckOption
+				if c.critical {
+					opts = append(opts, calcserver.Critical())
+				}
+				s.HealthChecker().Register(c.name, c.fn, opts...)
+			}
+
+			code, report := getDetail(t, s)
+			if code != tc.wantCode || report.Status != tc.wantStatus {
+				t.Errorf("got %d %s, want %d %s", code, report.Status, tc.wantCode, tc.wantStatus)
+			}
+			if len(report.Checks) != len(tc.checks) {
+				t.Fatalf("got %d checks, want %d", len(report.Checks), len(tc.checks))
+			}
+			for i, c := range tc.checks {
+				got := report.Checks[i]
+				if got.Name != c.name || got.Critical != c.critical {
+					t.Errorf("check %d = %+v, want %s critical=%v", i, got, c.name, c.critical)
+				}
+			}
+		})
+	}
+}
+
+// TestHealthDetailTimeout tests that slow checks fail at their timeout,
+// run concurrently, and do not hold up the report
+func TestHealthDetailTimeout(t *testing.T) {
+	h := calcserver.NewHealthChecker()
+	h.Register("exporter", hanging, calcserver.WithCheckTimeout(20*time.Millisecond))
+	h.Register("history", hanging, calcserver.WithCheckTimeout(20*time.Millisecond))
+	h.Register("store", passing, calcserver.Critical())
+	s, _ := newServer(t, calcserver.WithHealthChecker(h))
+
+	start := time.Now()
+	code, report := getDetail(t, s)
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("report took %s, want the timeouts to end the checks", elapsed)
+	}
+	if code != http.StatusOK || report.Status != api.HealthDegraded {
+		t.Errorf("got %d %s, want 200 degraded", code, report.Status)
+	}
+	for _, c := range report.Checks[:2] {
+		if c.Status != api.HealthUnhealthy || !strings.Contains(c.Error, "timed out") || c.LatencyMS < 20 {
+			t.Errorf("unexpected result for a slow check %+v", c)
+		}
+	}
+	if store := report.Checks[2]; store.Name != "store" || store.Status != api.HealthHealthy || store.Error != "" {
+		t.Errorf("unexpected result for a passing check %+v", store)
+	}
+}
+
+// TestHealthDetailPanic tests that a panicking check is reported as failed
+func TestHealthDetailPanic(t *testing.T) {
+	h := calcserver.NewHealthChecker()
+	h.Register("broken", func(context.Context) error { panic("nil store") }, calcserver.Critical())
+
+	report := h.Run(context.Background())
+	if report.Status != api.HealthUnhealthy || !strings.Contains(report.Checks[0].Error, "nil store") {
+		t.Errorf("unexpected report %+v", report)
+	}
+}
+
+// TestHealthStaysCheap tests that /health reports liveness without
+// running the checks
+func TestHealthStaysCheap(t *testing.T) {
+	s, _ := newServer(t)
+	ran := make(chan struct{}, 1)
+	s.HealthChecker().Register("store", func(context.Context) error {
+		ran <- struct{}{}
+		return errors.New("down")
+	}, calcserver.Critical())
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/health", nil))
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != `{"status":true}` {
+		t.Errorf("got %d %s, want 200 {\"status\":true}", rec.Code, rec.Body.String())
+	}
+	select {
+	case <-ran:
+		t.Error("/health ran the checks")
+	default:
+	}
+}
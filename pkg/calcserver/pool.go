@@ -0,0 +1,259 @@
+package calcserver
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/logger"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TenantHeader names the tenant of requests without a bearer token
+const TenantHeader = "X-Tenant"
+
+// Defaults used by NewCalculatorPool
+const (
+	DefaultTenantTTL  = 30 * time.Minute
+	DefaultMaxTenants = 1000
+)
+
+// keyTenantPrefix starts the names of tenants identified by an API key
+const keyTenantPrefix = "key-"
+
+// tenantName is the form of TenantHeader values
+var tenantName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// Tenant returns the tenant of r: for a bearer token, "key-" and a hash
+// of it, so the key itself never shows in logs or file names; otherwise
+// the TenantHeader, or "" for the default tenant. ok is false for a
+// TenantHeader that is not 1 to 64 letters, digits, '-' or '_', or that
+// starts with "key-".
+func Tenant(r *http.Request) (name string, ok bool) {
+	if token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); found && token != "" {
+		sum := sha256.Sum256([]byte(token))
+		return keyTenantPrefix + hex.EncodeToString(sum[:8]), true
+	}
+	name = r.Header.Get(TenantHeader)
+	if name == "" {
+		return "", true
+	}
+	if !tenantName.MatchString(name) || strings.HasPrefix(name, keyTenantPrefix) {
+		return "", false
+	}
+	return name, true
+}
+
+// errInvalidTenant is reported for a malformed TenantHeader
+var errInvalidTenant = errors.New("invalid " + TenantHeader + " header: use 1 to 64 letters, digits, '-' or '_', not starting with " + keyTenantPrefix)
+
+// PoolOption configures a CalculatorPool created by NewCalculatorPool
+type PoolOption func(*CalculatorPool)
+
+// WithTenantTTL evicts tenants idle for d. The default is
+// DefaultTenantTTL; a non-positive d keeps idle tenants until the pool
+// is full.
+func WithTenantTTL(d time.Duration) PoolOption {
+	return func(p *CalculatorPool) {
+		p.ttl = d
+	}
+}
+
+// WithMaxTenants caps the calculators held, evicting the least recently
+// used idle tenant to make room. The default is DefaultMaxTenants.
+func WithMaxTenants(n int) PoolOption {
+	return func(p *CalculatorPool) {
+		p.max = n
+	}
+}
+
+// WithTenantStore loads the state of a tenant's calculator from
+// store(tenant) when it is created, and saves it there when the tenant
+// is evicted and on Close
+func WithTenantStore(store func(tenant string) calculator.StateStore) PoolOption {
+	return func(p *CalculatorPool) {
+		p.store = store
+	}
+}
+
+// WithPoolClock sets the clock used for idle times and passed to the
+// calculators. The default is the system clock.
+func WithPoolClock(clock calculator.Clock) PoolOption {
+	return func(p *CalculatorPool) {
+		p.clock = clock
+	}
+}
+
+// WithCalculatorOptions creates the calculators of tenants with opts
+func WithCalculatorOptions(opts ...calculator.Option) PoolOption {
+	return func(p *CalculatorPool) {
+		p.calcOpts = append(p.calcOpts, opts...)
+	}
+}
+
+// CalculatorPool holds a Calculator per tenant, so that the state of one
+// tenant never reaches another. Calculators are created on first use and
+// evicted when idle for the TTL or to stay within the maximum, least
+// recently used first; evictions happen as tenants are acquired.
+// Calculators in use are never evicted, so the pool exceeds its maximum
+// while every calculator is in use. It is safe for concurrent use.
+type CalculatorPool struct {
+	log      logger.Logger
+	ttl      time.Duration
+	max      int
+	store    func(tenant string) calculator.StateStore
+	clock    calculator.Clock
+	calcOpts []calculator.Option
+
+	// mu guards the fields below. Tenants are loaded and saved under it,
+	// so an evicted tenant is saved before it can be loaded again.
+	mu      sync.Mutex
+	tenants map[string]*list.Element
+	lru     *list.List // of *tenant, most recently used first
+}
+
+// tenant is a calculator of a CalculatorPool
+type tenant struct {
+	name     string
+	calc     *calculator.Calculator
+	lastUsed time.Time
+	inUse    int
+}
+
+// systemClock is the calculator.Clock of the time package
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// NewCalculatorPool creates an empty CalculatorPool whose calculators
+// log through log
+func NewCalculatorPool(log logger.Logger, opts ...PoolOption) *CalculatorPool {
+	p := &CalculatorPool{
+		log:     log,
+		ttl:     DefaultTenantTTL,
+		max:     DefaultMaxTenants,
+		clock:   systemClock{},
+		tenants: map[string]*list.Element{},
+		lru:     list.New(),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Acquire returns the calculator of tenant name, creating it if needed,
+// and a function releasing it, which must be called once done with it
+func (p *CalculatorPool) Acquire(name string) (calc *calculator.Calculator, release func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.clock.Now()
+	p.evictIdle(now)
+
+	elem, ok := p.tenants[name]
+	if ok {
+		p.lru.MoveToFront(elem)
+	} else {
+		for p.max > 0 && p.lru.Len() >= p.max && p.evictLRU() {
+		}
+		elem = p.lru.PushFront(p.newTenant(name))
+		p.tenants[name] = elem
+	}
+	t := elem.Value.(*tenant)
+	t.inUse++
+	t.lastUsed = now
+
+	var once sync.Once
+	return t.calc, func() {
+		once.Do(func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			t.inUse--
+			t.lastUsed = p.clock.Now()
+		})
+	}
+}
+
+// Len returns the number of calculators held
+func (p *CalculatorPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lru.Len()
+}
+
+// Close saves the state of every tenant, as on eviction, and empties the
+// pool. Calculators in use keep working but are no longer saved.
+func (p *CalculatorPool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var errs []error
+	for p.lru.Len() > 0 {
+		errs = append(errs, p.evict(p.lru.Back()))
+	}
+	return errors.Join(errs...)
+}
+
+// newTenant creates the calculator of tenant name, loading its state
+func (p *CalculatorPool) newTenant(name string) *tenant {
+	log := p.log.With("tenant", name)
+	opts := append([]calculator.Option{calculator.WithClock(p.clock)}, p.calcOpts...)
+	calc := calculator.NewCalculator(log, opts...)
+	if p.store != nil {
+		if err := calc.LoadState(p.store(name)); err != nil {
+			log.Warnf("Loading the state of tenant %q failed, starting empty: %v", name, err)
+		}
+	}
+	return &tenant{name: name, calc: calc}
+}
+
+// evictIdle evicts the tenants idle for the TTL
+func (p *CalculatorPool) evictIdle(now time.Time) {
+	if p.ttl <= 0 {
+		return
+	}
+	for elem := p.lru.Back(); elem != nil; {
+		prev := elem.Prev()
+		if t := elem.Value.(*tenant); t.inUse == 0 && now.Sub(t.lastUsed) >= p.ttl {
+			_ = p.evict(elem)
+		}
+		elem = prev
+	}
+}
+
+// evictLRU evicts the least recently used idle tenant, reporting
+// whether there was one
+func (p *CalculatorPool) evictLRU() bool {
+	for elem := p.lru.Back(); elem != nil; elem = elem.Prev() {
+		if elem.Value.(*tenant).inUse == 0 {
+			_ = p.evict(elem)
+			return true
+		}
+	}
+	return false
+}
+
+// evict removes a tenant and saves its state. Failures are logged, and
+// returned for Close.
+func (p *CalculatorPool) evict(elem *list.Element) error {
+	t := p.lru.Remove(elem).(*tenant)
+	delete(p.tenants, t.name)
+	var errs []error
+	if p.store != nil {
+		if err := t.calc.SaveState(p.store(t.name)); err != nil {
+			p.log.Warnf("Saving the state of tenant %q failed: %v", t.name, err)
+			errs = append(errs, err)
+		}
+	}
+	if err := t.calc.Close(); err != nil {
+		p.log.Warnf("Closing the calculator of tenant %q failed: %v", t.name, err)
+		errs = append(errs, err)
+	}
+	p.log.Debugf("Evicted tenant %q", t.name)
+	return errors.Join(errs...)
+}
@@ -0,0 +1,251 @@
+package calcserver_test
+
+import (
+	"fmt"
+	"go-examples/pkg/calcserver"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/logger"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// poolClock is a clock tests move forward by hand
+type poolClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *poolClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *poolClock) After(time.Duration) <-chan time.Time { return nil }
+
+func (c *poolClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// tenantStores hands out a MemoryStore per tenant
+type tenantStores struct {
+	mu     sync.Mutex
+	stores map[string]*calculator.MemoryStore
+}
+
+func (s *tenantStores) store(tenant string) calculator.StateStore {
+	return s.get(tenant)
+}
+
+func (s *tenantStores) get(tenant string) *calculator.MemoryStore {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stores[tenant] == nil {
+		s.stores[tenant] = calculator.NewMemoryStore()
+	}
+	return s.stores[tenant]
+}
+
+// newPool creates a CalculatorPool with a fake clock and a store per
+// tenant
+func newPool(t *testing.T, opts ...calcserver.PoolOption) (*calcserver.CalculatorPool, *poolClock, *tenantStores) {
+	t.Helper()
+	log, _ := logger.NewObserved(zapcore.DebugLevel)
+	clock := &poolClock{now: time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)}
+	stores := &tenantStores{stores: map[string]*calculator.MemoryStore{}}
+	opts = append([]calcserver.PoolOption{calcserver.WithPoolClock(clock), calcserver.WithTenantStore(stores.store)}, opts...)
+	return calcserver.NewCalculatorPool(log, opts...), clock, stores
+}
+
+// history returns the history saved by calc
+func history(t *testing.T, calc *calculator.Calculator) []calculator.Entry {
+	t.Helper()
+	store := calculator.NewMemoryStore()
+	if err := calc.SaveState(store); err != nil {
+		t.Fatal(err)
+	}
+	state, _ := store.Load()
+	return state.History
+}
+
+// TestCalculatorPoolIsolation tests that tenants get their own
+// calculators, loaded from their own stores
+func TestCalculatorPoolIsolation(t *testing.T) {
+	pool, _, stores := newPool(t)
+	if err := stores.get("alice").Save(calculator.State{History: []calculator.Entry{{Operation: "add", A: 1, B: 2, Result: 3}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	alice, releaseAlice := pool.Acquire("alice")
+	defer releaseAlice()
+	bob, releaseBob := pool.Acquire("bob")
+	defer releaseBob()
+	if alice == bob {
+		t.Fatal("tenants share a calculator")
+	}
+	if h := history(t, alice); len(h) != 1 {
+		t.Errorf("alice's history = %+v, want the stored entry", h)
+	}
+	if h := history(t, bob); len(h) != 0 {
+		t.Errorf("bob's history = %+v, want none of alice's", h)
+	}
+	if again, release := pool.Acquire("alice"); again != alice {
+		t.Error("acquiring alice again returned another calculator")
+	} else {
+		release()
+	}
+}
+
+// TestCalculatorPoolTTL tests that idle tenants are evicted, and their
+// state saved, once idle for the TTL, and that tenants in use are not
+func TestCalculatorPoolTTL(t *testing.T) {
+	pool, clock, stores := newPool(t, calcserver.WithTenantTTL(time.Minute))
+	_, release := pool.Acquire("idle")
+	release()
+	_, releaseBusy := pool.Acquire("busy")
+	defer releaseBusy()
+
+	clock.advance(59 * time.Second)
+	_, release = pool.Acquire("other")
+	release()
+	if n := pool.Len(); n != 3 {
+		t.Fatalf("Len before the TTL = %d, want 3", n)
+	}
+
+	clock.advance(time.Second)
+	_, release = pool.Acquire("other")
+	release()
+	if n := pool.Len(); n != 2 {
+		t.Errorf("Len after the TTL = %d, want 2: busy and other", n)
+	}
+	if n := stores.get("idle").Saves(); n != 1 {
+		t.Errorf("idle tenant saved %d times on eviction, want 1", n)
+	}
+	if n := stores.get("busy").Saves(); n != 0 {
+		t.Errorf("tenant in use saved %d times, want it kept", n)
+	}
+}
+
+// TestCalculatorPoolLRU tests that a full pool evicts its least recently
+// used idle tenant, and grows past the cap only while all are in use
+func TestCalculatorPoolLRU(t *testing.T) {
+	pool, clock, stores := newPool(t, calcserver.WithMaxTenants(2))
+	use := func(name string) {
+		clock.advance(time.Second)
+		_, release := pool.Acquire(name)
+		release()
+	}
+	use("a")
+	use("b")
+	use("a") // b is now the least recently used
+	use("c")
+	if n := pool.Len(); n != 2 {
+		t.Fatalf("Len = %d, want the cap of 2", n)
+	}
+	if stores.get("b").Saves() != 1 || stores.get("a").Saves() != 0 {
+		t.Errorf("evicted b %d and a %d times, want b only", stores.get("b").Saves(), stores.get("a").Saves())
+	}
+
+	_, releaseA := pool.Acquire("a")
+	_, releaseC := pool.Acquire("c")
+	_, releaseD := pool.Acquire("d")
+	if n := pool.Len(); n != 3 {
+		t.Errorf("Len with every tenant in use = %d, want 3", n)
+	}
+	releaseA()
+	releaseC()
+	releaseD()
+	use("e")
+	if n := pool.Len(); n != 2 {
+		t.Errorf("Len = %d, want back to the cap of 2 after adding e", n)
+	}
+
+	if err := pool.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := pool.Len(); n != 0 || stores.get("e").Saves() != 1 {
+		t.Errorf("after Close, Len = %d and e saved %d times, want 0 and 1", n, stores.get("e").Saves())
+	}
+}
+
+// TestCalculatorPoolConcurrent tests concurrent tenants under the race
+// detector
+func TestCalculatorPoolConcurrent(t *testing.T) {
+	pool, clock, _ := newPool(t, calcserver.WithMaxTenants(5), calcserver.WithTenantTTL(time.Second))
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				calc, release := pool.Acquire(fmt.Sprintf("tenant-%d", (w*7+i)%20))
+				if got, err := calc.Compute("add", i, w); err != nil || got != i+w {
+					t.Errorf("add %d %d = %d, %v", i, w, got, err)
+				}
+				release()
+				if i%50 == 0 {
+					clock.advance(time.Second)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if n := pool.Len(); n > 5 {
+		t.Errorf("Len = %d after all released, want at most 5", n)
+	}
+}
+
+// TestServerCalculatorPool tests that calculations resolve their tenant
+// from the API key or TenantHeader, and that malformed tenants are
+// rejected
+func TestServerCalculatorPool(t *testing.T) {
+	pool, _, _ := newPool(t)
+	s, _ := newServer(t, calcserver.WithCalculatorPool(pool))
+
+	send := func(header, value string) int {
+		req := httptest.NewRequest("POST", "/calculate", strings.NewReader(`{"operation":"add","a":1,"b":2}`))
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req)
+		return rec.Code
+	}
+	for _, h := range [][2]string{
+		{"Authorization", "Bearer key-one"},
+		{"Authorization", "Bearer key-one"},
+		{calcserver.TenantHeader, "team-b"},
+		{"", ""},
+	} {
+		if code := send(h[0], h[1]); code != http.StatusOK {
+			t.Errorf("%s: %s answered %d", h[0], h[1], code)
+		}
+	}
+	if n := pool.Len(); n != 3 {
+		t.Errorf("Len = %d, want 3 tenants: a key, team-b and the default", n)
+	}
+	for _, bad := range []string{"key-impostor", "../etc", strings.Repeat("x", 65)} {
+		if code := send(calcserver.TenantHeader, bad); code != http.StatusBadRequest {
+			t.Errorf("%s %q answered %d, want 400", calcserver.TenantHeader, bad, code)
+		}
+	}
+}
+
+// TestTenant tests that API keys are hashed into tenant names
+func TestTenant(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	req.Header.Set(calcserver.TenantHeader, "ignored")
+	name, ok := calcserver.Tenant(req)
+	if !ok || !strings.HasPrefix(name, "key-") || strings.Contains(name, "s3cret") || len(name) != len("key-")+16 {
+		t.Errorf("Tenant = %q, %v, want key- and a hash", name, ok)
+	}
+}
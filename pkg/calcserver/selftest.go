@@ -0,0 +1,259 @@
+package calcserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-examples/pkg/api"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/slogger"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+)
+
+// SelfTestRequestID is the request ID of the requests sent by SelfTest,
+// so they can be told apart in logs
+const SelfTestRequestID = "self-test"
+
+// selfTestOperands are the operands each operation is checked on
+var selfTestOperands = [2]int{12, 4}
+
+// SelfTestResult is the outcome of one self-test check. Err is nil when
+// the check passed.
+type SelfTestResult struct {
+	Name string
+	Err  error
+}
+
+// SelfTestReport lists the outcome of every self-test check in the order
+// they ran
+type SelfTestReport struct {
+	Results []SelfTestResult
+}
+
+// OK reports whether every check passed
+func (r SelfTestReport) OK() bool {
+	return len(r.Failed()) == 0
+}
+
+// Failed returns the names of the checks that failed
+func (r SelfTestReport) Failed() []string {
+	var names []string
+	for _, result := range r.Results {
+		if result.Err != nil {
+			names = append(names, result.Name)
+		}
+	}
+	return names
+}
+
+// String summarizes the report, one check per line after a count
+func (r SelfTestReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "self-test: %d checks, %d failed\n", len(r.Results), len(r.Failed()))
+	for _, result := range r.Results {
+		if result.Err != nil {
+			fmt.Fprintf(&b, "  FAIL %s: %v\n", result.Name, result.Err)
+		} else {
+			fmt.Fprintf(&b, "  ok   %s\n", result.Name)
+		}
+	}
+	return b.String()
+}
+
+// SelfTestOption configures a run of SelfTest
+type SelfTestOption func(*selfTest)
+
+// selfTest is the configuration of a SelfTest run
+type selfTest struct {
+	header http.Header
+	checks []check
+}
+
+// WithSelfTestHeader sets a header on every self-test request, such as
+// the credentials the auth middleware asks for
+func WithSelfTestHeader(key, value string) SelfTestOption {
+	return func(t *selfTest) {
+		t.header.Set(key, value)
+	}
+}
+
+// WithSelfTestCheck adds a check run after the built-in ones, such as a
+// configuration check or the connectivity to a dependency. It runs for
+// up to DefaultCheckTimeout.
+func WithSelfTestCheck(name string, fn CheckFunc) SelfTestOption {
+	return func(t *selfTest) {
+		t.checks = append(t.checks, check{name: name, fn: fn, timeout: DefaultCheckTimeout})
+	}
+}
+
+// SelfTest checks the server without a network, by sending requests
+// through Handler: one calculation per operation, compared against the
+// calculator itself, GET /operations, /health, /health/detail, which
+// runs the registered dependency checks, and /ready. The checks of
+// WithSelfTestCheck run last. Calculations are performed on the
+// server's calculator, so they show up in its metrics and audit trail
+// under SelfTestRequestID.
+func (s *Server) SelfTest(ctx context.Context, opts ...SelfTestOption) SelfTestReport {
+	t := &selfTest{header: http.Header{}}
+	for _, opt := range opts {
+		opt(t)
+	}
+	t.header.Set(slogger.RequestIDHeader, SelfTestRequestID)
+
+	var report SelfTestReport
+	run := func(name string, fn func() error) {
+		report.Results = append(report.Results, SelfTestResult{Name: name, Err: fn()})
+	}
+
+	reference := calculator.NewCalculator(nil)
+	a, b := selfTestOperands[0], selfTestOperands[1]
+	for _, op := range s.calc.Describe() {
+		if op.OperandType == calculator.OperandFloat {
+			run("calculate "+op.Name, func() error {
+				return s.selfTestFloat(ctx, t, reference, op.Name, float64(a), float64(b))
+			})
+			continue
+		}
+		if op.Random {
+			run("calculate "+op.Name, func() error {
+				return s.selfTestRandom(ctx, t, op.Name, min(a, b), max(a, b))
+			})
+			continue
+		}
+		run("calculate "+op.Name, func() error {
+			// Operations of the server's own registry have no reference
+			want, err := reference.Compute(op.Name, a, b)
+			unknown := errors.Is(err, calculator.ErrUnknownOperation)
+			if err != nil && !unknown {
+				return fmt.Errorf("reference calculator: %w", err)
+			}
+			var resp api.CalculationResponse
+			if err := s.selfTestRequest(ctx, t, "POST", "/calculate", api.CalculationRequest{Operation: op.Name, A: a, B: b}, http.StatusOK, &resp); err != nil {
+				return err
+			}
+			if !unknown && resp.Result != want {
+				return fmt.Errorf("%s %d %d = %d, want %d", op.Name, a, b, resp.Result, want)
+			}
+			return nil
+		})
+	}
+	run("operations", func() error {
+		var resp api.OperationsResponse
+		if err := s.selfTestRequest(ctx, t, "GET", "/operations", nil, http.StatusOK, &resp); err != nil {
+			return err
+		}
+		if want := len(s.calc.Describe()); len(resp.Operations) != want {
+			return fmt.Errorf("lists %d operations, want %d", len(resp.Operations), want)
+		}
+		return nil
+	})
+	run("health", func() error {
+		var resp map[string]bool
+		if err := s.selfTestRequest(ctx, t, "GET", "/health", nil, http.StatusOK, &resp); err != nil {
+			return err
+		}
+		if !resp["status"] {
+			return errors.New("reports unhealthy status")
+		}
+		return nil
+	})
+	run("health detail", func() error {
+		var resp api.HealthReport
+		err := s.selfTestRequest(ctx, t, "GET", "/health/detail", nil, http.StatusOK, &resp)
+		if resp.Status == api.HealthUnhealthy {
+			var failed []string
+			for _, c := range resp.Checks {
+				if c.Status != api.HealthHealthy && c.Critical {
+					failed = append(failed, fmt.Sprintf("%s (%s)", c.Name, c.Error))
+				}
+			}
+			return fmt.Errorf("unhealthy: %s", strings.Join(failed, ", "))
+		}
+		return err
+	})
+	run("ready", func() error {
+		status := http.StatusServiceUnavailable
+		if s.Ready() {
+			status = http.StatusOK
+		}
+		var resp map[string]bool
+		return s.selfTestRequest(ctx, t, "GET", "/ready", nil, status, &resp)
+	})
+	for _, c := range t.checks {
+		run(c.name, func() error {
+			if result := c.run(ctx); result.Error != "" {
+				return errors.New(result.Error)
+			}
+			return nil
+		})
+	}
+	return report
+}
+
+// selfTestRandom checks that the random operation name on lo and hi
+// gives a number between them, as its results cannot be compared with
+// the reference calculator
+func (s *Server) selfTestRandom(ctx context.Context, t *selfTest, name string, lo, hi int) error {
+	var resp api.CalculationResponse
+	if err := s.selfTestRequest(ctx, t, "POST", "/calculate", api.CalculationRequest{Operation: name, A: lo, B: hi}, http.StatusOK, &resp); err != nil {
+		return err
+	}
+	if resp.Result < lo || resp.Result > hi {
+		return fmt.Errorf("%s %d %d = %d, want a number between them", name, lo, hi, resp.Result)
+	}
+	return nil
+}
+
+// selfTestFloat checks the float operation name on a and b against the
+// reference calculator
+func (s *Server) selfTestFloat(ctx context.Context, t *selfTest, reference *calculator.Calculator, name string, a, b float64) error {
+	want, err := reference.ComputeFloat(name, a, b)
+	if err != nil {
+		return fmt.Errorf("reference calculator: %w", err)
+	}
+	var resp api.FloatCalculationResponse
+	req := api.FloatCalculationRequest{Operation: name, A: a, B: b, Mode: api.ModeFloat}
+	if err := s.selfTestRequest(ctx, t, "POST", "/calculate", req, http.StatusOK, &resp); err != nil {
+		return err
+	}
+	if resp.Result != want {
+		return fmt.Errorf("%s %g %g = %g, want %g", name, a, b, resp.Result, want)
+	}
+	return nil
+}
+
+// selfTestRequest sends a request through the handler and decodes the
+// response into out, failing unless it has status want
+func (s *Server) selfTestRequest(ctx context.Context, t *selfTest, method, path string, body any, want int, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req := httptest.NewRequestWithContext(ctx, method, path, reader)
+	for key, values := range t.header {
+		req.Header[key] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+
+	err := json.Unmarshal(rec.Body.Bytes(), out)
+	if rec.Code != want {
+		return fmt.Errorf("%s %s: status %d, want %d: %s", method, path, rec.Code, want, strings.TrimSpace(rec.Body.String()))
+	}
+	if err != nil {
+		return fmt.Errorf("%s %s: invalid response %q: %w", method, path, strings.TrimSpace(rec.Body.String()), err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package calcserver_test
+
+import (
+	"context"
+	"go-examples/pkg/calcserver"
+	"go-examples/pkg/httpmw"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestSelfTest tests that a working server passes every check, and that
+// failing dependency checks, extra checks and middleware rejecting the
+// requests are reported by name
+func TestSelfTest(t *testing.T) {
+	s, _ := newServer(t)
+	report := s.SelfTest(context.Background())
+	if !report.OK() {
+		t.Fatalf("self-test of a working server failed:\n%s", report)
+	}
+	if summary := strings.SplitN(report.String(), "\n", 2)[0]; !strings.HasSuffix(summary, " checks, 0 failed") {
+		t.Errorf("summary = %q", report.String())
+	}
+
+	s.HealthChecker().Register("database", failing, calcserver.Critical())
+	report = s.SelfTest(context.Background(), calcserver.WithSelfTestCheck("config", failing))
+	if want := []string{"health detail", "config"}; !reflect.DeepEqual(report.Failed(), want) {
+		t.Errorf("failed checks = %v, want %v", report.Failed(), want)
+	}
+	if !strings.Contains(report.String(), "FAIL health detail: unhealthy: database (connection refused)") {
+		t.Errorf("summary misses the failing dependency:\n%s", report)
+	}
+}
+
+// TestSelfTestHeader tests that self-test requests carry the headers
+// middleware needs
+func TestSelfTestHeader(t *testing.T) {
+	s, _ := newServer(t, calcserver.WithMiddleware(httpmw.Auth(httpmw.BearerToken("secret"))))
+	if report := s.SelfTest(context.Background()); report.OK() {
+		t.Error("self-test passed without credentials")
+	}
+	if report := s.SelfTest(context.Background(), calcserver.WithSelfTestHeader("Authorization", "Bearer secret")); !report.OK() {
+		t.Errorf("self-test with credentials failed:\n%s", report)
+	}
+}
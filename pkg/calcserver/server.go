@@ -0,0 +1,289 @@
+// Package calcserver implements the HTTP API of the calculator service.
+package calcserver
+
+import (
+	"context"
+	"errors"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/httpmw"
+	"go-examples/pkg/jobs"
+	"go-examples/pkg/logger"
+	"go-examples/pkg/metrics"
+	"go-examples/pkg/slogger"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// DefaultDrainTimeout bounds how long Shutdown waits for in-flight requests
+const DefaultDrainTimeout = 10 * time.Second
+
+// DefaultMaxTraceSteps bounds the operations a trace of /evaluate or
+// /calculate/batch reports
+const DefaultMaxTraceSteps = 100
+
+// DefaultMaxBodyBytes bounds the size of the request bodies the
+// handlers read
+const DefaultMaxBodyBytes = 1 << 20
+
+// Server serves the calculator API and reports readiness on /ready.
+type Server struct {
+	calc          *calculator.Calculator
+	log           logger.Logger
+	httpServer    *http.Server
+	ready         atomic.Bool
+	drainTimeout  time.Duration
+	middleware    []httpmw.Middleware
+	requestLogger func(*http.Request) logger.Logger
+	metrics       metrics.Provider
+	calculations  metrics.Counter
+	h2c           bool
+	health        *HealthChecker
+	jobs          *jobs.Queue
+	shadow        *shadowConfig
+	pool          *CalculatorPool
+	evalTimeout   time.Duration
+	maxTrace      int
+	maxBody       int64
+
+	mu        sync.Mutex
+	listener  net.Listener // set by Serve, for Handoff
+	accepting *handoffListener
+	unserved  map[net.Conn]struct{}
+}
+
+// Option configures a Server created by New
+type Option func(*Server)
+
+// WithMiddleware wraps every route with mw, composed with httpmw.Chain:
+// the first middleware given is the outermost.
+func WithMiddleware(mw ...httpmw.Middleware) Option {
+	return func(s *Server) {
+		s.middleware = append(s.middleware, mw...)
+	}
+}
+
+// WithDrainTimeout sets how long Shutdown waits for in-flight requests.
+// The default is DefaultDrainTimeout.
+func WithDrainTimeout(d time.Duration) Option {
+	return func(s *Server) {
+		s.drainTimeout = d
+	}
+}
+
+// WithRequestLogger sets a function returning the logger used while
+// handling r, such as one carrying the request ID. By default the
+// server's logger is used.
+func WithRequestLogger(fn func(r *http.Request) logger.Logger) Option {
+	return func(s *Server) {
+		s.requestLogger = fn
+	}
+}
+
+// WithMetrics records request metrics (see httpmw.NewRequestMetrics) and
+// calculations_total by operation through p. The default is
+// metrics.Noop.
+func WithMetrics(p metrics.Provider) Option {
+	return func(s *Server) {
+		s.metrics = p
+	}
+}
+
+// WithHealthChecker runs the checks of h on GET /health/detail. By
+// default the server has a HealthChecker of its own, returned by
+// HealthChecker.
+func WithHealthChecker(h *HealthChecker) Option {
+	return func(s *Server) {
+		s.health = h
+	}
+}
+
+// WithJobQueue drains q on Shutdown, after the last request has been
+// served, within the same drain timeout
+func WithJobQueue(q *jobs.Queue) Option {
+	return func(s *Server) {
+		s.jobs = q
+	}
+}
+
+// WithUnencryptedHTTP2 accepts HTTP/2 without TLS (h2c) with prior
+// knowledge, as sent by clients using calcclient.WithForceHTTP2, next
+// to HTTP/1
+func WithUnencryptedHTTP2() Option {
+	return func(s *Server) {
+		s.h2c = true
+	}
+}
+
+// WithEvaluationTimeout stops the evaluation of an expression on
+// /evaluate after d, answering TIMEOUT. A non-positive d, the default,
+// bounds it only by the request's own context.
+func WithEvaluationTimeout(d time.Duration) Option {
+	return func(s *Server) {
+		s.evalTimeout = max(d, 0)
+	}
+}
+
+// WithMaxTraceSteps bounds the trace asked for on /evaluate and
+// /calculate/batch to its first n operations, followed by a marker
+// counting those left out. The default is DefaultMaxTraceSteps; a
+// non-positive n reports every operation.
+func WithMaxTraceSteps(n int) Option {
+	return func(s *Server) {
+		s.maxTrace = max(n, 0)
+	}
+}
+
+// WithMaxBodyBytes answers PAYLOAD_TOO_LARGE, with status 413, to
+// requests whose body is longer than n bytes. The default is
+// DefaultMaxBodyBytes; a non-positive n reads bodies of any size.
+func WithMaxBodyBytes(n int64) Option {
+	return func(s *Server) {
+		s.maxBody = max(n, 0)
+	}
+}
+
+// WithCalculatorPool performs the calculations of each tenant, as told
+// by Tenant, on its own calculator from p instead of the calculator
+// given to New. Shutdown closes p, saving the state of every tenant.
+func WithCalculatorPool(p *CalculatorPool) Option {
+	return func(s *Server) {
+		s.pool = p
+	}
+}
+
+// New creates a Server for calc that logs through log
+func New(calc *calculator.Calculator, log logger.Logger, opts ...Option) *Server {
+	s := &Server{
+		calc:         calc,
+		log:          log,
+		drainTimeout: DefaultDrainTimeout,
+		maxTrace:     DefaultMaxTraceSteps,
+		maxBody:      DefaultMaxBodyBytes,
+		metrics:      metrics.Noop{},
+		health:       NewHealthChecker(),
+		unserved:     map[net.Conn]struct{}{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.calculations = s.metrics.Counter("calculations_total", "Successful calculations.", "operation")
+
+	router := mux.NewRouter()
+	router.HandleFunc("/calculate", s.handleCalculate).Methods("POST")
+	router.HandleFunc("/calculate/batch", s.handleBatch).Methods("POST")
+	router.HandleFunc("/evaluate", s.handleEvaluate).Methods("POST")
+	router.HandleFunc("/operations", s.handleOperations).Methods("GET")
+	router.HandleFunc("/health", s.handleHealth).Methods("GET")
+	router.HandleFunc("/health/detail", s.handleHealthDetail).Methods("GET")
+	router.HandleFunc("/ready", s.handleReady).Methods("GET")
+
+	// Metrics is outermost, so it times the whole chain and counts the
+	// requests that middleware rejects
+	mw := append([]httpmw.Middleware{httpmw.Metrics(httpmw.NewRequestMetrics(s.metrics))}, s.middleware...)
+	if s.shadow != nil {
+		// Innermost, so only requests the middleware let through are
+		// mirrored, with the request ID set by it
+		mw = append(mw, s.shadowMiddleware())
+	}
+	s.httpServer = &http.Server{
+		Handler:           httpmw.Chain(mw...)(router),
+		ReadHeaderTimeout: 5 * time.Second, // Prevent Slowloris attacks
+		ConnState:         s.trackConn,
+	}
+	if s.h2c {
+		var protocols http.Protocols
+		protocols.SetHTTP1(true)
+		protocols.SetUnencryptedHTTP2(true)
+		s.httpServer.Protocols = &protocols
+	}
+	return s
+}
+
+// Handler returns the HTTP handler with all routes and middleware
+func (s *Server) Handler() http.Handler {
+	return s.httpServer.Handler
+}
+
+// HealthChecker returns the registry of the checks run by
+// GET /health/detail, for components to register theirs
+func (s *Server) HealthChecker() *HealthChecker {
+	return s.health
+}
+
+// Ready reports whether the server is accepting traffic
+func (s *Server) Ready() bool {
+	return s.ready.Load()
+}
+
+// ListenAndServe listens on addr and serves until Shutdown
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve accepts connections on l and marks the server ready, telling the
+// process that started this one with Handoff, if any. It returns nil
+// once Shutdown has been called.
+func (s *Server) Serve(l net.Listener) error {
+	accepting := newHandoffListener(l)
+	s.mu.Lock()
+	s.listener = l
+	s.accepting = accepting
+	s.mu.Unlock()
+	s.ready.Store(true)
+	notifyParent()
+	err := s.httpServer.Serve(accepting)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Shutdown marks the server not ready, stops accepting connections, and
+// waits up to the drain timeout, or until ctx is done, for in-flight
+// requests, then the jobs of WithJobQueue, to finish. It then closes the
+// pool of WithCalculatorPool, if any.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.ready.Store(false)
+	s.log.Infof("Draining connections (timeout %s)", s.drainTimeout)
+
+	ctx, cancel := context.WithTimeout(ctx, s.drainTimeout)
+	defer cancel()
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		s.log.Warnf("Drain incomplete: %v", err)
+		return err
+	}
+	if s.jobs != nil {
+		if err := s.jobs.Drain(ctx); err != nil {
+			s.log.Warnf("Job queue drain incomplete: %v", err)
+			return err
+		}
+	}
+	if s.pool != nil {
+		if err := s.pool.Close(); err != nil {
+			s.log.Warnf("Saving tenant state incomplete: %v", err)
+			return err
+		}
+	}
+	s.log.Info("Server stopped")
+	return nil
+}
+
+// ShutdownOnFatal registers Shutdown with slogger.RegisterOnFatal, so a
+// Fatal in slog mode, or any caller of slogger.Exit, drains the server
+// before the process exits. A request that is running the Fatal call
+// itself cannot finish, so the drain then lasts the full timeout;
+// slogger.OnFatalTimeout should allow for it.
+func (s *Server) ShutdownOnFatal() (unregister func()) {
+	return slogger.RegisterOnFatal(func() {
+		_ = s.Shutdown(context.Background())
+	})
+}
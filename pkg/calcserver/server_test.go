@@ -0,0 +1,944 @@
+package calcserver_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"go-examples/pkg/api"
+	"go-examples/pkg/calcserver"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/httpmw"
+	"go-examples/pkg/jobs"
+	"go-examples/pkg/logger"
+	"go-examples/pkg/metrics/prommetrics"
+	"go-examples/pkg/slogger"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap/zapcore"
+)
+
+// newServer creates a Server with an observed logger
+func newServer(t *testing.T, opts ...calcserver.Option) (*calcserver.Server, *logger.Observed) {
+	t.Helper()
+	log, observed := logger.NewObserved(zapcore.DebugLevel)
+	return calcserver.New(calculator.NewCalculator(log), log, opts...), observed
+}
+
+// serve starts s on a local port and returns its base URL
+func serve(t *testing.T, s *calcserver.Server) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		if err := s.Serve(l); err != nil {
+			t.Errorf("Serve failed: %v", err)
+		}
+	}()
+	// Serve marks the server ready before accepting
+	for !s.Ready() {
+		time.Sleep(time.Millisecond)
+	}
+	return "http://" + l.Addr().String()
+}
+
+// TestCalculate tests the calculate endpoint for each operation
+func TestCalculate(t *testing.T) {
+	s, _ := newServer(t)
+
+	testCases := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantResult int
+		wantError  string
+		wantCode   string
+	}{
+		{"Add", `{"operation":"add","a":5,"b":3}`, http.StatusOK, 8, "", ""},
+		{"Subtract", `{"operation":"subtract","a":10,"b":4}`, http.StatusOK, 6, "", ""},
+		{"Multiply", `{"operation":"multiply","a":6,"b":7}`, http.StatusOK, 42, "", ""},
+		{"Divide", `{"operation":"divide","a":20,"b":5}`, http.StatusOK, 4, "", ""},
+		{"Is prime", `{"operation":"isprime","a":9223372036854775783}`, http.StatusOK, 1, "", ""},
+		{"Shift right", `{"operation":"shr","a":-8,"b":1}`, http.StatusOK, -4, "", ""},
+		{"Shift out of range", `{"operation":"shl","a":1,"b":64}`, http.StatusBadRequest, 0, "Out of range: shift amount 64 must be between 0 and 63", api.CodeInvalidRequest},
+		{"Division by zero", `{"operation":"divide","a":1,"b":0}`, http.StatusBadRequest, 0, "Division by zero", api.CodeDivisionByZero},
+		{"Unknown operation", `{"operation":"modulo","a":1,"b":2}`, http.StatusBadRequest, 0, "Unknown operation: modulo", api.CodeUnknownOperation},
+		{"Malformed body", `{"operation":`, http.StatusBadRequest, 0, "Invalid request format", api.CodeInvalidRequest},
+		{"Missing operation", `{"a":1,"b":2}`, http.StatusBadRequest, 0, "Invalid request: operation is required", api.CodeInvalidRequest},
+		{"Operand out of range", `{"operation":"add","a":9999999999999999999999,"b":1}`, http.StatusBadRequest, 0, "Invalid request: a must be between -9223372036854775808 and 9223372036854775807", api.CodeInvalidRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.Handler().ServeHTTP(rec, httptest.NewRequest("POST", "/calculate", bytes.NewBufferString(tc.body)))
+
+			if rec.Code != tc.wantStatus {
+				t.Errorf("expected status %d, got %d", tc.wantStatus, rec.Code)
+			}
+			var resp api.CalculationResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("invalid JSON response %q: %v", rec.Body.String(), err)
+			}
+			if resp.Result != tc.wantResult || resp.Error != tc.wantError || resp.Code != tc.wantCode || resp.Success != (tc.wantError == "") {
+				t.Errorf("unexpected response %+v", resp)
+			}
+		})
+	}
+}
+
+// TestCalculateRequestID tests that the calculator logs the request ID
+// on its operation log lines, and only a valid one
+func TestCalculateRequestID(t *testing.T) {
+	s, observed := newServer(t)
+	for _, id := range []string{"req-42", "not valid\n"} {
+		req := httptest.NewRequest("POST", "/calculate", strings.NewReader(`{"operation":"multiply","a":6,"b":7}`))
+		req.Header.Set(slogger.RequestIDHeader, id)
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+
+	entries := observed.FilterMessageContains("Calculating checked multiplication")
+	if len(entries) != 2 {
+		t.Fatalf("got %d multiplication entries, want 2: %v", len(entries), observed.All())
+	}
+	if got := entries[0].Fields["request_id"]; got != "req-42" {
+		t.Errorf("request_id = %v, want req-42", got)
+	}
+	if got, ok := entries[1].Fields["request_id"]; ok {
+		t.Errorf("invalid request ID logged as %q", got)
+	}
+
+	// An ID the middleware generates is logged as well
+	for name, mw := range map[string]httpmw.Middleware{"httpmw": httpmw.RequestID(), "slogger": slogger.Middleware(slogger.New(slogger.WithWriter(io.Discard)))} {
+		s, observed := newServer(t, calcserver.WithMiddleware(mw))
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest("POST", "/calculate", strings.NewReader(`{"operation":"multiply","a":6,"b":7}`)))
+		id := rec.Header().Get(slogger.RequestIDHeader)
+		entries := observed.FilterMessageContains("Calculating checked multiplication")
+		if id == "" || len(entries) != 1 || entries[0].Fields["request_id"] != id {
+			t.Errorf("%s: request ID %q, logged %v", name, id, entries)
+		}
+	}
+}
+
+// TestCalculateStrict tests that lossy results are rejected with
+// PRECISION_LOSS and the detail to recover, by a strict calculator or
+// for requests asking for strict mode, and returned otherwise
+func TestCalculateStrict(t *testing.T) {
+	log, _ := logger.NewObserved(zapcore.DebugLevel)
+	lenient, _ := newServer(t)
+	strict := calcserver.New(calculator.NewCalculator(log, calculator.WithStrict()), log)
+
+	tests := []struct {
+		operation string
+		a, b      int
+		lossy     int
+		detail    api.PrecisionLossDetail
+	}{
+		{"divide", 7, 2, 3, api.PrecisionLossDetail{Result: 3, Remainder: 1, Float: 3.5}},
+		{"divide", math.MinInt, -1, math.MinInt, api.PrecisionLossDetail{Result: math.MinInt, Overflow: true, Float: -math.MinInt}},
+	}
+	send := func(s *calcserver.Server, req api.CalculationRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(req)
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest("POST", "/calculate", bytes.NewReader(body)))
+		return rec
+	}
+	for _, tc := range tests {
+		req := api.CalculationRequest{Operation: tc.operation, A: tc.a, B: tc.b}
+		rec := send(lenient, req)
+		var resp api.CalculationResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK || resp.Result != tc.lossy {
+			t.Errorf("%s %d %d = %d %s, want %d", tc.operation, tc.a, tc.b, rec.Code, rec.Body.String(), tc.lossy)
+		}
+
+		strictReq := req
+		strictReq.Strict = true
+		for name, rec := range map[string]*httptest.ResponseRecorder{"-strict": send(strict, req), `"strict": true`: send(lenient, strictReq)} {
+			apiErr := api.ParseError(rec.Code, rec.Body.Bytes())
+			if rec.Code != http.StatusBadRequest || !errors.Is(apiErr, api.ErrPrecisionLoss) {
+				t.Errorf("%s: %s %d %d = %d %s, want PRECISION_LOSS", name, tc.operation, tc.a, tc.b, rec.Code, rec.Body.String())
+				continue
+			}
+			if apiErr.Precision == nil || *apiErr.Precision != tc.detail {
+				t.Errorf("%s: %s %d %d detail = %+v, want %+v", name, tc.operation, tc.a, tc.b, apiErr.Precision, tc.detail)
+			}
+		}
+	}
+
+	if rec := send(strict, api.CalculationRequest{Operation: "divide", A: 8, B: 2}); rec.Code != http.StatusOK {
+		t.Errorf("strict exact division answered %d %s", rec.Code, rec.Body.String())
+	}
+	// Overflows are errors in both modes, rather than precision losses
+	for _, s := range []*calcserver.Server{lenient, strict} {
+		rec := send(s, api.CalculationRequest{Operation: "multiply", A: math.MinInt, B: -1})
+		if apiErr := api.ParseError(rec.Code, rec.Body.Bytes()); rec.Code != http.StatusUnprocessableEntity || !errors.Is(apiErr, api.ErrOverflow) {
+			t.Errorf("multiply MinInt -1 answered %d %s, want 422 OVERFLOW", rec.Code, rec.Body.String())
+		}
+	}
+}
+
+// TestCalculateFloat tests requests in float mode, next to integer
+// requests with and without a mode
+func TestCalculateFloat(t *testing.T) {
+	s, _ := newServer(t)
+
+	tests := []struct {
+		body   string
+		status int
+		want   string
+	}{
+		{`{"operation":"divide","a":7,"b":2,"mode":"float"}`, http.StatusOK, `{"result":3.5,"success":true,"mode":"float"}`},
+		{`{"operation":"add","a":0.5,"b":0.25,"mode":"float","strict":true}`, http.StatusOK, `{"result":0.75,"success":true,"mode":"float"}`},
+		{`{"operation":"divide","a":7,"b":2}`, http.StatusOK, `{"result":3,"success":true}`},
+		{`{"operation":"divide","a":7,"b":2,"mode":"int"}`, http.StatusOK, `{"result":3,"success":true}`},
+		{`{"operation":"divide","a":7.5,"b":2}`, http.StatusBadRequest, api.CodeInvalidRequest},
+		{`{"operation":"divide","a":7,"b":2,"mode":"decimal"}`, http.StatusBadRequest, api.CodeInvalidRequest},
+		{`{"operation":"divide","a":1,"b":0,"mode":"float"}`, http.StatusBadRequest, api.CodeDivisionByZero},
+		{`{"operation":"pow","a":-8,"b":0.5,"mode":"float"}`, http.StatusBadRequest, api.CodeInvalidRequest},
+		{`{"operation":"multiply","a":1e200,"b":1e200,"mode":"float"}`, http.StatusUnprocessableEntity, api.CodeOverflow},
+		{`{"operation":"modulo","a":1,"b":1,"mode":"float"}`, http.StatusBadRequest, api.CodeUnknownOperation},
+		{`{"operation":"log","a":8,"b":2,"mode":"float"}`, http.StatusOK, `{"result":3,"success":true,"mode":"float"}`},
+		{`{"operation":"ln","a":1,"mode":"float"}`, http.StatusOK, `{"result":0,"success":true,"mode":"float"}`},
+		{`{"operation":"exp","a":0,"mode":"float"}`, http.StatusOK, `{"result":1,"success":true,"mode":"float"}`},
+		{`{"operation":"log","a":8,"b":1,"mode":"float"}`, http.StatusBadRequest, api.CodeInvalidRequest},
+		{`{"operation":"ln","a":0,"mode":"float"}`, http.StatusBadRequest, api.CodeInvalidRequest},
+		{`{"operation":"exp","a":1000,"mode":"float"}`, http.StatusUnprocessableEntity, api.CodeOverflow},
+		{`{"operation":"ln","a":1,"b":0}`, http.StatusBadRequest, api.CodeUnknownOperation},
+		{`{"operation":"add","a":"one","b":1,"mode":"float"}`, http.StatusBadRequest, api.CodeInvalidRequest},
+	}
+	for _, tc := range tests {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest("POST", "/calculate", strings.NewReader(tc.body)))
+		if rec.Code != tc.status {
+			t.Errorf("%s answered %d %s, want %d", tc.body, rec.Code, rec.Body.String(), tc.status)
+			continue
+		}
+		if tc.status != http.StatusOK {
+			if apiErr := api.ParseError(rec.Code, rec.Body.Bytes()); apiErr.Code != tc.want {
+				t.Errorf("%s answered %s, want %s", tc.body, apiErr.Code, tc.want)
+			}
+		} else if got := strings.TrimSpace(rec.Body.String()); got != tc.want {
+			t.Errorf("%s answered %s, want %s", tc.body, got, tc.want)
+		}
+	}
+}
+
+// TestCalculateBig tests requests in big mode
+func TestCalculateBig(t *testing.T) {
+	s, _ := newServer(t)
+
+	tests := []struct {
+		body   string
+		status int
+		want   string
+	}{
+		{`{"operation":"multiply","a":"9223372036854775807","b":"2","mode":"big"}`, http.StatusOK, `{"result":"18446744073709551614","success":true,"mode":"big"}`},
+		{`{"operation":"mod","a":"-7","b":"2","mode":"big"}`, http.StatusOK, `{"result":"-1","success":true,"mode":"big"}`},
+		{`{"operation":"divide","a":"1","b":"0","mode":"big"}`, http.StatusBadRequest, api.CodeDivisionByZero},
+		{`{"operation":"add","a":"1e3","b":"1","mode":"big"}`, http.StatusBadRequest, api.CodeInvalidRequest},
+		{`{"operation":"add","a":1,"b":"1","mode":"big"}`, http.StatusBadRequest, api.CodeInvalidRequest},
+		{`{"operation":"pow","a":"2","b":"100","mode":"big"}`, http.StatusBadRequest, api.CodeUnknownOperation},
+	}
+	for _, tc := range tests {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest("POST", "/calculate", strings.NewReader(tc.body)))
+		if rec.Code != tc.status {
+			t.Errorf("%s answered %d %s, want %d", tc.body, rec.Code, rec.Body.String(), tc.status)
+			continue
+		}
+		if tc.status != http.StatusOK {
+			if apiErr := api.ParseError(rec.Code, rec.Body.Bytes()); apiErr.Code != tc.want {
+				t.Errorf("%s answered %s, want %s", tc.body, apiErr.Code, tc.want)
+			}
+		} else if got := strings.TrimSpace(rec.Body.String()); got != tc.want {
+			t.Errorf("%s answered %s, want %s", tc.body, got, tc.want)
+		}
+	}
+}
+
+// TestCalculateDecimal tests requests in decimal mode
+func TestCalculateDecimal(t *testing.T) {
+	s, _ := newServer(t)
+
+	tests := []struct {
+		body   string
+		status int
+		want   string
+	}{
+		{`{"operation":"multiply","a":"19.99","b":"3","mode":"decimal"}`, http.StatusOK, `{"result":"59.97","success":true,"mode":"decimal"}`},
+		{`{"operation":"divide","a":"1.00","b":"3","mode":"decimal"}`, http.StatusOK, `{"result":"0.33","success":true,"mode":"decimal"}`},
+		{`{"operation":"add","a":"0.1","b":"0.2","mode":"decimal"}`, http.StatusOK, `{"result":"0.3","success":true,"mode":"decimal"}`},
+		{`{"operation":"divide","a":"1","b":"0.00","mode":"decimal"}`, http.StatusBadRequest, api.CodeDivisionByZero},
+		{`{"operation":"add","a":"9223372036854775807","b":"1","mode":"decimal"}`, http.StatusUnprocessableEntity, api.CodeOverflow},
+		{`{"operation":"add","a":"1e3","b":"1","mode":"decimal"}`, http.StatusBadRequest, api.CodeInvalidRequest},
+		{`{"operation":"add","a":"1","b":"","mode":"decimal"}`, http.StatusBadRequest, api.CodeInvalidRequest},
+		{`{"operation":"add","a":19.99,"b":"1","mode":"decimal"}`, http.StatusBadRequest, api.CodeInvalidRequest},
+		{`{"operation":"mod","a":"7.5","b":"2","mode":"decimal"}`, http.StatusBadRequest, api.CodeUnknownOperation},
+	}
+	for _, tc := range tests {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest("POST", "/calculate", strings.NewReader(tc.body)))
+		if rec.Code != tc.status {
+			t.Errorf("%s answered %d %s, want %d", tc.body, rec.Code, rec.Body.String(), tc.status)
+			continue
+		}
+		if tc.status != http.StatusOK {
+			if apiErr := api.ParseError(rec.Code, rec.Body.Bytes()); apiErr.Code != tc.want {
+				t.Errorf("%s answered %s, want %s", tc.body, apiErr.Code, tc.want)
+			}
+		} else if got := strings.TrimSpace(rec.Body.String()); got != tc.want {
+			t.Errorf("%s answered %s, want %s", tc.body, got, tc.want)
+		}
+	}
+}
+
+// TestCalculateRegistered tests that operations registered in the
+// registry of the server's calculator are validated, served and listed
+// without changes to the server, and that servers of other registries
+// do not have them
+func TestCalculateRegistered(t *testing.T) {
+	registry := calculator.NewRegistry()
+	if err := registry.Register("avg", func(a, b int) (int, error) { return a/2 + b/2 + (a%2+b%2)/2, nil }); err != nil {
+		t.Fatal(err)
+	}
+	log, _ := logger.NewObserved(zapcore.DebugLevel)
+	s := calcserver.New(calculator.NewCalculator(log, calculator.WithRegistry(registry)), log)
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest("POST", "/calculate", bytes.NewBufferString(`{"operation":"avg","a":7,"b":3}`)))
+	var resp api.CalculationResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON response %q: %v", rec.Body.String(), err)
+	}
+	if rec.Code != http.StatusOK || resp.Result != 5 || !resp.Success {
+		t.Errorf("avg 7 3 = %d %+v, want 5", rec.Code, resp)
+	}
+
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/operations", nil))
+	if !strings.Contains(rec.Body.String(), `"name":"avg"`) {
+		t.Errorf("/operations does not list avg: %s", rec.Body.String())
+	}
+
+	// The default registry is untouched
+	other, _ := newServer(t)
+	rec = httptest.NewRecorder()
+	other.Handler().ServeHTTP(rec, httptest.NewRequest("POST", "/calculate", bytes.NewBufferString(`{"operation":"avg","a":7,"b":3}`)))
+	if apiErr := api.ParseError(rec.Code, rec.Body.Bytes()); rec.Code != http.StatusBadRequest || apiErr.Code != api.CodeUnknownOperation {
+		t.Errorf("avg on the default registry answered %d %s, want %s", rec.Code, rec.Body.String(), api.CodeUnknownOperation)
+	}
+	rec = httptest.NewRecorder()
+	other.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/operations", nil))
+	if strings.Contains(rec.Body.String(), `"name":"avg"`) {
+		t.Errorf("/operations of the default registry lists avg: %s", rec.Body.String())
+	}
+}
+
+// TestCalculatePercentChange tests that pctchange carries its full
+// result in float_result, which other operations leave out
+func TestCalculatePercentChange(t *testing.T) {
+	s, _ := newServer(t)
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`{"operation":"pctchange","a":3,"b":4}`, `{"result":33,"float_result":33.333333333333336,"success":true}`},
+		{`{"operation":"pctchange","a":200,"b":150}`, `{"result":-25,"float_result":-25,"success":true}`},
+		{`{"operation":"pctchange","a":5,"b":5}`, `{"result":0,"float_result":0,"success":true}`},
+		{`{"operation":"divide","a":7,"b":2}`, `{"result":3,"success":true}`},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest("POST", "/calculate", bytes.NewBufferString(tt.body)))
+		if got := strings.TrimSpace(rec.Body.String()); rec.Code != http.StatusOK || got != tt.want {
+			t.Errorf("%s answered %d %s, want %s", tt.body, rec.Code, got, tt.want)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest("POST", "/calculate", bytes.NewBufferString(`{"operation":"pctchange","a":0,"b":5}`)))
+	if apiErr := api.ParseError(rec.Code, rec.Body.Bytes()); !errors.Is(apiErr, api.ErrDivisionByZero) {
+		t.Errorf("pctchange 0 5 = %d %+v, want %v", rec.Code, apiErr, api.ErrDivisionByZero)
+	}
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest("POST", "/calculate", bytes.NewBufferString(`{"operation":"pctchange","a":3,"b":4,"strict":true}`)))
+	if apiErr := api.ParseError(rec.Code, rec.Body.Bytes()); !errors.Is(apiErr, api.ErrPrecisionLoss) {
+		t.Errorf("strict pctchange 3 4 = %d %+v, want %v", rec.Code, apiErr, api.ErrPrecisionLoss)
+	}
+}
+
+// TestCalculateExplain tests that requests with explain are answered
+// with the steps of the calculation, and others without
+func TestCalculateExplain(t *testing.T) {
+	s, _ := newServer(t)
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`{"operation":"divide","a":7,"b":2,"explain":true}`, `{"result":3,"success":true,"steps":[{"description":"checked divisor 2 for zero","value":2},{"description":"7 / 2 truncates toward zero to 3","value":3},{"description":"7 / 2 leaves the remainder 1","value":1}]}`},
+		{`{"operation":"xor","a":6,"b":3,"explain":true}`, `{"result":5,"success":true,"steps":[{"description":"xor 6 3 = 5","value":5}]}`},
+		{`{"operation":"divide","a":7,"b":2}`, `{"result":3,"success":true}`},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest("POST", "/calculate", bytes.NewBufferString(tt.body)))
+		if got := strings.TrimSpace(rec.Body.String()); rec.Code != http.StatusOK || got != tt.want {
+			t.Errorf("%s answered %d %s, want %s", tt.body, rec.Code, got, tt.want)
+		}
+	}
+
+	// Failures are answered with the error only
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest("POST", "/calculate", bytes.NewBufferString(`{"operation":"divide","a":7,"b":0,"explain":true}`)))
+	var resp api.CalculationResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusBadRequest || resp.Steps != nil {
+		t.Errorf("divide 7 0 answered %d %s, want an error without steps", rec.Code, rec.Body.String())
+	}
+
+	// Only int calculations have steps to explain
+	for _, body := range []string{
+		`{"operation":"divide","a":7,"b":2,"mode":"float","explain":true}`,
+		`{"operation":"add","a":"7","b":"2","mode":"big","explain":true}`,
+		`{"operation":"add","a":"7.5","b":"2","mode":"decimal","explain":true}`,
+	} {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest("POST", "/calculate", strings.NewReader(body)))
+		apiErr := api.ParseError(rec.Code, rec.Body.Bytes())
+		if rec.Code != http.StatusBadRequest || apiErr.Code != api.CodeInvalidRequest || len(apiErr.Fields) != 1 || apiErr.Fields[0].Field != "explain" {
+			t.Errorf("%s answered %d %s, want a field error for explain", body, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+// TestCalculateRandom tests the random operation, whose range includes
+// both operands, and its error for an empty range
+func TestCalculateRandom(t *testing.T) {
+	s, _ := newServer(t)
+	for range 20 {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest("POST", "/calculate", bytes.NewBufferString(`{"operation":"random","a":1,"b":6}`)))
+		var resp api.CalculationResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid JSON response %q: %v", rec.Body.String(), err)
+		}
+		if rec.Code != http.StatusOK || resp.Result < 1 || resp.Result > 6 {
+			t.Fatalf("random 1 6 = %d %+v, want 1 to 6", rec.Code, resp)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest("POST", "/calculate", bytes.NewBufferString(`{"operation":"random","a":6,"b":1}`)))
+	if apiErr := api.ParseError(rec.Code, rec.Body.Bytes()); rec.Code != http.StatusBadRequest || apiErr.Code != api.CodeInvalidRequest {
+		t.Errorf("random 6 1 = %d %+v, want %s", rec.Code, apiErr, api.CodeInvalidRequest)
+	}
+}
+
+// TestCalculateCancelled tests that an operation is not performed for
+// a client that went away
+func TestCalculateCancelled(t *testing.T) {
+	s, _ := newServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/calculate", bytes.NewBufferString(`{"operation":"pow","a":3,"b":40}`)).WithContext(ctx)
+	s.Handler().ServeHTTP(rec, req)
+	var resp api.CalculationResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON response %q: %v", rec.Body.String(), err)
+	}
+	if rec.Code != api.StatusClientClosedRequest || resp.Success || resp.Code != api.CodeCancelled {
+		t.Errorf("pow for a cancelled request = %d %+v, want %d %s", rec.Code, resp, api.StatusClientClosedRequest, api.CodeCancelled)
+	}
+}
+
+// TestEvaluate tests the evaluate endpoint, with the limits of the
+// calculator and the evaluation timeout
+func TestEvaluate(t *testing.T) {
+	log, _ := logger.NewObserved(zapcore.DebugLevel)
+	calc := calculator.NewCalculator(log, calculator.WithMaxOperations(3), calculator.WithMaxMagnitude(1000))
+	s := calcserver.New(calc, log)
+
+	tests := []struct {
+		body   string
+		status int
+		want   string
+	}{
+		{`{"expression":"2 + 3 * (4 - 1)"}`, http.StatusOK, `{"result":11,"success":true}`},
+		{`{"expression":"1 / 0"}`, http.StatusBadRequest, api.CodeDivisionByZero},
+		{`{"expression":"2 + * 3"}`, http.StatusBadRequest, api.CodeInvalidRequest},
+		{`{"expression":""}`, http.StatusBadRequest, api.CodeInvalidRequest},
+		{`{"expression":`, http.StatusBadRequest, api.CodeInvalidRequest},
+		{`{"expression":"1 + 1 + 1 + 1 + 1"}`, http.StatusUnprocessableEntity, api.CodeLimitExceeded},
+		{`{"expression":"100 * 100 - 9999"}`, http.StatusUnprocessableEntity, api.CodeLimitExceeded},
+	}
+	for _, tc := range tests {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest("POST", "/evaluate", strings.NewReader(tc.body)))
+		if rec.Code != tc.status {
+			t.Errorf("%s answered %d %s, want %d", tc.body, rec.Code, rec.Body.String(), tc.status)
+			continue
+		}
+		if tc.status != http.StatusOK {
+			if apiErr := api.ParseError(rec.Code, rec.Body.Bytes()); apiErr.Code != tc.want {
+				t.Errorf("%s answered %s, want %s", tc.body, apiErr.Code, tc.want)
+			}
+		} else if got := strings.TrimSpace(rec.Body.String()); got != tc.want {
+			t.Errorf("%s answered %s, want %s", tc.body, got, tc.want)
+		}
+	}
+}
+
+// TestEvaluateTrace tests the trace of an expression: the reductions in
+// order, up to the one that failed, bounded with a marker
+func TestEvaluateTrace(t *testing.T) {
+	s, _ := newServer(t, calcserver.WithMaxTraceSteps(3))
+	evaluate := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest("POST", "/evaluate", strings.NewReader(body)))
+		return rec
+	}
+
+	rec := evaluate(`{"expression":"2 + 3 * 4","trace":true}`)
+	var resp api.EvaluationResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("traced evaluation answered %d %s", rec.Code, rec.Body.String())
+	}
+	want := []api.TraceStep{
+		{Index: 0, Operation: "multiply", A: 3, B: 4, Result: 12},
+		{Index: 1, Operation: "add", A: 2, B: 12, Result: 14},
+	}
+	if len(resp.Trace) != len(want) {
+		t.Fatalf("trace = %+v, want %+v", resp.Trace, want)
+	}
+	for i := range want {
+		if got := resp.Trace[i]; got.DurationMS < 0 {
+			t.Errorf("step %d took %gms", i, got.DurationMS)
+		}
+		resp.Trace[i].DurationMS = 0
+		if resp.Trace[i] != want[i] {
+			t.Errorf("step %d = %+v, want %+v", i, resp.Trace[i], want[i])
+		}
+	}
+
+	rec = evaluate(`{"expression":"2 + 3 * 4"}`)
+	if strings.Contains(rec.Body.String(), "trace") {
+		t.Errorf("untraced evaluation answered %s", rec.Body.String())
+	}
+
+	// A failure keeps the trace up to the operation that failed
+	rec = evaluate(`{"expression":"1 + 2 + 3 / (4 - 4)","trace":true}`)
+	apiErr := api.ParseError(rec.Code, rec.Body.Bytes())
+	if apiErr.Code != api.CodeDivisionByZero || len(apiErr.Trace) != 3 {
+		t.Fatalf("failed evaluation answered %d %s, want a trace of 3 steps", rec.Code, rec.Body.String())
+	}
+	if last := apiErr.Trace[2]; last.Operation != "divide" || last.A != 3 || last.B != 0 || last.Code != api.CodeDivisionByZero {
+		t.Errorf("last step = %+v, want divide 3 0 failing with %s", last, api.CodeDivisionByZero)
+	}
+
+	rec = evaluate(`{"expression":"1 + 1 + 1 + 1 + 1 + 1","trace":true}`)
+	resp = api.EvaluationResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || len(resp.Trace) != 4 {
+		t.Fatalf("long evaluation answered %s, want 3 steps and a marker", rec.Body.String())
+	}
+	if marker := resp.Trace[3]; marker.Index != 3 || marker.Operation != "" || marker.Truncated != 2 {
+		t.Errorf("marker = %+v, want 2 operations left out", marker)
+	}
+}
+
+// TestBatch tests the batch endpoint: results at the indexes of their
+// calculations, failures in their own result, the trace, and the
+// validation of every calculation before any is performed
+func TestBatch(t *testing.T) {
+	s, _ := newServer(t)
+	batch := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest("POST", "/calculate/batch", strings.NewReader(body)))
+		return rec
+	}
+
+	rec := batch(`{"calculations":[{"operation":"add","a":1,"b":2},{"operation":"divide","a":1,"b":0},{"operation":"pow","a":2,"b":10}],"trace":true}`)
+	var resp api.BatchResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK || !resp.Success {
+		t.Fatalf("batch answered %d %s", rec.Code, rec.Body.String())
+	}
+	wantResults := []api.BatchResult{
+		{Result: 3},
+		{Code: api.CodeDivisionByZero, Error: "Division by zero"},
+		{Result: 1024},
+	}
+	if len(resp.Results) != len(wantResults) || len(resp.Trace) != len(wantResults) {
+		t.Fatalf("batch answered %s, want 3 results and 3 steps", rec.Body.String())
+	}
+	for i, want := range wantResults {
+		if resp.Results[i] != want {
+			t.Errorf("result %d = %+v, want %+v", i, resp.Results[i], want)
+		}
+		if step := resp.Trace[i]; step.Index != i || step.Code != want.Code || step.DurationMS < 0 {
+			t.Errorf("step %d = %+v, want index %d with code %q", i, step, i, want.Code)
+		}
+	}
+
+	rec = batch(`{"calculations":[{"operation":"add","a":1,"b":2},{"operation":"modulo","a":1,"b":2}]}`)
+	apiErr := api.ParseError(rec.Code, rec.Body.Bytes())
+	if rec.Code != http.StatusBadRequest || apiErr.Code != api.CodeInvalidRequest || len(apiErr.Fields) != 1 || apiErr.Fields[0].Field != "calculations[1].operation" {
+		t.Errorf("batch with an unknown operation answered %d %s, want a field error for calculations[1].operation", rec.Code, rec.Body.String())
+	}
+	if rec = batch(`{"calculations":`); rec.Code != http.StatusBadRequest {
+		t.Errorf("malformed batch answered %d, want 400", rec.Code)
+	}
+}
+
+// slowObserver delays each operation it is told about
+type slowObserver struct{ delay time.Duration }
+
+func (o slowObserver) OnOperation(string, int, int, int, error, time.Duration) { time.Sleep(o.delay) }
+
+// TestEvaluateTimeout tests that the evaluation timeout, and the context
+// of the request, stop an expression between its operations
+func TestEvaluateTimeout(t *testing.T) {
+	log, _ := logger.NewObserved(zapcore.DebugLevel)
+	calc := calculator.NewCalculator(log)
+	var counts calculator.CountingObserver
+	calc.AddObserver(slowObserver{delay: 20 * time.Millisecond})
+	calc.AddObserver(&counts)
+	s := calcserver.New(calc, log, calcserver.WithEvaluationTimeout(time.Millisecond))
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest("POST", "/evaluate", strings.NewReader(`{"expression":"1 + 1 + 1 + 1"}`)))
+	if apiErr := api.ParseError(rec.Code, rec.Body.Bytes()); rec.Code != http.StatusRequestTimeout || apiErr.Code != api.CodeTimeout {
+		t.Errorf("slow expression answered %d %s, want %d %s", rec.Code, rec.Body.String(), http.StatusRequestTimeout, api.CodeTimeout)
+	}
+	if got := counts.Count("add") - counts.Failures("add"); got != 1 {
+		t.Errorf("additions performed = %d, want 1 before the timeout", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest("POST", "/evaluate", strings.NewReader(`{"expression":"1 + 1"}`)).WithContext(ctx))
+	if apiErr := api.ParseError(rec.Code, rec.Body.Bytes()); rec.Code != api.StatusClientClosedRequest || apiErr.Code != api.CodeCancelled {
+		t.Errorf("cancelled request answered %d %s, want %d %s", rec.Code, rec.Body.String(), api.StatusClientClosedRequest, api.CodeCancelled)
+	}
+}
+
+// TestMaxBodyBytes tests that every route reading a body answers
+// PAYLOAD_TOO_LARGE to one above the limit, and serves one within it
+func TestMaxBodyBytes(t *testing.T) {
+	s, _ := newServer(t, calcserver.WithMaxBodyBytes(64))
+	tests := []struct {
+		path, body string
+	}{
+		{"/calculate", `{"operation":"add","a":1,"b":2}`},
+		{"/calculate", `{"operation":"add","a":"1","b":"2","mode":"big"}`},
+		{"/evaluate", `{"expression":"1 + 2"}`},
+		{"/calculate/batch", `{"calculations":[{"operation":"add","a":1,"b":2}]}`},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest("POST", tt.path, strings.NewReader(tt.body)))
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s %s answered %d %s, want 200", tt.path, tt.body, rec.Code, rec.Body.String())
+		}
+
+		padded := strings.Replace(tt.body, "{", "{"+strings.Repeat(" ", 64), 1)
+		rec = httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest("POST", tt.path, strings.NewReader(padded)))
+		if apiErr := api.ParseError(rec.Code, rec.Body.Bytes()); rec.Code != http.StatusRequestEntityTooLarge || apiErr.Code != api.CodePayloadTooLarge {
+			t.Errorf("%s with a %d-byte body answered %d %s, want %d %s", tt.path, len(padded), rec.Code, rec.Body.String(), http.StatusRequestEntityTooLarge, api.CodePayloadTooLarge)
+		}
+	}
+}
+
+// TestOperations tests that GET /operations lists every operation of the
+// calculator and that /calculate accepts each of them
+func TestOperations(t *testing.T) {
+	s, _ := newServer(t)
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/operations", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	var resp api.OperationsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON response %q: %v", rec.Body.String(), err)
+	}
+
+	want := calculator.Describe()
+	if len(resp.Operations) != len(want) {
+		t.Fatalf("expected %d operations, got %+v", len(want), resp.Operations)
+	}
+	for i, op := range resp.Operations {
+		if op.Name != want[i].Name || op.Arity != string(want[i].Arity) || op.OperandType != string(want[i].OperandType) || op.Aliases == nil {
+			t.Errorf("operation %d = %+v, want %+v", i, op, want[i])
+		}
+		for _, name := range append([]string{op.Name}, op.Aliases...) {
+			body := `{"operation":"` + name + `","a":6,"b":3}`
+			if op.OperandType == string(calculator.OperandFloat) {
+				body = `{"operation":"` + name + `","a":6,"b":3,"mode":"float"}`
+			} else if want[i].Random {
+				body = `{"operation":"` + name + `","a":3,"b":6}`
+			}
+			rec := httptest.NewRecorder()
+			s.Handler().ServeHTTP(rec, httptest.NewRequest("POST", "/calculate", bytes.NewBufferString(body)))
+			if rec.Code != http.StatusOK {
+				t.Errorf("listed operation %s answered %d: %s", name, rec.Code, rec.Body.String())
+			}
+		}
+	}
+}
+
+// TestErrorCarriesRequestID tests that error bodies report the request ID
+// assigned by the slogger middleware
+func TestErrorCarriesRequestID(t *testing.T) {
+	mw := slogger.Middleware(slogger.New(slogger.WithWriter(io.Discard)))
+	s, _ := newServer(t, calcserver.WithMiddleware(mw))
+
+	req := httptest.NewRequest("POST", "/calculate", bytes.NewBufferString(`{"operation":"divide","a":1,"b":0}`))
+	req.Header.Set(slogger.RequestIDHeader, "req-123")
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+
+	apiErr := api.ParseError(rec.Code, rec.Body.Bytes())
+	if apiErr.RequestID != "req-123" || !errors.Is(apiErr, api.ErrDivisionByZero) {
+		t.Errorf("unexpected error %+v", apiErr)
+	}
+}
+
+// TestReadiness tests /ready across the server lifecycle
+func TestReadiness(t *testing.T) {
+	s, _ := newServer(t)
+	ready := func() int {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/ready", nil))
+		return rec.Code
+	}
+
+	if got := ready(); got != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 before serving, got %d", got)
+	}
+	serve(t, s)
+	if got := ready(); got != http.StatusOK {
+		t.Errorf("expected 200 while serving, got %d", got)
+	}
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if got := ready(); got != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 after shutdown, got %d", got)
+	}
+}
+
+// blockingRoute returns middleware that holds requests to path until release is closed
+func blockingRoute(path string, started chan<- struct{}, release <-chan struct{}) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != path {
+				next.ServeHTTP(w, r)
+				return
+			}
+			close(started)
+			<-release
+			w.WriteHeader(http.StatusOK)
+		})
+	}
+}
+
+// TestShutdownDrainsInFlight tests that in-flight requests finish during Shutdown
+func TestShutdownDrainsInFlight(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	s, observed := newServer(t, calcserver.WithMiddleware(blockingRoute("/slow", started, release)))
+	url := serve(t, s)
+
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(url + "/slow")
+		if err != nil {
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+	<-started
+
+	done := make(chan error, 1)
+	go func() { done <- s.Shutdown(context.Background()) }()
+	for s.Ready() {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+
+	if err := <-done; err != nil {
+		t.Errorf("expected a clean drain, got %v", err)
+	}
+	if got := <-status; got != http.StatusOK {
+		t.Errorf("expected the in-flight request to complete, got status %d", got)
+	}
+	if len(observed.FilterMessageContains("Server stopped")) != 1 {
+		t.Error("expected a Server stopped entry")
+	}
+}
+
+// TestShutdownDrainsJobs tests that Shutdown waits for the jobs of the
+// queue given with WithJobQueue
+func TestShutdownDrainsJobs(t *testing.T) {
+	q := jobs.New(jobs.WithWorkers(1))
+	s, _ := newServer(t, calcserver.WithJobQueue(q))
+	serve(t, s)
+
+	release := make(chan struct{})
+	id, err := q.Submit(context.Background(), func(context.Context) (any, error) {
+		<-release
+		return 42, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- s.Shutdown(context.Background()) }()
+	select {
+	case err := <-done:
+		t.Fatalf("Shutdown returned %v with a job running", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+
+	if err := <-done; err != nil {
+		t.Errorf("expected a clean drain, got %v", err)
+	}
+	if job, _ := q.Get(id); job.Status != jobs.StatusDone || job.Result != 42 {
+		t.Errorf("job = %+v, want done", job)
+	}
+	if _, err := q.Submit(context.Background(), nil); !errors.Is(err, jobs.ErrDraining) {
+		t.Errorf("Submit after Shutdown = %v, want ErrDraining", err)
+	}
+}
+
+// TestShutdownDrainTimeout tests that Shutdown gives up after the drain timeout
+func TestShutdownDrainTimeout(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	s, observed := newServer(t,
+		calcserver.WithMiddleware(blockingRoute("/slow", started, release)),
+		calcserver.WithDrainTimeout(20*time.Millisecond))
+	url := serve(t, s)
+
+	go func() {
+		if resp, err := http.Get(url + "/slow"); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+
+	if err := s.Shutdown(context.Background()); err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if len(observed.FilterMessageContains("Drain incomplete")) != 1 {
+		t.Error("expected a Drain incomplete warning")
+	}
+}
+
+// TestFatalDrainsBeforeExit tests that a Fatal in a handler drains the
+// server before the exit function runs with the Fatal's code
+func TestFatalDrainsBeforeExit(t *testing.T) {
+	origTimeout := slogger.OnFatalTimeout
+	slogger.OnFatalTimeout = time.Second
+	defer func() { slogger.OnFatalTimeout = origTimeout }()
+
+	var s *calcserver.Server
+	var observed *logger.Observed
+	var mu sync.Mutex
+	var exitCode int
+	var readyAtExit bool
+	var drainEntriesAtExit []logger.ObservedEntry
+
+	origExit := slogger.OsExit
+	slogger.OsExit = func(code int) {
+		mu.Lock()
+		defer mu.Unlock()
+		exitCode = code
+		readyAtExit = s.Ready()
+		drainEntriesAtExit = observed.FilterMessageContains("Drain")
+	}
+	defer func() { slogger.OsExit = origExit }()
+
+	fatalRoute := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/boom" {
+				slogger.New(slogger.WithWriter(io.Discard)).FatalCode(3, "unrecoverable state")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	s, observed = newServer(t, calcserver.WithMiddleware(fatalRoute), calcserver.WithDrainTimeout(50*time.Millisecond))
+	unregister := s.ShutdownOnFatal()
+	defer unregister()
+	url := serve(t, s)
+
+	resp, err := http.Get(url + "/boom")
+	if err == nil {
+		resp.Body.Close()
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if exitCode != 3 {
+		t.Errorf("expected exit code 3, got %d", exitCode)
+	}
+	if readyAtExit {
+		t.Error("expected readiness to be false before exit")
+	}
+	// The /boom request is still running, so the drain times out
+	if len(drainEntriesAtExit) != 2 || drainEntriesAtExit[0].Message != "Draining connections (timeout 50ms)" {
+		t.Errorf("expected the drain to start and finish before exit, got %+v", drainEntriesAtExit)
+	}
+}
+
+// TestWithMetrics tests that requests and successful calculations are
+// recorded through the configured provider
+func TestWithMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	s, _ := newServer(t, calcserver.WithMetrics(prommetrics.New(reg)))
+
+	for _, body := range []string{
+		`{"operation":"add","a":1,"b":2}`,
+		`{"operation":"add","a":3,"b":4}`,
+		`{"operation":"divide","a":1,"b":0}`,
+	} {
+		s.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/calculate", bytes.NewBufferString(body)))
+	}
+
+	want := `
+# HELP calculations_total Successful calculations.
+# TYPE calculations_total counter
+calculations_total{operation="add"} 2
+# HELP http_requests_total Completed HTTP requests.
+# TYPE http_requests_total counter
+http_requests_total{code="200",method="POST"} 2
+http_requests_total{code="400",method="POST"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "calculations_total", "http_requests_total"); err != nil {
+		t.Error(err)
+	}
+}
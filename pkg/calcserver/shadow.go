@@ -0,0 +1,69 @@
+package calcserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-examples/pkg/api"
+	"go-examples/pkg/httpmw"
+	"go-examples/pkg/slogger"
+	"net/url"
+)
+
+// Outcomes of mirrored requests, the label of shadow_requests_total
+const (
+	ShadowMatch    = "match"
+	ShadowMismatch = "mismatch"
+	ShadowError    = "error"
+)
+
+// shadowConfig holds the settings of WithShadow
+type shadowConfig struct {
+	target  *url.URL
+	percent int
+	opts    []httpmw.ShadowOption
+}
+
+// WithShadow mirrors percent in 100 calculations to the service at
+// target, such as a new version being rolled out, with httpmw.Shadow,
+// and compares its answers to the server's. Each mirrored request is
+// counted in shadow_requests_total by outcome, ShadowMatch,
+// ShadowMismatch or ShadowError, and mismatches and errors are logged
+// as warnings. Results, success and error codes are compared; error
+// messages and request IDs may differ.
+func WithShadow(target *url.URL, percent int, opts ...httpmw.ShadowOption) Option {
+	return func(s *Server) {
+		s.shadow = &shadowConfig{target: target, percent: percent, opts: opts}
+	}
+}
+
+// shadowMiddleware returns the middleware of WithShadow
+func (s *Server) shadowMiddleware() httpmw.Middleware {
+	outcomes := s.metrics.Counter("shadow_requests_total", "Calculations mirrored to the shadow, by outcome.", "outcome")
+	return httpmw.Shadow(s.shadow.target, s.shadow.percent, func(primary, shadow httpmw.Response) {
+		id := primary.Header.Get(slogger.RequestIDHeader)
+		switch {
+		case shadow.Err != nil:
+			outcomes.Add(1, ShadowError)
+			s.log.Warnf("Shadow request %s failed: %v", id, shadow.Err)
+		case !sameCalculation(primary, shadow):
+			outcomes.Add(1, ShadowMismatch)
+			s.log.Warnf("Shadow response to %s differs: primary %d %s, shadow %d %s",
+				id, primary.Status, bytes.TrimSpace(primary.Body), shadow.Status, bytes.TrimSpace(shadow.Body))
+		default:
+			outcomes.Add(1, ShadowMatch)
+		}
+	}, s.shadow.opts...)
+}
+
+// sameCalculation reports whether two responses to a calculation agree
+// on the status, result, success and error code
+func sameCalculation(primary, shadow httpmw.Response) bool {
+	if primary.Status != shadow.Status {
+		return false
+	}
+	var p, sh api.CalculationResponse
+	if json.Unmarshal(primary.Body, &p) != nil || json.Unmarshal(shadow.Body, &sh) != nil {
+		return bytes.Equal(bytes.TrimSpace(primary.Body), bytes.TrimSpace(shadow.Body))
+	}
+	return p.Result == sh.Result && p.Success == sh.Success && p.Code == sh.Code
+}
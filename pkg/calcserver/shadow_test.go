@@ -0,0 +1,67 @@
+package calcserver_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-examples/pkg/api"
+	"go-examples/pkg/calcserver"
+	"go-examples/pkg/metrics/prommetrics"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// TestWithShadow tests that mirrored calculations are counted by outcome
+// and that mismatches are logged
+func TestWithShadow(t *testing.T) {
+	// The shadow is a server whose multiply is off by one
+	shadowSrv, _ := newServer(t)
+	shadow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := httptest.NewRecorder()
+		shadowSrv.Handler().ServeHTTP(rec, r)
+		var resp api.CalculationResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		if resp.Result == 12 {
+			resp.Result++
+		}
+		w.WriteHeader(rec.Code)
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer shadow.Close()
+	target, _ := url.Parse(shadow.URL)
+
+	reg := prometheus.NewRegistry()
+	s, observed := newServer(t, calcserver.WithMetrics(prommetrics.New(reg)), calcserver.WithShadow(target, 100))
+	for _, body := range []string{
+		`{"operation":"add","a":1,"b":2}`,
+		`{"operation":"multiply","a":3,"b":4}`,
+		`{"operation":"divide","a":1,"b":0}`,
+	} {
+		s.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/calculate", bytes.NewBufferString(body)))
+	}
+
+	want := `
+# HELP shadow_requests_total Calculations mirrored to the shadow, by outcome.
+# TYPE shadow_requests_total counter
+shadow_requests_total{outcome="match"} 2
+shadow_requests_total{outcome="mismatch"} 1
+`
+	var err error
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if err = testutil.GatherAndCompare(reg, strings.NewReader(want), "shadow_requests_total"); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logged := observed.FilterMessageContains("Shadow response"); len(logged) != 1 || !strings.Contains(logged[0].Message, `"result":13`) {
+		t.Errorf("logged %+v, want one mismatch", logged)
+	}
+}
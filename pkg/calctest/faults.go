@@ -0,0 +1,358 @@
+// Package calctest runs the calculator service in tests with
+// programmable faults, to check how clients handle failures without
+// hand-writing a flaky server each time:
+//
+//	faults := calctest.NewFaults().
+//		Errors("/calculate", 0.2).
+//		Latency("*", 10*time.Millisecond, 50*time.Millisecond)
+//	srv := calctest.NewServer(t, faults)
+//	client := calcclient.New(srv.URL)
+//
+// Faults are chosen per request and may be changed while the server
+// runs, through the Faults methods or the control endpoint at
+// ControlPath, for tests running in another process.
+package calctest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-examples/pkg/api"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+)
+
+// ControlPath is the endpoint reading and replacing the faults of a
+// running server. It is never faulted and never counted.
+const ControlPath = "/_faults"
+
+// AllRoutes is the route whose rule applies to paths without one
+const AllRoutes = "*"
+
+// Rule is the faults injected into the requests of a route. Each
+// probability, from 0 to 1, is drawn on its own: a request first waits
+// for the latency, then its connection may be reset, then it may fail
+// with a 500, and otherwise it is served, possibly with its body
+// truncated or its Content-Type wrong.
+type Rule struct {
+	Error            float64       // probability of a 500 INTERNAL error
+	Reset            float64       // probability of resetting the connection
+	Truncate         float64       // probability of cutting the body in half
+	WrongContentType float64       // probability of answering as text/html
+	LatencyMin       time.Duration // latency, drawn uniformly from min to max
+	LatencyMax       time.Duration
+}
+
+// Faults holds the Rule of each route and counts the requests per path.
+// It is safe for concurrent use, so faults may change mid-run.
+type Faults struct {
+	mu       sync.Mutex
+	rules    map[string]Rule
+	rand     *rand.Rand
+	requests map[string]int
+}
+
+// NewFaults returns Faults injecting nothing
+func NewFaults() *Faults {
+	return &Faults{
+		rules:    map[string]Rule{},
+		rand:     rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+		requests: map[string]int{},
+	}
+}
+
+// update changes the rule of route with fn
+func (f *Faults) update(route string, fn func(*Rule)) *Faults {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rule := f.rules[route]
+	fn(&rule)
+	f.rules[route] = rule
+	return f
+}
+
+// Errors fails requests to route with a 500 with probability p
+func (f *Faults) Errors(route string, p float64) *Faults {
+	return f.update(route, func(r *Rule) { r.Error = p })
+}
+
+// Resets resets the connection of requests to route with probability p,
+// before any response
+func (f *Faults) Resets(route string, p float64) *Faults {
+	return f.update(route, func(r *Rule) { r.Reset = p })
+}
+
+// Truncate cuts the response bodies of route in half with probability p
+func (f *Faults) Truncate(route string, p float64) *Faults {
+	return f.update(route, func(r *Rule) { r.Truncate = p })
+}
+
+// WrongContentType answers requests to route as text/html with
+// probability p
+func (f *Faults) WrongContentType(route string, p float64) *Faults {
+	return f.update(route, func(r *Rule) { r.WrongContentType = p })
+}
+
+// Latency delays requests to route by a duration drawn uniformly from
+// min to max; min == max is a fixed delay
+func (f *Faults) Latency(route string, min, max time.Duration) *Faults {
+	return f.update(route, func(r *Rule) { r.LatencyMin, r.LatencyMax = min, max })
+}
+
+// Seed makes the faults drawn from now on depend only on seed
+func (f *Faults) Seed(seed uint64) *Faults {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rand = rand.New(rand.NewPCG(seed, seed))
+	return f
+}
+
+// Clear removes every rule
+func (f *Faults) Clear() *Faults {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = map[string]Rule{}
+	return f
+}
+
+// Rules returns a copy of the rules by route
+func (f *Faults) Rules() map[string]Rule {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rules := make(map[string]Rule, len(f.rules))
+	for route, rule := range f.rules {
+		rules[route] = rule
+	}
+	return rules
+}
+
+// Requests returns the requests received for path, faulted or not
+func (f *Faults) Requests(path string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[path]
+}
+
+// fault is what is injected into one request
+type fault struct {
+	latency          time.Duration
+	reset            bool
+	error            bool
+	truncate         bool
+	wrongContentType bool
+}
+
+// draw counts a request to path and draws its faults
+func (f *Faults) draw(path string) fault {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests[path]++
+	rule, ok := f.rules[path]
+	if !ok {
+		rule = f.rules[AllRoutes]
+	}
+	chance := func(p float64) bool { return p > 0 && f.rand.Float64() < p }
+	d := fault{
+		latency:          rule.LatencyMin,
+		reset:            chance(rule.Reset),
+		error:            chance(rule.Error),
+		truncate:         chance(rule.Truncate),
+		wrongContentType: chance(rule.WrongContentType),
+	}
+	if spread := rule.LatencyMax - rule.LatencyMin; spread > 0 {
+		d.latency += time.Duration(f.rand.Int64N(int64(spread) + 1))
+	}
+	return d
+}
+
+// Wrap returns next with the faults injected, and the control endpoint
+// served at ControlPath
+func (f *Faults) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == ControlPath {
+			f.serveControl(w, r)
+			return
+		}
+		d := f.draw(r.URL.Path)
+
+		if d.latency > 0 {
+			timer := time.NewTimer(d.latency)
+			select {
+			case <-timer.C:
+			case <-r.Context().Done():
+				timer.Stop()
+				return
+			}
+		}
+		if d.reset {
+			reset(w)
+			return
+		}
+		if d.error {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(api.Internal("injected fault"))
+			return
+		}
+		if !d.truncate && !d.wrongContentType {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := httptest.NewRecorder()
+		next.ServeHTTP(rec, r)
+		body := rec.Body.Bytes()
+		if d.truncate {
+			body = body[:len(body)/2]
+		}
+		for key, values := range rec.Header() {
+			w.Header()[key] = values
+		}
+		if d.wrongContentType {
+			w.Header().Set("Content-Type", "text/html")
+		}
+		w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+		w.WriteHeader(rec.Code)
+		_, _ = w.Write(body)
+	})
+}
+
+// reset closes the connection of w without a response, with a TCP reset
+// where possible
+func reset(w http.ResponseWriter) {
+	conn, _, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		panic(http.ErrAbortHandler) // makes net/http drop the connection
+	}
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		_ = tcp.SetLinger(0)
+	}
+	_ = conn.Close()
+}
+
+// ruleJSON is a Rule as read and written by the control endpoint, with
+// latencies as strings such as "150ms"
+type ruleJSON struct {
+	Error            float64 `json:"error,omitempty"`
+	Reset            float64 `json:"reset,omitempty"`
+	Truncate         float64 `json:"truncate,omitempty"`
+	WrongContentType float64 `json:"wrong_content_type,omitempty"`
+	LatencyMin       string  `json:"latency_min,omitempty"`
+	LatencyMax       string  `json:"latency_max,omitempty"`
+}
+
+// serveControl answers GET with the rules by route, replaces them with
+// the body of PUT and clears them on DELETE
+func (f *Faults) serveControl(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodDelete:
+		f.Clear()
+	case http.MethodPut:
+		var body map[string]ruleJSON
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid rules: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		rules, err := parseRules(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.mu.Lock()
+		f.rules = rules
+		f.mu.Unlock()
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(rulesJSON(f.Rules()))
+}
+
+// rulesJSON converts rules for the control endpoint
+func rulesJSON(rules map[string]Rule) map[string]ruleJSON {
+	out := make(map[string]ruleJSON, len(rules))
+	for route, rule := range rules {
+		out[route] = ruleJSON{
+			Error:            rule.Error,
+			Reset:            rule.Reset,
+			Truncate:         rule.Truncate,
+			WrongContentType: rule.WrongContentType,
+			LatencyMin:       durationString(rule.LatencyMin),
+			LatencyMax:       durationString(rule.LatencyMax),
+		}
+	}
+	return out
+}
+
+// parseRules checks the rules sent to the control endpoint
+func parseRules(body map[string]ruleJSON) (map[string]Rule, error) {
+	rules := make(map[string]Rule, len(body))
+	var errs []error
+	for route, in := range body {
+		rule := Rule{Error: in.Error, Reset: in.Reset, Truncate: in.Truncate, WrongContentType: in.WrongContentType}
+		for _, p := range []float64{in.Error, in.Reset, in.Truncate, in.WrongContentType} {
+			if p < 0 || p > 1 {
+				errs = append(errs, fmt.Errorf("%s: probability %g is not between 0 and 1", route, p))
+			}
+		}
+		var err error
+		if rule.LatencyMin, err = parseDuration(in.LatencyMin); err != nil {
+			errs = append(errs, fmt.Errorf("%s: latency_min: %w", route, err))
+		}
+		if rule.LatencyMax, err = parseDuration(in.LatencyMax); err != nil {
+			errs = append(errs, fmt.Errorf("%s: latency_max: %w", route, err))
+		}
+		if rule.LatencyMax < rule.LatencyMin {
+			rule.LatencyMax = rule.LatencyMin
+		}
+		rules[route] = rule
+	}
+	return rules, errors.Join(errs...)
+}
+
+// parseDuration parses s, with "" as zero
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// durationString formats d, with zero as ""
+func durationString(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
+
+// Control replaces the rules of the server at baseURL through its
+// control endpoint, from a test that does not share its Faults
+func Control(baseURL string, rules map[string]Rule) error {
+	data, err := json.Marshal(rulesJSON(rules))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPut, baseURL+ControlPath, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("control endpoint answered %s", resp.Status)
+	}
+	return nil
+}
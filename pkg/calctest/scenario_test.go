@@ -0,0 +1,220 @@
+package calctest_test
+
+import (
+	"context"
+	"errors"
+	"go-examples/pkg/api"
+	"go-examples/pkg/calcclient"
+	"go-examples/pkg/calctest"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// breakerClock is a breaker clock tests move forward by hand
+type breakerClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *breakerClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *breakerClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// newBreaker returns a Breaker opening after threshold failures for a
+// minute, on a fake clock
+func newBreaker(threshold int) (*calcclient.Breaker, *breakerClock) {
+	clock := &breakerClock{now: time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)}
+	return calcclient.NewBreaker(
+		calcclient.WithFailureThreshold(threshold),
+		calcclient.WithOpenDuration(time.Minute),
+		calcclient.WithBreakerClock(clock.Now),
+	), clock
+}
+
+// TestNoRetryOnClientErrors tests that a 400 is returned after a single
+// request
+func TestNoRetryOnClientErrors(t *testing.T) {
+	srv := calctest.NewServer(t, nil)
+	client := calcclient.New(srv.URL)
+	if _, err := client.Calculate(context.Background(), "divide", 1, 0); !errors.Is(err, api.ErrDivisionByZero) {
+		t.Fatalf("Calculate error = %v, want division by zero", err)
+	}
+	if n := srv.Faults.Requests("/calculate"); n != 1 {
+		t.Errorf("sent %d requests, want 1", n)
+	}
+}
+
+// TestServerErrorsKeepBreakerClosed tests that 500s are returned as
+// INTERNAL errors, once each, and are not held against the service by
+// the circuit breaker, which only counts transport failures
+func TestServerErrorsKeepBreakerClosed(t *testing.T) {
+	srv := calctest.NewServer(t, calctest.NewFaults().Errors("/calculate", 1))
+	breaker, _ := newBreaker(3)
+	client := calcclient.New(srv.URL, calcclient.WithCircuitBreaker(breaker))
+	for i := 0; i < 5; i++ {
+		var apiErr *api.APIError
+		if _, err := client.Calculate(context.Background(), "add", 1, 2); !errors.As(err, &apiErr) || !errors.Is(err, api.ErrInternal) || apiErr.HTTPStatus != http.StatusInternalServerError {
+			t.Fatalf("Calculate error = %v, want a 500 INTERNAL error", err)
+		}
+	}
+	if n := srv.Faults.Requests("/calculate"); n != 5 {
+		t.Errorf("sent %d requests, want 5", n)
+	}
+	if s := breaker.State(); s != calcclient.StateClosed {
+		t.Errorf("breaker is %s, want closed", s)
+	}
+}
+
+// TestBreakerOpensAfterResets tests that the breaker opens after the
+// threshold of connection resets, fails fast while open, and closes
+// once a probe succeeds after the faults are lifted through the control
+// endpoint
+func TestBreakerOpensAfterResets(t *testing.T) {
+	srv := calctest.NewServer(t, calctest.NewFaults().Resets(calctest.AllRoutes, 1))
+	breaker, clock := newBreaker(3)
+	client := calcclient.New(srv.URL, calcclient.WithCircuitBreaker(breaker))
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		_, err := client.Calculate(ctx, "add", 1, 2)
+		if i < 3 && (err == nil || errors.Is(err, calcclient.ErrCircuitOpen)) {
+			t.Fatalf("call %d: error = %v, want a transport error", i, err)
+		}
+		if i >= 3 && !errors.Is(err, calcclient.ErrCircuitOpen) {
+			t.Fatalf("call %d: error = %v, want ErrCircuitOpen", i, err)
+		}
+	}
+	if n := srv.Faults.Requests("/calculate"); n != 3 {
+		t.Errorf("sent %d requests, want 3 before the breaker opened", n)
+	}
+
+	if err := calctest.Control(srv.URL, nil); err != nil {
+		t.Fatal(err)
+	}
+	clock.advance(time.Minute)
+	if got, err := client.Calculate(ctx, "add", 1, 2); err != nil || got != 3 {
+		t.Fatalf("probe = %d, %v, want 3", got, err)
+	}
+	if s := breaker.State(); s != calcclient.StateClosed {
+		t.Errorf("breaker is %s after a successful probe, want closed", s)
+	}
+}
+
+// TestTimeoutCountsAsFailure tests that responses slower than the client
+// timeout fail and count against the service
+func TestTimeoutCountsAsFailure(t *testing.T) {
+	srv := calctest.NewServer(t, calctest.NewFaults().Latency("/calculate", 200*time.Millisecond, 200*time.Millisecond))
+	breaker, _ := newBreaker(1)
+	client := calcclient.New(srv.URL, calcclient.WithTimeout(20*time.Millisecond), calcclient.WithCircuitBreaker(breaker))
+
+	start := time.Now()
+	if _, err := client.Calculate(context.Background(), "add", 1, 2); err == nil {
+		t.Fatal("Calculate succeeded past the timeout")
+	}
+	if elapsed := time.Since(start); elapsed >= 200*time.Millisecond {
+		t.Errorf("Calculate took %s, want it cut at the 20ms timeout", elapsed)
+	}
+	if s := breaker.State(); s != calcclient.StateOpen {
+		t.Errorf("breaker is %s after a timeout, want open", s)
+	}
+}
+
+// TestLocalFallbackOnResets tests that the local fallback answers while
+// connections are reset
+func TestLocalFallbackOnResets(t *testing.T) {
+	srv := calctest.NewServer(t, calctest.NewFaults().Resets("/calculate", 1))
+	client := calcclient.New(srv.URL, calcclient.WithLocalFallback(nil))
+	got, source, err := client.CalculateWithSource(context.Background(), "multiply", 6, 7)
+	if err != nil || got != 42 || source != calcclient.SourceLocal {
+		t.Errorf("CalculateWithSource = %d, %s, %v, want 42 computed locally", got, source, err)
+	}
+}
+
+// TestMalformedResponses tests that truncated bodies fail without
+// counting against the service, and that a wrong Content-Type is
+// tolerated, as the client decodes the body whatever its declared type
+func TestMalformedResponses(t *testing.T) {
+	faults := calctest.NewFaults().Truncate("/calculate", 1)
+	srv := calctest.NewServer(t, faults)
+	breaker, _ := newBreaker(1)
+	client := calcclient.New(srv.URL, calcclient.WithCircuitBreaker(breaker))
+	ctx := context.Background()
+
+	if _, err := client.Calculate(ctx, "add", 1, 2); err == nil || !strings.Contains(err.Error(), "parse") {
+		t.Errorf("Calculate with a truncated body: error = %v, want a parse error", err)
+	}
+	if s := breaker.State(); s != calcclient.StateClosed {
+		t.Errorf("breaker is %s after a truncated body, want closed", s)
+	}
+
+	faults.Clear().WrongContentType("/calculate", 1)
+	if got, err := client.Calculate(ctx, "add", 1, 2); err != nil || got != 3 {
+		t.Errorf("Calculate with a wrong Content-Type = %d, %v, want 3", got, err)
+	}
+}
+
+// TestErrorProbability tests that a seeded probability fails about that
+// share of requests, and only on its route
+func TestErrorProbability(t *testing.T) {
+	srv := calctest.NewServer(t, calctest.NewFaults().Seed(1).Errors("/calculate", 0.3))
+	client := calcclient.New(srv.URL)
+	ctx := context.Background()
+	failed := 0
+	for i := 0; i < 200; i++ {
+		if _, err := client.Calculate(ctx, "add", i, 1); err != nil {
+			failed++
+		}
+		if err := client.Health(ctx); err != nil {
+			t.Fatalf("Health failed: %v", err)
+		}
+	}
+	if failed < 40 || failed > 80 {
+		t.Errorf("%d of 200 calculations failed, want about 60", failed)
+	}
+}
+
+// TestControlEndpoint tests reading and rejecting rules through the
+// control endpoint
+func TestControlEndpoint(t *testing.T) {
+	srv := calctest.NewServer(t, calctest.NewFaults().Latency("/calculate", time.Millisecond, 5*time.Millisecond))
+
+	resp, err := http.Get(srv.URL + calctest.ControlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if want := `{"/calculate":{"latency_min":"1ms","latency_max":"5ms"}}`; strings.TrimSpace(string(body)) != want {
+		t.Errorf("GET %s = %s, want %s", calctest.ControlPath, body, want)
+	}
+
+	for _, bad := range []string{`{"/calculate":{"error":2}}`, `{"/calculate":{"latency_min":"soon"}}`, `[`} {
+		req, _ := http.NewRequest(http.MethodPut, srv.URL+calctest.ControlPath, strings.NewReader(bad))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("PUT %s answered %d, want 400", bad, resp.StatusCode)
+		}
+	}
+	if rules := srv.Faults.Rules(); rules["/calculate"].LatencyMax != 5*time.Millisecond {
+		t.Errorf("rejected rules replaced %+v", rules)
+	}
+	if n := srv.Faults.Requests(calctest.ControlPath); n != 0 {
+		t.Errorf("control requests were counted %d times", n)
+	}
+}
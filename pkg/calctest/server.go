@@ -0,0 +1,35 @@
+package calctest
+
+import (
+	"go-examples/pkg/calcserver"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/logger"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Server is a calculator service with faults, listening on a local port
+type Server struct {
+	*httptest.Server
+	Faults *Faults
+}
+
+// NewServer starts the real calcserver handler, created with opts and
+// without logging, behind faults, and closes it when t ends.
+// A nil faults injects nothing until changed through the control
+// endpoint.
+func NewServer(t testing.TB, faults *Faults, opts ...calcserver.Option) *Server {
+	t.Helper()
+	if faults == nil {
+		faults = NewFaults()
+	}
+	// Only fatal entries are kept, so long runs do not pile up the
+	// warnings logged for every error response
+	log, _ := logger.NewObserved(zapcore.FatalLevel)
+	handler := calcserver.New(calculator.NewCalculator(nil), log, opts...).Handler()
+	srv := httptest.NewServer(faults.Wrap(handler))
+	t.Cleanup(srv.Close)
+	return &Server{Server: srv, Faults: faults}
+}
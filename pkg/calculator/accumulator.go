@@ -0,0 +1,70 @@
+package calculator
+
+import (
+	"go-examples/pkg/logger"
+	"sync"
+)
+
+// Accumulator is a running total shared between goroutines, such as the
+// total of a session whose requests each add to it. It is safe for
+// concurrent use: no update is lost, and none overflows.
+type Accumulator struct {
+	log     logger.Logger
+	initial int
+
+	mu    sync.Mutex
+	value int
+}
+
+// NewAccumulator creates an Accumulator holding initial, with the
+// provided logger, or without logging if log is nil
+func NewAccumulator(log logger.Logger, initial int) *Accumulator {
+	if log == nil {
+		log = noOpLogger{}
+	}
+	return &Accumulator{log: log, initial: initial, value: initial}
+}
+
+// Add adds n to the total and returns the new total. It returns
+// ErrOverflow, leaving the total unchanged, when the sum does not fit in
+// an int.
+func (a *Accumulator) Add(n int) (int, error) {
+	return a.update("add", n, addChecked)
+}
+
+// Subtract subtracts n from the total and returns the new total. It
+// returns ErrOverflow, leaving the total unchanged, when the difference
+// does not fit in an int.
+func (a *Accumulator) Subtract(n int) (int, error) {
+	return a.update("subtract", n, subtractChecked)
+}
+
+// Value returns the total
+func (a *Accumulator) Value() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.value
+}
+
+// Reset sets the total back to the initial value of NewAccumulator
+func (a *Accumulator) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.value = a.initial
+	a.log.Debugf("Accumulator reset: %d", a.initial)
+}
+
+// update sets the total to op(total, n) under the lock, so that
+// concurrent updates are not lost
+func (a *Accumulator) update(name string, n int, op func(a, b int) (int, bool)) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	value, ok := op(a.value, n)
+	if !ok {
+		a.log.With("total", a.value, "value", n).Errorf("Accumulator %s failed: %v", name, ErrOverflow)
+		return 0, ErrOverflow
+	}
+	a.value = value
+	a.log.Debugf("Accumulator %s %d: %d", name, n, value)
+	return value, nil
+}
@@ -0,0 +1,103 @@
+package calculator_test
+
+import (
+	"errors"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/logger"
+	"math"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// TestAccumulator tests the running total, its overflow and Reset
+func TestAccumulator(t *testing.T) {
+	log, logs := logger.NewObserved(zapcore.DebugLevel)
+	acc := calculator.NewAccumulator(log, 10)
+	if got := acc.Value(); got != 10 {
+		t.Errorf("Value of a new accumulator = %d, want 10", got)
+	}
+	if got, err := acc.Add(5); err != nil || got != 15 {
+		t.Errorf("Add(5) = %d, %v; want 15", got, err)
+	}
+	if got, err := acc.Subtract(20); err != nil || got != -5 {
+		t.Errorf("Subtract(20) = %d, %v; want -5", got, err)
+	}
+	if len(logs.FilterMessageContains("Accumulator subtract 20: -5")) != 1 {
+		t.Errorf("accumulator update not logged at debug level: %v", logs.All())
+	}
+
+	if _, err := acc.Subtract(math.MaxInt); !errors.Is(err, calculator.ErrOverflow) {
+		t.Errorf("Subtract(MaxInt) from -5 error = %v, want %v", err, calculator.ErrOverflow)
+	}
+	if got := acc.Value(); got != -5 {
+		t.Errorf("Value after an overflow = %d, want -5 unchanged", got)
+	}
+	acc.Reset()
+	if got := acc.Value(); got != 10 {
+		t.Errorf("Value after Reset = %d, want the initial 10", got)
+	}
+
+	top := calculator.NewAccumulator(nil, math.MaxInt)
+	if _, err := top.Add(1); !errors.Is(err, calculator.ErrOverflow) {
+		t.Errorf("Add(1) to MaxInt error = %v, want %v", err, calculator.ErrOverflow)
+	}
+	if got, err := top.Subtract(math.MaxInt); err != nil || got != 0 {
+		t.Errorf("Subtract(MaxInt) from MaxInt = %d, %v; want 0", got, err)
+	}
+}
+
+// TestAccumulatorConcurrent updates one total from hundreds of
+// goroutines; run it with -race
+func TestAccumulatorConcurrent(t *testing.T) {
+	acc := calculator.NewAccumulator(noOpBenchLogger{}, 0)
+	const goroutines, iterations = 500, 200
+
+	var wg sync.WaitGroup
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range iterations {
+				if _, err := acc.Add(g); err != nil {
+					t.Errorf("Add failed: %v", err)
+					return
+				}
+				if _, err := acc.Subtract(1); err != nil {
+					t.Errorf("Subtract failed: %v", err)
+					return
+				}
+				_ = acc.Value()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Each goroutine adds g-1 per iteration
+	want := iterations * (goroutines*(goroutines-1)/2 - goroutines)
+	if got := acc.Value(); got != want {
+		t.Errorf("total after concurrent updates = %d, want %d", got, want)
+	}
+
+	// Concurrent overflows leave the total unchanged
+	full := calculator.NewAccumulator(noOpBenchLogger{}, math.MaxInt-goroutines/2)
+	var overflows sync.WaitGroup
+	var mu sync.Mutex
+	failed := 0
+	for range goroutines {
+		overflows.Add(1)
+		go func() {
+			defer overflows.Done()
+			if _, err := full.Add(1); errors.Is(err, calculator.ErrOverflow) {
+				mu.Lock()
+				failed++
+				mu.Unlock()
+			}
+		}()
+	}
+	overflows.Wait()
+	if got := full.Value(); got != math.MaxInt || failed != goroutines-goroutines/2 {
+		t.Errorf("total %d with %d overflows, want MaxInt with %d", got, failed, goroutines-goroutines/2)
+	}
+}
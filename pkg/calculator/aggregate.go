@@ -0,0 +1,167 @@
+package calculator
+
+import (
+	"math/big"
+	"slices"
+	"time"
+)
+
+// SumSlice returns the sum of nums, ErrOverflow when it does not fit in
+// an int, or ErrEmptyInput when nums is empty
+func (c *Calculator) SumSlice(nums []int) (result int, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notifyValues("sum", []any{nums}, result, err, time.Since(start)) }()
+	}
+	c.log.Infof("Calculating sum of %d numbers", len(nums))
+	if len(nums) == 0 {
+		c.log.Error("Sum of no numbers")
+		return 0, ErrEmptyInput
+	}
+	sum := 0
+	for i, n := range nums {
+		var ok bool
+		if sum, ok = addChecked(sum, n); !ok {
+			c.log.With("index", i).Error("Sum overflow")
+			return 0, ErrOverflow
+		}
+	}
+	c.log.Debugf("Sum result: %d", sum)
+	return sum, nil
+}
+
+// Mean returns the arithmetic mean of nums, or ErrEmptyInput when nums
+// is empty. The sum is exact, so it does not overflow, and the mean is
+// rounded to the nearest float64.
+func (c *Calculator) Mean(nums []int) (result float64, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notifyValues("mean", []any{nums}, result, err, time.Since(start)) }()
+	}
+	c.log.Infof("Calculating mean of %d numbers", len(nums))
+	if len(nums) == 0 {
+		c.log.Error("Mean of no numbers")
+		return 0, ErrEmptyInput
+	}
+	result = exactMean(nums)
+	c.log.Debugf("Mean result: %g", result)
+	return result, nil
+}
+
+// Min returns the smallest of nums, or ErrEmptyInput when nums is empty
+func (c *Calculator) Min(nums []int) (result int, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notifyValues("min", []any{nums}, result, err, time.Since(start)) }()
+	}
+	c.log.Infof("Calculating minimum of %d numbers", len(nums))
+	if len(nums) == 0 {
+		c.log.Error("Minimum of no numbers")
+		return 0, ErrEmptyInput
+	}
+	result = slices.Min(nums)
+	c.log.Debugf("Minimum result: %d", result)
+	return result, nil
+}
+
+// Max returns the largest of nums, or ErrEmptyInput when nums is empty
+func (c *Calculator) Max(nums []int) (result int, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notifyValues("max", []any{nums}, result, err, time.Since(start)) }()
+	}
+	c.log.Infof("Calculating maximum of %d numbers", len(nums))
+	if len(nums) == 0 {
+		c.log.Error("Maximum of no numbers")
+		return 0, ErrEmptyInput
+	}
+	result = slices.Max(nums)
+	c.log.Debugf("Maximum result: %d", result)
+	return result, nil
+}
+
+// Average returns the mean of values rounded to the nearest int, with
+// halves rounded away from zero, so that the average of 1 and 2 is 2 and
+// that of -1 and -2 is -2, where dividing the sum truncates. The sum is
+// exact, so that averaging values near MaxInt does not overflow. It
+// returns ErrEmptyInput for no values.
+func (c *Calculator) Average(values ...int) (result int, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notifyValues("average", []any{values}, result, err, time.Since(start)) }()
+	}
+	c.log.Infof("Calculating average of %d numbers", len(values))
+	if len(values) == 0 {
+		c.log.Error("Average of no numbers")
+		return 0, ErrEmptyInput
+	}
+	sum, count := exactSum(values), big.NewInt(int64(len(values)))
+	average, remainder := new(big.Int).QuoRem(sum, count, new(big.Int))
+	// Round away from zero when the remainder is half the count or more
+	if remainder.Lsh(remainder.Abs(remainder), 1).Cmp(count) >= 0 {
+		average.Add(average, big.NewInt(int64(sum.Sign())))
+	}
+	// The average lies between the smallest and largest values, so it
+	// fits in an int
+	result = int(average.Int64())
+	c.log.Debugf("Average result: %d", result)
+	return result, nil
+}
+
+// AverageFloat returns the mean of values, the float64 nearest to the
+// exact mean, or ErrEmptyInput for no values
+func (c *Calculator) AverageFloat(values ...int) (result float64, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notifyValues("average", []any{values}, result, err, time.Since(start)) }()
+	}
+	c.log.Infof("Calculating average of %d numbers", len(values))
+	if len(values) == 0 {
+		c.log.Error("Average of no numbers")
+		return 0, ErrEmptyInput
+	}
+	result = exactMean(values)
+	c.log.Debugf("Average result: %g", result)
+	return result, nil
+}
+
+// exactMean returns the float64 nearest to the exact mean of nums, the
+// mean of Mean and AverageFloat. nums must not be empty.
+func exactMean(nums []int) float64 {
+	mean, _ := ratio(exactSum(nums), big.NewInt(int64(len(nums)))).Float64()
+	return mean
+}
+
+// exactSum returns the sum of nums, which may not fit in an int
+func exactSum(nums []int) *big.Int {
+	sum, n := new(big.Int), new(big.Int)
+	for _, num := range nums {
+		sum.Add(sum, n.SetInt64(int64(num)))
+	}
+	return sum
+}
+
+// SumSlice returns the sum of nums, or ErrOverflow or ErrEmptyInput.
+func SumSlice(nums []int) (int, error) {
+	return noOpCalculator.SumSlice(nums)
+}
+
+// Mean returns the arithmetic mean of nums, or ErrEmptyInput.
+func Mean(nums []int) (float64, error) {
+	return noOpCalculator.Mean(nums)
+}
+
+// Min returns the smallest of nums, or ErrEmptyInput.
+func Min(nums []int) (int, error) {
+	return noOpCalculator.Min(nums)
+}
+
+// Max returns the largest of nums, or ErrEmptyInput.
+func Max(nums []int) (int, error) {
+	return noOpCalculator.Max(nums)
+}
+
+// Average returns the mean of values rounded half away from zero, or
+// ErrEmptyInput.
+func Average(values ...int) (int, error) {
+	return noOpCalculator.Average(values...)
+}
+
+// AverageFloat returns the mean of values, or ErrEmptyInput.
+func AverageFloat(values ...int) (float64, error) {
+	return noOpCalculator.AverageFloat(values...)
+}
@@ -0,0 +1,135 @@
+package calculator_test
+
+import (
+	"errors"
+	"go-examples/pkg/calculator"
+	"math"
+	"testing"
+)
+
+// TestAggregates tests SumSlice, Mean, Min and Max, as methods and
+// package-level functions
+func TestAggregates(t *testing.T) {
+	calc := calculator.NewCalculator(setupTestLogger())
+	tests := []struct {
+		name     string
+		nums     []int
+		sum      int
+		mean     float64
+		min, max int
+	}{
+		{"single", []int{7}, 7, 7, 7, 7},
+		{"mixed", []int{3, -1, 4, 1, -5, 9}, 11, 11.0 / 6, -5, 9},
+		{"all negative", []int{-3, -10, -2}, -15, -5, -10, -2},
+		{"extremes", []int{math.MaxInt, math.MinInt}, -1, -0.5, math.MinInt, math.MaxInt},
+	}
+	for _, tt := range tests {
+		for _, impl := range []struct {
+			name          string
+			sum, min, max func([]int) (int, error)
+			mean          func([]int) (float64, error)
+		}{
+			{"method", calc.SumSlice, calc.Min, calc.Max, calc.Mean},
+			{"function", calculator.SumSlice, calculator.Min, calculator.Max, calculator.Mean},
+		} {
+			if got, err := impl.sum(tt.nums); err != nil || got != tt.sum {
+				t.Errorf("%s %s: SumSlice = %d, %v; want %d", impl.name, tt.name, got, err, tt.sum)
+			}
+			if got, err := impl.mean(tt.nums); err != nil || got != tt.mean {
+				t.Errorf("%s %s: Mean = %g, %v; want %g", impl.name, tt.name, got, err, tt.mean)
+			}
+			if got, err := impl.min(tt.nums); err != nil || got != tt.min {
+				t.Errorf("%s %s: Min = %d, %v; want %d", impl.name, tt.name, got, err, tt.min)
+			}
+			if got, err := impl.max(tt.nums); err != nil || got != tt.max {
+				t.Errorf("%s %s: Max = %d, %v; want %d", impl.name, tt.name, got, err, tt.max)
+			}
+		}
+	}
+}
+
+// TestAggregatesEmpty tests that every aggregate of no numbers fails
+// with ErrEmptyInput
+func TestAggregatesEmpty(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	for _, nums := range [][]int{nil, {}} {
+		if _, err := calc.SumSlice(nums); !errors.Is(err, calculator.ErrEmptyInput) {
+			t.Errorf("SumSlice(%v) error = %v, want %v", nums, err, calculator.ErrEmptyInput)
+		}
+		if _, err := calc.Mean(nums); !errors.Is(err, calculator.ErrEmptyInput) {
+			t.Errorf("Mean(%v) error = %v, want %v", nums, err, calculator.ErrEmptyInput)
+		}
+		if _, err := calc.Min(nums); !errors.Is(err, calculator.ErrEmptyInput) {
+			t.Errorf("Min(%v) error = %v, want %v", nums, err, calculator.ErrEmptyInput)
+		}
+		if _, err := calc.Max(nums); !errors.Is(err, calculator.ErrEmptyInput) {
+			t.Errorf("Max(%v) error = %v, want %v", nums, err, calculator.ErrEmptyInput)
+		}
+		if _, err := calc.Average(nums...); !errors.Is(err, calculator.ErrEmptyInput) {
+			t.Errorf("Average(%v) error = %v, want %v", nums, err, calculator.ErrEmptyInput)
+		}
+		if _, err := calc.AverageFloat(nums...); !errors.Is(err, calculator.ErrEmptyInput) {
+			t.Errorf("AverageFloat(%v) error = %v, want %v", nums, err, calculator.ErrEmptyInput)
+		}
+	}
+}
+
+// TestAggregatesOverflow tests that a sum that does not fit in an int
+// fails, even when a later number would bring it back in range, while
+// the mean of the same numbers is exact
+func TestAggregatesOverflow(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	for _, nums := range [][]int{
+		{math.MaxInt, 1},
+		{math.MinInt, -1},
+		{math.MaxInt, math.MaxInt, math.MinInt},
+	} {
+		if _, err := calc.SumSlice(nums); !errors.Is(err, calculator.ErrOverflow) {
+			t.Errorf("SumSlice(%v) error = %v, want %v", nums, err, calculator.ErrOverflow)
+		}
+	}
+	if got, err := calc.Mean([]int{math.MaxInt, math.MaxInt}); err != nil || got != math.MaxInt {
+		t.Errorf("Mean(MaxInt, MaxInt) = %g, %v; want %d", got, err, math.MaxInt)
+	}
+}
+
+// TestAverage tests rounding halves away from zero, the exact mean and
+// averages whose sums do not fit in an int
+func TestAverage(t *testing.T) {
+	calc := calculator.NewCalculator(setupTestLogger())
+	tests := []struct {
+		values  []int
+		average int
+		exact   float64
+	}{
+		{[]int{7}, 7, 7},
+		{[]int{2, 4}, 3, 3},
+		{[]int{1, 2}, 2, 1.5},
+		{[]int{-1, -2}, -2, -1.5},
+		{[]int{1, 2, 2}, 2, 5.0 / 3},
+		{[]int{1, 1, 2}, 1, 4.0 / 3},
+		{[]int{-1, -1, -2}, -1, -4.0 / 3},
+		{[]int{-3, 4}, 1, 0.5},
+		{[]int{3, -4}, -1, -0.5},
+		{[]int{0, 0, 1, 1}, 1, 0.5},
+		{[]int{math.MaxInt, math.MaxInt}, math.MaxInt, math.MaxInt},
+		{[]int{math.MaxInt, math.MaxInt - 1}, math.MaxInt, math.MaxInt},
+		{[]int{math.MinInt, math.MinInt, math.MinInt}, math.MinInt, math.MinInt},
+		{[]int{math.MinInt, math.MaxInt}, -1, -0.5},
+	}
+	for _, tt := range tests {
+		if got, err := calc.Average(tt.values...); err != nil || got != tt.average {
+			t.Errorf("Average(%v) = %d, %v; want %d", tt.values, got, err, tt.average)
+		}
+		if got, err := calculator.AverageFloat(tt.values...); err != nil || got != tt.exact {
+			t.Errorf("AverageFloat(%v) = %g, %v; want %g", tt.values, got, err, tt.exact)
+		}
+		// Mean is the same exact mean
+		if got, err := calc.Mean(tt.values); err != nil || got != tt.exact {
+			t.Errorf("Mean(%v) = %g, %v; want %g", tt.values, got, err, tt.exact)
+		}
+	}
+	if got, err := calculator.Average(5, 6, 8); err != nil || got != 6 {
+		t.Errorf("package-level Average(5, 6, 8) = %d, %v; want 6", got, err)
+	}
+}
@@ -0,0 +1,84 @@
+package calculator
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Bases accepted by ToBase and FromBase, whose digits are 0-9 then a-z
+const (
+	MinBase = 2
+	MaxBase = 36
+)
+
+// ToBase returns n written in base, with lowercase letters for the
+// digits above 9 and a leading minus sign when negative. It returns a
+// *RangeError for a base outside MinBase to MaxBase.
+func (c *Calculator) ToBase(n, base int) (result string, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notifyValues("tobase", []any{n, base}, result, err, time.Since(start)) }()
+	}
+	c.log.Infof("Converting %d to base %d", n, base)
+	if err := checkBase(base); err != nil {
+		c.log.With("n", n, "base", base).Errorf("Conversion failed: %v", err)
+		return "", err
+	}
+	result = strconv.FormatInt(int64(n), base)
+	c.log.Debugf("Conversion result: %s", result)
+	return result, nil
+}
+
+// FromBase parses s as an integer written in base, with an optional sign
+// and digits in either case. It returns a *RangeError for a base outside
+// MinBase to MaxBase, an *InvalidNumberError for a digit that is not one
+// of base, and ErrOverflow for a value that does not fit in an int.
+func (c *Calculator) FromBase(s string, base int) (result int, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notifyValues("frombase", []any{s, base}, result, err, time.Since(start)) }()
+	}
+	c.log.Infof("Converting %s from base %d", s, base)
+	if err := checkBase(base); err != nil {
+		c.log.With("s", s, "base", base).Errorf("Conversion failed: %v", err)
+		return 0, err
+	}
+	n, err := parseBase(s, base)
+	if err != nil {
+		c.log.With("s", s, "base", base).Errorf("Conversion failed: %v", err)
+		return 0, err
+	}
+	c.log.Debugf("Conversion result: %d", n)
+	return n, nil
+}
+
+// parseBase parses s in base, translating the errors of strconv
+func parseBase(s string, base int) (int, error) {
+	n, err := strconv.ParseInt(s, base, strconv.IntSize)
+	if errors.Is(err, strconv.ErrRange) {
+		return 0, ErrOverflow
+	}
+	if err != nil {
+		return 0, &InvalidNumberError{Number: shortenName(s), Reason: fmt.Sprintf("not a base %d integer", base)}
+	}
+	return int(n), nil
+}
+
+// checkBase returns a *RangeError for a base outside MinBase to MaxBase
+func checkBase(base int) error {
+	if base < MinBase || base > MaxBase {
+		return &RangeError{Name: "base", Value: base, Min: MinBase, Max: MaxBase}
+	}
+	return nil
+}
+
+// ToBase returns n written in base, or a *RangeError.
+func ToBase(n, base int) (string, error) {
+	return noOpCalculator.ToBase(n, base)
+}
+
+// FromBase parses s as an integer written in base, or returns a
+// *RangeError, an *InvalidNumberError or ErrOverflow.
+func FromBase(s string, base int) (int, error) {
+	return noOpCalculator.FromBase(s, base)
+}
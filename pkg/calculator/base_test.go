@@ -0,0 +1,131 @@
+package calculator_test
+
+import (
+	"errors"
+	"fmt"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/calculator/proptest"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+// TestToBase tests conversions to common bases
+func TestToBase(t *testing.T) {
+	calc := calculator.NewCalculator(setupTestLogger())
+	tests := []struct {
+		n, base int
+		want    string
+	}{
+		{255, 16, "ff"},
+		{255, 2, "11111111"},
+		{8, 8, "10"},
+		{-255, 16, "-ff"},
+		{0, 2, "0"},
+		{35, 36, "z"},
+		{math.MaxInt, 16, "7fffffffffffffff"},
+		{math.MinInt, 16, "-8000000000000000"},
+		{math.MinInt, 2, "-1" + fmt.Sprintf("%063d", 0)},
+	}
+	for _, tt := range tests {
+		if got, err := calc.ToBase(tt.n, tt.base); err != nil || got != tt.want {
+			t.Errorf("ToBase(%d, %d) = %q, %v; want %q", tt.n, tt.base, got, err, tt.want)
+		}
+		if got, err := calculator.ToBase(tt.n, tt.base); err != nil || got != tt.want {
+			t.Errorf("package-level ToBase(%d, %d) = %q, %v; want %q", tt.n, tt.base, got, err, tt.want)
+		}
+	}
+}
+
+// TestFromBase tests parsing, including signs, either case and the
+// errors for invalid digits and overflow
+func TestFromBase(t *testing.T) {
+	calc := calculator.NewCalculator(setupTestLogger())
+	tests := []struct {
+		s       string
+		base    int
+		want    int
+		wantErr error
+	}{
+		{s: "ff", base: 16, want: 255},
+		{s: "FF", base: 16, want: 255},
+		{s: "-ff", base: 16, want: -255},
+		{s: "+101", base: 2, want: 5},
+		{s: "z", base: 36, want: 35},
+		{s: "7fffffffffffffff", base: 16, want: math.MaxInt},
+		{s: "-8000000000000000", base: 16, want: math.MinInt},
+		{s: "8000000000000000", base: 16, wantErr: calculator.ErrOverflow},
+		{s: "-8000000000000001", base: 16, wantErr: calculator.ErrOverflow},
+		{s: "12", base: 2, wantErr: calculator.ErrInvalidNumber},
+		{s: "g", base: 16, wantErr: calculator.ErrInvalidNumber},
+		{s: "0xff", base: 16, wantErr: calculator.ErrInvalidNumber},
+		{s: "1_000", base: 10, wantErr: calculator.ErrInvalidNumber},
+		{s: "", base: 10, wantErr: calculator.ErrInvalidNumber},
+		{s: "-", base: 10, wantErr: calculator.ErrInvalidNumber},
+	}
+	for _, tt := range tests {
+		got, err := calc.FromBase(tt.s, tt.base)
+		if !errors.Is(err, tt.wantErr) || got != tt.want {
+			t.Errorf("FromBase(%q, %d) = %d, %v; want %d, %v", tt.s, tt.base, got, err, tt.want, tt.wantErr)
+		}
+		if pkgGot, pkgErr := calculator.FromBase(tt.s, tt.base); pkgGot != got || !errors.Is(pkgErr, tt.wantErr) {
+			t.Errorf("package-level FromBase(%q, %d) = %d, %v; want %d, %v", tt.s, tt.base, pkgGot, pkgErr, got, err)
+		}
+	}
+}
+
+// TestBaseOutOfRange tests that both conversions reject bases outside
+// 2 to 36
+func TestBaseOutOfRange(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	for _, base := range []int{math.MinInt, -16, 0, 1, 37, math.MaxInt} {
+		var rangeErr *calculator.RangeError
+		if _, err := calc.ToBase(10, base); !errors.As(err, &rangeErr) || rangeErr.Value != base || rangeErr.Min != calculator.MinBase || rangeErr.Max != calculator.MaxBase {
+			t.Errorf("ToBase(10, %d) error = %v, want a *RangeError", base, err)
+		}
+		if _, err := calc.FromBase("10", base); !errors.Is(err, calculator.ErrOutOfRange) {
+			t.Errorf("FromBase(10, %d) error = %v, want %v", base, err, calculator.ErrOutOfRange)
+		}
+	}
+}
+
+// TestBaseRoundTrip tests that random values and the boundaries survive
+// a conversion to and from every base
+func TestBaseRoundTrip(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	rng := rand.New(rand.NewPCG(1, 2))
+	values := append([]int{}, proptest.Boundaries...)
+	for range 200 {
+		values = append(values, int(rng.Uint64()))
+	}
+	for base := calculator.MinBase; base <= calculator.MaxBase; base++ {
+		for _, n := range values {
+			s, err := calc.ToBase(n, base)
+			if err != nil {
+				t.Fatalf("ToBase(%d, %d) failed: %v", n, base, err)
+			}
+			if got, err := calc.FromBase(s, base); err != nil || got != n {
+				t.Errorf("FromBase(ToBase(%d, %d) = %q) = %d, %v", n, base, s, got, err)
+			}
+		}
+	}
+}
+
+func ExampleCalculator_ToBase() {
+	calc := calculator.NewCalculator(nil)
+	hex, _ := calc.ToBase(-255, 16)
+	n, _ := calc.FromBase(hex, 16)
+	fmt.Println(hex, n)
+	// Output: -ff -255
+}
+
+// TestFromBaseLongNumber tests that a long invalid number is shortened in
+// the error between runes
+func TestFromBaseLongNumber(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	var invalid *calculator.InvalidNumberError
+	if _, err := calc.FromBase("1"+strings.Repeat("é", 20), 10); !errors.As(err, &invalid) || invalid.Number != "1"+strings.Repeat("é", 7)+"..." {
+		t.Errorf("FromBase(1éé..., 10) error = %v, want the number cut after 7 runes é", err)
+	}
+}
@@ -0,0 +1,33 @@
+package calculator
+
+// Call is an operation to perform with CalculateAll: the name or alias
+// of the operation and its operands
+type Call struct {
+	Operation string
+	A, B      int
+}
+
+// Result is the outcome of a Call: its value, or the error it failed
+// with
+type Result struct {
+	Value int
+	Err   error
+}
+
+// CalculateAll performs each of calls with Compute, in order, and
+// returns their results at the same indexes. A failed call, including
+// one naming an unknown operation, only sets the Err of its own Result.
+func (c *Calculator) CalculateAll(calls []Call) []Result {
+	c.log.Infof("Calculating batch of %d operations", len(calls))
+	results := make([]Result, len(calls))
+	failed := 0
+	for i, call := range calls {
+		value, err := c.Compute(call.Operation, call.A, call.B)
+		results[i] = Result{Value: value, Err: err}
+		if err != nil {
+			failed++
+		}
+	}
+	c.log.Debugf("Batch done: %d operations, %d failed", len(calls), failed)
+	return results
+}
@@ -0,0 +1,57 @@
+package calculator_test
+
+import (
+	"errors"
+	"go-examples/pkg/calculator"
+	"math"
+	"testing"
+)
+
+// TestCalculateAll tests that results keep the order of the calls and
+// that failed calls do not affect the others
+func TestCalculateAll(t *testing.T) {
+	calc := calculator.NewCalculator(setupTestLogger())
+	calls := []calculator.Call{
+		{Operation: "add", A: 5, B: 3},
+		{Operation: "divide", A: 1, B: 0},
+		{Operation: "multiply", A: 6, B: 7},
+		{Operation: "root", A: 9, B: 2},
+		{Operation: "add", A: math.MaxInt, B: 1},
+		{Operation: "pow", A: 2, B: 10},
+	}
+	want := []struct {
+		value int
+		err   error
+	}{
+		{8, nil},
+		{0, calculator.ErrDivisionByZero},
+		{42, nil},
+		{0, calculator.ErrUnknownOperation},
+		{0, calculator.ErrOverflow},
+		{1024, nil},
+	}
+
+	results := calc.CalculateAll(calls)
+	if len(results) != len(want) {
+		t.Fatalf("CalculateAll returned %d results, want %d", len(results), len(want))
+	}
+	for i, r := range results {
+		if r.Value != want[i].value || !errors.Is(r.Err, want[i].err) || (want[i].err == nil) != (r.Err == nil) {
+			t.Errorf("result %d (%+v) = %d, %v; want %d, %v", i, calls[i], r.Value, r.Err, want[i].value, want[i].err)
+		}
+	}
+	var unknown *calculator.UnknownOperationError
+	if !errors.As(results[3].Err, &unknown) || unknown.Name != "root" {
+		t.Errorf("result 3 error = %v, want an *UnknownOperationError for root", results[3].Err)
+	}
+}
+
+// TestCalculateAllEmpty tests that no calls give no results
+func TestCalculateAllEmpty(t *testing.T) {
+	calc := calculator.NewCalculator(nil)
+	for _, calls := range [][]calculator.Call{nil, {}} {
+		if results := calc.CalculateAll(calls); len(results) != 0 {
+			t.Errorf("CalculateAll(%v) = %+v, want no results", calls, results)
+		}
+	}
+}
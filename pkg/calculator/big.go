@@ -0,0 +1,127 @@
+package calculator
+
+import (
+	"fmt"
+	"go-examples/pkg/logger"
+	"math/big"
+)
+
+// MaxBigDigits bounds the digits of the operands of a BigCalculator, so
+// that untrusted input cannot make an operation arbitrarily slow
+const MaxBigDigits = 1000
+
+// BigCalculator provides arithmetic on integers of any size, as decimal
+// strings, for values that overflow int. Division truncates toward zero
+// and the remainder has the sign of the dividend, as for Calculator.
+type BigCalculator struct {
+	log logger.Logger
+}
+
+// NewBigCalculator creates a new BigCalculator with the provided logger,
+// or without logging if log is nil
+func NewBigCalculator(log logger.Logger) *BigCalculator {
+	if log == nil {
+		log = noOpLogger{}
+	}
+	return &BigCalculator{log: log}
+}
+
+// Add returns the sum of the decimal integers a and b
+func (c *BigCalculator) Add(a, b string) (string, error) {
+	c.log.Infof("Calculating addition: %s + %s", a, b)
+	return c.apply("Addition", a, b, func(z, x, y *big.Int) error {
+		z.Add(x, y)
+		return nil
+	})
+}
+
+// Subtract returns the difference between the decimal integers a and b
+func (c *BigCalculator) Subtract(a, b string) (string, error) {
+	c.log.Infof("Calculating subtraction: %s - %s", a, b)
+	return c.apply("Subtraction", a, b, func(z, x, y *big.Int) error {
+		z.Sub(x, y)
+		return nil
+	})
+}
+
+// Multiply returns the product of the decimal integers a and b
+func (c *BigCalculator) Multiply(a, b string) (string, error) {
+	c.log.Infof("Calculating multiplication: %s * %s", a, b)
+	return c.apply("Multiplication", a, b, func(z, x, y *big.Int) error {
+		z.Mul(x, y)
+		return nil
+	})
+}
+
+// Divide returns the quotient of the decimal integers a and b, truncated
+// toward zero, or ErrDivisionByZero when b is zero
+func (c *BigCalculator) Divide(a, b string) (string, error) {
+	c.log.Infof("Calculating division: %s / %s", a, b)
+	return c.apply("Division", a, b, func(z, x, y *big.Int) error {
+		if y.Sign() == 0 {
+			return ErrDivisionByZero
+		}
+		z.Quo(x, y)
+		return nil
+	})
+}
+
+// Mod returns the remainder of dividing the decimal integers a and b,
+// with the sign of a, or ErrDivisionByZero when b is zero
+func (c *BigCalculator) Mod(a, b string) (string, error) {
+	c.log.Infof("Calculating modulo: %s %% %s", a, b)
+	return c.apply("Modulo", a, b, func(z, x, y *big.Int) error {
+		if y.Sign() == 0 {
+			return ErrDivisionByZero
+		}
+		z.Rem(x, y)
+		return nil
+	})
+}
+
+// apply parses a and b and performs the operation named name with op,
+// logging the result or the failure
+func (c *BigCalculator) apply(name, a, b string, op func(z, x, y *big.Int) error) (string, error) {
+	x, err := parseBig(a)
+	if err != nil {
+		c.log.With("a", a).Errorf("%s failed: %v", name, err)
+		return "", err
+	}
+	y, err := parseBig(b)
+	if err != nil {
+		c.log.With("b", b).Errorf("%s failed: %v", name, err)
+		return "", err
+	}
+	z := new(big.Int)
+	if err := op(z, x, y); err != nil {
+		c.log.With("a", a, "b", b).Errorf("%s failed: %v", name, err)
+		return "", err
+	}
+	result := z.String()
+	c.log.Debugf("%s result: %s", name, result)
+	return result, nil
+}
+
+// parseBig parses the decimal integer s, with an optional sign, or
+// returns an *InvalidNumberError
+func parseBig(s string) (*big.Int, error) {
+	digits := s
+	if len(digits) > 0 && (digits[0] == '+' || digits[0] == '-') {
+		digits = digits[1:]
+	}
+	if len(digits) > MaxBigDigits {
+		return nil, &InvalidNumberError{Number: shortenName(s), Reason: fmt.Sprintf("more than %d digits", MaxBigDigits)}
+	}
+	// SetString would also accept underscores and prefixes such as 0x
+	// with base 0, so only plain digits are let through
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return nil, &InvalidNumberError{Number: s, Reason: "not a decimal integer"}
+		}
+	}
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, &InvalidNumberError{Number: s, Reason: "not a decimal integer"}
+	}
+	return n, nil
+}
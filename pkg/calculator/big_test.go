@@ -0,0 +1,93 @@
+package calculator_test
+
+import (
+	"errors"
+	"fmt"
+	"go-examples/pkg/calculator"
+	"strings"
+	"testing"
+)
+
+// TestBigCalculator tests the operations on integers beyond int64 and
+// the errors for invalid operands
+func TestBigCalculator(t *testing.T) {
+	calc := calculator.NewBigCalculator(setupTestLogger())
+	ops := map[string]func(a, b string) (string, error){
+		"add":      calc.Add,
+		"subtract": calc.Subtract,
+		"multiply": calc.Multiply,
+		"divide":   calc.Divide,
+		"mod":      calc.Mod,
+	}
+
+	tests := []struct {
+		op, a, b string
+		expected string
+		wantErr  error
+	}{
+		{op: "add", a: "9223372036854775807", b: "1", expected: "9223372036854775808"},
+		{op: "add", a: "+5", b: "-7", expected: "-2"},
+		{op: "subtract", a: "-9223372036854775808", b: "1", expected: "-9223372036854775809"},
+		{op: "multiply", a: "1099511627776", b: "1099511627776", expected: "1208925819614629174706176"},
+		{op: "multiply", a: "2432902008176640000", b: "21", expected: "51090942171709440000"}, // 21!
+		{op: "divide", a: "51090942171709440000", b: "20", expected: "2554547108585472000"},
+		{op: "divide", a: "-7", b: "2", expected: "-3"},
+		{op: "divide", a: "7", b: "0", wantErr: calculator.ErrDivisionByZero},
+		{op: "divide", a: "7", b: "-0", wantErr: calculator.ErrDivisionByZero},
+		{op: "mod", a: "-7", b: "2", expected: "-1"},
+		{op: "mod", a: "100000000000000000000", b: "7", expected: "2"},
+		{op: "mod", a: "1", b: "0", wantErr: calculator.ErrDivisionByZero},
+		{op: "add", a: "", b: "1", wantErr: calculator.ErrInvalidNumber},
+		{op: "add", a: "1.5", b: "1", wantErr: calculator.ErrInvalidNumber},
+		{op: "add", a: "1", b: "0x10", wantErr: calculator.ErrInvalidNumber},
+		{op: "add", a: "1_000", b: "1", wantErr: calculator.ErrInvalidNumber},
+		{op: "add", a: " 1", b: "1", wantErr: calculator.ErrInvalidNumber},
+		{op: "add", a: "-", b: "1", wantErr: calculator.ErrInvalidNumber},
+		{op: "add", a: strings.Repeat("9", calculator.MaxBigDigits), b: "1", expected: "1" + strings.Repeat("0", calculator.MaxBigDigits)},
+		{op: "add", a: strings.Repeat("9", calculator.MaxBigDigits+1), b: "1", wantErr: calculator.ErrInvalidNumber},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %.20s %s", tt.op, tt.a, tt.b), func(t *testing.T) {
+			got, err := ops[tt.op](tt.a, tt.b)
+			if !errors.Is(err, tt.wantErr) || got != tt.expected {
+				t.Errorf("%s(%.20s, %s) = %.30s, %v; want %.30s, %v", tt.op, tt.a, tt.b, got, err, tt.expected, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestComputeBig tests dispatch by name, including operations without a
+// big implementation
+func TestComputeBig(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	if got, err := calc.ComputeBig("multiply", "4294967296", "4294967296"); err != nil || got != "18446744073709551616" {
+		t.Errorf("ComputeBig(multiply, 2^32, 2^32) = %s, %v; want 18446744073709551616", got, err)
+	}
+	for _, name := range []string{"pow", "modulo"} {
+		var unknown *calculator.UnknownOperationError
+		if _, err := calc.ComputeBig(name, "2", "3"); !errors.As(err, &unknown) || unknown.Name != name {
+			t.Errorf("ComputeBig(%s) error = %v, want an unknown operation", name, err)
+		}
+	}
+	var invalid *calculator.InvalidNumberError
+	if _, err := calc.ComputeBig("add", "1", "one"); !errors.As(err, &invalid) || invalid.Number != "one" {
+		t.Errorf("ComputeBig(add, 1, one) error = %v, want an invalid number", err)
+	}
+}
+
+func ExampleBigCalculator_Multiply() {
+	calc := calculator.NewBigCalculator(nil)
+	product, _ := calc.Multiply("9223372036854775807", "9223372036854775807")
+	fmt.Println(product)
+	// Output: 85070591730234615847396907784232501249
+}
+
+// TestComputeBigLongNumber tests that an operand with too many digits is
+// shortened in the error between runes
+func TestComputeBigLongNumber(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	var invalid *calculator.InvalidNumberError
+	if _, err := calc.ComputeBig("add", "1"+strings.Repeat("é", calculator.MaxBigDigits), "1"); !errors.As(err, &invalid) || invalid.Number != "1"+strings.Repeat("é", 7)+"..." {
+		t.Errorf("ComputeBig(add, 1éé..., 1) error = %v, want the number cut after 7 runes é", err)
+	}
+}
@@ -0,0 +1,125 @@
+package calculator
+
+import "time"
+
+// MaxShift is the largest shift amount of ShiftLeft and ShiftRight
+const MaxShift = 63
+
+// And returns the bitwise AND of a and b
+func (c *Calculator) And(a, b int) (result int) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notify("and", a, b, result, nil, time.Since(start)) }()
+	}
+	c.log.Infof("Calculating bitwise and: %d & %d", a, b)
+	result = a & b
+	c.log.Debugf("Bitwise and result: %d", result)
+	return result
+}
+
+// Or returns the bitwise OR of a and b
+func (c *Calculator) Or(a, b int) (result int) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notify("or", a, b, result, nil, time.Since(start)) }()
+	}
+	c.log.Infof("Calculating bitwise or: %d | %d", a, b)
+	result = a | b
+	c.log.Debugf("Bitwise or result: %d", result)
+	return result
+}
+
+// Xor returns the bitwise exclusive OR of a and b
+func (c *Calculator) Xor(a, b int) (result int) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notify("xor", a, b, result, nil, time.Since(start)) }()
+	}
+	c.log.Infof("Calculating bitwise xor: %d ^ %d", a, b)
+	result = a ^ b
+	c.log.Debugf("Bitwise xor result: %d", result)
+	return result
+}
+
+// Not returns the bitwise complement of a, which is -a - 1 in two's
+// complement
+func (c *Calculator) Not(a int) (result int) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notify("not", a, 0, result, nil, time.Since(start)) }()
+	}
+	c.log.Infof("Calculating bitwise not: ^%d", a)
+	result = ^a
+	c.log.Debugf("Bitwise not result: %d", result)
+	return result
+}
+
+// ShiftLeft returns a shifted left by n bits, discarding the bits
+// shifted out, so that the sign can change. It returns a *RangeError for
+// an n below 0 or above MaxShift.
+func (c *Calculator) ShiftLeft(a, n int) (result int, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notify("shl", a, n, result, err, time.Since(start)) }()
+	}
+	c.log.Infof("Calculating left shift: %d << %d", a, n)
+	if err := checkShift(n); err != nil {
+		c.log.With("a", a, "n", n).Errorf("Left shift failed: %v", err)
+		return 0, err
+	}
+	result = a << n
+	c.log.Debugf("Left shift result: %d", result)
+	return result, nil
+}
+
+// ShiftRight returns a shifted right by n bits. The shift is arithmetic:
+// the sign bit is copied into the bits shifted in, so a negative a stays
+// negative and ShiftRight(-1, n) is -1 for every n. It returns a
+// *RangeError for an n below 0 or above MaxShift.
+func (c *Calculator) ShiftRight(a, n int) (result int, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notify("shr", a, n, result, err, time.Since(start)) }()
+	}
+	c.log.Infof("Calculating right shift: %d >> %d", a, n)
+	if err := checkShift(n); err != nil {
+		c.log.With("a", a, "n", n).Errorf("Right shift failed: %v", err)
+		return 0, err
+	}
+	result = a >> n
+	c.log.Debugf("Right shift result: %d", result)
+	return result, nil
+}
+
+// checkShift returns a *RangeError for a shift amount outside 0 to
+// MaxShift
+func checkShift(n int) error {
+	if n < 0 || n > MaxShift {
+		return &RangeError{Name: "shift amount", Value: n, Min: 0, Max: MaxShift}
+	}
+	return nil
+}
+
+// And returns the bitwise AND of a and b.
+func And(a, b int) int {
+	return noOpCalculator.And(a, b)
+}
+
+// Or returns the bitwise OR of a and b.
+func Or(a, b int) int {
+	return noOpCalculator.Or(a, b)
+}
+
+// Xor returns the bitwise exclusive OR of a and b.
+func Xor(a, b int) int {
+	return noOpCalculator.Xor(a, b)
+}
+
+// Not returns the bitwise complement of a.
+func Not(a int) int {
+	return noOpCalculator.Not(a)
+}
+
+// ShiftLeft returns a shifted left by n bits, or a *RangeError.
+func ShiftLeft(a, n int) (int, error) {
+	return noOpCalculator.ShiftLeft(a, n)
+}
+
+// ShiftRight returns a shifted right by n bits, or a *RangeError.
+func ShiftRight(a, n int) (int, error) {
+	return noOpCalculator.ShiftRight(a, n)
+}
@@ -0,0 +1,108 @@
+package calculator_test
+
+import (
+	"errors"
+	"fmt"
+	"go-examples/pkg/calculator"
+	"math"
+	"testing"
+)
+
+// TestBitwise tests And, Or, Xor and Not, as methods and package-level
+// functions
+func TestBitwise(t *testing.T) {
+	calc := calculator.NewCalculator(setupTestLogger())
+	tests := []struct {
+		name       string
+		method, fn func(a, b int) int
+		a, b, want int
+	}{
+		{"And", calc.And, calculator.And, 0b1100, 0b1010, 0b1000},
+		{"And", calc.And, calculator.And, -1, 0x7f, 0x7f},
+		{"And", calc.And, calculator.And, math.MinInt, -1, math.MinInt},
+		{"Or", calc.Or, calculator.Or, 0b1100, 0b1010, 0b1110},
+		{"Or", calc.Or, calculator.Or, math.MinInt, math.MaxInt, -1},
+		{"Xor", calc.Xor, calculator.Xor, 0b1100, 0b1010, 0b0110},
+		{"Xor", calc.Xor, calculator.Xor, -1, 5, -6},
+		{"Not", func(a, _ int) int { return calc.Not(a) }, func(a, _ int) int { return calculator.Not(a) }, 0, 0, -1},
+		{"Not", func(a, _ int) int { return calc.Not(a) }, func(a, _ int) int { return calculator.Not(a) }, 5, 0, -6},
+		{"Not", func(a, _ int) int { return calc.Not(a) }, func(a, _ int) int { return calculator.Not(a) }, math.MinInt, 0, math.MaxInt},
+	}
+	for _, tt := range tests {
+		if got := tt.method(tt.a, tt.b); got != tt.want {
+			t.Errorf("%s(%d, %d) = %d, want %d", tt.name, tt.a, tt.b, got, tt.want)
+		}
+		if got := tt.fn(tt.a, tt.b); got != tt.want {
+			t.Errorf("package-level %s(%d, %d) = %d, want %d", tt.name, tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// TestShift tests both shifts, including the sign extension of right
+// shifts of negative numbers and the bits lost by left shifts
+func TestShift(t *testing.T) {
+	calc := calculator.NewCalculator(setupTestLogger())
+	ops := map[string]struct {
+		method, pkg func(a, n int) (int, error)
+	}{
+		"ShiftLeft":  {calc.ShiftLeft, calculator.ShiftLeft},
+		"ShiftRight": {calc.ShiftRight, calculator.ShiftRight},
+	}
+	tests := []struct {
+		op      string
+		a, n    int
+		want    int
+		wantErr error
+	}{
+		{op: "ShiftLeft", a: 1, n: 0, want: 1},
+		{op: "ShiftLeft", a: 3, n: 4, want: 48},
+		{op: "ShiftLeft", a: -3, n: 4, want: -48},
+		{op: "ShiftLeft", a: 1, n: 63, want: math.MinInt},
+		{op: "ShiftLeft", a: 3, n: 63, want: math.MinInt},
+		{op: "ShiftLeft", a: math.MaxInt, n: 1, want: -2},
+		{op: "ShiftRight", a: 48, n: 4, want: 3},
+		{op: "ShiftRight", a: 7, n: 1, want: 3},
+		{op: "ShiftRight", a: math.MaxInt, n: 63, want: 0},
+		// Right shifts of negative numbers copy the sign bit, rounding
+		// toward negative infinity unlike Divide
+		{op: "ShiftRight", a: -8, n: 1, want: -4},
+		{op: "ShiftRight", a: -7, n: 1, want: -4},
+		{op: "ShiftRight", a: -1, n: 1, want: -1},
+		{op: "ShiftRight", a: -1, n: 63, want: -1},
+		{op: "ShiftRight", a: math.MinInt, n: 62, want: -2},
+		{op: "ShiftRight", a: math.MinInt, n: 63, want: -1},
+		{op: "ShiftLeft", a: 1, n: -1, wantErr: calculator.ErrOutOfRange},
+		{op: "ShiftLeft", a: 1, n: 64, wantErr: calculator.ErrOutOfRange},
+		{op: "ShiftRight", a: -1, n: -1, wantErr: calculator.ErrOutOfRange},
+		{op: "ShiftRight", a: -1, n: 64, wantErr: calculator.ErrOutOfRange},
+		{op: "ShiftRight", a: 1, n: math.MaxInt, wantErr: calculator.ErrOutOfRange},
+		{op: "ShiftRight", a: 1, n: math.MinInt, wantErr: calculator.ErrOutOfRange},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s(%d, %d)", tt.op, tt.a, tt.n), func(t *testing.T) {
+			got, err := ops[tt.op].method(tt.a, tt.n)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("%s(%d, %d) = %d, %v; want %d, %v", tt.op, tt.a, tt.n, got, err, tt.want, tt.wantErr)
+			}
+			if pkgGot, pkgErr := ops[tt.op].pkg(tt.a, tt.n); pkgGot != got || !errors.Is(pkgErr, tt.wantErr) {
+				t.Errorf("package-level %s(%d, %d) = %d, %v; want %d, %v", tt.op, tt.a, tt.n, pkgGot, pkgErr, got, err)
+			}
+			var rangeErr *calculator.RangeError
+			if tt.wantErr != nil && (!errors.As(err, &rangeErr) || rangeErr.Value != tt.n || rangeErr.Min != 0 || rangeErr.Max != calculator.MaxShift) {
+				t.Errorf("%s(%d, %d) error = %#v, want a *RangeError for %d", tt.op, tt.a, tt.n, err, tt.n)
+			}
+		})
+	}
+}
+
+func ExampleCalculator_ShiftRight() {
+	calc := calculator.NewCalculator(nil)
+	shifted, _ := calc.ShiftRight(-7, 1)
+	quotient, _ := calc.Divide(-7, 2)
+	fmt.Println(shifted, quotient)
+	_, err := calc.ShiftRight(1, 64)
+	fmt.Println(err)
+	// Output:
+	// -4 -3
+	// shift amount 64 out of range, must be between 0 and 63
+}
@@ -1,91 +1,428 @@
 // Package calculator provides arithmetic operations on numbers.
+//
+// No exported function or method panics: each either returns an error or
+// is total, returning for any arguments, including zero values, nil and
+// the extremes of int. Integer overflow wraps in Add, Subtract and
+// Multiply, as Go arithmetic does, while their checked variants, Abs,
+// Negate, Pow and Compute report it as ErrOverflow. Division truncates toward zero,
+// unless Compute runs in strict mode (WithStrict or ComputeStrict),
+// which reports a remainder, and the overflow of MinInt / -1, as
+// ErrPrecisionLoss.
+// Methods need a Calculator created by NewCalculator. internal/panictest
+// checks this on every function.
 package calculator
 
 import (
+	"context"
 	"go-examples/pkg/logger"
+	"math"
+	"time"
+
+	"go.uber.org/zap/zapcore"
 )
 
 // Calculator provides arithmetic operations with logging capabilities
 type Calculator struct {
-	log logger.Logger
+	log       logger.Logger
+	ints      Generic[int] // the arithmetic of Add to Divide
+	registry  *Registry    // the operations of Compute
+	audit     logger.Logger
+	clock     Clock
+	strict    bool
+	angleUnit AngleUnit // of WithAngleUnit
+	state     *calcState
+	autoSave  *autoSaver
+	observers *observers
+	random    *randomSource // of WithRandSource, nil for math/rand/v2
+
+	historySize int // entries kept by WithHistory, 0 when not recording
+	maxVars     int // limit of WithMaxVars, 0 when unbounded
+	undoDepth   int // changes kept by WithUndo, 0 when disabled
+
+	maxOperations int // limit of WithMaxOperations, 0 when unbounded
+	maxMagnitude  int // limit of WithMaxMagnitude, 0 when unbounded
+}
+
+// Clock tells the time and waits. Tests pass a fake one with WithClock.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the Clock of the time package
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Option configures a Calculator
+type Option func(*Calculator)
+
+// WithClock sets the clock used for timestamps and autosave. The
+// default, also used for a nil clock, is the system clock.
+func WithClock(clock Clock) Option {
+	return func(c *Calculator) {
+		if clock != nil {
+			c.clock = clock
+		}
+	}
+}
+
+// WithAudit records every operation performed by Compute in audit,
+// typically a logger.NewAudit logger, as an entry Replay can re-execute
+func WithAudit(audit logger.Logger) Option {
+	return func(c *Calculator) {
+		c.audit = audit
+	}
+}
+
+// WithStrict makes Compute fail with a *PrecisionLossError instead of
+// returning a result that is not exact, as ComputeStrict does
+func WithStrict() Option {
+	return func(c *Calculator) {
+		c.strict = true
+	}
+}
+
+// NewCalculator creates a new Calculator instance with the provided
+// logger, or without logging if log is nil. With WithAutoSave, call Close
+// when done with it.
+func NewCalculator(log logger.Logger, opts ...Option) *Calculator {
+	if log == nil {
+		log = noOpLogger{}
+	}
+	c := &Calculator{
+		log:       log,
+		ints:      Generic[int]{log: log},
+		clock:     realClock{},
+		state:     newCalcState(),
+		registry:  defaultRegistry,
+		observers: &observers{},
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(c)
+		}
+	}
+	c.startAutoSave()
+	return c
 }
 
-// NewCalculator creates a new Calculator instance with the provided logger
-func NewCalculator(log logger.Logger) *Calculator {
-	return &Calculator{
-		log: log,
+// WithLogger returns a copy of c that logs to l, or does not log if l is
+// nil. The copy shares the memory, history, variables, registry and
+// observers of c, so a change through either is seen by both, and both
+// may be used at once from different goroutines. It also shares
+// autosaving: closing either stops it for both. The audit logger is
+// kept.
+func (c *Calculator) WithLogger(l logger.Logger) *Calculator {
+	if l == nil {
+		l = noOpLogger{}
 	}
+	scoped := *c
+	scoped.log = l
+	scoped.ints = Generic[int]{log: l}
+	return &scoped
+}
+
+// With returns a copy of c, as from WithLogger, whose log entries carry
+// the given key-value pairs, such as a request ID.
+func (c *Calculator) With(args ...interface{}) *Calculator {
+	return c.WithLogger(c.log.With(args...))
 }
 
 // Add returns the sum of two integers.
 // It's a simple function to demonstrate Go package functionality.
-func (c *Calculator) Add(a, b int) int {
-	c.log.Infof("Calculating addition: %d + %d", a, b)
-	result := a + b
-	c.log.Debugf("Addition result: %d", result)
-	return result
+func (c *Calculator) Add(a, b int) (result int) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notify("add", a, b, result, nil, time.Since(start)) }()
+	}
+	return c.ints.Add(a, b)
 }
 
 // Subtract returns the difference between two integers.
 // It subtracts the second argument from the first.
-func (c *Calculator) Subtract(a, b int) int {
-	c.log.Infof("Calculating subtraction: %d - %d", a, b)
-	result := a - b
-	c.log.Debugf("Subtraction result: %d", result)
-	return result
+func (c *Calculator) Subtract(a, b int) (result int) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notify("subtract", a, b, result, nil, time.Since(start)) }()
+	}
+	return c.ints.Subtract(a, b)
 }
 
 // Multiply returns the product of two integers.
 // It multiplies the first argument by the second.
-func (c *Calculator) Multiply(a, b int) int {
-	c.log.Infof("Calculating multiplication: %d * %d", a, b)
-	result := a * b
-	c.log.Debugf("Multiplication result: %d", result)
-	return result
+func (c *Calculator) Multiply(a, b int) (result int) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notify("multiply", a, b, result, nil, time.Since(start)) }()
+	}
+	return c.ints.Multiply(a, b)
+}
+
+// Divide returns the quotient of two integers, truncated toward zero.
+// It divides the first argument by the second, and returns
+// ErrDivisionByZero when the second is zero.
+func (c *Calculator) Divide(a, b int) (result int, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notify("divide", a, b, result, err, time.Since(start)) }()
+	}
+	return c.ints.Divide(a, b)
 }
 
-// Divide returns the quotient of two integers.
-// It divides the first argument by the second.
-func (c *Calculator) Divide(a, b int) int {
-	c.log.Infof("Calculating division: %d / %d", a, b)
+// Mod returns the remainder of dividing two integers, with the sign of
+// the first, as Go's % operator. It returns ErrDivisionByZero when the
+// second is zero, like Divide.
+func (c *Calculator) Mod(a, b int) (result int, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notify("mod", a, b, result, err, time.Since(start)) }()
+	}
+	if logger.LevelEnabled(c.log, zapcore.InfoLevel) {
+		c.log.Infof("Calculating modulo: %d %% %d", a, b)
+	}
 	if b == 0 {
-		c.log.Error("Division by zero")
-		return 0
+		c.log.With("a", a, "b", b).Error("Division by zero")
+		return 0, ErrDivisionByZero
+	}
+	result = a % b
+	if logger.LevelEnabled(c.log, zapcore.DebugLevel) {
+		c.log.Debugf("Modulo result: %d", result)
+	}
+	return result, nil
+}
+
+// Pow returns base raised to the power exp, by repeated squaring. Pow(0,
+// 0) is 1. It returns ErrNegativeInput for a negative exp, whose result
+// is not an integer, and ErrOverflow when the result does not fit in an
+// int, instead of wrapping.
+func (c *Calculator) Pow(base, exp int) (int, error) {
+	return c.PowCtx(context.Background(), base, exp)
+}
+
+// PowCtx performs like Pow, but checks ctx before each squaring,
+// returning ctx.Err() once it is done. A nil ctx never is.
+func (c *Calculator) PowCtx(ctx context.Context, base, exp int) (result int, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notify("pow", base, exp, result, err, time.Since(start)) }()
+	}
+	ctx = orBackground(ctx)
+	if logger.LevelEnabled(c.log, zapcore.InfoLevel) {
+		c.log.Infof("Calculating power: %d ^ %d", base, exp)
+	}
+	if exp < 0 {
+		c.log.With("base", base, "exp", exp).Error("Negative exponent")
+		return 0, ErrNegativeInput
+	}
+	result, square := 1, base
+	for ok, bits := true, exp; bits > 0; bits >>= 1 {
+		if err := ctx.Err(); err != nil {
+			c.log.With("base", base, "exp", exp).Warnf("Power stopped: %v", err)
+			return 0, err
+		}
+		if bits&1 == 1 {
+			result, ok = multiplyChecked(result, square)
+		}
+		// Square only while bits remain, so the last square cannot
+		// overflow a result that fits
+		if ok && bits > 1 {
+			square, ok = multiplyChecked(square, square)
+		}
+		if !ok {
+			c.log.With("base", base, "exp", exp).Error("Power overflow")
+			return 0, ErrOverflow
+		}
+	}
+	if logger.LevelEnabled(c.log, zapcore.DebugLevel) {
+		c.log.Debugf("Power result: %d", result)
+	}
+	return result, nil
+}
+
+// Abs returns the absolute value of a, or ErrOverflow for MinInt, whose
+// absolute value does not fit in an int
+func (c *Calculator) Abs(a int) (result int, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notify("abs", a, 0, result, err, time.Since(start)) }()
+	}
+	if logger.LevelEnabled(c.log, zapcore.InfoLevel) {
+		c.log.Infof("Calculating absolute value: |%d|", a)
+	}
+	if a == math.MinInt {
+		c.log.With("a", a).Error("Absolute value overflow")
+		return 0, ErrOverflow
+	}
+	result = a
+	if a < 0 {
+		result = -a
 	}
-	result := a / b
-	c.log.Debugf("Division result: %d", result)
-	return result
+	if logger.LevelEnabled(c.log, zapcore.DebugLevel) {
+		c.log.Debugf("Absolute value result: %d", result)
+	}
+	return result, nil
+}
+
+// Negate returns -a, or ErrOverflow for MinInt, whose negation does not
+// fit in an int
+func (c *Calculator) Negate(a int) (result int, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notify("negate", a, 0, result, err, time.Since(start)) }()
+	}
+	if logger.LevelEnabled(c.log, zapcore.InfoLevel) {
+		c.log.Infof("Calculating negation: -(%d)", a)
+	}
+	if a == math.MinInt {
+		c.log.With("a", a).Error("Negation overflow")
+		return 0, ErrOverflow
+	}
+	result = -a
+	if logger.LevelEnabled(c.log, zapcore.DebugLevel) {
+		c.log.Debugf("Negation result: %d", result)
+	}
+	return result, nil
+}
+
+// AddChecked returns the sum of two integers, or ErrOverflow when it
+// does not fit in an int
+func (c *Calculator) AddChecked(a, b int) (result int, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notify("add", a, b, result, err, time.Since(start)) }()
+	}
+	if logger.LevelEnabled(c.log, zapcore.InfoLevel) {
+		c.log.Infof("Calculating checked addition: %d + %d", a, b)
+	}
+	result, ok := addChecked(a, b)
+	return c.checked("Addition", a, b, result, ok)
+}
+
+// SubtractChecked returns the difference between two integers, or
+// ErrOverflow when it does not fit in an int
+func (c *Calculator) SubtractChecked(a, b int) (result int, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notify("subtract", a, b, result, err, time.Since(start)) }()
+	}
+	if logger.LevelEnabled(c.log, zapcore.InfoLevel) {
+		c.log.Infof("Calculating checked subtraction: %d - %d", a, b)
+	}
+	result, ok := subtractChecked(a, b)
+	return c.checked("Subtraction", a, b, result, ok)
+}
+
+// MultiplyChecked returns the product of two integers, or ErrOverflow
+// when it does not fit in an int, including MinInt * -1
+func (c *Calculator) MultiplyChecked(a, b int) (result int, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notify("multiply", a, b, result, err, time.Since(start)) }()
+	}
+	if logger.LevelEnabled(c.log, zapcore.InfoLevel) {
+		c.log.Infof("Calculating checked multiplication: %d * %d", a, b)
+	}
+	result, ok := multiplyChecked(a, b)
+	return c.checked("Multiplication", a, b, result, ok)
+}
+
+// checked logs the result of the checked operation named name, or its
+// overflow, which it returns as ErrOverflow
+func (c *Calculator) checked(name string, a, b, result int, ok bool) (int, error) {
+	if !ok {
+		c.log.With("a", a, "b", b).Errorf("%s overflow", name)
+		return 0, ErrOverflow
+	}
+	if logger.LevelEnabled(c.log, zapcore.DebugLevel) {
+		c.log.Debugf("%s result: %d", name, result)
+	}
+	return result, nil
+}
+
+// addChecked returns a + b, and false if it overflows int
+func addChecked(a, b int) (int, bool) {
+	if (b > 0 && a > math.MaxInt-b) || (b < 0 && a < math.MinInt-b) {
+		return 0, false
+	}
+	return a + b, true
+}
+
+// subtractChecked returns a - b, and false if it overflows int
+func subtractChecked(a, b int) (int, bool) {
+	if (b < 0 && a > math.MaxInt+b) || (b > 0 && a < math.MinInt+b) {
+		return 0, false
+	}
+	return a - b, true
+}
+
+// multiplyChecked returns a * b, and false if it overflows int
+func multiplyChecked(a, b int) (int, bool) {
+	product := a * b
+	// product/a is b unless wrapped, except for -1 * MinInt, whose
+	// quotient wraps back to MinInt
+	if a != 0 && (product/a != b || a == -1 && b == math.MinInt) {
+		return 0, false
+	}
+	return product, true
 }
 
 // For backward compatibility with existing code, keep the original functions
-// but they now use a default no-op logger
+// but without logging. They share noOpCalculator, except for Add, Subtract,
+// Multiply and Divide, which compute directly so that they never allocate,
+// Divide with the unlogged core of Generic.Divide.
+
+// noOpCalculator performs the package-level functions. It is created
+// once, without options, and none of those functions change it, so
+// goroutines share it safely.
+var noOpCalculator = NewCalculator(noOpLogger{})
 
 // Add returns the sum of two integers.
 func Add(a, b int) int {
-	// Create a calculator with a no-op logger for backward compatibility
-	calc := NewCalculator(noOpLogger{})
-	return calc.Add(a, b)
+	return a + b
 }
 
 // Subtract returns the difference between two integers.
 func Subtract(a, b int) int {
-	// Create a calculator with a no-op logger for backward compatibility
-	calc := NewCalculator(noOpLogger{})
-	return calc.Subtract(a, b)
+	return a - b
 }
 
 // Multiply returns the product of two integers.
 func Multiply(a, b int) int {
-	// Create a calculator with a no-op logger for backward compatibility
-	calc := NewCalculator(noOpLogger{})
-	return calc.Multiply(a, b)
+	return a * b
+}
+
+// AddChecked returns the sum of two integers, or ErrOverflow.
+func AddChecked(a, b int) (int, error) {
+	return noOpCalculator.AddChecked(a, b)
+}
+
+// SubtractChecked returns the difference between two integers, or
+// ErrOverflow.
+func SubtractChecked(a, b int) (int, error) {
+	return noOpCalculator.SubtractChecked(a, b)
+}
+
+// MultiplyChecked returns the product of two integers, or ErrOverflow.
+func MultiplyChecked(a, b int) (int, error) {
+	return noOpCalculator.MultiplyChecked(a, b)
+}
+
+// Divide returns the quotient of two integers, or ErrDivisionByZero.
+func Divide(a, b int) (int, error) {
+	return divide(a, b)
+}
+
+// Mod returns the remainder of dividing two integers, or
+// ErrDivisionByZero.
+func Mod(a, b int) (int, error) {
+	return noOpCalculator.Mod(a, b)
+}
+
+// Pow returns base raised to the power exp, or ErrNegativeInput or
+// ErrOverflow.
+func Pow(base, exp int) (int, error) {
+	return noOpCalculator.Pow(base, exp)
 }
 
-// Divide returns the quotient of two integers.
-func Divide(a, b int) int {
-	// Create a calculator with a no-op logger for backward compatibility
-	calc := NewCalculator(noOpLogger{})
-	return calc.Divide(a, b)
+// Abs returns the absolute value of a, or ErrOverflow.
+func Abs(a int) (int, error) {
+	return noOpCalculator.Abs(a)
+}
+
+// Negate returns -a, or ErrOverflow.
+func Negate(a int) (int, error) {
+	return noOpCalculator.Negate(a)
 }
 
 // noOpLogger is a no-operation logger for backward compatibility
@@ -102,3 +439,7 @@ func (l noOpLogger) Warnf(_ string, _ ...interface{})    {}
 func (l noOpLogger) Errorf(_ string, _ ...interface{})   {}
 func (l noOpLogger) Fatalf(_ string, _ ...interface{})   {}
 func (l noOpLogger) With(_ ...interface{}) logger.Logger { return l }
+
+// LevelEnabled reports that no level is written, so that hot paths skip
+// building their messages
+func (l noOpLogger) LevelEnabled(zapcore.Level) bool { return false }
@@ -1,10 +1,15 @@
 package calculator_test
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"sync"
 	"testing"
 
 	"go-examples/pkg/calculator"
+	"go-examples/pkg/calculator/proptest"
 	"go-examples/pkg/logger"
 	"go.uber.org/zap/zapcore"
 )
@@ -129,7 +134,15 @@ func testOperation(t *testing.T, operation string, testCases []struct {
 			case "multiply":
 				got = calc.Multiply(tc.a, tc.b)
 			case "divide":
-				got = calc.Divide(tc.a, tc.b)
+				var err error
+				if got, err = calc.Divide(tc.a, tc.b); err != nil {
+					t.Fatalf("Divide(%d, %d) failed: %v", tc.a, tc.b, err)
+				}
+			case "mod":
+				var err error
+				if got, err = calc.Mod(tc.a, tc.b); err != nil {
+					t.Fatalf("Mod(%d, %d) failed: %v", tc.a, tc.b, err)
+				}
 			default:
 				t.Fatalf("Unknown operation: %s", operation)
 			}
@@ -219,12 +232,6 @@ func TestDivide(t *testing.T) {
 			b:        2,
 			expected: 5,
 		},
-		{
-			name:     "division by zero",
-			a:        10,
-			b:        0,
-			expected: 0, // As per our implementation, division by zero returns 0
-		},
 		{
 			name:     "zero divided by number",
 			a:        0,
@@ -236,6 +243,238 @@ func TestDivide(t *testing.T) {
 	testOperation(t, "divide", testCases)
 }
 
+func TestMod(t *testing.T) {
+	testCases := []struct {
+		name     string
+		a, b     int
+		expected int
+	}{
+		{
+			name:     "positive numbers",
+			a:        17,
+			b:        5,
+			expected: 2,
+		},
+		{
+			name:     "negative dividend",
+			a:        -17,
+			b:        5,
+			expected: -2,
+		},
+		{
+			name:     "negative divisor",
+			a:        17,
+			b:        -5,
+			expected: 2,
+		},
+		{
+			name:     "exact division",
+			a:        15,
+			b:        5,
+			expected: 0,
+		},
+		{
+			name:     "smallest int by minus one",
+			a:        math.MinInt,
+			b:        -1,
+			expected: 0,
+		},
+	}
+
+	testOperation(t, "mod", testCases)
+}
+
+func TestModByZero(t *testing.T) {
+	log, observed := logger.NewObserved(zapcore.DebugLevel)
+	calc := calculator.NewCalculator(log)
+
+	// Mod reports a zero divisor as Divide does
+	if got, err := calc.Mod(17, 0); !errors.Is(err, calculator.ErrDivisionByZero) || got != 0 {
+		t.Errorf("Mod(17, 0) = %d, %v; want 0, %v", got, err, calculator.ErrDivisionByZero)
+	}
+	if _, err := calculator.Mod(17, 0); !errors.Is(err, calculator.ErrDivisionByZero) {
+		t.Errorf("package-level Mod(17, 0) error = %v; want %v", err, calculator.ErrDivisionByZero)
+	}
+	if n := len(observed.FilterLevel(zapcore.ErrorLevel)); n != 1 {
+		t.Errorf("expected 1 error entry, got %d", n)
+	}
+}
+
+func TestPow(t *testing.T) {
+	calc := calculator.NewCalculator(setupTestLogger())
+
+	testCases := []struct {
+		name      string
+		base, exp int
+		expected  int
+		wantErr   error
+	}{
+		{name: "zero to the zero", base: 0, exp: 0, expected: 1},
+		{name: "zero exponent", base: -7, exp: 0, expected: 1},
+		{name: "zero base", base: 0, exp: 5, expected: 0},
+		{name: "odd exponent", base: 3, exp: 5, expected: 243},
+		{name: "negative base, odd exponent", base: -2, exp: 7, expected: -128},
+		{name: "negative base, even exponent", base: -2, exp: 8, expected: 256},
+		{name: "one to a huge power", base: 1, exp: math.MaxInt, expected: 1},
+		{name: "minus one to a huge power", base: -1, exp: math.MaxInt, expected: -1},
+		{name: "largest power of two", base: 2, exp: 62, expected: 1 << 62},
+		{name: "smallest int", base: -2, exp: 63, expected: math.MinInt},
+		{name: "largest power of ten", base: 10, exp: 18, expected: 1_000_000_000_000_000_000},
+		{name: "largest int", base: math.MaxInt, exp: 1, expected: math.MaxInt},
+		{name: "two to the 63", base: 2, exp: 63, wantErr: calculator.ErrOverflow},
+		{name: "minus two to the 64", base: -2, exp: 64, wantErr: calculator.ErrOverflow},
+		{name: "ten to the 19", base: 10, exp: 19, wantErr: calculator.ErrOverflow},
+		{name: "largest int squared", base: math.MaxInt, exp: 2, wantErr: calculator.ErrOverflow},
+		{name: "smallest int squared", base: math.MinInt, exp: 2, wantErr: calculator.ErrOverflow},
+		{name: "huge exponent", base: 2, exp: math.MaxInt, wantErr: calculator.ErrOverflow},
+		{name: "negative exponent", base: 2, exp: -1, wantErr: calculator.ErrNegativeInput},
+		{name: "zero to a negative exponent", base: 0, exp: -1, wantErr: calculator.ErrNegativeInput},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := calc.Pow(tc.base, tc.exp)
+			if !errors.Is(err, tc.wantErr) || got != tc.expected {
+				t.Errorf("Pow(%d, %d) = %d, %v; want %d, %v", tc.base, tc.exp, got, err, tc.expected, tc.wantErr)
+			}
+			if pkgGot, pkgErr := calculator.Pow(tc.base, tc.exp); pkgGot != got || pkgErr != err {
+				t.Errorf("package-level Pow(%d, %d) = %d, %v; want %d, %v", tc.base, tc.exp, pkgGot, pkgErr, got, err)
+			}
+		})
+	}
+}
+
+// TestChecked tests the checked operations at the int boundaries,
+// including MinInt * -1, whose wrapped product is MinInt again
+func TestChecked(t *testing.T) {
+	calc := calculator.NewCalculator(setupTestLogger())
+	ops := map[string]struct {
+		method, pkg func(a, b int) (int, error)
+	}{
+		"AddChecked":      {calc.AddChecked, calculator.AddChecked},
+		"SubtractChecked": {calc.SubtractChecked, calculator.SubtractChecked},
+		"MultiplyChecked": {calc.MultiplyChecked, calculator.MultiplyChecked},
+	}
+
+	testCases := []struct {
+		op       string
+		a, b     int
+		expected int
+		wantErr  error
+	}{
+		{op: "AddChecked", a: 2, b: 3, expected: 5},
+		{op: "AddChecked", a: math.MaxInt, b: 0, expected: math.MaxInt},
+		{op: "AddChecked", a: math.MaxInt, b: math.MinInt, expected: -1},
+		{op: "AddChecked", a: math.MaxInt - 1, b: 1, expected: math.MaxInt},
+		{op: "AddChecked", a: math.MinInt + 1, b: -1, expected: math.MinInt},
+		{op: "AddChecked", a: math.MaxInt, b: 1, wantErr: calculator.ErrOverflow},
+		{op: "AddChecked", a: 1, b: math.MaxInt, wantErr: calculator.ErrOverflow},
+		{op: "AddChecked", a: math.MinInt, b: -1, wantErr: calculator.ErrOverflow},
+		{op: "AddChecked", a: math.MinInt, b: math.MinInt, wantErr: calculator.ErrOverflow},
+		{op: "SubtractChecked", a: 2, b: 3, expected: -1},
+		{op: "SubtractChecked", a: -1, b: math.MaxInt, expected: math.MinInt},
+		{op: "SubtractChecked", a: -1, b: math.MinInt, expected: math.MaxInt},
+		{op: "SubtractChecked", a: math.MinInt, b: math.MinInt, expected: 0},
+		{op: "SubtractChecked", a: math.MinInt, b: 1, wantErr: calculator.ErrOverflow},
+		{op: "SubtractChecked", a: 0, b: math.MinInt, wantErr: calculator.ErrOverflow},
+		{op: "SubtractChecked", a: math.MaxInt, b: -1, wantErr: calculator.ErrOverflow},
+		{op: "SubtractChecked", a: -2, b: math.MaxInt, wantErr: calculator.ErrOverflow},
+		{op: "MultiplyChecked", a: 6, b: 7, expected: 42},
+		{op: "MultiplyChecked", a: 0, b: math.MinInt, expected: 0},
+		{op: "MultiplyChecked", a: math.MinInt, b: 1, expected: math.MinInt},
+		{op: "MultiplyChecked", a: math.MaxInt, b: -1, expected: -math.MaxInt},
+		{op: "MultiplyChecked", a: 1 << 31, b: 1 << 31, expected: 1 << 62},
+		{op: "MultiplyChecked", a: -1, b: math.MinInt, wantErr: calculator.ErrOverflow},
+		{op: "MultiplyChecked", a: math.MinInt, b: -1, wantErr: calculator.ErrOverflow},
+		{op: "MultiplyChecked", a: 1 << 40, b: 1 << 40, wantErr: calculator.ErrOverflow},
+		{op: "MultiplyChecked", a: math.MaxInt, b: 2, wantErr: calculator.ErrOverflow},
+		{op: "MultiplyChecked", a: math.MinInt, b: math.MinInt, wantErr: calculator.ErrOverflow},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%s(%d, %d)", tc.op, tc.a, tc.b), func(t *testing.T) {
+			got, err := ops[tc.op].method(tc.a, tc.b)
+			if !errors.Is(err, tc.wantErr) || got != tc.expected {
+				t.Errorf("%s(%d, %d) = %d, %v; want %d, %v", tc.op, tc.a, tc.b, got, err, tc.expected, tc.wantErr)
+			}
+			if pkgGot, pkgErr := ops[tc.op].pkg(tc.a, tc.b); pkgGot != got || pkgErr != err {
+				t.Errorf("package-level %s(%d, %d) = %d, %v; want %d, %v", tc.op, tc.a, tc.b, pkgGot, pkgErr, got, err)
+			}
+		})
+	}
+}
+
+// TestAbsNegate tests Abs and Negate, whose only failure is MinInt,
+// which they must not return unchanged
+func TestAbsNegate(t *testing.T) {
+	calc := calculator.NewCalculator(setupTestLogger())
+	ops := map[string]struct {
+		method, pkg func(a int) (int, error)
+	}{
+		"Abs":    {calc.Abs, calculator.Abs},
+		"Negate": {calc.Negate, calculator.Negate},
+	}
+
+	testCases := []struct {
+		op       string
+		a        int
+		expected int
+		wantErr  error
+	}{
+		{op: "Abs", a: -5, expected: 5},
+		{op: "Abs", a: 5, expected: 5},
+		{op: "Abs", a: 0, expected: 0},
+		{op: "Abs", a: math.MaxInt, expected: math.MaxInt},
+		{op: "Abs", a: math.MinInt + 1, expected: math.MaxInt},
+		{op: "Abs", a: math.MinInt, wantErr: calculator.ErrOverflow},
+		{op: "Negate", a: 5, expected: -5},
+		{op: "Negate", a: -5, expected: 5},
+		{op: "Negate", a: 0, expected: 0},
+		{op: "Negate", a: math.MaxInt, expected: math.MinInt + 1},
+		{op: "Negate", a: math.MinInt + 1, expected: math.MaxInt},
+		{op: "Negate", a: math.MinInt, wantErr: calculator.ErrOverflow},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%s(%d)", tc.op, tc.a), func(t *testing.T) {
+			got, err := ops[tc.op].method(tc.a)
+			if !errors.Is(err, tc.wantErr) || got != tc.expected {
+				t.Errorf("%s(%d) = %d, %v; want %d, %v", tc.op, tc.a, got, err, tc.expected, tc.wantErr)
+			}
+			if pkgGot, pkgErr := ops[tc.op].pkg(tc.a); pkgGot != got || pkgErr != err {
+				t.Errorf("package-level %s(%d) = %d, %v; want %d, %v", tc.op, tc.a, pkgGot, pkgErr, got, err)
+			}
+		})
+	}
+}
+
+func TestDivideByZero(t *testing.T) {
+	// Create an observed logger so the error entry can be inspected
+	log, observed := logger.NewObserved(zapcore.DebugLevel)
+	calc := calculator.NewCalculator(log)
+
+	// Division by zero is an error, not a 0 quotient
+	if got, err := calc.Divide(10, 0); !errors.Is(err, calculator.ErrDivisionByZero) || got != 0 {
+		t.Errorf("Divide(10, 0) = %d, %v; want 0, %v", got, err, calculator.ErrDivisionByZero)
+	}
+	if _, err := calculator.Divide(10, 0); !errors.Is(err, calculator.ErrDivisionByZero) {
+		t.Errorf("package-level Divide(10, 0) error = %v; want %v", err, calculator.ErrDivisionByZero)
+	}
+
+	// The error entry should carry the operands as structured fields
+	entries := observed.FilterLevel(zapcore.ErrorLevel)
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 error entry, got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.Message != "Division by zero" {
+		t.Errorf("Message = %q; want %q", entry.Message, "Division by zero")
+	}
+	if entry.Fields["a"] != int64(10) || entry.Fields["b"] != int64(0) {
+		t.Errorf("expected operands a=10 and b=0 as fields, got: %v", entry.Fields)
+	}
+}
+
 // Example functions are treated as documentation and also as tests.
 // These examples appear in the generated documentation.
 func ExampleAdd() {
@@ -301,9 +540,35 @@ func ExampleCalculator_Multiply() {
 
 func ExampleDivide() {
 	// Using the functional version for backward compatibility
-	quotient := calculator.Divide(10, 2)
-	fmt.Println(quotient)
-	// Output: 5
+	quotient, err := calculator.Divide(10, 2)
+	fmt.Println(quotient, err)
+
+	// Division by zero is reported as an error
+	_, err = calculator.Divide(10, 0)
+	fmt.Println(errors.Is(err, calculator.ErrDivisionByZero))
+	// Output:
+	// 5 <nil>
+	// true
+}
+
+func ExampleMod() {
+	// Using the functional version for backward compatibility
+	remainder, err := calculator.Mod(17, 5)
+	fmt.Println(remainder, err)
+	// Output: 2 <nil>
+}
+
+func ExamplePow() {
+	// Using the functional version for backward compatibility
+	power, err := calculator.Pow(2, 10)
+	fmt.Println(power, err)
+
+	// Results that do not fit in an int are errors, not wrapped values
+	_, err = calculator.Pow(2, 64)
+	fmt.Println(err)
+	// Output:
+	// 1024 <nil>
+	// integer overflow
 }
 
 func ExampleCalculator_Divide() {
@@ -314,11 +579,149 @@ func ExampleCalculator_Divide() {
 	calc := calculator.NewCalculator(log)
 	
 	// Perform calculation with logging
-	quotient := calc.Divide(10, 2)
+	quotient, err := calc.Divide(10, 2)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 	fmt.Println(quotient)
 	// Output: 5
 }
 
+// TestPackageFunctionsAllocs tests that the package-level arithmetic
+// functions allocate nothing, even for operands too large for Go to box
+// without allocating
+func TestPackageFunctionsAllocs(t *testing.T) {
+	allocs := testing.AllocsPerRun(100, func() {
+		calculator.Add(123456, 654321)
+		calculator.Subtract(123456, 654321)
+		calculator.Multiply(123456, 654321)
+		_, _ = calculator.Divide(654321, 123)
+		_, _ = calculator.Divide(654321, 0)
+	})
+	if allocs != 0 {
+		t.Errorf("package-level functions allocated %.1f times per run, want 0", allocs)
+	}
+}
+
+// TestLogLevels tests that the arithmetic logs the messages of the
+// levels the logger writes, and only those
+func TestLogLevels(t *testing.T) {
+	tests := []struct {
+		level zapcore.Level
+		want  []string
+	}{
+		{zapcore.DebugLevel, []string{"Calculating addition: 2 + 3", "Addition result: 5", "Calculating checked multiplication: 4 * 5", "Multiplication result: 20",
+			"Calculating modulo: 7 % 2", "Modulo result: 1", "Calculating power: 2 ^ 3", "Power result: 8",
+			"Calculating absolute value: |-4|", "Absolute value result: 4", "Calculating negation: -(4)", "Negation result: -4"}},
+		{zapcore.InfoLevel, []string{"Calculating addition: 2 + 3", "Calculating checked multiplication: 4 * 5",
+			"Calculating modulo: 7 % 2", "Calculating power: 2 ^ 3", "Calculating absolute value: |-4|", "Calculating negation: -(4)"}},
+		{zapcore.ErrorLevel, nil},
+	}
+	for _, tt := range tests {
+		log, logs := logger.NewObserved(tt.level)
+		calc := calculator.NewCalculator(log)
+		calc.Add(2, 3)
+		_, _ = calc.MultiplyChecked(4, 5)
+		_, _ = calc.Mod(7, 2)
+		_, _ = calc.Pow(2, 3)
+		_, _ = calc.Abs(-4)
+		_, _ = calc.Negate(4)
+
+		var got []string
+		for _, e := range logs.All() {
+			got = append(got, e.Message)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s logger got %q, want %q", tt.level, got, tt.want)
+		}
+	}
+
+	// Errors are logged whatever the level of the other messages
+	log, logs := logger.NewObserved(zapcore.ErrorLevel)
+	calc := calculator.NewCalculator(log)
+	if _, err := calc.AddChecked(math.MaxInt, 1); !errors.Is(err, calculator.ErrOverflow) {
+		t.Fatalf("AddChecked(MaxInt, 1) error = %v, want %v", err, calculator.ErrOverflow)
+	}
+	if len(logs.FilterMessageContains("Addition overflow")) != 1 {
+		t.Errorf("overflow not logged at error level: %v", logs.All())
+	}
+}
+
+// TestWithLogger tests that a calculator from With logs its fields on
+// the operations, and shares memory, history and variables with the
+// calculator it was made from
+func TestWithLogger(t *testing.T) {
+	log, logs := logger.NewObserved(zapcore.DebugLevel)
+	calc := calculator.NewCalculator(log, calculator.WithHistory(10))
+	scoped := calc.With("request_id", "abc123")
+
+	if _, err := scoped.Compute("add", 2, 3); err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+	entries := logs.FilterMessageContains("Calculating checked addition")
+	if len(entries) != 1 || entries[0].Fields["request_id"] != "abc123" {
+		t.Errorf("scoped addition logged %v, want one entry with request_id abc123", entries)
+	}
+	scoped.Add(1, 1)
+	calc.Add(1, 1)
+	if entries := logs.FilterField("request_id", "abc123"); len(entries) != logs.Len()-2 {
+		t.Errorf("got %d of %d entries with request_id, want all but those of the original: %v", len(entries), logs.Len(), logs.All())
+	}
+
+	if _, err := scoped.MemAdd(7); err != nil {
+		t.Fatalf("MemAdd failed: %v", err)
+	}
+	if got := calc.MemRecall(); got != 7 {
+		t.Errorf("memory of the original = %d, want 7", got)
+	}
+	if err := calc.SetVar("x", 42); err != nil {
+		t.Fatalf("SetVar failed: %v", err)
+	}
+	if got, ok := scoped.GetVar("x"); !ok || got != 42 {
+		t.Errorf("scoped GetVar(x) = %d, %t, want 42, true", got, ok)
+	}
+	if history := calc.History(); len(history) != 1 || history[0].Result != 5 {
+		t.Errorf("history of the original = %v, want the scoped addition", history)
+	}
+
+	// A nil logger disables logging on the copy only
+	before := logs.Len()
+	calc.WithLogger(nil).Add(1, 2)
+	if logs.Len() != before {
+		t.Errorf("copy with a nil logger logged %d entries", logs.Len()-before)
+	}
+}
+
+// TestWithLoggerConcurrent tests that copies from With may be used at
+// once with the original, for the race detector
+func TestWithLoggerConcurrent(t *testing.T) {
+	log, _ := logger.NewObserved(zapcore.DebugLevel)
+	calc := calculator.NewCalculator(log, calculator.WithHistory(100))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			scoped := calc.With("worker", i)
+			for j := 0; j < 50; j++ {
+				if _, err := scoped.MemAdd(1); err != nil {
+					t.Errorf("MemAdd failed: %v", err)
+					return
+				}
+				_, _ = scoped.Compute("multiply", i, j)
+			}
+		}()
+	}
+	for j := 0; j < 50; j++ {
+		_, _ = calc.MemAdd(1)
+	}
+	wg.Wait()
+	if got := calc.MemRecall(); got != 8*50+50 {
+		t.Errorf("memory = %d, want %d", got, 8*50+50)
+	}
+}
+
 // ----------------------
 // Benchmark Tests
 // ----------------------
@@ -329,6 +732,7 @@ func BenchmarkAdd(b *testing.B) {
 	log := noOpBenchLogger{}
 	calc := calculator.NewCalculator(log)
 	
+	b.ReportAllocs()
 	b.ResetTimer() // Reset the timer to exclude setup time
 	for i := 0; i < b.N; i++ {
 		calc.Add(5, 3)
@@ -340,6 +744,7 @@ func BenchmarkSubtract(b *testing.B) {
 	log := noOpBenchLogger{}
 	calc := calculator.NewCalculator(log)
 	
+	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		calc.Subtract(5, 3)
@@ -351,6 +756,7 @@ func BenchmarkMultiply(b *testing.B) {
 	log := noOpBenchLogger{}
 	calc := calculator.NewCalculator(log)
 	
+	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		calc.Multiply(5, 3)
@@ -362,6 +768,7 @@ func BenchmarkMultiplySmall(b *testing.B) {
 	log := noOpBenchLogger{}
 	calc := calculator.NewCalculator(log)
 	
+	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		calc.Multiply(5, 3) // Small numbers
@@ -372,6 +779,7 @@ func BenchmarkMultiplyMedium(b *testing.B) {
 	log := noOpBenchLogger{}
 	calc := calculator.NewCalculator(log)
 	
+	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		calc.Multiply(1000, 1000) // Medium numbers
@@ -382,6 +790,7 @@ func BenchmarkMultiplyLarge(b *testing.B) {
 	log := noOpBenchLogger{}
 	calc := calculator.NewCalculator(log)
 	
+	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		calc.Multiply(1000000, 1000000) // Large numbers
@@ -393,6 +802,7 @@ func BenchmarkDivide(b *testing.B) {
 	log := noOpBenchLogger{}
 	calc := calculator.NewCalculator(log)
 	
+	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		calc.Divide(10, 2)
@@ -404,29 +814,78 @@ func BenchmarkDivideByZeroSafety(b *testing.B) {
 	log := noOpBenchLogger{}
 	calc := calculator.NewCalculator(log)
 	
+	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		calc.Divide(10, 0) // Tests the zero check
 	}
 }
 
+func BenchmarkPow(b *testing.B) {
+	// Create a no-op logger to minimize logging overhead
+	log := noOpBenchLogger{}
+	calc := calculator.NewCalculator(log)
+	
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		calc.Pow(3, 39)
+	}
+}
+
+// BenchmarkPowFloat is the math.Pow round trip Pow replaces, which is
+// only exact below 2^53
+func BenchmarkPowFloat(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = int(math.Pow(float64(3), float64(39)))
+	}
+}
+
 // Benchmarks with different logger configurations
 func BenchmarkAddWithRealLogger(b *testing.B) {
 	// Use a development logger (with actual logging overhead)
 	log, _ := logger.NewDevelopment()
 	calc := calculator.NewCalculator(log)
 	
+	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		calc.Add(5, 3)
 	}
 }
 
+// BenchmarkAddErrorLevel uses a logger writing errors only, for which
+// Add skips building its messages
+func BenchmarkAddErrorLevel(b *testing.B) {
+	calc := calculator.NewCalculator(logger.NewCustom(zapcore.ErrorLevel, true, logger.WithOutput(io.Discard)))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		calc.Add(5000, 3000)
+	}
+}
+
+// BenchmarkAddErrorLevelUnchecked is BenchmarkAddErrorLevel with the
+// logger hiding which levels it writes, so that Add builds the messages
+// the logger then drops
+func BenchmarkAddErrorLevelUnchecked(b *testing.B) {
+	log := logger.NewCustom(zapcore.ErrorLevel, true, logger.WithOutput(io.Discard))
+	calc := calculator.NewCalculator(struct{ logger.Logger }{log})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		calc.Add(5000, 3000)
+	}
+}
+
 func BenchmarkAddWithNoLogger(b *testing.B) {
 	// Use a no-op logger (minimal overhead)
 	log := noOpBenchLogger{}
 	calc := calculator.NewCalculator(log)
 	
+	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		calc.Add(5, 3)
@@ -436,6 +895,7 @@ func BenchmarkAddWithNoLogger(b *testing.B) {
 // Function-style vs method-style comparison
 func BenchmarkAddFunction(b *testing.B) {
 	// Using the package-level function
+	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		calculator.Add(5, 3)
@@ -447,6 +907,7 @@ func BenchmarkAddMethod(b *testing.B) {
 	log := noOpBenchLogger{}
 	calc := calculator.NewCalculator(log)
 	
+	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		calc.Add(5, 3)
@@ -467,3 +928,20 @@ func (l noOpBenchLogger) Warnf(_ string, _ ...interface{})    {}
 func (l noOpBenchLogger) Errorf(_ string, _ ...interface{})   {}
 func (l noOpBenchLogger) Fatalf(_ string, _ ...interface{})   {}
 func (l noOpBenchLogger) With(_ ...interface{}) logger.Logger { return l }
+
+// TestProperties checks the arithmetic properties of proptest on every
+// pair of boundary values, for the methods and the functions
+func TestProperties(t *testing.T) {
+	for name, ops := range map[string]proptest.Ops{
+		"methods":   proptest.FromCalculator(calculator.NewCalculator(noOpBenchLogger{})),
+		"functions": proptest.Default(),
+	} {
+		for _, a := range proptest.Boundaries {
+			for _, b := range proptest.Boundaries {
+				if err := proptest.Check(ops, a, b); err != nil {
+					t.Errorf("%s: %v", name, err)
+				}
+			}
+		}
+	}
+}
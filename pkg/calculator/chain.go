@@ -0,0 +1,75 @@
+package calculator
+
+// Chain performs a sequence of operations on a running value, as in
+// calc.Start(5).Add(3).Multiply(2).Result(). Each step is performed by
+// Compute, so it is logged, audited and recorded in the history like
+// any other operation. The first error stops the chain: later steps are
+// skipped and Result returns it, as bufio.Scanner defers its errors to
+// Err.
+//
+// A Chain is a value: each step returns a new Chain and leaves its
+// receiver unchanged, so a partial chain can be reused as the start of
+// others, before or after calling Result, and shared between goroutines.
+// Create one with Calculator.Start; the steps of a zero Chain do
+// nothing.
+type Chain struct {
+	calc  *Calculator
+	value int
+	steps int // steps performed, for the logs
+	err   error
+}
+
+// Start begins a Chain with the value n
+func (c *Calculator) Start(n int) Chain {
+	c.log.Debugf("Starting chain at %d", n)
+	return Chain{calc: c, value: n}
+}
+
+// Add adds n to the value
+func (ch Chain) Add(n int) Chain { return ch.step("add", n) }
+
+// Subtract subtracts n from the value
+func (ch Chain) Subtract(n int) Chain { return ch.step("subtract", n) }
+
+// Multiply multiplies the value by n
+func (ch Chain) Multiply(n int) Chain { return ch.step("multiply", n) }
+
+// Divide divides the value by n, truncating toward zero
+func (ch Chain) Divide(n int) Chain { return ch.step("divide", n) }
+
+// Mod replaces the value with the remainder of its division by n
+func (ch Chain) Mod(n int) Chain { return ch.step("mod", n) }
+
+// Pow raises the value to the power n
+func (ch Chain) Pow(n int) Chain { return ch.step("pow", n) }
+
+// Result returns the value after the steps, or the error of the step
+// that failed
+func (ch Chain) Result() (int, error) {
+	if ch.err != nil {
+		return 0, ch.err
+	}
+	return ch.value, nil
+}
+
+// step performs the operation name on the value and n, unless an earlier
+// step failed
+func (ch Chain) step(name string, n int) Chain {
+	if ch.calc == nil {
+		return ch
+	}
+	if ch.err != nil {
+		ch.calc.log.Debugf("Chain step %d skipped: %s %d after %v", ch.steps+1, name, n, ch.err)
+		return ch
+	}
+	ch.steps++
+	value, err := ch.calc.Compute(name, ch.value, n)
+	if err != nil {
+		ch.calc.log.Debugf("Chain step %d failed: %s %d: %v", ch.steps, name, n, err)
+		ch.err = err
+		return ch
+	}
+	ch.calc.log.Debugf("Chain step %d: %s %d = %d", ch.steps, name, n, value)
+	ch.value = value
+	return ch
+}
@@ -0,0 +1,82 @@
+package calculator_test
+
+import (
+	"errors"
+	"fmt"
+	"go-examples/pkg/calculator"
+	"math"
+	"testing"
+)
+
+// TestChain tests chains of every operation
+func TestChain(t *testing.T) {
+	calc := calculator.NewCalculator(setupTestLogger())
+	tests := []struct {
+		name  string
+		chain calculator.Chain
+		want  int
+	}{
+		{"empty", calc.Start(5), 5},
+		{"add multiply subtract", calc.Start(5).Add(3).Multiply(2).Subtract(1), 15},
+		{"divide mod", calc.Start(17).Divide(2).Mod(5), 3},
+		{"pow", calc.Start(2).Pow(10).Subtract(24), 1000},
+		{"zero", calculator.Chain{}.Add(3), 0},
+	}
+	for _, tt := range tests {
+		if got, err := tt.chain.Result(); err != nil || got != tt.want {
+			t.Errorf("%s: Result() = %d, %v; want %d", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+// TestChainError tests that the first error skips the remaining steps
+func TestChainError(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{}, calculator.WithHistory(10))
+	got, err := calc.Start(10).Subtract(4).Divide(0).Add(1).Multiply(3).Result()
+	if !errors.Is(err, calculator.ErrDivisionByZero) || got != 0 {
+		t.Errorf("Result() = %d, %v; want 0, %v", got, err, calculator.ErrDivisionByZero)
+	}
+	if h := calc.History(); len(h) != 1 || h[0].Operation != "subtract" {
+		t.Errorf("History() = %+v, want only the subtraction", h)
+	}
+
+	_, err = calc.Start(math.MaxInt).Add(1).Subtract(1).Result()
+	if !errors.Is(err, calculator.ErrOverflow) {
+		t.Errorf("Result() error = %v, want %v", err, calculator.ErrOverflow)
+	}
+}
+
+// TestChainReuse tests that a chain is unchanged by the steps and the
+// Result called on it
+func TestChainReuse(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	base := calc.Start(5).Add(3)
+	if got, err := base.Result(); err != nil || got != 8 {
+		t.Fatalf("base Result() = %d, %v; want 8", got, err)
+	}
+	doubled := base.Multiply(2)
+	failed := base.Divide(0)
+	if got, err := base.Subtract(1).Result(); err != nil || got != 7 {
+		t.Errorf("base.Subtract(1).Result() = %d, %v; want 7", got, err)
+	}
+	if got, err := doubled.Result(); err != nil || got != 16 {
+		t.Errorf("doubled Result() = %d, %v; want 16", got, err)
+	}
+	if _, err := failed.Add(1).Result(); !errors.Is(err, calculator.ErrDivisionByZero) {
+		t.Errorf("failed Result() error = %v, want %v", err, calculator.ErrDivisionByZero)
+	}
+	if got, err := base.Result(); err != nil || got != 8 {
+		t.Errorf("base Result() after reuse = %d, %v; want 8", got, err)
+	}
+}
+
+func ExampleChain() {
+	calc := calculator.NewCalculator(nil)
+	result, err := calc.Start(5).Add(3).Multiply(2).Subtract(1).Result()
+	fmt.Println(result, err)
+	_, err = calc.Start(5).Divide(0).Add(1).Result()
+	fmt.Println(err)
+	// Output:
+	// 15 <nil>
+	// divide 5 0: division by zero
+}
@@ -0,0 +1,168 @@
+package calculator_test
+
+import (
+	"context"
+	"errors"
+	"go-examples/pkg/calculator"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowSemiprime is the product of the two largest primes whose product
+// fits in an int64, which Pollard's rho takes thousands of steps to
+// split
+const slowSemiprime = 3037000453 * 3037000493
+
+// countdownContext is done once Err has been called n times, to cancel
+// a computation at a known point
+type countdownContext struct {
+	context.Context
+	n     atomic.Int64
+	calls atomic.Int64
+}
+
+func (c *countdownContext) Err() error {
+	c.calls.Add(1)
+	if c.n.Add(-1) < 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+func newCountdown(n int64) *countdownContext {
+	ctx := &countdownContext{Context: context.Background()}
+	ctx.n.Store(n)
+	return ctx
+}
+
+// TestPowCtx tests that PowCtx returns the results of Pow, and the
+// error of a done context, including one done mid-computation
+func TestPowCtx(t *testing.T) {
+	calc := calculator.NewCalculator(setupTestLogger())
+	if got, err := calc.PowCtx(context.Background(), 3, 13); err != nil || got != 1594323 {
+		t.Errorf("PowCtx(3, 13) = %d, %v; want 1594323", got, err)
+	}
+	// A nil context is context.Background()
+	if got, err := calc.PowCtx(nil, 2, 10); err != nil || got != 1024 {
+		t.Errorf("PowCtx(nil, 2, 10) = %d, %v; want 1024", got, err)
+	}
+	if _, err := calc.PowCtx(context.Background(), 2, -1); !errors.Is(err, calculator.ErrNegativeInput) {
+		t.Errorf("PowCtx(2, -1) error = %v, want %v", err, calculator.ErrNegativeInput)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := calc.PowCtx(cancelled, 3, 13); !errors.Is(err, context.Canceled) {
+		t.Errorf("PowCtx with a cancelled context error = %v, want %v", err, context.Canceled)
+	}
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	if _, err := calc.PowCtx(expired, 3, 13); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("PowCtx with an expired context error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// 1^MaxInt squares 63 times; stop it after 10
+	ctx := newCountdown(10)
+	if _, err := calc.PowCtx(ctx, 1, int(^uint(0)>>1)); !errors.Is(err, context.Canceled) || ctx.calls.Load() != 11 {
+		t.Errorf("PowCtx cancelled after 10 steps error = %v after %d checks, want %v after 11", err, ctx.calls.Load(), context.Canceled)
+	}
+}
+
+// TestFactorizeCtx tests that FactorizeCtx returns the factors of
+// Factorize, and stops within a few checks of its context being done
+func TestFactorizeCtx(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	want := []int{3037000453, 3037000493}
+	if got, err := calc.FactorizeCtx(context.Background(), slowSemiprime); err != nil || !slices.Equal(got, want) {
+		t.Errorf("FactorizeCtx(%d) = %v, %v; want %v", slowSemiprime, got, err, want)
+	}
+	if _, err := calc.FactorizeCtx(context.Background(), 1); !errors.Is(err, calculator.ErrOutOfRange) {
+		t.Errorf("FactorizeCtx(1) error = %v, want %v", err, calculator.ErrOutOfRange)
+	}
+
+	ctx := newCountdown(3)
+	got, err := calc.FactorizeCtx(ctx, slowSemiprime)
+	if !errors.Is(err, context.Canceled) || got != nil || ctx.calls.Load() != 4 {
+		t.Errorf("FactorizeCtx cancelled after 3 checks = %v, %v after %d checks; want %v after 4", got, err, ctx.calls.Load(), context.Canceled)
+	}
+}
+
+// TestFactorizeCtxDeadline tests that a deadline stops a long run of
+// factorizations promptly
+func TestFactorizeCtxDeadline(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	// Without the deadline, this runs for many seconds
+	var err error
+	for range 1000 {
+		if _, err = calc.FactorizeCtx(ctx, slowSemiprime); err != nil {
+			break
+		}
+	}
+	deadline, _ := ctx.Deadline()
+	if late := time.Since(deadline); !errors.Is(err, context.DeadlineExceeded) || late > 500*time.Millisecond {
+		t.Errorf("FactorizeCtx returned %v %s after the deadline, want %v within 500ms", err, late, context.DeadlineExceeded)
+	}
+}
+
+// TestComputeCtx tests that ComputeCtx performs nothing once the context
+// is done, and stops pow
+func TestComputeCtx(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{}, calculator.WithHistory(10))
+	if got, err := calc.ComputeCtx(context.Background(), "pow", 2, 10); err != nil || got != 1024 {
+		t.Errorf("ComputeCtx(pow, 2, 10) = %d, %v; want 1024", got, err)
+	}
+	if _, err := calc.ComputeStrictCtx(context.Background(), "divide", 7, 2); !errors.Is(err, calculator.ErrPrecisionLoss) {
+		t.Errorf("ComputeStrictCtx(divide, 7, 2) error = %v, want %v", err, calculator.ErrPrecisionLoss)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, name := range []string{"add", "pow"} {
+		if _, err := calc.ComputeCtx(cancelled, name, 2, 10); !errors.Is(err, context.Canceled) {
+			t.Errorf("ComputeCtx(%s) with a cancelled context error = %v, want %v", name, err, context.Canceled)
+		}
+	}
+	if _, err := calc.ComputeCtx(newCountdown(2), "pow", 1, 1<<40); !errors.Is(err, context.Canceled) {
+		t.Errorf("ComputeCtx(pow) cancelled mid-computation error = %v, want %v", err, context.Canceled)
+	}
+	if got := calc.History(); len(got) != 1 {
+		t.Errorf("History() = %+v, want only the first pow", got)
+	}
+}
+
+// TestEvaluateCtx tests that EvaluateCtx stops an expression once the
+// context is done, performing no operation after
+func TestEvaluateCtx(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	var counts calculator.CountingObserver
+	calc.AddObserver(&counts)
+	if got, err := calc.EvaluateCtx(nil, "2 + 3 * 4"); err != nil || got != 14 {
+		t.Errorf("EvaluateCtx(nil, 2 + 3 * 4) = %d, %v; want 14", got, err)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := calc.EvaluateCtx(cancelled, "1 + 2")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("EvaluateCtx with a cancelled context error = %v, want %v", err, context.Canceled)
+	}
+	var opErr *calculator.OperationError
+	if !errors.As(err, &opErr) || opErr.Operation != "add" || opErr.A != 1 || opErr.B != 2 {
+		t.Errorf("EvaluateCtx with a cancelled context error = %#v, want an *OperationError for add 1 2", err)
+	}
+	// Compute checks the context once per operation, so the countdown
+	// lets three additions of nine through, and the fourth is told to
+	// the observers as not performed
+	ctx := newCountdown(3)
+	if _, err := calc.EvaluateCtx(ctx, "1 + 1 + 1 + 1 + 1 + 1 + 1 + 1 + 1 + 1"); !errors.Is(err, context.Canceled) {
+		t.Errorf("EvaluateCtx cancelled mid-expression error = %v, want %v", err, context.Canceled)
+	}
+	if got := counts.Count("add") - counts.Failures("add"); got != 1+3 {
+		t.Errorf("additions performed = %d, want 1 before and 3 until cancelled", got)
+	}
+}
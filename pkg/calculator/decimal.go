@@ -0,0 +1,160 @@
+package calculator
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// MaxDecimalScale is the largest number of fractional digits of a
+// Decimal
+const MaxDecimalScale = 18
+
+// Decimal is a fixed-point decimal number, such as an amount of money:
+// an int64 count of units of 10^-scale. The zero value is 0, with no
+// fractional digits. Results have the larger scale of their operands
+// and are rounded half to even, "banker's rounding"; a result that does
+// not fit in an int64 of units returns ErrOverflow.
+type Decimal struct {
+	units int64
+	scale int
+}
+
+// NewDecimal returns units * 10^-scale, such as 1999 with the scale 2
+// for 19.99. It returns a *RangeError for a scale below 0 or above
+// MaxDecimalScale.
+func NewDecimal(units int64, scale int) (Decimal, error) {
+	if scale < 0 || scale > MaxDecimalScale {
+		return Decimal{}, &RangeError{Name: "scale", Value: scale, Min: 0, Max: MaxDecimalScale}
+	}
+	return Decimal{units: units, scale: scale}, nil
+}
+
+// ParseDecimal parses a decimal number such as "19.99" or "-5", with
+// the scale of its fractional digits, so that "1.50" has the scale 2.
+// It returns an *InvalidNumberError for other strings, including those
+// with more than MaxDecimalScale fractional digits, and ErrOverflow
+// when the number does not fit.
+func ParseDecimal(s string) (Decimal, error) {
+	body := s
+	if len(body) > 0 && (body[0] == '+' || body[0] == '-') {
+		body = body[1:]
+	}
+	intText, fracText, point := strings.Cut(body, ".")
+	number := shortenName(s)
+	if !isDigits(intText) || (point && !isDigits(fracText)) {
+		return Decimal{}, &InvalidNumberError{Number: number, Reason: "not a decimal number"}
+	}
+	if len(fracText) > MaxDecimalScale {
+		return Decimal{}, &InvalidNumberError{Number: number, Reason: fmt.Sprintf("more than %d fractional digits", MaxDecimalScale)}
+	}
+	// Only the range can fail once the digits are checked
+	units, err := strconv.ParseInt(s[:len(s)-len(body)]+intText+fracText, 10, 64)
+	if err != nil {
+		return Decimal{}, ErrOverflow
+	}
+	return Decimal{units: units, scale: len(fracText)}, nil
+}
+
+// Units returns d as a count of units of 10^-Scale
+func (d Decimal) Units() int64 {
+	return d.units
+}
+
+// Scale returns the number of fractional digits of d
+func (d Decimal) Scale() int {
+	return d.scale
+}
+
+// String returns d with all of its fractional digits, such as "19.90"
+func (d Decimal) String() string {
+	digits := strconv.FormatInt(d.units, 10)
+	sign := ""
+	if d.units < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	if d.scale == 0 {
+		return sign + digits
+	}
+	if len(digits) <= d.scale {
+		digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+	}
+	point := len(digits) - d.scale
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// Rescale returns d with scale fractional digits, rounded half to even
+// when it has more. It returns a *RangeError for a scale out of range,
+// and ErrOverflow when the result does not fit.
+func (d Decimal) Rescale(scale int) (Decimal, error) {
+	if scale < 0 || scale > MaxDecimalScale {
+		return Decimal{}, &RangeError{Name: "scale", Value: scale, Min: 0, Max: MaxDecimalScale}
+	}
+	return roundDecimal(d.rat(), scale)
+}
+
+// Add returns d + e, or ErrOverflow
+func (d Decimal) Add(e Decimal) (Decimal, error) {
+	return roundDecimal(new(big.Rat).Add(d.rat(), e.rat()), max(d.scale, e.scale))
+}
+
+// Subtract returns d - e, or ErrOverflow
+func (d Decimal) Subtract(e Decimal) (Decimal, error) {
+	return roundDecimal(new(big.Rat).Sub(d.rat(), e.rat()), max(d.scale, e.scale))
+}
+
+// Multiply returns d * e, rounded half to even, or ErrOverflow
+func (d Decimal) Multiply(e Decimal) (Decimal, error) {
+	return roundDecimal(new(big.Rat).Mul(d.rat(), e.rat()), max(d.scale, e.scale))
+}
+
+// Divide returns d / e, rounded half to even, ErrDivisionByZero when e
+// is zero, or ErrOverflow
+func (d Decimal) Divide(e Decimal) (Decimal, error) {
+	if e.units == 0 {
+		return Decimal{}, ErrDivisionByZero
+	}
+	return roundDecimal(new(big.Rat).Quo(d.rat(), e.rat()), max(d.scale, e.scale))
+}
+
+// rat returns d as a big.Rat
+func (d Decimal) rat() *big.Rat {
+	return new(big.Rat).SetFrac(big.NewInt(d.units), pow10(d.scale))
+}
+
+// roundDecimal returns r with scale fractional digits, rounded half to
+// even, or ErrOverflow if it does not fit
+func roundDecimal(r *big.Rat, scale int) (Decimal, error) {
+	num := new(big.Int).Mul(r.Num(), pow10(scale))
+	den := r.Denom()
+	units, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	// Twice the remainder against the positive denominator tells below,
+	// at or above the half; QuoRem truncated toward zero
+	half := rem.Lsh(rem.Abs(rem), 1).Cmp(den)
+	if half > 0 || (half == 0 && units.Bit(0) == 1) {
+		units.Add(units, big.NewInt(int64(num.Sign())))
+	}
+	if !units.IsInt64() {
+		return Decimal{}, ErrOverflow
+	}
+	return Decimal{units: units.Int64(), scale: scale}, nil
+}
+
+// pow10 returns 10^n
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// isDigits reports whether s is a non-empty string of decimal digits
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,202 @@
+package calculator_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/logger"
+	"math"
+	"strings"
+	"testing"
+)
+
+// mustDecimal parses s or fails t
+func mustDecimal(t *testing.T, s string) calculator.Decimal {
+	t.Helper()
+	d, err := calculator.ParseDecimal(s)
+	if err != nil {
+		t.Fatalf("ParseDecimal(%q) failed: %v", s, err)
+	}
+	return d
+}
+
+// TestParseDecimal tests that parsing keeps the scale of the string and
+// formatting gives it back
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    string
+		scale   int
+		wantErr error
+	}{
+		{s: "19.99", want: "19.99", scale: 2},
+		{s: "-0.05", want: "-0.05", scale: 2},
+		{s: "+1.50", want: "1.50", scale: 2},
+		{s: "007", want: "7", scale: 0},
+		{s: "0.000000000000000001", want: "0.000000000000000001", scale: 18},
+		{s: "-9223372036854775808", want: "-9223372036854775808", scale: 0},
+		{s: "-922337203685477.5808", want: "-922337203685477.5808", scale: 4},
+		{s: "9223372036854775808", wantErr: calculator.ErrOverflow},
+		{s: "0.0000000000000000001", wantErr: calculator.ErrInvalidNumber},
+		{s: "", wantErr: calculator.ErrInvalidNumber},
+		{s: "-", wantErr: calculator.ErrInvalidNumber},
+		{s: ".5", wantErr: calculator.ErrInvalidNumber},
+		{s: "5.", wantErr: calculator.ErrInvalidNumber},
+		{s: "1.2.3", wantErr: calculator.ErrInvalidNumber},
+		{s: "1e3", wantErr: calculator.ErrInvalidNumber},
+		{s: "--1", wantErr: calculator.ErrInvalidNumber},
+		{s: "1,000.00", wantErr: calculator.ErrInvalidNumber},
+		{s: strings.Repeat("9", 100), wantErr: calculator.ErrOverflow},
+	}
+	for _, tt := range tests {
+		d, err := calculator.ParseDecimal(tt.s)
+		if !errors.Is(err, tt.wantErr) || (err == nil && (d.String() != tt.want || d.Scale() != tt.scale)) {
+			t.Errorf("ParseDecimal(%q) = %s (scale %d), %v; want %s (scale %d), %v", tt.s, d, d.Scale(), err, tt.want, tt.scale, tt.wantErr)
+		}
+	}
+
+	var zero calculator.Decimal
+	if zero.String() != "0" || zero.Units() != 0 || zero.Scale() != 0 {
+		t.Errorf("zero Decimal = %s, %d units of scale %d", zero, zero.Units(), zero.Scale())
+	}
+	if d, err := calculator.NewDecimal(1999, 2); err != nil || d.String() != "19.99" {
+		t.Errorf("NewDecimal(1999, 2) = %s, %v; want 19.99", d, err)
+	}
+	for _, scale := range []int{-1, calculator.MaxDecimalScale + 1, math.MaxInt} {
+		if _, err := calculator.NewDecimal(1, scale); !errors.Is(err, calculator.ErrOutOfRange) {
+			t.Errorf("NewDecimal(1, %d) error = %v, want %v", scale, err, calculator.ErrOutOfRange)
+		}
+	}
+}
+
+// TestDecimalRescale tests banker's rounding on both sides of zero
+func TestDecimalRescale(t *testing.T) {
+	tests := []struct {
+		s     string
+		scale int
+		want  string
+	}{
+		{"2.345", 2, "2.34"},
+		{"2.355", 2, "2.36"},
+		{"2.3451", 2, "2.35"},
+		{"-2.345", 2, "-2.34"},
+		{"-2.355", 2, "-2.36"},
+		{"0.5", 0, "0"},
+		{"1.5", 0, "2"},
+		{"2.5", 0, "2"},
+		{"-0.5", 0, "0"},
+		{"-1.5", 0, "-2"},
+		{"1.5", 3, "1.500"},
+	}
+	for _, tt := range tests {
+		if got, err := mustDecimal(t, tt.s).Rescale(tt.scale); err != nil || got.String() != tt.want {
+			t.Errorf("%s.Rescale(%d) = %s, %v; want %s", tt.s, tt.scale, got, err, tt.want)
+		}
+	}
+
+	if _, err := mustDecimal(t, "9223372036854775807").Rescale(1); !errors.Is(err, calculator.ErrOverflow) {
+		t.Errorf("Rescale of MaxInt64 to one digit error = %v, want %v", err, calculator.ErrOverflow)
+	}
+	if _, err := mustDecimal(t, "1").Rescale(-1); !errors.Is(err, calculator.ErrOutOfRange) {
+		t.Errorf("Rescale(-1) error = %v, want %v", err, calculator.ErrOutOfRange)
+	}
+}
+
+// TestDecimalArithmetic tests that results keep the larger scale, are
+// rounded half to even, and report overflow and division by zero
+func TestDecimalArithmetic(t *testing.T) {
+	ops := map[string]func(d, e calculator.Decimal) (calculator.Decimal, error){
+		"add":      calculator.Decimal.Add,
+		"subtract": calculator.Decimal.Subtract,
+		"multiply": calculator.Decimal.Multiply,
+		"divide":   calculator.Decimal.Divide,
+	}
+	calc := calculator.NewCalculator(setupTestLogger())
+	tests := []struct {
+		op, a, b string
+		want     string
+		wantErr  error
+	}{
+		{op: "add", a: "0.1", b: "0.2", want: "0.3"},
+		{op: "add", a: "19.99", b: "0.01", want: "20.00"},
+		{op: "add", a: "1.5", b: "2.25", want: "3.75"},
+		{op: "subtract", a: "10", b: "0.01", want: "9.99"},
+		{op: "subtract", a: "0.01", b: "10.00", want: "-9.99"},
+		{op: "multiply", a: "19.99", b: "3", want: "59.97"},
+		{op: "multiply", a: "1.15", b: "0.10", want: "0.12"}, // 0.115 to even
+		{op: "multiply", a: "1.25", b: "0.10", want: "0.12"}, // 0.125 to even
+		{op: "multiply", a: "-1.35", b: "0.10", want: "-0.14"},
+		{op: "divide", a: "1.00", b: "3", want: "0.33"},
+		{op: "divide", a: "2.00", b: "3", want: "0.67"},
+		{op: "divide", a: "10", b: "4", want: "2"}, // 2.5 to even
+		{op: "divide", a: "-10", b: "4", want: "-2"},
+		{op: "divide", a: "0.01", b: "0.00", wantErr: calculator.ErrDivisionByZero},
+		{op: "add", a: "9223372036854775807", b: "1", wantErr: calculator.ErrOverflow},
+		{op: "add", a: "9223372036854775807", b: "0.1", wantErr: calculator.ErrOverflow},
+		{op: "multiply", a: "4294967296", b: "4294967296", wantErr: calculator.ErrOverflow},
+		{op: "divide", a: "9223372036854775807", b: "0.5", wantErr: calculator.ErrOverflow},
+		// Intermediate results beyond int64 are fine when the result fits
+		{op: "multiply", a: "922337203685477580.7", b: "0.1", want: "92233720368547758.1"},
+	}
+	for _, tt := range tests {
+		a, b := mustDecimal(t, tt.a), mustDecimal(t, tt.b)
+		got, err := ops[tt.op](a, b)
+		if !errors.Is(err, tt.wantErr) || (err == nil && got.String() != tt.want) {
+			t.Errorf("%s %s %s = %s, %v; want %s, %v", tt.a, tt.op, tt.b, got, err, tt.want, tt.wantErr)
+		}
+		if computed, cErr := calc.ComputeDecimal(tt.op, a, b); computed != got || !errors.Is(cErr, tt.wantErr) {
+			t.Errorf("ComputeDecimal(%s, %s, %s) = %s, %v; want %s, %v", tt.op, tt.a, tt.b, computed, cErr, got, err)
+		}
+	}
+
+	for _, name := range []string{"mod", "pow", "modulo"} {
+		if _, err := calc.ComputeDecimal(name, mustDecimal(t, "1.5"), mustDecimal(t, "1")); !errors.Is(err, calculator.ErrUnknownOperation) {
+			t.Errorf("ComputeDecimal(%s) error = %v, want %v", name, err, calculator.ErrUnknownOperation)
+		}
+	}
+}
+
+// TestComputeDecimalAudit tests that decimal calculations are audited
+// with their operands as strings, and skipped by Replay
+func TestComputeDecimalAudit(t *testing.T) {
+	var buf bytes.Buffer
+	audit, err := logger.NewAudit(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calc := calculator.NewCalculator(noOpBenchLogger{}, calculator.WithAudit(audit))
+	_, _ = calc.Compute("add", 1, 2)
+	_, _ = calc.ComputeDecimal("multiply", mustDecimal(t, "19.99"), mustDecimal(t, "3"))
+	_, _ = calc.ComputeDecimal("divide", mustDecimal(t, "1"), calculator.Decimal{})
+
+	for _, want := range []string{`"mode":"decimal"`, `"a":"19.99"`, `"result":"59.97"`} {
+		if !bytes.Contains(buf.Bytes(), []byte(want)) {
+			t.Errorf("audit log has no %s:\n%s", want, buf.String())
+		}
+	}
+	report, err := calculator.Replay(&buf, calculator.NewCalculator(noOpBenchLogger{}))
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if !report.OK() || report.Replayed != 1 {
+		t.Errorf("Replay = %+v, want the integer calculation only", report)
+	}
+}
+
+func ExampleDecimal_Multiply() {
+	price, _ := calculator.ParseDecimal("19.99")
+	rate, _ := calculator.ParseDecimal("0.07")
+	tax, _ := price.Multiply(rate)
+	fmt.Println(tax)
+	// Output: 1.40
+}
+
+// TestParseDecimalLongNumber tests that a long invalid number is
+// shortened in the error between runes
+func TestParseDecimalLongNumber(t *testing.T) {
+	var invalid *calculator.InvalidNumberError
+	if _, err := calculator.ParseDecimal("1" + strings.Repeat("é", 20)); !errors.As(err, &invalid) || invalid.Number != "1"+strings.Repeat("é", 7)+"..." {
+		t.Errorf("ParseDecimal(1éé...) error = %v, want the number cut after 7 runes é", err)
+	}
+}
@@ -0,0 +1,301 @@
+package calculator
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+var (
+	// ErrDivisionByZero is returned when the divisor of a division is zero.
+	ErrDivisionByZero = errors.New("division by zero")
+	// ErrUnknownOperation is matched by the *UnknownOperationError
+	// returned by Compute for a name no operation has.
+	ErrUnknownOperation = errors.New("unknown operation")
+	// ErrPrecisionLoss is matched by the *PrecisionLossError returned by
+	// Compute in strict mode for a result that is not exact.
+	ErrPrecisionLoss = errors.New("precision loss")
+	// ErrOverflow is returned by the operations that detect overflow,
+	// such as Pow, when the result does not fit in an int.
+	ErrOverflow = errors.New("integer overflow")
+	// ErrNegativeInput is returned when an operand that must not be
+	// negative is, such as the exponent of Pow.
+	ErrNegativeInput = errors.New("negative input")
+	// ErrNotFinite is returned by the float operations for a NaN or
+	// infinite operand, or a result that is not a number.
+	ErrNotFinite = errors.New("not a finite number")
+	// ErrInvalidNumber is matched by the *InvalidNumberError returned by
+	// BigCalculator for an operand that is not a decimal integer, and by
+	// FromBase for one that is not an integer in its base.
+	ErrInvalidNumber = errors.New("invalid number")
+	// ErrSyntax is matched by the *SyntaxError returned by Evaluate for a
+	// malformed expression.
+	ErrSyntax = errors.New("syntax error")
+	// ErrEmptyInput is returned by the aggregate functions, such as
+	// SumSlice, for an empty slice, which has no sum, mean or extremes.
+	ErrEmptyInput = errors.New("empty input")
+	// ErrOutOfRange is matched by the *RangeError returned for an operand
+	// outside the values an operation accepts, such as a shift amount.
+	ErrOutOfRange = errors.New("out of range")
+	// ErrUnknownVariable is matched by the *UnknownVariableError returned
+	// by Evaluate for a variable that is not set.
+	ErrUnknownVariable = errors.New("unknown variable")
+	// ErrInvalidVariable is matched by the *VariableError returned by
+	// SetVar for a name that is not an identifier, or a new variable
+	// beyond the limit of WithMaxVars.
+	ErrInvalidVariable = errors.New("invalid variable")
+	// ErrNoUndo is matched by the *UndoError returned by Undo and Redo
+	// when there is no change left to undo or redo.
+	ErrNoUndo = errors.New("no change to undo or redo")
+	// ErrRegistration is matched by the *RegistrationError returned by
+	// RegisterOperation for an operation that cannot be registered.
+	ErrRegistration = errors.New("operation registration failed")
+	// ErrDomain is matched by the *DomainError returned by the float
+	// functions, such as Log, for an operand outside their domain.
+	ErrDomain = errors.New("outside the domain")
+	// ErrLimit is matched by the *LimitError returned by Evaluate for an
+	// expression beyond the limits of WithMaxOperations or
+	// WithMaxMagnitude.
+	ErrLimit = errors.New("evaluation limit exceeded")
+)
+
+// UnknownOperationError reports the name of an operation Compute does
+// not know
+type UnknownOperationError struct {
+	Name string
+}
+
+func (e *UnknownOperationError) Error() string {
+	return "unknown operation: " + e.Name
+}
+
+// Is makes errors.Is(err, ErrUnknownOperation) match
+func (e *UnknownOperationError) Is(target error) bool {
+	return target == ErrUnknownOperation
+}
+
+// InvalidNumberError reports an operand of BigCalculator that is not a
+// decimal integer, or has more than MaxBigDigits digits, or one of
+// FromBase that is not an integer in its base
+type InvalidNumberError struct {
+	Number string // the operand, shortened when too long
+	Reason string
+}
+
+func (e *InvalidNumberError) Error() string {
+	return fmt.Sprintf("invalid number %q: %s", e.Number, e.Reason)
+}
+
+// Is makes errors.Is(err, ErrInvalidNumber) match
+func (e *InvalidNumberError) Is(target error) bool {
+	return target == ErrInvalidNumber
+}
+
+// SyntaxError reports a malformed expression given to Evaluate
+type SyntaxError struct {
+	Expr string
+	Pos  int // 1-based byte position of the problem in Expr
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at position %d: %s", e.Pos, e.Msg)
+}
+
+// Is makes errors.Is(err, ErrSyntax) match
+func (e *SyntaxError) Is(target error) bool {
+	return target == ErrSyntax
+}
+
+// RangeError reports an operand outside the range Min to Max, both
+// included, of the values an operation accepts
+type RangeError struct {
+	Name     string // what the operand is, such as "shift amount"
+	Value    int
+	Min, Max int
+}
+
+func (e *RangeError) Error() string {
+	return fmt.Sprintf("%s %d out of range, must be between %d and %d", e.Name, e.Value, e.Min, e.Max)
+}
+
+// Is makes errors.Is(err, ErrOutOfRange) match
+func (e *RangeError) Is(target error) bool {
+	return target == ErrOutOfRange
+}
+
+// DomainError reports an operand of a float function outside the values
+// it is defined for, such as a logarithm of 0
+type DomainError struct {
+	Name   string // what the operand is, such as "logarithm base"
+	Value  float64
+	Domain string // the values allowed, such as "positive"
+}
+
+func (e *DomainError) Error() string {
+	return fmt.Sprintf("%s %g outside the domain, must be %s", e.Name, e.Value, e.Domain)
+}
+
+// Is makes errors.Is(err, ErrDomain) match
+func (e *DomainError) Is(target error) bool {
+	return target == ErrDomain
+}
+
+// LimitError reports an expression Evaluate refused for exceeding one
+// of its limits, and by how much
+type LimitError struct {
+	Name  string // what was limited, such as "operation count"
+	Value int
+	Max   int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s %d exceeds the limit of %d", e.Name, e.Value, e.Max)
+}
+
+// Is makes errors.Is(err, ErrLimit) match
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimit
+}
+
+// UnknownVariableError reports a variable that is not set
+type UnknownVariableError struct {
+	Name string
+}
+
+func (e *UnknownVariableError) Error() string {
+	return "unknown variable: " + e.Name
+}
+
+// Is makes errors.Is(err, ErrUnknownVariable) match
+func (e *UnknownVariableError) Is(target error) bool {
+	return target == ErrUnknownVariable
+}
+
+// VariableError reports a variable SetVar refused, and why
+type VariableError struct {
+	Name   string // shortened when too long
+	Reason string
+}
+
+func (e *VariableError) Error() string {
+	return fmt.Sprintf("invalid variable %q: %s", e.Name, e.Reason)
+}
+
+// Is makes errors.Is(err, ErrInvalidVariable) match
+func (e *VariableError) Is(target error) bool {
+	return target == ErrInvalidVariable
+}
+
+// shortenName returns name, or for a name above 32 bytes its first 16
+// bytes or fewer, cut at the start of a rune, followed by "...", so that
+// errors do not repeat long names or operands in full
+func shortenName(name string) string {
+	if len(name) <= 32 {
+		return name
+	}
+	cut := 16
+	for cut > 0 && !utf8.RuneStart(name[cut]) {
+		cut--
+	}
+	return name[:cut] + "..."
+}
+
+// UndoError reports an Undo or Redo with no change left to revert or
+// perform again
+type UndoError struct {
+	Action string // "undo" or "redo"
+}
+
+func (e *UndoError) Error() string {
+	return "nothing to " + e.Action
+}
+
+// Is makes errors.Is(err, ErrNoUndo) match
+func (e *UndoError) Is(target error) bool {
+	return target == ErrNoUndo
+}
+
+// RegistrationError reports an operation RegisterOperation refused, and
+// why, such as a name that is already registered
+type RegistrationError struct {
+	Name   string // shortened when too long
+	Reason string
+}
+
+func (e *RegistrationError) Error() string {
+	return fmt.Sprintf("cannot register operation %q: %s", e.Name, e.Reason)
+}
+
+// Is makes errors.Is(err, ErrRegistration) match
+func (e *RegistrationError) Is(target error) bool {
+	return target == ErrRegistration
+}
+
+// PrecisionLossError reports an operation whose integer result is not
+// exact: a division with a remainder, or a result that overflows int.
+// Its fields let callers recover, from the remainder or the floating
+// point result.
+type PrecisionLossError struct {
+	Operation string  // name of the operation
+	A, B      int     // operands
+	Result    int     // the truncated or wrapped result outside strict mode
+	Remainder int     // remainder of a division: A = Result*B + Remainder
+	Overflow  bool    // whether the exact result overflows int
+	Float     float64 // the result in floating point
+}
+
+func (e *PrecisionLossError) Error() string {
+	if e.Overflow {
+		return fmt.Sprintf("precision loss: %s %d %d overflows int", e.Operation, e.A, e.B)
+	}
+	return fmt.Sprintf("precision loss: %s %d %d = %d remainder %d", e.Operation, e.A, e.B, e.Result, e.Remainder)
+}
+
+// Is makes errors.Is(err, ErrPrecisionLoss) match
+func (e *PrecisionLossError) Is(target error) bool {
+	return target == ErrPrecisionLoss
+}
+
+// OperationError reports the operation and operands of an error from
+// Compute, such as ErrOverflow for add with MaxInt and 1. It unwraps to
+// that error, so errors.Is matches its sentinel and errors.As its type,
+// such as *RangeError.
+type OperationError struct {
+	Operation string // name of the operation
+	A, B      int    // operands
+	Err       error  // the error of the operation
+}
+
+func (e *OperationError) Error() string {
+	return fmt.Sprintf("%s %d %d: %v", e.Operation, e.A, e.B, e.Err)
+}
+
+// Unwrap returns the error of the operation
+func (e *OperationError) Unwrap() error {
+	return e.Err
+}
+
+// Errors returns every sentinel error the package can return, so that
+// code translating them, such as the API error mapping, can be checked
+// for completeness.
+func Errors() []error {
+	return []error{
+		ErrDivisionByZero,
+		ErrUnknownOperation,
+		ErrPrecisionLoss,
+		ErrOverflow,
+		ErrNegativeInput,
+		ErrNotFinite,
+		ErrInvalidNumber,
+		ErrSyntax,
+		ErrEmptyInput,
+		ErrOutOfRange,
+		ErrUnknownVariable,
+		ErrInvalidVariable,
+		ErrNoUndo,
+		ErrRegistration,
+		ErrDomain,
+		ErrLimit,
+	}
+}
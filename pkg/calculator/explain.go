@@ -0,0 +1,135 @@
+package calculator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Step is a step of a computation explained by Explain or ExplainExpr:
+// what was done, in words, and the value it gave, such as the truncated
+// quotient 3 for "7 / 2 truncates toward zero to 3"
+type Step struct {
+	Description string
+	Value       int
+}
+
+// Explain performs the operation with name or alias name on a and b,
+// like Compute, and also returns the steps it took, for teaching: for
+// divide 7 2, checking the divisor for zero, truncating 7 / 2 to 3 and
+// the remainder 1. Operations without steps of their own, such as and,
+// are explained in one step. The outcome is returned as a Result, as
+// from CalculateAll, whose Err is also returned. When the operation
+// fails, the steps up to the failure are returned with its error; an
+// unknown operation has none.
+func (c *Calculator) Explain(name string, a, b int) (Result, []Step, error) {
+	result, err := c.Compute(name, a, b)
+	return Result{Value: result, Err: err}, c.ExplainResult(name, a, b, result, err), err
+}
+
+// ExplainResult returns the steps of Explain for an operation performed
+// otherwise, such as by ComputeCtx, from its result or error, without
+// performing it again. It returns nil for names no operation has.
+func (c *Calculator) ExplainResult(name string, a, b, result int, err error) []Step {
+	op, ok := c.registry.lookup(name)
+	if !ok {
+		return nil
+	}
+	if op.explain != nil {
+		return op.explain(a, b, result, err)
+	}
+	if err != nil {
+		return nil
+	}
+	if op.Arity == ArityUnary {
+		return []Step{{Description: fmt.Sprintf("%s %d = %d", op.Name, a, result), Value: result}}
+	}
+	return []Step{{Description: fmt.Sprintf("%s %d %d = %d", op.Name, a, b, result), Value: result}}
+}
+
+// ExplainExpr evaluates expr like Evaluate and also returns the order of
+// evaluation, as recorded by a Trace: a step for each operation
+// performed, such as "3 * 3 = 9" before "2 + 9 = 11" for "2 + 3 * 3".
+// When the evaluation fails, the steps up to the failure are returned
+// with its error.
+func (c *Calculator) ExplainExpr(expr string) (int, []Step, error) {
+	trace := NewTrace(0)
+	result, err := c.WithObserver(trace).Evaluate(expr)
+	var steps []Step
+	for _, s := range trace.Steps() {
+		if s.Err == nil {
+			steps = append(steps, Step{Description: fmt.Sprintf("%d %c %d = %d", s.A, operatorSymbols[s.Operation], s.B, s.Result), Value: s.Result})
+		}
+	}
+	return result, steps, err
+}
+
+// explainChecked returns the explanation of the checked operation
+// written with symbol, such as + for add
+func explainChecked(symbol string) func(a, b, result int, err error) []Step {
+	return func(a, b, result int, err error) []Step {
+		switch {
+		case errors.Is(err, ErrOverflow):
+			return []Step{{Description: fmt.Sprintf("%d %s %d does not fit in an int: overflow", a, symbol, b)}}
+		case err != nil:
+			return nil
+		}
+		return []Step{{Description: fmt.Sprintf("%d %s %d = %d, which fits in an int", a, symbol, b, result), Value: result}}
+	}
+}
+
+// explainDivide explains divide: the check of the divisor, then the
+// quotient, truncated when there is a remainder, and its rejection by
+// strict mode when it is not exact
+func explainDivide(a, b, _ int, err error) []Step {
+	if b == 0 {
+		return []Step{{Description: "checked divisor for zero: it is zero, so there is no quotient"}}
+	}
+	steps := []Step{{Description: fmt.Sprintf("checked divisor %d for zero", b), Value: b}}
+	switch {
+	case a == math.MinInt && b == -1:
+		steps = append(steps, Step{Description: fmt.Sprintf("%d / -1 does not fit in an int and wraps to %d", a, a), Value: a})
+	case a%b != 0:
+		steps = append(steps,
+			Step{Description: fmt.Sprintf("%d / %d truncates toward zero to %d", a, b, a/b), Value: a / b},
+			Step{Description: fmt.Sprintf("%d / %d leaves the remainder %d", a, b, a%b), Value: a % b},
+		)
+	default:
+		return append(steps, Step{Description: fmt.Sprintf("%d / %d = %d exactly", a, b, a/b), Value: a / b})
+	}
+	var loss *PrecisionLossError
+	switch {
+	case !errors.As(err, &loss):
+	case loss.Overflow:
+		steps = append(steps, Step{Description: "rejected in strict mode: overflow"})
+	default:
+		steps = append(steps, Step{Description: fmt.Sprintf("rejected in strict mode: remainder %d", loss.Remainder), Value: loss.Remainder})
+	}
+	return steps
+}
+
+// explainMod explains mod: the check of the divisor, then the remainder
+func explainMod(a, b, _ int, _ error) []Step {
+	if b == 0 {
+		return []Step{{Description: "checked divisor for zero: it is zero, so there is no remainder"}}
+	}
+	return []Step{
+		{Description: fmt.Sprintf("checked divisor %d for zero", b), Value: b},
+		{Description: fmt.Sprintf("%d / %d leaves the remainder %d, with the sign of %d", a, b, a%b, a), Value: a % b},
+	}
+}
+
+// explainPow explains pow: the check of the exponent, then the power
+func explainPow(a, b, result int, err error) []Step {
+	if b < 0 {
+		return []Step{{Description: fmt.Sprintf("checked exponent %d for a negative: it is negative", b), Value: b}}
+	}
+	steps := []Step{{Description: fmt.Sprintf("checked exponent %d for a negative", b), Value: b}}
+	switch {
+	case errors.Is(err, ErrOverflow):
+		return append(steps, Step{Description: fmt.Sprintf("%d ^ %d does not fit in an int: overflow", a, b)})
+	case err != nil:
+		return steps
+	}
+	return append(steps, Step{Description: fmt.Sprintf("%d ^ %d = %d", a, b, result), Value: result})
+}
@@ -0,0 +1,130 @@
+package calculator_test
+
+import (
+	"errors"
+	"go-examples/pkg/calculator"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// TestExplain tests the steps of the operations explained step by step
+// and of the others, with their results and errors
+func TestExplain(t *testing.T) {
+	tests := []struct {
+		operation string
+		a, b      int
+		want      int
+		wantErr   error
+		steps     []calculator.Step
+	}{
+		{"divide", 7, 2, 3, nil, []calculator.Step{
+			{Description: "checked divisor 2 for zero", Value: 2},
+			{Description: "7 / 2 truncates toward zero to 3", Value: 3},
+			{Description: "7 / 2 leaves the remainder 1", Value: 1},
+		}},
+		{"divide", -8, 2, -4, nil, []calculator.Step{
+			{Description: "checked divisor 2 for zero", Value: 2},
+			{Description: "-8 / 2 = -4 exactly", Value: -4},
+		}},
+		{"divide", math.MinInt, -1, math.MinInt, nil, []calculator.Step{
+			{Description: "checked divisor -1 for zero", Value: -1},
+			{Description: "-9223372036854775808 / -1 does not fit in an int and wraps to -9223372036854775808", Value: math.MinInt},
+		}},
+		{"divide", 7, 0, 0, calculator.ErrDivisionByZero, []calculator.Step{
+			{Description: "checked divisor for zero: it is zero, so there is no quotient"},
+		}},
+		{"mod", -7, 2, -1, nil, []calculator.Step{
+			{Description: "checked divisor 2 for zero", Value: 2},
+			{Description: "-7 / 2 leaves the remainder -1, with the sign of -7", Value: -1},
+		}},
+		{"add", 2, 3, 5, nil, []calculator.Step{{Description: "2 + 3 = 5, which fits in an int", Value: 5}}},
+		{"multiply", math.MaxInt, 2, 0, calculator.ErrOverflow, []calculator.Step{
+			{Description: "9223372036854775807 * 2 does not fit in an int: overflow"},
+		}},
+		{"pow", 2, 10, 1024, nil, []calculator.Step{
+			{Description: "checked exponent 10 for a negative", Value: 10},
+			{Description: "2 ^ 10 = 1024", Value: 1024},
+		}},
+		{"pow", 2, -1, 0, calculator.ErrNegativeInput, []calculator.Step{
+			{Description: "checked exponent -1 for a negative: it is negative", Value: -1},
+		}},
+		{"and", 12, 10, 8, nil, []calculator.Step{{Description: "and 12 10 = 8", Value: 8}}},
+		{"abs", -5, 0, 5, nil, []calculator.Step{{Description: "abs -5 = 5", Value: 5}}},
+		{"shl", 1, 64, 0, calculator.ErrOutOfRange, nil},
+		{"modulo", 1, 2, 0, calculator.ErrUnknownOperation, nil},
+	}
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	for _, tc := range tests {
+		got, steps, err := calc.Explain(tc.operation, tc.a, tc.b)
+		if got.Value != tc.want || !errors.Is(err, tc.wantErr) || (tc.wantErr == nil && err != nil) || got.Err != err {
+			t.Errorf("Explain(%s, %d, %d) = %+v, %v, want %d, %v", tc.operation, tc.a, tc.b, got, err, tc.want, tc.wantErr)
+		}
+		if !reflect.DeepEqual(steps, tc.steps) {
+			t.Errorf("Explain(%s, %d, %d) steps = %q, want %q", tc.operation, tc.a, tc.b, steps, tc.steps)
+		}
+	}
+
+	// Explain performs the operation like Compute
+	strict := calculator.NewCalculator(noOpBenchLogger{}, calculator.WithStrict(), calculator.WithHistory(5))
+	for _, tc := range []struct {
+		a, b  int
+		steps []calculator.Step
+	}{
+		{7, 2, []calculator.Step{
+			{Description: "checked divisor 2 for zero", Value: 2},
+			{Description: "7 / 2 truncates toward zero to 3", Value: 3},
+			{Description: "7 / 2 leaves the remainder 1", Value: 1},
+			{Description: "rejected in strict mode: remainder 1", Value: 1},
+		}},
+		{math.MinInt, -1, []calculator.Step{
+			{Description: "checked divisor -1 for zero", Value: -1},
+			{Description: "-9223372036854775808 / -1 does not fit in an int and wraps to -9223372036854775808", Value: math.MinInt},
+			{Description: "rejected in strict mode: overflow"},
+		}},
+		{8, 2, []calculator.Step{
+			{Description: "checked divisor 2 for zero", Value: 2},
+			{Description: "8 / 2 = 4 exactly", Value: 4},
+		}},
+	} {
+		got, steps, err := strict.Explain("divide", tc.a, tc.b)
+		if wantErr := len(tc.steps) > 2; errors.Is(err, calculator.ErrPrecisionLoss) != wantErr || (wantErr && got.Value != 0) {
+			t.Errorf("strict Explain(divide, %d, %d) = %+v, %v", tc.a, tc.b, got, err)
+		}
+		if !reflect.DeepEqual(steps, tc.steps) {
+			t.Errorf("strict Explain(divide, %d, %d) steps = %q, want %q", tc.a, tc.b, steps, tc.steps)
+		}
+	}
+	if _, _, err := strict.Explain("add", 1, 2); err != nil || len(strict.History()) != 2 {
+		t.Errorf("Explain(add, 1, 2) error = %v with history %v, want it recorded", err, strict.History())
+	}
+}
+
+// TestExplainExpr tests that the steps of an expression follow the
+// order of evaluation, with a step per operation and none for reading
+// a variable
+func TestExplainExpr(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	if err := calc.SetVar("qty", 3); err != nil {
+		t.Fatal(err)
+	}
+	got, steps, err := calc.ExplainExpr("2 + qty * (4 - 1)")
+	want := []calculator.Step{
+		{Description: "4 - 1 = 3", Value: 3},
+		{Description: "3 * 3 = 9", Value: 9},
+		{Description: "2 + 9 = 11", Value: 11},
+	}
+	if got != 11 || err != nil || !reflect.DeepEqual(steps, want) {
+		t.Errorf("ExplainExpr = %d, %q, %v, want 11, %q", got, steps, err, want)
+	}
+
+	// The steps stop at the failure
+	_, steps, err = calc.ExplainExpr("1 + 2 + 3 / 0")
+	want = []calculator.Step{{Description: "1 + 2 = 3", Value: 3}}
+	if !errors.Is(err, calculator.ErrDivisionByZero) || !reflect.DeepEqual(steps, want) {
+		t.Errorf("ExplainExpr(division by zero) = %q, %v, want %q and %v", steps, err, want, calculator.ErrDivisionByZero)
+	}
+	if _, steps, err := calc.ExplainExpr("1 +"); !errors.Is(err, calculator.ErrSyntax) || steps != nil {
+		t.Errorf("ExplainExpr(1 +) = %q, %v, want no steps and a syntax error", steps, err)
+	}
+}
@@ -0,0 +1,307 @@
+package calculator
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+)
+
+// WithMaxOperations limits the expressions of Evaluate to n operations,
+// so that an expression with more returns a *LimitError before any is
+// performed. A sign counts as an operation. A non-positive n, the
+// default, leaves the number of operations unbounded.
+func WithMaxOperations(n int) Option {
+	return func(c *Calculator) {
+		c.maxOperations = max(n, 0)
+	}
+}
+
+// WithMaxMagnitude limits the values Evaluate computes to n in absolute
+// value, so that an operation giving a larger one, even on the way to a
+// smaller result, stops the evaluation with a *LimitError. The
+// operation itself is performed, and recorded like any other. A
+// non-positive n, the default, bounds values only by the size of int.
+func WithMaxMagnitude(n int) Option {
+	return func(c *Calculator) {
+		c.maxMagnitude = max(n, 0)
+	}
+}
+
+// Evaluate returns the value of the integer arithmetic expression expr,
+// such as "2 + 3 * (4 - 1)", with +, -, *, / and parentheses. * and /
+// bind tighter than + and -, operators of the same precedence apply
+// from left to right, and a leading - or + applies to the operand after
+// it. Operands are numbers or the variables set by SetVar, such as
+// "price * qty"; a variable that is not set returns an
+// *UnknownVariableError. A malformed expression returns a *SyntaxError
+// giving the position of the problem. Each operation is performed by
+// Compute, so WithStrict, WithAudit and WithHistory apply to each one,
+// and its errors are those of Compute: wrapped in an *OperationError
+// naming the operation and its operands, to be matched with errors.Is,
+// such as against ErrDivisionByZero, and errors.As. An expression
+// beyond the limits of WithMaxOperations or WithMaxMagnitude returns a
+// *LimitError.
+func (c *Calculator) Evaluate(expr string) (int, error) {
+	return c.evaluate(context.Background(), expr)
+}
+
+// EvaluateCtx performs like Evaluate, but checks ctx before each
+// operation, returning ctx.Err() wrapped in an *OperationError naming
+// the operation it stopped at once ctx is done, so that a deadline
+// stops a long expression. A nil ctx never is.
+func (c *Calculator) EvaluateCtx(ctx context.Context, expr string) (int, error) {
+	return c.evaluate(orBackground(ctx), expr)
+}
+
+// evaluate performs Evaluate, stopping once ctx is done
+func (c *Calculator) evaluate(ctx context.Context, expr string) (int, error) {
+	c.log.Infof("Evaluating expression: %s", expr)
+	p := &parser{expr: expr, maxOperations: c.maxOperations}
+	p.next()
+	n := p.parseExpr()
+	if p.err == nil && p.tok.kind != tokEOF {
+		p.fail("unexpected %s", p.tok)
+	}
+	if p.err != nil {
+		c.log.With("expr", expr).Errorf("Evaluation failed: %v", p.err)
+		return 0, p.err
+	}
+	result, err := c.eval(ctx, n)
+	if err != nil {
+		c.log.With("expr", expr).Errorf("Evaluation failed: %v", err)
+		return 0, err
+	}
+	c.log.Debugf("Evaluation result: %d", result)
+	return result, nil
+}
+
+// eval computes the value of the expression tree n. Each operation is
+// performed by ComputeCtx, which checks ctx and tells the observers of
+// c of it, so that a Trace records the reductions in order.
+func (c *Calculator) eval(ctx context.Context, n *node) (int, error) {
+	if n.variable != "" {
+		value, ok := c.GetVar(n.variable)
+		if !ok {
+			return 0, &UnknownVariableError{Name: n.variable}
+		}
+		return value, nil
+	}
+	if n.name == "" {
+		return n.value, nil
+	}
+	a, err := c.eval(ctx, n.left)
+	if err != nil {
+		return 0, err
+	}
+	b, err := c.eval(ctx, n.right)
+	if err != nil {
+		return 0, err
+	}
+	result, err := c.ComputeCtx(ctx, n.name, a, b)
+	if err != nil {
+		return 0, err
+	}
+	if c.maxMagnitude > 0 && (result > c.maxMagnitude || result < -c.maxMagnitude) {
+		return 0, &LimitError{Name: "magnitude of intermediate value", Value: result, Max: c.maxMagnitude}
+	}
+	return result, nil
+}
+
+// node is an expression tree: a number, a variable, or the operation
+// name applied to the values of left and right
+type node struct {
+	name        string
+	value       int
+	variable    string
+	left, right *node
+}
+
+// operatorNames maps the operators of an expression to the operations
+// performing them
+var operatorNames = map[byte]string{
+	'+': "add",
+	'-': "subtract",
+	'*': "multiply",
+	'/': "divide",
+}
+
+// operatorSymbols maps the operations of operatorNames back to their
+// operators, for the steps of ExplainExpr
+var operatorSymbols = map[string]byte{
+	"add":      '+',
+	"subtract": '-',
+	"multiply": '*',
+	"divide":   '/',
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokIdent
+	tokOperator
+	tokOpen
+	tokClose
+)
+
+// token is a lexical token of an expression, at the 1-based position pos
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+// maxNesting bounds the parentheses and signs an operand can be nested
+// in, so that untrusted input cannot exhaust the stack
+const maxNesting = 100
+
+// parser is a recursive-descent parser of expressions. The first error
+// is kept in err, after which the parse functions return nil.
+type parser struct {
+	expr  string
+	off   int // byte offset of the next token
+	tok   token
+	err   error
+	depth int // nesting of the factor being parsed
+
+	operations    int // operations parsed so far
+	maxOperations int // limit of WithMaxOperations, 0 when unbounded
+}
+
+// next reads the next token into p.tok
+func (p *parser) next() {
+	for p.off < len(p.expr) && isSpace(p.expr[p.off]) {
+		p.off++
+	}
+	start := p.off
+	if start == len(p.expr) {
+		p.tok = token{kind: tokEOF, pos: start + 1}
+		return
+	}
+	ch := p.expr[start]
+	kind := tokOperator
+	switch {
+	case isDigit(ch):
+		for p.off < len(p.expr) && isDigit(p.expr[p.off]) {
+			p.off++
+		}
+		p.tok = token{kind: tokNumber, text: p.expr[start:p.off], pos: start + 1}
+		return
+	case isIdentChar(ch):
+		for p.off < len(p.expr) && isIdentChar(p.expr[p.off]) {
+			p.off++
+		}
+		p.tok = token{kind: tokIdent, text: p.expr[start:p.off], pos: start + 1}
+		return
+	case ch == '(':
+		kind = tokOpen
+	case ch == ')':
+		kind = tokClose
+	case operatorNames[ch] == "":
+		// Report whole runes rather than the bytes of one
+		r, _ := utf8.DecodeRuneInString(p.expr[start:])
+		p.tok = token{kind: tokOperator, text: string(r), pos: start + 1}
+		p.fail("unexpected character %q", r)
+		return
+	}
+	p.off++
+	p.tok = token{kind: kind, text: p.expr[start:p.off], pos: start + 1}
+}
+
+// fail records a *SyntaxError at the current token, unless there is one
+func (p *parser) fail(format string, args ...any) {
+	if p.err == nil {
+		p.err = &SyntaxError{Expr: p.expr, Pos: p.tok.pos, Msg: fmt.Sprintf(format, args...)}
+	}
+}
+
+// operation returns the node of the operation name on left and right,
+// recording a *LimitError instead when it is one operation too many
+func (p *parser) operation(name string, left, right *node) *node {
+	p.operations++
+	if p.maxOperations > 0 && p.operations > p.maxOperations && p.err == nil {
+		p.err = &LimitError{Name: "operation count", Value: p.operations, Max: p.maxOperations}
+	}
+	return &node{name: name, left: left, right: right}
+}
+
+// parseExpr parses a sum: terms separated by + or -
+func (p *parser) parseExpr() *node {
+	n := p.parseTerm()
+	for p.err == nil && p.tok.kind == tokOperator && (p.tok.text == "+" || p.tok.text == "-") {
+		name := operatorNames[p.tok.text[0]]
+		p.next()
+		n = p.operation(name, n, p.parseTerm())
+	}
+	return n
+}
+
+// parseTerm parses a product: factors separated by * or /
+func (p *parser) parseTerm() *node {
+	n := p.parseFactor()
+	for p.err == nil && p.tok.kind == tokOperator && (p.tok.text == "*" || p.tok.text == "/") {
+		name := operatorNames[p.tok.text[0]]
+		p.next()
+		n = p.operation(name, n, p.parseFactor())
+	}
+	return n
+}
+
+// parseFactor parses a number, a variable, a signed factor or a
+// parenthesized expression
+func (p *parser) parseFactor() *node {
+	if p.err != nil {
+		return nil
+	}
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxNesting {
+		p.fail("expression nested more than %d deep", maxNesting)
+		return nil
+	}
+	switch tok := p.tok; {
+	case tok.kind == tokNumber:
+		value, err := strconv.Atoi(tok.text)
+		if err != nil {
+			p.fail("number %s does not fit in an int", tok.text)
+			return nil
+		}
+		p.next()
+		return &node{value: value}
+	case tok.kind == tokIdent:
+		p.next()
+		return &node{variable: tok.text}
+	case tok.kind == tokOperator && (tok.text == "-" || tok.text == "+"):
+		p.next()
+		operand := p.parseFactor()
+		if tok.text == "+" {
+			return operand
+		}
+		return p.operation("subtract", &node{}, operand)
+	case tok.kind == tokOpen:
+		p.next()
+		n := p.parseExpr()
+		if p.err == nil && p.tok.kind != tokClose {
+			p.fail("expected \")\" to close the \"(\" at position %d, found %s", tok.pos, p.tok)
+			return nil
+		}
+		p.next()
+		return n
+	default:
+		p.fail("expected a number or \"(\", found %s", tok)
+		return nil
+	}
+}
+
+func isDigit(ch byte) bool { return '0' <= ch && ch <= '9' }
+
+func isSpace(ch byte) bool { return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' }
@@ -0,0 +1,182 @@
+package calculator_test
+
+import (
+	"errors"
+	"fmt"
+	"go-examples/pkg/calculator"
+	"maps"
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestEvaluate tests precedence, associativity, parentheses and signs
+func TestEvaluate(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	tests := []struct {
+		expr string
+		want int
+	}{
+		{"42", 42},
+		{"2 + 3 * 4", 14},
+		{"(2 + 3) * 4", 20},
+		{"10 - 4 - 3", 3},
+		{"100 / 10 / 5", 2},
+		{"7 / 2", 3},
+		{"-7 / 2", -3},
+		{"2 * -3", -6},
+		{"-(2 + 3)", -5},
+		{"--4", 4},
+		{"+5 - +2", 3},
+		{" ( ( 1 ) ) ", 1},
+		{"1+2*3-4/2", 5},
+		{"9223372036854775807", math.MaxInt},
+		{"-9223372036854775807 - 1", math.MinInt},
+	}
+	for _, tt := range tests {
+		got, err := calc.Evaluate(tt.expr)
+		if err != nil || got != tt.want {
+			t.Errorf("Evaluate(%q) = %d, %v; want %d", tt.expr, got, err, tt.want)
+		}
+	}
+}
+
+// TestEvaluateSyntaxError tests that malformed expressions report the
+// position of the problem
+func TestEvaluateSyntaxError(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	tests := []struct {
+		expr string
+		pos  int
+		msg  string
+	}{
+		{"", 1, `expected a number or "(", found end of expression`},
+		{"2 + * 3", 5, `expected a number or "(", found "*"`},
+		{"2 +", 4, `expected a number or "(", found end of expression`},
+		{"(1 + 2", 7, `expected ")" to close the "(" at position 1, found end of expression`},
+		{"1 + 2)", 6, `unexpected ")"`},
+		{"2 3", 3, `unexpected "3"`},
+		{"2 % 3", 3, `unexpected character '%'`},
+		{"2 × 3", 3, `unexpected character '×'`},
+		{"99999999999999999999", 1, "number 99999999999999999999 does not fit in an int"},
+		{strings.Repeat("(", 101) + "1" + strings.Repeat(")", 101), 101, "expression nested more than 100 deep"},
+	}
+	for _, tt := range tests {
+		_, err := calc.Evaluate(tt.expr)
+		var syntax *calculator.SyntaxError
+		if !errors.As(err, &syntax) {
+			t.Errorf("Evaluate(%q) error = %v, want a *SyntaxError", tt.expr, err)
+			continue
+		}
+		if syntax.Pos != tt.pos || syntax.Msg != tt.msg || syntax.Expr != tt.expr {
+			t.Errorf("Evaluate(%q) error = %+v, want position %d: %s", tt.expr, syntax, tt.pos, tt.msg)
+		}
+		if !errors.Is(err, calculator.ErrSyntax) {
+			t.Errorf("Evaluate(%q) error does not match ErrSyntax", tt.expr)
+		}
+	}
+}
+
+// TestEvaluateErrors tests that the errors of the operations are
+// returned as Compute returns them, after the whole expression parsed
+func TestEvaluateErrors(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	tests := []struct {
+		expr string
+		want error
+	}{
+		{"1 / (2 - 2)", calculator.ErrDivisionByZero},
+		{"9223372036854775807 + 1", calculator.ErrOverflow},
+		{"-(-9223372036854775807 - 1)", calculator.ErrOverflow},
+		{"1 / 0 +", calculator.ErrSyntax},
+	}
+	for _, tt := range tests {
+		if _, err := calc.Evaluate(tt.expr); !errors.Is(err, tt.want) {
+			t.Errorf("Evaluate(%q) error = %v, want %v", tt.expr, err, tt.want)
+		}
+	}
+
+	var opErr *calculator.OperationError
+	if _, err := calc.Evaluate("2 * (3 - 1 / 0)"); !errors.As(err, &opErr) || *opErr != (calculator.OperationError{Operation: "divide", A: 1, B: 0, Err: calculator.ErrDivisionByZero}) {
+		t.Errorf("Evaluate(2 * (3 - 1 / 0)) error = %#v, want the *OperationError of 1 / 0", err)
+	}
+
+	strict := calculator.NewCalculator(noOpBenchLogger{}, calculator.WithStrict())
+	if _, err := strict.Evaluate("7 / 2 * 2"); !errors.Is(err, calculator.ErrPrecisionLoss) {
+		t.Errorf("strict Evaluate(7 / 2 * 2) error = %v, want %v", err, calculator.ErrPrecisionLoss)
+	}
+}
+
+// TestEvaluateHistory tests that each operation of an expression is
+// recorded, innermost first
+func TestEvaluateHistory(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{}, calculator.WithHistory(10))
+	if _, err := calc.Evaluate("2 + 3 * 4"); err != nil {
+		t.Fatal(err)
+	}
+	var ops []string
+	for _, e := range calc.History() {
+		ops = append(ops, fmt.Sprintf("%s %d %d", e.Operation, e.A, e.B))
+	}
+	if got, want := strings.Join(ops, ", "), "multiply 3 4, add 2 12"; got != want {
+		t.Errorf("History() = %s, want %s", got, want)
+	}
+}
+
+// TestEvaluateLimits tests that each limit returns its *LimitError,
+// the operation count before any operation is performed, and that
+// expressions within the limits are unaffected
+func TestEvaluateLimits(t *testing.T) {
+	var counts calculator.CountingObserver
+	calc := calculator.NewCalculator(noOpBenchLogger{}, calculator.WithMaxOperations(3), calculator.WithMaxMagnitude(1000))
+	calc.AddObserver(&counts)
+	tests := []struct {
+		expr string
+		want int
+		err  *calculator.LimitError
+	}{
+		{"2 + 3 * 4 - 5", 9, nil},
+		{"-1000 + 999 * 1", -1, nil},
+		{"1 + 1 + 1 + 1 + 1", 0, &calculator.LimitError{Name: "operation count", Value: 4, Max: 3}},
+		{"-(1 + 1 + 1 + 1)", 0, &calculator.LimitError{Name: "operation count", Value: 4, Max: 3}},
+		{"1 + 1 + 1 + 1 + 1 + 1 / 0", 0, &calculator.LimitError{Name: "operation count", Value: 4, Max: 3}},
+		{"100 * 100 / 100", 0, &calculator.LimitError{Name: "magnitude of intermediate value", Value: 10000, Max: 1000}},
+		{"-999 - 2", 0, &calculator.LimitError{Name: "magnitude of intermediate value", Value: -1001, Max: 1000}},
+	}
+	for _, tt := range tests {
+		got, err := calc.Evaluate(tt.expr)
+		if tt.err == nil {
+			if err != nil || got != tt.want {
+				t.Errorf("Evaluate(%q) = %d, %v; want %d", tt.expr, got, err, tt.want)
+			}
+			continue
+		}
+		var limit *calculator.LimitError
+		if !errors.As(err, &limit) || *limit != *tt.err || !errors.Is(err, calculator.ErrLimit) {
+			t.Errorf("Evaluate(%q) error = %v, want %v", tt.expr, err, tt.err)
+		}
+	}
+	// The expressions over the operation count performed nothing, those
+	// over the magnitude stopped at the operation exceeding it
+	if got, want := counts.Counts(), map[string]int{"add": 2, "subtract": 4, "multiply": 3}; !maps.Equal(got, want) {
+		t.Errorf("operations performed = %v, want %v", got, want)
+	}
+
+	// Without the options, the limits are those of int
+	unlimited := calculator.NewCalculator(noOpBenchLogger{}, calculator.WithMaxOperations(-1))
+	expr := "1" + strings.Repeat(" + 1", 999)
+	if got, err := unlimited.Evaluate(expr); err != nil || got != 1000 {
+		t.Errorf("Evaluate(1000 ones) = %d, %v; want 1000", got, err)
+	}
+}
+
+func ExampleCalculator_Evaluate() {
+	calc := calculator.NewCalculator(nil)
+	result, _ := calc.Evaluate("2 + 3 * (4 - 1)")
+	fmt.Println(result)
+	_, err := calc.Evaluate("2 + * 3")
+	fmt.Println(err)
+	// Output:
+	// 11
+	// syntax error at position 5: expected a number or "(", found "*"
+}
@@ -0,0 +1,74 @@
+package calculator
+
+import "time"
+
+// MaxFibonacci is the largest n whose Fibonacci number fits in an int
+const MaxFibonacci = 92
+
+// Fibonacci returns the Fibonacci number F(n), with F(0) = 0 and F(1) =
+// 1, computed iteratively. It returns ErrNegativeInput for a negative n
+// and ErrOverflow for n above MaxFibonacci.
+func (c *Calculator) Fibonacci(n int) (result int, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notify("fib", n, 0, result, err, time.Since(start)) }()
+	}
+	c.log.Infof("Calculating Fibonacci number: F(%d)", n)
+	switch {
+	case n < 0:
+		err = ErrNegativeInput
+	case n > MaxFibonacci:
+		err = ErrOverflow
+	}
+	if err != nil {
+		c.log.With("n", n).Errorf("Fibonacci failed: %v", err)
+		return 0, err
+	}
+	a, b := 0, 1
+	for range n {
+		a, b = b, a+b
+	}
+	c.log.Debugf("Fibonacci result after %d iterations: %d", n, a)
+	return a, nil
+}
+
+// FibonacciSlice returns the first n Fibonacci numbers, F(0) to F(n-1).
+// It returns ErrNegativeInput for a negative n and ErrOverflow when the
+// last does not fit in an int, for n above MaxFibonacci+1.
+func (c *Calculator) FibonacciSlice(n int) (result []int, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notifyValues("fibslice", []any{n}, result, err, time.Since(start)) }()
+	}
+	c.log.Infof("Calculating the first %d Fibonacci numbers", n)
+	switch {
+	case n < 0:
+		err = ErrNegativeInput
+	case n > MaxFibonacci+1:
+		err = ErrOverflow
+	}
+	if err != nil {
+		c.log.With("n", n).Errorf("Fibonacci failed: %v", err)
+		return nil, err
+	}
+	terms := make([]int, n)
+	for i := range terms {
+		if i < 2 {
+			terms[i] = i
+			continue
+		}
+		terms[i] = terms[i-1] + terms[i-2]
+	}
+	c.log.Debugf("Fibonacci numbers after %d iterations", max(n-2, 0))
+	return terms, nil
+}
+
+// Fibonacci returns the Fibonacci number F(n), or ErrNegativeInput or
+// ErrOverflow.
+func Fibonacci(n int) (int, error) {
+	return noOpCalculator.Fibonacci(n)
+}
+
+// FibonacciSlice returns the first n Fibonacci numbers, or
+// ErrNegativeInput or ErrOverflow.
+func FibonacciSlice(n int) ([]int, error) {
+	return noOpCalculator.FibonacciSlice(n)
+}
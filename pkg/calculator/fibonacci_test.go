@@ -0,0 +1,67 @@
+package calculator_test
+
+import (
+	"errors"
+	"go-examples/pkg/calculator"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// TestFibonacci tests the first numbers, the largest that fits in an
+// int and the errors on both sides
+func TestFibonacci(t *testing.T) {
+	calc := calculator.NewCalculator(setupTestLogger())
+	tests := []struct {
+		n       int
+		want    int
+		wantErr error
+	}{
+		{n: 0, want: 0},
+		{n: 1, want: 1},
+		{n: 2, want: 1},
+		{n: 10, want: 55},
+		{n: 92, want: 7540113804746346429},
+		{n: 93, wantErr: calculator.ErrOverflow},
+		{n: math.MaxInt, wantErr: calculator.ErrOverflow},
+		{n: -1, wantErr: calculator.ErrNegativeInput},
+		{n: math.MinInt, wantErr: calculator.ErrNegativeInput},
+	}
+	for _, tt := range tests {
+		if got, err := calc.Fibonacci(tt.n); got != tt.want || !errors.Is(err, tt.wantErr) {
+			t.Errorf("Fibonacci(%d) = %d, %v; want %d, %v", tt.n, got, err, tt.want, tt.wantErr)
+		}
+		if got, err := calculator.Fibonacci(tt.n); got != tt.want || !errors.Is(err, tt.wantErr) {
+			t.Errorf("package-level Fibonacci(%d) = %d, %v; want %d, %v", tt.n, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+// TestFibonacciSlice tests that the slice holds the numbers Fibonacci
+// returns, up to the last that fits in an int
+func TestFibonacciSlice(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	if got, err := calc.FibonacciSlice(0); err != nil || len(got) != 0 {
+		t.Errorf("FibonacciSlice(0) = %v, %v; want none", got, err)
+	}
+	if got, err := calc.FibonacciSlice(7); err != nil || !reflect.DeepEqual(got, []int{0, 1, 1, 2, 3, 5, 8}) {
+		t.Errorf("FibonacciSlice(7) = %v, %v", got, err)
+	}
+
+	all, err := calc.FibonacciSlice(calculator.MaxFibonacci + 1)
+	if err != nil || len(all) != calculator.MaxFibonacci+1 {
+		t.Fatalf("FibonacciSlice(93) = %d numbers, %v; want 93", len(all), err)
+	}
+	for n, got := range all {
+		if want, _ := calc.Fibonacci(n); got != want {
+			t.Errorf("FibonacciSlice(93)[%d] = %d, want %d", n, got, want)
+		}
+	}
+
+	if got, err := calc.FibonacciSlice(94); got != nil || !errors.Is(err, calculator.ErrOverflow) {
+		t.Errorf("FibonacciSlice(94) = %v, %v; want %v", got, err, calculator.ErrOverflow)
+	}
+	if got, err := calculator.FibonacciSlice(-1); got != nil || !errors.Is(err, calculator.ErrNegativeInput) {
+		t.Errorf("FibonacciSlice(-1) = %v, %v; want %v", got, err, calculator.ErrNegativeInput)
+	}
+}
@@ -0,0 +1,141 @@
+package calculator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Migration upgrades a decoded state file from its version to the next
+// one, editing its top-level fields in place
+type Migration func(doc map[string]json.RawMessage) error
+
+// FileStore is a StateStore writing the State as JSON to a file. Saves
+// replace the file atomically, so a crash leaves the previous state.
+// Files of older schema versions are upgraded on Load by the migrations
+// for their version.
+type FileStore struct {
+	path       string
+	migrations map[int]Migration
+}
+
+// FileStoreOption configures a FileStore
+type FileStoreOption func(*FileStore)
+
+// WithMigration upgrades files of version from to version from+1 with m,
+// replacing the built-in migration for from, if any. A nil m removes it,
+// so files of version from fail to load.
+func WithMigration(from int, m Migration) FileStoreOption {
+	return func(f *FileStore) {
+		if m == nil {
+			delete(f.migrations, from)
+			return
+		}
+		f.migrations[from] = m
+	}
+}
+
+// NewFileStore creates a FileStore for the file at path. The file is
+// created by the first Save.
+func NewFileStore(path string, opts ...FileStoreOption) *FileStore {
+	f := &FileStore{
+		path:       path,
+		migrations: map[int]Migration{0: migrateV0},
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(f)
+		}
+	}
+	return f
+}
+
+// migrateV0 upgrades files written before the schema was versioned,
+// which kept the variables in "vars"
+func migrateV0(doc map[string]json.RawMessage) error {
+	if vars, ok := doc["vars"]; ok {
+		doc["variables"] = vars
+		delete(doc, "vars")
+	}
+	return nil
+}
+
+// Load reads the State from the file, upgrading older versions. A
+// missing file is an empty State.
+func (f *FileStore) Load() (State, error) {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return State{Version: StateVersion}, nil
+	}
+	if err != nil {
+		return State{}, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return State{}, fmt.Errorf("corrupt state file %s: %w", f.path, err)
+	}
+	version := 0
+	if raw, ok := doc["version"]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return State{}, fmt.Errorf("corrupt state file %s: invalid version: %w", f.path, err)
+		}
+	}
+	if version > StateVersion {
+		return State{}, fmt.Errorf("state file %s has version %d, newer than the supported %d", f.path, version, StateVersion)
+	}
+	for ; version < StateVersion; version++ {
+		migrate, ok := f.migrations[version]
+		if !ok {
+			return State{}, fmt.Errorf("state file %s has version %d, which cannot be migrated", f.path, version)
+		}
+		if err := migrate(doc); err != nil {
+			return State{}, fmt.Errorf("failed to migrate state file %s from version %d: %w", f.path, version, err)
+		}
+	}
+	doc["version"] = json.RawMessage(fmt.Sprint(StateVersion))
+
+	data, err = json.Marshal(doc)
+	if err != nil {
+		return State{}, fmt.Errorf("failed to encode migrated state: %w", err)
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return State{}, fmt.Errorf("corrupt state file %s: %w", f.path, err)
+	}
+	return state, nil
+}
+
+// Save writes state to a temporary file next to the file and renames it
+// over the file
+func (f *FileStore) Save(state State) error {
+	state.Version = StateVersion
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create state file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // fails harmlessly once renamed
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package calculator
+
+import (
+	"math"
+	"time"
+)
+
+// Float operations take and return float64 for calculations with
+// fractions. They never return NaN or an infinity: a NaN or infinite
+// operand returns ErrNotFinite, as does a result that is not a number,
+// and a result too large for a float64 returns ErrOverflow.
+
+// AddFloat returns the sum of two floats
+func (c *Calculator) AddFloat(a, b float64) (result float64, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notifyValues("add", []any{a, b}, result, err, time.Since(start)) }()
+	}
+	c.log.Infof("Calculating addition: %g + %g", a, b)
+	return c.floatResult("Addition", a, b, a+b)
+}
+
+// SubtractFloat returns the difference between two floats
+func (c *Calculator) SubtractFloat(a, b float64) (result float64, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notifyValues("subtract", []any{a, b}, result, err, time.Since(start)) }()
+	}
+	c.log.Infof("Calculating subtraction: %g - %g", a, b)
+	return c.floatResult("Subtraction", a, b, a-b)
+}
+
+// MultiplyFloat returns the product of two floats
+func (c *Calculator) MultiplyFloat(a, b float64) (result float64, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notifyValues("multiply", []any{a, b}, result, err, time.Since(start)) }()
+	}
+	c.log.Infof("Calculating multiplication: %g * %g", a, b)
+	return c.floatResult("Multiplication", a, b, a*b)
+}
+
+// DivideFloat returns the quotient of two floats, or ErrDivisionByZero
+// when the second is zero rather than an infinity
+func (c *Calculator) DivideFloat(a, b float64) (result float64, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notifyValues("divide", []any{a, b}, result, err, time.Since(start)) }()
+	}
+	c.log.Infof("Calculating division: %g / %g", a, b)
+	if b == 0 {
+		c.log.With("a", a, "b", b).Error("Division by zero")
+		return 0, ErrDivisionByZero
+	}
+	return c.floatResult("Division", a, b, a/b)
+}
+
+// ModFloat returns the remainder of dividing two floats, with the sign
+// of the first, as math.Mod, or ErrDivisionByZero when the second is zero
+func (c *Calculator) ModFloat(a, b float64) (result float64, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notifyValues("mod", []any{a, b}, result, err, time.Since(start)) }()
+	}
+	c.log.Infof("Calculating modulo: %g %% %g", a, b)
+	if b == 0 {
+		c.log.With("a", a, "b", b).Error("Division by zero")
+		return 0, ErrDivisionByZero
+	}
+	return c.floatResult("Modulo", a, b, math.Mod(a, b))
+}
+
+// PowFloat returns base raised to the power exp, as math.Pow. Negative
+// exponents are allowed, but zero to a negative power returns
+// ErrDivisionByZero and a negative base to a fractional power
+// ErrNotFinite.
+func (c *Calculator) PowFloat(base, exp float64) (result float64, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notifyValues("pow", []any{base, exp}, result, err, time.Since(start)) }()
+	}
+	c.log.Infof("Calculating power: %g ^ %g", base, exp)
+	if base == 0 && exp < 0 {
+		c.log.With("base", base, "exp", exp).Error("Division by zero")
+		return 0, ErrDivisionByZero
+	}
+	return c.floatResult("Power", base, exp, math.Pow(base, exp))
+}
+
+// Log returns the logarithm of x in base, exact for powers of 2 in base
+// 2 and of 10 in base 10, so that Log(8, 2) is 3. It returns a
+// *DomainError for x of 0 or less, whose logarithm is not a number, and
+// for a base of 0 or less or 1.
+func (c *Calculator) Log(x, base float64) (result float64, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notifyValues("log", []any{x, base}, result, err, time.Since(start)) }()
+	}
+	c.log.Infof("Calculating logarithm: %g in base %g", x, base)
+	switch {
+	case x <= 0:
+		err = &DomainError{Name: "logarithm argument", Value: x, Domain: "positive"}
+	case base <= 0 || base == 1:
+		err = &DomainError{Name: "logarithm base", Value: base, Domain: "positive and not 1"}
+	}
+	if err != nil {
+		c.log.With("x", x, "base", base).Errorf("Logarithm failed: %v", err)
+		return 0, err
+	}
+	switch base {
+	case 2:
+		result = math.Log2(x)
+	case 10:
+		result = math.Log10(x)
+	default:
+		result = math.Log(x) / math.Log(base)
+	}
+	return c.floatResult("Logarithm", x, base, result)
+}
+
+// Ln returns the natural logarithm of x, in base e. It returns a
+// *DomainError for x of 0 or less.
+func (c *Calculator) Ln(x float64) (result float64, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notifyValues("ln", []any{x}, result, err, time.Since(start)) }()
+	}
+	c.log.Infof("Calculating logarithm: %g in base e", x)
+	if x <= 0 {
+		err := &DomainError{Name: "logarithm argument", Value: x, Domain: "positive"}
+		c.log.With("x", x).Errorf("Natural logarithm failed: %v", err)
+		return 0, err
+	}
+	return c.floatResult("Natural logarithm", x, 0, math.Log(x))
+}
+
+// Exp returns e raised to the power x. Like the other float operations,
+// it returns ErrOverflow rather than an infinity when the result is too
+// large for a float64, from x above about 709.78.
+func (c *Calculator) Exp(x float64) (result float64, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notifyValues("exp", []any{x}, result, err, time.Since(start)) }()
+	}
+	c.log.Infof("Calculating exponential: e ^ %g", x)
+	return c.floatResult("Exponential", x, 0, math.Exp(x))
+}
+
+// floatResult checks the operands and result of the operation named
+// name, logging the result or the failure
+func (c *Calculator) floatResult(name string, a, b, result float64) (float64, error) {
+	var err error
+	switch {
+	case !finite(a) || !finite(b) || math.IsNaN(result):
+		err = ErrNotFinite
+	case math.IsInf(result, 0):
+		err = ErrOverflow
+	default:
+		c.log.Debugf("%s result: %g", name, result)
+		return result, nil
+	}
+	c.log.With("a", a, "b", b).Errorf("%s failed: %v", name, err)
+	return 0, err
+}
+
+// finite reports whether f is neither NaN nor an infinity
+func finite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
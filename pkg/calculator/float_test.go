@@ -0,0 +1,206 @@
+package calculator_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/calculator/proptest"
+	"go-examples/pkg/logger"
+	"math"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// TestFloatOperations tests the float operations, including NaN and
+// infinite operands and results
+func TestFloatOperations(t *testing.T) {
+	calc := calculator.NewCalculator(setupTestLogger())
+	ops := map[string]func(a, b float64) (float64, error){
+		"add":      calc.AddFloat,
+		"subtract": calc.SubtractFloat,
+		"multiply": calc.MultiplyFloat,
+		"divide":   calc.DivideFloat,
+		"mod":      calc.ModFloat,
+		"pow":      calc.PowFloat,
+	}
+
+	tests := []struct {
+		op       string
+		a, b     float64
+		expected float64
+		wantErr  error
+	}{
+		{op: "add", a: 0.1, b: 0.2, expected: 0.3},
+		{op: "add", a: math.MaxFloat64, b: math.MaxFloat64, wantErr: calculator.ErrOverflow},
+		{op: "add", a: math.NaN(), b: 1, wantErr: calculator.ErrNotFinite},
+		{op: "add", a: math.Inf(1), b: math.Inf(-1), wantErr: calculator.ErrNotFinite},
+		{op: "subtract", a: 1.5, b: 2.25, expected: -0.75},
+		{op: "subtract", a: 1, b: math.Inf(1), wantErr: calculator.ErrNotFinite},
+		{op: "multiply", a: 2.5, b: -4, expected: -10},
+		{op: "multiply", a: 1e200, b: 1e200, wantErr: calculator.ErrOverflow},
+		{op: "divide", a: 7, b: 2, expected: 3.5},
+		{op: "divide", a: 1, b: 3, expected: 1.0 / 3},
+		{op: "divide", a: 1, b: 0, wantErr: calculator.ErrDivisionByZero},
+		{op: "divide", a: 1, b: math.Copysign(0, -1), wantErr: calculator.ErrDivisionByZero},
+		{op: "divide", a: math.MaxFloat64, b: 0.5, wantErr: calculator.ErrOverflow},
+		{op: "mod", a: 7.5, b: 2, expected: 1.5},
+		{op: "mod", a: -7.5, b: 2, expected: -1.5},
+		{op: "mod", a: 1, b: 0, wantErr: calculator.ErrDivisionByZero},
+		{op: "pow", a: 2, b: 0.5, expected: math.Sqrt2},
+		{op: "pow", a: 2, b: -2, expected: 0.25},
+		{op: "pow", a: 0, b: 0, expected: 1},
+		{op: "pow", a: 0, b: -1, wantErr: calculator.ErrDivisionByZero},
+		{op: "pow", a: -8, b: 1.0 / 3, wantErr: calculator.ErrNotFinite},
+		{op: "pow", a: 10, b: 400, wantErr: calculator.ErrOverflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %g %g", tt.op, tt.a, tt.b), func(t *testing.T) {
+			got, err := ops[tt.op](tt.a, tt.b)
+			if !errors.Is(err, tt.wantErr) || !proptest.AlmostEqual(got, tt.expected, 1e-12) {
+				t.Errorf("%s(%g, %g) = %g, %v; want %g, %v", tt.op, tt.a, tt.b, got, err, tt.expected, tt.wantErr)
+			}
+			if computed, cerr := calc.ComputeFloat(tt.op, tt.a, tt.b); computed != got || cerr != err {
+				t.Errorf("ComputeFloat(%q, %g, %g) = %g, %v; want %g, %v", tt.op, tt.a, tt.b, computed, cerr, got, err)
+			}
+		})
+	}
+}
+
+// TestComputeFloatUnknown tests that ComputeFloat reports unknown
+// operations
+func TestComputeFloatUnknown(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	var unknown *calculator.UnknownOperationError
+	if _, err := calc.ComputeFloat("modulo", 1, 1); !errors.As(err, &unknown) || unknown.Name != "modulo" {
+		t.Errorf(`ComputeFloat("modulo", 1, 1) error = %v, want an unknown operation`, err)
+	}
+}
+
+// TestComputeFloatAudit tests that float calculations are audited and
+// skipped by Replay
+func TestComputeFloatAudit(t *testing.T) {
+	var buf bytes.Buffer
+	audit, err := logger.NewAudit(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calc := calculator.NewCalculator(noOpBenchLogger{}, calculator.WithAudit(audit))
+	_, _ = calc.Compute("divide", 7, 2)
+	_, _ = calc.ComputeFloat("divide", 7, 2)
+	_, _ = calc.ComputeFloat("divide", 7, 0)
+
+	if !bytes.Contains(buf.Bytes(), []byte(`"mode":"float"`)) {
+		t.Errorf("audit log has no float entry:\n%s", buf.String())
+	}
+	report, err := calculator.Replay(&buf, calculator.NewCalculator(noOpBenchLogger{}))
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if !report.OK() || report.Replayed != 1 {
+		t.Errorf("Replay = %+v, want the integer calculation only", report)
+	}
+}
+
+func ExampleCalculator_DivideFloat() {
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	result, _ := calc.DivideFloat(7, 2)
+	fmt.Println(result)
+	_, err := calc.DivideFloat(7, 0)
+	fmt.Println(err)
+	// Output:
+	// 3.5
+	// division by zero
+}
+
+// TestLogarithms tests Log and Ln on known exact values, and the
+// arguments and bases outside their domains
+func TestLogarithms(t *testing.T) {
+	log, logs := logger.NewObserved(zapcore.DebugLevel)
+	calc := calculator.NewCalculator(log)
+	tests := []struct {
+		x, base float64
+		want    float64
+	}{
+		{8, 2, 3},
+		{1024, 2, 10},
+		{0.125, 2, -3},
+		{1000, 10, 3},
+		{1e-5, 10, -5},
+		{81, 3, 4},
+		{1, 7, 0},
+		{2, 0.5, -1},
+		{math.E * math.E, math.E, 2},
+	}
+	for _, tt := range tests {
+		if got, err := calc.Log(tt.x, tt.base); err != nil || !proptest.AlmostEqual(got, tt.want, 1e-12) {
+			t.Errorf("Log(%g, %g) = %g, %v; want %g", tt.x, tt.base, got, err, tt.want)
+		}
+	}
+	if got, err := calc.Log(8, 2); err != nil || got != 3 {
+		t.Errorf("Log(8, 2) = %g, %v; want exactly 3", got, err)
+	}
+	if len(logs.FilterMessageContains("Calculating logarithm: 8 in base 2")) == 0 {
+		t.Errorf("base not logged: %v", logs.All())
+	}
+
+	for _, tt := range []struct{ x, want float64 }{{math.E, 1}, {1, 0}, {1 / math.E, -1}, {math.Exp(10), 10}} {
+		if got, err := calc.Ln(tt.x); err != nil || !proptest.AlmostEqual(got, tt.want, 1e-12) {
+			t.Errorf("Ln(%g) = %g, %v; want %g", tt.x, got, err, tt.want)
+		}
+	}
+
+	domain := []struct {
+		x, base float64
+		name    string
+	}{
+		{0, 10, "logarithm argument"},
+		{-1, 10, "logarithm argument"},
+		{8, 1, "logarithm base"},
+		{8, 0, "logarithm base"},
+		{8, -2, "logarithm base"},
+	}
+	for _, tt := range domain {
+		var domainErr *calculator.DomainError
+		if _, err := calc.Log(tt.x, tt.base); !errors.As(err, &domainErr) || domainErr.Name != tt.name || !errors.Is(err, calculator.ErrDomain) {
+			t.Errorf("Log(%g, %g) error = %v, want a *DomainError for the %s", tt.x, tt.base, err, tt.name)
+		}
+	}
+	for _, x := range []float64{0, -1, math.Inf(-1)} {
+		if _, err := calc.Ln(x); !errors.Is(err, calculator.ErrDomain) {
+			t.Errorf("Ln(%g) error = %v, want %v", x, err, calculator.ErrDomain)
+		}
+	}
+	for _, x := range []float64{math.NaN(), math.Inf(1)} {
+		if _, err := calc.Ln(x); !errors.Is(err, calculator.ErrNotFinite) {
+			t.Errorf("Ln(%g) error = %v, want %v", x, err, calculator.ErrNotFinite)
+		}
+		if _, err := calc.Log(8, x); !errors.Is(err, calculator.ErrNotFinite) {
+			t.Errorf("Log(8, %g) error = %v, want %v", x, err, calculator.ErrNotFinite)
+		}
+	}
+}
+
+// TestExp tests Exp on known values, and its overflow
+func TestExp(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	for _, tt := range []struct{ x, want float64 }{{0, 1}, {1, math.E}, {-1, 1 / math.E}, {math.Ln2 * 10, 1024}} {
+		if got, err := calc.Exp(tt.x); err != nil || !proptest.AlmostEqual(got, tt.want, 1e-12) {
+			t.Errorf("Exp(%g) = %g, %v; want %g", tt.x, got, err, tt.want)
+		}
+	}
+	if got, err := calc.Exp(-1000); err != nil || got != 0 {
+		t.Errorf("Exp(-1000) = %g, %v; want 0", got, err)
+	}
+	if _, err := calc.Exp(710); !errors.Is(err, calculator.ErrOverflow) {
+		t.Errorf("Exp(710) error = %v, want %v", err, calculator.ErrOverflow)
+	}
+	if _, err := calc.Exp(math.NaN()); !errors.Is(err, calculator.ErrNotFinite) {
+		t.Errorf("Exp(NaN) error = %v, want %v", err, calculator.ErrNotFinite)
+	}
+	if got, err := calc.ComputeFloat("exp", 2, 99); err != nil || !proptest.AlmostEqual(got, math.E*math.E, 1e-12) {
+		t.Errorf("ComputeFloat(exp, 2) = %g, %v; want e²", got, err)
+	}
+}
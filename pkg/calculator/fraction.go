@@ -0,0 +1,111 @@
+package calculator
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Fraction is an exact rational number, kept in lowest terms with a
+// positive denominator. The zero value is 0. Its arithmetic is exact:
+// an operation whose result has a numerator or denominator that does
+// not fit in an int returns ErrOverflow rather than rounding.
+type Fraction struct {
+	num int
+	den int // 0 in the zero value, which is read as 1
+}
+
+// NewFraction returns num/den in lowest terms, with the sign on the
+// numerator. It returns ErrDivisionByZero when den is zero, and
+// ErrOverflow when the reduced fraction does not fit, as for 1/MinInt,
+// whose denominator would be -MinInt.
+func NewFraction(num, den int) (Fraction, error) {
+	if den == 0 {
+		return Fraction{}, ErrDivisionByZero
+	}
+	return fromRat(big.NewRat(int64(num), int64(den)))
+}
+
+// ParseFraction parses a fraction written as "num/den", such as "3/4"
+// or "-7/2", or as a whole number such as "5". It returns an
+// *InvalidNumberError for other strings, and the errors of NewFraction.
+func ParseFraction(s string) (Fraction, error) {
+	numText, denText, slash := strings.Cut(s, "/")
+	if !slash {
+		denText = "1"
+	}
+	num, err := strconv.Atoi(numText)
+	if err == nil {
+		var den int
+		if den, err = strconv.Atoi(denText); err == nil {
+			return NewFraction(num, den)
+		}
+	}
+	if errors.Is(err, strconv.ErrRange) {
+		return Fraction{}, ErrOverflow
+	}
+	return Fraction{}, &InvalidNumberError{Number: shortenName(s), Reason: "not a fraction"}
+}
+
+// Num returns the numerator of f, which has the sign of f
+func (f Fraction) Num() int {
+	return f.num
+}
+
+// Den returns the denominator of f, which is positive
+func (f Fraction) Den() int {
+	return max(f.den, 1)
+}
+
+// String returns f as "num/den", or as "num" when f is a whole number
+func (f Fraction) String() string {
+	if f.Den() == 1 {
+		return strconv.Itoa(f.num)
+	}
+	return strconv.Itoa(f.num) + "/" + strconv.Itoa(f.den)
+}
+
+// Float64 returns the nearest float64 to f
+func (f Fraction) Float64() float64 {
+	value, _ := f.rat().Float64()
+	return value
+}
+
+// Add returns f + g, or ErrOverflow
+func (f Fraction) Add(g Fraction) (Fraction, error) {
+	return fromRat(new(big.Rat).Add(f.rat(), g.rat()))
+}
+
+// Subtract returns f - g, or ErrOverflow
+func (f Fraction) Subtract(g Fraction) (Fraction, error) {
+	return fromRat(new(big.Rat).Sub(f.rat(), g.rat()))
+}
+
+// Multiply returns f * g, or ErrOverflow
+func (f Fraction) Multiply(g Fraction) (Fraction, error) {
+	return fromRat(new(big.Rat).Mul(f.rat(), g.rat()))
+}
+
+// Divide returns f / g, ErrDivisionByZero when g is zero, or ErrOverflow
+func (f Fraction) Divide(g Fraction) (Fraction, error) {
+	if g.num == 0 {
+		return Fraction{}, ErrDivisionByZero
+	}
+	return fromRat(new(big.Rat).Quo(f.rat(), g.rat()))
+}
+
+// rat returns f as a big.Rat
+func (f Fraction) rat() *big.Rat {
+	return big.NewRat(int64(f.num), int64(f.Den()))
+}
+
+// fromRat returns r, which big.Rat keeps in lowest terms with a positive
+// denominator, as a Fraction, or ErrOverflow if it does not fit
+func fromRat(r *big.Rat) (Fraction, error) {
+	num, den := r.Num(), r.Denom()
+	if !num.IsInt64() || !den.IsInt64() {
+		return Fraction{}, ErrOverflow
+	}
+	return Fraction{num: int(num.Int64()), den: int(den.Int64())}, nil
+}
@@ -0,0 +1,185 @@
+package calculator_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/logger"
+	"math"
+	"strings"
+	"testing"
+)
+
+// mustFraction parses s or fails t
+func mustFraction(t *testing.T, s string) calculator.Fraction {
+	t.Helper()
+	f, err := calculator.ParseFraction(s)
+	if err != nil {
+		t.Fatalf("ParseFraction(%q) failed: %v", s, err)
+	}
+	return f
+}
+
+// TestNewFraction tests the reduction to lowest terms, the sign of the
+// denominator and the zero value
+func TestNewFraction(t *testing.T) {
+	tests := []struct {
+		num, den int
+		want     string
+		wantErr  error
+	}{
+		{num: 3, den: 4, want: "3/4"},
+		{num: 6, den: 8, want: "3/4"},
+		{num: 6, den: -8, want: "-3/4"},
+		{num: -6, den: -8, want: "3/4"},
+		{num: 0, den: -5, want: "0"},
+		{num: 10, den: 5, want: "2"},
+		{num: math.MinInt, den: 2, want: "-4611686018427387904"},
+		{num: math.MinInt, den: math.MinInt, want: "1"},
+		{num: 1, den: 0, wantErr: calculator.ErrDivisionByZero},
+		{num: 1, den: math.MinInt, wantErr: calculator.ErrOverflow},
+		{num: math.MinInt, den: -1, wantErr: calculator.ErrOverflow},
+	}
+	for _, tt := range tests {
+		f, err := calculator.NewFraction(tt.num, tt.den)
+		if !errors.Is(err, tt.wantErr) || (err == nil && f.String() != tt.want) {
+			t.Errorf("NewFraction(%d, %d) = %s, %v; want %s, %v", tt.num, tt.den, f, err, tt.want, tt.wantErr)
+		}
+		if err == nil && f.Den() <= 0 {
+			t.Errorf("NewFraction(%d, %d) has denominator %d", tt.num, tt.den, f.Den())
+		}
+	}
+
+	var zero calculator.Fraction
+	if zero.String() != "0" || zero.Num() != 0 || zero.Den() != 1 || zero.Float64() != 0 {
+		t.Errorf("zero Fraction = %s, %d/%d", zero, zero.Num(), zero.Den())
+	}
+	if sum, err := zero.Add(mustFraction(t, "1/2")); err != nil || sum.String() != "1/2" {
+		t.Errorf("0 + 1/2 = %s, %v; want 1/2", sum, err)
+	}
+}
+
+// TestParseFraction tests fractions, whole numbers and invalid strings
+func TestParseFraction(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    string
+		wantErr error
+	}{
+		{s: "3/4", want: "3/4"},
+		{s: "-7/2", want: "-7/2"},
+		{s: "+2/4", want: "1/2"},
+		{s: "3/-4", want: "-3/4"},
+		{s: "5", want: "5"},
+		{s: "4/2", want: "2"},
+		{s: "1/0", wantErr: calculator.ErrDivisionByZero},
+		{s: "99999999999999999999/2", wantErr: calculator.ErrOverflow},
+		{s: "", wantErr: calculator.ErrInvalidNumber},
+		{s: "/", wantErr: calculator.ErrInvalidNumber},
+		{s: "1/", wantErr: calculator.ErrInvalidNumber},
+		{s: "/2", wantErr: calculator.ErrInvalidNumber},
+		{s: "1/2/3", wantErr: calculator.ErrInvalidNumber},
+		{s: "1.5/2", wantErr: calculator.ErrInvalidNumber},
+		{s: " 1/2", wantErr: calculator.ErrInvalidNumber},
+		{s: strings.Repeat("x", 100), wantErr: calculator.ErrInvalidNumber},
+	}
+	for _, tt := range tests {
+		f, err := calculator.ParseFraction(tt.s)
+		if !errors.Is(err, tt.wantErr) || (err == nil && f.String() != tt.want) {
+			t.Errorf("ParseFraction(%q) = %s, %v; want %s, %v", tt.s, f, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+// TestFractionArithmetic tests that the operations are exact, and
+// report overflow and division by zero
+func TestFractionArithmetic(t *testing.T) {
+	ops := map[string]func(f, g calculator.Fraction) (calculator.Fraction, error){
+		"add":      calculator.Fraction.Add,
+		"subtract": calculator.Fraction.Subtract,
+		"multiply": calculator.Fraction.Multiply,
+		"divide":   calculator.Fraction.Divide,
+	}
+	calc := calculator.NewCalculator(setupTestLogger())
+	tests := []struct {
+		op, a, b string
+		want     string
+		wantErr  error
+	}{
+		{op: "add", a: "1/3", b: "1/6", want: "1/2"},
+		{op: "add", a: "1/2", b: "-1/2", want: "0"},
+		{op: "subtract", a: "1/3", b: "1/2", want: "-1/6"},
+		{op: "multiply", a: "2/3", b: "9/4", want: "3/2"},
+		{op: "multiply", a: "-2/3", b: "-3/2", want: "1"},
+		{op: "divide", a: "1/2", b: "3/4", want: "2/3"},
+		{op: "divide", a: "1/2", b: "-3", want: "-1/6"},
+		{op: "divide", a: "1/2", b: "0", wantErr: calculator.ErrDivisionByZero},
+		{op: "divide", a: "1/2", b: "0/7", wantErr: calculator.ErrDivisionByZero},
+		{op: "add", a: "9223372036854775807", b: "1", wantErr: calculator.ErrOverflow},
+		{op: "multiply", a: "1/4294967296", b: "1/4294967296", wantErr: calculator.ErrOverflow},
+		// Intermediate results beyond int are fine when the result fits
+		{op: "multiply", a: "9223372036854775807/2", b: "2/9223372036854775807", want: "1"},
+		{op: "add", a: "1/9223372036854775807", b: "-1/9223372036854775807", want: "0"},
+	}
+	for _, tt := range tests {
+		a, b := mustFraction(t, tt.a), mustFraction(t, tt.b)
+		got, err := ops[tt.op](a, b)
+		if !errors.Is(err, tt.wantErr) || (err == nil && got.String() != tt.want) {
+			t.Errorf("%s %s %s = %s, %v; want %s, %v", tt.a, tt.op, tt.b, got, err, tt.want, tt.wantErr)
+		}
+		if computed, cErr := calc.ComputeFraction(tt.op, a, b); computed != got || !errors.Is(cErr, tt.wantErr) {
+			t.Errorf("ComputeFraction(%s, %s, %s) = %s, %v; want %s, %v", tt.op, tt.a, tt.b, computed, cErr, got, err)
+		}
+	}
+
+	for _, name := range []string{"mod", "pow", "modulo"} {
+		if _, err := calc.ComputeFraction(name, mustFraction(t, "1/2"), mustFraction(t, "1/3")); !errors.Is(err, calculator.ErrUnknownOperation) {
+			t.Errorf("ComputeFraction(%s) error = %v, want %v", name, err, calculator.ErrUnknownOperation)
+		}
+	}
+}
+
+// TestComputeFractionAudit tests that fraction calculations are audited
+// with their operands as strings, and skipped by Replay
+func TestComputeFractionAudit(t *testing.T) {
+	var buf bytes.Buffer
+	audit, err := logger.NewAudit(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calc := calculator.NewCalculator(noOpBenchLogger{}, calculator.WithAudit(audit))
+	_, _ = calc.Compute("add", 1, 2)
+	_, _ = calc.ComputeFraction("add", mustFraction(t, "1/3"), mustFraction(t, "1/6"))
+	_, _ = calc.ComputeFraction("divide", mustFraction(t, "1/3"), calculator.Fraction{})
+
+	for _, want := range []string{`"mode":"fraction"`, `"a":"1/3"`, `"result":"1/2"`} {
+		if !bytes.Contains(buf.Bytes(), []byte(want)) {
+			t.Errorf("audit log has no %s:\n%s", want, buf.String())
+		}
+	}
+	report, err := calculator.Replay(&buf, calculator.NewCalculator(noOpBenchLogger{}))
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if !report.OK() || report.Replayed != 1 {
+		t.Errorf("Replay = %+v, want the integer calculation only", report)
+	}
+}
+
+func ExampleFraction_Add() {
+	a, _ := calculator.ParseFraction("1/3")
+	b, _ := calculator.NewFraction(1, 6)
+	sum, _ := a.Add(b)
+	fmt.Println(sum, sum.Float64())
+	// Output: 1/2 0.5
+}
+
+// TestParseFractionLongNumber tests that a long invalid fraction is
+// shortened in the error between runes
+func TestParseFractionLongNumber(t *testing.T) {
+	var invalid *calculator.InvalidNumberError
+	if _, err := calculator.ParseFraction("1/" + strings.Repeat("é", 20)); !errors.As(err, &invalid) || invalid.Number != "1/"+strings.Repeat("é", 7)+"..." {
+		t.Errorf("ParseFraction(1/éé...) error = %v, want the fraction cut after 7 runes é", err)
+	}
+}
@@ -0,0 +1,109 @@
+package calculator_test
+
+import (
+	"errors"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/calculator/proptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// FuzzCompute checks the arithmetic properties of proptest on arbitrary
+// operands, seeded with every pair of boundary values
+func FuzzCompute(f *testing.F) {
+	for _, a := range proptest.Boundaries {
+		for _, b := range proptest.Boundaries {
+			f.Add(a, b)
+		}
+	}
+	ops := proptest.FromCalculator(calculator.NewCalculator(noOpBenchLogger{}))
+	f.Fuzz(func(t *testing.T, a, b int) {
+		if err := proptest.Check(ops, a, b); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+// FuzzEvaluateExpression checks that Evaluate fails on arbitrary input
+// only with the errors of the package, and that the values of the
+// expressions it accepts round-trip, seeded with expressions of every
+// pair of boundary values and with malformed ones
+func FuzzEvaluateExpression(f *testing.F) {
+	for _, a := range proptest.Boundaries {
+		for _, b := range proptest.Boundaries {
+			for _, op := range []string{"+", "-", "*", "/"} {
+				f.Add(proptest.Format(a) + " " + op + " " + proptest.Format(b))
+			}
+		}
+	}
+	for _, expr := range []string{"", "(", "1 +", "-(2 + 3) * 4", "--4", "price * qty", "99999999999999999999", strings.Repeat("(", 101) + "1"} {
+		f.Add(expr)
+	}
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	f.Fuzz(func(t *testing.T, expr string) {
+		_, err := calc.Evaluate(expr)
+		if err != nil && !slices.ContainsFunc(calculator.Errors(), func(target error) bool { return errors.Is(err, target) }) {
+			t.Errorf("Evaluate(%q) error = %v, not an error of the package", expr, err)
+		}
+		if err := proptest.EvaluateRoundTrip(calc.Evaluate, expr); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+// FuzzParseInt checks that FromBase reads back what ToBase writes for
+// every int in every base, and that a string FromBase accepts is the
+// one ToBase writes for its value, up to case, a plus sign and leading
+// zeros, seeded with every boundary value in the usual bases and with
+// malformed numbers
+func FuzzParseInt(f *testing.F) {
+	for _, n := range proptest.Boundaries {
+		for _, base := range []int{2, 8, 10, 16, 36} {
+			f.Add(strconv.FormatInt(int64(n), base), n, base)
+		}
+	}
+	for _, s := range []string{"", "+", "-", "-0", "+007F", "0x10", "1_000", "zz", " 1", "9223372036854775808", "-9223372036854775809"} {
+		f.Add(s, 0, 16)
+	}
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	f.Fuzz(func(t *testing.T, s string, n, base int) {
+		base = calculator.MinBase + int(uint(base)%(calculator.MaxBase-calculator.MinBase+1))
+		text, err := calc.ToBase(n, base)
+		if err != nil {
+			t.Fatalf("ToBase(%d, %d) error = %v", n, base, err)
+		}
+		if got, err := calc.FromBase(text, base); err != nil || got != n {
+			t.Errorf("FromBase(ToBase(%d, %d) = %q) = %d, %v; want %d", n, base, text, got, err, n)
+		}
+
+		got, err := calc.FromBase(s, base)
+		if err != nil {
+			if !errors.Is(err, calculator.ErrInvalidNumber) && !errors.Is(err, calculator.ErrOverflow) {
+				t.Errorf("FromBase(%q, %d) error = %v, want an invalid number or an overflow", s, base, err)
+			}
+			return
+		}
+		if text, err := calc.ToBase(got, base); err != nil || text != canonicalNumber(s) {
+			t.Errorf("FromBase(%q, %d) = %d, written back as %q, %v; want %q", s, base, got, text, err, canonicalNumber(s))
+		}
+	})
+}
+
+// canonicalNumber returns s, a number FromBase accepts, as ToBase
+// writes it: in lowercase, without a plus sign or leading zeros
+func canonicalNumber(s string) string {
+	s = strings.ToLower(s)
+	sign := ""
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign, s = "-", s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	if s = strings.TrimLeft(s, "0"); s == "" {
+		return "0"
+	}
+	return sign + s
+}
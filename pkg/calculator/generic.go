@@ -0,0 +1,99 @@
+package calculator
+
+import (
+	"go-examples/pkg/logger"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Number is the types a Generic calculator computes with: the integer
+// and float types, and types defined on them
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// Generic provides the arithmetic of Calculator for any Number type,
+// with the same logging. Its operations follow Go arithmetic on T:
+// integer overflow wraps and integer division truncates toward zero,
+// while float division is exact up to rounding and float overflow gives
+// an infinity. Division by zero returns ErrDivisionByZero for every type.
+//
+// Calculator performs its int arithmetic with a Generic[int]; use
+// Calculator for the int operations it adds, such as Pow and Compute,
+// and its float methods to have NaN and infinities reported as errors.
+type Generic[T Number] struct {
+	log logger.Logger
+}
+
+// NewGeneric creates a Generic calculator for T with the provided
+// logger, or without logging if log is nil
+func NewGeneric[T Number](log logger.Logger) *Generic[T] {
+	if log == nil {
+		log = noOpLogger{}
+	}
+	return &Generic[T]{log: log}
+}
+
+// Add returns the sum of a and b
+func (g *Generic[T]) Add(a, b T) T {
+	if logger.LevelEnabled(g.log, zapcore.InfoLevel) {
+		g.log.Infof("Calculating addition: %v + %v", a, b)
+	}
+	result := a + b
+	if logger.LevelEnabled(g.log, zapcore.DebugLevel) {
+		g.log.Debugf("Addition result: %v", result)
+	}
+	return result
+}
+
+// Subtract returns the difference between a and b
+func (g *Generic[T]) Subtract(a, b T) T {
+	if logger.LevelEnabled(g.log, zapcore.InfoLevel) {
+		g.log.Infof("Calculating subtraction: %v - %v", a, b)
+	}
+	result := a - b
+	if logger.LevelEnabled(g.log, zapcore.DebugLevel) {
+		g.log.Debugf("Subtraction result: %v", result)
+	}
+	return result
+}
+
+// Multiply returns the product of a and b
+func (g *Generic[T]) Multiply(a, b T) T {
+	if logger.LevelEnabled(g.log, zapcore.InfoLevel) {
+		g.log.Infof("Calculating multiplication: %v * %v", a, b)
+	}
+	result := a * b
+	if logger.LevelEnabled(g.log, zapcore.DebugLevel) {
+		g.log.Debugf("Multiplication result: %v", result)
+	}
+	return result
+}
+
+// Divide returns the quotient of a and b, truncated toward zero for
+// integer types, or ErrDivisionByZero when b is zero
+func (g *Generic[T]) Divide(a, b T) (T, error) {
+	if logger.LevelEnabled(g.log, zapcore.InfoLevel) {
+		g.log.Infof("Calculating division: %v / %v", a, b)
+	}
+	result, err := divide(a, b)
+	if err != nil {
+		g.log.With("a", a, "b", b).Error("Division by zero")
+		return 0, err
+	}
+	if logger.LevelEnabled(g.log, zapcore.DebugLevel) {
+		g.log.Debugf("Division result: %v", result)
+	}
+	return result, nil
+}
+
+// divide performs Divide without logging, for the package-level Divide
+// as well, which must not allocate
+func divide[T Number](a, b T) (T, error) {
+	if b == 0 {
+		return 0, ErrDivisionByZero
+	}
+	return a / b, nil
+}
@@ -0,0 +1,82 @@
+package calculator_test
+
+import (
+	"errors"
+	"fmt"
+	"go-examples/pkg/calculator"
+	"math"
+	"testing"
+)
+
+// TestGenericInt64 tests the int64 operations, which wrap on overflow
+// and truncate divisions toward zero
+func TestGenericInt64(t *testing.T) {
+	calc := calculator.NewGeneric[int64](setupTestLogger())
+	if got := calc.Add(math.MaxInt64, 1); got != math.MinInt64 {
+		t.Errorf("Add(MaxInt64, 1) = %d, want MinInt64", got)
+	}
+	if got := calc.Subtract(3, 5); got != -2 {
+		t.Errorf("Subtract(3, 5) = %d, want -2", got)
+	}
+	if got := calc.Multiply(1<<40, 1<<40); got != 0 {
+		t.Errorf("Multiply(1<<40, 1<<40) = %d, want 0", got)
+	}
+
+	tests := []struct {
+		a, b, want int64
+	}{
+		{7, 2, 3},
+		{-7, 2, -3},
+		{7, -2, -3},
+		{math.MinInt64, -1, math.MinInt64},
+	}
+	for _, tt := range tests {
+		if got, err := calc.Divide(tt.a, tt.b); err != nil || got != tt.want {
+			t.Errorf("Divide(%d, %d) = %d, %v; want %d", tt.a, tt.b, got, err, tt.want)
+		}
+	}
+	if _, err := calc.Divide(1, 0); !errors.Is(err, calculator.ErrDivisionByZero) {
+		t.Errorf("Divide(1, 0) error = %v, want %v", err, calculator.ErrDivisionByZero)
+	}
+}
+
+// TestGenericUint8 tests that unsigned types wrap below zero
+func TestGenericUint8(t *testing.T) {
+	calc := calculator.NewGeneric[uint8](nil)
+	if got := calc.Subtract(1, 2); got != math.MaxUint8 {
+		t.Errorf("Subtract(1, 2) = %d, want 255", got)
+	}
+	if got, err := calc.Divide(255, 2); err != nil || got != 127 {
+		t.Errorf("Divide(255, 2) = %d, %v; want 127", got, err)
+	}
+}
+
+// TestGenericFloat64 tests the float64 operations, whose division is
+// not truncated
+func TestGenericFloat64(t *testing.T) {
+	calc := calculator.NewGeneric[float64](setupTestLogger())
+	if got := calc.Add(0.5, 0.25); got != 0.75 {
+		t.Errorf("Add(0.5, 0.25) = %g, want 0.75", got)
+	}
+	if got := calc.Multiply(math.MaxFloat64, 2); !math.IsInf(got, 1) {
+		t.Errorf("Multiply(MaxFloat64, 2) = %g, want +Inf", got)
+	}
+	if got, err := calc.Divide(7, 2); err != nil || got != 3.5 {
+		t.Errorf("Divide(7, 2) = %g, %v; want 3.5", got, err)
+	}
+	if got, err := calc.Divide(-1, 4); err != nil || got != -0.25 {
+		t.Errorf("Divide(-1, 4) = %g, %v; want -0.25", got, err)
+	}
+	if _, err := calc.Divide(1, 0); !errors.Is(err, calculator.ErrDivisionByZero) {
+		t.Errorf("Divide(1, 0) error = %v, want %v", err, calculator.ErrDivisionByZero)
+	}
+}
+
+func ExampleGeneric_Divide() {
+	ints := calculator.NewGeneric[int64](nil)
+	floats := calculator.NewGeneric[float64](nil)
+	q, _ := ints.Divide(7, 2)
+	f, _ := floats.Divide(7, 2)
+	fmt.Println(q, f)
+	// Output: 3 3.5
+}
@@ -0,0 +1,57 @@
+package calculator
+
+import "slices"
+
+// WithHistory records the last n operations performed by Compute and
+// ComputeStrict, with their results, in the history of the calculator,
+// dropping the oldest entry once it holds n. Failed operations are not
+// recorded. The history is part of the State, so it is saved and loaded
+// with the variables and memory register. A non-positive n disables
+// recording, which is the default.
+func WithHistory(n int) Option {
+	return func(c *Calculator) {
+		c.historySize = max(n, 0)
+	}
+}
+
+// History returns the recorded operations, oldest first. It is safe for
+// concurrent use.
+func (c *Calculator) History() []Entry {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+	return slices.Clone(c.state.state.History)
+}
+
+// ClearHistory removes every recorded operation. It is safe for
+// concurrent use.
+func (c *Calculator) ClearHistory() {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+	if len(c.state.state.History) == 0 {
+		return
+	}
+	c.state.state.History = nil
+	c.state.gen++
+}
+
+// record appends entry to the history, evicting the oldest entries
+// beyond the size set by WithHistory
+func (c *Calculator) record(entry Entry) {
+	if c.historySize == 0 {
+		return
+	}
+	entry.Time = c.clock.Now()
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+	c.state.state.History = trimHistory(append(c.state.state.History, entry), c.historySize)
+	c.state.gen++
+}
+
+// trimHistory drops the oldest entries of history beyond n, reusing its
+// array so that a full history does not grow it
+func trimHistory(history []Entry, n int) []Entry {
+	if n <= 0 || len(history) <= n {
+		return history
+	}
+	return append(history[:0], history[len(history)-n:]...)
+}
@@ -0,0 +1,136 @@
+package calculator_test
+
+import (
+	"fmt"
+	"go-examples/pkg/calculator"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tickClock is a Clock advancing a second on every call to Now
+type tickClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *tickClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(time.Second)
+	return c.now
+}
+
+func (c *tickClock) After(time.Duration) <-chan time.Time { return nil }
+
+// TestHistory tests that successful operations are recorded in order,
+// with their timestamps, and that the oldest are evicted beyond the cap
+func TestHistory(t *testing.T) {
+	start := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	calc := calculator.NewCalculator(noOpBenchLogger{}, calculator.WithHistory(3), calculator.WithClock(&tickClock{now: start}))
+
+	calls := []struct {
+		op   string
+		a, b int
+	}{
+		{"add", 1, 2},
+		{"divide", 1, 0}, // fails, not recorded
+		{"subtract", 5, 3},
+		{"multiply", 4, 5},
+		{"divide", 9, 2},
+	}
+	for _, c := range calls {
+		_, _ = calc.Compute(c.op, c.a, c.b)
+	}
+	want := []calculator.Entry{
+		{Operation: "subtract", A: 5, B: 3, Result: 2, Time: start.Add(2 * time.Second)},
+		{Operation: "multiply", A: 4, B: 5, Result: 20, Time: start.Add(3 * time.Second)},
+		{Operation: "divide", A: 9, B: 2, Result: 4, Time: start.Add(4 * time.Second)},
+	}
+	if got := calc.History(); !reflect.DeepEqual(got, want) {
+		t.Errorf("History() = %+v, want %+v", got, want)
+	}
+
+	calc.History()[0].Result = 99
+	if got := calc.History(); got[0].Result != 2 {
+		t.Errorf("History() shares its entries with the calculator")
+	}
+
+	calc.ClearHistory()
+	if got := calc.History(); len(got) != 0 {
+		t.Errorf("History() after ClearHistory = %+v, want none", got)
+	}
+	_, _ = calc.Compute("add", 2, 2)
+	if got := calc.History(); len(got) != 1 || got[0].Result != 4 {
+		t.Errorf("History() after ClearHistory and add = %+v, want the add", got)
+	}
+}
+
+// TestHistoryDisabled tests that nothing is recorded without WithHistory
+// or with a non-positive size
+func TestHistoryDisabled(t *testing.T) {
+	for _, opts := range [][]calculator.Option{nil, {calculator.WithHistory(0)}, {calculator.WithHistory(-1)}} {
+		calc := calculator.NewCalculator(noOpBenchLogger{}, opts...)
+		_, _ = calc.Compute("add", 1, 2)
+		if got := calc.History(); len(got) != 0 {
+			t.Errorf("History() with %d options = %+v, want none", len(opts), got)
+		}
+	}
+}
+
+// TestHistoryLoadState tests that a loaded history is cut to the cap
+func TestHistoryLoadState(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{}, calculator.WithHistory(2))
+	store := calculator.NewMemoryStore()
+	if err := store.Save(testState); err != nil {
+		t.Fatal(err)
+	}
+	if err := calc.LoadState(store); err != nil {
+		t.Fatal(err)
+	}
+	if got := calc.History(); !reflect.DeepEqual(got, testState.History[1:]) {
+		t.Errorf("History() = %+v, want %+v", got, testState.History[1:])
+	}
+}
+
+// TestHistoryConcurrent records, reads and clears the history from many
+// goroutines, for the race detector
+func TestHistoryConcurrent(t *testing.T) {
+	const workers, calls = 8, 100
+	calc := calculator.NewCalculator(noOpBenchLogger{}, calculator.WithHistory(16))
+	var wg sync.WaitGroup
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range calls {
+				_, _ = calc.Compute("add", i, j)
+				if len(calc.History()) > 16 {
+					t.Error("History() exceeds its cap")
+					return
+				}
+				if j%25 == 0 {
+					calc.ClearHistory()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if got := calc.History(); len(got) > 16 {
+		t.Errorf("History() holds %d entries, want at most 16", len(got))
+	}
+}
+
+func ExampleCalculator_History() {
+	calc := calculator.NewCalculator(nil, calculator.WithHistory(2))
+	_, _ = calc.Compute("add", 1, 2)
+	_, _ = calc.Compute("multiply", 3, 4)
+	_, _ = calc.Compute("subtract", 9, 5)
+	for _, e := range calc.History() {
+		fmt.Println(e.Operation, e.A, e.B, e.Result)
+	}
+	// Output:
+	// multiply 3 4 12
+	// subtract 9 5 4
+}
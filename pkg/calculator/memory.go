@@ -0,0 +1,54 @@
+package calculator
+
+// MemAdd adds v to the memory register, like the M+ key, and returns the
+// new memory value. It returns ErrOverflow, leaving the memory
+// unchanged, when the sum does not fit in an int. The memory register is
+// part of the State and is safe for concurrent use.
+func (c *Calculator) MemAdd(v int) (int, error) {
+	return c.updateMemory("add", v, addChecked)
+}
+
+// MemSubtract subtracts v from the memory register, like the M- key, and
+// returns the new memory value. It returns ErrOverflow, leaving the
+// memory unchanged, when the difference does not fit in an int.
+func (c *Calculator) MemSubtract(v int) (int, error) {
+	return c.updateMemory("subtract", v, subtractChecked)
+}
+
+// MemRecall returns the value of the memory register, like the MR key
+func (c *Calculator) MemRecall() int {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+	return c.state.state.Memory
+}
+
+// MemClear sets the memory register to 0, like the MC key
+func (c *Calculator) MemClear() {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+	if c.state.state.Memory != 0 {
+		c.saveUndo()
+		c.state.state.Memory = 0
+		c.state.gen++
+	}
+	c.log.Debug("Memory cleared: 0")
+}
+
+// updateMemory sets the memory register to op(memory, v) under the
+// state lock, so that concurrent updates are not lost
+func (c *Calculator) updateMemory(name string, v int, op func(a, b int) (int, bool)) (int, error) {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+	memory, ok := op(c.state.state.Memory, v)
+	if !ok {
+		c.log.With("memory", c.state.state.Memory, "value", v).Errorf("Memory %s failed: %v", name, ErrOverflow)
+		return 0, ErrOverflow
+	}
+	if v != 0 {
+		c.saveUndo()
+		c.state.state.Memory = memory
+		c.state.gen++
+	}
+	c.log.Debugf("Memory %s %d: %d", name, v, memory)
+	return memory, nil
+}
@@ -0,0 +1,94 @@
+package calculator_test
+
+import (
+	"errors"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/logger"
+	"math"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// TestMemory tests the memory keys, the overflow of the register and
+// that the memory is saved with the state
+func TestMemory(t *testing.T) {
+	log, logs := logger.NewObserved(zapcore.DebugLevel)
+	calc := calculator.NewCalculator(log)
+	if got := calc.MemRecall(); got != 0 {
+		t.Errorf("MemRecall of a new calculator = %d, want 0", got)
+	}
+	if got, err := calc.MemAdd(5); err != nil || got != 5 {
+		t.Errorf("MemAdd(5) = %d, %v; want 5", got, err)
+	}
+	if got, err := calc.MemSubtract(8); err != nil || got != -3 {
+		t.Errorf("MemSubtract(8) = %d, %v; want -3", got, err)
+	}
+	if got := calc.MemRecall(); got != -3 {
+		t.Errorf("MemRecall = %d, want -3", got)
+	}
+	if len(logs.FilterMessageContains("Memory subtract 8: -3")) != 1 {
+		t.Errorf("memory update not logged at debug level: %v", logs.All())
+	}
+
+	if _, err := calc.MemSubtract(math.MaxInt); !errors.Is(err, calculator.ErrOverflow) {
+		t.Errorf("MemSubtract(MaxInt) from -3 error = %v, want %v", err, calculator.ErrOverflow)
+	}
+	if got := calc.MemRecall(); got != -3 {
+		t.Errorf("MemRecall after an overflow = %d, want -3 unchanged", got)
+	}
+
+	store := calculator.NewMemoryStore()
+	if err := calc.SaveState(store); err != nil {
+		t.Fatal(err)
+	}
+	calc.MemClear()
+	if got := calc.MemRecall(); got != 0 {
+		t.Errorf("MemRecall after MemClear = %d, want 0", got)
+	}
+	if err := calc.LoadState(store); err != nil {
+		t.Fatal(err)
+	}
+	if got := calc.MemRecall(); got != -3 {
+		t.Errorf("MemRecall after LoadState = %d, want -3", got)
+	}
+}
+
+// TestMemoryConcurrent hammers the memory register from many goroutines,
+// as calcservice does with its shared calculator; run it with -race
+func TestMemoryConcurrent(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	const goroutines, iterations = 16, 1000
+
+	var wg sync.WaitGroup
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range iterations {
+				if _, err := calc.MemAdd(g + 1); err != nil {
+					t.Errorf("MemAdd failed: %v", err)
+					return
+				}
+				if i%2 == 0 {
+					if _, err := calc.MemSubtract(1); err != nil {
+						t.Errorf("MemSubtract failed: %v", err)
+						return
+					}
+				}
+				_ = calc.MemRecall()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Each goroutine adds (g+1)*iterations and subtracts iterations/2
+	want := 0
+	for g := range goroutines {
+		want += (g+1)*iterations - iterations/2
+	}
+	if got := calc.MemRecall(); got != want {
+		t.Errorf("MemRecall after concurrent updates = %d, want %d", got, want)
+	}
+}
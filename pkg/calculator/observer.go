@@ -0,0 +1,154 @@
+package calculator
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Observer is told of every operation a Calculator performs, to count or
+// time operations without parsing logs
+type Observer interface {
+	// OnOperation is called once the operation name has been performed
+	// on a and b, with its result or error and how long it took
+	OnOperation(name string, a, b, result int, err error, d time.Duration)
+}
+
+// ValueObserver is an Observer also told of the operations whose
+// operands or result are not ints, such as Sin, Log, Average and
+// Factorize. OnValues gets their operands and result as they are, such
+// as a float64 for Sin and the []int of Average; Observers that are not
+// ValueObservers are not told of them.
+type ValueObserver interface {
+	Observer
+	OnValues(name string, operands []any, result any, err error, d time.Duration)
+}
+
+// observers are the observers of a Calculator, a list replaced as a
+// whole by AddObserver so that operations read it without locking
+type observers struct {
+	mu   sync.Mutex // held by AddObserver
+	list atomic.Pointer[[]Observer]
+}
+
+// load returns the observers, nil if there are none
+func (o *observers) load() []Observer {
+	if list := o.list.Load(); list != nil {
+		return *list
+	}
+	return nil
+}
+
+// AddObserver registers o to be told of every operation c performs,
+// whether it succeeded or failed: those of Compute and its variants,
+// including those of Apply, Evaluate, Chain and CalculateAll, and the
+// methods performing one operation, such as Add, Divide, PowCtx and
+// RandomInt, each under its operation's name. Operations whose operands
+// or result are not ints, such as Sin, Log and Average, are told to
+// ValueObservers only. An operation is told once, as performed by
+// Compute when it comes from Compute; names no operation has are not
+// reported. Observers are called in the order they were added, after
+// the operation and outside the locks of c, so they may use c. A panic
+// in an observer is logged and does not affect the operation or the
+// other observers. A nil o is ignored.
+func (c *Calculator) AddObserver(o Observer) {
+	if o == nil {
+		return
+	}
+	c.observers.mu.Lock()
+	defer c.observers.mu.Unlock()
+	list := append(slices.Clone(c.observers.load()), o)
+	c.observers.list.Store(&list)
+}
+
+// observing returns the time now, to measure an operation for notify or
+// notifyValues, and whether c has observers to tell of it
+func (c *Calculator) observing() (time.Time, bool) {
+	if len(c.observers.load()) == 0 {
+		return time.Time{}, false
+	}
+	return time.Now(), true
+}
+
+// notify tells the observers of c of an operation
+func (c *Calculator) notify(name string, a, b, result int, err error, d time.Duration) {
+	for _, o := range c.observers.load() {
+		c.callObserver(o, name, func() { o.OnOperation(name, a, b, result, err, d) })
+	}
+}
+
+// notifyValues tells the ValueObservers of c of an operation whose
+// operands or result are not ints
+func (c *Calculator) notifyValues(name string, operands []any, result any, err error, d time.Duration) {
+	for _, o := range c.observers.load() {
+		if vo, ok := o.(ValueObserver); ok {
+			c.callObserver(o, name, func() { vo.OnValues(name, operands, result, err, d) })
+		}
+	}
+}
+
+// callObserver calls o through call, recovering from its panic
+func (c *Calculator) callObserver(o Observer, name string, call func()) {
+	defer func() {
+		if p := recover(); p != nil {
+			c.log.Errorf("Observer %T panicked on %s: %v", o, name, p)
+		}
+	}()
+	call()
+}
+
+// CountingObserver is a ValueObserver counting operations by name. Its
+// zero value is ready to use, and it is safe for concurrent use.
+type CountingObserver struct {
+	mu       sync.Mutex
+	counts   map[string]int
+	failures map[string]int
+}
+
+// OnOperation counts the operation name, and its failure if err is not
+// nil
+func (o *CountingObserver) OnOperation(name string, a, b, result int, err error, d time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.counts == nil {
+		o.counts = make(map[string]int)
+		o.failures = make(map[string]int)
+	}
+	o.counts[name]++
+	if err != nil {
+		o.failures[name]++
+	}
+}
+
+// OnValues counts the operation name like OnOperation, for the
+// operations whose operands or result are not ints
+func (o *CountingObserver) OnValues(name string, _ []any, _ any, err error, d time.Duration) {
+	o.OnOperation(name, 0, 0, 0, err, d)
+}
+
+// Count returns how many times the operation name was performed
+func (o *CountingObserver) Count(name string) int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.counts[name]
+}
+
+// Failures returns how many times the operation name failed
+func (o *CountingObserver) Failures(name string) int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.failures[name]
+}
+
+// Counts returns a copy of the counts, by operation name
+func (o *CountingObserver) Counts() map[string]int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	counts := maps.Clone(o.counts)
+	if counts == nil {
+		counts = make(map[string]int)
+	}
+	return counts
+}
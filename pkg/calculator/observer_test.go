@@ -0,0 +1,251 @@
+package calculator_test
+
+import (
+	"errors"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/logger"
+	"maps"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// observedCall is an operation reported to a recordingObserver
+type observedCall struct {
+	name        string
+	a, b        int
+	result      int
+	err         error
+	nonNegative bool
+}
+
+// recordingObserver records the operations it is told of
+type recordingObserver struct {
+	mu    sync.Mutex
+	calls []observedCall
+}
+
+func (o *recordingObserver) OnOperation(name string, a, b, result int, err error, d time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.calls = append(o.calls, observedCall{name, a, b, result, err, d >= 0})
+}
+
+// panickingObserver panics on every operation
+type panickingObserver struct{}
+
+func (panickingObserver) OnOperation(string, int, int, int, error, time.Duration) {
+	panic("observer bug")
+}
+
+// TestObserver tests that observers see exactly the operations
+// performed, through every entry point, with their results and errors
+func TestObserver(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{}, calculator.WithStrict())
+	rec := &recordingObserver{}
+	calc.AddObserver(rec)
+	calc.AddObserver(nil)
+
+	_, _ = calc.Compute("add", 2, 3)
+	_, _ = calc.Compute("divide", 7, 2) // lossy in strict mode
+	_, _ = calc.Compute("modulo", 1, 2) // not an operation
+	_, _ = calc.Evaluate("4 * 5")
+	_, _ = calc.Apply("subtract", 1, 1)
+	_ = calc.Add(1, 1)                    // not through Compute
+	_, _ = calc.Divide(7, 2)              // not strict outside Compute
+	_, _ = calc.Pow(2, -1)                // as PowCtx
+	_, _ = calc.Sin(0)                    // for ValueObservers only
+	_, _ = calc.ComputeFloat("add", 1, 2) // likewise
+
+	want := []observedCall{
+		{"add", 2, 3, 5, nil, true},
+		{"divide", 7, 2, 0, nil, true},
+		{"multiply", 4, 5, 20, nil, true},
+		{"subtract", 1, 1, 0, nil, true},
+		{"add", 1, 1, 2, nil, true},
+		{"divide", 7, 2, 3, nil, true},
+		{"pow", 2, -1, 0, calculator.ErrNegativeInput, true},
+	}
+	if len(rec.calls) != len(want) {
+		t.Fatalf("observed %+v, want %d operations", rec.calls, len(want))
+	}
+	for i, w := range want {
+		got := rec.calls[i]
+		if i == 1 {
+			if !errors.Is(got.err, calculator.ErrPrecisionLoss) {
+				t.Errorf("observed divide error = %v, want %v", got.err, calculator.ErrPrecisionLoss)
+			}
+			got.err = nil
+		}
+		if got != w {
+			t.Errorf("operation %d observed as %+v, want %+v", i, got, w)
+		}
+	}
+}
+
+// valueCall is an operation reported to a valueObserver
+type valueCall struct {
+	name     string
+	operands []any
+	result   any
+	err      error
+}
+
+// valueObserver records the operations it is told of, as OnValues
+// reports them and as OnOperation does
+type valueObserver struct {
+	recordingObserver
+	values []valueCall
+}
+
+func (o *valueObserver) OnValues(name string, operands []any, result any, err error, d time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.values = append(o.values, valueCall{name, operands, result, err})
+}
+
+// TestValueObserver tests that ValueObservers are told of the
+// operations on values other than ints, each once, with their operands
+// and results
+func TestValueObserver(t *testing.T) {
+	half, err := calculator.NewFraction(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	third, err := calculator.NewFraction(1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	obs := &valueObserver{}
+	calc.AddObserver(obs)
+
+	_, _ = calc.Sin(0)
+	_, _ = calc.Average(1, 2)
+	_, _ = calc.Factorize(12)
+	_, _ = calc.Log(-1, 10)
+	_ = calc.IsPrime(7)
+	_, _ = calc.ComputeFloat("multiply", 1.5, 2)
+	_, _ = calc.ComputeFraction("add", half, third)
+	_, _ = calc.ComputeTrig("sec", 0, calculator.Radians) // not an operation
+	_, _ = calc.Compute("isprime", 7, 0)                  // an int operation
+
+	want := []valueCall{
+		{"sin", []any{0.0, calculator.Radians}, 0.0, nil},
+		{"average", []any{[]int{1, 2}}, 2, nil},
+		{"factorize", []any{12}, []int{2, 2, 3}, nil},
+		{"log", []any{-1.0, 10.0}, 0.0, nil},
+		{"isprime", []any{7}, true, nil},
+		{"multiply", []any{1.5, 2.0}, 3.0, nil},
+	}
+	if len(obs.values) != len(want)+1 {
+		t.Fatalf("observed %+v, want %d operations", obs.values, len(want)+1)
+	}
+	for i, w := range want {
+		got := obs.values[i]
+		if i == 3 {
+			var domainErr *calculator.DomainError
+			if !errors.As(got.err, &domainErr) {
+				t.Errorf("observed log error = %v, want a *DomainError", got.err)
+			}
+			got.err = nil
+		}
+		if !reflect.DeepEqual(got, w) {
+			t.Errorf("operation %d observed as %+v, want %+v", i, got, w)
+		}
+	}
+	if got := obs.values[len(want)]; got.name != "add" || got.result.(calculator.Fraction).String() != "5/6" {
+		t.Errorf("fraction addition observed as %+v, want add giving 5/6", got)
+	}
+	wantInts := []observedCall{{"isprime", 7, 0, 1, nil, true}}
+	if !reflect.DeepEqual(obs.calls, wantInts) {
+		t.Errorf("int operations observed as %+v, want %+v", obs.calls, wantInts)
+	}
+}
+
+// TestObserverPanic tests that a panicking observer is logged, and
+// neither fails the operation nor keeps the other observers from
+// running
+func TestObserverPanic(t *testing.T) {
+	log, logs := logger.NewObserved(zapcore.DebugLevel)
+	calc := calculator.NewCalculator(log)
+	var counts calculator.CountingObserver
+	calc.AddObserver(panickingObserver{})
+	calc.AddObserver(&counts)
+
+	if got, err := calc.Compute("multiply", 6, 7); err != nil || got != 42 {
+		t.Errorf("Compute with a panicking observer = %d, %v; want 42", got, err)
+	}
+	if counts.Count("multiply") != 1 {
+		t.Errorf("observer after a panicking one counted %v", counts.Counts())
+	}
+	if len(logs.FilterMessageContains("panicked on multiply: observer bug")) != 1 {
+		t.Errorf("observer panic not logged: %v", logs.All())
+	}
+}
+
+// TestObserverReentrant tests that observers may use the calculator they
+// observe
+func TestObserverReentrant(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{}, calculator.WithHistory(10))
+	obs := observerFunc(func(name string, a, b, result int, err error, d time.Duration) {
+		_ = calc.History()
+		_ = calc.Vars()
+		calc.MemClear()
+	})
+	calc.AddObserver(obs)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = calc.Compute("add", 1, 2)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("an observer using the calculator deadlocked")
+	}
+}
+
+// observerFunc is a function as an Observer
+type observerFunc func(name string, a, b, result int, err error, d time.Duration)
+
+func (f observerFunc) OnOperation(name string, a, b, result int, err error, d time.Duration) {
+	f(name, a, b, result, err, d)
+}
+
+// TestCountingObserver tests counting operations and failures from
+// many goroutines; run it with -race
+func TestCountingObserver(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	var counts calculator.CountingObserver
+	if got := counts.Counts(); got == nil || len(got) != 0 {
+		t.Errorf("Counts() of a new observer = %#v, want an empty map", got)
+	}
+	calc.AddObserver(&counts)
+
+	var wg sync.WaitGroup
+	for g := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 100 {
+				_, _ = calc.Compute("add", g, i)
+				_, _ = calc.Compute("divide", i, g%2) // fails for even g
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := counts.Counts(); !maps.Equal(got, map[string]int{"add": 1000, "divide": 1000}) {
+		t.Errorf("Counts() = %v, want 1000 add and 1000 divide", got)
+	}
+	if got := counts.Failures("divide"); got != 500 {
+		t.Errorf("Failures(divide) = %d, want 500", got)
+	}
+	if got := counts.Failures("add"); got != 0 {
+		t.Errorf("Failures(add) = %d, want 0", got)
+	}
+}
@@ -0,0 +1,461 @@
+package calculator
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+)
+
+// Arity is how many operands an operation takes
+type Arity string
+
+// Arities of operations
+const (
+	ArityUnary    Arity = "unary"
+	ArityBinary   Arity = "binary"
+	ArityVariadic Arity = "variadic"
+)
+
+// OperandType is the type of the operands of an operation
+type OperandType string
+
+// Operand types of operations
+const (
+	OperandInt    OperandType = "int"
+	OperandFloat  OperandType = "float"
+	OperandString OperandType = "string"
+)
+
+// Operation describes an operation that Compute performs, for discovery
+// by clients. Random operations, such as random, give different results
+// for the same operands, so that results cannot be checked by computing
+// them again. Operations with a FloatResult, such as pctchange, have
+// fractional results, which Compute truncates toward zero and
+// ComputeFloatResultCtx returns in full.
+type Operation struct {
+	Name        string
+	Aliases     []string
+	Arity       Arity
+	OperandType OperandType
+	Description string
+	Random      bool
+	FloatResult bool
+}
+
+// operation is an Operation with its implementation, nil for the
+// operations with float operands only, the one watching for
+// cancellation, the one returning the full result of an operation with
+// a FloatResult, its float, arbitrary-precision, fraction and decimal
+// implementations, if any, the check of strict mode, which returns the
+// precision lost by result, if any; nil for operations whose results
+// are always exact, and the steps of Explain, if the operation has its
+// own
+type operation struct {
+	Operation
+	apply            func(c *Calculator, a, b int) (int, error)
+	applyCtx         func(c *Calculator, ctx context.Context, a, b int) (int, error)
+	applyFloatResult func(c *Calculator, a, b int) (float64, error)
+	applyFloat       func(c *Calculator, a, b float64) (float64, error)
+	applyBig         func(c *BigCalculator, a, b string) (string, error)
+	applyFraction    func(a, b Fraction) (Fraction, error)
+	applyDecimal     func(a, b Decimal) (Decimal, error)
+	loss             func(a, b, result int) *PrecisionLossError
+	explain          func(a, b, result int, err error) []Step
+}
+
+// operations lists the built-in operations, the start of every Registry
+var operations = []operation{
+	{
+		Operation:     Operation{Name: "add", Arity: ArityBinary, OperandType: OperandInt, Description: "Sum of a and b"},
+		apply:         (*Calculator).AddChecked,
+		explain:       explainChecked("+"),
+		applyFloat:    (*Calculator).AddFloat,
+		applyBig:      (*BigCalculator).Add,
+		applyFraction: Fraction.Add,
+		applyDecimal:  Decimal.Add,
+	},
+	{
+		Operation:     Operation{Name: "subtract", Arity: ArityBinary, OperandType: OperandInt, Description: "Difference of a and b"},
+		apply:         (*Calculator).SubtractChecked,
+		explain:       explainChecked("-"),
+		applyFloat:    (*Calculator).SubtractFloat,
+		applyBig:      (*BigCalculator).Subtract,
+		applyFraction: Fraction.Subtract,
+		applyDecimal:  Decimal.Subtract,
+	},
+	{
+		Operation:     Operation{Name: "multiply", Arity: ArityBinary, OperandType: OperandInt, Description: "Product of a and b"},
+		apply:         (*Calculator).MultiplyChecked,
+		explain:       explainChecked("*"),
+		applyFloat:    (*Calculator).MultiplyFloat,
+		applyBig:      (*BigCalculator).Multiply,
+		applyFraction: Fraction.Multiply,
+		applyDecimal:  Decimal.Multiply,
+	},
+	{
+		Operation:     Operation{Name: "divide", Arity: ArityBinary, OperandType: OperandInt, Description: "Quotient of a and b, truncated toward zero"},
+		apply:         func(c *Calculator, a, b int) (int, error) { return c.Divide(a, b) },
+		applyFloat:    (*Calculator).DivideFloat,
+		applyBig:      (*BigCalculator).Divide,
+		applyFraction: Fraction.Divide,
+		applyDecimal:  Decimal.Divide,
+		explain:       explainDivide,
+		loss: func(a, b, result int) *PrecisionLossError {
+			if a == math.MinInt && b == -1 {
+				return overflow(true, -float64(a))
+			}
+			if a%b == 0 {
+				return nil
+			}
+			return &PrecisionLossError{Remainder: a % b, Float: float64(a) / float64(b)}
+		},
+	},
+	{
+		Operation:  Operation{Name: "mod", Arity: ArityBinary, OperandType: OperandInt, Description: "Remainder of a divided by b, with the sign of a"},
+		apply:      (*Calculator).Mod,
+		applyFloat: (*Calculator).ModFloat,
+		applyBig:   (*BigCalculator).Mod,
+		explain:    explainMod,
+	},
+	{
+		Operation:  Operation{Name: "pow", Arity: ArityBinary, OperandType: OperandInt, Description: "a raised to the power b, for b of 0 or more"},
+		apply:      (*Calculator).Pow,
+		applyCtx:   (*Calculator).PowCtx,
+		applyFloat: (*Calculator).PowFloat,
+		explain:    explainPow,
+	},
+	{
+		Operation: Operation{Name: "abs", Arity: ArityUnary, OperandType: OperandInt, Description: "Absolute value of a; b is ignored"},
+		apply:     func(c *Calculator, a, _ int) (int, error) { return c.Abs(a) },
+	},
+	{
+		Operation: Operation{Name: "negate", Arity: ArityUnary, OperandType: OperandInt, Description: "a with the opposite sign; b is ignored"},
+		apply:     func(c *Calculator, a, _ int) (int, error) { return c.Negate(a) },
+	},
+	{
+		Operation: Operation{Name: "and", Arity: ArityBinary, OperandType: OperandInt, Description: "Bitwise AND of a and b"},
+		apply:     total((*Calculator).And),
+	},
+	{
+		Operation: Operation{Name: "or", Arity: ArityBinary, OperandType: OperandInt, Description: "Bitwise OR of a and b"},
+		apply:     total((*Calculator).Or),
+	},
+	{
+		Operation: Operation{Name: "xor", Arity: ArityBinary, OperandType: OperandInt, Description: "Bitwise exclusive OR of a and b"},
+		apply:     total((*Calculator).Xor),
+	},
+	{
+		Operation: Operation{Name: "not", Arity: ArityUnary, OperandType: OperandInt, Description: "Bitwise complement of a; b is ignored"},
+		apply:     func(c *Calculator, a, _ int) (int, error) { return c.Not(a), nil },
+	},
+	{
+		Operation: Operation{Name: "shl", Arity: ArityBinary, OperandType: OperandInt, Description: "a shifted left by b bits, for b from 0 to 63"},
+		apply:     (*Calculator).ShiftLeft,
+	},
+	{
+		Operation: Operation{Name: "shr", Arity: ArityBinary, OperandType: OperandInt, Description: "a shifted right by b bits, copying the sign bit, for b from 0 to 63"},
+		apply:     (*Calculator).ShiftRight,
+	},
+	{
+		Operation: Operation{Name: "fib", Arity: ArityUnary, OperandType: OperandInt, Description: "Fibonacci number F(a), for a from 0 to 92; b is ignored"},
+		apply:     func(c *Calculator, a, _ int) (int, error) { return c.Fibonacci(a) },
+	},
+	{
+		Operation: Operation{Name: "isprime", Arity: ArityUnary, OperandType: OperandInt, Description: "1 if a is prime, 0 otherwise; b is ignored"},
+		apply: func(c *Calculator, a, _ int) (int, error) {
+			if c.IsPrime(a) {
+				return 1, nil
+			}
+			return 0, nil
+		},
+	},
+	{
+		Operation:        Operation{Name: "pctchange", Arity: ArityBinary, OperandType: OperandInt, Description: "Change from a to b in percent of a, truncated toward zero", FloatResult: true},
+		apply:            (*Calculator).truncatedPercentChange,
+		applyFloatResult: (*Calculator).PercentChange,
+		loss:             percentChangeLoss,
+	},
+	{
+		Operation: Operation{Name: "random", Aliases: []string{"rand"}, Arity: ArityBinary, OperandType: OperandInt, Description: "Number picked at random from a to b, both included", Random: true},
+		apply:     (*Calculator).RandomInt,
+	},
+	{
+		Operation:  Operation{Name: "log", Arity: ArityBinary, OperandType: OperandFloat, Description: "Logarithm of a in base b, for a above 0 and b above 0 but not 1"},
+		applyFloat: (*Calculator).Log,
+	},
+	{
+		Operation:  Operation{Name: "ln", Arity: ArityUnary, OperandType: OperandFloat, Description: "Natural logarithm of a, for a above 0; b is ignored"},
+		applyFloat: func(c *Calculator, a, _ float64) (float64, error) { return c.Ln(a) },
+	},
+	{
+		Operation:  Operation{Name: "exp", Arity: ArityUnary, OperandType: OperandFloat, Description: "e raised to the power a; b is ignored"},
+		applyFloat: func(c *Calculator, a, _ float64) (float64, error) { return c.Exp(a) },
+	},
+}
+
+// total adapts an operation that cannot fail to the signature of apply
+func total(op func(c *Calculator, a, b int) int) func(c *Calculator, a, b int) (int, error) {
+	return func(c *Calculator, a, b int) (int, error) {
+		return op(c, a, b), nil
+	}
+}
+
+// overflow returns the loss of a result that wrapped, whose value in
+// floating point is float, or nil if it did not wrap
+func overflow(wrapped bool, float float64) *PrecisionLossError {
+	if !wrapped {
+		return nil
+	}
+	return &PrecisionLossError{Overflow: true, Float: float}
+}
+
+// Describe returns every operation Compute performs with the default
+// registry, including those added by RegisterOperation, sorted by name
+func Describe() []Operation {
+	return defaultRegistry.describe()
+}
+
+// LookupOperation returns the operation with name or alias name in the
+// default registry
+func LookupOperation(name string) (Operation, bool) {
+	return defaultRegistry.lookupOperation(name)
+}
+
+// Compute performs the operation with name or alias name on a and b. It
+// returns an *UnknownOperationError for other names and for operations
+// with float operands only, such as ln, ErrDivisionByZero when dividing
+// by zero and ErrOverflow for a sum, difference, product or power that
+// does not fit in an int. With WithStrict, it returns a
+// *PrecisionLossError for a result that is not exact: a division with a
+// remainder, a fractional percent change, or MinInt / -1, which wraps
+// otherwise. The other errors of an operation are wrapped in an
+// *OperationError with its name and operands, which errors.Is and
+// errors.As see through. With WithAudit, each operation performed is
+// recorded under its name, with its result or error, and with
+// WithHistory each one that succeeded is added to the history.
+func (c *Calculator) Compute(name string, a, b int) (int, error) {
+	result, _, err := c.compute(context.Background(), name, a, b, c.strict)
+	return result, err
+}
+
+// ComputeStrict performs like Compute, but in strict mode whether or not
+// the Calculator was created WithStrict, for callers choosing per call
+func (c *Calculator) ComputeStrict(name string, a, b int) (int, error) {
+	result, _, err := c.compute(context.Background(), name, a, b, true)
+	return result, err
+}
+
+// ComputeCtx performs like Compute, but stops the operations that can
+// take long, such as pow, when ctx is done, returning ctx.Err(). It
+// returns ctx.Err() without performing anything if ctx is already done;
+// a nil ctx never is.
+func (c *Calculator) ComputeCtx(ctx context.Context, name string, a, b int) (int, error) {
+	result, _, err := c.compute(orBackground(ctx), name, a, b, c.strict)
+	return result, err
+}
+
+// ComputeStrictCtx performs like ComputeStrict, stopping when ctx is
+// done as ComputeCtx does
+func (c *Calculator) ComputeStrictCtx(ctx context.Context, name string, a, b int) (int, error) {
+	result, _, err := c.compute(orBackground(ctx), name, a, b, true)
+	return result, err
+}
+
+// ComputeFloatResultCtx performs like ComputeCtx, also returning the
+// result in floating point: in full for the operations with a
+// FloatResult, such as 33.333333333333336 for pctchange 3 4, whose int
+// result is 33, and the int result converted for the others
+func (c *Calculator) ComputeFloatResultCtx(ctx context.Context, name string, a, b int) (int, float64, error) {
+	return c.compute(orBackground(ctx), name, a, b, c.strict)
+}
+
+// ComputeStrictFloatResultCtx performs like ComputeFloatResultCtx in
+// strict mode, where the fractional result of an operation with a
+// FloatResult is a *PrecisionLossError, as for divide
+func (c *Calculator) ComputeStrictFloatResultCtx(ctx context.Context, name string, a, b int) (int, float64, error) {
+	return c.compute(orBackground(ctx), name, a, b, true)
+}
+
+// orBackground returns ctx, or context.Background() for a nil ctx
+func orBackground(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
+}
+
+func (c *Calculator) compute(ctx context.Context, name string, a, b int, strict bool) (int, float64, error) {
+	op, ok := c.registry.lookup(name)
+	if !ok || op.apply == nil {
+		return 0, 0, &UnknownOperationError{Name: name}
+	}
+	start := time.Now()
+	// The operation is told to the observers once, below, and not by the
+	// methods implementing it
+	impl := c
+	if len(c.observers.load()) > 0 {
+		quiet := *c
+		quiet.observers = &observers{}
+		impl = &quiet
+	}
+	var result int
+	var full float64
+	err := ctx.Err()
+	switch {
+	case err != nil:
+		c.log.With("a", a, "b", b).Warnf("Operation %s not performed: %v", op.Name, err)
+	case op.applyCtx != nil:
+		result, err = op.applyCtx(impl, ctx, a, b)
+	case op.applyFloatResult != nil:
+		if full, err = op.applyFloatResult(impl, a, b); err == nil {
+			result, err = op.apply(impl, a, b)
+		}
+	default:
+		result, err = op.apply(impl, a, b)
+	}
+	if err == nil && strict && op.loss != nil {
+		if loss := op.loss(a, b, result); loss != nil {
+			loss.Operation, loss.A, loss.B, loss.Result = op.Name, a, b, result
+			result, err = 0, loss
+		}
+	}
+	if err == nil {
+		c.record(Entry{Operation: op.Name, A: a, B: b, Result: result})
+		c.setResult(result)
+	}
+	if c.audit != nil {
+		entry := c.audit.With(auditOperation, op.Name, auditA, a, auditB, b)
+		if err != nil {
+			entry.With(auditError, err.Error()).Info(auditMessage)
+		} else {
+			entry.With(auditResult, result).Info(auditMessage)
+		}
+	}
+	c.notify(op.Name, a, b, result, err, time.Since(start))
+	if op.applyFloatResult == nil || err != nil {
+		full = float64(result)
+	}
+	// A *PrecisionLossError already has the operation and operands
+	var loss *PrecisionLossError
+	if err != nil && !errors.As(err, &loss) {
+		err = &OperationError{Operation: op.Name, A: a, B: b, Err: err}
+	}
+	return result, full, err
+}
+
+// ComputeFloat performs the operation with name or alias name on a and
+// b in floating point. It returns an *UnknownOperationError for names
+// no operation has or whose operation has no float implementation, and
+// the errors of the float operations, such as ErrNotFinite. With
+// WithAudit, each operation performed is recorded like those of Compute,
+// in float mode; Replay skips these entries. Strict mode does not apply.
+func (c *Calculator) ComputeFloat(name string, a, b float64) (float64, error) {
+	op, ok := c.registry.lookup(name)
+	if !ok || op.applyFloat == nil {
+		return 0, &UnknownOperationError{Name: name}
+	}
+	result, err := op.applyFloat(c, a, b)
+	if c.audit != nil {
+		entry := c.audit.With(auditOperation, op.Name, auditMode, auditModeFloat, auditA, a, auditB, b)
+		if err != nil {
+			entry.With(auditError, err.Error()).Info(auditMessage)
+		} else {
+			entry.With(auditResult, result).Info(auditMessage)
+		}
+	}
+	return result, err
+}
+
+// ComputeBig performs the operation with name or alias name on the
+// decimal integers a and b with a BigCalculator sharing the logger of c.
+// It returns an *UnknownOperationError for names no operation has or
+// whose operation has no arbitrary-precision implementation, such as
+// pow, and the errors of BigCalculator, such as an *InvalidNumberError.
+// With WithAudit, each operation performed is recorded like those of
+// Compute, in big mode; Replay skips these entries.
+func (c *Calculator) ComputeBig(name, a, b string) (result string, err error) {
+	op, ok := c.registry.lookup(name)
+	if !ok || op.applyBig == nil {
+		return "", &UnknownOperationError{Name: name}
+	}
+	if start, ok := c.observing(); ok {
+		defer func() { c.notifyValues(op.Name, []any{a, b}, result, err, time.Since(start)) }()
+	}
+	result, err = op.applyBig(&BigCalculator{log: c.log}, a, b)
+	if c.audit != nil {
+		entry := c.audit.With(auditOperation, op.Name, auditMode, auditModeBig, auditA, a, auditB, b)
+		if err != nil {
+			entry.With(auditError, err.Error()).Info(auditMessage)
+		} else {
+			entry.With(auditResult, result).Info(auditMessage)
+		}
+	}
+	return result, err
+}
+
+// ComputeFraction performs the operation with name or alias name on the
+// fractions a and b, exactly. It returns an *UnknownOperationError for
+// names no operation has or whose operation has no fraction
+// implementation, and the errors of Fraction, such as ErrDivisionByZero.
+// With WithAudit, each operation performed is recorded like those of
+// Compute, in fraction mode, with the fractions as strings; Replay skips
+// these entries.
+func (c *Calculator) ComputeFraction(name string, a, b Fraction) (result Fraction, err error) {
+	op, ok := c.registry.lookup(name)
+	if !ok || op.applyFraction == nil {
+		return Fraction{}, &UnknownOperationError{Name: name}
+	}
+	if start, ok := c.observing(); ok {
+		defer func() { c.notifyValues(op.Name, []any{a, b}, result, err, time.Since(start)) }()
+	}
+	c.log.Infof("Calculating %s of fractions: %s and %s", op.Name, a, b)
+	result, err = op.applyFraction(a, b)
+	if err != nil {
+		c.log.With("a", a.String(), "b", b.String()).Errorf("Fraction %s failed: %v", op.Name, err)
+	} else {
+		c.log.Debugf("Fraction %s result: %s", op.Name, result)
+	}
+	if c.audit != nil {
+		entry := c.audit.With(auditOperation, op.Name, auditMode, auditModeFraction, auditA, a.String(), auditB, b.String())
+		if err != nil {
+			entry.With(auditError, err.Error()).Info(auditMessage)
+		} else {
+			entry.With(auditResult, result.String()).Info(auditMessage)
+		}
+	}
+	return result, err
+}
+
+// ComputeDecimal performs the operation with name or alias name on the
+// decimals a and b, rounding half to even to the larger scale of the
+// two. It returns an *UnknownOperationError for names no operation has
+// or whose operation has no decimal implementation, and the errors of
+// Decimal, such as ErrOverflow. With WithAudit, each operation performed
+// is recorded like those of Compute, in decimal mode, with the decimals
+// as strings; Replay skips these entries.
+func (c *Calculator) ComputeDecimal(name string, a, b Decimal) (result Decimal, err error) {
+	op, ok := c.registry.lookup(name)
+	if !ok || op.applyDecimal == nil {
+		return Decimal{}, &UnknownOperationError{Name: name}
+	}
+	if start, ok := c.observing(); ok {
+		defer func() { c.notifyValues(op.Name, []any{a, b}, result, err, time.Since(start)) }()
+	}
+	c.log.Infof("Calculating %s of decimals: %s and %s", op.Name, a, b)
+	result, err = op.applyDecimal(a, b)
+	if err != nil {
+		c.log.With("a", a.String(), "b", b.String()).Errorf("Decimal %s failed: %v", op.Name, err)
+	} else {
+		c.log.Debugf("Decimal %s result: %s", op.Name, result)
+	}
+	if c.audit != nil {
+		entry := c.audit.With(auditOperation, op.Name, auditMode, auditModeDecimal, auditA, a.String(), auditB, b.String())
+		if err != nil {
+			entry.With(auditError, err.Error()).Info(auditMessage)
+		} else {
+			entry.With(auditResult, result.String()).Info(auditMessage)
+		}
+	}
+	return result, err
+}
@@ -0,0 +1,268 @@
+package calculator_test
+
+import (
+	"errors"
+	"go-examples/pkg/calculator"
+	"math"
+	"testing"
+)
+
+// TestCompute tests that every described operation computes the same
+// result as its method, under its name and each alias
+func TestCompute(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	methods := map[string]func(a, b int) int{
+		"add":      calc.Add,
+		"subtract": calc.Subtract,
+		"multiply": calc.Multiply,
+		"divide": func(a, b int) int {
+			quotient, _ := calc.Divide(a, b)
+			return quotient
+		},
+		"mod": func(a, b int) int {
+			remainder, _ := calc.Mod(a, b)
+			return remainder
+		},
+		"pow": func(a, b int) int {
+			power, _ := calc.Pow(a, b)
+			return power
+		},
+		"abs": func(a, _ int) int {
+			abs, _ := calc.Abs(a)
+			return abs
+		},
+		"negate": func(a, _ int) int {
+			negation, _ := calc.Negate(a)
+			return negation
+		},
+		"pctchange": func(a, b int) int {
+			change, _ := calc.PercentChange(a, b)
+			return int(change)
+		},
+		"fib": func(a, _ int) int {
+			fib, _ := calc.Fibonacci(a)
+			return fib
+		},
+		"isprime": func(a, _ int) int {
+			if calc.IsPrime(a) {
+				return 1
+			}
+			return 0
+		},
+		"and": calc.And,
+		"or":  calc.Or,
+		"xor": calc.Xor,
+		"not": func(a, _ int) int { return calc.Not(a) },
+		"shl": func(a, b int) int {
+			shifted, _ := calc.ShiftLeft(a, b)
+			return shifted
+		},
+		"shr": func(a, b int) int {
+			shifted, _ := calc.ShiftRight(a, b)
+			return shifted
+		},
+	}
+
+	// Operations with float operands only are performed by ComputeFloat
+	floatMethods := map[string]func(a, b float64) (float64, error){
+		"log": calc.Log,
+		"ln":  func(a, _ float64) (float64, error) { return calc.Ln(a) },
+		"exp": func(a, _ float64) (float64, error) { return calc.Exp(a) },
+	}
+
+	// Random operations give a number from a to b
+	randomOps := map[string]bool{"random": true}
+
+	ops := calculator.Describe()
+	if len(ops) != len(methods)+len(floatMethods)+len(randomOps) {
+		t.Errorf("Describe returned %d operations, want %d", len(ops), len(methods)+len(floatMethods)+len(randomOps))
+	}
+	for _, op := range ops {
+		if randomOps[op.Name] {
+			for _, name := range append([]string{op.Name}, op.Aliases...) {
+				if got, err := calc.Compute(name, 4, 12); err != nil || got < 4 || got > 12 || !op.Random {
+					t.Errorf("Compute(%s, 4, 12) = %d, %v; want a number from 4 to 12", name, got, err)
+				}
+			}
+			continue
+		}
+		if op.Random {
+			t.Errorf("operation %s is described as random", op.Name)
+		}
+		if method, ok := floatMethods[op.Name]; ok {
+			want, _ := method(12, 4)
+			if got, err := calc.ComputeFloat(op.Name, 12, 4); err != nil || got != want || op.OperandType != calculator.OperandFloat {
+				t.Errorf("ComputeFloat(%s, 12, 4) = %g, %v; want %g", op.Name, got, err, want)
+			}
+			var unknown *calculator.UnknownOperationError
+			if _, err := calc.Compute(op.Name, 12, 4); !errors.As(err, &unknown) {
+				t.Errorf("Compute(%s) of a float operation error = %v, want an *UnknownOperationError", op.Name, err)
+			}
+			continue
+		}
+		method, ok := methods[op.Name]
+		if !ok {
+			t.Errorf("no test for operation %s", op.Name)
+			continue
+		}
+		if op.Description == "" || op.Arity == "" || op.OperandType == "" {
+			t.Errorf("operation %s is incompletely described: %+v", op.Name, op)
+		}
+		for _, name := range append([]string{op.Name}, op.Aliases...) {
+			got, err := calc.Compute(name, 12, 4)
+			if err != nil {
+				t.Errorf("Compute(%s) failed: %v", name, err)
+			} else if want := method(12, 4); got != want {
+				t.Errorf("Compute(%s, 12, 4) = %d, want %d", name, got, want)
+			}
+		}
+	}
+}
+
+// TestComputeErrors tests the errors of unknown operations and of
+// dividing by zero
+func TestComputeErrors(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+
+	_, err := calc.Compute("modulo", 1, 2)
+	var unknown *calculator.UnknownOperationError
+	if !errors.Is(err, calculator.ErrUnknownOperation) || !errors.As(err, &unknown) || unknown.Name != "modulo" {
+		t.Errorf("Compute(modulo) error = %v, want unknown operation modulo", err)
+	}
+	if _, ok := calculator.LookupOperation("modulo"); ok {
+		t.Error("LookupOperation(modulo) found an operation")
+	}
+
+	for _, name := range []string{"divide", "mod"} {
+		if _, err := calc.Compute(name, 1, 0); !errors.Is(err, calculator.ErrDivisionByZero) {
+			t.Errorf("Compute(%s, 1, 0) error = %v, want %v", name, err, calculator.ErrDivisionByZero)
+		}
+	}
+}
+
+// TestOperationError tests that the errors of Compute carry the
+// operation and operands, under the operation's name for an alias, and
+// still match their sentinel
+func TestOperationError(t *testing.T) {
+	tests := []struct {
+		operation string
+		a, b      int
+		want      error
+		message   string
+	}{
+		{"divide", 1, 0, calculator.ErrDivisionByZero, "divide 1 0: division by zero"},
+		{"add", math.MaxInt, 1, calculator.ErrOverflow, "add 9223372036854775807 1: integer overflow"},
+		{"pow", 2, -1, calculator.ErrNegativeInput, "pow 2 -1: negative input"},
+		{"shl", 1, 64, calculator.ErrOutOfRange, "shl 1 64: shift amount 64 out of range, must be between 0 and 63"},
+		{"rand", 6, 1, calculator.ErrOutOfRange, "random 6 1: random maximum 1 out of range, must be between 6 and 9223372036854775807"},
+	}
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	for _, tc := range tests {
+		_, err := calc.Compute(tc.operation, tc.a, tc.b)
+		var opErr *calculator.OperationError
+		if !errors.Is(err, tc.want) || !errors.As(err, &opErr) {
+			t.Errorf("Compute(%s, %d, %d) error = %v, want an *OperationError matching %v", tc.operation, tc.a, tc.b, err, tc.want)
+			continue
+		}
+		if err.Error() != tc.message {
+			t.Errorf("Compute(%s, %d, %d) error = %q, want %q", tc.operation, tc.a, tc.b, err, tc.message)
+		}
+		if opErr.A != tc.a || opErr.B != tc.b || opErr.Unwrap() != opErr.Err {
+			t.Errorf("Compute(%s, %d, %d) error = %+v", tc.operation, tc.a, tc.b, *opErr)
+		}
+	}
+
+	var rangeErr *calculator.RangeError
+	if _, err := calc.Compute("shl", 1, 64); !errors.As(err, &rangeErr) || rangeErr.Value != 64 {
+		t.Errorf("Compute(shl, 1, 64) error = %v, want a *RangeError for 64", err)
+	}
+	// Unknown operations and precision loss report the operation
+	// themselves
+	var opErr *calculator.OperationError
+	if _, err := calc.Compute("modulo", 1, 2); errors.As(err, &opErr) {
+		t.Errorf("Compute(modulo) error = %v, want no *OperationError", err)
+	}
+	if _, err := calc.ComputeStrict("divide", 7, 2); errors.As(err, &opErr) {
+		t.Errorf("ComputeStrict(divide, 7, 2) error = %v, want no *OperationError", err)
+	}
+}
+
+// TestComputeStrict tests that strict mode reports each lossy division,
+// with the remainder or overflow and the floating point result, and
+// that results are unchanged outside it
+func TestComputeStrict(t *testing.T) {
+	tests := []struct {
+		operation string
+		a, b      int
+		lossy     int // result outside strict mode
+		remainder int
+		overflow  bool
+		float     float64
+	}{
+		{"divide", 7, 2, 3, 1, false, 3.5},
+		{"divide", -7, 2, -3, -1, false, -3.5},
+		{"divide", math.MinInt, -1, math.MinInt, 0, true, -float64(math.MinInt)},
+	}
+	strict := calculator.NewCalculator(noOpBenchLogger{}, calculator.WithStrict())
+	lenient := calculator.NewCalculator(noOpBenchLogger{})
+	for _, tc := range tests {
+		if got, err := lenient.Compute(tc.operation, tc.a, tc.b); err != nil || got != tc.lossy {
+			t.Errorf("Compute(%s, %d, %d) = %d, %v, want %d", tc.operation, tc.a, tc.b, got, err, tc.lossy)
+		}
+		for name, compute := range map[string]func(string, int, int) (int, error){"WithStrict": strict.Compute, "ComputeStrict": lenient.ComputeStrict} {
+			_, err := compute(tc.operation, tc.a, tc.b)
+			var loss *calculator.PrecisionLossError
+			if !errors.Is(err, calculator.ErrPrecisionLoss) || !errors.As(err, &loss) {
+				t.Errorf("%s: %s(%d, %d) error = %v, want precision loss", name, tc.operation, tc.a, tc.b, err)
+				continue
+			}
+			want := calculator.PrecisionLossError{Operation: tc.operation, A: tc.a, B: tc.b, Result: tc.lossy, Remainder: tc.remainder, Overflow: tc.overflow, Float: tc.float}
+			if *loss != want {
+				t.Errorf("%s: %s(%d, %d) error = %+v, want %+v", name, tc.operation, tc.a, tc.b, *loss, want)
+			}
+		}
+	}
+
+	for _, exact := range []struct {
+		operation string
+		a, b      int
+	}{
+		{"divide", 8, 2}, {"divide", math.MinInt, 1}, {"add", math.MaxInt, -1}, {"add", math.MinInt, 0},
+		{"subtract", -1, math.MaxInt}, {"multiply", 0, math.MinInt}, {"multiply", math.MinInt, 1},
+	} {
+		want, _ := lenient.Compute(exact.operation, exact.a, exact.b)
+		if got, err := strict.Compute(exact.operation, exact.a, exact.b); err != nil || got != want {
+			t.Errorf("strict %s(%d, %d) = %d, %v, want %d", exact.operation, exact.a, exact.b, got, err, want)
+		}
+	}
+	if _, err := strict.Compute("divide", 1, 0); !errors.Is(err, calculator.ErrDivisionByZero) {
+		t.Errorf("strict division by zero error = %v, want %v", err, calculator.ErrDivisionByZero)
+	}
+}
+
+// TestComputeOverflow tests that sums, differences, products, powers
+// and negations that do not fit in an int are reported as ErrOverflow, in strict mode or not
+func TestComputeOverflow(t *testing.T) {
+	tests := []struct {
+		operation string
+		a, b      int
+	}{
+		{"add", math.MaxInt, 1},
+		{"add", math.MinInt, -1},
+		{"subtract", math.MinInt, 1},
+		{"subtract", 0, math.MinInt},
+		{"multiply", math.MaxInt, 2},
+		{"multiply", -1, math.MinInt},
+		{"pow", 2, 63},
+		{"abs", math.MinInt, 0},
+		{"negate", math.MinInt, 0},
+	}
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	for _, tc := range tests {
+		for name, compute := range map[string]func(string, int, int) (int, error){"Compute": calc.Compute, "ComputeStrict": calc.ComputeStrict} {
+			if got, err := compute(tc.operation, tc.a, tc.b); !errors.Is(err, calculator.ErrOverflow) {
+				t.Errorf("%s(%s, %d, %d) = %d, %v, want %v", name, tc.operation, tc.a, tc.b, got, err, calculator.ErrOverflow)
+			}
+		}
+	}
+}
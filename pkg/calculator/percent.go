@@ -0,0 +1,98 @@
+package calculator
+
+import (
+	"math/big"
+	"time"
+)
+
+// PercentChange returns the change from old to new in percent of old,
+// such as 50 from 4 to 6 and -25 from 4 to 3: decreases are negative.
+// The change is relative to the size of old, so that from -4 to -2, an
+// increase, is 50 too. The result is the float64 nearest to the exact
+// change, without the truncation of integer division. It returns
+// ErrDivisionByZero when old is 0, from which no change is a percentage.
+func (c *Calculator) PercentChange(old, new int) (result float64, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notifyValues("pctchange", []any{old, new}, result, err, time.Since(start)) }()
+	}
+	c.log.Infof("Calculating percent change: %d to %d", old, new)
+	if old == 0 {
+		c.log.With("old", old, "new", new).Error("Division by zero")
+		return 0, ErrDivisionByZero
+	}
+	result, _ = ratio(percentChange(old, new)).Float64()
+	c.log.Debugf("Percent change result: %g", result)
+	return result, nil
+}
+
+// Ratio returns a divided by b, such as 0.75 for 3 and 4 where Divide
+// truncates to 0. The result is the float64 nearest to the exact
+// quotient, even for ints beyond the 53 bits a float64 holds exactly. It
+// returns ErrDivisionByZero when b is 0.
+func (c *Calculator) Ratio(a, b int) (result float64, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notifyValues("ratio", []any{a, b}, result, err, time.Since(start)) }()
+	}
+	c.log.Infof("Calculating ratio: %d / %d", a, b)
+	if b == 0 {
+		c.log.With("a", a, "b", b).Error("Division by zero")
+		return 0, ErrDivisionByZero
+	}
+	result, _ = ratio(big.NewInt(int64(a)), big.NewInt(int64(b))).Float64()
+	c.log.Debugf("Ratio result: %g", result)
+	return result, nil
+}
+
+// truncatedPercentChange is the operation pctchange, the percent change
+// of PercentChange truncated toward zero. It returns ErrOverflow when
+// that does not fit in an int, as from 1 to MaxInt.
+func (c *Calculator) truncatedPercentChange(from, to int) (int, error) {
+	if from == 0 {
+		return 0, ErrDivisionByZero
+	}
+	change, base := percentChange(from, to)
+	quotient := change.Quo(change, base)
+	if !quotient.IsInt64() {
+		c.log.With("old", from, "new", to).Errorf("Percent change failed: %v", ErrOverflow)
+		return 0, ErrOverflow
+	}
+	return int(quotient.Int64()), nil
+}
+
+// percentChangeLoss is the check of strict mode for pctchange, whose
+// remainder is that of dividing the change times 100 by the size of a
+func percentChangeLoss(a, b, _ int) *PrecisionLossError {
+	change, base := percentChange(a, b)
+	exact, _ := ratio(change, base).Float64()
+	remainder := change.Rem(change, base)
+	if remainder.Sign() == 0 {
+		return nil
+	}
+	return &PrecisionLossError{Remainder: int(remainder.Int64()), Float: exact}
+}
+
+// percentChange returns the percent change from a non-zero from to to as
+// the fraction change/base, with base the size of from
+func percentChange(from, to int) (change, base *big.Int) {
+	change = big.NewInt(int64(to))
+	change.Sub(change, big.NewInt(int64(from)))
+	change.Mul(change, big.NewInt(100))
+	base = big.NewInt(int64(from))
+	return change, base.Abs(base)
+}
+
+// ratio returns the exact fraction num/den, for a non-zero den
+func ratio(num, den *big.Int) *big.Rat {
+	return new(big.Rat).SetFrac(num, den)
+}
+
+// PercentChange returns the change from old to new in percent of old, or
+// ErrDivisionByZero when old is 0.
+func PercentChange(old, new int) (float64, error) {
+	return noOpCalculator.PercentChange(old, new)
+}
+
+// Ratio returns a divided by b in floating point, or ErrDivisionByZero.
+func Ratio(a, b int) (float64, error) {
+	return noOpCalculator.Ratio(a, b)
+}
@@ -0,0 +1,117 @@
+package calculator_test
+
+import (
+	"context"
+	"errors"
+	"go-examples/pkg/calculator"
+	"math"
+	"testing"
+)
+
+// TestPercentChange tests the sign of increases and decreases, changes
+// from negative values and the error of a change from 0
+func TestPercentChange(t *testing.T) {
+	calc := calculator.NewCalculator(setupTestLogger())
+	tests := []struct {
+		old, new int
+		want     float64
+	}{
+		{4, 6, 50},
+		{4, 3, -25},
+		{100, 0, -100},
+		{5, 5, 0},
+		{1, 3, 200},
+		{3, 4, 100.0 / 3},
+		{3, 2, -100.0 / 3},
+		{-4, -2, 50},
+		{-4, -6, -50},
+		{-2, 2, 200},
+		{2, -2, -200},
+		{1, math.MaxInt, 922337203685477580600},
+		{math.MinInt, math.MaxInt, 200},
+	}
+	for _, tt := range tests {
+		if got, err := calc.PercentChange(tt.old, tt.new); err != nil || got != tt.want {
+			t.Errorf("PercentChange(%d, %d) = %g, %v; want %g", tt.old, tt.new, got, err, tt.want)
+		}
+	}
+
+	for _, n := range []int{0, 1, -1} {
+		if _, err := calc.PercentChange(0, n); !errors.Is(err, calculator.ErrDivisionByZero) {
+			t.Errorf("PercentChange(0, %d) error = %v, want %v", n, err, calculator.ErrDivisionByZero)
+		}
+	}
+	if got, err := calculator.PercentChange(8, 6); err != nil || got != -25 {
+		t.Errorf("package-level PercentChange(8, 6) = %g, %v; want -25", got, err)
+	}
+}
+
+// TestRatio tests ratios without truncation, rounded once even beyond
+// the ints a float64 holds exactly
+func TestRatio(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	tests := []struct {
+		a, b int
+		want float64
+	}{
+		{3, 4, 0.75},
+		{-3, 4, -0.75},
+		{1, 3, 1.0 / 3},
+		{7, -2, -3.5},
+		{0, 5, 0},
+		{math.MaxInt, 1, math.MaxInt},
+		// Rounding 2^53+1 to a float64 first would give 3002399751580330.5
+		{1<<53 + 1, 3, 3002399751580331},
+	}
+	for _, tt := range tests {
+		if got, err := calc.Ratio(tt.a, tt.b); err != nil || got != tt.want {
+			t.Errorf("Ratio(%d, %d) = %g, %v; want %g", tt.a, tt.b, got, err, tt.want)
+		}
+	}
+	if _, err := calculator.Ratio(1, 0); !errors.Is(err, calculator.ErrDivisionByZero) {
+		t.Errorf("Ratio(1, 0) error = %v, want %v", err, calculator.ErrDivisionByZero)
+	}
+}
+
+// TestComputePercentChange tests the operation pctchange, truncated by
+// Compute, in full from ComputeFloatResultCtx and lossy in strict mode
+func TestComputePercentChange(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	tests := []struct {
+		a, b   int
+		result int
+		full   float64
+	}{
+		{3, 4, 33, 100.0 / 3},
+		{3, 2, -33, -100.0 / 3},
+		{4, 6, 50, 50},
+	}
+	for _, tt := range tests {
+		if got, err := calc.Compute("pctchange", tt.a, tt.b); err != nil || got != tt.result {
+			t.Errorf("Compute(pctchange, %d, %d) = %d, %v; want %d", tt.a, tt.b, got, err, tt.result)
+		}
+		got, full, err := calc.ComputeFloatResultCtx(context.Background(), "pctchange", tt.a, tt.b)
+		if err != nil || got != tt.result || full != tt.full {
+			t.Errorf("ComputeFloatResultCtx(pctchange, %d, %d) = %d, %g, %v; want %d, %g", tt.a, tt.b, got, full, err, tt.result, tt.full)
+		}
+	}
+	if _, full, err := calc.ComputeFloatResultCtx(context.Background(), "divide", 7, 2); err != nil || full != 3 {
+		t.Errorf("ComputeFloatResultCtx(divide, 7, 2) = %g, %v; want the int result 3", full, err)
+	}
+	if _, err := calc.Compute("pctchange", 0, 5); !errors.Is(err, calculator.ErrDivisionByZero) {
+		t.Errorf("Compute(pctchange, 0, 5) error = %v, want %v", err, calculator.ErrDivisionByZero)
+	}
+	if _, err := calc.Compute("pctchange", 1, math.MaxInt); !errors.Is(err, calculator.ErrOverflow) {
+		t.Errorf("Compute(pctchange, 1, MaxInt) error = %v, want %v", err, calculator.ErrOverflow)
+	}
+
+	// 100 * (4 - 3) = 33 * 3 + 1
+	var loss *calculator.PrecisionLossError
+	_, full, err := calc.ComputeStrictFloatResultCtx(context.Background(), "pctchange", 3, 4)
+	if !errors.As(err, &loss) || loss.Result != 33 || loss.Remainder != 1 || loss.Float != 100.0/3 || full != 0 {
+		t.Errorf("strict pctchange 3 4 = %g, %v; want a precision loss", full, err)
+	}
+	if got, err := calc.ComputeStrict("pctchange", 4, 3); err != nil || got != -25 {
+		t.Errorf("strict pctchange 4 3 = %d, %v; want -25", got, err)
+	}
+}
@@ -0,0 +1,180 @@
+package calculator
+
+import (
+	"context"
+	"math"
+	"math/bits"
+	"slices"
+	"time"
+)
+
+// millerRabinBases are the witnesses for which the Miller-Rabin test is
+// deterministic for every n below 2^64
+var millerRabinBases = []uint64{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37}
+
+// IsPrime reports whether n is a prime number. It runs the Miller-Rabin
+// test with a set of witnesses that makes it exact for every int, so it
+// stays fast for large n. Numbers below 2 are not prime.
+func (c *Calculator) IsPrime(n int) (result bool) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notifyValues("isprime", []any{n}, result, nil, time.Since(start)) }()
+	}
+	c.log.Infof("Checking primality: %d", n)
+	result = n >= 2 && isPrime(uint64(n))
+	c.log.Debugf("Primality result: %t", result)
+	return result
+}
+
+// Factorize returns the prime factors of n in ascending order, each as
+// many times as it divides n, so that their product is n. It returns a
+// *RangeError for n below 2, which has no prime factorization.
+func (c *Calculator) Factorize(n int) ([]int, error) {
+	return c.FactorizeCtx(context.Background(), n)
+}
+
+// FactorizeCtx performs like Factorize, but checks ctx as it searches
+// for factors, returning ctx.Err() once it is done. A nil ctx never is.
+func (c *Calculator) FactorizeCtx(ctx context.Context, n int) (result []int, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notifyValues("factorize", []any{n}, result, err, time.Since(start)) }()
+	}
+	ctx = orBackground(ctx)
+	c.log.Infof("Factorizing: %d", n)
+	if n < 2 {
+		err := &RangeError{Name: "number to factorize", Value: n, Min: 2, Max: math.MaxInt}
+		c.log.With("n", n).Errorf("Factorization failed: %v", err)
+		return nil, err
+	}
+	var factors []int
+	m := uint64(n)
+	// Trial division removes the small factors, leaving Pollard's rho to
+	// split what remains
+	for _, p := range millerRabinBases {
+		for m%p == 0 {
+			factors = append(factors, int(p))
+			m /= p
+		}
+	}
+	factors, err = appendFactors(ctx, factors, m)
+	if err != nil {
+		c.log.With("n", n).Warnf("Factorization stopped: %v", err)
+		return nil, err
+	}
+	slices.Sort(factors)
+	c.log.Debugf("Factorization result: %v", factors)
+	return factors, nil
+}
+
+// appendFactors appends the prime factors of m, which has no factor
+// below 41, to factors, or returns ctx.Err()
+func appendFactors(ctx context.Context, factors []int, m uint64) ([]int, error) {
+	if m == 1 {
+		return factors, nil
+	}
+	if isPrime(m) {
+		return append(factors, int(m)), nil
+	}
+	d, err := pollardRho(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	if factors, err = appendFactors(ctx, factors, d); err != nil {
+		return nil, err
+	}
+	return appendFactors(ctx, factors, m/d)
+}
+
+// isPrime is the Miller-Rabin test of n >= 2
+func isPrime(n uint64) bool {
+	for _, p := range millerRabinBases {
+		if n%p == 0 {
+			return n == p
+		}
+	}
+	// n-1 = d * 2^s with d odd
+	s := bits.TrailingZeros64(n - 1)
+	d := (n - 1) >> s
+	for _, a := range millerRabinBases {
+		x := powMod(a, d, n)
+		if x == 1 || x == n-1 {
+			continue
+		}
+		composite := true
+		for range s - 1 {
+			x = mulMod(x, x, n)
+			if x == n-1 {
+				composite = false
+				break
+			}
+		}
+		if composite {
+			return false
+		}
+	}
+	return true
+}
+
+// rhoCheckInterval is how many steps of Pollard's rho run between
+// checks of the context
+const rhoCheckInterval = 1 << 10
+
+// pollardRho returns a nontrivial factor of the odd composite n, by
+// Pollard's rho algorithm with Floyd's cycle detection, or ctx.Err()
+func pollardRho(ctx context.Context, n uint64) (uint64, error) {
+	for c := uint64(1); ; c++ {
+		// n is below 2^63, so the sum cannot overflow
+		f := func(x uint64) uint64 { return (mulMod(x, x, n) + c) % n }
+		x, y, d := uint64(2), uint64(2), uint64(1)
+		for step := 0; d == 1; step++ {
+			if step%rhoCheckInterval == 0 {
+				if err := ctx.Err(); err != nil {
+					return 0, err
+				}
+			}
+			x = f(x)
+			y = f(f(y))
+			d = gcd(max(x, y)-min(x, y), n)
+		}
+		if d != n {
+			return d, nil
+		}
+	}
+}
+
+// mulMod returns a * b mod m, for a and b below m, without overflow
+func mulMod(a, b, m uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	_, rem := bits.Div64(hi, lo, m)
+	return rem
+}
+
+// powMod returns base^exp mod m
+func powMod(base, exp, m uint64) uint64 {
+	result := uint64(1)
+	base %= m
+	for ; exp > 0; exp >>= 1 {
+		if exp&1 == 1 {
+			result = mulMod(result, base, m)
+		}
+		base = mulMod(base, base, m)
+	}
+	return result
+}
+
+func gcd(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// IsPrime reports whether n is a prime number.
+func IsPrime(n int) bool {
+	return noOpCalculator.IsPrime(n)
+}
+
+// Factorize returns the prime factors of n in ascending order, or a
+// *RangeError.
+func Factorize(n int) ([]int, error) {
+	return noOpCalculator.Factorize(n)
+}
@@ -0,0 +1,89 @@
+package calculator_test
+
+import (
+	"errors"
+	"go-examples/pkg/calculator"
+	"math"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// TestIsPrime tests small numbers against trial division, and
+// Carmichael numbers and values near MaxInt64, which fool weaker tests
+func TestIsPrime(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	for n := -10; n <= 2000; n++ {
+		want := n >= 2
+		for d := 2; d*d <= n; d++ {
+			if n%d == 0 {
+				want = false
+				break
+			}
+		}
+		if got := calc.IsPrime(n); got != want {
+			t.Errorf("IsPrime(%d) = %t, want %t", n, got, want)
+		}
+	}
+
+	tests := []struct {
+		n    int
+		want bool
+	}{
+		{561, false}, // Carmichael numbers
+		{1105, false},
+		{1729, false},
+		{41041, false},
+		{3215031751, false}, // strong pseudoprime to bases 2, 3, 5 and 7
+		{2147483647, true},  // 2^31 - 1
+		{4611685975477714963, false},
+		{math.MaxInt64, false},
+		{math.MaxInt64 - 24, true}, // the largest prime below 2^63
+		{math.MaxInt64 - 26, false},
+		{math.MinInt64, false},
+	}
+	for _, tt := range tests {
+		if got := calc.IsPrime(tt.n); got != tt.want {
+			t.Errorf("IsPrime(%d) = %t, want %t", tt.n, got, tt.want)
+		}
+		if got := calculator.IsPrime(tt.n); got != tt.want {
+			t.Errorf("package-level IsPrime(%d) = %t, want %t", tt.n, got, tt.want)
+		}
+	}
+}
+
+// TestFactorize tests factorizations with repeated, small and large
+// prime factors
+func TestFactorize(t *testing.T) {
+	calc := calculator.NewCalculator(setupTestLogger())
+	tests := []struct {
+		n    int
+		want []int
+	}{
+		{2, []int{2}},
+		{4, []int{2, 2}},
+		{12, []int{2, 2, 3}},
+		{97, []int{97}},
+		{561, []int{3, 11, 17}},
+		{1 << 62, slices.Repeat([]int{2}, 62)},
+		{1681, []int{41, 41}},
+		{4611685975477714963, []int{2147483629, 2147483647}},
+		{9223372021822390277, []int{2147483647, 4294967291}},
+		{math.MaxInt64, []int{7, 7, 73, 127, 337, 92737, 649657}},
+		{math.MaxInt64 - 24, []int{math.MaxInt64 - 24}},
+	}
+	for _, tt := range tests {
+		if got, err := calc.Factorize(tt.n); err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Factorize(%d) = %v, %v; want %v", tt.n, got, err, tt.want)
+		}
+	}
+	if got, err := calculator.Factorize(360); err != nil || !reflect.DeepEqual(got, []int{2, 2, 2, 3, 3, 5}) {
+		t.Errorf("package-level Factorize(360) = %v, %v", got, err)
+	}
+
+	for _, n := range []int{1, 0, -12, math.MinInt} {
+		if got, err := calc.Factorize(n); !errors.Is(err, calculator.ErrOutOfRange) || got != nil {
+			t.Errorf("Factorize(%d) = %v, %v; want %v", n, got, err, calculator.ErrOutOfRange)
+		}
+	}
+}
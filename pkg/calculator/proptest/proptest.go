@@ -0,0 +1,184 @@
+// Package proptest checks arithmetic invariants of the calculator, so
+// that its own tests, its fuzz targets and packages reusing it can run
+// the same properties on any inputs:
+//
+//	ops := proptest.FromCalculator(calc)
+//	for _, a := range proptest.Boundaries {
+//		for _, b := range proptest.Boundaries {
+//			if err := proptest.Check(ops, a, b); err != nil {
+//				t.Error(err)
+//			}
+//		}
+//	}
+package proptest
+
+import (
+	"errors"
+	"fmt"
+	"go-examples/pkg/calculator"
+	"math"
+	"strconv"
+)
+
+// Boundaries are the values around which overflow and sign handling
+// break, for seeding fuzz corpora and table tests
+var Boundaries = []int{
+	0, 1, -1, 2, -2,
+	math.MaxInt8, math.MinInt8,
+	math.MaxInt32, math.MinInt32,
+	math.MaxInt - 1, math.MaxInt,
+	math.MinInt + 1, math.MinInt,
+}
+
+// Ops are the operations the properties are checked on. Evaluate is
+// optional: the parser properties are skipped without it.
+type Ops struct {
+	Add, Subtract, Multiply func(a, b int) int
+	Divide                  func(a, b int) (int, error)
+	Evaluate                func(expr string) (int, error)
+}
+
+// FromCalculator returns the operations of c
+func FromCalculator(c *calculator.Calculator) Ops {
+	return Ops{Add: c.Add, Subtract: c.Subtract, Multiply: c.Multiply, Divide: c.Divide, Evaluate: c.Evaluate}
+}
+
+// Default returns the package-level operations of the calculator
+func Default() Ops {
+	return Ops{Add: calculator.Add, Subtract: calculator.Subtract, Multiply: calculator.Multiply, Divide: calculator.Divide}
+}
+
+// Check runs every property on a and b and returns the violations
+// joined, or nil
+func Check(ops Ops, a, b int) error {
+	return errors.Join(
+		Commutative("Add", ops.Add, a, b),
+		Commutative("Multiply", ops.Multiply, a, b),
+		AddSubtractInverse(ops, a, b),
+		DivisionIdentity(ops, a, b),
+		ParseRoundTrip(ops, a, b),
+	)
+}
+
+// Commutative checks that op(a, b) equals op(b, a)
+func Commutative(name string, op func(a, b int) int, a, b int) error {
+	if x, y := op(a, b), op(b, a); x != y {
+		return fmt.Errorf("%s(%d, %d) = %d but %s(%d, %d) = %d", name, a, b, x, name, b, a, y)
+	}
+	return nil
+}
+
+// AddSubtractInverse checks that subtracting b from a + b gives a, when
+// a + b does not overflow
+func AddSubtractInverse(ops Ops, a, b int) error {
+	if (b > 0 && a > math.MaxInt-b) || (b < 0 && a < math.MinInt-b) {
+		return nil
+	}
+	sum := ops.Add(a, b)
+	if got := ops.Subtract(sum, b); got != a {
+		return fmt.Errorf("Subtract(Add(%d, %d), %d) = %d, want %d", a, b, b, got, a)
+	}
+	return nil
+}
+
+// DivisionIdentity checks that Divide truncates toward zero, so that
+// a - Divide(a, b)*b is the remainder a%b for a non-zero b, and that
+// dividing by zero returns calculator.ErrDivisionByZero
+func DivisionIdentity(ops Ops, a, b int) error {
+	q, err := ops.Divide(a, b)
+	if b == 0 {
+		if !errors.Is(err, calculator.ErrDivisionByZero) {
+			return fmt.Errorf("Divide(%d, 0) = %d, %v, want %v", a, q, err, calculator.ErrDivisionByZero)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Divide(%d, %d) failed: %v", a, b, err)
+	}
+	r := a - q*b
+	if r != a%b {
+		return fmt.Errorf("Divide(%d, %d) = %d leaves remainder %d, want %d", a, b, q, r, a%b)
+	}
+	return nil
+}
+
+// Format returns n as an operand of an expression, which Evaluate reads
+// back as n. MinInt is written as a subtraction, since its magnitude
+// alone does not fit in an int.
+func Format(n int) string {
+	if n == math.MinInt {
+		return fmt.Sprintf("(%d - 1)", math.MinInt+1)
+	}
+	return strconv.Itoa(n)
+}
+
+// ParseRoundTrip checks that Evaluate reads back a and b as formatted
+// by Format, and that a + b, a - b, a * b and a / b so formatted give
+// the results of the operations, unless Evaluate reports an overflow,
+// which Add, Subtract and Multiply wrap
+func ParseRoundTrip(ops Ops, a, b int) error {
+	if ops.Evaluate == nil {
+		return nil
+	}
+	var errs []error
+	for _, n := range []int{a, b} {
+		if got, err := ops.Evaluate(Format(n)); err != nil || got != n {
+			errs = append(errs, fmt.Errorf("Evaluate(%q) = %d, %v, want %d", Format(n), got, err, n))
+		}
+	}
+	binary := func(op func(a, b int) int) func(a, b int) (int, error) {
+		return func(a, b int) (int, error) { return op(a, b), nil }
+	}
+	for _, op := range []struct {
+		symbol string
+		apply  func(a, b int) (int, error)
+	}{
+		{"+", binary(ops.Add)},
+		{"-", binary(ops.Subtract)},
+		{"*", binary(ops.Multiply)},
+		{"/", ops.Divide},
+	} {
+		expr := Format(a) + " " + op.symbol + " " + Format(b)
+		got, err := ops.Evaluate(expr)
+		if errors.Is(err, calculator.ErrOverflow) {
+			continue
+		}
+		want, wantErr := op.apply(a, b)
+		if got != want || !errors.Is(err, wantErr) || (wantErr == nil && err != nil) {
+			errs = append(errs, fmt.Errorf("Evaluate(%q) = %d, %v, want %d, %v", expr, got, err, want, wantErr))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// EvaluateRoundTrip checks that an expr evaluate accepts has the same
+// value when evaluated again, and that evaluate reads that value back as
+// formatted by Format. It checks nothing for an expr evaluate rejects.
+func EvaluateRoundTrip(evaluate func(expr string) (int, error), expr string) error {
+	value, err := evaluate(expr)
+	if err != nil {
+		return nil
+	}
+	if again, err := evaluate(expr); err != nil || again != value {
+		return fmt.Errorf("Evaluate(%q) = %d, then %d, %v", expr, value, again, err)
+	}
+	if got, err := evaluate(Format(value)); err != nil || got != value {
+		return fmt.Errorf("Evaluate(%q) = %d, %v, want %d, the value of %q", Format(value), got, err, value, expr)
+	}
+	return nil
+}
+
+// AlmostEqual reports whether the floats a and b differ by at most
+// epsilon, relative to the larger of their magnitudes when it exceeds 1,
+// so that results of float operations can be compared despite rounding.
+// NaN equals nothing, and infinities only themselves.
+func AlmostEqual(a, b, epsilon float64) bool {
+	if a == b {
+		return true
+	}
+	if math.IsNaN(a) || math.IsNaN(b) || math.IsInf(a, 0) || math.IsInf(b, 0) {
+		return false
+	}
+	scale := math.Max(1, math.Max(math.Abs(a), math.Abs(b)))
+	return math.Abs(a-b) <= epsilon*scale
+}
@@ -0,0 +1,88 @@
+package proptest_test
+
+import (
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/calculator/proptest"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestCheckDetectsViolations tests that each property fails for an
+// implementation breaking it
+func TestCheckDetectsViolations(t *testing.T) {
+	broken := proptest.Default()
+	broken.Add = func(a, b int) int { return a + 2*b }
+	broken.Divide = func(a, b int) (int, error) {
+		if b == 0 {
+			return 0, nil
+		}
+		return a/b + 1, nil
+	}
+
+	if err := proptest.Commutative("Add", broken.Add, 1, 2); err == nil {
+		t.Error("Commutative accepted a non-commutative Add")
+	}
+	if err := proptest.AddSubtractInverse(broken, 1, 2); err == nil {
+		t.Error("AddSubtractInverse accepted a broken Add")
+	}
+	if err := proptest.DivisionIdentity(broken, 7, 2); err == nil {
+		t.Error("DivisionIdentity accepted a wrong quotient")
+	}
+	if err := proptest.DivisionIdentity(broken, 7, 0); err == nil {
+		t.Error("DivisionIdentity accepted division by zero without an error")
+	}
+
+	// An evaluator ignoring the last operand
+	broken.Evaluate = func(expr string) (int, error) {
+		n, err := strconv.Atoi(strings.Fields(expr)[0])
+		return n, err
+	}
+	if err := proptest.ParseRoundTrip(broken, 1, 2); err == nil {
+		t.Error("ParseRoundTrip accepted an evaluator reading only the first operand")
+	}
+	if err := proptest.EvaluateRoundTrip(broken.Evaluate, "3 + 4"); err != nil {
+		t.Errorf("EvaluateRoundTrip(3 + 4) = %v, want nil for an evaluator consistent with itself", err)
+	}
+	calls := 0
+	unstable := func(string) (int, error) { calls++; return calls, nil }
+	if err := proptest.EvaluateRoundTrip(unstable, "1"); err == nil {
+		t.Error("EvaluateRoundTrip accepted an evaluator giving a new value each time")
+	}
+}
+
+// TestFormat tests that every boundary value is formatted as an
+// expression the calculator reads back
+func TestFormat(t *testing.T) {
+	ops := proptest.FromCalculator(calculator.NewCalculator(nil))
+	if got := proptest.Format(math.MinInt); got != "(-9223372036854775807 - 1)" {
+		t.Errorf("Format(MinInt) = %q", got)
+	}
+	for _, n := range proptest.Boundaries {
+		if err := proptest.ParseRoundTrip(ops, n, -n); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// TestAlmostEqual tests the float comparison
+func TestAlmostEqual(t *testing.T) {
+	tests := []struct {
+		a, b, epsilon float64
+		want          bool
+	}{
+		{0.1 + 0.2, 0.3, 1e-9, true},
+		{1, 1.1, 1e-9, false},
+		{1e20, 1e20 + 1e6, 1e-9, true}, // relative above 1
+		{1e-12, 2e-12, 1e-9, true},     // absolute below 1
+		{math.Inf(1), math.Inf(1), 1e-9, true},
+		{math.Inf(1), math.MaxFloat64, 1e-9, false},
+		{math.NaN(), math.NaN(), 1e-9, false},
+	}
+	for _, tt := range tests {
+		if got := proptest.AlmostEqual(tt.a, tt.b, tt.epsilon); got != tt.want {
+			t.Errorf("AlmostEqual(%g, %g, %g) = %v, want %v", tt.a, tt.b, tt.epsilon, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package calculator
+
+import (
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// randomSource is the source of RandomInt, shared by calls from any
+// goroutine
+type randomSource struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// WithRandSource makes RandomInt draw from src, such as
+// rand.NewPCG(1, 2), so that a seeded source gives the same numbers on
+// every run. The source need not be safe for concurrent use. The default,
+// also used for a nil src, is the randomly seeded source of math/rand/v2.
+func WithRandSource(src rand.Source) Option {
+	return func(c *Calculator) {
+		c.random = nil
+		if src != nil {
+			c.random = &randomSource{rand: rand.New(src)}
+		}
+	}
+}
+
+// RandomInt returns a number picked uniformly at random from min to max,
+// both included, such as a dice roll for 1 and 6. Every number is as
+// likely, even when the size of the range is not a power of two. It
+// returns a *RangeError when min is above max. It is safe for concurrent
+// use.
+func (c *Calculator) RandomInt(min, max int) (result int, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notify("random", min, max, result, err, time.Since(start)) }()
+	}
+	c.log.Infof("Picking a random number: %d to %d", min, max)
+	if min > max {
+		err := &RangeError{Name: "random maximum", Value: max, Min: min, Max: math.MaxInt}
+		c.log.With("min", min, "max", max).Errorf("Random number failed: %v", err)
+		return 0, err
+	}
+	// span is the size of the range less one, which fits in a uint64
+	// even from MinInt to MaxInt
+	span := uint64(max) - uint64(min)
+	var offset uint64
+	switch {
+	case c.random == nil && span == math.MaxUint64:
+		offset = rand.Uint64()
+	case c.random == nil:
+		offset = rand.Uint64N(span + 1)
+	default:
+		c.random.mu.Lock()
+		if span == math.MaxUint64 {
+			offset = c.random.rand.Uint64()
+		} else {
+			offset = c.random.rand.Uint64N(span + 1)
+		}
+		c.random.mu.Unlock()
+	}
+	result = int(uint64(min) + offset)
+	c.log.Debugf("Random number: %d", result)
+	return result, nil
+}
+
+// RandomInt returns a number picked uniformly at random from min to max,
+// both included, or a *RangeError.
+func RandomInt(min, max int) (int, error) {
+	return noOpCalculator.RandomInt(min, max)
+}
@@ -0,0 +1,119 @@
+package calculator_test
+
+import (
+	"errors"
+	"go-examples/pkg/calculator"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// TestRandomIntSeeded tests that a seeded source gives the same numbers
+// on every run, through RandomInt and the random operation
+func TestRandomIntSeeded(t *testing.T) {
+	calc := calculator.NewCalculator(setupTestLogger(), calculator.WithRandSource(rand.NewPCG(1, 2)))
+	var rolls []int
+	for range 10 {
+		roll, err := calc.RandomInt(1, 6)
+		if err != nil {
+			t.Fatalf("RandomInt(1, 6) failed: %v", err)
+		}
+		rolls = append(rolls, roll)
+	}
+	if want := []int{5, 4, 5, 5, 2, 1, 3, 3, 1, 2}; !slices.Equal(rolls, want) {
+		t.Errorf("rolls with seed 1, 2 = %v, want %v", rolls, want)
+	}
+	if got, err := calc.RandomInt(-1000000, 1000000); err != nil || got != 323833 {
+		t.Errorf("RandomInt(-1000000, 1000000) = %d, %v; want 323833", got, err)
+	}
+	if got, err := calc.RandomInt(math.MinInt, math.MaxInt); err != nil || got != -1018463739037169590 {
+		t.Errorf("RandomInt(MinInt, MaxInt) = %d, %v; want -1018463739037169590", got, err)
+	}
+
+	again := calculator.NewCalculator(noOpBenchLogger{}, calculator.WithRandSource(rand.NewPCG(1, 2)))
+	if got, err := again.Compute("rand", 1, 6); err != nil || got != 5 {
+		t.Errorf("Compute(rand, 1, 6) with seed 1, 2 = %d, %v; want 5", got, err)
+	}
+}
+
+// TestRandomIntRange tests single-number ranges, the extremes of int
+// and the error of an empty range
+func TestRandomIntRange(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	for _, n := range []int{0, -3, math.MinInt, math.MaxInt} {
+		if got, err := calc.RandomInt(n, n); err != nil || got != n {
+			t.Errorf("RandomInt(%d, %d) = %d, %v; want %d", n, n, got, err, n)
+		}
+	}
+	if got, err := calc.RandomInt(math.MaxInt-1, math.MaxInt); err != nil || got < math.MaxInt-1 {
+		t.Errorf("RandomInt(MaxInt-1, MaxInt) = %d, %v", got, err)
+	}
+	if got, err := calculator.RandomInt(math.MinInt, math.MinInt+1); err != nil || got > math.MinInt+1 {
+		t.Errorf("RandomInt(MinInt, MinInt+1) = %d, %v", got, err)
+	}
+
+	var rangeErr *calculator.RangeError
+	if _, err := calc.RandomInt(6, 1); !errors.As(err, &rangeErr) || rangeErr.Value != 1 || rangeErr.Min != 6 {
+		t.Errorf("RandomInt(6, 1) error = %v, want a *RangeError", err)
+	}
+	if _, err := calc.Compute("random", 6, 1); !errors.As(err, &rangeErr) {
+		t.Errorf("Compute(random, 6, 1) error = %v, want a *RangeError", err)
+	}
+}
+
+// TestRandomIntDistribution tests that every number of small ranges,
+// including ones whose size is not a power of two, is picked about as
+// often, with a chi-squared test
+func TestRandomIntDistribution(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{}, calculator.WithRandSource(rand.NewPCG(7, 11)))
+	// Critical values of the chi-squared distribution at p = 0.001
+	tests := []struct {
+		min, max int
+		critical float64
+	}{
+		{1, 6, 20.52},
+		{-1, 1, 13.82},
+		{10, 16, 22.46},
+	}
+	const perNumber = 10000
+	for _, tt := range tests {
+		n := tt.max - tt.min + 1
+		counts := make([]int, n)
+		for range n * perNumber {
+			got, err := calc.RandomInt(tt.min, tt.max)
+			if err != nil || got < tt.min || got > tt.max {
+				t.Fatalf("RandomInt(%d, %d) = %d, %v", tt.min, tt.max, got, err)
+			}
+			counts[got-tt.min]++
+		}
+		var chi2 float64
+		for _, c := range counts {
+			d := float64(c - perNumber)
+			chi2 += d * d / perNumber
+		}
+		if chi2 > tt.critical {
+			t.Errorf("RandomInt(%d, %d) counts %v, chi-squared %.2f above %.2f", tt.min, tt.max, counts, chi2, tt.critical)
+		}
+	}
+}
+
+// TestRandomIntConcurrent tests sharing a seeded calculator between
+// goroutines; run it with -race
+func TestRandomIntConcurrent(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{}, calculator.WithRandSource(rand.NewPCG(1, 2)))
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				if got, err := calc.RandomInt(1, 6); err != nil || got < 1 || got > 6 {
+					t.Errorf("RandomInt(1, 6) = %d, %v", got, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
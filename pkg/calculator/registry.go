@@ -0,0 +1,168 @@
+package calculator
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Registry is a set of operations performed by name, starting with the
+// built-in ones. Calculators use the default registry, to which
+// RegisterOperation adds and which Describe and LookupOperation read,
+// unless given another with WithRegistry. It is safe for concurrent
+// use.
+type Registry struct {
+	mu  sync.RWMutex
+	ops []operation
+}
+
+// defaultRegistry is the registry of RegisterOperation
+var defaultRegistry = NewRegistry()
+
+// NewRegistry creates a Registry of the built-in operations
+func NewRegistry() *Registry {
+	return &Registry{ops: slices.Clone(operations)}
+}
+
+// WithRegistry makes the calculator perform the operations of r, for
+// operations that only some calculators have; a nil r keeps the default
+// registry
+func WithRegistry(r *Registry) Option {
+	return func(c *Calculator) {
+		if r == nil {
+			r = defaultRegistry
+		}
+		c.registry = r
+	}
+}
+
+// RegisterOperation adds the binary integer operation fn under name to
+// the default registry, for Compute, Apply and Describe. Names are
+// lowercase identifiers, such as "avg", that no operation has as its
+// name or alias. It returns a *RegistrationError for other names, and a
+// nil fn.
+func RegisterOperation(name string, fn func(a, b int) (int, error)) error {
+	return defaultRegistry.Register(name, fn)
+}
+
+// Operations returns the names of the operations of the default
+// registry, sorted
+func Operations() []string {
+	return defaultRegistry.Operations()
+}
+
+// Register adds the binary integer operation fn under name, as
+// RegisterOperation does
+func (r *Registry) Register(name string, fn func(a, b int) (int, error)) error {
+	if reason := checkOperationName(name, fn); reason != "" {
+		name = shortenName(name)
+		return &RegistrationError{Name: name, Reason: reason}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.lookupLocked(name); ok {
+		return &RegistrationError{Name: name, Reason: "already registered"}
+	}
+	r.ops = append(r.ops, operation{
+		Operation: Operation{Name: name, Arity: ArityBinary, OperandType: OperandInt, Description: "Registered operation"},
+		apply: func(c *Calculator, a, b int) (int, error) {
+			c.log.Infof("Calculating %s: %d, %d", name, a, b)
+			result, err := fn(a, b)
+			if err != nil {
+				c.log.With("a", a, "b", b).Errorf("Operation %s failed: %v", name, err)
+				return 0, err
+			}
+			c.log.Debugf("Operation %s result: %d", name, result)
+			return result, nil
+		},
+	})
+	return nil
+}
+
+// Operations returns the names of the operations of r, sorted
+func (r *Registry) Operations() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, len(r.ops))
+	for i, op := range r.ops {
+		names[i] = op.Name
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Apply performs the operation with name or alias name in the registry
+// of c on a and b. It is Compute, for callers of RegisterOperation:
+// operations log as the built-in ones do, and the options of c, such as
+// WithStrict and WithAudit, apply.
+func (c *Calculator) Apply(name string, a, b int) (int, error) {
+	return c.Compute(name, a, b)
+}
+
+// Describe returns every operation Compute performs with the registry
+// of c, as Describe does with the default registry
+func (c *Calculator) Describe() []Operation {
+	return c.registry.describe()
+}
+
+// LookupOperation returns the operation with name or alias name in the
+// registry of c
+func (c *Calculator) LookupOperation(name string) (Operation, bool) {
+	return c.registry.lookupOperation(name)
+}
+
+// describe returns the operations of r, sorted by name
+func (r *Registry) describe() []Operation {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ops := make([]Operation, len(r.ops))
+	for i, op := range r.ops {
+		ops[i] = op.Operation
+		ops[i].Aliases = slices.Clone(op.Aliases)
+	}
+	slices.SortFunc(ops, func(a, b Operation) int { return strings.Compare(a.Name, b.Name) })
+	return ops
+}
+
+// lookup returns the operation with name or alias name
+func (r *Registry) lookup(name string) (operation, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.lookupLocked(name)
+}
+
+// lookupOperation returns the description of the operation with name or
+// alias name
+func (r *Registry) lookupOperation(name string) (Operation, bool) {
+	op, ok := r.lookup(name)
+	if !ok {
+		return Operation{}, false
+	}
+	return op.Operation, true
+}
+
+// lookupLocked is lookup with r.mu held
+func (r *Registry) lookupLocked(name string) (operation, bool) {
+	for _, op := range r.ops {
+		if op.Name == name || slices.Contains(op.Aliases, name) {
+			return op, true
+		}
+	}
+	return operation{}, false
+}
+
+// checkOperationName returns why name and fn cannot be registered, or ""
+func checkOperationName(name string, fn func(a, b int) (int, error)) string {
+	if fn == nil {
+		return "nil function"
+	}
+	if name == "" || len(name) > MaxVarNameLength || isDigit(name[0]) {
+		return "not a lowercase identifier"
+	}
+	for i := range len(name) {
+		if ch := name[i]; ch != '_' && !isDigit(ch) && (ch < 'a' || ch > 'z') {
+			return "not a lowercase identifier"
+		}
+	}
+	return ""
+}
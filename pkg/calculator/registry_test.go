@@ -0,0 +1,137 @@
+package calculator_test
+
+import (
+	"errors"
+	"go-examples/pkg/calculator"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// average is an operation to register, rounding toward zero without
+// overflow
+func average(a, b int) (int, error) {
+	return a/2 + b/2 + (a%2+b%2)/2, nil
+}
+
+// TestRegistry tests registering and applying an operation with a
+// registry of its own, leaving the default registry alone
+func TestRegistry(t *testing.T) {
+	registry := calculator.NewRegistry()
+	if err := registry.Register("avg", average); err != nil {
+		t.Fatalf("Register(avg) failed: %v", err)
+	}
+	calc := calculator.NewCalculator(setupTestLogger(), calculator.WithRegistry(registry), calculator.WithHistory(10))
+	if got, err := calc.Apply("avg", 7, 4); err != nil || got != 5 {
+		t.Errorf("Apply(avg, 7, 4) = %d, %v; want 5", got, err)
+	}
+	if got, err := calc.Apply("add", 7, 4); err != nil || got != 11 {
+		t.Errorf("Apply(add, 7, 4) = %d, %v; want 11 from the built-in operations", got, err)
+	}
+	if got := calc.History(); len(got) != 2 || got[0].Operation != "avg" {
+		t.Errorf("History() = %+v, want avg recorded", got)
+	}
+
+	sentinel := errors.New("boom")
+	if err := registry.Register("fail", func(a, b int) (int, error) { return 0, sentinel }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := calc.Apply("fail", 1, 2); !errors.Is(err, sentinel) {
+		t.Errorf("Apply(fail) error = %v, want %v", err, sentinel)
+	}
+
+	var unknown *calculator.UnknownOperationError
+	plain := calculator.NewCalculator(noOpBenchLogger{})
+	if _, err := plain.Apply("avg", 7, 4); !errors.As(err, &unknown) || unknown.Name != "avg" {
+		t.Errorf("Apply(avg) with the default registry error = %v, want avg unknown", err)
+	}
+	if slices.Contains(calculator.Operations(), "avg") {
+		t.Error("registering with NewRegistry changed the default registry")
+	}
+}
+
+// TestRegisterInvalid tests that names in use, names that are not
+// lowercase identifiers and nil functions are refused
+func TestRegisterInvalid(t *testing.T) {
+	registry := calculator.NewRegistry()
+	if err := registry.Register("avg", average); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		fn     func(a, b int) (int, error)
+		reason string
+	}{
+		{"add", average, "already registered"},
+		{"avg", average, "already registered"},
+		{"", average, "not a lowercase identifier"},
+		{"Avg", average, "not a lowercase identifier"},
+		{"2x", average, "not a lowercase identifier"},
+		{"a-b", average, "not a lowercase identifier"},
+		{strings.Repeat("x", calculator.MaxVarNameLength+1), average, "not a lowercase identifier"},
+		{"mean", nil, "nil function"},
+	}
+	for _, tt := range tests {
+		var regErr *calculator.RegistrationError
+		err := registry.Register(tt.name, tt.fn)
+		if !errors.As(err, &regErr) || !errors.Is(err, calculator.ErrRegistration) || regErr.Reason != tt.reason {
+			t.Errorf("Register(%.20q) error = %v, want %q", tt.name, err, tt.reason)
+		}
+	}
+	var regErr *calculator.RegistrationError
+	if err := registry.Register("x"+strings.Repeat("é", 20), average); !errors.As(err, &regErr) || regErr.Name != "x"+strings.Repeat("é", 7)+"..." {
+		t.Errorf("Register(xéé...) error = %v, want the name cut after 7 runes é", err)
+	}
+
+	// Only refused registrations touch the default registry here
+	if err := calculator.RegisterOperation("add", average); !errors.Is(err, calculator.ErrRegistration) {
+		t.Errorf("RegisterOperation(add) error = %v, want %v", err, calculator.ErrRegistration)
+	}
+	if got, err := calculator.NewCalculator(noOpBenchLogger{}).Apply("add", 7, 4); err != nil || got != 11 {
+		t.Errorf("add after a refused registration = %d, %v; want 11", got, err)
+	}
+}
+
+// TestOperations tests that the names are sorted and include those
+// registered
+func TestOperations(t *testing.T) {
+	names := calculator.Operations()
+	if !slices.IsSorted(names) || len(names) != len(calculator.Describe()) || !slices.Contains(names, "divide") {
+		t.Errorf("Operations() = %v", names)
+	}
+
+	registry := calculator.NewRegistry()
+	if err := registry.Register("avg", average); err != nil {
+		t.Fatal(err)
+	}
+	got := registry.Operations()
+	if !slices.IsSorted(got) || len(got) != len(names)+1 || !slices.Contains(got, "avg") {
+		t.Errorf("Operations() after Register(avg) = %v", got)
+	}
+}
+
+// TestRegistryConcurrent registers and applies operations from many
+// goroutines; run it with -race
+func TestRegistryConcurrent(t *testing.T) {
+	registry := calculator.NewRegistry()
+	calc := calculator.NewCalculator(noOpBenchLogger{}, calculator.WithRegistry(registry))
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 50 {
+				_ = registry.Register("op"+strconv.Itoa((g*50+i)%100), average)
+				_, _ = calc.Apply("op"+strconv.Itoa(i), 4, 2)
+				_, _ = calc.Apply("add", i, g)
+				_ = registry.Operations()
+			}
+		}()
+	}
+	wg.Wait()
+	if got := len(registry.Operations()) - len(calculator.Operations()); got != 100 {
+		t.Errorf("%d operations registered concurrently, want 100", got)
+	}
+}
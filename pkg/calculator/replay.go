@@ -0,0 +1,211 @@
+package calculator
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Message and fields of the audit entries written by Compute
+const (
+	auditMessage      = "Calculation"
+	auditOperation    = "operation"
+	auditA            = "a"
+	auditB            = "b"
+	auditResult       = "result"
+	auditError        = "error"
+	auditMode         = "mode" // set by ComputeFloat, ComputeBig, ComputeFraction and ComputeDecimal
+	auditModeFloat    = "float"
+	auditModeBig      = "big"
+	auditModeFraction = "fraction"
+	auditModeDecimal  = "decimal"
+)
+
+// ReplayOutcome is the result of an operation, or its error message
+type ReplayOutcome struct {
+	Result int
+	Err    string
+}
+
+func (o ReplayOutcome) String() string {
+	if o.Err != "" {
+		return "error " + strconv.Quote(o.Err)
+	}
+	return strconv.Itoa(o.Result)
+}
+
+// ReplayMismatch is an audit entry whose recomputed outcome differs from
+// the recorded one
+type ReplayMismatch struct {
+	Line       int // 1-based line number in the stream
+	Operation  string
+	A, B       int
+	Recorded   ReplayOutcome
+	Recomputed ReplayOutcome
+}
+
+// ReplayUnknown is an audit entry for an operation the calculator does
+// not know
+type ReplayUnknown struct {
+	Line      int // 1-based line number in the stream
+	Operation string
+}
+
+// ReplayReport summarizes a Replay
+type ReplayReport struct {
+	Replayed   int // calculation entries read, known or not
+	Mismatches []ReplayMismatch
+	Unknown    []ReplayUnknown
+}
+
+// OK reports whether every entry was replayed to its recorded outcome
+func (r ReplayReport) OK() bool {
+	return len(r.Mismatches) == 0 && len(r.Unknown) == 0
+}
+
+func (r ReplayReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "replayed %d calculations: %d mismatched, %d unknown\n", r.Replayed, len(r.Mismatches), len(r.Unknown))
+	for _, m := range r.Mismatches {
+		fmt.Fprintf(&b, "line %d: %s %d %d recorded %s, recomputed %s\n", m.Line, m.Operation, m.A, m.B, m.Recorded, m.Recomputed)
+	}
+	for _, u := range r.Unknown {
+		fmt.Fprintf(&b, "line %d: unknown operation %s\n", u.Line, u.Operation)
+	}
+	return b.String()
+}
+
+// replayEntry is an audit entry as written by Compute. The operands and
+// result are decoded by parse, once the entry is known to be one of
+// Compute: those of the other modes need not be numbers.
+type replayEntry struct {
+	Message   string          `json:"msg"`
+	Operation string          `json:"operation"`
+	A         json.RawMessage `json:"a"`
+	B         json.RawMessage `json:"b"`
+	Result    json.RawMessage `json:"result"`
+	Error     string          `json:"error"`
+	Mode      string          `json:"mode"`
+}
+
+// Replay re-executes the calculations recorded by WithAudit in r on calc,
+// or on a calculator without logging if calc is nil, and reports the
+// entries whose recomputed outcome differs from the recorded one, a sign
+// of a corrupted log or of behavior drift between versions, and those of
+// unknown operations. Other entries, including the calculations of
+// ComputeFloat, ComputeBig, ComputeFraction and ComputeDecimal and those
+// of random operations, whose results cannot be recomputed, are
+// skipped. A line that is not a well-formed entry stops the replay with
+// an error.
+//
+// Replay checks what the entries say, not whether they were altered
+// afterwards; logger.VerifyAuditStream checks the hash chain.
+func Replay(r io.Reader, calc *Calculator) (ReplayReport, error) {
+	if r == nil {
+		return ReplayReport{}, errors.New("no audit log to replay")
+	}
+	if calc == nil {
+		calc = NewCalculator(nil)
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var report ReplayReport
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var entry replayEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return report, fmt.Errorf("line %d: malformed entry: %w", line, err)
+		}
+		if entry.Message != auditMessage || entry.Mode != "" {
+			continue
+		}
+		if op, ok := calc.registry.lookup(entry.Operation); ok && op.Random {
+			continue
+		}
+		report.Replayed++
+
+		recorded, a, b, err := entry.parse()
+		if err != nil {
+			return report, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		var recomputed ReplayOutcome
+		result, err := calc.Compute(entry.Operation, a, b)
+		switch {
+		case errors.Is(err, ErrUnknownOperation):
+			report.Unknown = append(report.Unknown, ReplayUnknown{Line: line, Operation: entry.Operation})
+			continue
+		case err != nil:
+			// The audit log records the error of the operation, without
+			// the operation and operands of the *OperationError
+			var opErr *OperationError
+			if errors.As(err, &opErr) {
+				err = opErr.Err
+			}
+			recomputed.Err = err.Error()
+		default:
+			recomputed.Result = result
+		}
+		if recomputed != recorded {
+			report.Mismatches = append(report.Mismatches, ReplayMismatch{
+				Line:       line,
+				Operation:  entry.Operation,
+				A:          a,
+				B:          b,
+				Recorded:   recorded,
+				Recomputed: recomputed,
+			})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return report, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return report, nil
+}
+
+// parse returns the recorded outcome and operands of e
+func (e replayEntry) parse() (ReplayOutcome, int, int, error) {
+	if e.Operation == "" || isNull(e.A) || isNull(e.B) || (isNull(e.Result) && e.Error == "") {
+		return ReplayOutcome{}, 0, 0, errors.New("calculation entry misses operation, operands or outcome")
+	}
+	a, err := parseInt(e.A)
+	if err != nil {
+		return ReplayOutcome{}, 0, 0, fmt.Errorf("invalid operand a: %w", err)
+	}
+	b, err := parseInt(e.B)
+	if err != nil {
+		return ReplayOutcome{}, 0, 0, fmt.Errorf("invalid operand b: %w", err)
+	}
+	if e.Error != "" {
+		return ReplayOutcome{Err: e.Error}, a, b, nil
+	}
+	result, err := parseInt(e.Result)
+	if err != nil {
+		return ReplayOutcome{}, 0, 0, fmt.Errorf("invalid result: %w", err)
+	}
+	return ReplayOutcome{Result: result}, a, b, nil
+}
+
+// isNull reports whether the field raw is missing or null
+func isNull(raw json.RawMessage) bool {
+	return len(raw) == 0 || string(raw) == "null"
+}
+
+// parseInt parses the JSON number, or string holding one, raw as an int
+func parseInt(raw json.RawMessage) (int, error) {
+	var n json.Number
+	if err := json.Unmarshal(raw, &n); err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(n.String())
+}
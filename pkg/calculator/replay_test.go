@@ -0,0 +1,97 @@
+package calculator_test
+
+import (
+	"bytes"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/logger"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestReplay tests the report of a crafted log with a tampered result
+// and an unknown operation
+func TestReplay(t *testing.T) {
+	f, err := os.Open("testdata/audit.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	report, err := calculator.Replay(f, calculator.NewCalculator(noOpBenchLogger{}))
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	want := calculator.ReplayReport{
+		Replayed: 5,
+		Mismatches: []calculator.ReplayMismatch{{
+			Line:       2,
+			Operation:  "multiply",
+			A:          6,
+			B:          7,
+			Recorded:   calculator.ReplayOutcome{Result: 24},
+			Recomputed: calculator.ReplayOutcome{Result: 42},
+		}},
+		Unknown: []calculator.ReplayUnknown{{Line: 5, Operation: "modulo"}},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("Replay = %+v, want %+v", report, want)
+	}
+	if report.OK() {
+		t.Error("OK = true for a report with problems")
+	}
+
+	wantText := `replayed 5 calculations: 1 mismatched, 1 unknown
+line 2: multiply 6 7 recorded 24, recomputed 42
+line 5: unknown operation modulo
+`
+	if got := report.String(); got != wantText {
+		t.Errorf("String = %q, want %q", got, wantText)
+	}
+}
+
+// TestReplayAuditLog tests that a log written through WithAudit replays
+// cleanly, skipping random operations
+func TestReplayAuditLog(t *testing.T) {
+	var buf bytes.Buffer
+	audit, err := logger.NewAudit(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calc := calculator.NewCalculator(noOpBenchLogger{}, calculator.WithAudit(audit))
+	for _, op := range []string{"add", "subtract", "multiply", "divide"} {
+		_, _ = calc.Compute(op, 12, 4)
+		_, _ = calc.Compute(op, -7, 0)
+	}
+	_, _ = calc.Compute("modulo", 1, 1) // not performed, so not recorded
+	_, _ = calc.Compute("random", 1, 6) // not recomputed, so not replayed
+
+	if err := logger.VerifyAuditStream(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("audit log does not verify: %v", err)
+	}
+	report, err := calculator.Replay(&buf, calculator.NewCalculator(noOpBenchLogger{}))
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if !report.OK() || report.Replayed != 8 {
+		t.Errorf("Replay = %+v, want 8 matching calculations", report)
+	}
+}
+
+// TestReplayMalformed tests that lines which are not calculation entries
+// stop the replay with their line number
+func TestReplayMalformed(t *testing.T) {
+	for name, log := range map[string]string{
+		"not JSON":        "{\"msg\":\"Calculation\"\n",
+		"missing outcome": `{"msg":"Calculation","operation":"add","a":1,"b":2}`,
+		"invalid operand": `{"msg":"Calculation","operation":"add","a":1.5,"b":2,"result":3}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := calculator.Replay(strings.NewReader(log), calculator.NewCalculator(noOpBenchLogger{}))
+			if err == nil || !strings.HasPrefix(err.Error(), "line 1: ") {
+				t.Errorf("Replay error = %v, want one for line 1", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package calculator
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// StateVersion is the schema version of the State written by this
+// version of the package
+const StateVersion = 1
+
+// Entry is an operation in the history of a calculator
+type Entry struct {
+	Operation string    `json:"operation"`
+	A         int       `json:"a"`
+	B         int       `json:"b"`
+	Result    int       `json:"result"`
+	Time      time.Time `json:"time"`
+}
+
+// State is the part of a calculator that outlives the process: its
+// variables, memory register and the tail of its history, oldest first
+type State struct {
+	Version   int            `json:"version"`
+	Variables map[string]int `json:"variables"`
+	Memory    int            `json:"memory"`
+	History   []Entry        `json:"history"`
+}
+
+// clone returns a copy of s sharing no maps or slices with it
+func (s State) clone() State {
+	s.Variables = maps.Clone(s.Variables)
+	s.History = slices.Clone(s.History)
+	return s
+}
+
+// StateStore persists the State of a calculator. Load returns an empty
+// State of the current version when nothing was saved yet.
+type StateStore interface {
+	Load() (State, error)
+	Save(State) error
+}
+
+// MemoryStore is a StateStore keeping the State in memory, for tests and
+// for processes that only hand state between calculators. It is safe
+// for concurrent use.
+type MemoryStore struct {
+	mu    sync.Mutex
+	state State
+	saves int
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{state: State{Version: StateVersion}}
+}
+
+// Load returns a copy of the saved State
+func (m *MemoryStore) Load() (State, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.clone(), nil
+}
+
+// Save keeps a copy of state
+func (m *MemoryStore) Save(state State) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.state = state.clone()
+	m.state.Version = StateVersion
+	m.saves++
+	return nil
+}
+
+// Saves returns how many times Save was called
+func (m *MemoryStore) Saves() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.saves
+}
+
+// calcState is the State of a calculator, shared by copies of it
+type calcState struct {
+	mu    sync.Mutex
+	state State
+	gen   uint64 // incremented on every change, so autosave skips clean state
+
+	result     int         // of the last operation, restored by Undo
+	undo, redo []undoEntry // of WithUndo, most recent last
+}
+
+func newCalcState() *calcState {
+	return &calcState{state: State{Version: StateVersion}}
+}
+
+// snapshot returns a copy of the state and its generation
+func (s *calcState) snapshot() (State, uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state.clone(), s.gen
+}
+
+// errNoStore is returned for a nil StateStore
+var errNoStore = errors.New("no state store")
+
+// SaveState writes the variables, memory register and history of the
+// calculator to store
+func (c *Calculator) SaveState(store StateStore) error {
+	if store == nil {
+		return errNoStore
+	}
+	state, _ := c.state.snapshot()
+	return store.Save(state)
+}
+
+// LoadState replaces the variables, memory register and history of the
+// calculator with those in store, keeping only the most recent entries
+// of the history with WithHistory. Changes made before cannot be undone.
+func (c *Calculator) LoadState(store StateStore) error {
+	if store == nil {
+		return errNoStore
+	}
+	state, err := store.Load()
+	if err != nil {
+		return err
+	}
+	state.Version = StateVersion
+	state = state.clone()
+	state.History = trimHistory(state.History, c.historySize)
+	c.state.mu.Lock()
+	c.state.state = state
+	c.state.undo, c.state.redo = nil, nil
+	c.state.gen++
+	c.state.mu.Unlock()
+	c.log.Debugf("Loaded state: %d variables, %d history entries", len(state.Variables), len(state.History))
+	return nil
+}
+
+// autoSaver saves the state of a calculator to a store periodically and
+// on Close
+type autoSaver struct {
+	store    StateStore
+	interval time.Duration
+	done     chan struct{}
+	stopped  chan struct{}
+
+	closeOnce sync.Once
+
+	mu    sync.Mutex // serializes saves
+	saved uint64     // generation of the last save
+}
+
+// WithAutoSave saves the state to store every interval when it changed,
+// and once more on Close. With a non-positive interval the state is only
+// saved on Close; a nil store disables autosave.
+func WithAutoSave(store StateStore, interval time.Duration) Option {
+	return func(c *Calculator) {
+		if store == nil {
+			c.autoSave = nil
+			return
+		}
+		c.autoSave = &autoSaver{store: store, interval: interval}
+	}
+}
+
+// startAutoSave runs the autosave loop, if configured
+func (c *Calculator) startAutoSave() {
+	a := c.autoSave
+	if a == nil {
+		return
+	}
+	a.done = make(chan struct{})
+	a.stopped = make(chan struct{})
+	go func() {
+		defer close(a.stopped)
+		for {
+			var tick <-chan time.Time // nil, never ready, without an interval
+			if a.interval > 0 {
+				tick = c.clock.After(a.interval)
+			}
+			select {
+			case <-tick:
+				if err := c.flush(); err != nil {
+					c.log.Warnf("Autosave failed: %v", err)
+				}
+			case <-a.done:
+				return
+			}
+		}
+	}()
+}
+
+// flush saves the state to the autosave store if it changed since the
+// last save
+func (c *Calculator) flush() error {
+	a := c.autoSave
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	state, gen := c.state.snapshot()
+	if gen == a.saved {
+		return nil
+	}
+	if err := a.store.Save(state); err != nil {
+		return err
+	}
+	a.saved = gen
+	return nil
+}
+
+// Close stops autosaving and saves the state a last time. It is a no-op
+// without WithAutoSave.
+func (c *Calculator) Close() error {
+	a := c.autoSave
+	if a == nil {
+		return nil
+	}
+	a.closeOnce.Do(func() {
+		close(a.done)
+		<-a.stopped
+	})
+	return c.flush()
+}
@@ -0,0 +1,223 @@
+package calculator_test
+
+import (
+	"encoding/json"
+	"errors"
+	"go-examples/pkg/calculator"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testState is a State with every field set and a history in a
+// deliberately unsorted time order, so round trips must keep the order
+var testState = calculator.State{
+	Version:   calculator.StateVersion,
+	Variables: map[string]int{"x": 5, "total": -12},
+	Memory:    42,
+	History: []calculator.Entry{
+		{Operation: "multiply", A: -3, B: 4, Result: -12, Time: time.Date(2026, 10, 1, 12, 0, 5, 0, time.UTC)},
+		{Operation: "add", A: 2, B: 3, Result: 5, Time: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)},
+		{Operation: "divide", A: 9, B: 2, Result: 4, Time: time.Date(2026, 10, 1, 12, 0, 9, 0, time.UTC)},
+	},
+}
+
+// TestStateRoundTrip tests that the state survives a calculator, and
+// both stores, unchanged
+func TestStateRoundTrip(t *testing.T) {
+	stores := map[string]func(t *testing.T) calculator.StateStore{
+		"memory": func(*testing.T) calculator.StateStore { return calculator.NewMemoryStore() },
+		"file": func(t *testing.T) calculator.StateStore {
+			return calculator.NewFileStore(filepath.Join(t.TempDir(), "state.json"))
+		},
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			source := calculator.NewMemoryStore()
+			if err := source.Save(testState); err != nil {
+				t.Fatal(err)
+			}
+			calc := calculator.NewCalculator(noOpBenchLogger{})
+			if err := calc.LoadState(source); err != nil {
+				t.Fatalf("LoadState failed: %v", err)
+			}
+
+			store := newStore(t)
+			if err := calc.SaveState(store); err != nil {
+				t.Fatalf("SaveState failed: %v", err)
+			}
+			restored := calculator.NewCalculator(noOpBenchLogger{})
+			if err := restored.LoadState(store); err != nil {
+				t.Fatalf("LoadState failed: %v", err)
+			}
+
+			check := calculator.NewMemoryStore()
+			if err := restored.SaveState(check); err != nil {
+				t.Fatal(err)
+			}
+			got, _ := check.Load()
+			if !reflect.DeepEqual(got, testState) {
+				t.Errorf("round trip = %+v, want %+v", got, testState)
+			}
+		})
+	}
+}
+
+// TestFileStoreEmpty tests that a missing file loads as an empty state
+func TestFileStoreEmpty(t *testing.T) {
+	state, err := calculator.NewFileStore(filepath.Join(t.TempDir(), "state.json")).Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !reflect.DeepEqual(state, calculator.State{Version: calculator.StateVersion}) {
+		t.Errorf("Load = %+v, want an empty state", state)
+	}
+}
+
+// TestFileStoreCorrupt tests that corrupt and too new files fail to load
+// and are left alone
+func TestFileStoreCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	newer := filepath.Join(dir, "newer.json")
+	if err := os.WriteFile(newer, []byte(`{"version": 99}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{
+		"testdata/state_corrupt.json": "corrupt state file",
+		newer:                         "newer than the supported",
+	} {
+		before, _ := os.ReadFile(path)
+		calc := calculator.NewCalculator(noOpBenchLogger{})
+		err := calc.LoadState(calculator.NewFileStore(path))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("LoadState(%s) error = %v, want %q", path, err, want)
+		}
+		if after, _ := os.ReadFile(path); string(after) != string(before) {
+			t.Errorf("LoadState(%s) modified the file", path)
+		}
+	}
+}
+
+// TestFileStoreMigration tests loading a file written before the schema
+// was versioned, and a custom migration hook
+func TestFileStoreMigration(t *testing.T) {
+	state, err := calculator.NewFileStore("testdata/state_v0.json").Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	want := calculator.State{
+		Version:   calculator.StateVersion,
+		Variables: map[string]int{"x": 5, "total": -12},
+		Memory:    42,
+		History:   []calculator.Entry{testState.History[1], testState.History[0]},
+	}
+	if !reflect.DeepEqual(state, want) {
+		t.Errorf("Load = %+v, want %+v", state, want)
+	}
+
+	reset := calculator.WithMigration(0, func(doc map[string]json.RawMessage) error {
+		doc["memory"] = json.RawMessage("0")
+		return nil
+	})
+	if state, err := calculator.NewFileStore("testdata/state_v0.json", reset).Load(); err != nil || state.Memory != 0 {
+		t.Errorf("Load with a custom migration = %+v, %v, want memory 0", state, err)
+	}
+
+	failing := calculator.WithMigration(0, func(map[string]json.RawMessage) error { return errors.New("boom") })
+	if _, err := calculator.NewFileStore("testdata/state_v0.json", failing).Load(); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Load with a failing migration error = %v", err)
+	}
+}
+
+// TestFileStoreSaveAtomic tests that saves replace the file without
+// leaving temporary files behind
+func TestFileStoreSaveAtomic(t *testing.T) {
+	dir := t.TempDir()
+	store := calculator.NewFileStore(filepath.Join(dir, "state.json"))
+	for i := 0; i < 3; i++ {
+		state := testState
+		state.Memory = i
+		if err := store.Save(state); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 || entries[0].Name() != "state.json" {
+		t.Errorf("directory holds %v, want only state.json", entries)
+	}
+	if state, _ := store.Load(); state.Memory != 2 {
+		t.Errorf("Load = %+v, want the last save", state)
+	}
+}
+
+// fakeClock hands out the channels returned by After, so tests fire
+// the autosave ticks themselves
+type fakeClock struct {
+	afters chan chan time.Time
+}
+
+func (f *fakeClock) Now() time.Time { return time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC) }
+
+func (f *fakeClock) After(time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	f.afters <- ch
+	return ch
+}
+
+// TestAutoSave tests that changed state is saved on the next tick, that
+// clean state is not saved again, and that Close flushes
+func TestAutoSave(t *testing.T) {
+	clock := &fakeClock{afters: make(chan chan time.Time)}
+	store := calculator.NewMemoryStore()
+	calc := calculator.NewCalculator(noOpBenchLogger{},
+		calculator.WithClock(clock),
+		calculator.WithAutoSave(store, time.Minute))
+
+	// tick fires the timer the loop waits on and returns once the loop
+	// waits on the next one, so the tick has been handled
+	timer := <-clock.afters
+	tick := func() {
+		timer <- time.Time{}
+		timer = <-clock.afters
+	}
+	source := calculator.NewMemoryStore()
+	if err := source.Save(testState); err != nil {
+		t.Fatal(err)
+	}
+
+	tick()
+	if n := store.Saves(); n != 0 {
+		t.Fatalf("clean state was saved %d times", n)
+	}
+
+	if err := calc.LoadState(source); err != nil {
+		t.Fatal(err)
+	}
+	if n := store.Saves(); n != 0 {
+		t.Fatalf("state was saved %d times before the tick", n)
+	}
+	tick()
+	if got, _ := store.Load(); store.Saves() != 1 || !reflect.DeepEqual(got, testState) {
+		t.Fatalf("after a tick the store holds %+v after %d saves", got, store.Saves())
+	}
+	tick()
+	if n := store.Saves(); n != 1 {
+		t.Fatalf("unchanged state was saved again, %d saves", n)
+	}
+
+	if err := calc.LoadState(source); err != nil {
+		t.Fatal(err)
+	}
+	if err := calc.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if n := store.Saves(); n != 2 {
+		t.Errorf("Close did not flush the changed state, %d saves", n)
+	}
+	if err := calc.Close(); err != nil || store.Saves() != 2 {
+		t.Errorf("second Close = %v after %d saves, want a no-op", err, store.Saves())
+	}
+}
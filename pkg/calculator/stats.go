@@ -0,0 +1,136 @@
+package calculator
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+// VarianceKind selects the divisor of Variance and StdDev
+type VarianceKind int
+
+// Kinds of variance
+const (
+	// Population divides by n, for nums that are the whole population
+	Population VarianceKind = iota
+	// Sample divides by n-1 (Bessel's correction), for nums that are a
+	// sample of a larger population
+	Sample
+)
+
+// Median returns the middle value of nums once sorted, or the mean of
+// the two middle values for an even count, or ErrEmptyInput when nums
+// is empty. nums is not modified.
+func (c *Calculator) Median(nums []int) (result float64, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notifyValues("median", []any{nums}, result, err, time.Since(start)) }()
+	}
+	c.log.Infof("Calculating median of %d numbers", len(nums))
+	if len(nums) == 0 {
+		c.log.Error("Median of no numbers")
+		return 0, ErrEmptyInput
+	}
+	sorted := slices.Sorted(slices.Values(nums))
+	mid := len(sorted) / 2
+	var median float64
+	if len(sorted)%2 == 1 {
+		median = float64(sorted[mid])
+	} else {
+		// Halving first keeps the sum of the middle values in int
+		a, b := sorted[mid-1], sorted[mid]
+		median = float64(a/2+b/2) + float64(a%2+b%2)/2
+	}
+	c.log.Debugf("Median result: %g", median)
+	return median, nil
+}
+
+// Variance returns the population or sample variance of nums, chosen by
+// kind, computed in one pass with Welford's algorithm, which stays
+// accurate where summing squares cancels out. A single number has the
+// variance 0, of either kind. It returns ErrEmptyInput when nums is
+// empty, and a *RangeError for an unknown kind.
+func (c *Calculator) Variance(nums []int, kind VarianceKind) (result float64, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notifyValues("variance", []any{nums, kind}, result, err, time.Since(start)) }()
+	}
+	c.log.Infof("Calculating %s variance of %d numbers", kind.name(), len(nums))
+	variance, err := c.variance(nums, kind)
+	if err != nil {
+		return 0, err
+	}
+	c.log.Debugf("Variance result: %g", variance)
+	return variance, nil
+}
+
+// StdDev returns the population or sample standard deviation of nums,
+// chosen by kind: the square root of their Variance. It returns the
+// errors of Variance.
+func (c *Calculator) StdDev(nums []int, kind VarianceKind) (result float64, err error) {
+	if start, ok := c.observing(); ok {
+		defer func() { c.notifyValues("stddev", []any{nums, kind}, result, err, time.Since(start)) }()
+	}
+	c.log.Infof("Calculating %s standard deviation of %d numbers", kind.name(), len(nums))
+	variance, err := c.variance(nums, kind)
+	if err != nil {
+		return 0, err
+	}
+	stdDev := math.Sqrt(variance)
+	c.log.Debugf("Standard deviation result: %g", stdDev)
+	return stdDev, nil
+}
+
+// variance implements Variance, logging failures
+func (c *Calculator) variance(nums []int, kind VarianceKind) (float64, error) {
+	if kind != Population && kind != Sample {
+		err := &RangeError{Name: "variance kind", Value: int(kind), Min: int(Population), Max: int(Sample)}
+		c.log.Errorf("Variance failed: %v", err)
+		return 0, err
+	}
+	if len(nums) == 0 {
+		c.log.Error("Variance of no numbers")
+		return 0, ErrEmptyInput
+	}
+	if len(nums) == 1 {
+		return 0, nil
+	}
+	// m2 accumulates the squared distances to the running mean
+	var mean, m2 float64
+	for i, num := range nums {
+		x := float64(num)
+		delta := x - mean
+		mean += delta / float64(i+1)
+		m2 += delta * (x - mean)
+	}
+	if kind == Sample {
+		return m2 / float64(len(nums)-1), nil
+	}
+	return m2 / float64(len(nums)), nil
+}
+
+// name returns the kind for log messages
+func (k VarianceKind) name() string {
+	switch k {
+	case Population:
+		return "population"
+	case Sample:
+		return "sample"
+	}
+	return "unknown"
+}
+
+// Median returns the median of nums, or ErrEmptyInput.
+func Median(nums []int) (float64, error) {
+	return noOpCalculator.Median(nums)
+}
+
+// Variance returns the population or sample variance of nums, or
+// ErrEmptyInput or a *RangeError.
+func Variance(nums []int, kind VarianceKind) (float64, error) {
+	return noOpCalculator.Variance(nums, kind)
+}
+
+// StdDev returns the population or sample standard deviation of nums,
+// or ErrEmptyInput or a *RangeError.
+func StdDev(nums []int, kind VarianceKind) (float64, error) {
+	return noOpCalculator.StdDev(nums, kind)
+}
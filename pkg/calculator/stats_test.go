@@ -0,0 +1,106 @@
+package calculator_test
+
+import (
+	"errors"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/calculator/proptest"
+	"math"
+	"slices"
+	"testing"
+)
+
+// TestMedian tests odd and even counts, unsorted input and middle values
+// whose sum overflows int
+func TestMedian(t *testing.T) {
+	calc := calculator.NewCalculator(setupTestLogger())
+	tests := []struct {
+		nums []int
+		want float64
+	}{
+		{[]int{7}, 7},
+		{[]int{3, 1, 2}, 2},
+		{[]int{4, 1, 3, 2}, 2.5},
+		{[]int{-3, -1}, -2},
+		{[]int{-4, 1}, -1.5},
+		{[]int{5, 5, 5, 1}, 5},
+		{[]int{math.MaxInt, math.MaxInt - 2}, math.MaxInt - 1},
+		{[]int{math.MinInt, math.MaxInt}, -0.5},
+	}
+	for _, tt := range tests {
+		input := slices.Clone(tt.nums)
+		if got, err := calc.Median(input); err != nil || got != tt.want {
+			t.Errorf("Median(%v) = %g, %v; want %g", tt.nums, got, err, tt.want)
+		}
+		if !slices.Equal(input, tt.nums) {
+			t.Errorf("Median(%v) modified its input to %v", tt.nums, input)
+		}
+		if got, err := calculator.Median(tt.nums); err != nil || got != tt.want {
+			t.Errorf("package-level Median(%v) = %g, %v; want %g", tt.nums, got, err, tt.want)
+		}
+	}
+}
+
+// TestVariance tests both kinds of variance and standard deviation, as
+// methods and package-level functions
+func TestVariance(t *testing.T) {
+	calc := calculator.NewCalculator(setupTestLogger())
+	tests := []struct {
+		name               string
+		nums               []int
+		population, sample float64
+	}{
+		{"single", []int{42}, 0, 0},
+		{"equal", []int{3, 3, 3}, 0, 0},
+		{"textbook", []int{2, 4, 4, 4, 5, 5, 7, 9}, 4, 32.0 / 7},
+		{"negative", []int{-1, 1}, 1, 2},
+		// Summing squares loses these in float64; Welford does not
+		{"large offset", []int{1e9 + 4, 1e9 + 7, 1e9 + 13, 1e9 + 16}, 22.5, 30},
+		{"extremes", []int{math.MinInt, math.MaxInt}, 0x1p126, 0x1p127},
+	}
+	for _, tt := range tests {
+		for _, kind := range []struct {
+			kind calculator.VarianceKind
+			want float64
+		}{{calculator.Population, tt.population}, {calculator.Sample, tt.sample}} {
+			if got, err := calc.Variance(tt.nums, kind.kind); err != nil || !proptest.AlmostEqual(got, kind.want, 1e-9) {
+				t.Errorf("%s: Variance(%d) = %g, %v; want %g", tt.name, kind.kind, got, err, kind.want)
+			}
+			if got, err := calculator.Variance(tt.nums, kind.kind); err != nil || !proptest.AlmostEqual(got, kind.want, 1e-9) {
+				t.Errorf("%s: package-level Variance(%d) = %g, %v; want %g", tt.name, kind.kind, got, err, kind.want)
+			}
+			want := math.Sqrt(kind.want)
+			if got, err := calc.StdDev(tt.nums, kind.kind); err != nil || !proptest.AlmostEqual(got, want, 1e-9) {
+				t.Errorf("%s: StdDev(%d) = %g, %v; want %g", tt.name, kind.kind, got, err, want)
+			}
+			if got, err := calculator.StdDev(tt.nums, kind.kind); err != nil || !proptest.AlmostEqual(got, want, 1e-9) {
+				t.Errorf("%s: package-level StdDev(%d) = %g, %v; want %g", tt.name, kind.kind, got, err, want)
+			}
+		}
+	}
+}
+
+// TestStatsErrors tests that empty input and unknown kinds fail
+func TestStatsErrors(t *testing.T) {
+	calc := calculator.NewCalculator(setupTestLogger())
+	for _, nums := range [][]int{nil, {}} {
+		if _, err := calc.Median(nums); !errors.Is(err, calculator.ErrEmptyInput) {
+			t.Errorf("Median(%v) error = %v, want %v", nums, err, calculator.ErrEmptyInput)
+		}
+		for _, kind := range []calculator.VarianceKind{calculator.Population, calculator.Sample} {
+			if _, err := calc.Variance(nums, kind); !errors.Is(err, calculator.ErrEmptyInput) {
+				t.Errorf("Variance(%v, %d) error = %v, want %v", nums, kind, err, calculator.ErrEmptyInput)
+			}
+			if _, err := calc.StdDev(nums, kind); !errors.Is(err, calculator.ErrEmptyInput) {
+				t.Errorf("StdDev(%v, %d) error = %v, want %v", nums, kind, err, calculator.ErrEmptyInput)
+			}
+		}
+	}
+	for _, kind := range []calculator.VarianceKind{-1, 2} {
+		if _, err := calc.Variance([]int{1, 2}, kind); !errors.Is(err, calculator.ErrOutOfRange) {
+			t.Errorf("Variance(%d) error = %v, want %v", kind, err, calculator.ErrOutOfRange)
+		}
+		if _, err := calc.StdDev([]int{1, 2}, kind); !errors.Is(err, calculator.ErrOutOfRange) {
+			t.Errorf("StdDev(%d) error = %v, want %v", kind, err, calculator.ErrOutOfRange)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package calculator
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// TraceStep is an operation recorded by a Trace: its name, operands,
+// result or error and how long it took. The marker ending a truncated
+// Trace has no Operation, and the number of operations left out in
+// Truncated.
+type TraceStep struct {
+	Operation    string
+	A, B, Result int
+	Err          error
+	Duration     time.Duration
+	Truncated    int
+}
+
+// Trace is an Observer recording the operations it is told of in order,
+// up to a maximum number of steps, such as the reductions of an
+// expression or the calls of a batch. Give it to WithObserver for a
+// calculator recording the operations of one request only. It is safe
+// for concurrent use.
+type Trace struct {
+	mu      sync.Mutex
+	max     int
+	steps   []TraceStep
+	dropped int
+}
+
+// NewTrace returns a Trace recording the first maxSteps operations. A
+// non-positive maxSteps records all of them.
+func NewTrace(maxSteps int) *Trace {
+	return &Trace{max: max(maxSteps, 0)}
+}
+
+// OnOperation records the operation name, or counts it as left out once
+// the trace has its maximum number of steps
+func (t *Trace) OnOperation(name string, a, b, result int, err error, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.max > 0 && len(t.steps) == t.max {
+		t.dropped++
+		return
+	}
+	t.steps = append(t.steps, TraceStep{Operation: name, A: a, B: b, Result: result, Err: err, Duration: d})
+}
+
+// Steps returns the operations recorded, in the order they were
+// performed, followed by a marker when some were left out
+func (t *Trace) Steps() []TraceStep {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	steps := slices.Clone(t.steps)
+	if t.dropped > 0 {
+		steps = append(steps, TraceStep{Truncated: t.dropped})
+	}
+	return steps
+}
+
+// WithObserver returns a copy of c, as from WithLogger, that also tells
+// o of every operation it performs, such as a Trace of one request. c
+// and its other copies do not tell o; observers added to c afterwards
+// are not told of the operations of the copy. A nil o returns a copy
+// with the observers of c.
+func (c *Calculator) WithObserver(o Observer) *Calculator {
+	observed := *c
+	observed.observers = &observers{}
+	list := c.observers.load()
+	if o != nil {
+		list = append(slices.Clone(list), o)
+	}
+	observed.observers.list.Store(&list)
+	return &observed
+}
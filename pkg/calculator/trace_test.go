@@ -0,0 +1,100 @@
+package calculator_test
+
+import (
+	"errors"
+	"go-examples/pkg/calculator"
+	"testing"
+)
+
+// TestTraceFailure tests that the trace of a failing expression ends at
+// the operation that failed, with its error
+func TestTraceFailure(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	trace := calculator.NewTrace(0)
+	if _, err := calc.WithObserver(trace).Evaluate("2 * 3 + 4 / (5 - 5) + 1"); !errors.Is(err, calculator.ErrDivisionByZero) {
+		t.Fatalf("Evaluate error = %v, want %v", err, calculator.ErrDivisionByZero)
+	}
+	steps := trace.Steps()
+	want := []string{"multiply", "subtract", "divide"}
+	if len(steps) != len(want) {
+		t.Fatalf("trace = %+v, want operations %v", steps, want)
+	}
+	for i, s := range steps {
+		if s.Operation != want[i] {
+			t.Errorf("step %d = %s, want %s", i, s.Operation, want[i])
+		}
+	}
+	last := steps[len(steps)-1]
+	if last.A != 4 || last.B != 0 || !errors.Is(last.Err, calculator.ErrDivisionByZero) {
+		t.Errorf("last step = %+v, want divide 4 0 failing with %v", last, calculator.ErrDivisionByZero)
+	}
+}
+
+// TestTraceReconstructs tests that a successful trace gives the result
+// of the expression: replaying its steps in order, each on numbers of
+// the expression or results of earlier steps, ends at the result
+func TestTraceReconstructs(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	trace := calculator.NewTrace(0)
+	got, err := calc.WithObserver(trace).Evaluate("(1 + 2) * (10 - 4) / 3")
+	if err != nil || got != 6 {
+		t.Fatalf("Evaluate = %d, %v; want 6", got, err)
+	}
+	available := map[int]int{1: 1, 2: 1, 10: 1, 4: 1, 3: 1}
+	take := func(n int) bool {
+		if available[n] == 0 {
+			return false
+		}
+		available[n]--
+		return true
+	}
+	var last int
+	for _, s := range trace.Steps() {
+		if !take(s.A) || !take(s.B) {
+			t.Fatalf("%s %d %d uses a value that is neither in the expression nor a result", s.Operation, s.A, s.B)
+		}
+		replayed, err := calc.Compute(s.Operation, s.A, s.B)
+		if err != nil || replayed != s.Result || s.Err != nil {
+			t.Fatalf("%s %d %d recorded %d, %v; replayed %d, %v", s.Operation, s.A, s.B, s.Result, s.Err, replayed, err)
+		}
+		available[replayed]++
+		last = replayed
+	}
+	if last != got {
+		t.Errorf("trace reconstructs %d, want the result %d", last, got)
+	}
+}
+
+// TestTraceBound tests that a trace keeps its first steps and ends with
+// a marker counting the operations left out
+func TestTraceBound(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	var counts calculator.CountingObserver
+	calc.AddObserver(&counts)
+	trace := calculator.NewTrace(3)
+	if got, err := calc.WithObserver(trace).Evaluate("1 + 1 + 1 + 1 + 1 + 1"); err != nil || got != 6 {
+		t.Fatalf("Evaluate = %d, %v; want 6", got, err)
+	}
+	steps := trace.Steps()
+	if len(steps) != 4 {
+		t.Fatalf("trace = %+v, want 3 steps and a marker", steps)
+	}
+	for i, s := range steps[:3] {
+		if s.Operation != "add" || s.Result != i+2 {
+			t.Errorf("step %d = %+v, want add giving %d", i, s, i+2)
+		}
+	}
+	if marker := steps[3]; marker.Operation != "" || marker.Truncated != 2 {
+		t.Errorf("marker = %+v, want 2 operations left out", marker)
+	}
+
+	// The copy keeps telling the observers of calc, and calc does not
+	// tell the trace
+	if got := counts.Count("add"); got != 5 {
+		t.Errorf("observer of calc told of %d additions, want 5", got)
+	}
+	calc.Add(1, 1)
+	if got := trace.Steps()[3].Truncated; got != 2 {
+		t.Errorf("marker counts %d after an operation of calc, want 2", got)
+	}
+}
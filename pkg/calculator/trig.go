@@ -0,0 +1,139 @@
+package calculator
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+// AngleUnit is the unit of the angles of Sin, Cos and Tan
+type AngleUnit int
+
+// Angle units
+const (
+	// Radians is the unit of the math package, and the default
+	Radians AngleUnit = iota
+	// Degrees divides a turn into 360
+	Degrees
+)
+
+// WithAngleUnit makes Sin, Cos and Tan take angles in unit. Units other
+// than Radians and Degrees are ignored.
+func WithAngleUnit(unit AngleUnit) Option {
+	return func(c *Calculator) {
+		if unit == Radians || unit == Degrees {
+			c.angleUnit = unit
+		}
+	}
+}
+
+// Sin returns the sine of the angle x, in the unit of WithAngleUnit,
+// radians by default. Like the other float operations, it returns
+// ErrNotFinite for a NaN or infinite x.
+func (c *Calculator) Sin(x float64) (float64, error) {
+	return c.ComputeTrig("sin", x, c.angleUnit)
+}
+
+// Cos returns the cosine of the angle x, in the unit of WithAngleUnit
+func (c *Calculator) Cos(x float64) (float64, error) {
+	return c.ComputeTrig("cos", x, c.angleUnit)
+}
+
+// Tan returns the tangent of the angle x, in the unit of WithAngleUnit.
+// It returns ErrNotFinite for odd multiples of 90 degrees, where the
+// tangent is infinite, rather than ±Inf. No float64 is an odd multiple
+// of π/2, so in radians the tangent is large there, but finite.
+func (c *Calculator) Tan(x float64) (float64, error) {
+	return c.ComputeTrig("tan", x, c.angleUnit)
+}
+
+// trigNames are the names logged for the trigonometric functions
+var trigNames = map[string]string{"sin": "Sine", "cos": "Cosine", "tan": "Tangent"}
+
+// ComputeTrig performs the trigonometric function with name sin, cos or
+// tan on the angle x in unit, whatever the unit of WithAngleUnit. In
+// degrees, multiples of 90 are exact, so that sin 180 is 0 and cos 90
+// is 0 rather than nearly. It returns an *UnknownOperationError for
+// other names and a *RangeError for an unknown unit.
+func (c *Calculator) ComputeTrig(name string, x float64, unit AngleUnit) (result float64, err error) {
+	c.log.Infof("Calculating %s: %g %s", name, x, unit.name())
+	logName, ok := trigNames[name]
+	if !ok {
+		err := &UnknownOperationError{Name: name}
+		c.log.Errorf("Trigonometric function failed: %v", err)
+		return 0, err
+	}
+	if start, ok := c.observing(); ok {
+		defer func() { c.notifyValues(name, []any{x, unit}, result, err, time.Since(start)) }()
+	}
+	if unit != Radians && unit != Degrees {
+		err := &RangeError{Name: "angle unit", Value: int(unit), Min: int(Radians), Max: int(Degrees)}
+		c.log.Errorf("Trigonometric function failed: %v", err)
+		return 0, err
+	}
+	if !finite(x) {
+		c.log.With("x", x).Errorf("Trigonometric function failed: %v", ErrNotFinite)
+		return 0, ErrNotFinite
+	}
+	sin, cos := math.Sincos(x)
+	if unit == Degrees {
+		sin, cos = sincosDegrees(x)
+	}
+	result = sin
+	switch name {
+	case "cos":
+		result = cos
+	case "tan":
+		if cos == 0 {
+			c.log.With("x", x).Errorf("%s failed: %v", logName, ErrNotFinite)
+			return 0, ErrNotFinite
+		}
+		result = sin / cos
+	}
+	return c.floatResult(logName, x, 0, result)
+}
+
+// sincosDegrees returns the sine and cosine of x degrees, exact for
+// multiples of 90. It reduces x to d in [-45, 45] degrees from the
+// nearest multiple of 90, which is exact, before converting to radians.
+func sincosDegrees(x float64) (sin, cos float64) {
+	r := math.Mod(x, 360)
+	quadrant := math.Round(r / 90)
+	sin, cos = math.Sincos((r - quadrant*90) * math.Pi / 180)
+	switch int(quadrant) & 3 {
+	case 1:
+		sin, cos = cos, -sin
+	case 2:
+		sin, cos = -sin, -cos
+	case 3:
+		sin, cos = -cos, sin
+	}
+	// Avoid -0 at the exact multiples
+	return sin + 0, cos + 0
+}
+
+// RoundTo returns x rounded to places decimal places, for display, such
+// as 0.5 for a sine of 0.49999999999999994 rounded to 10 places. Negative
+// places round to a whole number. NaN and infinities are returned as
+// they are.
+func RoundTo(x float64, places int) float64 {
+	if !finite(x) {
+		return x
+	}
+	// Every float64 has at most 1074 decimal places
+	rounded, err := strconv.ParseFloat(strconv.FormatFloat(x, 'f', min(max(places, 0), 1074), 64), 64)
+	if err != nil {
+		return x
+	}
+	return rounded
+}
+
+func (u AngleUnit) name() string {
+	switch u {
+	case Radians:
+		return "radians"
+	case Degrees:
+		return "degrees"
+	}
+	return "angle unit " + strconv.Itoa(int(u))
+}
@@ -0,0 +1,130 @@
+package calculator_test
+
+import (
+	"errors"
+	"go-examples/pkg/calculator"
+	"go-examples/pkg/calculator/proptest"
+	"math"
+	"testing"
+)
+
+// TestTrigDegrees tests sines, cosines and tangents of common angles in
+// degrees, exact at multiples of 90, and the pole of the tangent
+func TestTrigDegrees(t *testing.T) {
+	calc := calculator.NewCalculator(setupTestLogger(), calculator.WithAngleUnit(calculator.Degrees))
+	tests := []struct {
+		x             float64
+		sin, cos, tan float64
+	}{
+		{0, 0, 1, 0},
+		{30, 0.5, math.Sqrt(3) / 2, 1 / math.Sqrt(3)},
+		{45, math.Sqrt2 / 2, math.Sqrt2 / 2, 1},
+		{60, math.Sqrt(3) / 2, 0.5, math.Sqrt(3)},
+		{135, math.Sqrt2 / 2, -math.Sqrt2 / 2, -1},
+		{180, 0, -1, 0},
+		{-30, -0.5, math.Sqrt(3) / 2, -1 / math.Sqrt(3)},
+		{360, 0, 1, 0},
+		{720 + 30, 0.5, math.Sqrt(3) / 2, 1 / math.Sqrt(3)},
+		{-360 * 1e10, 0, 1, 0},
+	}
+	for _, tt := range tests {
+		if got, err := calc.Sin(tt.x); err != nil || !proptest.AlmostEqual(got, tt.sin, 1e-15) {
+			t.Errorf("Sin(%g°) = %g, %v; want %g", tt.x, got, err, tt.sin)
+		}
+		if got, err := calc.Cos(tt.x); err != nil || !proptest.AlmostEqual(got, tt.cos, 1e-15) {
+			t.Errorf("Cos(%g°) = %g, %v; want %g", tt.x, got, err, tt.cos)
+		}
+		if got, err := calc.Tan(tt.x); err != nil || !proptest.AlmostEqual(got, tt.tan, 1e-15) {
+			t.Errorf("Tan(%g°) = %g, %v; want %g", tt.x, got, err, tt.tan)
+		}
+	}
+
+	// Multiples of 90 are exact
+	exact := []struct {
+		name string
+		x    float64
+		want float64
+	}{
+		{"sin", 90, 1}, {"sin", 180, 0}, {"sin", 270, -1}, {"sin", -90, -1},
+		{"cos", 90, 0}, {"cos", 180, -1}, {"cos", 270, 0}, {"cos", -180, -1},
+	}
+	for _, tt := range exact {
+		if got, err := calc.ComputeTrig(tt.name, tt.x, calculator.Degrees); err != nil || got != tt.want || got == 0 && math.Signbit(got) {
+			t.Errorf("%s %g° = %g, %v; want exactly %g", tt.name, tt.x, got, err, tt.want)
+		}
+	}
+
+	for _, x := range []float64{90, 270, -90, 450} {
+		if got, err := calc.Tan(x); !errors.Is(err, calculator.ErrNotFinite) {
+			t.Errorf("Tan(%g°) = %g, %v; want %v", x, got, err, calculator.ErrNotFinite)
+		}
+	}
+}
+
+// TestTrigRadians tests the default unit, choosing the unit per call,
+// and invalid arguments
+func TestTrigRadians(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{})
+	if got, err := calc.Sin(math.Pi / 6); err != nil || !proptest.AlmostEqual(got, 0.5, 1e-15) {
+		t.Errorf("Sin(π/6) = %g, %v; want 0.5", got, err)
+	}
+	if got, err := calc.Cos(math.Pi); err != nil || got != -1 {
+		t.Errorf("Cos(π) = %g, %v; want -1", got, err)
+	}
+	// No float64 is π/2, so the tangent there is finite
+	if got, err := calc.Tan(math.Pi / 2); err != nil || got < 1e15 {
+		t.Errorf("Tan(π/2) = %g, %v; want a large finite tangent", got, err)
+	}
+	if got, err := calc.ComputeTrig("cos", 60, calculator.Degrees); err != nil || !proptest.AlmostEqual(got, 0.5, 1e-15) {
+		t.Errorf("ComputeTrig(cos, 60, Degrees) = %g, %v; want 0.5", got, err)
+	}
+
+	for _, x := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		if _, err := calc.Sin(x); !errors.Is(err, calculator.ErrNotFinite) {
+			t.Errorf("Sin(%g) error = %v, want %v", x, err, calculator.ErrNotFinite)
+		}
+	}
+	var unknown *calculator.UnknownOperationError
+	if _, err := calc.ComputeTrig("cot", 1, calculator.Radians); !errors.As(err, &unknown) || unknown.Name != "cot" {
+		t.Errorf("ComputeTrig(cot) error = %v, want cot unknown", err)
+	}
+	var rangeErr *calculator.RangeError
+	if _, err := calc.ComputeTrig("sin", 1, calculator.AngleUnit(7)); !errors.As(err, &rangeErr) || rangeErr.Name != "angle unit" {
+		t.Errorf("ComputeTrig with unit 7 error = %v, want a *RangeError", err)
+	}
+
+	// An unknown unit keeps radians
+	ignored := calculator.NewCalculator(noOpBenchLogger{}, calculator.WithAngleUnit(calculator.AngleUnit(-1)))
+	if got, err := ignored.Cos(math.Pi); err != nil || got != -1 {
+		t.Errorf("Cos(π) with an unknown unit option = %g, %v; want -1", got, err)
+	}
+}
+
+// TestRoundTo tests rounding for display
+func TestRoundTo(t *testing.T) {
+	tests := []struct {
+		x      float64
+		places int
+		want   float64
+	}{
+		{0.49999999999999994, 10, 0.5},
+		{math.Pi, 2, 3.14},
+		{math.Pi, 4, 3.1416},
+		{-2.675, 1, -2.7},
+		{2.5, 0, 2},
+		{1234.5678, -3, 1235},
+		{1e-300, 2000, 1e-300},
+		{math.MaxFloat64, 2, math.MaxFloat64},
+	}
+	for _, tt := range tests {
+		if got := calculator.RoundTo(tt.x, tt.places); got != tt.want {
+			t.Errorf("RoundTo(%g, %d) = %g, want %g", tt.x, tt.places, got, tt.want)
+		}
+	}
+	if got := calculator.RoundTo(math.NaN(), 2); !math.IsNaN(got) {
+		t.Errorf("RoundTo(NaN, 2) = %g, want NaN", got)
+	}
+	if got := calculator.RoundTo(math.Inf(-1), 2); !math.IsInf(got, -1) {
+		t.Errorf("RoundTo(-Inf, 2) = %g, want -Inf", got)
+	}
+}
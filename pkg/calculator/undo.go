@@ -0,0 +1,89 @@
+package calculator
+
+import "maps"
+
+// WithUndo lets Undo and Redo walk back and forth through the last n
+// changes to the calculator: the operations performed by Compute and
+// ComputeStrict, including those of Evaluate and Chain, and the updates
+// of the memory register and variables. Each change keeps a copy of the
+// variables, so n bounds the memory used. A non-positive n disables
+// undo, which is the default.
+func WithUndo(n int) Option {
+	return func(c *Calculator) {
+		c.undoDepth = max(n, 0)
+	}
+}
+
+// Undo reverts the last change, restoring the result shown before it
+// along with the memory register and variables, and returns that
+// result, 0 before the first operation. Undone changes can be redone
+// until the next change. It returns an *UndoError when there is nothing
+// left to undo. It is safe for concurrent use.
+func (c *Calculator) Undo() (int, error) {
+	return c.step("undo", &c.state.undo, &c.state.redo)
+}
+
+// Redo performs the last change reverted by Undo again, and returns the
+// result it showed. It returns an *UndoError when nothing was undone
+// since the last change.
+func (c *Calculator) Redo() (int, error) {
+	return c.step("redo", &c.state.redo, &c.state.undo)
+}
+
+// undoEntry is what a calculator shows and remembers at some point: the
+// result of the last operation, the memory register and the variables
+type undoEntry struct {
+	result    int
+	memory    int
+	variables map[string]int
+}
+
+// step pops the entry to restore from from, pushing the current one on
+// to, for Undo and Redo
+func (c *Calculator) step(action string, from, to *[]undoEntry) (int, error) {
+	s := c.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(*from) == 0 {
+		err := &UndoError{Action: action}
+		c.log.Warnf("Cannot %s: %v", action, err)
+		return 0, err
+	}
+	entry := (*from)[len(*from)-1]
+	*from = (*from)[:len(*from)-1]
+	*to = append(*to, s.current())
+	s.result = entry.result
+	s.state.Memory = entry.memory
+	s.state.Variables = entry.variables
+	s.gen++
+	c.log.Debugf("%s: result %d, memory %d, %d steps left", action, entry.result, entry.memory, len(*from))
+	return entry.result, nil
+}
+
+// saveUndo pushes what the calculator shows on the undo stack before a
+// change, dropping the oldest entry beyond the depth of WithUndo, and
+// forgets the undone changes. c.state.mu must be held.
+func (c *Calculator) saveUndo() {
+	if c.undoDepth == 0 {
+		return
+	}
+	s := c.state
+	s.undo = append(s.undo, s.current())
+	if len(s.undo) > c.undoDepth {
+		s.undo = append(s.undo[:0], s.undo[len(s.undo)-c.undoDepth:]...)
+	}
+	s.redo = nil
+}
+
+// setResult makes result the one shown, as a change Undo reverts
+func (c *Calculator) setResult(result int) {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+	c.saveUndo()
+	c.state.result = result
+}
+
+// current returns what the calculator shows; s.mu must be held
+func (s *calcState) current() undoEntry {
+	return undoEntry{result: s.result, memory: s.state.Memory, variables: maps.Clone(s.state.Variables)}
+}
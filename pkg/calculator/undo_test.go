@@ -0,0 +1,115 @@
+package calculator_test
+
+import (
+	"errors"
+	"go-examples/pkg/calculator"
+	"maps"
+	"testing"
+)
+
+// TestUndoRedo tests walking back and forth through operations, memory
+// and variable updates
+func TestUndoRedo(t *testing.T) {
+	calc := calculator.NewCalculator(setupTestLogger(), calculator.WithUndo(10))
+	mustCompute := func(name string, a, b int) {
+		t.Helper()
+		if _, err := calc.Compute(name, a, b); err != nil {
+			t.Fatalf("Compute(%s, %d, %d) failed: %v", name, a, b, err)
+		}
+	}
+	mustCompute("add", 2, 3)
+	if _, err := calc.MemAdd(5); err != nil {
+		t.Fatal(err)
+	}
+	mustCompute("multiply", 5, 4)
+	if err := calc.SetVar("x", 20); err != nil {
+		t.Fatal(err)
+	}
+	// Failed operations change nothing, so there is nothing to undo
+	if _, err := calc.Compute("divide", 1, 0); err == nil {
+		t.Fatal("divide by zero succeeded")
+	}
+
+	steps := []struct {
+		result int
+		memory int
+		vars   map[string]int
+	}{
+		{20, 5, map[string]int{}}, // before SetVar
+		{5, 5, map[string]int{}},  // before multiply
+		{5, 0, map[string]int{}},  // before MemAdd
+		{0, 0, map[string]int{}},  // before add
+	}
+	for i, want := range steps {
+		got, err := calc.Undo()
+		if err != nil || got != want.result || calc.MemRecall() != want.memory || !maps.Equal(calc.Vars(), want.vars) {
+			t.Errorf("undo %d = %d, %v, memory %d, vars %v; want %d, memory %d, vars %v",
+				i+1, got, err, calc.MemRecall(), calc.Vars(), want.result, want.memory, want.vars)
+		}
+	}
+	var undoErr *calculator.UndoError
+	if _, err := calc.Undo(); !errors.As(err, &undoErr) || undoErr.Action != "undo" || !errors.Is(err, calculator.ErrNoUndo) {
+		t.Errorf("Undo past the beginning error = %v, want an *UndoError", err)
+	}
+
+	for i, want := range []int{5, 5, 20, 20} {
+		if got, err := calc.Redo(); err != nil || got != want {
+			t.Errorf("redo %d = %d, %v; want %d", i+1, got, err, want)
+		}
+	}
+	if got, ok := calc.GetVar("x"); !ok || got != 20 || calc.MemRecall() != 5 {
+		t.Errorf("after redoing everything x = %d, %t, memory %d; want 20 and 5", got, ok, calc.MemRecall())
+	}
+	if _, err := calc.Redo(); !errors.As(err, &undoErr) || undoErr.Action != "redo" {
+		t.Errorf("Redo past the end error = %v, want an *UndoError", err)
+	}
+}
+
+// TestUndoClearsRedo tests that a change after an undo forgets the
+// undone changes
+func TestUndoClearsRedo(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{}, calculator.WithUndo(10))
+	_, _ = calc.Compute("add", 1, 1)
+	_, _ = calc.Compute("add", 2, 2)
+	_, _ = calc.Compute("add", 3, 3)
+	if got, err := calc.Undo(); err != nil || got != 4 {
+		t.Fatalf("Undo = %d, %v; want 4", got, err)
+	}
+	if got, err := calc.Undo(); err != nil || got != 2 {
+		t.Fatalf("Undo = %d, %v; want 2", got, err)
+	}
+
+	_, _ = calc.Compute("multiply", 5, 5)
+	if _, err := calc.Redo(); !errors.Is(err, calculator.ErrNoUndo) {
+		t.Errorf("Redo after a new operation error = %v, want %v", err, calculator.ErrNoUndo)
+	}
+	if got, err := calc.Undo(); err != nil || got != 2 {
+		t.Errorf("Undo of the new operation = %d, %v; want 2", got, err)
+	}
+	if got, err := calc.Redo(); err != nil || got != 25 {
+		t.Errorf("Redo of the new operation = %d, %v; want 25", got, err)
+	}
+}
+
+// TestUndoDepth tests that only the last changes can be undone, and
+// that undo is off by default
+func TestUndoDepth(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{}, calculator.WithUndo(2))
+	for i := range 5 {
+		_, _ = calc.Compute("add", i, 0)
+	}
+	for _, want := range []int{3, 2} {
+		if got, err := calc.Undo(); err != nil || got != want {
+			t.Errorf("Undo = %d, %v; want %d", got, err, want)
+		}
+	}
+	if _, err := calc.Undo(); !errors.Is(err, calculator.ErrNoUndo) {
+		t.Errorf("Undo beyond the depth error = %v, want %v", err, calculator.ErrNoUndo)
+	}
+
+	plain := calculator.NewCalculator(noOpBenchLogger{})
+	_, _ = plain.Compute("add", 1, 2)
+	if _, err := plain.Undo(); !errors.Is(err, calculator.ErrNoUndo) {
+		t.Errorf("Undo without WithUndo error = %v, want %v", err, calculator.ErrNoUndo)
+	}
+}
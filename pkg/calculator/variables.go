@@ -0,0 +1,95 @@
+package calculator
+
+import (
+	"fmt"
+	"maps"
+)
+
+// MaxVarNameLength is the length limit of variable names
+const MaxVarNameLength = 64
+
+// WithMaxVars limits the variables of the calculator to n, so that
+// SetVar of a new name beyond them returns a *VariableError; variables
+// already set can still be updated. A non-positive n, the default,
+// leaves the number of variables unbounded.
+func WithMaxVars(n int) Option {
+	return func(c *Calculator) {
+		c.maxVars = max(n, 0)
+	}
+}
+
+// SetVar binds the variable name to value, replacing its previous value.
+// Names are case-sensitive identifiers: a letter or underscore followed
+// by letters, digits and underscores, up to MaxVarNameLength bytes, so
+// that they cannot be confused with numbers. It returns a *VariableError
+// for other names, and for a new name beyond the limit of WithMaxVars.
+// Variables are part of the State and are safe for concurrent use.
+func (c *Calculator) SetVar(name string, value int) error {
+	if reason := checkVarName(name); reason != "" {
+		name = shortenName(name)
+		err := &VariableError{Name: name, Reason: reason}
+		c.log.Errorf("Setting variable failed: %v", err)
+		return err
+	}
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+	vars := c.state.state.Variables
+	if _, ok := vars[name]; !ok && c.maxVars > 0 && len(vars) >= c.maxVars {
+		err := &VariableError{Name: name, Reason: fmt.Sprintf("more than %d variables", c.maxVars)}
+		c.log.Errorf("Setting variable failed: %v", err)
+		return err
+	}
+	if old, ok := vars[name]; !ok || old != value {
+		c.saveUndo()
+		if vars == nil {
+			vars = make(map[string]int)
+			c.state.state.Variables = vars
+		}
+		vars[name] = value
+		c.state.gen++
+	}
+	c.log.Debugf("Variable set: %s = %d", name, value)
+	return nil
+}
+
+// GetVar returns the value of the variable name, and whether it is set
+func (c *Calculator) GetVar(name string) (int, bool) {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+	value, ok := c.state.state.Variables[name]
+	return value, ok
+}
+
+// Vars returns a copy of the variables, by name
+func (c *Calculator) Vars() map[string]int {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+	vars := maps.Clone(c.state.state.Variables)
+	if vars == nil {
+		vars = make(map[string]int)
+	}
+	return vars
+}
+
+// checkVarName returns why name is not a valid variable name, or ""
+func checkVarName(name string) string {
+	switch {
+	case name == "":
+		return "empty name"
+	case len(name) > MaxVarNameLength:
+		return fmt.Sprintf("longer than %d bytes", MaxVarNameLength)
+	case isDigit(name[0]):
+		return "starts with a digit"
+	}
+	for i := range len(name) {
+		if !isIdentChar(name[i]) {
+			return "not an identifier"
+		}
+	}
+	return ""
+}
+
+// isIdentChar reports whether ch can appear in an identifier
+func isIdentChar(ch byte) bool {
+	return ch == '_' || 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || isDigit(ch)
+}
@@ -0,0 +1,136 @@
+package calculator_test
+
+import (
+	"errors"
+	"go-examples/pkg/calculator"
+	"maps"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestSetVar tests valid and invalid names, case sensitivity and
+// rebinding a name to a new value
+func TestSetVar(t *testing.T) {
+	calc := calculator.NewCalculator(setupTestLogger())
+	for _, name := range []string{"x", "X", "_tmp", "total2", "add", strings.Repeat("v", calculator.MaxVarNameLength)} {
+		if err := calc.SetVar(name, len(name)); err != nil {
+			t.Errorf("SetVar(%q) failed: %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "42", "1x", "x-y", "x y", "é", "mr!", strings.Repeat("v", calculator.MaxVarNameLength+1)} {
+		var varErr *calculator.VariableError
+		if err := calc.SetVar(name, 1); !errors.As(err, &varErr) || !errors.Is(err, calculator.ErrInvalidVariable) {
+			t.Errorf("SetVar(%q) error = %v, want a *VariableError", name, err)
+		}
+	}
+	// Long names are shortened in errors, between runes
+	var varErr *calculator.VariableError
+	if err := calc.SetVar("x"+strings.Repeat("é", 20), 1); !errors.As(err, &varErr) || varErr.Name != "x"+strings.Repeat("é", 7)+"..." {
+		t.Errorf("SetVar(xéé...) error = %v, want the name cut after 7 runes é", err)
+	}
+
+	// Names are case-sensitive, and setting a name again replaces it
+	if err := calc.SetVar("x", 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := calc.SetVar("X", 20); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := calc.GetVar("x"); !ok || got != 10 {
+		t.Errorf("GetVar(x) = %d, %t; want 10", got, ok)
+	}
+	if got, ok := calc.GetVar("X"); !ok || got != 20 {
+		t.Errorf("GetVar(X) = %d, %t; want 20", got, ok)
+	}
+	if got, ok := calc.GetVar("missing"); ok || got != 0 {
+		t.Errorf("GetVar(missing) = %d, %t; want unset", got, ok)
+	}
+
+	vars := calc.Vars()
+	if len(vars) != 6 || vars["x"] != 10 || vars["_tmp"] != 4 {
+		t.Errorf("Vars() = %v", vars)
+	}
+	vars["x"] = 99
+	if got, _ := calc.GetVar("x"); got != 10 {
+		t.Errorf("changing the map of Vars changed x to %d", got)
+	}
+	if vars := calculator.NewCalculator(noOpBenchLogger{}).Vars(); vars == nil || len(vars) != 0 {
+		t.Errorf("Vars() of a new calculator = %#v, want an empty map", vars)
+	}
+}
+
+// TestMaxVars tests that the limit refuses new names only
+func TestMaxVars(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{}, calculator.WithMaxVars(2))
+	for _, name := range []string{"a", "b"} {
+		if err := calc.SetVar(name, 1); err != nil {
+			t.Fatalf("SetVar(%s) failed: %v", name, err)
+		}
+	}
+	if err := calc.SetVar("c", 1); !errors.Is(err, calculator.ErrInvalidVariable) {
+		t.Errorf("SetVar of a third variable error = %v, want %v", err, calculator.ErrInvalidVariable)
+	}
+	if err := calc.SetVar("a", 2); err != nil {
+		t.Errorf("updating a variable at the limit failed: %v", err)
+	}
+	if vars := calc.Vars(); !maps.Equal(vars, map[string]int{"a": 2, "b": 1}) {
+		t.Errorf("Vars() = %v, want a=2 b=1", vars)
+	}
+}
+
+// TestEvaluateVariables tests variables in expressions, including one
+// named like an operation, and unknown variables
+func TestEvaluateVariables(t *testing.T) {
+	calc := calculator.NewCalculator(setupTestLogger())
+	for name, value := range map[string]int{"price": 250, "qty": 4, "add": 1, "Price": 1} {
+		if err := calc.SetVar(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		expr string
+		want int
+	}{
+		{"price * qty", 1000},
+		{"price*qty - Price", 999},
+		{"-(price + add) / 3", -83},
+		{"add + add", 2},
+	}
+	for _, tt := range tests {
+		if got, err := calc.Evaluate(tt.expr); err != nil || got != tt.want {
+			t.Errorf("Evaluate(%q) = %d, %v; want %d", tt.expr, got, err, tt.want)
+		}
+	}
+
+	var unknown *calculator.UnknownVariableError
+	if _, err := calc.Evaluate("price * PRICE"); !errors.As(err, &unknown) || unknown.Name != "PRICE" {
+		t.Errorf("Evaluate with an unknown variable error = %v, want PRICE unknown", err)
+	}
+	if _, err := calc.Evaluate("price qty"); !errors.Is(err, calculator.ErrSyntax) {
+		t.Errorf("Evaluate(price qty) error = %v, want %v", err, calculator.ErrSyntax)
+	}
+}
+
+// TestVarsConcurrent sets and reads variables from many goroutines; run
+// it with -race
+func TestVarsConcurrent(t *testing.T) {
+	calc := calculator.NewCalculator(noOpBenchLogger{}, calculator.WithMaxVars(10))
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 200 {
+				_ = calc.SetVar("v"+strconv.Itoa((g+i)%20), i)
+				calc.GetVar("v" + strconv.Itoa(i%20))
+				_ = calc.Vars()
+			}
+		}()
+	}
+	wg.Wait()
+	if n := len(calc.Vars()); n != 10 {
+		t.Errorf("%d variables after concurrent updates, want the limit of 10", n)
+	}
+}
@@ -0,0 +1,45 @@
+package httpmw
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// Auth returns middleware that rejects requests for which check returns
+// false with a JSON 401 response. Requests to skipPaths, such as health
+// checks, are let through unchecked.
+func Auth(check func(r *http.Request) bool, skipPaths ...string) Middleware {
+	skip := make(map[string]bool, len(skipPaths))
+	for _, p := range skipPaths {
+		skip[p] = true
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !skip[r.URL.Path] && !check(r) {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeError(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// BearerToken returns a check for Auth that accepts requests whose
+// Authorization header is "Bearer <token>" for one of tokens. Tokens are
+// compared in constant time.
+func BearerToken(tokens ...string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || got == "" {
+			return false
+		}
+		for _, token := range tokens {
+			if subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1 {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,49 @@
+package httpmw_test
+
+import (
+	"go-examples/pkg/httpmw"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestAuth tests bearer tokens, missing credentials and skipped paths
+func TestAuth(t *testing.T) {
+	handler := httpmw.Auth(httpmw.BearerToken("t1", "t2"), "/health")(okHandler)
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		want   int
+	}{
+		{"first token", "/calculate", "Bearer t1", http.StatusOK},
+		{"second token", "/calculate", "Bearer t2", http.StatusOK},
+		{"wrong token", "/calculate", "Bearer t3", http.StatusUnauthorized},
+		{"empty token", "/calculate", "Bearer ", http.StatusUnauthorized},
+		{"wrong scheme", "/calculate", "Basic dDE6", http.StatusUnauthorized},
+		{"missing", "/calculate", "", http.StatusUnauthorized},
+		{"skipped path", "/health", "", http.StatusOK},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tc.path, nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tc.want {
+				t.Errorf("status = %d, want %d", rec.Code, tc.want)
+			}
+			if tc.want == http.StatusUnauthorized {
+				if rec.Header().Get("WWW-Authenticate") != "Bearer" {
+					t.Error("expected a WWW-Authenticate challenge")
+				}
+				if body := rec.Body.String(); body != `{"success":false,"error":"unauthorized"}`+"\n" {
+					t.Errorf("unexpected body %q", body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package httpmw
+
+import (
+	"bufio"
+	"fmt"
+	"go-examples/pkg/slogger"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// DefaultRedactFields are the JSON fields whose values CaptureBodies
+// hides unless WithRedactFields says otherwise
+var DefaultRedactFields = []string{"api_key", "token"}
+
+// CaptureOption configures CaptureBodies
+type CaptureOption func(*captureConfig)
+
+// captureConfig holds the settings of CaptureBodies
+type captureConfig struct {
+	redact *regexp.Regexp
+}
+
+// WithRedactFields sets the JSON fields whose values are replaced with
+// "[REDACTED]" before bodies are logged, at any depth. Fields are
+// matched by exact name.
+func WithRedactFields(fields ...string) CaptureOption {
+	return func(c *captureConfig) {
+		c.redact = redactPattern(fields)
+	}
+}
+
+// redactPattern matches a JSON member named one of fields, capturing the
+// name and colon. The value may be cut short by truncation.
+func redactPattern(fields []string) *regexp.Regexp {
+	if len(fields) == 0 {
+		return nil
+	}
+	quoted := make([]string, len(fields))
+	for i, f := range fields {
+		quoted[i] = regexp.QuoteMeta(f)
+	}
+	return regexp.MustCompile(`("(?:` + strings.Join(quoted, "|") + `)"\s*:\s*)(?:"(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`)
+}
+
+// CaptureBodies returns middleware that logs up to limit bytes of the
+// request and response bodies of each request at Debug, with the
+// request ID set by RequestID or Logging, for debugging what clients
+// actually sent and received. The request body is copied as the handler
+// reads it, so the handler still gets all of it. Responses that are
+// flushed or hijacked are streaming, so their capture stops and only the
+// request body is logged. Nothing is captured while l has Debug off.
+func CaptureBodies(l slogger.Logger, limit int, opts ...CaptureOption) Middleware {
+	cfg := &captureConfig{redact: redactPattern(DefaultRedactFields)}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if limit <= 0 || !l.Handler().Enabled(r.Context(), slog.LevelDebug) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			reqBody := &capturedBody{limit: limit}
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = &teeReadCloser{ReadCloser: r.Body, capture: reqBody}
+			}
+			cw := &captureWriter{ResponseWriter: w, body: &capturedBody{limit: limit}}
+			next.ServeHTTP(cw, r)
+
+			args := []any{
+				"request_id", w.Header().Get(slogger.RequestIDHeader),
+				"method", r.Method,
+				"path", r.URL.Path,
+			}
+			args = append(args, reqBody.attrs("request", cfg.redact)...)
+			if cw.streaming {
+				args = append(args, "response_streaming", true)
+			} else {
+				args = append(args, cw.body.attrs("response", cfg.redact)...)
+			}
+			l.DebugContext(r.Context(), "HTTP bodies", args...)
+		})
+	}
+}
+
+// capturedBody keeps the first limit bytes written to it and counts all
+// of them
+type capturedBody struct {
+	limit int
+	data  []byte
+	total int64
+}
+
+func (b *capturedBody) Write(p []byte) (int, error) {
+	if room := b.limit - len(b.data); room > 0 {
+		if len(p) < room {
+			room = len(p)
+		}
+		b.data = append(b.data, p[:room]...)
+	}
+	b.total += int64(len(p))
+	return len(p), nil
+}
+
+// attrs returns the log attributes describing the body, prefixed with
+// side, after redaction
+func (b *capturedBody) attrs(side string, redact *regexp.Regexp) []any {
+	body := string(b.data)
+	if redact != nil {
+		body = redact.ReplaceAllString(body, `${1}"[REDACTED]"`)
+	}
+	return []any{
+		side + "_body", body,
+		side + "_bytes", b.total,
+		side + "_truncated", b.total > int64(len(b.data)),
+	}
+}
+
+// teeReadCloser copies what is read from the request body to capture
+type teeReadCloser struct {
+	io.ReadCloser
+	capture *capturedBody
+}
+
+func (t *teeReadCloser) Read(p []byte) (int, error) {
+	n, err := t.ReadCloser.Read(p)
+	if n > 0 {
+		_, _ = t.capture.Write(p[:n])
+	}
+	return n, err
+}
+
+// captureWriter copies the response body to body until the handler
+// starts streaming
+type captureWriter struct {
+	http.ResponseWriter
+	body      *capturedBody
+	streaming bool
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	if !w.streaming {
+		_, _ = w.body.Write(b[:n])
+	}
+	return n, err
+}
+
+// Flush stops capturing and flushes the underlying writer, when it
+// supports http.Flusher
+func (w *captureWriter) Flush() {
+	w.streaming = true
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack stops capturing and hands over the connection, when the
+// underlying writer supports http.Hijacker
+func (w *captureWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("httpmw: %T does not support hijacking: %w", w.ResponseWriter, http.ErrNotSupported)
+	}
+	w.streaming = true
+	return h.Hijack()
+}
+
+// Unwrap returns the wrapped writer for use by http.ResponseController
+func (w *captureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
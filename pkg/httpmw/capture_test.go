@@ -0,0 +1,157 @@
+package httpmw_test
+
+import (
+	"bytes"
+	"go-examples/pkg/httpmw"
+	"go-examples/pkg/slogger"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// echoHandler answers with the request body it read in full
+var echoHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	_, _ = w.Write(body)
+})
+
+// capture sends body through CaptureBodies around handler and returns
+// the response and the logged record, if any
+func capture(t *testing.T, handler http.Handler, body string, limit int, opts ...httpmw.CaptureOption) (*httptest.ResponseRecorder, map[string]any) {
+	t.Helper()
+	var buf bytes.Buffer
+	log := slogger.New(slogger.WithWriter(&buf), slogger.WithJSON(), slogger.WithLevel(slog.LevelDebug))
+	h := httpmw.Chain(httpmw.RequestID(), httpmw.CaptureBodies(log, limit, opts...))(handler)
+
+	req := httptest.NewRequest("POST", "/calculate", strings.NewReader(body))
+	req.Header.Set(slogger.RequestIDHeader, "req-7")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if buf.Len() == 0 {
+		return rec, nil
+	}
+	return rec, lastRecord(t, &buf)
+}
+
+// TestCaptureBodies tests that both bodies are logged with the request ID
+// and that the handler reads the full request body
+func TestCaptureBodies(t *testing.T) {
+	body := `{"operation":"add","a":1,"b":2}`
+	rec, record := capture(t, echoHandler, body, 1024)
+
+	if rec.Body.String() != body {
+		t.Errorf("handler echoed %q, want the full body", rec.Body.String())
+	}
+	want := map[string]any{
+		"msg":                "HTTP bodies",
+		"level":              "DEBUG",
+		"request_id":         "req-7",
+		"path":               "/calculate",
+		"request_body":       body,
+		"request_bytes":      float64(len(body)),
+		"request_truncated":  false,
+		"response_body":      body,
+		"response_truncated": false,
+	}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("%s = %v, want %v", key, record[key], value)
+		}
+	}
+}
+
+// TestCaptureTruncates tests that bodies are cut at the limit while the
+// handler still receives everything
+func TestCaptureTruncates(t *testing.T) {
+	body := strings.Repeat("0123456789", 10)
+	rec, record := capture(t, echoHandler, body, 16)
+
+	if rec.Body.String() != body {
+		t.Errorf("handler received %d bytes, want %d", rec.Body.Len(), len(body))
+	}
+	for _, side := range []string{"request", "response"} {
+		if record[side+"_body"] != body[:16] || record[side+"_bytes"] != float64(100) || record[side+"_truncated"] != true {
+			t.Errorf("unexpected %s capture in %v", side, record)
+		}
+	}
+}
+
+// TestCaptureRedacts tests redaction of default and configured fields,
+// including values cut by truncation
+func TestCaptureRedacts(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		limit int
+		opts  []httpmw.CaptureOption
+		want  string
+	}{
+		{
+			name:  "default fields",
+			body:  `{"api_key": "k-123", "nested": {"token":"t\"x"}, "a": 1}`,
+			limit: 1024,
+			want:  `{"api_key": "[REDACTED]", "nested": {"token":"[REDACTED]"}, "a": 1}`,
+		},
+		{
+			name:  "non-string values",
+			body:  `{"token":12345,"b":[1]}`,
+			limit: 1024,
+			want:  `{"token":"[REDACTED]","b":[1]}`,
+		},
+		{
+			name:  "value cut by the limit",
+			body:  `{"a":1,"token":"secret-value"}`,
+			limit: 20,
+			want:  `{"a":1,"token":"[REDACTED]"`,
+		},
+		{
+			name:  "configured fields",
+			body:  `{"password":"hunter2","token":"kept"}`,
+			limit: 1024,
+			opts:  []httpmw.CaptureOption{httpmw.WithRedactFields("password")},
+			want:  `{"password":"[REDACTED]","token":"kept"}`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, record := capture(t, echoHandler, tc.body, tc.limit, tc.opts...)
+			if record["request_body"] != tc.want || record["response_body"] != tc.want {
+				t.Errorf("logged %q and %q, want %q", record["request_body"], record["response_body"], tc.want)
+			}
+		})
+	}
+}
+
+// TestCaptureStreaming tests that a flushing handler bypasses response
+// capture and still streams
+func TestCaptureStreaming(t *testing.T) {
+	streaming := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("event: 1\n"))
+		w.(http.Flusher).Flush()
+		_, _ = w.Write([]byte("event: 2\n"))
+	})
+	rec, record := capture(t, streaming, `{}`, 1024)
+
+	if !rec.Flushed || rec.Body.String() != "event: 1\nevent: 2\n" {
+		t.Errorf("expected the flushed stream, got flushed=%v body %q", rec.Flushed, rec.Body.String())
+	}
+	if _, ok := record["response_body"]; ok || record["response_streaming"] != true {
+		t.Errorf("expected no response capture, got %v", record)
+	}
+}
+
+// TestCaptureDisabled tests that nothing is logged without Debug or with
+// a zero limit
+func TestCaptureDisabled(t *testing.T) {
+	var buf bytes.Buffer
+	info := slogger.New(slogger.WithWriter(&buf), slogger.WithJSON(), slogger.WithLevel(slog.LevelInfo))
+	req := httptest.NewRequest("POST", "/", strings.NewReader("body"))
+	httpmw.CaptureBodies(info, 1024)(echoHandler).ServeHTTP(httptest.NewRecorder(), req)
+
+	if _, record := capture(t, echoHandler, "body", 0); record != nil || buf.Len() != 0 {
+		t.Errorf("expected no capture, got %v %s", record, buf.String())
+	}
+}
@@ -0,0 +1,71 @@
+package httpmw
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// CORSConfig configures CORS
+type CORSConfig struct {
+	// AllowedOrigins lists the origins allowed to call the API. "*"
+	// allows any origin.
+	AllowedOrigins []string
+	// AllowedMethods are answered to preflight requests. Empty means
+	// GET, POST and OPTIONS.
+	AllowedMethods []string
+	// AllowedHeaders are answered to preflight requests. Empty means
+	// Content-Type, Authorization and X-Request-ID.
+	AllowedHeaders []string
+	// MaxAge lets browsers cache preflight results, in seconds. Zero
+	// leaves the header out.
+	MaxAge int
+}
+
+// CORS returns middleware that adds CORS headers for allowed origins and
+// answers preflight requests with 204 without calling the next handler.
+// Requests from other origins get no CORS headers, so browsers block
+// them; non-browser clients are unaffected.
+func CORS(cfg CORSConfig) Middleware {
+	allowed := make(map[string]bool, len(cfg.AllowedOrigins))
+	for _, o := range cfg.AllowedOrigins {
+		allowed[o] = true
+	}
+	methods := strings.Join(orDefault(cfg.AllowedMethods, "GET", "POST", "OPTIONS"), ", ")
+	headers := strings.Join(orDefault(cfg.AllowedHeaders, "Content-Type", "Authorization", "X-Request-ID"), ", ")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			w.Header().Add("Vary", "Origin")
+			if origin == "" || !(allowed["*"] || allowed[origin]) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if allowed["*"] {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+
+			if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("Access-Control-Allow-Methods", methods)
+			w.Header().Set("Access-Control-Allow-Headers", headers)
+			if cfg.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(cfg.MaxAge))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// orDefault returns values, or def when values is empty
+func orDefault(values []string, def ...string) []string {
+	if len(values) == 0 {
+		return def
+	}
+	return values
+}
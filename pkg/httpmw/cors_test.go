@@ -0,0 +1,71 @@
+package httpmw_test
+
+import (
+	"go-examples/pkg/httpmw"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestCORS tests simple and preflight requests from allowed and other origins
+func TestCORS(t *testing.T) {
+	cfg := httpmw.CORSConfig{AllowedOrigins: []string{"https://app.example.com"}, MaxAge: 600}
+	tests := []struct {
+		name        string
+		method      string
+		origin      string
+		preflight   bool
+		wantStatus  int
+		wantOrigin  string
+		wantMethods string
+		wantMaxAge  string
+	}{
+		{"simple allowed", "GET", "https://app.example.com", false, http.StatusOK, "https://app.example.com", "", ""},
+		{"simple other origin", "GET", "https://evil.example.com", false, http.StatusOK, "", "", ""},
+		{"no origin", "GET", "", false, http.StatusOK, "", "", ""},
+		{"preflight allowed", "OPTIONS", "https://app.example.com", true, http.StatusNoContent, "https://app.example.com", "GET, POST, OPTIONS", "600"},
+		{"preflight other origin", "OPTIONS", "https://evil.example.com", true, http.StatusOK, "", "", ""},
+		{"options without preflight", "OPTIONS", "https://app.example.com", false, http.StatusOK, "https://app.example.com", "", ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/calculate", nil)
+			if tc.origin != "" {
+				req.Header.Set("Origin", tc.origin)
+			}
+			if tc.preflight {
+				req.Header.Set("Access-Control-Request-Method", "POST")
+			}
+			rec := httptest.NewRecorder()
+			httpmw.CORS(cfg)(okHandler).ServeHTTP(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tc.wantOrigin {
+				t.Errorf("Allow-Origin = %q, want %q", got, tc.wantOrigin)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Methods"); got != tc.wantMethods {
+				t.Errorf("Allow-Methods = %q, want %q", got, tc.wantMethods)
+			}
+			if got := rec.Header().Get("Access-Control-Max-Age"); got != tc.wantMaxAge {
+				t.Errorf("Max-Age = %q, want %q", got, tc.wantMaxAge)
+			}
+			if rec.Header().Get("Vary") != "Origin" {
+				t.Errorf("expected Vary: Origin, got %q", rec.Header().Get("Vary"))
+			}
+		})
+	}
+}
+
+// TestCORSWildcard tests that "*" allows any origin
+func TestCORSWildcard(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Origin", "https://anywhere.example.com")
+	rec := httptest.NewRecorder()
+	httpmw.CORS(httpmw.CORSConfig{AllowedOrigins: []string{"*"}})(okHandler).ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Allow-Origin = %q, want *", got)
+	}
+}
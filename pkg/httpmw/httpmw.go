@@ -0,0 +1,52 @@
+// Package httpmw provides net/http middleware for request IDs, logging,
+// body capture, panic recovery, CORS, authentication, rate limiting,
+// metrics and mirroring requests to a shadow service. It depends only on net/http and the slogger, ratelimit and
+// metrics packages, so it works with any router.
+//
+// Order matters for several pairs. Chain applies middleware in the order
+// given, the first being outermost, and the recommended order is:
+//
+//	httpmw.Chain(
+//		httpmw.Recovery(log),            // outermost: catches panics in everything below
+//		httpmw.RequestID(),              // early: Logging and Recovery report its ID
+//		httpmw.Metrics(m),               // before Logging: times and counts the whole chain
+//		httpmw.Logging(log),             // logs rejections by the middleware below
+//		httpmw.CaptureBodies(log, 4096), // after RequestID: logs its ID
+//		httpmw.CORS(cors),               // before Auth: preflight requests carry no credentials
+//		httpmw.RateLimit(10, 20),        // before Auth: limits failed attempts too
+//		httpmw.Auth(check),
+//		httpmw.Shadow(u, 10, compare),   // innermost: mirrors accepted requests with their ID
+//	)
+package httpmw
+
+import (
+	"net/http"
+	"time"
+)
+
+// Middleware wraps an http.Handler. It is an alias, so functions such as
+// slogger.Middleware can be used directly.
+type Middleware = func(http.Handler) http.Handler
+
+// Now returns the current time. It is a variable to allow tests to
+// control rate limiting and the durations recorded by Metrics.
+var Now = time.Now
+
+// Chain composes mw into one Middleware. Requests pass through mw in the
+// order given, so the first is the outermost.
+func Chain(mw ...Middleware) Middleware {
+	return func(next http.Handler) http.Handler {
+		for i := len(mw) - 1; i >= 0; i-- {
+			next = mw[i](next)
+		}
+		return next
+	}
+}
+
+// writeError sends a JSON error body in the format used by the
+// calculator API
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(`{"success":false,"error":"` + message + `"}` + "\n"))
+}
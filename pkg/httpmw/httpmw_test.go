@@ -0,0 +1,153 @@
+package httpmw_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-examples/pkg/httpmw"
+	"go-examples/pkg/slogger"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// okHandler answers every request with 200
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+// recorder returns middleware appending name to calls on the way in and
+// "/"+name on the way out
+func recorder(calls *[]string, name string) httpmw.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*calls = append(*calls, name)
+			next.ServeHTTP(w, r)
+			*calls = append(*calls, "/"+name)
+		})
+	}
+}
+
+// installFakeClock replaces httpmw.Now for one test
+func installFakeClock(t *testing.T) *time.Time {
+	t.Helper()
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	orig := httpmw.Now
+	httpmw.Now = func() time.Time { return now }
+	t.Cleanup(func() { httpmw.Now = orig })
+	return &now
+}
+
+// TestChainOrder tests that requests pass through middleware in the
+// order given
+func TestChainOrder(t *testing.T) {
+	var calls []string
+	handler := httpmw.Chain(recorder(&calls, "a"), recorder(&calls, "b"), recorder(&calls, "c"))(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			calls = append(calls, "handler")
+		}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	want := []string{"a", "b", "c", "handler", "/c", "/b", "/a"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+// TestChainEmpty tests that an empty chain returns the handler itself
+func TestChainEmpty(t *testing.T) {
+	rec := httptest.NewRecorder()
+	httpmw.Chain()(okHandler).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rec.Code)
+	}
+}
+
+// TestRecommendedOrder tests the ordering-sensitive pairs of the
+// documented chain: recovery outermost still reports the request ID,
+// RequestID and Logging agree on the ID, and Metrics and Logging both see
+// requests rejected further in
+func TestRecommendedOrder(t *testing.T) {
+	var buf bytes.Buffer
+	log := slogger.New(slogger.WithWriter(&buf), slogger.WithJSON())
+	var m httpmw.RequestMetrics
+	handler := httpmw.Chain(
+		httpmw.Recovery(log),
+		httpmw.RequestID(),
+		httpmw.Metrics(&m),
+		httpmw.Logging(log),
+		httpmw.CORS(httpmw.CORSConfig{AllowedOrigins: []string{"*"}}),
+		httpmw.RateLimit(1, 1, httpmw.WithKeyFunc(func(*http.Request) string { return "all" })),
+		httpmw.Auth(httpmw.BearerToken("secret")),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/panic" {
+			panic("boom")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	installFakeClock(t)
+
+	// A request without credentials is rejected by Auth
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/calculate", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rec.Code)
+	}
+	id := rec.Header().Get(slogger.RequestIDHeader)
+	access := lastRecord(t, &buf)
+	if access["msg"] != "access" || access["status"] != float64(401) || access["request_id"] != id {
+		t.Errorf("expected the 401 to be logged with request ID %s, got %v", id, access)
+	}
+
+	// The bucket is now empty, so the next request is rate limited
+	// before reaching Auth
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/calculate", nil))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rec.Code)
+	}
+
+	snap := m.Snapshot()
+	if snap.ByStatus[http.StatusUnauthorized] != 1 || snap.ByStatus[http.StatusTooManyRequests] != 1 {
+		t.Errorf("expected the rejections to be counted, got %v", snap.ByStatus)
+	}
+}
+
+// TestRecoveryOutermost tests that a panic below every other middleware
+// is recovered and logged with the request ID
+func TestRecoveryOutermost(t *testing.T) {
+	var buf bytes.Buffer
+	log := slogger.New(slogger.WithWriter(&buf), slogger.WithJSON())
+	var m httpmw.RequestMetrics
+	handler := httpmw.Chain(httpmw.Recovery(log), httpmw.RequestID(), httpmw.Metrics(&m), httpmw.Logging(log))(
+		http.HandlerFunc(func(http.ResponseWriter, *http.Request) { panic("boom") }))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(slogger.RequestIDHeader, "req-9")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", rec.Code)
+	}
+	record := lastRecord(t, &buf)
+	if record["msg"] != "panic recovered" || record["request_id"] != "req-9" {
+		t.Errorf("expected a panic record for req-9, got %v", record)
+	}
+	if got := m.Snapshot().ByStatus[http.StatusInternalServerError]; got != 1 {
+		t.Errorf("expected the panic to count as a 500, got %d", got)
+	}
+}
+
+// lastRecord decodes the last JSON log line in buf
+func lastRecord(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var record map[string]any
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &record); err != nil {
+		t.Fatalf("invalid log line %q: %v", lines[len(lines)-1], err)
+	}
+	return record
+}
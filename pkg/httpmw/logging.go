@@ -0,0 +1,18 @@
+package httpmw
+
+import "go-examples/pkg/slogger"
+
+// Logging returns middleware that logs an access line for every request
+// and stores a request-scoped logger in the context, using
+// slogger.Middleware. Behind RequestID it logs the same request ID.
+func Logging(l slogger.Logger, opts ...slogger.MiddlewareOption) Middleware {
+	return slogger.Middleware(l, opts...)
+}
+
+// Recovery returns middleware that logs panics and answers with a JSON
+// 500 response, using slogger.RecoveryMiddleware. Placed outermost, it
+// also recovers panics raised by other middleware; the request then has
+// no access line, since Logging never sees it complete.
+func Recovery(l slogger.Logger, opts ...slogger.RecoverOption) Middleware {
+	return slogger.RecoveryMiddleware(l, opts...)
+}
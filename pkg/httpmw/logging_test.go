@@ -0,0 +1,47 @@
+package httpmw_test
+
+import (
+	"bytes"
+	"go-examples/pkg/httpmw"
+	"go-examples/pkg/slogger"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestLogging tests the access line and the request-scoped logger
+func TestLogging(t *testing.T) {
+	var buf bytes.Buffer
+	log := slogger.New(slogger.WithWriter(&buf), slogger.WithJSON())
+	handler := httpmw.Logging(log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slogger.FromContext(r.Context()).Info("handling")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	req := httptest.NewRequest("POST", "/calculate", nil)
+	req.Header.Set(slogger.RequestIDHeader, "req-2")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	record := lastRecord(t, &buf)
+	if record["msg"] != "access" || record["status"] != float64(http.StatusAccepted) || record["request_id"] != "req-2" {
+		t.Errorf("unexpected access record %v", record)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(`"msg":"handling","request_id":"req-2"`)) {
+		t.Errorf("expected the handler's line to carry the request ID, got %s", buf.String())
+	}
+}
+
+// TestRecovery tests the 500 response for a panicking handler
+func TestRecovery(t *testing.T) {
+	var buf bytes.Buffer
+	log := slogger.New(slogger.WithWriter(&buf), slogger.WithJSON())
+	handler := httpmw.Recovery(log)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { panic("boom") }))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", rec.Code)
+	}
+	if record := lastRecord(t, &buf); record["panic"] != "boom" {
+		t.Errorf("unexpected panic record %v", record)
+	}
+}
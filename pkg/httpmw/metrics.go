@@ -0,0 +1,115 @@
+package httpmw
+
+import (
+	"go-examples/pkg/metrics"
+	"go-examples/pkg/slogger"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RequestMetrics collects request counts and latency. The zero value is
+// ready to use and safe for concurrent use.
+type RequestMetrics struct {
+	mu       sync.Mutex
+	inFlight int
+	byStatus map[int]uint64
+	total    uint64
+	duration time.Duration
+
+	// Instruments created by NewRequestMetrics; nil for the zero value
+	requests      metrics.Counter
+	latency       metrics.Histogram
+	inFlightGauge metrics.Gauge
+}
+
+// NewRequestMetrics returns a RequestMetrics that also records requests
+// through p, as http_requests_total by method and code,
+// http_request_duration_seconds by method, and http_requests_in_flight
+func NewRequestMetrics(p metrics.Provider) *RequestMetrics {
+	p = metrics.OrNoop(p)
+	return &RequestMetrics{
+		requests:      p.Counter("http_requests_total", "Completed HTTP requests.", "method", "code"),
+		latency:       p.Histogram("http_request_duration_seconds", "HTTP request latency in seconds.", nil, "method"),
+		inFlightGauge: p.Gauge("http_requests_in_flight", "HTTP requests being served."),
+	}
+}
+
+// MetricsSnapshot is a copy of the values collected by RequestMetrics
+type MetricsSnapshot struct {
+	InFlight      int            // requests being served
+	Requests      uint64         // completed requests
+	ByStatus      map[int]uint64 // completed requests per status code
+	TotalDuration time.Duration  // summed duration of completed requests
+}
+
+// Snapshot returns the current values
+func (m *RequestMetrics) Snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := MetricsSnapshot{
+		InFlight:      m.inFlight,
+		Requests:      m.total,
+		ByStatus:      make(map[int]uint64, len(m.byStatus)),
+		TotalDuration: m.duration,
+	}
+	for status, n := range m.byStatus {
+		s.ByStatus[status] = n
+	}
+	return s
+}
+
+// Metrics returns middleware that records every request in m. A request
+// whose handler panics is counted as a 500.
+func Metrics(m *RequestMetrics) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := Now()
+			m.mu.Lock()
+			m.inFlight++
+			m.mu.Unlock()
+			if m.inFlightGauge != nil {
+				m.inFlightGauge.Add(1)
+			}
+
+			rw := slogger.WrapResponseWriter(w)
+			completed := false
+			defer func() {
+				elapsed := Now().Sub(start)
+				status := rw.Status()
+				if !completed {
+					status = http.StatusInternalServerError
+				}
+				if m.requests != nil {
+					method := methodLabel(r.Method)
+					m.inFlightGauge.Add(-1)
+					m.requests.Add(1, method, strconv.Itoa(status))
+					m.latency.Observe(elapsed.Seconds(), method)
+				}
+				m.mu.Lock()
+				defer m.mu.Unlock()
+				m.inFlight--
+				if m.byStatus == nil {
+					m.byStatus = map[int]uint64{}
+				}
+				m.byStatus[status]++
+				m.total++
+				m.duration += elapsed
+			}()
+			next.ServeHTTP(rw, r)
+			completed = true
+		})
+	}
+}
+
+// methodLabel returns method for the standard methods and "OTHER"
+// otherwise, so clients cannot create unbounded label values
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "OTHER"
+}
@@ -0,0 +1,87 @@
+package httpmw_test
+
+import (
+	"go-examples/pkg/httpmw"
+	"go-examples/pkg/metrics/prommetrics"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// TestMetrics tests counts per status, durations and in-flight requests
+func TestMetrics(t *testing.T) {
+	now := installFakeClock(t)
+	var m httpmw.RequestMetrics
+	var inFlight int
+	handler := httpmw.Metrics(&m)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inFlight = m.Snapshot().InFlight
+		*now = now.Add(10 * time.Millisecond)
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	for _, path := range []string{"/", "/", "/missing"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	snap := m.Snapshot()
+	if inFlight != 1 || snap.InFlight != 0 {
+		t.Errorf("in flight = %d during and %d after, want 1 and 0", inFlight, snap.InFlight)
+	}
+	if snap.Requests != 3 || snap.ByStatus[http.StatusOK] != 2 || snap.ByStatus[http.StatusNotFound] != 1 {
+		t.Errorf("unexpected counts %+v", snap)
+	}
+	if snap.TotalDuration != 30*time.Millisecond {
+		t.Errorf("TotalDuration = %s, want 30ms", snap.TotalDuration)
+	}
+
+	// Snapshots are copies
+	snap.ByStatus[http.StatusOK] = 100
+	if m.Snapshot().ByStatus[http.StatusOK] != 2 {
+		t.Error("modifying a snapshot changed the metrics")
+	}
+}
+
+// TestNewRequestMetrics tests that requests are also recorded through the
+// provider, with non-standard methods grouped under OTHER
+func TestNewRequestMetrics(t *testing.T) {
+	installFakeClock(t)
+	reg := prometheus.NewRegistry()
+	m := httpmw.NewRequestMetrics(prommetrics.New(reg))
+	handler := httpmw.Metrics(m)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+
+	for _, method := range []string{"GET", "GET", "BREW"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/", nil))
+	}
+
+	want := `
+# HELP http_requests_in_flight HTTP requests being served.
+# TYPE http_requests_in_flight gauge
+http_requests_in_flight 0
+# HELP http_requests_total Completed HTTP requests.
+# TYPE http_requests_total counter
+http_requests_total{code="200",method="GET"} 2
+http_requests_total{code="405",method="OTHER"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "http_requests_total", "http_requests_in_flight"); err != nil {
+		t.Error(err)
+	}
+	if n := testutil.CollectAndCount(reg, "http_request_duration_seconds"); n != 2 {
+		t.Errorf("got %d latency series, want 2", n)
+	}
+	if m.Snapshot().Requests != 3 {
+		t.Errorf("snapshot counted %d requests, want 3", m.Snapshot().Requests)
+	}
+}
@@ -0,0 +1,81 @@
+package httpmw
+
+import (
+	"go-examples/pkg/ratelimit"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxBuckets is the number of clients tracked before refilled buckets
+// are evicted
+const maxBuckets = 1024
+
+// RateLimitOption configures RateLimit
+type RateLimitOption func(*rateLimiter)
+
+// WithKeyFunc sets how requests are grouped into buckets. The default is
+// the client IP from RemoteAddr; return a constant for a global limit.
+func WithKeyFunc(fn func(r *http.Request) string) RateLimitOption {
+	return func(l *rateLimiter) {
+		l.key = fn
+	}
+}
+
+// RateLimit returns middleware that allows each client perSecond
+// requests per second on average, with bursts of up to burst requests,
+// using a token bucket per client from pkg/ratelimit. Requests over the
+// limit get a JSON 429 response with a Retry-After header.
+func RateLimit(perSecond float64, burst int, opts ...RateLimitOption) Middleware {
+	l := &rateLimiter{
+		limiters: ratelimit.NewKeyed(perSecond, burst, ratelimit.WithClock(clock{}), ratelimit.WithMaxKeys(maxBuckets)),
+		key:      clientIP,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if wait, ok := l.allow(l.key(r)); !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				writeError(w, http.StatusTooManyRequests, "rate limit exceeded")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rateLimiter holds a token bucket per key
+type rateLimiter struct {
+	limiters *ratelimit.KeyedLimiter
+	key      func(r *http.Request) string
+}
+
+// allow takes a token from the bucket for key. When none is left it
+// returns false and how long until the next token.
+func (l *rateLimiter) allow(key string) (time.Duration, bool) {
+	r := l.limiters.Reserve(key)
+	if wait := r.Delay(); wait > 0 {
+		r.Cancel()
+		return wait, false
+	}
+	return 0, true
+}
+
+// clock reads the time from Now, so tests can fake it
+type clock struct{}
+
+func (clock) Now() time.Time                         { return Now() }
+func (clock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// clientIP returns the host part of RemoteAddr
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
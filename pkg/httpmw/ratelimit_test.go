@@ -0,0 +1,90 @@
+package httpmw_test
+
+import (
+	"go-examples/pkg/httpmw"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// serveFrom sends a request from remote through handler and returns the response
+func serveFrom(handler http.Handler, remote string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = remote
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// TestRateLimit tests bursts, refill and per-client buckets
+func TestRateLimit(t *testing.T) {
+	now := installFakeClock(t)
+	handler := httpmw.RateLimit(2, 3)(okHandler)
+
+	for i := 0; i < 3; i++ {
+		if rec := serveFrom(handler, "10.0.0.1:1234"); rec.Code != http.StatusOK {
+			t.Fatalf("request %d of the burst: status %d", i+1, rec.Code)
+		}
+	}
+	rec := serveFrom(handler, "10.0.0.1:1234")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 after the burst, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want 1", got)
+	}
+
+	// Another client has its own bucket, even from another port
+	if rec := serveFrom(handler, "10.0.0.2:1234"); rec.Code != http.StatusOK {
+		t.Errorf("expected another client to be allowed, got %d", rec.Code)
+	}
+
+	// Two tokens per second: half a second refills one
+	*now = now.Add(500 * time.Millisecond)
+	if rec := serveFrom(handler, "10.0.0.1:5678"); rec.Code != http.StatusOK {
+		t.Errorf("expected a refilled token, got %d", rec.Code)
+	}
+	if rec := serveFrom(handler, "10.0.0.1:5678"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected 429 with the bucket empty again, got %d", rec.Code)
+	}
+}
+
+// TestRateLimitKeyFunc tests a global limit shared by all clients
+func TestRateLimitKeyFunc(t *testing.T) {
+	installFakeClock(t)
+	handler := httpmw.RateLimit(1, 1, httpmw.WithKeyFunc(func(*http.Request) string { return "global" }))(okHandler)
+
+	if rec := serveFrom(handler, "10.0.0.1:1"); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if rec := serveFrom(handler, "10.0.0.2:1"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected the shared bucket to be empty, got %d", rec.Code)
+	}
+}
+
+// TestRateLimitConcurrent tests that concurrent requests never exceed the burst
+func TestRateLimitConcurrent(t *testing.T) {
+	installFakeClock(t)
+	handler := httpmw.RateLimit(1, 10)(okHandler)
+
+	var mu sync.Mutex
+	allowed := 0
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if serveFrom(handler, "10.0.0.1:1").Code == http.StatusOK {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if allowed != 10 {
+		t.Errorf("allowed %d requests, want 10", allowed)
+	}
+}
@@ -0,0 +1,36 @@
+package httpmw
+
+import (
+	"context"
+	"go-examples/pkg/slogger"
+	"net/http"
+)
+
+// requestIDKey is the context key for the ID set by RequestID
+type requestIDKey struct{}
+
+// RequestID returns middleware that gives every request an ID: a valid
+// X-Request-ID sent by the client, or a new one from
+// slogger.NewRequestID. The ID is stored in the request context for
+// RequestIDFrom, echoed in the X-Request-ID response header, and written
+// back to the request header so that Logging reuses it.
+func RequestID() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(slogger.RequestIDHeader)
+			if !slogger.ValidRequestID(id) {
+				id = slogger.NewRequestID()
+				r.Header.Set(slogger.RequestIDHeader, id)
+			}
+			w.Header().Set(slogger.RequestIDHeader, id)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+		})
+	}
+}
+
+// RequestIDFrom returns the ID stored in ctx by RequestID, or "" when
+// RequestID has not run
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
@@ -0,0 +1,52 @@
+package httpmw_test
+
+import (
+	"go-examples/pkg/httpmw"
+	"go-examples/pkg/slogger"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestRequestID tests that provided IDs are kept and others replaced
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		provided string
+		keep     bool
+	}{
+		{"provided", "req-1", true},
+		{"missing", "", false},
+		{"invalid", "has space", false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var fromCtx, fromHeader string
+			handler := httpmw.RequestID()(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				fromCtx = httpmw.RequestIDFrom(r.Context())
+				fromHeader = r.Header.Get(slogger.RequestIDHeader)
+			}))
+			req := httptest.NewRequest("GET", "/", nil)
+			if tc.provided != "" {
+				req.Header.Set(slogger.RequestIDHeader, tc.provided)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			id := rec.Header().Get(slogger.RequestIDHeader)
+			if tc.keep != (id == tc.provided) || !slogger.ValidRequestID(id) {
+				t.Errorf("response ID = %q, provided %q", id, tc.provided)
+			}
+			if fromCtx != id || fromHeader != id {
+				t.Errorf("context ID %q and request header %q, want %q", fromCtx, fromHeader, id)
+			}
+		})
+	}
+}
+
+// TestRequestIDFromWithoutMiddleware tests the empty ID outside RequestID
+func TestRequestIDFromWithoutMiddleware(t *testing.T) {
+	if id := httpmw.RequestIDFrom(httptest.NewRequest("GET", "/", nil).Context()); id != "" {
+		t.Errorf("expected no ID, got %q", id)
+	}
+}
@@ -0,0 +1,255 @@
+package httpmw
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go-examples/pkg/slogger"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ShadowHeader marks requests mirrored by Shadow, so the shadow can tell
+// them apart and Shadow does not mirror them again
+const ShadowHeader = "X-Shadow"
+
+// DefaultShadowTimeout bounds a mirrored request unless WithShadowTimeout
+// says otherwise
+const DefaultShadowTimeout = 2 * time.Second
+
+const (
+	// maxShadowBody is the largest request or response body mirrored
+	// and compared; larger requests are not mirrored
+	maxShadowBody = 1 << 20
+	// maxShadowInFlight bounds the mirrored requests waiting for a slow
+	// shadow; requests beyond it are not mirrored
+	maxShadowInFlight = 100
+)
+
+// Response is a response to a request mirrored by Shadow. Err is set,
+// and the other fields are empty, when the shadow could not be reached
+// or its response not read.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+	Err    error
+}
+
+// ShadowOption configures Shadow
+type ShadowOption func(*shadow)
+
+// WithShadowTimeout sets how long a mirrored request may take
+func WithShadowTimeout(d time.Duration) ShadowOption {
+	return func(s *shadow) {
+		s.timeout = d
+	}
+}
+
+// WithShadowClient sends mirrored requests through c instead of
+// http.DefaultClient
+func WithShadowClient(c *http.Client) ShadowOption {
+	return func(s *shadow) {
+		s.client = c
+	}
+}
+
+// WithShadowRand samples requests with r instead of a randomly seeded
+// source, so tests can predict which requests are mirrored
+func WithShadowRand(r *rand.Rand) ShadowOption {
+	return func(s *shadow) {
+		s.rand = r
+	}
+}
+
+// WithShadowFilter sets which requests may be mirrored. The default
+// mirrors POST /calculate only.
+func WithShadowFilter(fn func(r *http.Request) bool) ShadowOption {
+	return func(s *shadow) {
+		s.eligible = fn
+	}
+}
+
+// Shadow returns middleware that mirrors a sample of percent in 100
+// eligible requests to target, such as a new version of the service, and
+// passes the primary and shadow responses to compare. Mirroring happens on its
+// own goroutine after the primary response is written, so the client
+// never waits for the shadow and its response is never altered.
+//
+// Mirrored requests carry the method, path, query, headers and body of
+// the original with ShadowHeader set to "true". After RequestID, they
+// keep the request ID, so both sides log the same one. Requests that
+// already carry ShadowHeader, and those with bodies over 1 MiB, are not
+// mirrored; neither are requests while 100 mirrored ones are pending.
+func Shadow(target *url.URL, percent int, compare func(primary, shadow Response), opts ...ShadowOption) Middleware {
+	s := &shadow{
+		target:   target,
+		percent:  percent,
+		compare:  compare,
+		client:   http.DefaultClient,
+		timeout:  DefaultShadowTimeout,
+		rand:     rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+		eligible: isCalculation,
+		inFlight: make(chan struct{}, maxShadowInFlight),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get(ShadowHeader) != "" || !s.eligible(r) || !s.sample() {
+				next.ServeHTTP(w, r)
+				return
+			}
+			select {
+			case s.inFlight <- struct{}{}:
+			default:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxShadowBody+1))
+			if err != nil || len(body) > maxShadowBody {
+				<-s.inFlight
+				r.Body = readCloser{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+				next.ServeHTTP(w, r)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			mirror := s.newRequest(r, body)
+
+			rec := &shadowRecorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r)
+			primary := Response{Status: rec.status, Header: w.Header().Clone(), Body: rec.body.Bytes()}
+			if primary.Status == 0 {
+				primary.Status = http.StatusOK
+			}
+
+			go func() {
+				defer func() { <-s.inFlight }()
+				s.compare(primary, s.send(mirror))
+			}()
+		})
+	}
+}
+
+// shadow holds the settings of Shadow
+type shadow struct {
+	target   *url.URL
+	percent  int
+	compare  func(primary, shadow Response)
+	client   *http.Client
+	timeout  time.Duration
+	eligible func(r *http.Request) bool
+	inFlight chan struct{}
+
+	mu   sync.Mutex // guards rand, which is not safe for concurrent use
+	rand *rand.Rand
+}
+
+// isCalculation reports whether r is a POST /calculate
+func isCalculation(r *http.Request) bool {
+	return r.Method == http.MethodPost && r.URL.Path == "/calculate"
+}
+
+// sample reports whether a request is mirrored
+func (s *shadow) sample() bool {
+	switch {
+	case s.percent <= 0:
+		return false
+	case s.percent >= 100:
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rand.IntN(100) < s.percent
+}
+
+// shadowRequest is a copy of a request, taken before the handler can
+// change it
+type shadowRequest struct {
+	ctx    context.Context
+	method string
+	url    *url.URL
+	header http.Header
+	body   []byte
+}
+
+// newRequest copies r for the target
+func (s *shadow) newRequest(r *http.Request, body []byte) shadowRequest {
+	u := s.target.JoinPath(r.URL.Path)
+	u.RawQuery = r.URL.RawQuery
+	header := r.Header.Clone()
+	header.Set(ShadowHeader, "true")
+	if header.Get(slogger.RequestIDHeader) == "" {
+		if id := RequestIDFrom(r.Context()); id != "" {
+			header.Set(slogger.RequestIDHeader, id)
+		}
+	}
+	// The mirrored request outlives the original, but keeps its values
+	ctx := context.WithoutCancel(r.Context())
+	return shadowRequest{ctx: ctx, method: r.Method, url: u, header: header, body: body}
+}
+
+// send sends the mirrored request and reads the response
+func (s *shadow) send(m shadowRequest) Response {
+	ctx, cancel := context.WithTimeout(m.ctx, s.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, m.method, m.url.String(), bytes.NewReader(m.body))
+	if err != nil {
+		return Response{Err: err}
+	}
+	req.Header = m.header
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return Response{Err: err}
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxShadowBody))
+	if err != nil {
+		return Response{Err: fmt.Errorf("failed to read shadow response: %w", err)}
+	}
+	return Response{Status: resp.StatusCode, Header: resp.Header, Body: body}
+}
+
+// shadowRecorder copies the status and the first maxShadowBody bytes of
+// the primary response as they are written
+type shadowRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *shadowRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *shadowRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	if room := maxShadowBody - w.body.Len(); room > 0 {
+		w.body.Write(b[:min(n, room)])
+	}
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *shadowRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// readCloser reads from one reader and closes another
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
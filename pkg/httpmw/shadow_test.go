@@ -0,0 +1,152 @@
+package httpmw_test
+
+import (
+	"go-examples/pkg/httpmw"
+	"go-examples/pkg/slogger"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// comparison is a pair of responses passed to the compare callback
+type comparison struct {
+	primary, shadow httpmw.Response
+}
+
+// shadowed starts a shadow server answering with shadowHandler and
+// returns the primary handler, echoing the request body, mirrored to it
+// with percent and opts, and the channel receiving the comparisons
+func shadowed(t *testing.T, shadowHandler http.Handler, percent int, opts ...httpmw.ShadowOption) (http.Handler, <-chan comparison) {
+	t.Helper()
+	shadowSrv := httptest.NewServer(shadowHandler)
+	t.Cleanup(shadowSrv.Close)
+	target, _ := url.Parse(shadowSrv.URL)
+
+	compared := make(chan comparison, 1000)
+	compare := func(primary, shadow httpmw.Response) {
+		compared <- comparison{primary, shadow}
+	}
+	return httpmw.Chain(httpmw.RequestID(), httpmw.Shadow(target, percent, compare, opts...))(echoHandler), compared
+}
+
+// calculate sends a calculation through h
+func calculate(h http.Handler, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/calculate", strings.NewReader(body))
+	req.Header.Set(slogger.RequestIDHeader, "req-42")
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// receive waits for a comparison
+func receive(t *testing.T, compared <-chan comparison) comparison {
+	t.Helper()
+	select {
+	case c := <-compared:
+		return c
+	case <-time.After(5 * time.Second):
+		t.Fatal("no comparison reported")
+		return comparison{}
+	}
+}
+
+// TestShadowMirrors tests that mirrored requests carry the body, request
+// ID and ShadowHeader, and that both responses reach compare
+func TestShadowMirrors(t *testing.T) {
+	var gotHeader, gotID, gotBody string
+	h, compared := shadowed(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotHeader, gotID, gotBody = r.Header.Get(httpmw.ShadowHeader), r.Header.Get(slogger.RequestIDHeader), string(body)
+		_, _ = w.Write(body)
+	}), 100)
+
+	body := `{"operation":"add","a":1,"b":2}`
+	if rec := calculate(h, body); rec.Body.String() != body {
+		t.Fatalf("primary response = %q, want %q", rec.Body.String(), body)
+	}
+	c := receive(t, compared)
+	if gotHeader != "true" || gotID != "req-42" || gotBody != body {
+		t.Errorf("shadow got %s=%q, request ID %q, body %q", httpmw.ShadowHeader, gotHeader, gotID, gotBody)
+	}
+	if c.shadow.Err != nil || string(c.primary.Body) != body || string(c.shadow.Body) != body || c.primary.Status != http.StatusOK {
+		t.Errorf("compared primary %+v and shadow %+v", c.primary, c.shadow)
+	}
+}
+
+// TestShadowMismatch tests that differing responses reach compare as
+// they are
+func TestShadowMismatch(t *testing.T) {
+	h, compared := shadowed(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"result":4}`))
+	}), 100)
+
+	calculate(h, `{"result":3}`)
+	c := receive(t, compared)
+	if string(c.primary.Body) == string(c.shadow.Body) || c.shadow.Status != http.StatusBadRequest {
+		t.Errorf("compared primary %+v and shadow %+v, want a mismatch", c.primary, c.shadow)
+	}
+}
+
+// TestShadowSampling tests that a seeded source mirrors exactly the
+// requests it picks, and that other requests are never mirrored
+func TestShadowSampling(t *testing.T) {
+	var mirrored atomic.Int32
+	h, compared := shadowed(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mirrored.Add(1)
+	}), 30, httpmw.WithShadowRand(rand.New(rand.NewPCG(1, 2))))
+
+	const requests = 200
+	want := 0
+	picks := rand.New(rand.NewPCG(1, 2))
+	for i := 0; i < requests; i++ {
+		if picks.IntN(100) < 30 {
+			want++
+		}
+		calculate(h, `{}`)
+	}
+	for i := 0; i < want; i++ {
+		receive(t, compared)
+	}
+	if int(mirrored.Load()) != want || want == 0 || want == requests {
+		t.Errorf("mirrored %d of %d requests, want %d", mirrored.Load(), requests, want)
+	}
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest("GET", "/operations", nil),
+		httptest.NewRequest("POST", "/calculate", strings.NewReader(`{}`)),
+	} {
+		req.Header.Set(httpmw.ShadowHeader, "true") // ignored for /operations anyway
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	select {
+	case c := <-compared:
+		t.Errorf("mirrored an ineligible request: %+v", c)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// TestShadowSlow tests that a slow or failing shadow does not delay the
+// client
+func TestShadowSlow(t *testing.T) {
+	release := make(chan struct{})
+	h, compared := shadowed(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}), 100, httpmw.WithShadowTimeout(200*time.Millisecond))
+	defer close(release)
+
+	start := time.Now()
+	calculate(h, `{}`)
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("client waited %s for the shadow", elapsed)
+	}
+	if c := receive(t, compared); c.shadow.Err == nil {
+		t.Errorf("shadow past its timeout reported %+v, want an error", c.shadow)
+	}
+}
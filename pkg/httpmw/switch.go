@@ -0,0 +1,64 @@
+package httpmw
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// Switch is middleware that can be replaced while serving, for settings
+// such as CORS origins or rate limits that are reloaded at runtime.
+// Replace builds the new middleware around every handler wrapped so far,
+// so state such as rate limit buckets starts afresh. It is safe for
+// concurrent use.
+type Switch struct {
+	mu       sync.Mutex
+	mw       Middleware
+	handlers []*switchHandler
+}
+
+// NewSwitch returns a Switch applying mw. A nil mw passes requests
+// through unchanged.
+func NewSwitch(mw Middleware) *Switch {
+	return &Switch{mw: mw}
+}
+
+// Middleware wraps next with the current middleware, and with the
+// middleware of later calls to Replace
+func (s *Switch) Middleware(next http.Handler) http.Handler {
+	h := &switchHandler{next: next}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h.build(s.mw)
+	s.handlers = append(s.handlers, h)
+	return h
+}
+
+// Replace makes requests go through mw from now on. Requests already in
+// the old middleware finish there.
+func (s *Switch) Replace(mw Middleware) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mw = mw
+	for _, h := range s.handlers {
+		h.build(mw)
+	}
+}
+
+// switchHandler serves requests through the handler last built for it
+type switchHandler struct {
+	next    http.Handler
+	current atomic.Pointer[http.Handler]
+}
+
+func (h *switchHandler) build(mw Middleware) {
+	handler := h.next
+	if mw != nil {
+		handler = mw(h.next)
+	}
+	h.current.Store(&handler)
+}
+
+func (h *switchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	(*h.current.Load()).ServeHTTP(w, r)
+}
@@ -0,0 +1,58 @@
+package httpmw_test
+
+import (
+	"go-examples/pkg/httpmw"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// TestSwitch tests replacing middleware while handlers wrapped by the
+// Switch keep serving
+func TestSwitch(t *testing.T) {
+	s := httpmw.NewSwitch(nil)
+	handler := s.Middleware(okHandler)
+	status := func(token string) int {
+		req := httptest.NewRequest("GET", "/calculate", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if got := status(""); got != http.StatusOK {
+		t.Errorf("without middleware: %d, want 200", got)
+	}
+	s.Replace(httpmw.Auth(httpmw.BearerToken("t1")))
+	if got := status(""); got != http.StatusUnauthorized {
+		t.Errorf("after adding Auth: %d, want 401", got)
+	}
+	s.Replace(httpmw.Auth(httpmw.BearerToken("t2")))
+	if before, after := status("t1"), status("t2"); before != http.StatusUnauthorized || after != http.StatusOK {
+		t.Errorf("after replacing the token: old %d, new %d, want 401 and 200", before, after)
+	}
+}
+
+// TestSwitchConcurrent tests replacing middleware under load, for the
+// race detector
+func TestSwitchConcurrent(t *testing.T) {
+	s := httpmw.NewSwitch(httpmw.RateLimit(1000, 1000))
+	handler := s.Middleware(okHandler)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+			}
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		s.Replace(httpmw.RateLimit(1000, 1000))
+	}
+	wg.Wait()
+}
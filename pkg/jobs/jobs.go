@@ -0,0 +1,321 @@
+// Package jobs runs functions in the background on a bounded pool of
+// workers, tracking the status and outcome of each job, for work such as
+// asynchronous calculations that outlives the request submitting it.
+//
+//	q := jobs.New(jobs.WithWorkers(4), jobs.WithDepth(100))
+//	id, err := q.Submit(ctx, func(ctx context.Context) (any, error) { ... })
+//	job, _ := q.Get(id)
+//	...
+//	err = q.Drain(shutdownCtx)
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-examples/pkg/metrics"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrQueueFull is returned by Submit when the queue already holds as
+	// many pending jobs as its depth
+	ErrQueueFull = errors.New("jobs: queue is full")
+	// ErrDraining is returned by Submit once Drain has been called
+	ErrDraining = errors.New("jobs: queue is draining")
+)
+
+// Defaults used by New
+const (
+	DefaultWorkers      = 4
+	DefaultDepth        = 100
+	DefaultKeepFinished = 1000
+)
+
+// ID identifies a job within its Queue
+type ID uint64
+
+func (id ID) String() string {
+	return fmt.Sprintf("job-%d", uint64(id))
+}
+
+// Func is the work of a job. It should return once ctx is done.
+type Func func(ctx context.Context) (any, error)
+
+// Status is the state of a job
+type Status int
+
+// Job statuses
+const (
+	StatusPending Status = iota
+	StatusRunning
+	StatusDone
+	StatusFailed
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusPending:
+		return "pending"
+	case StatusRunning:
+		return "running"
+	case StatusDone:
+		return "done"
+	case StatusFailed:
+		return "failed"
+	}
+	return "unknown"
+}
+
+// Job is a snapshot of a submitted job
+type Job struct {
+	ID        ID
+	Status    Status
+	Result    any   // set when Status is StatusDone
+	Err       error // set when Status is StatusFailed
+	Submitted time.Time
+	Started   time.Time // zero while pending
+	Finished  time.Time // zero until done or failed
+}
+
+// Option configures a Queue created by New
+type Option func(*Queue)
+
+// WithWorkers sets how many jobs run at the same time
+func WithWorkers(n int) Option {
+	return func(q *Queue) {
+		q.workers = n
+	}
+}
+
+// WithDepth sets how many jobs may wait for a worker before Submit
+// returns ErrQueueFull
+func WithDepth(n int) Option {
+	return func(q *Queue) {
+		q.depth = n
+	}
+}
+
+// WithKeepFinished sets how many finished jobs Get still reports; older
+// ones are forgotten first
+func WithKeepFinished(n int) Option {
+	return func(q *Queue) {
+		q.keepFinished = n
+	}
+}
+
+// WithMetrics records the number of pending jobs in jobs_queue_depth and
+// the duration of finished jobs in job_duration_seconds by status
+func WithMetrics(p metrics.Provider) Option {
+	return func(q *Queue) {
+		q.metrics = p
+	}
+}
+
+// job is the state of a submitted job, guarded by Queue.mu
+type job struct {
+	Job
+	fn     Func
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// Queue runs submitted jobs on a fixed number of workers. It is safe for
+// concurrent use.
+type Queue struct {
+	workers      int
+	depth        int
+	keepFinished int
+	metrics      metrics.Provider
+
+	depthGauge metrics.Gauge
+	duration   metrics.Histogram
+
+	pending chan *job
+	wg      sync.WaitGroup // workers
+
+	mu       sync.Mutex
+	draining bool
+	nextID   ID
+	jobs     map[ID]*job
+	finished []ID // oldest first
+}
+
+// New starts a Queue and its workers
+func New(opts ...Option) *Queue {
+	q := &Queue{
+		workers:      DefaultWorkers,
+		depth:        DefaultDepth,
+		keepFinished: DefaultKeepFinished,
+		jobs:         map[ID]*job{},
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
+	if q.workers < 1 {
+		q.workers = 1
+	}
+	if q.depth < 0 {
+		q.depth = 0
+	}
+	p := metrics.OrNoop(q.metrics)
+	q.depthGauge = p.Gauge("jobs_queue_depth", "Jobs waiting for a worker.")
+	q.duration = p.Histogram("job_duration_seconds", "Time jobs took to run, by final status.", nil, "status")
+
+	q.pending = make(chan *job, q.depth)
+	q.wg.Add(q.workers)
+	for i := 0; i < q.workers; i++ {
+		go q.work()
+	}
+	return q
+}
+
+// Submit queues fn and returns the ID of its job. The job's context is
+// derived from ctx, so cancelling ctx cancels the job; callers whose
+// ctx ends before the job should run, such as HTTP handlers, pass
+// context.WithoutCancel(ctx). It returns ErrQueueFull when the queue is
+// at its depth and ErrDraining after Drain.
+func (q *Queue) Submit(ctx context.Context, fn Func) (ID, error) {
+	jobCtx, cancel := context.WithCancel(ctx)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.draining {
+		cancel()
+		return 0, ErrDraining
+	}
+	q.nextID++
+	j := &job{
+		Job:    Job{ID: q.nextID, Status: StatusPending, Submitted: time.Now()},
+		fn:     fn,
+		ctx:    jobCtx,
+		cancel: cancel,
+	}
+	select {
+	case q.pending <- j:
+	default:
+		cancel()
+		return 0, ErrQueueFull
+	}
+	q.jobs[j.ID] = j
+	q.depthGauge.Add(1)
+	return j.ID, nil
+}
+
+// Get returns a snapshot of the job with id, or false when there is no
+// such job or it finished long enough ago to be forgotten
+func (q *Queue) Get(id ID) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j, ok := q.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return j.Job, true
+}
+
+// Cancel cancels the context of the job with id. A pending job then
+// fails without running; a running one fails if its Func honors the
+// cancellation. It returns false when the job is unknown or finished.
+func (q *Queue) Cancel(id ID) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j, ok := q.jobs[id]
+	if !ok || j.Status == StatusDone || j.Status == StatusFailed {
+		return false
+	}
+	j.cancel()
+	return true
+}
+
+// Drain stops accepting jobs and waits for the pending and running ones
+// to finish. If ctx is done first, the remaining jobs are cancelled and
+// Drain returns ctx.Err() without waiting for them. Later calls wait
+// the same way.
+func (q *Queue) Drain(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.draining {
+		q.draining = true
+		close(q.pending)
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		q.mu.Lock()
+		for _, j := range q.jobs {
+			j.cancel()
+		}
+		q.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// work runs pending jobs until the queue is drained
+func (q *Queue) work() {
+	defer q.wg.Done()
+	for j := range q.pending {
+		q.depthGauge.Add(-1)
+		q.run(j)
+	}
+}
+
+// run runs j, unless it was cancelled while pending, and records its
+// outcome
+func (q *Queue) run(j *job) {
+	defer j.cancel()
+
+	q.mu.Lock()
+	j.Started = time.Now()
+	if err := j.ctx.Err(); err != nil {
+		q.finish(j, nil, err)
+		q.mu.Unlock()
+		return
+	}
+	j.Status = StatusRunning
+	q.mu.Unlock()
+
+	result, err := call(j.ctx, j.fn)
+
+	q.mu.Lock()
+	q.finish(j, result, err)
+	q.mu.Unlock()
+}
+
+// call runs fn, turning a panic into an error
+func call(ctx context.Context, fn Func) (result any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("jobs: job panicked: %v", r)
+		}
+	}()
+	return fn(ctx)
+}
+
+// finish records the outcome of j and forgets the oldest finished jobs
+// beyond keepFinished. q.mu must be held.
+func (q *Queue) finish(j *job, result any, err error) {
+	j.Finished = time.Now()
+	if err != nil {
+		j.Status = StatusFailed
+		j.Err = err
+	} else {
+		j.Status = StatusDone
+		j.Result = result
+	}
+	q.duration.Observe(j.Finished.Sub(j.Started).Seconds(), j.Status.String())
+
+	q.finished = append(q.finished, j.ID)
+	for len(q.finished) > q.keepFinished {
+		delete(q.jobs, q.finished[0])
+		q.finished = q.finished[1:]
+	}
+}
@@ -0,0 +1,304 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-examples/pkg/jobs"
+	"go-examples/pkg/metrics/prommetrics"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// gate holds jobs until it is opened, counting how many run at once
+type gate struct {
+	open    chan struct{}
+	started chan struct{}
+	running atomic.Int32
+	peak    atomic.Int32
+}
+
+func newGate() *gate {
+	return &gate{open: make(chan struct{}), started: make(chan struct{}, 100)}
+}
+
+// job returns a Func that waits for the gate and returns result
+func (g *gate) job(result any) jobs.Func {
+	return func(ctx context.Context) (any, error) {
+		n := g.running.Add(1)
+		defer g.running.Add(-1)
+		for {
+			peak := g.peak.Load()
+			if n <= peak || g.peak.CompareAndSwap(peak, n) {
+				break
+			}
+		}
+		g.started <- struct{}{}
+		select {
+		case <-g.open:
+			return result, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// waitStarted waits for n jobs to reach the gate
+func (g *gate) waitStarted(t *testing.T, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-g.started:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("only %d of %d jobs started", i, n)
+		}
+	}
+}
+
+// waitFor polls until the job with id reaches a final status
+func waitFor(t *testing.T, q *jobs.Queue, id jobs.ID) jobs.Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, ok := q.Get(id)
+		if !ok {
+			t.Fatalf("%s is unknown", id)
+		}
+		if job.Status == jobs.StatusDone || job.Status == jobs.StatusFailed {
+			return job
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("%s did not finish", id)
+	return jobs.Job{}
+}
+
+// TestConcurrencyLimit tests that no more jobs run at once than there
+// are workers, and that all of them complete with their results
+func TestConcurrencyLimit(t *testing.T) {
+	q := jobs.New(jobs.WithWorkers(3), jobs.WithDepth(20))
+	g := newGate()
+
+	var ids []jobs.ID
+	for i := 0; i < 10; i++ {
+		id, err := q.Submit(context.Background(), g.job(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	g.waitStarted(t, 3)
+	if job, _ := q.Get(ids[9]); job.Status != jobs.StatusPending {
+		t.Errorf("last job is %s while the workers are busy, want pending", job.Status)
+	}
+	close(g.open)
+
+	for i, id := range ids {
+		job := waitFor(t, q, id)
+		if job.Status != jobs.StatusDone || job.Result != i || job.Err != nil {
+			t.Errorf("%s = %+v, want done with result %d", id, job, i)
+		}
+		if job.Started.Before(job.Submitted) || job.Finished.Before(job.Started) {
+			t.Errorf("%s has inconsistent times %+v", id, job)
+		}
+	}
+	if peak := g.peak.Load(); peak != 3 {
+		t.Errorf("peak concurrency = %d, want 3", peak)
+	}
+	if err := q.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestQueueFull tests that Submit refuses jobs beyond the depth
+func TestQueueFull(t *testing.T) {
+	q := jobs.New(jobs.WithWorkers(1), jobs.WithDepth(2))
+	g := newGate()
+	defer func() {
+		close(g.open)
+		_ = q.Drain(context.Background())
+	}()
+
+	if _, err := q.Submit(context.Background(), g.job(nil)); err != nil {
+		t.Fatal(err)
+	}
+	g.waitStarted(t, 1)
+	for i := 0; i < 2; i++ {
+		if _, err := q.Submit(context.Background(), g.job(nil)); err != nil {
+			t.Fatalf("pending job %d: %v", i, err)
+		}
+	}
+	if _, err := q.Submit(context.Background(), g.job(nil)); !errors.Is(err, jobs.ErrQueueFull) {
+		t.Errorf("got %v, want ErrQueueFull", err)
+	}
+}
+
+// TestCancel tests cancelling a running job, a pending one, and one
+// through the context given to Submit
+func TestCancel(t *testing.T) {
+	q := jobs.New(jobs.WithWorkers(1))
+	g := newGate()
+	defer func() { _ = q.Drain(context.Background()) }()
+
+	running, _ := q.Submit(context.Background(), g.job(nil))
+	g.waitStarted(t, 1)
+	pending, _ := q.Submit(context.Background(), g.job(nil))
+	ctx, cancel := context.WithCancel(context.Background())
+	byContext, _ := q.Submit(ctx, g.job(nil))
+
+	if job, _ := q.Get(running); job.Status != jobs.StatusRunning {
+		t.Fatalf("first job is %s, want running", job.Status)
+	}
+	if !q.Cancel(pending) || !q.Cancel(running) {
+		t.Fatal("Cancel returned false for unfinished jobs")
+	}
+	cancel()
+
+	for _, id := range []jobs.ID{running, pending, byContext} {
+		job := waitFor(t, q, id)
+		if job.Status != jobs.StatusFailed || !errors.Is(job.Err, context.Canceled) {
+			t.Errorf("%s = %s %v, want failed with context.Canceled", id, job.Status, job.Err)
+		}
+	}
+	// Only the running job reached the gate
+	if n := len(g.started); n != 0 {
+		t.Errorf("%d cancelled pending jobs ran", n)
+	}
+	if q.Cancel(running) || q.Cancel(12345) {
+		t.Error("Cancel returned true for a finished or unknown job")
+	}
+}
+
+// TestDrain tests that Drain lets in-flight and pending jobs complete
+// while new submissions are rejected
+func TestDrain(t *testing.T) {
+	q := jobs.New(jobs.WithWorkers(2))
+	g := newGate()
+
+	var ids []jobs.ID
+	for i := 0; i < 4; i++ {
+		id, _ := q.Submit(context.Background(), g.job(i))
+		ids = append(ids, id)
+	}
+	g.waitStarted(t, 2)
+
+	drained := make(chan error, 1)
+	go func() { drained <- q.Drain(context.Background()) }()
+
+	// Wait for Drain to take effect, then check new jobs are refused
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, err := q.Submit(context.Background(), g.job(nil))
+		if errors.Is(err, jobs.ErrDraining) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Submit kept accepting jobs during Drain: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case err := <-drained:
+		t.Fatalf("Drain returned %v with jobs in flight", err)
+	default:
+	}
+
+	close(g.open)
+	if err := <-drained; err != nil {
+		t.Fatal(err)
+	}
+	for i, id := range ids {
+		if job, _ := q.Get(id); job.Status != jobs.StatusDone || job.Result != i {
+			t.Errorf("%s = %+v after Drain, want done", id, job)
+		}
+	}
+}
+
+// TestDrainTimeout tests that Drain cancels the remaining jobs when its
+// context ends
+func TestDrainTimeout(t *testing.T) {
+	q := jobs.New(jobs.WithWorkers(1))
+	g := newGate()
+	running, _ := q.Submit(context.Background(), g.job(nil))
+	pending, _ := q.Submit(context.Background(), g.job(nil))
+	g.waitStarted(t, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := q.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Drain = %v, want DeadlineExceeded", err)
+	}
+	for _, id := range []jobs.ID{running, pending} {
+		if job := waitFor(t, q, id); job.Status != jobs.StatusFailed {
+			t.Errorf("%s = %s, want failed", id, job.Status)
+		}
+	}
+	if err := q.Drain(context.Background()); err != nil {
+		t.Errorf("second Drain = %v", err)
+	}
+}
+
+// TestPanicAndRetention tests that a panicking job fails, and that only
+// the newest finished jobs are kept
+func TestPanicAndRetention(t *testing.T) {
+	q := jobs.New(jobs.WithWorkers(1), jobs.WithKeepFinished(2))
+	defer func() { _ = q.Drain(context.Background()) }()
+
+	panicking, _ := q.Submit(context.Background(), func(context.Context) (any, error) { panic("boom") })
+	if job := waitFor(t, q, panicking); job.Status != jobs.StatusFailed || !strings.Contains(job.Err.Error(), "boom") {
+		t.Errorf("panicking job = %+v", job)
+	}
+
+	var last jobs.ID
+	for i := 0; i < 2; i++ {
+		last, _ = q.Submit(context.Background(), func(context.Context) (any, error) { return "ok", nil })
+		waitFor(t, q, last)
+	}
+	if _, ok := q.Get(panicking); ok {
+		t.Error("the oldest finished job was kept")
+	}
+	if job, ok := q.Get(last); !ok || job.Result != "ok" {
+		t.Errorf("newest job = %+v, %v", job, ok)
+	}
+}
+
+// TestMetrics tests the queue depth gauge and the duration histogram
+func TestMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	q := jobs.New(jobs.WithWorkers(1), jobs.WithMetrics(prommetrics.New(reg)))
+	g := newGate()
+
+	var ids []jobs.ID
+	for i := 0; i < 3; i++ {
+		id, _ := q.Submit(context.Background(), g.job(nil))
+		ids = append(ids, id)
+	}
+	g.waitStarted(t, 1)
+	depth := func(want int) string {
+		return fmt.Sprintf(`
+# HELP jobs_queue_depth Jobs waiting for a worker.
+# TYPE jobs_queue_depth gauge
+jobs_queue_depth %d
+`, want)
+	}
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(depth(2)), "jobs_queue_depth"); err != nil {
+		t.Errorf("with one job running: %v", err)
+	}
+
+	q.Cancel(ids[2])
+	close(g.open)
+	if err := q.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(depth(0)), "jobs_queue_depth"); err != nil {
+		t.Errorf("after Drain: %v", err)
+	}
+	if n := testutil.CollectAndCount(reg, "job_duration_seconds"); n != 2 {
+		t.Errorf("got %d duration series, want done and failed", n)
+	}
+}
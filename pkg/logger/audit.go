@@ -0,0 +1,200 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// genesisHash is the prev_hash of the first entry in an audit stream
+var genesisHash = strings.Repeat("0", sha256.Size*2)
+
+// NewAudit creates an append-only audit logger writing JSON lines to w.
+// Every entry carries a monotonically increasing seq field starting at 1,
+// the hash of the previous entry in prev_hash, and its own hash: the
+// SHA-256 of the entry's canonical JSON form (sorted keys, without the
+// hash field). Removing, reordering, or editing lines breaks the chain,
+// which VerifyAuditStream detects.
+//
+// Audit loggers always encode JSON and never sample or rate-limit.
+// Level filtering below Info is refused with an error.
+func NewAudit(w io.Writer, opts ...Option) (Logger, error) {
+	o := newOptions(opts)
+	if o.levelSpec != nil && o.levelSpec.MinLevel() < zapcore.InfoLevel {
+		return nil, fmt.Errorf("audit logger does not allow levels below info, got %q", o.levelSpec.String())
+	}
+	o.floor = zapcore.InfoLevel
+
+	encoder := zapcore.NewJSONEncoder(newEncoderConfig())
+	out := zapcore.Lock(zapcore.AddSync(w))
+	state := &auditState{prevHash: genesisHash}
+	return buildLogger(zapcore.InfoLevel, o, func(enab zapcore.LevelEnabler) zapcore.Core {
+		return &auditCore{LevelEnabler: enab, enc: encoder, out: out, state: state}
+	}), nil
+}
+
+// auditState is the hash chain shared by an audit logger and its children
+type auditState struct {
+	mu       sync.Mutex
+	seq      uint64
+	prevHash string
+}
+
+// auditCore encodes entries as chained, hashed JSON lines
+type auditCore struct {
+	zapcore.LevelEnabler
+	enc   zapcore.Encoder
+	out   zapcore.WriteSyncer
+	state *auditState
+}
+
+func (c *auditCore) With(fields []zapcore.Field) zapcore.Core {
+	enc := c.enc.Clone()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return &auditCore{LevelEnabler: c.LevelEnabler, enc: enc, out: c.out, state: c.state}
+}
+
+func (c *auditCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *auditCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+
+	seq := c.state.seq + 1
+	chained := make([]zapcore.Field, 0, len(fields)+2)
+	chained = append(chained, fields...)
+	chained = append(chained, zap.Uint64("seq", seq), zap.String("prev_hash", c.state.prevHash))
+
+	buf, err := c.enc.EncodeEntry(ent, chained)
+	if err != nil {
+		return err
+	}
+	entry, err := decodeAuditEntry(buf.Bytes())
+	buf.Free()
+	if err != nil {
+		return err
+	}
+
+	hash, err := auditHash(entry)
+	if err != nil {
+		return err
+	}
+	entry["hash"] = hash
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := c.out.Write(append(line, '\n')); err != nil {
+		return err
+	}
+
+	c.state.seq = seq
+	c.state.prevHash = hash
+	if ent.Level > zapcore.ErrorLevel {
+		return c.out.Sync()
+	}
+	return nil
+}
+
+func (c *auditCore) Sync() error {
+	return c.out.Sync()
+}
+
+// decodeAuditEntry parses a JSON line, keeping numbers in their literal form
+func decodeAuditEntry(line []byte) (map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	var entry map[string]interface{}
+	if err := dec.Decode(&entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// auditHash returns the hex SHA-256 of the canonical JSON form of entry
+// without its hash field
+func auditHash(entry map[string]interface{}) (string, error) {
+	canonical := make(map[string]interface{}, len(entry))
+	for k, v := range entry {
+		if k != "hash" {
+			canonical[k] = v
+		}
+	}
+	data, err := json.Marshal(canonical)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// AuditVerifyError reports the first entry of an audit stream that fails
+// verification
+type AuditVerifyError struct {
+	Line   int    // 1-based line number in the stream
+	Seq    uint64 // sequence number expected at that line
+	Reason string
+}
+
+func (e *AuditVerifyError) Error() string {
+	return fmt.Sprintf("audit log verification failed at line %d (seq %d): %s", e.Line, e.Seq, e.Reason)
+}
+
+// VerifyAuditStream reads an audit log written by NewAudit and checks
+// the sequence numbers and hash chain of every entry. It returns an
+// *AuditVerifyError identifying the first entry that fails.
+func VerifyAuditStream(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	prevHash := genesisHash
+	var seq uint64
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		seq++
+		fail := func(format string, args ...interface{}) error {
+			return &AuditVerifyError{Line: line, Seq: seq, Reason: fmt.Sprintf(format, args...)}
+		}
+
+		entry, err := decodeAuditEntry(scanner.Bytes())
+		if err != nil {
+			return fail("malformed entry: %v", err)
+		}
+		if got := fmt.Sprint(entry["seq"]); got != fmt.Sprint(seq) {
+			return fail("sequence number %s out of order", got)
+		}
+		if got, _ := entry["prev_hash"].(string); got != prevHash {
+			return fail("prev_hash does not match the previous entry")
+		}
+		recorded, _ := entry["hash"].(string)
+		hash, err := auditHash(entry)
+		if err != nil {
+			return fail("cannot hash entry: %v", err)
+		}
+		if recorded != hash {
+			return fail("hash mismatch, entry was modified")
+		}
+		prevHash = hash
+	}
+	return scanner.Err()
+}
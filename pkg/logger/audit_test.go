@@ -0,0 +1,157 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"go-examples/pkg/logger"
+)
+
+// writeAuditEntries writes n entries to a fresh audit log and returns its lines
+func writeAuditEntries(t *testing.T, n int) []string {
+	t.Helper()
+	var buf bytes.Buffer
+	log, err := logger.NewAudit(&buf)
+	if err != nil {
+		t.Fatalf("NewAudit returned error: %v", err)
+	}
+	for i := 1; i <= n; i++ {
+		log.With("user", "alice", "amount", i*10).Infof("transfer %d", i)
+	}
+	return strings.Split(strings.TrimSpace(buf.String()), "\n")
+}
+
+// TestAuditChain tests that entries carry sequence numbers and chained hashes
+func TestAuditChain(t *testing.T) {
+	lines := writeAuditEntries(t, 3)
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d", len(lines))
+	}
+
+	prevHash := strings.Repeat("0", 64)
+	for i, line := range lines {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("line %d is not JSON: %v", i+1, err)
+		}
+		if entry["seq"] != float64(i+1) {
+			t.Errorf("line %d: seq = %v; want %d", i+1, entry["seq"], i+1)
+		}
+		if entry["prev_hash"] != prevHash {
+			t.Errorf("line %d: prev_hash = %v; want %v", i+1, entry["prev_hash"], prevHash)
+		}
+		hash, _ := entry["hash"].(string)
+		if len(hash) != 64 {
+			t.Errorf("line %d: invalid hash %q", i+1, hash)
+		}
+		prevHash = hash
+	}
+
+	if err := logger.VerifyAuditStream(strings.NewReader(strings.Join(lines, "\n"))); err != nil {
+		t.Errorf("VerifyAuditStream returned error for an intact log: %v", err)
+	}
+}
+
+// TestVerifyAuditStreamDetectsTampering tests that corruption is reported with its sequence number
+func TestVerifyAuditStreamDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name       string
+		corrupt    func(lines []string) []string
+		wantSeq    uint64
+		wantReason string
+	}{
+		{
+			name: "modified field",
+			corrupt: func(lines []string) []string {
+				lines[2] = strings.Replace(lines[2], `"amount":30`, `"amount":3000`, 1)
+				return lines
+			},
+			wantSeq:    3,
+			wantReason: "hash mismatch",
+		},
+		{
+			name: "deleted line",
+			corrupt: func(lines []string) []string {
+				return append(lines[:1], lines[2:]...)
+			},
+			wantSeq:    2,
+			wantReason: "out of order",
+		},
+		{
+			name: "reordered lines",
+			corrupt: func(lines []string) []string {
+				lines[3], lines[4] = lines[4], lines[3]
+				return lines
+			},
+			wantSeq:    4,
+			wantReason: "out of order",
+		},
+		{
+			name: "truncated line",
+			corrupt: func(lines []string) []string {
+				lines[1] = lines[1][:len(lines[1])/2]
+				return lines
+			},
+			wantSeq:    2,
+			wantReason: "malformed entry",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			lines := tc.corrupt(writeAuditEntries(t, 5))
+			err := logger.VerifyAuditStream(strings.NewReader(strings.Join(lines, "\n")))
+
+			var verifyErr *logger.AuditVerifyError
+			if !errors.As(err, &verifyErr) {
+				t.Fatalf("expected *AuditVerifyError, got %v", err)
+			}
+			if verifyErr.Seq != tc.wantSeq {
+				t.Errorf("Seq = %d; want %d (%v)", verifyErr.Seq, tc.wantSeq, err)
+			}
+			if !strings.Contains(verifyErr.Reason, tc.wantReason) {
+				t.Errorf("Reason = %q; want it to contain %q", verifyErr.Reason, tc.wantReason)
+			}
+		})
+	}
+}
+
+// TestNewAuditRejectsLowLevels tests that audit loggers refuse Debug filtering
+func TestNewAuditRejectsLowLevels(t *testing.T) {
+	spec, err := logger.ParseLevelSpec("info,audit=debug")
+	if err != nil {
+		t.Fatalf("ParseLevelSpec returned error: %v", err)
+	}
+	if _, err := logger.NewAudit(&bytes.Buffer{}, logger.WithLevelSpec(spec)); err == nil {
+		t.Error("expected error for a level spec below info")
+	}
+
+	spec, err = logger.ParseLevelSpec("warn")
+	if err != nil {
+		t.Fatalf("ParseLevelSpec returned error: %v", err)
+	}
+	if _, err := logger.NewAudit(&bytes.Buffer{}, logger.WithLevelSpec(spec)); err != nil {
+		t.Errorf("expected levels above info to be accepted, got: %v", err)
+	}
+}
+
+// TestAuditDropsDebug tests that debug entries never reach the audit log
+func TestAuditDropsDebug(t *testing.T) {
+	var buf bytes.Buffer
+	log, err := logger.NewAudit(&buf)
+	if err != nil {
+		t.Fatalf("NewAudit returned error: %v", err)
+	}
+	log.Debug("not audited")
+	log.Info("audited")
+
+	if strings.Contains(buf.String(), "not audited") {
+		t.Errorf("debug entry written to audit log: %s", buf.String())
+	}
+	if err := logger.VerifyAuditStream(&buf); err != nil {
+		t.Errorf("VerifyAuditStream returned error: %v", err)
+	}
+}
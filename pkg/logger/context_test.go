@@ -0,0 +1,25 @@
+package logger_test
+
+import (
+	"context"
+	"testing"
+
+	"go-examples/pkg/logger"
+	"go.uber.org/zap/zapcore"
+)
+
+// TestNewContextFromContext tests storing and retrieving a Logger
+func TestNewContextFromContext(t *testing.T) {
+	log, observed := logger.NewObserved(zapcore.InfoLevel)
+	ctx := logger.NewContext(context.Background(), log.With("request_id", "req-1"))
+
+	logger.FromContext(ctx, &mockLogger{}).Info("from context")
+	if got := observed.FilterField("request_id", "req-1"); len(got) != 1 {
+		t.Errorf("expected the stored logger to be returned, got %d entries", len(got))
+	}
+
+	fallback := &mockLogger{}
+	if got := logger.FromContext(context.Background(), fallback); got != fallback {
+		t.Error("expected the fallback for a context without a logger")
+	}
+}
@@ -0,0 +1,68 @@
+package logger_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"go-examples/pkg/logger"
+	"go.uber.org/zap/zapcore"
+)
+
+// syncBuffer is a buffer that counts Sync calls
+type syncBuffer struct {
+	bytes.Buffer
+	syncs int
+}
+
+func (b *syncBuffer) Sync() error {
+	b.syncs++
+	return nil
+}
+
+// TestWithExitFunc tests that Fatal logs, syncs, and calls the injected exit function
+func TestWithExitFunc(t *testing.T) {
+	tests := []struct {
+		name    string
+		logFunc func(log logger.Logger)
+		message string
+	}{
+		{
+			name:    "Fatal",
+			logFunc: func(log logger.Logger) { log.Fatal("server failed") },
+			message: "server failed",
+		},
+		{
+			name:    "Fatalf",
+			logFunc: func(log logger.Logger) { log.Fatalf("server failed: %s", "port in use") },
+			message: "server failed: port in use",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf syncBuffer
+			var codes []int
+			var syncsAtExit int
+			log := logger.NewCustom(zapcore.InfoLevel, true,
+				logger.WithOutput(&buf),
+				logger.WithExitFunc(func(code int) {
+					codes = append(codes, code)
+					syncsAtExit = buf.syncs
+				}),
+			)
+
+			tc.logFunc(log)
+
+			if !strings.Contains(buf.String(), tc.message) {
+				t.Errorf("expected output to contain %q, got: %s", tc.message, buf.String())
+			}
+			if len(codes) != 1 || codes[0] != 1 {
+				t.Errorf("exit codes = %v; want [1]", codes)
+			}
+			if syncsAtExit == 0 {
+				t.Error("expected output to be synced before exit")
+			}
+		})
+	}
+}
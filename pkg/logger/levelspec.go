@@ -0,0 +1,170 @@
+package logger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// LevelSpec holds a default minimum level plus per-module overrides.
+// Modules are matched against the name given to Named loggers; the
+// most specific (longest) dot-separated prefix wins.
+type LevelSpec struct {
+	Default zapcore.Level
+	Modules map[string]zapcore.Level
+}
+
+// ParseLevelSpec parses a spec such as "info,calculator=debug,server.http=warn".
+// A bare level sets the default; module=level pairs add overrides.
+// A spec without a bare level defaults to info.
+func ParseLevelSpec(spec string) (LevelSpec, error) {
+	ls := LevelSpec{
+		Default: zapcore.InfoLevel,
+		Modules: map[string]zapcore.Level{},
+	}
+	if strings.TrimSpace(spec) == "" {
+		return ls, fmt.Errorf("empty level spec")
+	}
+
+	defaultSet := false
+	for i, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return ls, fmt.Errorf("level spec %q: empty entry at position %d", spec, i+1)
+		}
+
+		name, levelText, hasModule := strings.Cut(part, "=")
+		if !hasModule {
+			if defaultSet {
+				return ls, fmt.Errorf("level spec %q: default level given more than once", spec)
+			}
+			level, err := parseLevel(part)
+			if err != nil {
+				return ls, fmt.Errorf("level spec %q: %v", spec, err)
+			}
+			ls.Default = level
+			defaultSet = true
+			continue
+		}
+
+		name = strings.TrimSpace(name)
+		if name == "" || strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".") {
+			return ls, fmt.Errorf("level spec %q: invalid module name in %q", spec, part)
+		}
+		if _, exists := ls.Modules[name]; exists {
+			return ls, fmt.Errorf("level spec %q: module %q given more than once", spec, name)
+		}
+		level, err := parseLevel(strings.TrimSpace(levelText))
+		if err != nil {
+			return ls, fmt.Errorf("level spec %q: module %q: %v", spec, name, err)
+		}
+		ls.Modules[name] = level
+	}
+	return ls, nil
+}
+
+// parseLevel converts a level name into a zapcore.Level
+func parseLevel(text string) (zapcore.Level, error) {
+	var level zapcore.Level
+	if text == "" {
+		return level, fmt.Errorf("missing level")
+	}
+	if err := level.UnmarshalText([]byte(strings.ToLower(text))); err != nil {
+		return level, fmt.Errorf("unknown level %q", text)
+	}
+	return level, nil
+}
+
+// LevelFor returns the minimum level for the named logger
+func (ls LevelSpec) LevelFor(name string) zapcore.Level {
+	level := ls.Default
+	matched := -1
+	for module, moduleLevel := range ls.Modules {
+		if len(module) <= matched {
+			continue
+		}
+		if name == module || strings.HasPrefix(name, module+".") {
+			level = moduleLevel
+			matched = len(module)
+		}
+	}
+	return level
+}
+
+// MinLevel returns the lowest level enabled for any module
+func (ls LevelSpec) MinLevel() zapcore.Level {
+	level := ls.Default
+	for _, moduleLevel := range ls.Modules {
+		if moduleLevel < level {
+			level = moduleLevel
+		}
+	}
+	return level
+}
+
+// String formats the spec in the syntax accepted by ParseLevelSpec
+func (ls LevelSpec) String() string {
+	parts := []string{ls.Default.String()}
+	modules := make([]string, 0, len(ls.Modules))
+	for module := range ls.Modules {
+		modules = append(modules, module)
+	}
+	sort.Strings(modules)
+	for _, module := range modules {
+		parts = append(parts, module+"="+ls.Modules[module].String())
+	}
+	return strings.Join(parts, ",")
+}
+
+// levelSpecVar holds the LevelSpec shared by a logger and the loggers
+// derived from it, so SetLevelSpec changes all of them
+type levelSpecVar struct {
+	spec  atomic.Pointer[LevelSpec]
+	floor zapcore.Level // lowest level the underlying core writes
+}
+
+// levelSpecCore filters entries by the level configured for their logger name
+type levelSpecCore struct {
+	zapcore.Core
+	levels *levelSpecVar
+}
+
+func (c *levelSpecCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelSpecCore{Core: c.Core.With(fields), levels: c.levels}
+}
+
+func (c *levelSpecCore) Enabled(level zapcore.Level) bool {
+	return level >= c.levels.spec.Load().MinLevel() && c.Core.Enabled(level)
+}
+
+func (c *levelSpecCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level < c.levels.spec.Load().LevelFor(ent.LoggerName) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// SetLevelSpec changes the levels of a logger built with WithLevelSpec,
+// and of every logger derived from it, while they are in use
+func (l *zapLogger) SetLevelSpec(ls LevelSpec) error {
+	if l.levels == nil {
+		return fmt.Errorf("logger was not built with a level spec")
+	}
+	if ls.MinLevel() < l.levels.floor {
+		return fmt.Errorf("logger does not allow levels below %s, got %q", l.levels.floor, ls.String())
+	}
+	l.levels.spec.Store(&ls)
+	return nil
+}
+
+// SetLevelSpec changes the levels of l at runtime when the
+// implementation supports it, and returns an error otherwise
+func SetLevelSpec(l Logger, ls LevelSpec) error {
+	if s, ok := l.(interface{ SetLevelSpec(LevelSpec) error }); ok {
+		return s.SetLevelSpec(ls)
+	}
+	return fmt.Errorf("%T does not support changing levels", l)
+}
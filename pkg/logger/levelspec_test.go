@@ -0,0 +1,204 @@
+package logger_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"go-examples/pkg/logger"
+	"go.uber.org/zap/zapcore"
+)
+
+// TestParseLevelSpec tests parsing of valid level specs
+func TestParseLevelSpec(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        string
+		wantDefault zapcore.Level
+		wantModules map[string]zapcore.Level
+	}{
+		{
+			name:        "plain level",
+			spec:        "info",
+			wantDefault: zapcore.InfoLevel,
+			wantModules: map[string]zapcore.Level{},
+		},
+		{
+			name:        "uppercase level",
+			spec:        "DEBUG",
+			wantDefault: zapcore.DebugLevel,
+			wantModules: map[string]zapcore.Level{},
+		},
+		{
+			name:        "default with overrides",
+			spec:        "info,calculator=debug,server.http=warn",
+			wantDefault: zapcore.InfoLevel,
+			wantModules: map[string]zapcore.Level{
+				"calculator":  zapcore.DebugLevel,
+				"server.http": zapcore.WarnLevel,
+			},
+		},
+		{
+			name:        "overrides only",
+			spec:        "calculator=error",
+			wantDefault: zapcore.InfoLevel,
+			wantModules: map[string]zapcore.Level{"calculator": zapcore.ErrorLevel},
+		},
+		{
+			name:        "whitespace around entries",
+			spec:        " warn , calculator = debug ",
+			wantDefault: zapcore.WarnLevel,
+			wantModules: map[string]zapcore.Level{"calculator": zapcore.DebugLevel},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ls, err := logger.ParseLevelSpec(tc.spec)
+			if err != nil {
+				t.Fatalf("ParseLevelSpec(%q) returned error: %v", tc.spec, err)
+			}
+			if ls.Default != tc.wantDefault {
+				t.Errorf("Default = %v; want %v", ls.Default, tc.wantDefault)
+			}
+			if len(ls.Modules) != len(tc.wantModules) {
+				t.Fatalf("Modules = %v; want %v", ls.Modules, tc.wantModules)
+			}
+			for module, level := range tc.wantModules {
+				if ls.Modules[module] != level {
+					t.Errorf("Modules[%q] = %v; want %v", module, ls.Modules[module], level)
+				}
+			}
+		})
+	}
+}
+
+// TestParseLevelSpecErrors tests that malformed specs are rejected
+func TestParseLevelSpecErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		contains string
+	}{
+		{name: "empty spec", spec: "", contains: "empty level spec"},
+		{name: "unknown level", spec: "verbose", contains: `unknown level "verbose"`},
+		{name: "empty entry", spec: "info,,calculator=debug", contains: "empty entry at position 2"},
+		{name: "missing module name", spec: "=debug", contains: "invalid module name"},
+		{name: "missing module level", spec: "calculator=", contains: "missing level"},
+		{name: "unknown module level", spec: "calculator=loud", contains: `module "calculator"`},
+		{name: "duplicate default", spec: "info,debug", contains: "default level given more than once"},
+		{name: "duplicate module", spec: "calculator=debug,calculator=warn", contains: `module "calculator" given more than once`},
+		{name: "trailing dot", spec: "server.=warn", contains: "invalid module name"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := logger.ParseLevelSpec(tc.spec)
+			if err == nil {
+				t.Fatalf("ParseLevelSpec(%q) expected error", tc.spec)
+			}
+			if !strings.Contains(err.Error(), tc.contains) {
+				t.Errorf("error %q does not contain %q", err.Error(), tc.contains)
+			}
+		})
+	}
+}
+
+// TestLevelSpecPrecedence tests that the most specific prefix wins
+func TestLevelSpecPrecedence(t *testing.T) {
+	ls, err := logger.ParseLevelSpec("warn,server=info,server.http=debug,server.http.admin=error")
+	if err != nil {
+		t.Fatalf("ParseLevelSpec returned error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		want zapcore.Level
+	}{
+		{name: "", want: zapcore.WarnLevel},
+		{name: "calculator", want: zapcore.WarnLevel},
+		{name: "server", want: zapcore.InfoLevel},
+		{name: "server.db", want: zapcore.InfoLevel},
+		{name: "server.http", want: zapcore.DebugLevel},
+		{name: "server.http.client", want: zapcore.DebugLevel},
+		{name: "server.http.admin", want: zapcore.ErrorLevel},
+		{name: "server.http.admin.users", want: zapcore.ErrorLevel},
+		{name: "serverless", want: zapcore.WarnLevel},
+	}
+
+	for _, tc := range tests {
+		if got := ls.LevelFor(tc.name); got != tc.want {
+			t.Errorf("LevelFor(%q) = %v; want %v", tc.name, got, tc.want)
+		}
+	}
+
+	if got := ls.MinLevel(); got != zapcore.DebugLevel {
+		t.Errorf("MinLevel() = %v; want %v", got, zapcore.DebugLevel)
+	}
+	if got := ls.String(); got != "warn,server=info,server.http=debug,server.http.admin=error" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+// TestWithLevelSpecFiltering tests filtering through named loggers end to end
+func TestWithLevelSpecFiltering(t *testing.T) {
+	ls, err := logger.ParseLevelSpec("info,calculator=debug,server.http=warn")
+	if err != nil {
+		t.Fatalf("ParseLevelSpec returned error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	root := logger.NewCustom(zapcore.ErrorLevel, true, logger.WithOutput(&buf), logger.WithLevelSpec(ls))
+	calc := logger.Named(root, "calculator")
+	server := logger.Named(root, "server")
+	httpLog := logger.Named(server, "http")
+
+	root.Debug("root debug")
+	root.Info("root info")
+	calc.Debug("calculator debug")
+	server.Info("server info")
+	httpLog.Info("http info")
+	httpLog.Warn("http warn")
+	logger.Named(calc, "parser").With("key", "value").Debug("parser debug")
+
+	output := buf.String()
+	for _, want := range []string{"root info", "calculator debug", "server info", "http warn", "parser debug"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected output to contain %q, got: %s", want, output)
+		}
+	}
+	for _, unwanted := range []string{"root debug", "http info"} {
+		if strings.Contains(output, unwanted) {
+			t.Errorf("expected output not to contain %q, got: %s", unwanted, output)
+		}
+	}
+}
+
+// TestSetLevelSpec tests changing the levels of a logger and its
+// children while they are in use
+func TestSetLevelSpec(t *testing.T) {
+	var buf bytes.Buffer
+	root := logger.NewCustom(zapcore.InfoLevel, true, logger.WithOutput(&buf), logger.WithLevelSpec(logger.LevelSpec{Default: zapcore.InfoLevel}))
+	calc := logger.Named(root, "calculator").With("key", "value")
+
+	calc.Debug("before")
+	ls, _ := logger.ParseLevelSpec("warn,calculator=debug")
+	if err := logger.SetLevelSpec(root, ls); err != nil {
+		t.Fatal(err)
+	}
+	calc.Debug("after")
+	root.Info("root info")
+
+	output := buf.String()
+	if strings.Contains(output, "before") || strings.Contains(output, "root info") || !strings.Contains(output, "after") {
+		t.Errorf("levels were not changed, got: %s", output)
+	}
+
+	if err := logger.SetLevelSpec(logger.NewCustom(zapcore.InfoLevel, true), ls); err == nil {
+		t.Error("changed the levels of a logger without a level spec")
+	}
+	audit, _ := logger.NewAudit(&buf, logger.WithLevelSpec(logger.LevelSpec{Default: zapcore.InfoLevel}))
+	if err := logger.SetLevelSpec(audit, ls); err == nil {
+		t.Error("lowered an audit logger below info")
+	}
+}
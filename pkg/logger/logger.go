@@ -3,7 +3,7 @@
 package logger
 
 import (
-	"os"
+	"go-examples/pkg/metrics"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -28,7 +28,9 @@ type Logger interface {
 
 // zapLogger wraps zap.SugaredLogger to implement our Logger interface
 type zapLogger struct {
-	sugar *zap.SugaredLogger
+	sugar   *zap.SugaredLogger
+	levels  *levelSpecVar        // nil unless built with WithLevelSpec
+	enabler zapcore.LevelEnabler // the core, whose levels derived loggers share
 }
 
 // NewDevelopment creates a logger with development-friendly defaults
@@ -38,7 +40,7 @@ func NewDevelopment() (Logger, error) {
 		return nil, err
 	}
 	sugar := logger.Sugar()
-	return &zapLogger{sugar: sugar}, nil
+	return &zapLogger{sugar: sugar, enabler: logger.Core()}, nil
 }
 
 // NewProduction creates a logger with production-friendly defaults
@@ -48,13 +50,32 @@ func NewProduction() (Logger, error) {
 		return nil, err
 	}
 	sugar := logger.Sugar()
-	return &zapLogger{sugar: sugar}, nil
+	return &zapLogger{sugar: sugar, enabler: logger.Core()}, nil
 }
 
 // NewCustom creates a logger with custom configuration
-func NewCustom(level zapcore.Level, isProduction bool) Logger {
+func NewCustom(level zapcore.Level, isProduction bool, opts ...Option) Logger {
+	o := newOptions(opts)
+
 	// Create encoder config based on environment
-	encoderConfig := zapcore.EncoderConfig{
+	encoderConfig := newEncoderConfig()
+
+	// Use JSON encoder for production, console encoder for development
+	var encoder zapcore.Encoder
+	if isProduction {
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	} else {
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	}
+
+	return buildLogger(level, o, func(enab zapcore.LevelEnabler) zapcore.Core {
+		return zapcore.NewCore(encoder, o.output, enab)
+	})
+}
+
+// newEncoderConfig returns the encoder settings shared by all constructors
+func newEncoderConfig() zapcore.EncoderConfig {
+	return zapcore.EncoderConfig{
 		TimeKey:        "ts",
 		LevelKey:       "level",
 		NameKey:        "logger",
@@ -68,25 +89,46 @@ func NewCustom(level zapcore.Level, isProduction bool) Logger {
 		EncodeDuration: zapcore.SecondsDurationEncoder,
 		EncodeCaller:   zapcore.ShortCallerEncoder,
 	}
+}
 
-	// Use JSON encoder for production, console encoder for development
-	var encoder zapcore.Encoder
-	if isProduction {
-		encoder = zapcore.NewJSONEncoder(encoderConfig)
+// buildLogger creates the core via newCore and applies the filters and
+// hooks configured by options. When a level spec is set, the core is
+// created at the lowest level allowed, so SetLevelSpec can lower the
+// levels later, and entries are filtered by logger name on top of it.
+func buildLogger(level zapcore.Level, o *options, newCore func(zapcore.LevelEnabler) zapcore.Core) Logger {
+	var core zapcore.Core
+	var levels *levelSpecVar
+	if o.levelSpec != nil {
+		levels = &levelSpecVar{floor: o.floor}
+		levels.spec.Store(o.levelSpec)
+		core = &levelSpecCore{Core: newCore(o.floor), levels: levels}
 	} else {
-		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+		core = newCore(level)
+	}
+	if len(o.static) > 0 {
+		core = core.With(fieldsFromKV(o.static))
 	}
-
-	// Configure output
-	core := zapcore.NewCore(
-		encoder,
-		zapcore.AddSync(os.Stdout),
-		level,
-	)
 
 	// Create logger
-	logger := zap.New(core, zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))
-	return &zapLogger{sugar: logger.Sugar()}
+	zapOpts := []zap.Option{
+		zap.AddCaller(),
+		zap.AddStacktrace(zapcore.ErrorLevel),
+		zap.WithFatalHook(exitHook{core: core, exit: o.exitFunc}),
+	}
+	if o.entries != nil {
+		zapOpts = append(zapOpts, zap.Hooks(countEntries(o.entries)))
+	}
+	logger := zap.New(core, zapOpts...)
+	return &zapLogger{sugar: logger.Sugar(), levels: levels, enabler: core}
+}
+
+// countEntries returns a hook adding each written entry to entries,
+// labelled with its level
+func countEntries(entries metrics.Counter) func(zapcore.Entry) error {
+	return func(e zapcore.Entry) error {
+		entries.Add(1, e.Level.String())
+		return nil
+	}
 }
 
 // Implementation of Logger interface methods
@@ -102,5 +144,50 @@ func (l *zapLogger) Errorf(template string, args ...interface{}) { l.sugar.Error
 func (l *zapLogger) Fatalf(template string, args ...interface{}) { l.sugar.Fatalf(template, args...) }
 
 func (l *zapLogger) With(args ...interface{}) Logger {
-	return &zapLogger{sugar: l.sugar.With(args...)}
+	return &zapLogger{sugar: l.sugar.With(args...), levels: l.levels, enabler: l.enabler}
+}
+
+// Named returns a child logger whose name is appended to the parent's,
+// separated by a dot. Level specs match modules against this name.
+func (l *zapLogger) Named(name string) Logger {
+	return &zapLogger{sugar: l.sugar.Named(name), levels: l.levels, enabler: l.enabler}
+}
+
+// Named returns a named child of l when the implementation supports
+// naming, and l unchanged otherwise.
+func Named(l Logger, name string) Logger {
+	if n, ok := l.(interface{ Named(string) Logger }); ok {
+		return n.Named(name)
+	}
+	return l
+}
+
+// LevelEnabled reports whether entries at level may be written, before
+// the level specs of WithLevelSpec filter them by logger name
+func (l *zapLogger) LevelEnabled(level zapcore.Level) bool {
+	return l.enabler.Enabled(level)
+}
+
+// LevelEnabled reports whether l writes entries at level, when the
+// implementation can tell, and true otherwise. Hot paths check it to
+// skip building the arguments of messages that would be dropped.
+func LevelEnabled(l Logger, level zapcore.Level) bool {
+	if e, ok := l.(interface{ LevelEnabled(zapcore.Level) bool }); ok {
+		return e.LevelEnabled(level)
+	}
+	return true
+}
+
+// Sync flushes buffered entries to the output
+func (l *zapLogger) Sync() error {
+	return l.sugar.Sync()
+}
+
+// Sync flushes l when the implementation buffers output, and returns nil
+// otherwise.
+func Sync(l Logger) error {
+	if s, ok := l.(interface{ Sync() error }); ok {
+		return s.Sync()
+	}
+	return nil
 }
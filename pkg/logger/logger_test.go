@@ -273,6 +273,36 @@ func TestStructuredLogging(t *testing.T) {
 	// but it will be displayed in the test output
 }
 
+// TestLevelEnabled tests reporting the levels a logger writes, through
+// derived loggers and level specs, and for loggers that cannot tell
+func TestLevelEnabled(t *testing.T) {
+	log, _ := logger.NewObserved(zapcore.InfoLevel)
+	for _, l := range []logger.Logger{log, log.With("key", "value"), logger.Named(log, "calculator")} {
+		if logger.LevelEnabled(l, zapcore.DebugLevel) {
+			t.Error("debug level enabled for an info logger")
+		}
+		if !logger.LevelEnabled(l, zapcore.InfoLevel) || !logger.LevelEnabled(l, zapcore.ErrorLevel) {
+			t.Error("info or error level disabled for an info logger")
+		}
+	}
+
+	root := logger.NewCustom(zapcore.InfoLevel, true, logger.WithOutput(&bytes.Buffer{}), logger.WithLevelSpec(logger.LevelSpec{Default: zapcore.ErrorLevel}))
+	if logger.LevelEnabled(root, zapcore.WarnLevel) {
+		t.Error("warn level enabled under an error level spec")
+	}
+	ls, _ := logger.ParseLevelSpec("error,calculator=debug")
+	if err := logger.SetLevelSpec(root, ls); err != nil {
+		t.Fatal(err)
+	}
+	if !logger.LevelEnabled(logger.Named(root, "calculator"), zapcore.DebugLevel) {
+		t.Error("debug level disabled after enabling it for calculator")
+	}
+
+	if !logger.LevelEnabled(&mockLogger{}, zapcore.DebugLevel) {
+		t.Error("a logger that cannot tell reported a disabled level")
+	}
+}
+
 // mockLogger is a mock implementation of Logger for testing
 type mockLogger struct{}
 
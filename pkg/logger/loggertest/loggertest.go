@@ -0,0 +1,92 @@
+// Package loggertest provides loggers for tests that write to the test's
+// log output and record every line, including Fatal entries, without
+// terminating the test process.
+package loggertest
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"go-examples/pkg/logger"
+	"go.uber.org/zap/zapcore"
+)
+
+// Recorder captures the output, syncs, and exit calls of a test logger
+type Recorder struct {
+	t testing.TB
+
+	mu    sync.Mutex
+	lines []string
+	exits []int
+	syncs int
+}
+
+// New creates a debug-level logger for t. Output is written to t.Log and
+// recorded; Fatal entries are recorded and their exit code captured
+// instead of exiting. Additional options are applied after the defaults.
+func New(t testing.TB, opts ...logger.Option) (logger.Logger, *Recorder) {
+	t.Helper()
+	rec := &Recorder{t: t}
+	defaults := []logger.Option{
+		logger.WithOutput(rec),
+		logger.WithExitFunc(rec.exit),
+	}
+	log := logger.NewCustom(zapcore.DebugLevel, false, append(defaults, opts...)...)
+	return log, rec
+}
+
+// Write records one encoded log entry and mirrors it to the test log
+func (r *Recorder) Write(p []byte) (int, error) {
+	line := strings.TrimSuffix(string(p), "\n")
+	r.mu.Lock()
+	r.lines = append(r.lines, line)
+	r.mu.Unlock()
+	r.t.Log(line)
+	return len(p), nil
+}
+
+// Sync records that the logger flushed its output
+func (r *Recorder) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.syncs++
+	return nil
+}
+
+func (r *Recorder) exit(code int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exits = append(r.exits, code)
+}
+
+// Lines returns a copy of the recorded log lines
+func (r *Recorder) Lines() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.lines...)
+}
+
+// Contains reports whether any recorded line contains s
+func (r *Recorder) Contains(s string) bool {
+	for _, line := range r.Lines() {
+		if strings.Contains(line, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExitCodes returns the codes passed to the exit function by Fatal entries
+func (r *Recorder) ExitCodes() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]int(nil), r.exits...)
+}
+
+// Syncs returns how many times the logger synced its output
+func (r *Recorder) Syncs() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.syncs
+}
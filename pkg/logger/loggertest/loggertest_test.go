@@ -0,0 +1,40 @@
+package loggertest_test
+
+import (
+	"testing"
+
+	"go-examples/pkg/logger/loggertest"
+)
+
+// TestNewRecordsEntries tests that entries are recorded
+func TestNewRecordsEntries(t *testing.T) {
+	log, rec := loggertest.New(t)
+
+	log.Debug("debug message")
+	log.With("key", "value").Infof("info %d", 42)
+
+	lines := rec.Lines()
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %v", len(lines), lines)
+	}
+	if !rec.Contains("debug message") || !rec.Contains("info 42") || !rec.Contains("value") {
+		t.Errorf("expected recorded messages and fields, got: %v", lines)
+	}
+}
+
+// TestNewCapturesFatal tests that Fatal entries are recorded without exiting
+func TestNewCapturesFatal(t *testing.T) {
+	log, rec := loggertest.New(t)
+
+	log.Fatal("server failed")
+
+	if !rec.Contains("server failed") {
+		t.Errorf("expected fatal entry to be recorded, got: %v", rec.Lines())
+	}
+	if codes := rec.ExitCodes(); len(codes) != 1 || codes[0] != 1 {
+		t.Errorf("exit codes = %v; want [1]", codes)
+	}
+	if rec.Syncs() == 0 {
+		t.Error("expected the logger to sync before exiting")
+	}
+}
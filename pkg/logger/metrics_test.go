@@ -0,0 +1,37 @@
+package logger_test
+
+import (
+	"strings"
+	"testing"
+
+	"go-examples/pkg/logger"
+	"go-examples/pkg/metrics/prommetrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap/zapcore"
+)
+
+// TestWithMetrics tests that written entries are counted by level and
+// filtered ones are not
+func TestWithMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	log, _ := logger.NewObserved(zapcore.InfoLevel, logger.WithMetrics(prommetrics.New(reg)))
+
+	log.Debug("filtered")
+	log.Info("one")
+	log.With("k", "v").Info("two")
+	logger.Named(log, "calculator").Warn("three")
+	log.Error("four")
+
+	want := `
+# HELP log_entries_total Log entries written, by level.
+# TYPE log_entries_total counter
+log_entries_total{level="error"} 1
+log_entries_total{level="info"} 2
+log_entries_total{level="warn"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
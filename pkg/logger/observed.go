@@ -0,0 +1,137 @@
+package logger
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// ObservedEntry is a snapshot of one entry captured by an observed logger.
+// Field values keep their encoded types: integers are int64, durations
+// are time.Duration, errors are their message strings, and so on.
+type ObservedEntry struct {
+	Level      zapcore.Level
+	Message    string
+	LoggerName string
+	Time       time.Time
+	Fields     map[string]interface{}
+}
+
+// Observed gives access to the entries captured by a logger created with
+// NewObserved. It is safe to read while logging continues.
+type Observed struct {
+	logs *observer.ObservedLogs
+}
+
+// NewObserved creates a logger that keeps entries at or above level in
+// memory so tests can assert on messages and structured fields without
+// parsing encoded output.
+func NewObserved(level zapcore.Level, opts ...Option) (Logger, *Observed) {
+	o := newOptions(opts)
+	var logs *observer.ObservedLogs
+	log := buildLogger(level, o, func(enab zapcore.LevelEnabler) zapcore.Core {
+		var core zapcore.Core
+		core, logs = observer.New(enab)
+		return core
+	})
+	return log, &Observed{logs: logs}
+}
+
+// Len returns the number of captured entries
+func (o *Observed) Len() int {
+	return o.logs.Len()
+}
+
+// All returns snapshots of every captured entry in logging order
+func (o *Observed) All() []ObservedEntry {
+	return snapshot(o.logs.All())
+}
+
+// FilterLevel returns the entries logged exactly at level
+func (o *Observed) FilterLevel(level zapcore.Level) []ObservedEntry {
+	return snapshot(o.logs.FilterLevelExact(level).All())
+}
+
+// FilterMessageContains returns the entries whose message contains s
+func (o *Observed) FilterMessageContains(s string) []ObservedEntry {
+	return o.filter(func(e ObservedEntry) bool {
+		return strings.Contains(e.Message, s)
+	})
+}
+
+// FilterField returns the entries with a field named key equal to value.
+// Numeric values compare by value regardless of their Go integer or
+// float type, so FilterField("a", 10) matches an int64 field.
+func (o *Observed) FilterField(key string, value interface{}) []ObservedEntry {
+	return o.filter(func(e ObservedEntry) bool {
+		got, ok := e.Fields[key]
+		return ok && valuesEqual(got, value)
+	})
+}
+
+func (o *Observed) filter(keep func(ObservedEntry) bool) []ObservedEntry {
+	var entries []ObservedEntry
+	for _, e := range o.All() {
+		if keep(e) {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// snapshot converts observer entries into independent ObservedEntry values
+func snapshot(logged []observer.LoggedEntry) []ObservedEntry {
+	entries := make([]ObservedEntry, 0, len(logged))
+	for _, e := range logged {
+		entries = append(entries, ObservedEntry{
+			Level:      e.Level,
+			Message:    e.Message,
+			LoggerName: e.LoggerName,
+			Time:       e.Time,
+			Fields:     e.ContextMap(),
+		})
+	}
+	return entries
+}
+
+// valuesEqual compares field values, treating numbers of different
+// types as equal when they represent the same value
+func valuesEqual(a, b interface{}) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	switch {
+	case isInt(va) && isInt(vb):
+		return va.Int() == vb.Int()
+	case isUint(va) && isUint(vb):
+		return va.Uint() == vb.Uint()
+	case isInt(va) && isUint(vb):
+		return va.Int() >= 0 && uint64(va.Int()) == vb.Uint()
+	case isUint(va) && isInt(vb):
+		return vb.Int() >= 0 && va.Uint() == uint64(vb.Int())
+	case isFloat(va) && isFloat(vb):
+		return va.Float() == vb.Float()
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func isInt(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+func isUint(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return false
+}
+
+func isFloat(v reflect.Value) bool {
+	return v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64
+}
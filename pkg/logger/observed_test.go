@@ -0,0 +1,132 @@
+package logger_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"go-examples/pkg/logger"
+	"go.uber.org/zap/zapcore"
+)
+
+// TestNewObserved tests capturing entries with typed fields
+func TestNewObserved(t *testing.T) {
+	log, observed := logger.NewObserved(zapcore.InfoLevel)
+
+	log.Debug("filtered out")
+	log.With("count", 3, "ratio", 0.5, "name", "calc").Info("first")
+	log.With("elapsed", 2*time.Second, "err", errors.New("boom")).Warnf("second %d", 2)
+	logger.Named(log, "calculator").Error("third")
+
+	all := observed.All()
+	if len(all) != 3 || observed.Len() != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(all))
+	}
+
+	first := all[0]
+	if first.Level != zapcore.InfoLevel || first.Message != "first" {
+		t.Errorf("unexpected first entry: %+v", first)
+	}
+	if first.Fields["count"] != int64(3) {
+		t.Errorf("count = %#v; want int64(3)", first.Fields["count"])
+	}
+	if first.Fields["ratio"] != 0.5 || first.Fields["name"] != "calc" {
+		t.Errorf("unexpected fields: %v", first.Fields)
+	}
+
+	second := all[1]
+	if second.Message != "second 2" {
+		t.Errorf("Message = %q; want %q", second.Message, "second 2")
+	}
+	if second.Fields["elapsed"] != 2*time.Second {
+		t.Errorf("elapsed = %#v; want 2s", second.Fields["elapsed"])
+	}
+	if second.Fields["err"] != "boom" {
+		t.Errorf("err = %#v; want %q", second.Fields["err"], "boom")
+	}
+
+	if all[2].LoggerName != "calculator" {
+		t.Errorf("LoggerName = %q; want %q", all[2].LoggerName, "calculator")
+	}
+}
+
+// TestObservedFilters tests the filter helpers
+func TestObservedFilters(t *testing.T) {
+	log, observed := logger.NewObserved(zapcore.DebugLevel)
+
+	log.With("a", 10, "b", 0).Error("Division by zero")
+	log.With("a", 10, "b", 2).Debug("Division result")
+	log.With("op", "add").Info("Calculating addition")
+
+	if got := observed.FilterLevel(zapcore.ErrorLevel); len(got) != 1 || got[0].Message != "Division by zero" {
+		t.Errorf("FilterLevel(Error) = %+v", got)
+	}
+	if got := observed.FilterMessageContains("Division"); len(got) != 2 {
+		t.Errorf("FilterMessageContains(Division) returned %d entries; want 2", len(got))
+	}
+	if got := observed.FilterField("a", 10); len(got) != 2 {
+		t.Errorf("FilterField(a, 10) returned %d entries; want 2", len(got))
+	}
+	if got := observed.FilterField("b", uint8(0)); len(got) != 1 {
+		t.Errorf("FilterField(b, uint8(0)) returned %d entries; want 1", len(got))
+	}
+	if got := observed.FilterField("op", "add"); len(got) != 1 {
+		t.Errorf("FilterField(op, add) returned %d entries; want 1", len(got))
+	}
+	if got := observed.FilterField("missing", 1); len(got) != 0 {
+		t.Errorf("FilterField(missing) returned %d entries; want 0", len(got))
+	}
+}
+
+// TestObservedSnapshotsAreIndependent tests that returned entries are copies
+func TestObservedSnapshotsAreIndependent(t *testing.T) {
+	log, observed := logger.NewObserved(zapcore.InfoLevel)
+	log.With("key", "original").Info("message")
+
+	entries := observed.All()
+	entries[0].Fields["key"] = "changed"
+
+	if got := observed.All()[0].Fields["key"]; got != "original" {
+		t.Errorf("stored field changed to %v through a snapshot", got)
+	}
+}
+
+// TestObservedConcurrentAccess tests reading entries while logging continues.
+// Run with -race to detect unsynchronized access.
+func TestObservedConcurrentAccess(t *testing.T) {
+	log, observed := logger.NewObserved(zapcore.InfoLevel)
+
+	const writers, perWriter = 8, 100
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				log.With("writer", w, "i", i).Infof("entry %d", i)
+			}
+		}(w)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			_ = observed.All()
+			_ = observed.FilterField("writer", i%writers)
+			_ = observed.FilterMessageContains(fmt.Sprint(i))
+		}
+	}()
+
+	wg.Wait()
+	<-done
+
+	if got := observed.Len(); got != writers*perWriter {
+		t.Errorf("Len() = %d; want %d", got, writers*perWriter)
+	}
+	if got := observed.FilterField("writer", 3); len(got) != perWriter {
+		t.Errorf("FilterField(writer, 3) returned %d entries; want %d", len(got), perWriter)
+	}
+}
@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"go-examples/pkg/metrics"
+	"io"
+	"os"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Option configures optional behavior of loggers built by NewCustom
+type Option func(*options)
+
+// options holds the settings collected from Option values
+type options struct {
+	output    zapcore.WriteSyncer
+	levelSpec *LevelSpec
+	floor     zapcore.Level // lowest level a level spec may enable
+	exitFunc  func(int)
+	static    []interface{}
+	entries   metrics.Counter
+}
+
+// newOptions applies opts on top of the defaults
+func newOptions(opts []Option) *options {
+	o := &options{
+		output:   zapcore.AddSync(os.Stdout),
+		exitFunc: os.Exit,
+		floor:    zapcore.DebugLevel,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithOutput sends log output to w instead of stdout
+func WithOutput(w io.Writer) Option {
+	return func(o *options) {
+		o.output = zapcore.AddSync(w)
+	}
+}
+
+// WithLevelSpec applies a default level plus per-module overrides.
+// It takes precedence over the level passed to NewCustom.
+func WithLevelSpec(ls LevelSpec) Option {
+	return func(o *options) {
+		o.levelSpec = &ls
+	}
+}
+
+// WithStaticFields attaches key-value pairs to every entry. The fields
+// are added to the core, so loggers derived with With inherit them and
+// can override a key by setting it again.
+func WithStaticFields(kv ...interface{}) Option {
+	return func(o *options) {
+		o.static = append(o.static, kv...)
+	}
+}
+
+// WithServiceInfo attaches service, version, and env fields identifying
+// the running service to every entry
+func WithServiceInfo(name, version, env string) Option {
+	return WithStaticFields("service", name, "version", version, "env", env)
+}
+
+// WithProcessInfo attaches hostname and pid fields to every entry
+func WithProcessInfo() Option {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return WithStaticFields("hostname", hostname, "pid", os.Getpid())
+}
+
+// WithExitFunc replaces os.Exit as the function called after a Fatal
+// entry has been written and synced. Tests use it to exercise Fatal
+// paths without terminating the process.
+func WithExitFunc(fn func(int)) Option {
+	return func(o *options) {
+		o.exitFunc = fn
+	}
+}
+
+// exitHook runs after Fatal entries: it syncs the core and then calls
+// the configured exit function with status 1
+type exitHook struct {
+	core zapcore.Core
+	exit func(int)
+}
+
+func (h exitHook) OnWrite(_ *zapcore.CheckedEntry, _ []zapcore.Field) {
+	_ = h.core.Sync()
+	h.exit(1)
+}
+
+// WithMetrics counts the entries written, by level, in a
+// log_entries_total counter created with p
+func WithMetrics(p metrics.Provider) Option {
+	return func(o *options) {
+		o.entries = metrics.OrNoop(p).Counter("log_entries_total", "Log entries written, by level.", "level")
+	}
+}
@@ -0,0 +1,188 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// maxRateLimitKeys bounds how many distinct messages are tracked before
+// idle windows are pruned
+const maxRateLimitKeys = 1024
+
+// RateLimitOption configures a logger created by NewRateLimited
+type RateLimitOption func(*rateLimited)
+
+// RateLimitAllLevels applies the budget to Debug and Info as well.
+// By default only Warn and Error are limited.
+func RateLimitAllLevels() RateLimitOption {
+	return func(r *rateLimited) {
+		r.allLevels = true
+	}
+}
+
+// NewRateLimited wraps l so that each distinct Warn or Error message is
+// emitted at most perInterval times per interval. Messages beyond the
+// budget are counted, and the next allowed emission of the same message
+// is preceded by a "suppressed N similar messages" summary. Formatted
+// calls are keyed by their template, so varying arguments share a budget.
+// Fatal is never limited. Derived loggers share the budget.
+func NewRateLimited(l Logger, perInterval int, interval time.Duration, opts ...RateLimitOption) Logger {
+	r := &rateLimited{
+		Logger: l,
+		state: &rateLimitState{
+			perInterval: perInterval,
+			interval:    interval,
+			windows:     map[string]*rateWindow{},
+		},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// rateLimited is a Logger that applies a shared rate limit state
+type rateLimited struct {
+	Logger
+	state     *rateLimitState
+	allLevels bool
+}
+
+// rateLimitState tracks one fixed window per message key
+type rateLimitState struct {
+	mu          sync.Mutex
+	perInterval int
+	interval    time.Duration
+	windows     map[string]*rateWindow
+}
+
+type rateWindow struct {
+	start      time.Time
+	count      int
+	suppressed int
+}
+
+// allow reports whether a message with key may be emitted now, and how
+// many earlier messages with the same key were suppressed
+func (s *rateLimitState) allow(key string) (bool, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := Now()
+	w, ok := s.windows[key]
+	if !ok {
+		s.prune(now)
+		w = &rateWindow{start: now}
+		s.windows[key] = w
+	} else if now.Sub(w.start) >= s.interval {
+		// Start a new window; suppressed counts carry over until reported
+		w.start = now
+		w.count = 0
+	}
+
+	if w.count >= s.perInterval {
+		w.suppressed++
+		return false, 0
+	}
+	w.count++
+	suppressed := w.suppressed
+	w.suppressed = 0
+	return true, suppressed
+}
+
+// prune drops expired windows with nothing left to report once the
+// number of tracked keys reaches maxRateLimitKeys
+func (s *rateLimitState) prune(now time.Time) {
+	if len(s.windows) < maxRateLimitKeys {
+		return
+	}
+	for key, w := range s.windows {
+		if w.suppressed == 0 && now.Sub(w.start) >= s.interval {
+			delete(s.windows, key)
+		}
+	}
+}
+
+// emit logs through log when the budget allows it, first reporting any
+// suppressed messages for the same key
+func (r *rateLimited) emit(level zapcore.Level, key string, log func(Logger)) {
+	ok, suppressed := r.state.allow(level.String() + "\x00" + key)
+	if !ok {
+		return
+	}
+	if suppressed > 0 {
+		summary := r.Logger.With("message", key, "suppressed", suppressed)
+		msg := fmt.Sprintf("suppressed %d similar messages", suppressed)
+		if level == zapcore.ErrorLevel {
+			summary.Error(msg)
+		} else {
+			summary.Warn(msg)
+		}
+	}
+	log(r.Logger)
+}
+
+func (r *rateLimited) Debug(args ...interface{}) {
+	if !r.allLevels {
+		r.Logger.Debug(args...)
+		return
+	}
+	r.emit(zapcore.DebugLevel, fmt.Sprint(args...), func(l Logger) { l.Debug(args...) })
+}
+
+func (r *rateLimited) Info(args ...interface{}) {
+	if !r.allLevels {
+		r.Logger.Info(args...)
+		return
+	}
+	r.emit(zapcore.InfoLevel, fmt.Sprint(args...), func(l Logger) { l.Info(args...) })
+}
+
+func (r *rateLimited) Warn(args ...interface{}) {
+	r.emit(zapcore.WarnLevel, fmt.Sprint(args...), func(l Logger) { l.Warn(args...) })
+}
+
+func (r *rateLimited) Error(args ...interface{}) {
+	r.emit(zapcore.ErrorLevel, fmt.Sprint(args...), func(l Logger) { l.Error(args...) })
+}
+
+func (r *rateLimited) Debugf(template string, args ...interface{}) {
+	if !r.allLevels {
+		r.Logger.Debugf(template, args...)
+		return
+	}
+	r.emit(zapcore.DebugLevel, template, func(l Logger) { l.Debugf(template, args...) })
+}
+
+func (r *rateLimited) Infof(template string, args ...interface{}) {
+	if !r.allLevels {
+		r.Logger.Infof(template, args...)
+		return
+	}
+	r.emit(zapcore.InfoLevel, template, func(l Logger) { l.Infof(template, args...) })
+}
+
+func (r *rateLimited) Warnf(template string, args ...interface{}) {
+	r.emit(zapcore.WarnLevel, template, func(l Logger) { l.Warnf(template, args...) })
+}
+
+func (r *rateLimited) Errorf(template string, args ...interface{}) {
+	r.emit(zapcore.ErrorLevel, template, func(l Logger) { l.Errorf(template, args...) })
+}
+
+func (r *rateLimited) With(args ...interface{}) Logger {
+	return &rateLimited{Logger: r.Logger.With(args...), state: r.state, allLevels: r.allLevels}
+}
+
+// Named returns a named child sharing the same budget
+func (r *rateLimited) Named(name string) Logger {
+	return &rateLimited{Logger: Named(r.Logger, name), state: r.state, allLevels: r.allLevels}
+}
+
+// LevelEnabled reports whether the wrapped logger writes entries at level
+func (r *rateLimited) LevelEnabled(level zapcore.Level) bool {
+	return LevelEnabled(r.Logger, level)
+}
@@ -0,0 +1,102 @@
+package logger_test
+
+import (
+	"testing"
+	"time"
+
+	"go-examples/pkg/logger"
+	"go.uber.org/zap/zapcore"
+)
+
+// TestRateLimitedBudget tests that messages beyond the budget are suppressed
+// and summarized at the next allowed emission
+func TestRateLimitedBudget(t *testing.T) {
+	clock := installFakeClock(t)
+	base, observed := logger.NewObserved(zapcore.DebugLevel)
+	log := logger.NewRateLimited(base, 3, time.Second)
+
+	for i := 0; i < 1252; i++ {
+		log.Warnf("audit write failed: attempt %d", i)
+	}
+	if got := observed.Len(); got != 3 {
+		t.Fatalf("expected 3 entries within the budget, got %d", got)
+	}
+
+	clock.Advance(time.Second)
+	log.Warnf("audit write failed: attempt %d", 1252)
+
+	entries := observed.All()
+	if len(entries) != 5 {
+		t.Fatalf("expected summary and message after the interval, got %d entries", len(entries))
+	}
+	summary := entries[3]
+	if summary.Message != "suppressed 1249 similar messages" {
+		t.Errorf("summary message = %q", summary.Message)
+	}
+	if summary.Level != zapcore.WarnLevel {
+		t.Errorf("summary level = %v; want warn", summary.Level)
+	}
+	if summary.Fields["suppressed"] != int64(1249) || summary.Fields["message"] != "audit write failed: attempt %d" {
+		t.Errorf("unexpected summary fields: %v", summary.Fields)
+	}
+	if entries[4].Message != "audit write failed: attempt 1252" {
+		t.Errorf("message after summary = %q", entries[4].Message)
+	}
+
+	// The summary is reported once
+	log.Warnf("audit write failed: attempt %d", 1253)
+	if got := len(observed.FilterMessageContains("suppressed")); got != 1 {
+		t.Errorf("expected 1 summary, got %d", got)
+	}
+}
+
+// TestRateLimitedIndependentMessages tests that distinct messages have separate budgets
+func TestRateLimitedIndependentMessages(t *testing.T) {
+	installFakeClock(t)
+	base, observed := logger.NewObserved(zapcore.DebugLevel)
+	log := logger.NewRateLimited(base, 1, time.Minute)
+
+	log.Warn("disk full")
+	log.Warn("disk full")
+	log.Warn("network down")
+	log.Error("disk full")
+	log.Error("disk full")
+	log.With("component", "audit").Warn("network down")
+
+	if got := len(observed.FilterMessageContains("disk full")); got != 2 {
+		t.Errorf("expected one warn and one error for 'disk full', got %d", got)
+	}
+	if got := len(observed.FilterMessageContains("network down")); got != 1 {
+		t.Errorf("expected derived loggers to share the budget, got %d entries", got)
+	}
+}
+
+// TestRateLimitedLevels tests that Debug and Info pass through unless enabled
+func TestRateLimitedLevels(t *testing.T) {
+	installFakeClock(t)
+
+	base, observed := logger.NewObserved(zapcore.DebugLevel)
+	log := logger.NewRateLimited(base, 1, time.Minute)
+	for i := 0; i < 5; i++ {
+		log.Debug("debug")
+		log.Infof("info %d", i)
+	}
+	if got := observed.Len(); got != 10 {
+		t.Errorf("expected Debug and Info to pass through, got %d entries", got)
+	}
+
+	base, observed = logger.NewObserved(zapcore.DebugLevel)
+	log = logger.NewRateLimited(base, 1, time.Minute, logger.RateLimitAllLevels())
+	for i := 0; i < 5; i++ {
+		log.Debug("debug")
+		log.Infof("info %d", i)
+	}
+	if got := observed.Len(); got != 2 {
+		t.Errorf("expected Debug and Info to be limited, got %d entries", got)
+	}
+	base, _ = logger.NewObserved(zapcore.InfoLevel)
+	log = logger.NewRateLimited(base, 1, time.Minute)
+	if logger.LevelEnabled(log, zapcore.DebugLevel) || !logger.LevelEnabled(logger.Named(log, "calculator"), zapcore.InfoLevel) {
+		t.Error("rate-limited logger does not report the levels of the wrapped logger")
+	}
+}
@@ -0,0 +1,101 @@
+package logger
+
+import (
+	"fmt"
+	"go-examples/pkg/metrics"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Config describes the logger built by the factory of a registered
+// implementation. Implementations ignore settings they do not support.
+type Config struct {
+	// Level holds the minimum levels. Implementations without per-module
+	// levels apply only the default.
+	Level LevelSpec
+	// Output receives the entries; nil means the implementation's default
+	Output io.Writer
+	// Text selects human-readable output instead of the implementation's
+	// default encoding
+	Text bool
+	// Service, Version and Env, when Service is set, identify the
+	// running service on every entry
+	Service, Version, Env string
+	// Metrics, when set, counts the entries written by level in
+	// log_entries_total
+	Metrics metrics.Provider
+	// ExitFunc is called by Fatal after the entry is written; nil means
+	// os.Exit
+	ExitFunc func(int)
+}
+
+// Factory builds a logger of a registered implementation
+type Factory func(Config) (Logger, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{}
+)
+
+// Register makes an implementation available to New under name, which
+// is case-insensitive. It is meant to be called from init functions and
+// panics if name is already registered or factory is nil.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	name = strings.ToLower(name)
+	if factory == nil {
+		panic("logger: Register factory is nil for " + name)
+	}
+	if _, dup := registry[name]; dup {
+		panic("logger: Register called twice for " + name)
+	}
+	registry[name] = factory
+}
+
+// New builds a logger with the implementation registered under name
+func New(name string, cfg Config) (Logger, error) {
+	registryMu.RLock()
+	factory, ok := registry[strings.ToLower(name)]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown log system: %s, supported systems are %s", name, strings.Join(Systems(), ", "))
+	}
+	return factory(cfg)
+}
+
+// Systems returns the names of the registered implementations, sorted
+func Systems() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func init() {
+	Register("zap", newZap)
+}
+
+// newZap builds a zap logger, writing JSON unless cfg.Text is set
+func newZap(cfg Config) (Logger, error) {
+	opts := []Option{WithLevelSpec(cfg.Level)}
+	if cfg.Output != nil {
+		opts = append(opts, WithOutput(cfg.Output))
+	}
+	if cfg.ExitFunc != nil {
+		opts = append(opts, WithExitFunc(cfg.ExitFunc))
+	}
+	if cfg.Service != "" {
+		opts = append(opts, WithServiceInfo(cfg.Service, cfg.Version, cfg.Env))
+	}
+	if cfg.Metrics != nil {
+		opts = append(opts, WithMetrics(cfg.Metrics))
+	}
+	return NewCustom(cfg.Level.Default, !cfg.Text, opts...), nil
+}
@@ -0,0 +1,43 @@
+package logger_test
+
+import (
+	"bytes"
+	"go-examples/pkg/logger"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestRegistry tests building the zap implementation by name and the
+// error for unknown names
+func TestRegistry(t *testing.T) {
+	if !slices.Contains(logger.Systems(), "zap") {
+		t.Fatalf("zap is not registered: %v", logger.Systems())
+	}
+
+	var buf bytes.Buffer
+	log, err := logger.New("ZAP", logger.Config{Output: &buf, Service: "calc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.Info("hello")
+	if !strings.Contains(buf.String(), `"service":"calc"`) {
+		t.Errorf("expected JSON with service info, got %s", buf.String())
+	}
+
+	_, err = logger.New("log4j", logger.Config{})
+	if err == nil || !strings.Contains(err.Error(), "unknown log system: log4j") || !strings.Contains(err.Error(), "zap") {
+		t.Errorf("expected an error listing the registered systems, got %v", err)
+	}
+}
+
+// TestRegisterDuplicate tests that a name cannot be registered twice,
+// whatever its case
+func TestRegisterDuplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected Register to panic for a registered name")
+		}
+	}()
+	logger.Register("Zap", func(logger.Config) (logger.Logger, error) { return nil, nil })
+}
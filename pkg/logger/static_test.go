@@ -0,0 +1,71 @@
+package logger_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"go-examples/pkg/logger"
+	"go.uber.org/zap/zapcore"
+)
+
+// TestWithServiceInfo tests that service fields appear on root and derived loggers
+func TestWithServiceInfo(t *testing.T) {
+	log, observed := logger.NewObserved(zapcore.InfoLevel,
+		logger.WithServiceInfo("calcservice", "v1.2.3", "staging"),
+	)
+
+	log.Info("root")
+	log.With("request_id", "abc").Info("derived")
+	logger.Named(log, "calculator").Info("named")
+
+	for _, entry := range observed.All() {
+		if entry.Fields["service"] != "calcservice" || entry.Fields["version"] != "v1.2.3" || entry.Fields["env"] != "staging" {
+			t.Errorf("entry %q missing service fields: %v", entry.Message, entry.Fields)
+		}
+	}
+	if got := observed.FilterField("request_id", "abc"); len(got) != 1 {
+		t.Errorf("expected derived fields alongside service fields, got %d entries", len(got))
+	}
+}
+
+// TestWithStaticFieldsOverride tests that a more specific With overrides a static field
+func TestWithStaticFieldsOverride(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.NewCustom(zapcore.InfoLevel, true,
+		logger.WithOutput(&buf),
+		logger.WithStaticFields("region", "eu-west-1", "team", "payments"),
+	)
+
+	log.With("region", "us-east-1").Info("overridden")
+	log.Info("default")
+
+	entries := decodeLines(t, &buf)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0]["region"] != "us-east-1" || entries[0]["team"] != "payments" {
+		t.Errorf("expected region override and inherited team, got: %v", entries[0])
+	}
+	if entries[1]["region"] != "eu-west-1" {
+		t.Errorf("expected static region on root logger, got: %v", entries[1])
+	}
+}
+
+// TestWithProcessInfo tests that hostname and pid are attached
+func TestWithProcessInfo(t *testing.T) {
+	log, observed := logger.NewObserved(zapcore.InfoLevel, logger.WithProcessInfo())
+	log.With("key", "value").Info("message")
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Skipf("hostname unavailable: %v", err)
+	}
+	entry := observed.All()[0]
+	if entry.Fields["hostname"] != hostname {
+		t.Errorf("hostname = %v; want %q", entry.Fields["hostname"], hostname)
+	}
+	if entry.Fields["pid"] != int64(os.Getpid()) {
+		t.Errorf("pid = %v; want %d", entry.Fields["pid"], os.Getpid())
+	}
+}
@@ -0,0 +1,127 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Now returns the current time. It is a variable so tests can substitute
+// a fake clock; the default reading carries Go's monotonic clock, so
+// elapsed times are unaffected by wall-clock adjustments.
+var Now = time.Now
+
+// StartTimer starts timing an operation and returns a function that logs
+// msg at Info level with an elapsed field when called. It is intended for
+// use as:
+//
+//	done := logger.StartTimer(log, "request handled", "op", "add")
+//	defer done()
+//
+// Fields passed to the returned function are added after kv.
+func StartTimer(l Logger, msg string, kv ...interface{}) func(extraKV ...interface{}) {
+	start := Now()
+	return func(extraKV ...interface{}) {
+		fields := make([]interface{}, 0, len(kv)+len(extraKV)+2)
+		fields = append(fields, kv...)
+		fields = append(fields, extraKV...)
+		fields = append(fields, "elapsed", Now().Sub(start))
+		l.With(fields...).Info(msg)
+	}
+}
+
+// WithLazy returns a child logger whose context fields are produced by fn.
+// fn is called at most once, and only when an entry is actually written,
+// so expensive field construction is skipped for filtered entries.
+func (l *zapLogger) WithLazy(fn func() []interface{}) Logger {
+	lazy := &lazyFields{fn: fn}
+	base := l.sugar.Desugar().WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		return &lazyCore{Core: c, lazy: lazy}
+	}))
+	return &zapLogger{sugar: base.Sugar(), levels: l.levels, enabler: l.enabler}
+}
+
+// WithLazy returns a child of l with lazily evaluated context fields when
+// the implementation supports it. Other implementations receive the
+// fields eagerly through With.
+func WithLazy(l Logger, fn func() []interface{}) Logger {
+	if lw, ok := l.(interface {
+		WithLazy(func() []interface{}) Logger
+	}); ok {
+		return lw.WithLazy(fn)
+	}
+	return l.With(fn()...)
+}
+
+// lazyFields evaluates a field function once and caches the result
+type lazyFields struct {
+	once   sync.Once
+	fn     func() []interface{}
+	fields []zapcore.Field
+}
+
+func (lf *lazyFields) get() []zapcore.Field {
+	lf.once.Do(func() {
+		lf.fields = fieldsFromKV(lf.fn())
+	})
+	return lf.fields
+}
+
+// lazyCore defers adding lazy fields until an entry passes filtering
+type lazyCore struct {
+	zapcore.Core
+	lazy  *lazyFields
+	extra []zapcore.Field
+
+	once     sync.Once
+	resolved zapcore.Core
+}
+
+func (c *lazyCore) With(fields []zapcore.Field) zapcore.Core {
+	extra := make([]zapcore.Field, 0, len(c.extra)+len(fields))
+	extra = append(extra, c.extra...)
+	extra = append(extra, fields...)
+	return &lazyCore{Core: c.Core, lazy: c.lazy, extra: extra}
+}
+
+func (c *lazyCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	// Let the wrapped core apply its own filtering (including level specs)
+	if c.Core.Check(ent, nil) == nil {
+		return ce
+	}
+	return ce.AddCore(ent, c)
+}
+
+func (c *lazyCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	c.once.Do(func() {
+		c.resolved = c.Core.With(c.lazy.get()).With(c.extra)
+	})
+	return c.resolved.Write(ent, fields)
+}
+
+// fieldsFromKV converts loosely typed key-value pairs into zap fields,
+// following the same conventions as the sugared logger
+func fieldsFromKV(kv []interface{}) []zapcore.Field {
+	fields := make([]zapcore.Field, 0, len(kv)/2)
+	for i := 0; i < len(kv); {
+		if f, ok := kv[i].(zapcore.Field); ok {
+			fields = append(fields, f)
+			i++
+			continue
+		}
+		if i == len(kv)-1 {
+			fields = append(fields, zap.Any("ignored", kv[i]))
+			break
+		}
+		key, ok := kv[i].(string)
+		if !ok {
+			key = fmt.Sprint(kv[i])
+		}
+		fields = append(fields, zap.Any(key, kv[i+1]))
+		i += 2
+	}
+	return fields
+}
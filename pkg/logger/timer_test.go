@@ -0,0 +1,165 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"go-examples/pkg/logger"
+	"go.uber.org/zap/zapcore"
+)
+
+// fakeClock returns a controllable time source for logger.Now
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// installFakeClock replaces logger.Now for the duration of the test
+func installFakeClock(t *testing.T) *fakeClock {
+	t.Helper()
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	orig := logger.Now
+	logger.Now = clock.Now
+	t.Cleanup(func() { logger.Now = orig })
+	return clock
+}
+
+// decodeLines decodes JSON log lines written to buf
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("failed to decode log line %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// TestStartTimer tests that the elapsed time is measured with the injected clock
+func TestStartTimer(t *testing.T) {
+	clock := installFakeClock(t)
+
+	var buf bytes.Buffer
+	log := logger.NewCustom(zapcore.InfoLevel, true, logger.WithOutput(&buf))
+
+	done := logger.StartTimer(log, "operation finished", "op", "add")
+	clock.Advance(1500 * time.Millisecond)
+	done("result", 8)
+
+	entries := decodeLines(t, &buf)
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d: %s", len(entries), buf.String())
+	}
+	entry := entries[0]
+	if entry["msg"] != "operation finished" {
+		t.Errorf("msg = %v; want %q", entry["msg"], "operation finished")
+	}
+	if entry["elapsed"] != 1.5 {
+		t.Errorf("elapsed = %v; want 1.5 (seconds)", entry["elapsed"])
+	}
+	if entry["op"] != "add" {
+		t.Errorf("op = %v; want %q", entry["op"], "add")
+	}
+	if entry["result"] != float64(8) {
+		t.Errorf("result = %v; want 8", entry["result"])
+	}
+}
+
+// TestStartTimerDeferred tests the intended defer usage
+func TestStartTimerDeferred(t *testing.T) {
+	clock := installFakeClock(t)
+
+	var buf bytes.Buffer
+	log := logger.NewCustom(zapcore.InfoLevel, true, logger.WithOutput(&buf))
+
+	func() {
+		done := logger.StartTimer(log, "deferred")
+		defer done()
+		clock.Advance(250 * time.Millisecond)
+	}()
+
+	entries := decodeLines(t, &buf)
+	if len(entries) != 1 || entries[0]["elapsed"] != 0.25 {
+		t.Errorf("expected one entry with elapsed 0.25, got: %s", buf.String())
+	}
+}
+
+// TestWithLazySkippedWhenFiltered tests that lazy fields are not built for filtered entries
+func TestWithLazySkippedWhenFiltered(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.NewCustom(zapcore.InfoLevel, true, logger.WithOutput(&buf))
+
+	calls := 0
+	lazy := logger.WithLazy(log, func() []interface{} {
+		calls++
+		return []interface{}{"expensive", "value"}
+	})
+
+	lazy.Debug("filtered")
+	lazy.With("extra", 1).Debugf("filtered %d", 2)
+	if calls != 0 {
+		t.Fatalf("lazy function called %d times for filtered entries", calls)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("expected no output, got: %s", buf.String())
+	}
+
+	lazy.Info("first")
+	lazy.Warn("second")
+	if calls != 1 {
+		t.Errorf("lazy function called %d times; want 1", calls)
+	}
+
+	entries := decodeLines(t, &buf)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d: %s", len(entries), buf.String())
+	}
+	for _, entry := range entries {
+		if entry["expensive"] != "value" {
+			t.Errorf("expected lazy field on entry, got: %v", entry)
+		}
+	}
+}
+
+// TestWithLazyDerived tests that loggers derived from a lazy logger keep its fields
+func TestWithLazyDerived(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.NewCustom(zapcore.DebugLevel, true, logger.WithOutput(&buf))
+
+	lazy := logger.WithLazy(log, func() []interface{} {
+		return []interface{}{"lazy", true}
+	})
+	lazy.With("request_id", "abc").Info("derived")
+
+	entries := decodeLines(t, &buf)
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	if entries[0]["lazy"] != true || entries[0]["request_id"] != "abc" {
+		t.Errorf("expected lazy and derived fields, got: %v", entries[0])
+	}
+}
+
+// TestWithLazyFallback tests that other Logger implementations get eager fields
+func TestWithLazyFallback(t *testing.T) {
+	calls := 0
+	log := logger.WithLazy(&mockLogger{}, func() []interface{} {
+		calls++
+		return nil
+	})
+	if log == nil || calls != 1 {
+		t.Errorf("expected eager evaluation for loggers without WithLazy, calls = %d", calls)
+	}
+}
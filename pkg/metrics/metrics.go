@@ -0,0 +1,67 @@
+// Package metrics is a minimal instrumentation interface, so packages
+// can record metrics without depending on a particular backend. The
+// prommetrics and otelmetrics subpackages implement it with Prometheus
+// and OpenTelemetry; Noop discards everything and is the default.
+//
+// Instruments are created once, with the names of their labels, and
+// observations pass the label values in the same order:
+//
+//	requests := p.Counter("http_requests_total", "Completed requests.", "code")
+//	requests.Add(1, "200")
+package metrics
+
+// DefaultBuckets are histogram bucket bounds, in seconds, suited to
+// request latencies
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Counter is a value that only goes up
+type Counter interface {
+	// Add increases the counter by delta, which must not be negative
+	Add(delta float64, labelValues ...string)
+}
+
+// Gauge is a value that goes up and down
+type Gauge interface {
+	Set(value float64, labelValues ...string)
+	Add(delta float64, labelValues ...string)
+}
+
+// Histogram counts observations in buckets
+type Histogram interface {
+	Observe(value float64, labelValues ...string)
+}
+
+// Provider creates instruments. Creating an instrument twice with the
+// same name returns one that records into the same series.
+type Provider interface {
+	Counter(name, help string, labelNames ...string) Counter
+	Gauge(name, help string, labelNames ...string) Gauge
+	// Histogram uses DefaultBuckets when buckets is nil
+	Histogram(name, help string, buckets []float64, labelNames ...string) Histogram
+}
+
+// Noop is a Provider whose instruments discard observations without
+// allocating
+type Noop struct{}
+
+var (
+	_ Provider  = Noop{}
+	_ Counter   = Noop{}
+	_ Gauge     = Noop{}
+	_ Histogram = Noop{}
+)
+
+func (Noop) Counter(string, string, ...string) Counter                { return Noop{} }
+func (Noop) Gauge(string, string, ...string) Gauge                    { return Noop{} }
+func (Noop) Histogram(string, string, []float64, ...string) Histogram { return Noop{} }
+func (Noop) Add(float64, ...string)                                   {}
+func (Noop) Set(float64, ...string)                                   {}
+func (Noop) Observe(float64, ...string)                               {}
+
+// OrNoop returns p, or Noop when p is nil
+func OrNoop(p Provider) Provider {
+	if p == nil {
+		return Noop{}
+	}
+	return p
+}
@@ -0,0 +1,38 @@
+package metrics_test
+
+import (
+	"go-examples/pkg/metrics"
+	"testing"
+)
+
+// labels are built once, as instrumented code does for fixed label sets
+var labels = []string{"GET", "200"}
+
+// TestNoopAllocs tests that observing through Noop allocates nothing
+func TestNoopAllocs(t *testing.T) {
+	p := metrics.OrNoop(nil)
+	counter := p.Counter("requests_total", "", "method", "code")
+	gauge := p.Gauge("in_flight", "")
+	histogram := p.Histogram("duration_seconds", "", nil, "method")
+
+	allocs := testing.AllocsPerRun(100, func() {
+		counter.Add(1, labels...)
+		gauge.Set(3)
+		gauge.Add(-1)
+		histogram.Observe(0.25, labels[0])
+	})
+	if allocs != 0 {
+		t.Errorf("Noop allocated %.1f times per run, want 0", allocs)
+	}
+}
+
+// BenchmarkNoop measures observations that are discarded
+func BenchmarkNoop(b *testing.B) {
+	counter := metrics.Noop{}.Counter("requests_total", "", "method", "code")
+	histogram := metrics.Noop{}.Histogram("duration_seconds", "", nil, "method")
+	b.ReportAllocs()
+	for b.Loop() {
+		counter.Add(1, labels...)
+		histogram.Observe(0.25, labels[0])
+	}
+}
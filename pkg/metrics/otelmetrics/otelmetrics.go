@@ -0,0 +1,121 @@
+// Package otelmetrics implements metrics.Provider with OpenTelemetry
+// instruments created from a metric.Meter. Label names become attribute
+// keys.
+package otelmetrics
+
+import (
+	"context"
+	"go-examples/pkg/metrics"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Provider creates OpenTelemetry instruments
+type Provider struct {
+	meter metric.Meter
+
+	mu     sync.Mutex
+	gauges map[string]*gauge
+}
+
+// New returns a Provider creating its instruments with meter
+func New(meter metric.Meter) *Provider {
+	return &Provider{meter: meter, gauges: map[string]*gauge{}}
+}
+
+// Counter returns a Float64Counter-backed Counter
+func (p *Provider) Counter(name, help string, labelNames ...string) metrics.Counter {
+	c, err := p.meter.Float64Counter(name, metric.WithDescription(help))
+	if err != nil {
+		panic(err)
+	}
+	return counter{c: c, labels: labelNames}
+}
+
+// Gauge returns a Float64UpDownCounter-backed Gauge. Set is recorded as
+// the difference from the last value set or added through this Provider.
+func (p *Provider) Gauge(name, help string, labelNames ...string) metrics.Gauge {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if g, ok := p.gauges[name]; ok {
+		return g
+	}
+	c, err := p.meter.Float64UpDownCounter(name, metric.WithDescription(help))
+	if err != nil {
+		panic(err)
+	}
+	g := &gauge{c: c, labels: labelNames, values: map[string]float64{}}
+	p.gauges[name] = g
+	return g
+}
+
+// Histogram returns a Float64Histogram-backed Histogram
+func (p *Provider) Histogram(name, help string, buckets []float64, labelNames ...string) metrics.Histogram {
+	if buckets == nil {
+		buckets = metrics.DefaultBuckets
+	}
+	h, err := p.meter.Float64Histogram(name, metric.WithDescription(help), metric.WithExplicitBucketBoundaries(buckets...))
+	if err != nil {
+		panic(err)
+	}
+	return histogram{h: h, labels: labelNames}
+}
+
+// attributes pairs label names with values. Missing values are empty.
+func attributes(names, values []string) metric.MeasurementOption {
+	kvs := make([]attribute.KeyValue, len(names))
+	for i, name := range names {
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		kvs[i] = attribute.String(name, value)
+	}
+	return metric.WithAttributes(kvs...)
+}
+
+type counter struct {
+	c      metric.Float64Counter
+	labels []string
+}
+
+func (c counter) Add(delta float64, labelValues ...string) {
+	c.c.Add(context.Background(), delta, attributes(c.labels, labelValues))
+}
+
+type gauge struct {
+	c      metric.Float64UpDownCounter
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]float64 // current value per label values, joined
+}
+
+func (g *gauge) Set(value float64, labelValues ...string) {
+	key := strings.Join(labelValues, "\x00")
+	g.mu.Lock()
+	delta := value - g.values[key]
+	g.values[key] = value
+	g.mu.Unlock()
+	g.c.Add(context.Background(), delta, attributes(g.labels, labelValues))
+}
+
+func (g *gauge) Add(delta float64, labelValues ...string) {
+	key := strings.Join(labelValues, "\x00")
+	g.mu.Lock()
+	g.values[key] += delta
+	g.mu.Unlock()
+	g.c.Add(context.Background(), delta, attributes(g.labels, labelValues))
+}
+
+type histogram struct {
+	h      metric.Float64Histogram
+	labels []string
+}
+
+func (h histogram) Observe(value float64, labelValues ...string) {
+	h.h.Record(context.Background(), value, attributes(h.labels, labelValues))
+}
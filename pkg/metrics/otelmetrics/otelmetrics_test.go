@@ -0,0 +1,69 @@
+package otelmetrics_test
+
+import (
+	"context"
+	"go-examples/pkg/metrics/otelmetrics"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// collect returns the metrics read from reader by name
+func collect(t *testing.T, reader sdkmetric.Reader) map[string]metricdata.Aggregation {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	data := map[string]metricdata.Aggregation{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			data[m.Name] = m.Data
+		}
+	}
+	return data
+}
+
+// TestProvider tests each instrument against a manual reader
+func TestProvider(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	p := otelmetrics.New(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test"))
+
+	requests := p.Counter("requests_total", "Requests.", "code")
+	requests.Add(1, "200")
+	requests.Add(2, "200")
+
+	inFlight := p.Gauge("in_flight", "In flight.")
+	inFlight.Set(5)
+	inFlight.Add(-1)
+	inFlight.Set(2)
+
+	latency := p.Histogram("duration_seconds", "Latency.", []float64{0.1, 1}, "method")
+	latency.Observe(0.05, "GET")
+	latency.Observe(0.5, "GET")
+
+	data := collect(t, reader)
+
+	sum, ok := data["requests_total"].(metricdata.Sum[float64])
+	if !ok || len(sum.DataPoints) != 1 || sum.DataPoints[0].Value != 3 {
+		t.Errorf("unexpected requests_total %+v", data["requests_total"])
+	} else if code, _ := sum.DataPoints[0].Attributes.Value(attribute.Key("code")); code.AsString() != "200" {
+		t.Errorf("code = %q, want 200", code.AsString())
+	}
+
+	gauge, ok := data["in_flight"].(metricdata.Sum[float64])
+	if !ok || len(gauge.DataPoints) != 1 || gauge.DataPoints[0].Value != 2 {
+		t.Errorf("unexpected in_flight %+v", data["in_flight"])
+	}
+
+	hist, ok := data["duration_seconds"].(metricdata.Histogram[float64])
+	if !ok || len(hist.DataPoints) != 1 {
+		t.Fatalf("unexpected duration_seconds %+v", data["duration_seconds"])
+	}
+	point := hist.DataPoints[0]
+	if point.Count != 2 || point.Sum != 0.55 || len(point.BucketCounts) != 3 || point.BucketCounts[0] != 1 || point.BucketCounts[1] != 1 {
+		t.Errorf("unexpected histogram point %+v", point)
+	}
+}
@@ -0,0 +1,95 @@
+// Package prommetrics implements metrics.Provider with Prometheus
+// collectors registered on a prometheus.Registerer.
+package prommetrics
+
+import (
+	"errors"
+	"go-examples/pkg/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Provider creates Prometheus collectors
+type Provider struct {
+	reg       prometheus.Registerer
+	namespace string
+}
+
+// Option configures a Provider
+type Option func(*Provider)
+
+// WithNamespace prefixes every metric name with namespace and an
+// underscore
+func WithNamespace(namespace string) Option {
+	return func(p *Provider) {
+		p.namespace = namespace
+	}
+}
+
+// New returns a Provider registering its collectors on reg
+func New(reg prometheus.Registerer, opts ...Option) *Provider {
+	p := &Provider{reg: reg}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Counter returns a CounterVec-backed Counter
+func (p *Provider) Counter(name, help string, labelNames ...string) metrics.Counter {
+	vec := prometheus.NewCounterVec(prometheus.CounterOpts{Namespace: p.namespace, Name: name, Help: help}, labelNames)
+	return counter{register(p.reg, vec)}
+}
+
+// Gauge returns a GaugeVec-backed Gauge
+func (p *Provider) Gauge(name, help string, labelNames ...string) metrics.Gauge {
+	vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{Namespace: p.namespace, Name: name, Help: help}, labelNames)
+	return gauge{register(p.reg, vec)}
+}
+
+// Histogram returns a HistogramVec-backed Histogram
+func (p *Provider) Histogram(name, help string, buckets []float64, labelNames ...string) metrics.Histogram {
+	if buckets == nil {
+		buckets = metrics.DefaultBuckets
+	}
+	vec := prometheus.NewHistogramVec(prometheus.HistogramOpts{Namespace: p.namespace, Name: name, Help: help, Buckets: buckets}, labelNames)
+	return histogram{register(p.reg, vec)}
+}
+
+// register registers c, or returns the collector already registered
+// under its name. Registering a different kind of collector under a
+// taken name is a programming error and panics.
+func register[C prometheus.Collector](reg prometheus.Registerer, c C) C {
+	if err := reg.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(C); ok {
+				return existing
+			}
+		}
+		panic(err)
+	}
+	return c
+}
+
+type counter struct{ vec *prometheus.CounterVec }
+
+func (c counter) Add(delta float64, labelValues ...string) {
+	c.vec.WithLabelValues(labelValues...).Add(delta)
+}
+
+type gauge struct{ vec *prometheus.GaugeVec }
+
+func (g gauge) Set(value float64, labelValues ...string) {
+	g.vec.WithLabelValues(labelValues...).Set(value)
+}
+
+func (g gauge) Add(delta float64, labelValues ...string) {
+	g.vec.WithLabelValues(labelValues...).Add(delta)
+}
+
+type histogram struct{ vec *prometheus.HistogramVec }
+
+func (h histogram) Observe(value float64, labelValues ...string) {
+	h.vec.WithLabelValues(labelValues...).Observe(value)
+}
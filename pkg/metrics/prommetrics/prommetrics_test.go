@@ -0,0 +1,63 @@
+package prommetrics_test
+
+import (
+	"go-examples/pkg/metrics/prommetrics"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// TestProvider tests each instrument against the registry it registers on
+func TestProvider(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	p := prommetrics.New(reg, prommetrics.WithNamespace("test"))
+
+	requests := p.Counter("requests_total", "Requests.", "code")
+	requests.Add(1, "200")
+	requests.Add(2, "500")
+	// Creating an instrument again records into the same series
+	p.Counter("requests_total", "Requests.", "code").Add(1, "200")
+
+	inFlight := p.Gauge("in_flight", "In flight.")
+	inFlight.Set(5)
+	inFlight.Add(-2)
+
+	latency := p.Histogram("duration_seconds", "Latency.", []float64{0.1, 1}, "method")
+	latency.Observe(0.05, "GET")
+	latency.Observe(0.5, "GET")
+
+	want := `
+# HELP test_duration_seconds Latency.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{method="GET",le="0.1"} 1
+test_duration_seconds_bucket{method="GET",le="1"} 2
+test_duration_seconds_bucket{method="GET",le="+Inf"} 2
+test_duration_seconds_sum{method="GET"} 0.55
+test_duration_seconds_count{method="GET"} 2
+# HELP test_in_flight In flight.
+# TYPE test_in_flight gauge
+test_in_flight 3
+# HELP test_requests_total Requests.
+# TYPE test_requests_total counter
+test_requests_total{code="200"} 2
+test_requests_total{code="500"} 2
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestProviderConflict tests that reusing a name for another kind of
+// instrument panics
+func TestProviderConflict(t *testing.T) {
+	p := prommetrics.New(prometheus.NewRegistry())
+	p.Counter("taken", "A counter.")
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic")
+		}
+	}()
+	p.Gauge("taken", "A gauge.")
+}
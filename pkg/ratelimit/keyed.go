@@ -0,0 +1,163 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// WithIdleTimeout makes Evict keep limiters used within d, even when
+// their bucket is full. The default is zero: every full bucket may be
+// evicted.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.idleTimeout = d
+	}
+}
+
+// WithMaxKeys runs Evict whenever a new key would grow a KeyedLimiter
+// past n limiters. Keys are still added when nothing can be evicted. The
+// default is zero: no limit.
+func WithMaxKeys(n int) Option {
+	return func(cfg *config) {
+		cfg.maxKeys = n
+	}
+}
+
+// WithEvictionInterval runs Evict every d in a goroutine until Close is
+// called. Without it, KeyedLimiter starts no goroutines.
+func WithEvictionInterval(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.evictInterval = d
+	}
+}
+
+// KeyedLimiter holds a Limiter per key, all with the same rate and
+// burst. Limiters are created on first use; Evict drops those whose
+// bucket has refilled completely and that have been idle for the idle
+// timeout, as a new Limiter behaves the same. It is safe for concurrent
+// use.
+type KeyedLimiter struct {
+	rate  float64
+	burst int
+	cfg   config
+
+	mu       sync.Mutex
+	limiters map[string]*keyedEntry
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// keyedEntry is the Limiter of one key and when it was last used
+type keyedEntry struct {
+	limiter  *Limiter
+	lastUsed time.Time
+}
+
+// NewKeyed returns a KeyedLimiter whose limiters refill perSecond tokens
+// per second into buckets of burst tokens
+func NewKeyed(perSecond float64, burst int, opts ...Option) *KeyedLimiter {
+	k := &KeyedLimiter{
+		rate:     perSecond,
+		burst:    burst,
+		cfg:      newConfig(opts),
+		limiters: map[string]*keyedEntry{},
+	}
+	if k.cfg.evictInterval > 0 {
+		k.stop, k.done = make(chan struct{}), make(chan struct{})
+		go k.evictLoop()
+	}
+	return k
+}
+
+// Limiter returns the Limiter for key, creating it if needed, and marks
+// the key as used
+func (k *KeyedLimiter) Limiter(key string) *Limiter {
+	now := k.cfg.clock.Now()
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	e, ok := k.limiters[key]
+	if !ok {
+		if k.cfg.maxKeys > 0 && len(k.limiters) >= k.cfg.maxKeys {
+			k.evictLocked(now)
+		}
+		e = &keyedEntry{limiter: newLimiter(k.rate, k.burst, k.cfg.clock)}
+		k.limiters[key] = e
+	}
+	e.lastUsed = now
+	return e.limiter
+}
+
+// Allow takes a token from the bucket of key if one is available
+func (k *KeyedLimiter) Allow(key string) bool {
+	return k.Limiter(key).Allow()
+}
+
+// Reserve takes a token from the bucket of key; see Limiter.Reserve
+func (k *KeyedLimiter) Reserve(key string) *Reservation {
+	return k.Limiter(key).Reserve()
+}
+
+// Wait blocks until the bucket of key has a token; see Limiter.Wait
+func (k *KeyedLimiter) Wait(ctx context.Context, key string) error {
+	return k.Limiter(key).Wait(ctx)
+}
+
+// Len returns the number of keys with a Limiter
+func (k *KeyedLimiter) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return len(k.limiters)
+}
+
+// Evict drops the limiters whose bucket is full and that have been idle
+// for the idle timeout, and returns how many it dropped
+func (k *KeyedLimiter) Evict() int {
+	now := k.cfg.clock.Now()
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.evictLocked(now)
+}
+
+// evictLocked implements Evict. k.mu must be held.
+func (k *KeyedLimiter) evictLocked(now time.Time) int {
+	evicted := 0
+	for key, e := range k.limiters {
+		if now.Sub(e.lastUsed) < k.cfg.idleTimeout {
+			continue
+		}
+		if e.limiter.Tokens() >= e.limiter.burst {
+			delete(k.limiters, key)
+			evicted++
+		}
+	}
+	return evicted
+}
+
+// evictLoop runs Evict every eviction interval until Close
+func (k *KeyedLimiter) evictLoop() {
+	defer close(k.done)
+	for {
+		select {
+		case <-k.stop:
+			return
+		case <-k.cfg.clock.After(k.cfg.evictInterval):
+			k.Evict()
+		}
+	}
+}
+
+// Close stops the eviction goroutine, if any, and waits for it to exit.
+// The limiters stay usable.
+func (k *KeyedLimiter) Close() {
+	if k.stop == nil {
+		return
+	}
+	k.closeOnce.Do(func() {
+		close(k.stop)
+		<-k.done
+	})
+}
@@ -0,0 +1,128 @@
+package ratelimit_test
+
+import (
+	"go-examples/pkg/ratelimit"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestKeyedIndependent tests that keys have separate buckets
+func TestKeyedIndependent(t *testing.T) {
+	clock := newFakeClock()
+	k := ratelimit.NewKeyed(1, 1, ratelimit.WithClock(clock))
+
+	if !k.Allow("a") || k.Allow("a") {
+		t.Error("expected key a to allow exactly its burst")
+	}
+	if !k.Allow("b") {
+		t.Error("expected key b to have its own bucket")
+	}
+	if k.Limiter("a") != k.Limiter("a") {
+		t.Error("expected the same Limiter for the same key")
+	}
+	if k.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", k.Len())
+	}
+}
+
+// TestKeyedEvict tests that only full, idle limiters are evicted
+func TestKeyedEvict(t *testing.T) {
+	clock := newFakeClock()
+	k := ratelimit.NewKeyed(1, 2, ratelimit.WithClock(clock), ratelimit.WithIdleTimeout(time.Minute))
+
+	k.Allow("busy")
+	k.Allow("idle")
+	clock.Advance(2 * time.Second) // both buckets refill
+	if got := k.Evict(); got != 0 {
+		t.Errorf("Evict() = %d before the idle timeout, want 0", got)
+	}
+
+	clock.Advance(time.Minute)
+	k.Allow("busy") // used recently, and refilled by the next check
+	clock.Advance(30 * time.Second)
+	if got := k.Evict(); got != 1 || k.Len() != 1 {
+		t.Errorf("Evict() = %d leaving %d keys, want the idle key evicted", got, k.Len())
+	}
+
+	// An evicted key starts again with a full bucket
+	if !k.Allow("idle") || !k.Allow("idle") || k.Allow("idle") {
+		t.Error("expected a fresh bucket for an evicted key")
+	}
+}
+
+// TestKeyedMaxKeys tests eviction when a new key exceeds the limit
+func TestKeyedMaxKeys(t *testing.T) {
+	clock := newFakeClock()
+	k := ratelimit.NewKeyed(1, 1, ratelimit.WithClock(clock), ratelimit.WithMaxKeys(2))
+
+	k.Allow("a")
+	k.Allow("b")
+	k.Allow("c") // nothing refilled, so nothing to evict
+	if k.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", k.Len())
+	}
+	clock.Advance(time.Second)
+	k.Allow("d")
+	if k.Len() != 1 {
+		t.Errorf("Len() = %d, want only the new key after eviction", k.Len())
+	}
+}
+
+// TestKeyedEvictionInterval tests the eviction goroutine
+func TestKeyedEvictionInterval(t *testing.T) {
+	clock := newFakeClock()
+	k := ratelimit.NewKeyed(1, 1, ratelimit.WithClock(clock), ratelimit.WithEvictionInterval(time.Minute))
+	defer k.Close()
+
+	k.Allow("a")
+	waitForWaiters(t, clock, 1)
+	clock.Advance(time.Minute)
+	deadline := time.Now().Add(time.Second)
+	for k.Len() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the eviction goroutine to evict the refilled key")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	k.Close()
+	k.Close()
+}
+
+// TestKeyedConcurrent tests that many goroutines per key share the burst
+// exactly; run with -race
+func TestKeyedConcurrent(t *testing.T) {
+	clock := newFakeClock()
+	k := ratelimit.NewKeyed(1, 50, ratelimit.WithClock(clock), ratelimit.WithMaxKeys(2))
+	keys := []string{"a", "b", "c", "d"}
+	allowed := make([]atomic.Int64, len(keys))
+
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		for g := 0; g < 20; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for n := 0; n < 10; n++ {
+					if k.Allow(key) {
+						allowed[i].Add(1)
+					}
+					if r := k.Reserve(key); r.Delay() > 0 {
+						r.Cancel()
+					} else {
+						allowed[i].Add(1)
+					}
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	for i, key := range keys {
+		if got := allowed[i].Load(); got != 50 {
+			t.Errorf("key %s allowed %d events, want the burst of 50", key, got)
+		}
+	}
+}
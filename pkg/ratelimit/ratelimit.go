@@ -0,0 +1,211 @@
+// Package ratelimit implements token-bucket rate limiters. A Limiter
+// holds one bucket; a KeyedLimiter holds one per key, such as per client.
+// Neither starts goroutines unless KeyedLimiter eviction is scheduled
+// with WithEvictionInterval.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrNeverAllowed is returned by Wait when the limiter can never grant a
+// token, because its burst is below one or its bucket is empty and never
+// refills
+var ErrNeverAllowed = errors.New("rate limit can never be satisfied")
+
+// Clock tells the time and waits. Tests pass a fake one with WithClock.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the Clock of the time package
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// config holds the settings of New and NewKeyed
+type config struct {
+	clock         Clock
+	idleTimeout   time.Duration
+	maxKeys       int
+	evictInterval time.Duration
+}
+
+// Option configures a Limiter or KeyedLimiter. Options about keys only
+// apply to KeyedLimiter.
+type Option func(*config)
+
+// WithClock sets the clock used for refills and waits. The default is
+// the system clock.
+func WithClock(c Clock) Option {
+	return func(cfg *config) {
+		cfg.clock = c
+	}
+}
+
+// newConfig applies opts to the defaults
+func newConfig(opts []Option) config {
+	cfg := config{clock: realClock{}}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// Limiter allows events at perSecond on average with bursts of up to
+// burst, using a token bucket that starts full. It is safe for
+// concurrent use.
+type Limiter struct {
+	rate  float64
+	burst float64
+	clock Clock
+
+	mu     sync.Mutex
+	tokens float64 // negative while reservations wait for refills
+	last   time.Time
+}
+
+// New returns a Limiter refilling perSecond tokens per second into a
+// bucket of burst tokens. A perSecond of zero or less never refills.
+func New(perSecond float64, burst int, opts ...Option) *Limiter {
+	return newLimiter(perSecond, burst, newConfig(opts).clock)
+}
+
+// newLimiter returns a full Limiter
+func newLimiter(perSecond float64, burst int, clock Clock) *Limiter {
+	return &Limiter{
+		rate:   perSecond,
+		burst:  float64(burst),
+		clock:  clock,
+		tokens: float64(burst),
+		last:   clock.Now(),
+	}
+}
+
+// Allow takes a token if one is available and reports whether it did
+func (l *Limiter) Allow() bool {
+	now := l.clock.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(now)
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// Reserve takes a token now, even if it only becomes available later,
+// and returns a Reservation saying when. Callers that do not act on it
+// should Cancel it.
+func (l *Limiter) Reserve() *Reservation {
+	now := l.clock.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(now)
+	if l.burst < 1 || (l.tokens < 1 && l.rate <= 0) {
+		return &Reservation{limiter: l}
+	}
+	l.tokens--
+	at := now
+	if l.tokens < 0 {
+		at = now.Add(time.Duration(-l.tokens / l.rate * float64(time.Second)))
+	}
+	return &Reservation{limiter: l, ok: true, at: at}
+}
+
+// Wait blocks until a token is available and takes it. It returns
+// ctx.Err() if ctx ends first, without consuming a token, and
+// ErrNeverAllowed if no token will ever be available.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r := l.Reserve()
+	if !r.OK() {
+		return ErrNeverAllowed
+	}
+	delay := r.Delay()
+	if delay == 0 {
+		return nil
+	}
+	select {
+	case <-l.clock.After(delay):
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
+}
+
+// Tokens returns the number of tokens available now. It is negative
+// while reservations wait for refills.
+func (l *Limiter) Tokens() float64 {
+	now := l.clock.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(now)
+	return l.tokens
+}
+
+// refill adds the tokens earned since the last call. l.mu must be held.
+func (l *Limiter) refill(now time.Time) {
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		if l.rate > 0 {
+			l.tokens = math.Min(l.burst, l.tokens+elapsed.Seconds()*l.rate)
+		}
+		l.last = now
+	}
+}
+
+// Reservation is a token taken by Reserve
+type Reservation struct {
+	limiter  *Limiter
+	ok       bool
+	at       time.Time // when the token is available
+	canceled bool
+}
+
+// OK reports whether the token will ever be available. A Reservation
+// that is not OK holds no token.
+func (r *Reservation) OK() bool {
+	return r.ok
+}
+
+// Delay returns how long to wait before acting on the reservation: zero
+// when the token is available now, and the maximum duration when it
+// never will be
+func (r *Reservation) Delay() time.Duration {
+	if !r.ok {
+		return time.Duration(math.MaxInt64)
+	}
+	if d := r.at.Sub(r.limiter.clock.Now()); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// Cancel returns the token to the bucket. Calling it more than once, or
+// on a Reservation that is not OK, does nothing.
+func (r *Reservation) Cancel() {
+	if !r.ok || r.canceled {
+		return
+	}
+	r.canceled = true
+	l := r.limiter
+	now := l.clock.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(now)
+	l.tokens = math.Min(l.burst, l.tokens+1)
+}
@@ -0,0 +1,203 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"go-examples/pkg/ratelimit"
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock that only moves when Advance is called
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []waiter
+}
+
+// waiter is a channel returned by After and when it fires
+type waiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w := waiter{at: c.now.Add(d), ch: make(chan time.Time, 1)}
+	c.waiters = append(c.waiters, w)
+	return w.ch
+}
+
+// Advance moves the clock forward and fires the waiters that are due
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = pending
+}
+
+// Waiters returns the number of pending After calls
+func (c *fakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// waitForWaiters blocks until n After calls are pending
+func waitForWaiters(t *testing.T, c *fakeClock, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for c.Waiters() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d pending waits, got %d", n, c.Waiters())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestRefill tests the token math as time passes
+func TestRefill(t *testing.T) {
+	clock := newFakeClock()
+	l := ratelimit.New(4, 2, ratelimit.WithClock(clock))
+
+	steps := []struct {
+		advance time.Duration
+		want    []bool
+		tokens  float64
+	}{
+		{0, []bool{true, true, false}, 0},
+		{100 * time.Millisecond, []bool{false}, 0.4},
+		{150 * time.Millisecond, []bool{true, false}, 0},
+		{10 * time.Second, []bool{true, true, false}, 0}, // capped at the burst
+		{375 * time.Millisecond, []bool{true}, 0.5},
+	}
+	for i, step := range steps {
+		clock.Advance(step.advance)
+		for j, want := range step.want {
+			if got := l.Allow(); got != want {
+				t.Errorf("step %d, call %d: Allow() = %v, want %v", i, j, got, want)
+			}
+		}
+		if got := l.Tokens(); math.Abs(got-step.tokens) > 1e-9 {
+			t.Errorf("step %d: Tokens() = %v, want %v", i, got, step.tokens)
+		}
+	}
+}
+
+// TestReserve tests the delays of reservations beyond the burst, and
+// that canceling returns the token
+func TestReserve(t *testing.T) {
+	clock := newFakeClock()
+	l := ratelimit.New(2, 1, ratelimit.WithClock(clock))
+
+	delays := []time.Duration{0, 500 * time.Millisecond, time.Second}
+	var reservations []*ratelimit.Reservation
+	for i, want := range delays {
+		r := l.Reserve()
+		if !r.OK() || r.Delay() != want {
+			t.Errorf("reservation %d: OK %v, Delay %v, want %v", i, r.OK(), r.Delay(), want)
+		}
+		reservations = append(reservations, r)
+	}
+
+	clock.Advance(250 * time.Millisecond)
+	if got := reservations[1].Delay(); got != 250*time.Millisecond {
+		t.Errorf("Delay after 250ms = %v, want 250ms", got)
+	}
+
+	reservations[2].Cancel()
+	reservations[2].Cancel()
+	if got := l.Tokens(); got != -0.5 {
+		t.Errorf("Tokens() after one cancel = %v, want -0.5", got)
+	}
+}
+
+// TestNeverAllowed tests limiters that cannot grant a token
+func TestNeverAllowed(t *testing.T) {
+	clock := newFakeClock()
+	tests := map[string]*ratelimit.Limiter{
+		"zero burst":     ratelimit.New(10, 0, ratelimit.WithClock(clock)),
+		"no refill left": ratelimit.New(0, 1, ratelimit.WithClock(clock)),
+	}
+	tests["no refill left"].Allow()
+	for name, l := range tests {
+		if l.Allow() {
+			t.Errorf("%s: Allow() = true", name)
+		}
+		if r := l.Reserve(); r.OK() {
+			t.Errorf("%s: Reserve() is OK with delay %v", name, r.Delay())
+		}
+		if err := l.Wait(context.Background()); !errors.Is(err, ratelimit.ErrNeverAllowed) {
+			t.Errorf("%s: Wait() = %v, want ErrNeverAllowed", name, err)
+		}
+	}
+}
+
+// TestWait tests that Wait returns once the token is available
+func TestWait(t *testing.T) {
+	clock := newFakeClock()
+	l := ratelimit.New(1, 1, ratelimit.WithClock(clock))
+
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("first Wait: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- l.Wait(context.Background()) }()
+	waitForWaiters(t, clock, 1)
+
+	clock.Advance(999 * time.Millisecond)
+	select {
+	case err := <-done:
+		t.Fatalf("Wait returned %v before the refill", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	clock.Advance(time.Millisecond)
+	if err := <-done; err != nil {
+		t.Errorf("Wait: %v", err)
+	}
+}
+
+// TestWaitCanceled tests that Wait honors context cancellation and gives
+// its token back
+func TestWaitCanceled(t *testing.T) {
+	clock := newFakeClock()
+	l := ratelimit.New(1, 1, ratelimit.WithClock(clock))
+	l.Allow()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- l.Wait(ctx) }()
+	waitForWaiters(t, clock, 1)
+	cancel()
+
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Wait() = %v, want context.Canceled", err)
+	}
+	if got := l.Tokens(); got != 0 {
+		t.Errorf("Tokens() = %v, want the canceled token back", got)
+	}
+	if err := l.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait on a done context = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,124 @@
+package slogger
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// AccessLogFormat selects how Middleware logs completed requests.
+type AccessLogFormat int
+
+const (
+	// FormatStructured logs an "access" message with method, path,
+	// status, bytes, duration, remote, user_agent and request_id attrs.
+	FormatStructured AccessLogFormat = iota
+	// FormatCombined logs the Apache combined log format line as the
+	// message, keeping request_id as an attr.
+	FormatCombined
+)
+
+// combinedTimeLayout is the timestamp layout of the combined log format.
+const combinedTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+// MiddlewareOption configures the access logging done by Middleware.
+type MiddlewareOption func(*middlewareConfig)
+
+// middlewareConfig holds the settings collected from MiddlewareOption values.
+type middlewareConfig struct {
+	format    AccessLogFormat
+	skipPaths map[string]bool
+}
+
+// WithFormat sets the access log format. The default is FormatStructured.
+func WithFormat(format AccessLogFormat) MiddlewareOption {
+	return func(c *middlewareConfig) {
+		c.format = format
+	}
+}
+
+// WithSkipPaths disables access logging for requests whose URL path
+// exactly matches one of paths, such as health checks. Skipped requests
+// still get a request ID and a ResponseLogger.
+func WithSkipPaths(paths ...string) MiddlewareOption {
+	return func(c *middlewareConfig) {
+		for _, p := range paths {
+			c.skipPaths[p] = true
+		}
+	}
+}
+
+// logAccess writes the access line for a completed request.
+func (c *middlewareConfig) logAccess(rl *ResponseLogger, rw *RecordingWriter, r *http.Request) {
+	if c.skipPaths[r.URL.Path] {
+		return
+	}
+	if c.format == FormatCombined {
+		rl.logger.InfoContext(r.Context(), combinedLine(rl, rw, r))
+		return
+	}
+	rl.logger.InfoContext(r.Context(), "access",
+		"method", r.Method,
+		"path", r.URL.Path,
+		"status", rw.Status(),
+		"bytes", rw.BytesWritten(),
+		"duration", Now().Sub(rl.start),
+		"remote", r.RemoteAddr,
+		"user_agent", r.UserAgent())
+}
+
+// combinedLine formats a request in the Apache combined log format:
+//
+//	host ident user [time] "request line" status bytes "referer" "user agent"
+func combinedLine(rl *ResponseLogger, rw *RecordingWriter, r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	user := "-"
+	if name, _, ok := r.BasicAuth(); ok && name != "" {
+		user = name
+	} else if r.URL.User != nil && r.URL.User.Username() != "" {
+		user = r.URL.User.Username()
+	}
+	size := "-"
+	if n := rw.BytesWritten(); n > 0 {
+		size = strconv.FormatInt(n, 10)
+	}
+	uri := r.RequestURI
+	if uri == "" {
+		uri = r.URL.RequestURI()
+	}
+	return fmt.Sprintf(`%s - %s [%s] "%s %s %s" %d %s "%s" "%s"`,
+		orDash(host), escapeField(user), rl.start.Format(combinedTimeLayout),
+		escapeField(r.Method), escapeField(uri), escapeField(r.Proto),
+		rw.Status(), size, escapeField(orDash(r.Referer())), escapeField(orDash(r.UserAgent())))
+}
+
+// orDash returns "-" for empty values, as the combined format expects.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// escapeField escapes quotes, backslashes and control characters so that
+// client-supplied values cannot break the line structure.
+func escapeField(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c == 0x7f:
+			fmt.Fprintf(&b, "\\x%02x", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,129 @@
+package slogger_test
+
+import (
+	"bytes"
+	"flag"
+	"go-examples/pkg/slogger"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// fixedRequest returns a request with every field used by the access log set
+func fixedRequest(target string) *http.Request {
+	req := httptest.NewRequest("GET", target, nil)
+	req.RemoteAddr = "192.0.2.10:52314"
+	req.Header.Set(slogger.RequestIDHeader, "req-42")
+	req.Header.Set("Referer", "http://example.com/start")
+	req.Header.Set("User-Agent", `calcclient/1.0 "test"`)
+	return req
+}
+
+// serveWithMiddleware runs handler behind Middleware and returns the log output
+func serveWithMiddleware(t *testing.T, req *http.Request, handler http.HandlerFunc, opts ...slogger.MiddlewareOption) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	logger := slogger.New(slogger.WithWriter(&buf), slogger.WithJSON())
+	slogger.Middleware(logger, opts...)(handler).ServeHTTP(httptest.NewRecorder(), req)
+	return &buf
+}
+
+// TestCombinedFormatGolden tests the combined log line against a golden file
+func TestCombinedFormatGolden(t *testing.T) {
+	advance := installFakeClock(t)
+	testCases := []struct {
+		name    string
+		target  string
+		handler http.HandlerFunc
+	}{
+		{"ok", "/calculate?op=add&a=1&b=2", func(w http.ResponseWriter, _ *http.Request) {
+			advance(15 * time.Millisecond)
+			w.Write([]byte(`{"result":3}`))
+		}},
+		{"empty_error", "/calculate", func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := serveWithMiddleware(t, fixedRequest(tc.target), tc.handler, slogger.WithFormat(slogger.FormatCombined))
+			record := decodeJSONRecord(t, buf)
+			got := record["msg"].(string) + "\n"
+
+			golden := filepath.Join("testdata", "combined_"+tc.name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("cannot read golden file: %v", err)
+			}
+			if got != string(want) {
+				t.Errorf("combined line mismatch\ngot:  %s\nwant: %s", got, want)
+			}
+			if record["request_id"] != "req-42" {
+				t.Errorf("expected request_id attr alongside the combined line, got %v", record["request_id"])
+			}
+		})
+	}
+}
+
+// TestStructuredFormat tests the attrs of the default access line
+func TestStructuredFormat(t *testing.T) {
+	advance := installFakeClock(t)
+	buf := serveWithMiddleware(t, fixedRequest("/calculate?op=add"), func(w http.ResponseWriter, _ *http.Request) {
+		advance(250 * time.Millisecond)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("12345"))
+	})
+
+	record := decodeJSONRecord(t, buf)
+	want := map[string]any{
+		"msg":        "access",
+		"method":     "GET",
+		"path":       "/calculate",
+		"status":     float64(http.StatusCreated),
+		"bytes":      float64(5),
+		"duration":   float64(250 * time.Millisecond),
+		"remote":     "192.0.2.10:52314",
+		"user_agent": `calcclient/1.0 "test"`,
+		"request_id": "req-42",
+	}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("expected %s=%v, got %v", key, value, record[key])
+		}
+	}
+}
+
+// TestSkipPaths tests that skipped paths are not logged but still get an ID
+func TestSkipPaths(t *testing.T) {
+	var seenID string
+	handler := func(_ http.ResponseWriter, r *http.Request) {
+		if rl, ok := slogger.ResponseLoggerFrom(r.Context()); ok {
+			seenID = rl.RequestID()
+		}
+	}
+
+	buf := serveWithMiddleware(t, fixedRequest("/health"), handler, slogger.WithSkipPaths("/health", "/ready"))
+	if buf.Len() != 0 {
+		t.Errorf("expected no access line for /health, got: %s", buf.String())
+	}
+	if seenID != "req-42" {
+		t.Errorf("expected skipped request to keep its ID, got %q", seenID)
+	}
+
+	buf = serveWithMiddleware(t, fixedRequest("/health/deep"), handler, slogger.WithSkipPaths("/health"))
+	if !strings.Contains(buf.String(), `"path":"/health/deep"`) {
+		t.Errorf("expected only exact matches to be skipped, got: %s", buf.String())
+	}
+}
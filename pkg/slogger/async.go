@@ -0,0 +1,246 @@
+package slogger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// AsyncOption configures a handler created by AsyncHandler.
+type AsyncOption func(*asyncState)
+
+// WithDropOldest discards the oldest buffered record to make room when
+// the buffer is full, instead of blocking the logging goroutine.
+// Discarded records are counted by Dropped.
+func WithDropOldest() AsyncOption {
+	return func(s *asyncState) {
+		s.dropOldest = true
+	}
+}
+
+// AsyncHandler returns a handler that queues records in a buffer of the
+// given size and passes them to next from a single consumer goroutine, so
+// logging does not wait on slow output. A negative buffer is taken as 0,
+// which hands each record to the consumer directly. By default a full
+// buffer blocks the caller; see WithDropOldest. Errors returned by next
+// are discarded.
+//
+// flush waits until every record queued before the call has been handled,
+// or until ctx is done. It is also registered with RegisterOnFatal so
+// that Fatal and FatalCode drain the buffer before exiting, until the
+// handler is closed with Close.
+func AsyncHandler(next slog.Handler, buffer int, opts ...AsyncOption) (slog.Handler, func(ctx context.Context) error) {
+	s := &asyncState{records: make(chan asyncRecord, max(buffer, 0)), done: make(chan struct{})}
+	for _, opt := range opts {
+		opt(s)
+	}
+	go s.consume()
+
+	// FatalCode bounds the wait with OnFatalTimeout
+	s.unregister = RegisterOnFatal(func() { _ = s.flush(context.Background()) })
+	return &asyncHandler{next: next, state: s}, s.flush
+}
+
+// Close releases what a handler created by AsyncHandler or
+// SamplingHandler, or derived from one, holds in the background.
+//
+// An async handler is flushed for at most the life of ctx, then its
+// consumer goroutine stops and its flush is unregistered from
+// RegisterOnFatal. Records still queued when ctx ends are discarded, and
+// ctx.Err() is returned. Records logged after Close are passed to next by
+// the logging goroutine.
+//
+// A sampling handler passes the summaries of its current tick to next
+// and stops its timer; it keeps sampling, with summaries passed by the
+// first record after each tick only.
+//
+// Closing again does nothing. Close returns nil at once for other
+// handlers, and does not close the handlers h passes records to.
+func Close(ctx context.Context, h slog.Handler) error {
+	switch h := h.(type) {
+	case *asyncHandler:
+		return h.state.close(ctx)
+	case *samplingHandler:
+		return h.state.close(ctx)
+	}
+	return nil
+}
+
+// Dropped returns the number of records discarded by a handler created by
+// AsyncHandler with WithDropOldest, or by one derived from it. It returns
+// 0 for other handlers.
+func Dropped(h slog.Handler) uint64 {
+	if a, ok := h.(*asyncHandler); ok {
+		return a.state.dropped.Load()
+	}
+	return 0
+}
+
+// asyncHandler queues records for the consumer goroutine
+type asyncHandler struct {
+	next  slog.Handler
+	state *asyncState
+}
+
+// asyncRecord is a queued record with the handler that must receive it
+type asyncRecord struct {
+	ctx     context.Context
+	handler slog.Handler
+	record  slog.Record
+}
+
+// asyncState is the queue shared by an async handler and its children
+type asyncState struct {
+	records    chan asyncRecord
+	dropOldest bool
+	dropped    atomic.Uint64
+	done       chan struct{} // closed to stop the consumer
+	unregister func()        // removes the fatal flush
+
+	mu        sync.Mutex
+	closed    bool
+	queued    uint64 // records accepted by Handle
+	completed uint64 // records handled or dropped
+	waiters   []asyncWaiter
+}
+
+// asyncWaiter is a pending flush
+type asyncWaiter struct {
+	target uint64
+	done   chan struct{}
+}
+
+func (h *asyncHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *asyncHandler) Handle(ctx context.Context, r slog.Record) error {
+	queued := h.state.enqueue(asyncRecord{
+		// The caller's context may be canceled before the record is handled
+		ctx:     context.WithoutCancel(ctx),
+		handler: h.next,
+		record:  r.Clone(),
+	})
+	if !queued {
+		return h.next.Handle(ctx, r)
+	}
+	return nil
+}
+
+func (h *asyncHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &asyncHandler{next: h.next.WithAttrs(attrs), state: h.state}
+}
+
+func (h *asyncHandler) WithGroup(name string) slog.Handler {
+	return &asyncHandler{next: h.next.WithGroup(name), state: h.state}
+}
+
+// enqueue adds rec to the buffer according to the full-buffer policy,
+// and reports false once the handler is closed, leaving rec to the caller
+func (s *asyncState) enqueue(rec asyncRecord) bool {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return false
+	}
+	s.queued++
+	s.mu.Unlock()
+
+	if !s.dropOldest {
+		select {
+		case s.records <- rec:
+			return true
+		case <-s.done:
+			// Close gave up on the buffer while the caller waited
+			s.complete()
+			return false
+		}
+	}
+	for {
+		select {
+		case s.records <- rec:
+			return true
+		case <-s.done:
+			s.complete()
+			return false
+		default:
+		}
+		select {
+		case <-s.records:
+			s.dropped.Add(1)
+			s.complete()
+		default:
+		}
+	}
+}
+
+// consume hands queued records to their handlers until the handler is
+// closed
+func (s *asyncState) consume() {
+	for {
+		select {
+		case rec := <-s.records:
+			_ = rec.handler.Handle(rec.ctx, rec.record)
+			s.complete()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// close flushes the queued records within ctx and stops the consumer
+func (s *asyncState) close(ctx context.Context) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	// Records accepted from now on are handled by their callers, so the
+	// flush waits for a fixed number of records
+	s.closed = true
+	s.mu.Unlock()
+
+	err := s.flush(ctx)
+	close(s.done)
+	s.unregister()
+	return err
+}
+
+// complete marks one record as done and releases satisfied flushes
+func (s *asyncState) complete() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.completed++
+	waiting := s.waiters[:0]
+	for _, w := range s.waiters {
+		if s.completed >= w.target {
+			close(w.done)
+		} else {
+			waiting = append(waiting, w)
+		}
+	}
+	s.waiters = waiting
+}
+
+// flush waits until the records queued so far are done or ctx ends
+func (s *asyncState) flush(ctx context.Context) error {
+	s.mu.Lock()
+	if s.completed >= s.queued {
+		s.mu.Unlock()
+		return nil
+	}
+	w := asyncWaiter{target: s.queued, done: make(chan struct{})}
+	s.waiters = append(s.waiters, w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-s.done:
+		// Close discarded what was left
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
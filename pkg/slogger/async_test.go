@@ -0,0 +1,202 @@
+package slogger_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"go-examples/pkg/slogger"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// gateHandler signals each record it receives and blocks until released
+type gateHandler struct {
+	*countingHandler
+	started chan string
+	release chan struct{}
+}
+
+func newGateHandler() *gateHandler {
+	return &gateHandler{countingHandler: newCountingHandler(), started: make(chan string, 100), release: make(chan struct{})}
+}
+
+func (h *gateHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.started <- r.Message
+	<-h.release
+	return h.countingHandler.Handle(ctx, r)
+}
+
+func (h *gateHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+// TestAsyncHandlerFlush tests that every record queued before flush is delivered
+func TestAsyncHandlerFlush(t *testing.T) {
+	counter := newCountingHandler()
+	handler, flush := slogger.AsyncHandler(counter, 16)
+	log := slog.New(handler)
+
+	for i := 0; i < 1000; i++ {
+		log.Info("queued", "i", i)
+	}
+	if err := flush(context.Background()); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+	if got := counter.counts["queued"]; got != 1000 {
+		t.Errorf("expected 1000 records delivered, got %d", got)
+	}
+	if got := slogger.Dropped(handler); got != 0 {
+		t.Errorf("expected no drops with the blocking policy, got %d", got)
+	}
+}
+
+// TestAsyncHandlerDropOldest tests the drop policy at capacity
+func TestAsyncHandlerDropOldest(t *testing.T) {
+	gate := newGateHandler()
+	handler, flush := slogger.AsyncHandler(gate, 2, slogger.WithDropOldest())
+	log := slog.New(handler)
+
+	log.Info("rec0")
+	<-gate.started // the consumer now holds rec0
+	for _, msg := range []string{"rec1", "rec2", "rec3", "rec4", "rec5", "rec6", "rec7", "rec8", "rec9"} {
+		log.Info(msg)
+	}
+	close(gate.release)
+	if err := flush(context.Background()); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+
+	if got := slogger.Dropped(handler.WithAttrs([]slog.Attr{slog.Int("a", 1)})); got != 7 {
+		t.Errorf("expected 7 dropped records, got %d", got)
+	}
+	for _, msg := range []string{"rec0", "rec8", "rec9"} {
+		if gate.counts[msg] != 1 {
+			t.Errorf("expected %s delivered, got counts %v", msg, gate.counts)
+		}
+	}
+	if gate.counts["rec1"] != 0 {
+		t.Errorf("expected the oldest records dropped, got counts %v", gate.counts)
+	}
+}
+
+// TestAsyncHandlerFlushDeadline tests that flush gives up when ctx ends
+func TestAsyncHandlerFlushDeadline(t *testing.T) {
+	gate := newGateHandler()
+	defer close(gate.release)
+	handler, flush := slogger.AsyncHandler(gate, 4)
+	slog.New(handler).Info("stuck")
+	<-gate.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := flush(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+// TestAsyncHandlerFlushedOnFatal tests that Fatal drains the buffer before exiting
+func TestAsyncHandlerFlushedOnFatal(t *testing.T) {
+	gate := newGateHandler()
+	handler, _ := slogger.AsyncHandler(gate, 8)
+	logger := slogger.New(slogger.WithWriter(io.Discard), slogger.WithAdditionalHandler(handler))
+
+	var delivered int
+	origExit := slogger.OsExit
+	slogger.OsExit = func(int) { delivered = gate.counts["before exit"] + gate.counts["exiting"] }
+	defer func() { slogger.OsExit = origExit }()
+
+	logger.Info("before exit")
+	go func() {
+		// Let records through only once Fatal is waiting on the flush
+		<-gate.started
+		close(gate.release)
+	}()
+	logger.Fatal("exiting")
+
+	if delivered != 2 {
+		t.Errorf("expected both records delivered before exit, got %d", delivered)
+	}
+}
+
+// TestAsyncHandlerNegativeBuffer tests that a negative buffer hands
+// records over unbuffered instead of panicking
+func TestAsyncHandlerNegativeBuffer(t *testing.T) {
+	counter := newCountingHandler()
+	handler, flush := slogger.AsyncHandler(counter, -1)
+	defer slogger.Close(context.Background(), handler)
+	slog.New(handler).Info("unbuffered")
+	if err := flush(context.Background()); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+	if got := counter.counts["unbuffered"]; got != 1 {
+		t.Errorf("expected the record delivered, got %d", got)
+	}
+}
+
+// TestAsyncHandlerClose tests that Close delivers the queued records, and
+// that records logged afterwards are handled by the caller
+func TestAsyncHandlerClose(t *testing.T) {
+	counter := newCountingHandler()
+	handler, _ := slogger.AsyncHandler(counter, 16)
+	log := slog.New(handler.WithAttrs([]slog.Attr{slog.Int("a", 1)}))
+	for i := 0; i < 100; i++ {
+		log.Info("queued")
+	}
+	if err := slogger.Close(context.Background(), handler); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if got := counter.counts["queued"]; got != 100 {
+		t.Errorf("expected 100 records delivered by Close, got %d", got)
+	}
+
+	log.Info("after close")
+	if got := counter.counts["after close"]; got != 1 {
+		t.Errorf("expected the record logged after Close delivered at once, got %d", got)
+	}
+	if err := slogger.Close(context.Background(), handler); err != nil {
+		t.Errorf("second Close = %v, want nil", err)
+	}
+	if err := slogger.Close(context.Background(), slog.NewTextHandler(io.Discard, nil)); err != nil {
+		t.Errorf("Close of a handler that is not async = %v, want nil", err)
+	}
+}
+
+// TestAsyncHandlerCloseUnregisters tests that a closed handler no longer
+// holds up Fatal, even when Close gave up on its buffer
+func TestAsyncHandlerCloseUnregisters(t *testing.T) {
+	installExit(t)
+	origTimeout := slogger.OnFatalTimeout
+	slogger.OnFatalTimeout = 100 * time.Millisecond
+	defer func() { slogger.OnFatalTimeout = origTimeout }()
+
+	gate := newGateHandler()
+	defer close(gate.release)
+	handler, flush := slogger.AsyncHandler(gate, 4)
+	log := slog.New(handler)
+	log.Info("stuck")
+	<-gate.started
+	log.Info("queued")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := slogger.Close(ctx, handler); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if err := flush(context.Background()); err != nil {
+		t.Errorf("flush after Close = %v, want nil", err)
+	}
+
+	var buf bytes.Buffer
+	slogger.New(slogger.WithWriter(&buf)).Fatal("exiting")
+	if strings.Contains(buf.String(), "fatal cleanup timed out") {
+		t.Errorf("expected Fatal not to wait on the closed handler, got: %s", buf.String())
+	}
+}
+
+// TestDroppedOtherHandler tests Dropped on a handler that is not async
+func TestDroppedOtherHandler(t *testing.T) {
+	if got := slogger.Dropped(slog.NewTextHandler(io.Discard, nil)); got != 0 {
+		t.Errorf("expected 0, got %d", got)
+	}
+}
@@ -0,0 +1,20 @@
+package slogger
+
+import "context"
+
+// contextKey is the key under which a Logger is stored in a context.
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying l.
+func NewContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the Logger stored in ctx by NewContext, or a zero
+// Logger that logs through the global slog default when there is none.
+func FromContext(ctx context.Context) Logger {
+	if l, ok := ctx.Value(contextKey{}).(Logger); ok {
+		return l
+	}
+	return Logger{}
+}
@@ -0,0 +1,109 @@
+package slogger_test
+
+import (
+	"bytes"
+	"context"
+	"go-examples/pkg/slogger"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// ctxValueKey is a context key used to tag test contexts
+type ctxValueKey struct{}
+
+// contextRecordingHandler records the contexts passed to Handle
+type contextRecordingHandler struct {
+	mu       sync.Mutex
+	contexts []context.Context
+	records  []slog.Record
+}
+
+func (h *contextRecordingHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *contextRecordingHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.contexts = append(h.contexts, ctx)
+	h.records = append(h.records, r)
+	return nil
+}
+
+func (h *contextRecordingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *contextRecordingHandler) WithGroup(string) slog.Handler      { return h }
+
+// installRecordingHandler makes a recording handler the global default for the test
+func installRecordingHandler(t *testing.T) *contextRecordingHandler {
+	t.Helper()
+	handler := &contextRecordingHandler{}
+	origLogger := slog.Default()
+	slog.SetDefault(slog.New(handler))
+	t.Cleanup(func() { slog.SetDefault(origLogger) })
+	return handler
+}
+
+// TestContextMethods tests that each *Context method passes its context to the handler
+func TestContextMethods(t *testing.T) {
+	handler := installRecordingHandler(t)
+	ctx := context.WithValue(context.Background(), ctxValueKey{}, "trace-123")
+
+	logger := slogger.Logger{}
+	logger.DebugContext(ctx, "debug")
+	logger.InfoContext(ctx, "info")
+	logger.WarnContext(ctx, "warn")
+	logger.ErrorContext(ctx, "error")
+
+	wantLevels := []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError}
+	if len(handler.records) != len(wantLevels) {
+		t.Fatalf("expected %d records, got %d", len(wantLevels), len(handler.records))
+	}
+	for i, got := range handler.contexts {
+		if got.Value(ctxValueKey{}) != "trace-123" {
+			t.Errorf("record %d: handler did not receive the caller's context", i)
+		}
+		if handler.records[i].Level != wantLevels[i] {
+			t.Errorf("record %d: level = %v; want %v", i, handler.records[i].Level, wantLevels[i])
+		}
+	}
+}
+
+// TestResponseLoggerUsesRequestContext tests that the request's context reaches the handler
+func TestResponseLoggerUsesRequestContext(t *testing.T) {
+	handler := installRecordingHandler(t)
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req = req.WithContext(context.WithValue(req.Context(), ctxValueKey{}, "trace-456"))
+
+	logger := slogger.Logger{}
+	logger.NewResponseLogger("req-1").Response(200, req)
+
+	if len(handler.contexts) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(handler.contexts))
+	}
+	if handler.contexts[0].Value(ctxValueKey{}) != "trace-456" {
+		t.Error("handler did not receive the request's context")
+	}
+}
+
+// TestNewContextFromContext tests storing and retrieving a Logger
+func TestNewContextFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slogger.New(slogger.WithWriter(&buf)).With("request_id", "req-9")
+	ctx := slogger.NewContext(context.Background(), logger)
+
+	retrieved := slogger.FromContext(ctx)
+	retrieved.Info("from context")
+	if !strings.Contains(buf.String(), "request_id=req-9") {
+		t.Errorf("expected the stored logger to be returned, got: %s", buf.String())
+	}
+
+	// A context without a logger yields a zero Logger using the default
+	handler := installRecordingHandler(t)
+	empty := slogger.FromContext(context.Background())
+	empty.Info("fallback")
+	if len(handler.records) != 1 {
+		t.Errorf("expected fallback logger to log through the default, got %d records", len(handler.records))
+	}
+}
@@ -0,0 +1,119 @@
+package slogger
+
+import (
+	"log/slog"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// ErrOption configures the attribute created by Err.
+type ErrOption func(*errConfig)
+
+// errConfig holds the settings collected from ErrOption values.
+type errConfig struct {
+	stack bool
+}
+
+// WithErrStack adds a stack attribute captured where Err is called,
+// unless an error in the chain already carries its own stack trace
+// through a StackTrace method.
+func WithErrStack() ErrOption {
+	return func(c *errConfig) {
+		c.stack = true
+	}
+}
+
+// Err returns an "error" attribute grouping the error message as msg and
+// its dynamic type as type. When err wraps other errors, chain lists the
+// messages of every error in the chain, outermost first. A nil err yields
+// an empty attribute that handlers omit.
+func Err(err error, opts ...ErrOption) slog.Attr {
+	if err == nil {
+		return slog.Attr{}
+	}
+	cfg := errConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	attrs := []slog.Attr{
+		slog.String("msg", err.Error()),
+		slog.String("type", reflect.TypeOf(err).String()),
+	}
+	if chain := errorChain(err); len(chain) > 1 {
+		attrs = append(attrs, slog.Any("chain", chain))
+	}
+	if cfg.stack && !hasStackTrace(err) {
+		attrs = append(attrs, slog.String("stack", callerStack(1)))
+	}
+	return slog.Attr{Key: "error", Value: slog.GroupValue(attrs...)}
+}
+
+// errorChain returns the messages of err and every error it wraps,
+// outermost first
+func errorChain(err error) []string {
+	var chain []string
+	walkErrors(err, func(e error) bool {
+		chain = append(chain, e.Error())
+		return true
+	})
+	return chain
+}
+
+// hasStackTrace reports whether an error in the chain has a StackTrace
+// method, as errors from github.com/pkg/errors and similar packages do
+func hasStackTrace(err error) bool {
+	found := false
+	walkErrors(err, func(e error) bool {
+		_, found = reflect.TypeOf(e).MethodByName("StackTrace")
+		return !found
+	})
+	return found
+}
+
+// walkErrors calls visit for err and the errors it wraps, depth first,
+// following both Unwrap() error and Unwrap() []error, until visit
+// returns false
+func walkErrors(err error, visit func(error) bool) bool {
+	if err == nil {
+		return true
+	}
+	if !visit(err) {
+		return false
+	}
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		return walkErrors(u.Unwrap(), visit)
+	case interface{ Unwrap() []error }:
+		for _, inner := range u.Unwrap() {
+			if !walkErrors(inner, visit) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// callerStack formats the stack starting skip frames above its caller
+func callerStack(skip int) string {
+	pcs := make([]uintptr, 32)
+	// Skip runtime.Callers and callerStack itself
+	n := runtime.Callers(skip+2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var b strings.Builder
+	for {
+		frame, more := frames.Next()
+		b.WriteString(frame.Function)
+		b.WriteString("\n\t")
+		b.WriteString(frame.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(frame.Line))
+		b.WriteByte('\n')
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,105 @@
+package slogger_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go-examples/pkg/slogger"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// errNegative is a sentinel used to build wrapped chains
+var errNegative = errors.New("negative operand")
+
+// stackError carries its own stack trace, like errors from github.com/pkg/errors
+type stackError struct{ msg string }
+
+func (e *stackError) Error() string        { return e.msg }
+func (e *stackError) StackTrace() []string { return []string{"origin"} }
+
+// TestErrJSON tests the error group for a wrapped chain in JSON output
+func TestErrJSON(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slogger.New(slogger.WithWriter(&buf), slogger.WithJSON())
+
+	err := fmt.Errorf("calculate: %w", fmt.Errorf("sqrt: %w", errNegative))
+	logger.Error("failed", slogger.Err(err))
+
+	group, ok := decodeJSONRecord(t, &buf)["error"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected an error object, got: %s", buf.String())
+	}
+	if group["msg"] != "calculate: sqrt: negative operand" {
+		t.Errorf("unexpected msg %v", group["msg"])
+	}
+	if group["type"] != "*fmt.wrapError" {
+		t.Errorf("unexpected type %v", group["type"])
+	}
+	chain, _ := group["chain"].([]any)
+	want := []string{"calculate: sqrt: negative operand", "sqrt: negative operand", "negative operand"}
+	if fmt.Sprint(chain) != fmt.Sprint(want) {
+		t.Errorf("expected chain %v, got %v", want, group["chain"])
+	}
+}
+
+// TestErrText tests the dotted keys in text output and the absent chain
+// for unwrapped errors
+func TestErrText(t *testing.T) {
+	var buf bytes.Buffer
+	slogger.New(slogger.WithWriter(&buf)).Error("failed", slogger.Err(errNegative))
+
+	out := buf.String()
+	for _, want := range []string{`error.msg="negative operand"`, "error.type=*errors.errorString"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got: %s", want, out)
+		}
+	}
+	if strings.Contains(out, "error.chain") {
+		t.Errorf("expected no chain for an unwrapped error, got: %s", out)
+	}
+}
+
+// TestErrJoined tests that joined errors are all listed in the chain
+func TestErrJoined(t *testing.T) {
+	var buf bytes.Buffer
+	slogger.New(slogger.WithWriter(&buf), slogger.WithJSON()).
+		Error("failed", slogger.Err(errors.Join(errNegative, errors.New("overflow"))))
+
+	group := decodeJSONRecord(t, &buf)["error"].(map[string]any)
+	chain, _ := group["chain"].([]any)
+	if len(chain) != 3 || chain[1] != "negative operand" || chain[2] != "overflow" {
+		t.Errorf("expected the join followed by both errors, got %v", group["chain"])
+	}
+}
+
+// TestErrNil tests that a nil error adds nothing to the record
+func TestErrNil(t *testing.T) {
+	var buf bytes.Buffer
+	slogger.New(slogger.WithWriter(&buf)).Info("ok", slogger.Err(nil), "op", "add")
+
+	if strings.Contains(buf.String(), "error") || strings.Contains(buf.String(), "nil") {
+		t.Errorf("expected no error attr, got: %s", buf.String())
+	}
+	if attr := slogger.Err(nil); !attr.Equal(slog.Attr{}) {
+		t.Errorf("expected an empty attr, got %v", attr)
+	}
+}
+
+// TestErrStack tests the optional call-site stack trace
+func TestErrStack(t *testing.T) {
+	stack := func(err error) string {
+		var buf bytes.Buffer
+		slogger.New(slogger.WithWriter(&buf), slogger.WithJSON()).Error("failed", slogger.Err(err, slogger.WithErrStack()))
+		s, _ := decodeJSONRecord(t, &buf)["error"].(map[string]any)["stack"].(string)
+		return s
+	}
+
+	if s := stack(errNegative); !strings.HasPrefix(s, "go-examples/pkg/slogger_test.TestErrStack.func1\n") {
+		t.Errorf("expected a stack starting at the caller, got %q", s)
+	}
+	if s := stack(fmt.Errorf("wrapped: %w", &stackError{msg: "deep"})); s != "" {
+		t.Errorf("expected no stack for errors carrying their own, got %q", s)
+	}
+}
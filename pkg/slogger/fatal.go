@@ -0,0 +1,112 @@
+package slogger
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// OnFatalTimeout bounds how long FatalCode waits for the callbacks
+// registered with RegisterOnFatal before exiting anyway.
+var OnFatalTimeout = 5 * time.Second
+
+// onFatal holds the registered cleanup callbacks
+var onFatal struct {
+	mu    sync.Mutex
+	next  int
+	funcs map[int]func()
+	order []int
+}
+
+// RegisterOnFatal adds fn to the callbacks run by Fatal and FatalCode
+// before the process exits, so buffered sinks can flush and servers can
+// shut down. Callbacks run in reverse registration order, like deferred
+// calls. A panicking callback is logged and does not stop the others.
+// The returned function unregisters fn.
+func RegisterOnFatal(fn func()) (unregister func()) {
+	onFatal.mu.Lock()
+	defer onFatal.mu.Unlock()
+	if onFatal.funcs == nil {
+		onFatal.funcs = map[int]func(){}
+	}
+	id := onFatal.next
+	onFatal.next++
+	onFatal.funcs[id] = fn
+	onFatal.order = append(onFatal.order, id)
+
+	return func() {
+		onFatal.mu.Lock()
+		defer onFatal.mu.Unlock()
+		delete(onFatal.funcs, id)
+		onFatal.order = slices.DeleteFunc(onFatal.order, func(o int) bool { return o == id })
+	}
+}
+
+// fatalCallbacks returns the registered callbacks, most recent first
+func fatalCallbacks() []func() {
+	onFatal.mu.Lock()
+	defer onFatal.mu.Unlock()
+	var funcs []func()
+	for i := len(onFatal.order) - 1; i >= 0; i-- {
+		if fn, ok := onFatal.funcs[onFatal.order[i]]; ok {
+			funcs = append(funcs, fn)
+		}
+	}
+	return funcs
+}
+
+// FatalCode logs a message at error level, runs the callbacks registered
+// with RegisterOnFatal for at most OnFatalTimeout, and exits the program
+// with the given status code through OsExit.
+func (l Logger) FatalCode(code int, msg string, args ...any) {
+	l.fatal(code, msg, args...)
+}
+
+// fatal logs, runs the callbacks and exits; it must be called directly
+// by an exported method for the reported source to be correct
+func (l Logger) fatal(code int, msg string, args ...any) {
+	l.log(context.Background(), 1, slog.LevelError, msg, args...)
+	l.runOnFatal()
+	OsExit(code)
+}
+
+// Exit runs the callbacks registered with RegisterOnFatal for at most
+// OnFatalTimeout and exits the program through OsExit. It lets code
+// outside slogger, such as the exit hook of a zap logger, share the
+// cleanup path of Fatal.
+func Exit(code int) {
+	Logger{}.runOnFatal()
+	OsExit(code)
+}
+
+// runOnFatal runs the fatal callbacks within the time budget
+func (l Logger) runOnFatal() {
+	funcs := fatalCallbacks()
+	if len(funcs) == 0 {
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, fn := range funcs {
+			l.runProtected(fn)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(OnFatalTimeout):
+		l.base().Log(context.Background(), slog.LevelError, "fatal cleanup timed out", "timeout", OnFatalTimeout)
+	}
+}
+
+// runProtected calls fn, logging instead of propagating a panic
+func (l Logger) runProtected(fn func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			l.base().Log(context.Background(), slog.LevelError, "fatal cleanup panicked", "panic", r)
+		}
+	}()
+	fn()
+}
@@ -0,0 +1,104 @@
+package slogger_test
+
+import (
+	"bytes"
+	"go-examples/pkg/slogger"
+	"strings"
+	"testing"
+	"time"
+)
+
+// installExit replaces slogger.OsExit and returns the recorded exit codes
+func installExit(t *testing.T) *[]int {
+	t.Helper()
+	var codes []int
+	origExit := slogger.OsExit
+	slogger.OsExit = func(code int) { codes = append(codes, code) }
+	t.Cleanup(func() { slogger.OsExit = origExit })
+	return &codes
+}
+
+// TestFatalCodeRunsCallbacks tests that callbacks run, panics are contained,
+// and the exit code is passed on
+func TestFatalCodeRunsCallbacks(t *testing.T) {
+	codes := installExit(t)
+	var buf bytes.Buffer
+	logger := slogger.New(slogger.WithWriter(&buf))
+
+	var order []string
+	unregisterFlush := slogger.RegisterOnFatal(func() { order = append(order, "flush") })
+	defer unregisterFlush()
+	unregisterPanic := slogger.RegisterOnFatal(func() {
+		order = append(order, "panic")
+		panic("cleanup exploded")
+	})
+	defer unregisterPanic()
+
+	logger.FatalCode(3, "cannot continue", "reason", "disk full")
+
+	if strings.Join(order, ",") != "panic,flush" {
+		t.Errorf("expected both callbacks in reverse registration order, got %v", order)
+	}
+	if len(*codes) != 1 || (*codes)[0] != 3 {
+		t.Errorf("expected a single exit with code 3, got %v", *codes)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "cannot continue") || !strings.Contains(out, "reason=\"disk full\"") {
+		t.Errorf("expected the fatal log line, got: %s", out)
+	}
+	if !strings.Contains(out, "fatal cleanup panicked") || !strings.Contains(out, "cleanup exploded") {
+		t.Errorf("expected the contained panic to be logged, got: %s", out)
+	}
+}
+
+// TestFatalUnregister tests that unregistered callbacks no longer run
+func TestFatalUnregister(t *testing.T) {
+	installExit(t)
+	ran := false
+	unregister := slogger.RegisterOnFatal(func() { ran = true })
+	unregister()
+
+	slogger.New(slogger.WithWriter(&bytes.Buffer{})).Fatal("bye")
+	if ran {
+		t.Error("expected unregistered callback not to run")
+	}
+}
+
+// TestFatalCallbackTimeout tests that a stuck callback does not block the exit
+func TestFatalCallbackTimeout(t *testing.T) {
+	codes := installExit(t)
+	origTimeout := slogger.OnFatalTimeout
+	slogger.OnFatalTimeout = 10 * time.Millisecond
+	defer func() { slogger.OnFatalTimeout = origTimeout }()
+
+	release := make(chan struct{})
+	defer close(release)
+	unregister := slogger.RegisterOnFatal(func() { <-release })
+	defer unregister()
+
+	var buf bytes.Buffer
+	slogger.New(slogger.WithWriter(&buf)).FatalCode(2, "stuck")
+
+	if len(*codes) != 1 || (*codes)[0] != 2 {
+		t.Errorf("expected exit code 2 despite the stuck callback, got %v", *codes)
+	}
+	if !strings.Contains(buf.String(), "fatal cleanup timed out") {
+		t.Errorf("expected a timeout message, got: %s", buf.String())
+	}
+}
+
+// TestExitRunsCallbacks tests that Exit shares the Fatal cleanup path
+func TestExitRunsCallbacks(t *testing.T) {
+	codes := installExit(t)
+	ran := false
+	unregister := slogger.RegisterOnFatal(func() { ran = true })
+	defer unregister()
+
+	slogger.Exit(4)
+	if !ran {
+		t.Error("expected the callback to run")
+	}
+	if len(*codes) != 1 || (*codes)[0] != 4 {
+		t.Errorf("expected exit code 4, got %v", *codes)
+	}
+}
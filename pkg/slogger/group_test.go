@@ -0,0 +1,104 @@
+package slogger_test
+
+import (
+	"bytes"
+	"go-examples/pkg/slogger"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestWithGroupText tests that grouped attrs use dotted keys in text output
+func TestWithGroupText(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slogger.New(slogger.WithWriter(&buf)).With("service", "calc")
+
+	logger.WithGroup("calc").Info("done", "op", "add", "result", 8,
+		slog.Group("operands", "a", 3, "b", 5))
+
+	out := buf.String()
+	for _, want := range []string{"service=calc", "calc.op=add", "calc.result=8", "calc.operands.a=3", "calc.operands.b=5"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got: %s", want, out)
+		}
+	}
+}
+
+// TestWithGroupJSON tests that grouped attrs are nested objects in JSON output
+func TestWithGroupJSON(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slogger.New(slogger.WithWriter(&buf), slogger.WithJSON())
+
+	logger.WithGroup("calc").With("op", "add").Info("done", "result", 8,
+		slog.Group("operands", "a", 3, "b", 5))
+
+	record := decodeJSONRecord(t, &buf)
+	calc, ok := record["calc"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected a nested calc object, got: %s", buf.String())
+	}
+	if calc["op"] != "add" || calc["result"] != float64(8) {
+		t.Errorf("unexpected calc group %v", calc)
+	}
+	operands, ok := calc["operands"].(map[string]any)
+	if !ok || operands["a"] != float64(3) || operands["b"] != float64(5) {
+		t.Errorf("expected nested operands group, got %v", calc["operands"])
+	}
+	if _, ok := record["op"]; ok {
+		t.Error("expected op inside the group, not at the top level")
+	}
+}
+
+// TestResponseGroupedFields tests the http group in ResponseLogger output
+func TestResponseGroupedFields(t *testing.T) {
+	advance := installFakeClock(t)
+	req := httptest.NewRequest("GET", "/calculate", nil)
+
+	t.Run("JSON", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slogger.New(slogger.WithWriter(&buf), slogger.WithJSON(), slogger.WithGroupedFields())
+		rl := logger.NewResponseLogger("req-1").Start()
+		advance(2 * time.Millisecond)
+		rl.Response(http.StatusOK, req, "result", 8)
+
+		record := decodeJSONRecord(t, &buf)
+		group, ok := record["http"].(map[string]any)
+		if !ok {
+			t.Fatalf("expected an http object, got: %s", buf.String())
+		}
+		if group["code"] != float64(200) || group["uri"] != "/calculate" || group["duration_ms"] != float64(2) {
+			t.Errorf("unexpected http group %v", group)
+		}
+		if record["result"] != float64(8) || record["request_id"] != "req-1" {
+			t.Errorf("expected caller args and request_id at the top level, got %v", record)
+		}
+		if _, ok := record["code"]; ok {
+			t.Error("expected code only inside the http group")
+		}
+	})
+
+	t.Run("Text", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slogger.New(slogger.WithWriter(&buf), slogger.WithGroupedFields())
+		logger.NewResponseLogger("req-2").Response(http.StatusNotFound, req)
+
+		out := buf.String()
+		for _, want := range []string{"http.code=404", "http.method=GET", "http.uri=/calculate", "request_id=req-2"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("expected %q in output, got: %s", want, out)
+			}
+		}
+	})
+
+	t.Run("Default flat", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slogger.New(slogger.WithWriter(&buf))
+		logger.NewResponseLogger("req-3").Response(http.StatusOK, req)
+		if !strings.Contains(buf.String(), " code=200") || strings.Contains(buf.String(), "http.") {
+			t.Errorf("expected flat fields without WithGroupedFields, got: %s", buf.String())
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package slogger
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+)
+
+// RequestIDHeader is the header used to read and propagate request IDs.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the length of client-provided request IDs.
+const maxRequestIDLength = 128
+
+// responseLoggerKey is the context key for the request's ResponseLogger.
+type responseLoggerKey struct{}
+
+// NewRequestID returns a random UUIDv4 string generated with crypto/rand.
+func NewRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// crypto/rand only fails if the OS entropy source is broken
+		panic(fmt.Sprintf("slogger: cannot generate request ID: %v", err))
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// Middleware returns HTTP middleware that assigns every request an ID,
+// echoes it in the X-Request-ID response header, and stores a
+// ResponseLogger bound to it in the request context, along with its
+// request-scoped Logger for retrieval with FromContext. A well-formed
+// X-Request-ID sent by the client is preserved; otherwise a new one is
+// generated with NewRequestID. Once the handler returns, an access line
+// is logged with the status and size captured by a RecordingWriter, in
+// the format chosen with WithFormat.
+func Middleware(l Logger, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	cfg := &middlewareConfig{skipPaths: map[string]bool{}}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if !ValidRequestID(id) {
+				id = NewRequestID()
+			}
+			w.Header().Set(RequestIDHeader, id)
+
+			rw := WrapResponseWriter(w)
+			rl := l.NewResponseLogger(id).Start().Track(rw)
+			ctx := context.WithValue(r.Context(), responseLoggerKey{}, rl)
+			r = r.WithContext(NewContext(ctx, rl.Logger()))
+			next.ServeHTTP(rw, r)
+			cfg.logAccess(rl, rw, r)
+		})
+	}
+}
+
+// ResponseLoggerFrom returns the ResponseLogger stored in ctx by Middleware.
+func ResponseLoggerFrom(ctx context.Context) (*ResponseLogger, bool) {
+	rl, ok := ctx.Value(responseLoggerKey{}).(*ResponseLogger)
+	return rl, ok
+}
+
+// ValidRequestID reports whether a client-provided ID is safe to reuse:
+// non-empty, bounded in length, and made of printable ASCII.
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,153 @@
+package slogger_test
+
+import (
+	"bytes"
+	"context"
+	"go-examples/pkg/slogger"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// uuidV4Pattern matches canonical UUIDv4 strings
+var uuidV4Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+// TestNewRequestID tests that generated IDs are well-formed and unique
+func TestNewRequestID(t *testing.T) {
+	seen := make(map[string]bool, 1000)
+	for i := 0; i < 1000; i++ {
+		id := slogger.NewRequestID()
+		if !uuidV4Pattern.MatchString(id) {
+			t.Fatalf("malformed request ID %q", id)
+		}
+		if seen[id] {
+			t.Fatalf("duplicate request ID %q after %d calls", id, i)
+		}
+		seen[id] = true
+	}
+}
+
+// TestMiddlewareGeneratesID tests that requests without an ID get a new one
+func TestMiddlewareGeneratesID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slogger.New(slogger.WithWriter(&buf))
+
+	var seenID string
+	handler := slogger.Middleware(logger)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		rl, ok := slogger.ResponseLoggerFrom(r.Context())
+		if !ok {
+			t.Fatal("expected a ResponseLogger in the request context")
+		}
+		seenID = rl.RequestID()
+		rl.Response(http.StatusOK, r)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/calculate", nil))
+
+	headerID := rec.Header().Get(slogger.RequestIDHeader)
+	if !uuidV4Pattern.MatchString(headerID) {
+		t.Errorf("expected generated UUIDv4 in response header, got %q", headerID)
+	}
+	if seenID != headerID {
+		t.Errorf("handler saw ID %q; response header has %q", seenID, headerID)
+	}
+	if !strings.Contains(buf.String(), "request_id="+headerID) {
+		t.Errorf("expected log line with the request ID, got: %s", buf.String())
+	}
+}
+
+// TestMiddlewarePreservesProvidedID tests that well-formed client IDs are kept
+func TestMiddlewarePreservesProvidedID(t *testing.T) {
+	tests := []struct {
+		name     string
+		provided string
+		preserve bool
+	}{
+		{name: "client id", provided: "client-abc-123", preserve: true},
+		{name: "uuid", provided: "1b4e28ba-2fa1-41d2-883f-0016d3cca427", preserve: true},
+		{name: "contains space", provided: "bad id", preserve: false},
+		{name: "too long", provided: strings.Repeat("x", 200), preserve: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := slogger.Middleware(slogger.New(slogger.WithWriter(&bytes.Buffer{})))(
+				http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set(slogger.RequestIDHeader, tc.provided)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			got := rec.Header().Get(slogger.RequestIDHeader)
+			if tc.preserve && got != tc.provided {
+				t.Errorf("expected provided ID %q to be preserved, got %q", tc.provided, got)
+			}
+			if !tc.preserve && !uuidV4Pattern.MatchString(got) {
+				t.Errorf("expected invalid ID to be replaced with a UUID, got %q", got)
+			}
+		})
+	}
+}
+
+// TestMiddlewareSharesResponseLogger tests that nested handlers get the same instance
+func TestMiddlewareSharesResponseLogger(t *testing.T) {
+	var fromOuter, fromInner *slogger.ResponseLogger
+	inner := http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		fromInner, _ = slogger.ResponseLoggerFrom(r.Context())
+	})
+	outer := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fromOuter, _ = slogger.ResponseLoggerFrom(r.Context())
+		inner.ServeHTTP(w, r)
+	})
+
+	handler := slogger.Middleware(slogger.New(slogger.WithWriter(&bytes.Buffer{})))(outer)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if fromOuter == nil || fromOuter != fromInner {
+		t.Errorf("expected the same ResponseLogger instance, got %p and %p", fromOuter, fromInner)
+	}
+
+	if _, ok := slogger.ResponseLoggerFrom(httptest.NewRequest("GET", "/", nil).Context()); ok {
+		t.Error("expected no ResponseLogger outside the middleware")
+	}
+}
+
+// computeDeep stands in for code far below the handler that only has a context
+func computeDeep(ctx context.Context) {
+	slogger.FromContext(ctx).Info("deep computation", "step", 3)
+}
+
+// TestMiddlewareStoresRequestLogger tests that FromContext returns a logger
+// carrying the middleware's request ID
+func TestMiddlewareStoresRequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slogger.New(slogger.WithWriter(&buf), slogger.WithJSON())
+
+	handler := slogger.Middleware(logger, slogger.WithSkipPaths("/calculate"))(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		computeDeep(r.Context())
+	}))
+	req := httptest.NewRequest("POST", "/calculate", nil)
+	req.Header.Set(slogger.RequestIDHeader, "req-deep")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	record := decodeJSONRecord(t, &buf)
+	if record["msg"] != "deep computation" || record["request_id"] != "req-deep" {
+		t.Errorf("expected the nested line to carry the request ID, got: %s", buf.String())
+	}
+}
+
+// TestResponseLoggerLogger tests that the child logger carries the request ID
+func TestResponseLoggerLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slogger.New(slogger.WithWriter(&buf))
+	child := logger.NewResponseLogger("req-9").Logger()
+	child.Info("parsing body")
+
+	if !strings.Contains(buf.String(), "request_id=req-9") {
+		t.Errorf("expected request_id on the child logger, got: %s", buf.String())
+	}
+}
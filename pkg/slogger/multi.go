@@ -0,0 +1,59 @@
+package slogger
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// NewMultiHandler returns a handler that sends every record to each of
+// handlers whose Enabled reports true for its level. It is enabled when
+// any child is, and errors from children are joined with errors.Join
+// after every child has been tried.
+func NewMultiHandler(handlers ...slog.Handler) slog.Handler {
+	return &multiHandler{handlers: handlers}
+}
+
+// multiHandler fans records out to several handlers
+type multiHandler struct {
+	handlers []slog.Handler
+}
+
+func (h *multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, child := range h.handlers {
+		if child.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, child := range h.handlers {
+		if !child.Enabled(ctx, r.Level) {
+			continue
+		}
+		// Each child gets its own copy so none can affect the others
+		if err := child.Handle(ctx, r.Clone()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h *multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	children := make([]slog.Handler, len(h.handlers))
+	for i, child := range h.handlers {
+		children[i] = child.WithAttrs(attrs)
+	}
+	return &multiHandler{handlers: children}
+}
+
+func (h *multiHandler) WithGroup(name string) slog.Handler {
+	children := make([]slog.Handler, len(h.handlers))
+	for i, child := range h.handlers {
+		children[i] = child.WithGroup(name)
+	}
+	return &multiHandler{handlers: children}
+}
@@ -0,0 +1,110 @@
+package slogger_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"go-examples/pkg/slogger"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// failingHandler accepts every record and fails to handle it
+type failingHandler struct{ err error }
+
+func (h failingHandler) Enabled(context.Context, slog.Level) bool  { return true }
+func (h failingHandler) Handle(context.Context, slog.Record) error { return h.err }
+func (h failingHandler) WithAttrs([]slog.Attr) slog.Handler        { return h }
+func (h failingHandler) WithGroup(string) slog.Handler             { return h }
+
+// TestMultiHandlerLevels tests that each child receives only its levels
+func TestMultiHandlerLevels(t *testing.T) {
+	var debugBuf, warnBuf bytes.Buffer
+	multi := slogger.NewMultiHandler(
+		slog.NewTextHandler(&debugBuf, &slog.HandlerOptions{Level: slog.LevelDebug}),
+		slog.NewTextHandler(&warnBuf, &slog.HandlerOptions{Level: slog.LevelWarn}),
+	)
+	if !multi.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("expected debug enabled because one child accepts it")
+	}
+
+	log := slog.New(multi)
+	log.Debug("debug line")
+	log.Warn("warn line")
+
+	if !strings.Contains(debugBuf.String(), "debug line") || !strings.Contains(debugBuf.String(), "warn line") {
+		t.Errorf("expected both lines in the debug handler, got: %s", debugBuf.String())
+	}
+	if strings.Contains(warnBuf.String(), "debug line") || !strings.Contains(warnBuf.String(), "warn line") {
+		t.Errorf("expected only the warn line in the warn handler, got: %s", warnBuf.String())
+	}
+}
+
+// TestMultiHandlerNoneEnabled tests Enabled when no child accepts the level
+func TestMultiHandlerNoneEnabled(t *testing.T) {
+	multi := slogger.NewMultiHandler(
+		slog.NewTextHandler(&bytes.Buffer{}, &slog.HandlerOptions{Level: slog.LevelError}),
+	)
+	if multi.Enabled(context.Background(), slog.LevelInfo) {
+		t.Error("expected info disabled")
+	}
+}
+
+// TestMultiHandlerWithAttrs tests that attrs and groups reach every child
+func TestMultiHandlerWithAttrs(t *testing.T) {
+	var textBuf, jsonBuf bytes.Buffer
+	log := slog.New(slogger.NewMultiHandler(
+		slog.NewTextHandler(&textBuf, nil),
+		slog.NewJSONHandler(&jsonBuf, nil),
+	)).With("service", "calc").WithGroup("op")
+
+	log.Info("done", "name", "add")
+
+	if !strings.Contains(textBuf.String(), "service=calc") || !strings.Contains(textBuf.String(), "op.name=add") {
+		t.Errorf("expected attrs in text output, got: %s", textBuf.String())
+	}
+	record := decodeJSONRecord(t, &jsonBuf)
+	if record["service"] != "calc" || record["op"].(map[string]any)["name"] != "add" {
+		t.Errorf("expected attrs in JSON output, got: %v", record)
+	}
+}
+
+// TestMultiHandlerFailingChild tests that one failure does not block delivery
+func TestMultiHandlerFailingChild(t *testing.T) {
+	errDisk := errors.New("disk full")
+	var buf bytes.Buffer
+	multi := slogger.NewMultiHandler(failingHandler{err: errDisk}, slog.NewTextHandler(&buf, nil))
+
+	r := slog.NewRecord(slogger.Now(), slog.LevelInfo, "still delivered", 0)
+	err := multi.Handle(context.Background(), r)
+
+	if !errors.Is(err, errDisk) {
+		t.Errorf("expected the child error to be returned, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "still delivered") {
+		t.Errorf("expected the healthy child to receive the record, got: %s", buf.String())
+	}
+}
+
+// TestWithAdditionalHandler tests the constructor option with redaction
+func TestWithAdditionalHandler(t *testing.T) {
+	var primary, extra bytes.Buffer
+	logger := slogger.New(slogger.WithWriter(&primary),
+		slogger.WithAdditionalHandler(slog.NewJSONHandler(&extra, &slog.HandlerOptions{Level: slog.LevelError})),
+		slogger.WithRedactedKeys("token"))
+
+	logger.Info("info only", "token", "abc")
+	logger.Error("both", "token", "abc")
+
+	if !strings.Contains(primary.String(), "info only") || !strings.Contains(primary.String(), "both") {
+		t.Errorf("expected both lines in the primary output, got: %s", primary.String())
+	}
+	record := decodeJSONRecord(t, &extra)
+	if record["msg"] != "both" || record["token"] != slogger.Redacted {
+		t.Errorf("expected only the redacted error line in the extra handler, got: %s", extra.String())
+	}
+	if strings.Contains(primary.String()+extra.String(), "abc") {
+		t.Error("expected the token to be redacted in every destination")
+	}
+}
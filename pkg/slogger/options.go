@@ -0,0 +1,182 @@
+package slogger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+// Option configures a Logger created by New.
+type Option func(*config)
+
+// config holds the settings collected from Option values.
+type config struct {
+	level   slog.Level
+	json    bool
+	writer  io.Writer
+	source  bool
+	grouped bool
+	redact  []string
+	extra   []slog.Handler
+	sample  *sampling
+	split   bool
+}
+
+// sampling holds the arguments given to WithSampling.
+type sampling struct {
+	initial, thereafter int
+	tick                time.Duration
+	opts                []SamplingOption
+}
+
+// WithLevel sets the minimum level that is logged. The default is info.
+func WithLevel(level slog.Level) Option {
+	return func(c *config) {
+		c.level = level
+	}
+}
+
+// WithLevelFromEnv sets the minimum level from the environment variable key
+// when it is set, using LevelFromEnv. An unset variable keeps the level
+// configured by earlier options.
+func WithLevelFromEnv(key string) Option {
+	return func(c *config) {
+		if _, ok := os.LookupEnv(key); ok {
+			c.level = LevelFromEnv(key)
+		}
+	}
+}
+
+// LevelFromEnv parses the environment variable key as a level name
+// (debug, info, warn or error, case-insensitive). It returns info when
+// the variable is unset or empty, and logs a warning through the default
+// slog logger before falling back to info for unrecognized values.
+func LevelFromEnv(key string) slog.Level {
+	value := strings.TrimSpace(os.Getenv(key))
+	switch strings.ToLower(value) {
+	case "debug":
+		return slog.LevelDebug
+	case "info", "":
+		return slog.LevelInfo
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	}
+	slog.Default().Log(context.Background(), slog.LevelWarn, "unrecognized log level, using info",
+		"key", key, "value", value)
+	return slog.LevelInfo
+}
+
+// WithJSON encodes records as JSON instead of key=value text.
+func WithJSON() Option {
+	return func(c *config) {
+		c.json = true
+	}
+}
+
+// WithWriter sends output to w instead of stderr, or instead of stdout
+// with WithSplitStreams.
+func WithWriter(w io.Writer) Option {
+	return func(c *config) {
+		c.writer = w
+	}
+}
+
+// WithSource adds the source file, line and function of the logging call
+// to records. The reported location is the code calling slogger, including
+// for formatted variants and ResponseLogger methods.
+func WithSource(enabled bool) Option {
+	return func(c *config) {
+		c.source = enabled
+	}
+}
+
+// WithGroupedFields makes ResponseLogger nest its code, method, uri,
+// duration_ms and bytes_written attributes under an "http" group.
+func WithGroupedFields() Option {
+	return func(c *config) {
+		c.grouped = true
+	}
+}
+
+// WithRedactedKeys masks attributes with any of the given keys, wrapping
+// the handler with RedactingHandler. It can be given more than once.
+func WithRedactedKeys(keys ...string) Option {
+	return func(c *config) {
+		c.redact = append(c.redact, keys...)
+	}
+}
+
+// WithAdditionalHandler also sends records to h, alongside the writer
+// configured with WithWriter. h applies its own level filtering; the
+// Logger's level only governs the primary output. Redaction configured
+// with WithRedactedKeys covers every destination.
+func WithAdditionalHandler(h slog.Handler) Option {
+	return func(c *config) {
+		c.extra = append(c.extra, h)
+	}
+}
+
+// WithSampling limits repeated Debug and Info messages with
+// SamplingHandler, across every destination.
+func WithSampling(initial, thereafter int, tick time.Duration, opts ...SamplingOption) Option {
+	return func(c *config) {
+		c.sample = &sampling{initial: initial, thereafter: thereafter, tick: tick, opts: opts}
+	}
+}
+
+// WithSplitStreams sends error records to stderr and everything else to
+// stdout, or to the writer given with WithWriter, using NewSplit.
+func WithSplitStreams() Option {
+	return func(c *config) {
+		c.split = true
+	}
+}
+
+// New creates a Logger with its own handler, leaving the global slog
+// default untouched so independent components can be configured separately.
+func New(opts ...Option) Logger {
+	cfg := config{
+		level: slog.LevelInfo,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.writer == nil {
+		cfg.writer = os.Stderr
+		if cfg.split {
+			cfg.writer = os.Stdout
+		}
+	}
+
+	level := new(slog.LevelVar)
+	level.Set(cfg.level)
+	handlerOpts := &slog.HandlerOptions{
+		Level:     level,
+		AddSource: cfg.source,
+	}
+	newHandler := func(w io.Writer) slog.Handler {
+		if cfg.json {
+			return slog.NewJSONHandler(w, handlerOpts)
+		}
+		return slog.NewTextHandler(w, handlerOpts)
+	}
+	handler := newHandler(cfg.writer)
+	if cfg.split {
+		handler = NewSplit(handler, newHandler(os.Stderr), slog.LevelError)
+	}
+	if len(cfg.extra) > 0 {
+		handler = NewMultiHandler(append([]slog.Handler{handler}, cfg.extra...)...)
+	}
+	if cfg.sample != nil {
+		handler = SamplingHandler(handler, cfg.sample.initial, cfg.sample.thereafter, cfg.sample.tick, cfg.sample.opts...)
+	}
+	if len(cfg.redact) > 0 {
+		handler = RedactingHandler(handler, cfg.redact...)
+	}
+	return Logger{logger: slog.New(handler), level: level, groupHTTP: cfg.grouped}
+}
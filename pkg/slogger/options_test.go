@@ -0,0 +1,220 @@
+package slogger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"go-examples/pkg/slogger"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// TestNewText tests the default text encoding into a buffer
+func TestNewText(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slogger.New(slogger.WithWriter(&buf))
+
+	logger.Info("text message", "count", 3)
+
+	output := buf.String()
+	if !strings.Contains(output, "level=INFO") || !strings.Contains(output, `msg="text message"`) || !strings.Contains(output, "count=3") {
+		t.Errorf("unexpected text output: %s", output)
+	}
+}
+
+// TestNewJSON tests JSON encoding into a buffer
+func TestNewJSON(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slogger.New(slogger.WithWriter(&buf), slogger.WithJSON())
+
+	logger.Warn("json message", "count", 3)
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("output is not JSON: %v (%s)", err, buf.String())
+	}
+	if record["level"] != "WARN" || record["msg"] != "json message" || record["count"] != float64(3) {
+		t.Errorf("unexpected JSON record: %v", record)
+	}
+}
+
+// TestNewLevelFiltering tests that WithLevel filters lower levels
+func TestNewLevelFiltering(t *testing.T) {
+	tests := []struct {
+		name     string
+		level    slog.Level
+		expected []string
+		filtered []string
+	}{
+		{
+			name:     "default info",
+			level:    slog.LevelInfo,
+			expected: []string{"info line", "warn line", "error line"},
+			filtered: []string{"debug line"},
+		},
+		{
+			name:     "debug",
+			level:    slog.LevelDebug,
+			expected: []string{"debug line", "info line", "warn line", "error line"},
+		},
+		{
+			name:     "error",
+			level:    slog.LevelError,
+			expected: []string{"error line"},
+			filtered: []string{"debug line", "info line", "warn line"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slogger.New(slogger.WithWriter(&buf), slogger.WithLevel(tc.level))
+
+			logger.Debug("debug line")
+			logger.Info("info line")
+			logger.Warn("warn line")
+			logger.Error("error line")
+
+			output := buf.String()
+			for _, want := range tc.expected {
+				if !strings.Contains(output, want) {
+					t.Errorf("expected output to contain %q, got: %s", want, output)
+				}
+			}
+			for _, unwanted := range tc.filtered {
+				if strings.Contains(output, unwanted) {
+					t.Errorf("expected output not to contain %q, got: %s", unwanted, output)
+				}
+			}
+		})
+	}
+}
+
+// TestNewSource tests that WithSource adds source information
+func TestNewSource(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slogger.New(slogger.WithWriter(&buf), slogger.WithSource(true))
+
+	logger.Info("with source")
+
+	if !strings.Contains(buf.String(), "source=") {
+		t.Errorf("expected source attribute, got: %s", buf.String())
+	}
+}
+
+// TestNewLeavesDefaultUntouched tests that New does not modify the global default
+func TestNewLeavesDefaultUntouched(t *testing.T) {
+	var defaultBuf bytes.Buffer
+	origLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&defaultBuf, &slog.HandlerOptions{Level: slog.LevelWarn})))
+	defer slog.SetDefault(origLogger)
+	installed := slog.Default()
+
+	var buf bytes.Buffer
+	logger := slogger.New(slogger.WithWriter(&buf), slogger.WithLevel(slog.LevelDebug))
+	logger.Debug("private debug")
+
+	if slog.Default() != installed {
+		t.Error("New replaced the global default logger")
+	}
+	if slog.Default().Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("New changed the global default level")
+	}
+	if defaultBuf.Len() > 0 {
+		t.Errorf("expected nothing written to the default handler, got: %s", defaultBuf.String())
+	}
+	if !strings.Contains(buf.String(), "private debug") {
+		t.Errorf("expected private logger output, got: %s", buf.String())
+	}
+}
+
+// TestLevelFromEnv tests parsing of each supported value
+func TestLevelFromEnv(t *testing.T) {
+	tests := []struct {
+		value string
+		want  slog.Level
+	}{
+		{value: "debug", want: slog.LevelDebug},
+		{value: "DEBUG", want: slog.LevelDebug},
+		{value: "info", want: slog.LevelInfo},
+		{value: "Warn", want: slog.LevelWarn},
+		{value: "warning", want: slog.LevelWarn},
+		{value: "error", want: slog.LevelError},
+		{value: " error ", want: slog.LevelError},
+		{value: "", want: slog.LevelInfo},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.value, func(t *testing.T) {
+			t.Setenv("TEST_LOG_LEVEL", tc.value)
+			if got := slogger.LevelFromEnv("TEST_LOG_LEVEL"); got != tc.want {
+				t.Errorf("LevelFromEnv() = %v; want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestLevelFromEnvInvalid tests the fallback and warning for unrecognized values
+func TestLevelFromEnvInvalid(t *testing.T) {
+	var buf bytes.Buffer
+	origLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	defer slog.SetDefault(origLogger)
+
+	t.Setenv("TEST_LOG_LEVEL", "verbose")
+	if got := slogger.LevelFromEnv("TEST_LOG_LEVEL"); got != slog.LevelInfo {
+		t.Errorf("LevelFromEnv() = %v; want info", got)
+	}
+	output := buf.String()
+	if !strings.Contains(output, "level=WARN") || !strings.Contains(output, "value=verbose") {
+		t.Errorf("expected a warning naming the invalid value, got: %s", output)
+	}
+}
+
+// TestWithLevelFromEnv tests the constructor option
+func TestWithLevelFromEnv(t *testing.T) {
+	t.Setenv("TEST_LOG_LEVEL", "error")
+	var buf bytes.Buffer
+	logger := slogger.New(slogger.WithWriter(&buf), slogger.WithLevelFromEnv("TEST_LOG_LEVEL"))
+	logger.Warn("filtered")
+	logger.Error("kept")
+	if strings.Contains(buf.String(), "filtered") || !strings.Contains(buf.String(), "kept") {
+		t.Errorf("expected only error output, got: %s", buf.String())
+	}
+
+	// An unset variable keeps the level from earlier options
+	buf.Reset()
+	logger = slogger.New(slogger.WithWriter(&buf), slogger.WithLevel(slog.LevelDebug), slogger.WithLevelFromEnv("TEST_LOG_LEVEL_UNSET"))
+	if got := logger.Level(); got != slog.LevelDebug {
+		t.Errorf("Level() = %v; want debug", got)
+	}
+}
+
+// TestSetLevel tests flipping the level at runtime
+func TestSetLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slogger.New(slogger.WithWriter(&buf))
+	child := logger.With("component", "calc")
+
+	child.Debug("before")
+	if buf.Len() > 0 {
+		t.Fatalf("expected debug to be filtered initially, got: %s", buf.String())
+	}
+
+	logger.SetLevel(slog.LevelDebug)
+	child.Debug("after lowering")
+	if !strings.Contains(buf.String(), "after lowering") {
+		t.Errorf("expected debug after SetLevel(Debug), got: %s", buf.String())
+	}
+	if got := child.Level(); got != slog.LevelDebug {
+		t.Errorf("child Level() = %v; want debug", got)
+	}
+
+	buf.Reset()
+	logger.SetLevel(slog.LevelError)
+	child.Warn("after raising")
+	if buf.Len() > 0 {
+		t.Errorf("expected warn to be filtered after SetLevel(Error), got: %s", buf.String())
+	}
+}
@@ -0,0 +1,108 @@
+package slogger
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+)
+
+// RecordingWriter wraps an http.ResponseWriter and records the status code
+// and number of body bytes written through it. It implements http.Flusher,
+// http.Hijacker and io.ReaderFrom by delegating to the wrapped writer, so
+// streaming responses and connection upgrades keep working.
+type RecordingWriter struct {
+	http.ResponseWriter
+	status       int
+	bytesWritten int64
+}
+
+// WrapResponseWriter returns a RecordingWriter around w.
+func WrapResponseWriter(w http.ResponseWriter) *RecordingWriter {
+	return &RecordingWriter{ResponseWriter: w}
+}
+
+// WriteHeader records the status code and passes it on. Only the first
+// final status is sent; later calls are ignored, as net/http would.
+// Informational 1xx codes other than 101 are passed through unrecorded.
+func (w *RecordingWriter) WriteHeader(code int) {
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.status != 0 {
+		return
+	}
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write counts the bytes written to the underlying writer. Writing before
+// WriteHeader implies a 200 status.
+func (w *RecordingWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytesWritten += int64(n)
+	return n, err
+}
+
+// ReadFrom copies from r, using the underlying writer's io.ReaderFrom
+// when available, and counts the bytes copied.
+func (w *RecordingWriter) ReadFrom(r io.Reader) (int64, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err := rf.ReadFrom(r)
+		w.bytesWritten += n
+		return n, err
+	}
+	// Hide ReadFrom from io.Copy to avoid recursing into this method
+	return io.Copy(struct{ io.Writer }{w}, r)
+}
+
+// Flush sends buffered data to the client when the underlying writer
+// supports http.Flusher.
+func (w *RecordingWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Hijack lets the caller take over the connection when the underlying
+// writer supports http.Hijacker.
+func (w *RecordingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("slogger: %T does not support hijacking: %w", w.ResponseWriter, http.ErrNotSupported)
+	}
+	if w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return h.Hijack()
+}
+
+// Unwrap returns the wrapped writer for use by http.ResponseController.
+func (w *RecordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Status returns the recorded status code, or 200 if the handler has not
+// written a header or body yet, matching what net/http sends by default.
+func (w *RecordingWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// BytesWritten returns the number of body bytes written so far.
+func (w *RecordingWriter) BytesWritten() int64 {
+	return w.bytesWritten
+}
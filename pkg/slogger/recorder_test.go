@@ -0,0 +1,197 @@
+package slogger_test
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"go-examples/pkg/slogger"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestRecordingWriterImplicitOK tests that a handler that never calls
+// WriteHeader is reported as 200
+func TestRecordingWriterImplicitOK(t *testing.T) {
+	rw := slogger.WrapResponseWriter(httptest.NewRecorder())
+	if got := rw.Status(); got != http.StatusOK {
+		t.Errorf("expected 200 before anything is written, got %d", got)
+	}
+
+	if _, err := rw.Write([]byte("hello")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if got := rw.Status(); got != http.StatusOK {
+		t.Errorf("expected implicit 200 after Write, got %d", got)
+	}
+}
+
+// TestRecordingWriterExplicitStatus tests that explicit codes are recorded
+// and that only the first WriteHeader takes effect
+func TestRecordingWriterExplicitStatus(t *testing.T) {
+	testCases := []struct {
+		name  string
+		codes []int
+		want  int
+	}{
+		{"Created", []int{http.StatusCreated}, http.StatusCreated},
+		{"Not found", []int{http.StatusNotFound}, http.StatusNotFound},
+		{"Double WriteHeader", []int{http.StatusBadRequest, http.StatusInternalServerError}, http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			rw := slogger.WrapResponseWriter(rec)
+			for _, code := range tc.codes {
+				rw.WriteHeader(code)
+			}
+			if got := rw.Status(); got != tc.want {
+				t.Errorf("expected recorded status %d, got %d", tc.want, got)
+			}
+			if rec.Code != tc.want {
+				t.Errorf("expected %d sent to the client, got %d", tc.want, rec.Code)
+			}
+		})
+	}
+}
+
+// TestRecordingWriterInformational tests that 1xx responses do not count
+// as the final status
+func TestRecordingWriterInformational(t *testing.T) {
+	rw := slogger.WrapResponseWriter(httptest.NewRecorder())
+	rw.WriteHeader(http.StatusEarlyHints)
+	rw.WriteHeader(http.StatusAccepted)
+	if got := rw.Status(); got != http.StatusAccepted {
+		t.Errorf("expected 202 after an early hint, got %d", got)
+	}
+}
+
+// TestRecordingWriterWriteAfterHeader tests that a later Write does not
+// replace an explicit status
+func TestRecordingWriterWriteAfterHeader(t *testing.T) {
+	rw := slogger.WrapResponseWriter(httptest.NewRecorder())
+	rw.WriteHeader(http.StatusTeapot)
+	rw.Write([]byte("short and stout"))
+	if got := rw.Status(); got != http.StatusTeapot {
+		t.Errorf("expected 418, got %d", got)
+	}
+}
+
+// TestRecordingWriterCountsBytes tests byte counting across multiple writes
+func TestRecordingWriterCountsBytes(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rw := slogger.WrapResponseWriter(rec)
+	for _, chunk := range []string{"one", "two ", "three"} {
+		if _, err := io.WriteString(rw, chunk); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if n, err := io.Copy(rw, strings.NewReader("four")); err != nil || n != 4 {
+		t.Fatalf("io.Copy returned %d, %v", n, err)
+	}
+
+	if got := rw.BytesWritten(); got != 16 {
+		t.Errorf("expected 16 bytes written, got %d", got)
+	}
+	if got := rec.Body.String(); got != "onetwo threefour" {
+		t.Errorf("unexpected body %q", got)
+	}
+}
+
+// fullWriter is a ResponseWriter that also implements Hijacker and ReaderFrom
+type fullWriter struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+	readFrom bool
+}
+
+func (w *fullWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.hijacked = true
+	return nil, nil, nil
+}
+
+func (w *fullWriter) ReadFrom(r io.Reader) (int64, error) {
+	w.readFrom = true
+	return w.Body.ReadFrom(r)
+}
+
+// TestRecordingWriterPassthrough tests that optional interfaces reach the
+// underlying writer
+func TestRecordingWriterPassthrough(t *testing.T) {
+	inner := &fullWriter{ResponseRecorder: httptest.NewRecorder()}
+	rw := slogger.WrapResponseWriter(inner)
+
+	var w http.ResponseWriter = rw
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		t.Fatal("expected RecordingWriter to implement http.Flusher")
+	}
+	flusher.Flush()
+	if !inner.Flushed {
+		t.Error("expected Flush to reach the underlying writer")
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		t.Fatal("expected RecordingWriter to implement http.Hijacker")
+	}
+	if _, _, err := hijacker.Hijack(); err != nil {
+		t.Errorf("Hijack failed: %v", err)
+	}
+	if !inner.hijacked {
+		t.Error("expected Hijack to reach the underlying writer")
+	}
+
+	readerFrom, ok := w.(io.ReaderFrom)
+	if !ok {
+		t.Fatal("expected RecordingWriter to implement io.ReaderFrom")
+	}
+	n, err := readerFrom.ReadFrom(bytes.NewReader([]byte("streamed")))
+	if err != nil || n != 8 {
+		t.Fatalf("ReadFrom returned %d, %v", n, err)
+	}
+	if !inner.readFrom {
+		t.Error("expected ReadFrom to reach the underlying writer")
+	}
+	if got := rw.BytesWritten(); got != 8 {
+		t.Errorf("expected 8 bytes counted, got %d", got)
+	}
+
+	if got := http.NewResponseController(w).Flush(); got != nil {
+		t.Errorf("expected ResponseController to unwrap to the underlying writer, got %v", got)
+	}
+}
+
+// TestRecordingWriterHijackUnsupported tests that hijacking fails cleanly
+// when the underlying writer cannot be hijacked
+func TestRecordingWriterHijackUnsupported(t *testing.T) {
+	rw := slogger.WrapResponseWriter(httptest.NewRecorder())
+	_, _, err := rw.Hijack()
+	if !errors.Is(err, http.ErrNotSupported) {
+		t.Errorf("expected http.ErrNotSupported, got %v", err)
+	}
+}
+
+// TestMiddlewareLogsFinalStatus tests that the middleware logs the status
+// and size written by a handler that never touches the ResponseLogger
+func TestMiddlewareLogsFinalStatus(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slogger.New(slogger.WithWriter(&buf))
+
+	handler := slogger.Middleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("missing"))
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/nowhere", nil))
+
+	out := buf.String()
+	for _, want := range []string{"msg=access", "status=404", "bytes=7", "path=/nowhere"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in log output, got: %s", want, out)
+		}
+	}
+}
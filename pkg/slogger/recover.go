@@ -0,0 +1,87 @@
+package slogger
+
+import (
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+)
+
+// RecoverOption configures Recover and RecoveryMiddleware.
+type RecoverOption func(*recoverConfig)
+
+// recoverConfig holds the settings collected from RecoverOption values.
+type recoverConfig struct {
+	repanic bool
+	w       http.ResponseWriter // set by RecoveryMiddleware to send a 500
+}
+
+// WithRepanic re-raises the recovered panic after it has been logged, so
+// outer middleware still sees it.
+func WithRepanic() RecoverOption {
+	return func(c *recoverConfig) {
+		c.repanic = true
+	}
+}
+
+// Recover recovers a panic in an HTTP handler and logs it at error level
+// with the panic value, stack trace, method, path and, when Middleware
+// has run, the request ID. RecoveryMiddleware placed outside Middleware
+// takes the ID from the X-Request-ID response header instead. It must be
+// deferred directly:
+//
+//	defer slogger.Recover(log, r)
+func Recover(l Logger, r *http.Request, opts ...RecoverOption) {
+	p := recover()
+	if p == nil {
+		return
+	}
+	cfg := recoverConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	args := []any{"panic", p, "method", r.Method, "path", r.URL.Path, "stack", string(debug.Stack())}
+	if rl, ok := ResponseLoggerFrom(r.Context()); ok {
+		args = append(args, "request_id", rl.RequestID())
+	} else if cfg.w != nil {
+		if id := cfg.w.Header().Get(RequestIDHeader); id != "" {
+			args = append(args, "request_id", id)
+		}
+	}
+	l.log(r.Context(), 0, slog.LevelError, "panic recovered", args...)
+
+	if cfg.w != nil {
+		writePanicResponse(cfg.w)
+	}
+	if cfg.repanic {
+		panic(p)
+	}
+}
+
+// RecoveryMiddleware returns HTTP middleware that recovers panics with
+// Recover and answers with a JSON 500 response, unless the handler had
+// already started its response.
+func RecoveryMiddleware(l Logger, opts ...RecoverOption) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw, ok := w.(*RecordingWriter)
+			if !ok {
+				rw = WrapResponseWriter(w)
+			}
+			// The full slice expression keeps concurrent requests from
+			// appending into the shared opts array
+			defer Recover(l, r, append(opts[:len(opts):len(opts)], func(c *recoverConfig) { c.w = rw })...)
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// writePanicResponse sends the 500 response if nothing was written yet
+func writePanicResponse(w http.ResponseWriter) {
+	if rw, ok := w.(*RecordingWriter); ok && rw.status != 0 {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusInternalServerError)
+	w.Write([]byte(`{"success":false,"error":"internal server error"}` + "\n"))
+}
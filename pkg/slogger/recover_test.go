@@ -0,0 +1,123 @@
+package slogger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-examples/pkg/slogger"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// panicHandler panics with a fixed value
+func panicHandler(_ http.ResponseWriter, _ *http.Request) {
+	panic("division table corrupted")
+}
+
+// TestRecoveryMiddleware tests the log entry and 500 response for a panic
+func TestRecoveryMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slogger.New(slogger.WithWriter(&buf), slogger.WithJSON())
+	handler := slogger.Middleware(logger, slogger.WithSkipPaths("/calculate"))(
+		slogger.RecoveryMiddleware(logger)(http.HandlerFunc(panicHandler)))
+
+	req := httptest.NewRequest("POST", "/calculate", nil)
+	req.Header.Set(slogger.RequestIDHeader, "req-7")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected JSON content type, got %q", ct)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body["success"] != false {
+		t.Errorf("expected a JSON error body, got %q (%v)", rec.Body.String(), err)
+	}
+
+	record := decodeJSONRecord(t, &buf)
+	want := map[string]any{
+		"level":      "ERROR",
+		"msg":        "panic recovered",
+		"panic":      "division table corrupted",
+		"method":     "POST",
+		"path":       "/calculate",
+		"request_id": "req-7",
+	}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("expected %s=%v, got %v", key, value, record[key])
+		}
+	}
+	if stack, _ := record["stack"].(string); !strings.Contains(stack, "panicHandler") {
+		t.Errorf("expected a stack trace through the handler, got %q", stack)
+	}
+}
+
+// TestRecoveryMiddlewareOutsideMiddleware tests that the request ID is
+// still logged when recovery wraps the request ID middleware
+func TestRecoveryMiddlewareOutsideMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slogger.New(slogger.WithWriter(&buf), slogger.WithJSON())
+	handler := slogger.RecoveryMiddleware(logger)(
+		slogger.Middleware(logger, slogger.WithSkipPaths("/calculate"))(http.HandlerFunc(panicHandler)))
+
+	req := httptest.NewRequest("POST", "/calculate", nil)
+	req.Header.Set(slogger.RequestIDHeader, "req-8")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if record := decodeJSONRecord(t, &buf); record["request_id"] != "req-8" {
+		t.Errorf("expected request_id=req-8, got %v", record["request_id"])
+	}
+}
+
+// TestRecoveryMiddlewareKeepsStartedResponse tests that a response already
+// under way is not overwritten
+func TestRecoveryMiddlewareKeepsStartedResponse(t *testing.T) {
+	logger := slogger.New(slogger.WithWriter(&bytes.Buffer{}))
+	handler := slogger.RecoveryMiddleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		panic("late failure")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusAccepted || rec.Body.Len() != 0 {
+		t.Errorf("expected the started 202 response untouched, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+// TestRecoverRepanic tests that WithRepanic propagates the original panic
+func TestRecoverRepanic(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slogger.New(slogger.WithWriter(&buf))
+	req := httptest.NewRequest("GET", "/calculate", nil)
+
+	defer func() {
+		if p := recover(); p != "division table corrupted" {
+			t.Errorf("expected the original panic to propagate, got %v", p)
+		}
+		if !strings.Contains(buf.String(), "panic recovered") {
+			t.Errorf("expected the panic to be logged before propagating, got: %s", buf.String())
+		}
+	}()
+	func() {
+		defer slogger.Recover(logger, req, slogger.WithRepanic())
+		panicHandler(nil, req)
+	}()
+	t.Error("expected the panic to propagate")
+}
+
+// TestRecoverNoPanic tests that Recover does nothing without a panic
+func TestRecoverNoPanic(t *testing.T) {
+	var buf bytes.Buffer
+	func() {
+		defer slogger.Recover(slogger.New(slogger.WithWriter(&buf)), httptest.NewRequest("GET", "/", nil))
+	}()
+	if buf.Len() != 0 {
+		t.Errorf("expected no output, got: %s", buf.String())
+	}
+}
@@ -0,0 +1,111 @@
+package slogger
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+// Redacted replaces secret values in log output.
+const Redacted = "[REDACTED]"
+
+// SecretOption configures a value created by Secret.
+type SecretOption func(*secret)
+
+// KeepLast4 keeps the last four characters of the secret visible after
+// the Redacted marker, which helps tell keys apart. Secrets shorter than
+// eight characters are fully masked so that most of the value stays hidden.
+func KeepLast4() SecretOption {
+	return func(s *secret) {
+		s.keep = 4
+	}
+}
+
+// Secret wraps a sensitive value so that it logs as Redacted, whatever
+// key it is logged under and whichever handler formats it.
+func Secret(v string, opts ...SecretOption) slog.LogValuer {
+	s := secret{value: v}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s
+}
+
+// secret is a string that masks itself when logged or printed
+type secret struct {
+	value string
+	keep  int
+}
+
+// LogValue implements slog.LogValuer
+func (s secret) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
+
+// String masks the value for fmt and %v as well
+func (s secret) String() string {
+	if s.keep > 0 && len(s.value) >= 2*s.keep {
+		return Redacted + s.value[len(s.value)-s.keep:]
+	}
+	return Redacted
+}
+
+// RedactingHandler wraps next so that attributes whose key matches one of
+// keys, compared case-insensitively, are logged as Redacted. Attributes
+// inside groups and attributes added with With are matched too; a
+// matching group is masked as a whole.
+func RedactingHandler(next slog.Handler, keys ...string) slog.Handler {
+	set := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		set[strings.ToLower(k)] = true
+	}
+	return &redactingHandler{next: next, keys: set}
+}
+
+// redactingHandler masks configured keys before passing records on
+type redactingHandler struct {
+	next slog.Handler
+	keys map[string]bool
+}
+
+func (h *redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactingHandler) Handle(ctx context.Context, r slog.Record) error {
+	masked := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		masked.AddAttrs(h.redact(a))
+		return true
+	})
+	return h.next.Handle(ctx, masked)
+}
+
+func (h *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	masked := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		masked[i] = h.redact(a)
+	}
+	return &redactingHandler{next: h.next.WithAttrs(masked), keys: h.keys}
+}
+
+func (h *redactingHandler) WithGroup(name string) slog.Handler {
+	return &redactingHandler{next: h.next.WithGroup(name), keys: h.keys}
+}
+
+// redact masks a if its key matches, descending into group values
+func (h *redactingHandler) redact(a slog.Attr) slog.Attr {
+	if h.keys[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, Redacted)
+	}
+	value := a.Value.Resolve()
+	if value.Kind() != slog.KindGroup {
+		return a
+	}
+	members := value.Group()
+	masked := make([]slog.Attr, len(members))
+	for i, m := range members {
+		masked[i] = h.redact(m)
+	}
+	return slog.Attr{Key: a.Key, Value: slog.GroupValue(masked...)}
+}
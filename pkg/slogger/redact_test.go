@@ -0,0 +1,106 @@
+package slogger_test
+
+import (
+	"bytes"
+	"fmt"
+	"go-examples/pkg/slogger"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// TestSecret tests that Secret values are masked by text and JSON handlers
+func TestSecret(t *testing.T) {
+	testCases := []struct {
+		name   string
+		secret slog.LogValuer
+		want   string
+	}{
+		{"Fully masked", slogger.Secret("sk-live-123456789"), "[REDACTED]"},
+		{"Keep last 4", slogger.Secret("sk-live-123456789", slogger.KeepLast4()), "[REDACTED]6789"},
+		{"Short secret fully masked", slogger.Secret("abc123", slogger.KeepLast4()), "[REDACTED]"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var text, js bytes.Buffer
+			slogger.New(slogger.WithWriter(&text)).Info("call", "api_key", tc.secret)
+			slogger.New(slogger.WithWriter(&js), slogger.WithJSON()).Info("call", "api_key", tc.secret)
+
+			if !strings.Contains(text.String(), "api_key="+tc.want) {
+				t.Errorf("expected api_key=%s in text output, got: %s", tc.want, text.String())
+			}
+			if got := decodeJSONRecord(t, &js)["api_key"]; got != tc.want {
+				t.Errorf("expected %q in JSON output, got %v", tc.want, got)
+			}
+			if strings.Contains(text.String()+js.String(), "sk-live-1234") {
+				t.Error("secret leaked into output")
+			}
+			if got := fmt.Sprint(tc.secret); got != tc.want {
+				t.Errorf("expected fmt to print %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+// TestRedactingHandlerText tests key-based masking with a text handler
+func TestRedactingHandlerText(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slogger.RedactingHandler(slog.NewTextHandler(&buf, nil), "password", "Authorization")
+	log := slog.New(handler).With("authorization", "Bearer abc")
+
+	log.Info("login", "user", "alice", "PASSWORD", "hunter2",
+		slog.Group("request", "body", "x=1", "password", "hunter2"))
+
+	out := buf.String()
+	for _, want := range []string{"authorization=[REDACTED]", "PASSWORD=[REDACTED]", "request.password=[REDACTED]", "user=alice", `request.body="x=1"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got: %s", want, out)
+		}
+	}
+	if strings.Contains(out, "hunter2") || strings.Contains(out, "Bearer") {
+		t.Errorf("secret leaked into output: %s", out)
+	}
+}
+
+// TestRedactingHandlerJSON tests masking inside groups and matching group keys
+func TestRedactingHandlerJSON(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slogger.RedactingHandler(slog.NewJSONHandler(&buf, nil), "token", "credentials")
+	log := slog.New(handler).WithGroup("auth")
+
+	log.Info("refresh", "token", "t0k3n", "expires", 3600,
+		slog.Group("credentials", "user", "alice", "secret", "s3cr3t"))
+
+	record := decodeJSONRecord(t, &buf)
+	auth, ok := record["auth"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected an auth group, got: %s", buf.String())
+	}
+	if auth["token"] != slogger.Redacted {
+		t.Errorf("expected token inside a WithGroup group to be masked, got %v", auth["token"])
+	}
+	if auth["credentials"] != slogger.Redacted {
+		t.Errorf("expected a matching group to be masked as a whole, got %v", auth["credentials"])
+	}
+	if auth["expires"] != float64(3600) {
+		t.Errorf("expected non-matching attrs untouched, got %v", auth["expires"])
+	}
+}
+
+// TestWithRedactedKeys tests the constructor option
+func TestWithRedactedKeys(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slogger.New(slogger.WithWriter(&buf), slogger.WithJSON(),
+		slogger.WithRedactedKeys("api_key"), slogger.WithRedactedKeys("body"))
+
+	logger.With("api_key", "k-123").Info("request", "body", `{"a":1}`, "op", "add")
+
+	record := decodeJSONRecord(t, &buf)
+	if record["api_key"] != slogger.Redacted || record["body"] != slogger.Redacted {
+		t.Errorf("expected api_key and body masked, got %v", record)
+	}
+	if record["op"] != "add" {
+		t.Errorf("expected op untouched, got %v", record["op"])
+	}
+}
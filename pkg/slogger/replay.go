@@ -0,0 +1,152 @@
+package slogger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ReplayHandler buffers records until Redirect names their destination,
+// so lines logged during startup, before the log configuration is known,
+// still reach the configured sink.
+type ReplayHandler struct {
+	state *replayState
+	steps []replayStep // attrs and groups added to this handler, in order
+
+	once    sync.Once
+	derived slog.Handler // target with steps applied, set after Redirect
+}
+
+// replayState is the buffer shared by a ReplayHandler and its children
+type replayState struct {
+	mu       sync.Mutex
+	capacity int
+	records  []replayRecord
+	dropped  int
+	firstAt  time.Time // time of the first dropped record
+	target   slog.Handler
+}
+
+// replayRecord is a buffered record with the handler that logged it
+type replayRecord struct {
+	ctx     context.Context
+	handler *ReplayHandler
+	record  slog.Record
+}
+
+// replayStep is one WithAttrs or WithGroup call to repeat on the target
+type replayStep struct {
+	attrs []slog.Attr
+	group string
+}
+
+// NewReplayHandler returns a handler that keeps up to capacity records in
+// memory. When more arrive, the oldest are dropped and a warning with the
+// dropped count is replayed ahead of the rest.
+func NewReplayHandler(capacity int) *ReplayHandler {
+	return &ReplayHandler{state: &replayState{capacity: capacity}}
+}
+
+// Redirect replays the buffered records to target in logging order,
+// skipping those target does not enable, and sends later records straight
+// to it. Only the first call has an effect.
+func (h *ReplayHandler) Redirect(target slog.Handler) {
+	s := h.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.target != nil {
+		return
+	}
+	s.target = target
+
+	ctx := context.Background()
+	if s.dropped > 0 && target.Enabled(ctx, slog.LevelWarn) {
+		marker := slog.NewRecord(s.firstAt, slog.LevelWarn, "dropped early log records", 0)
+		marker.AddAttrs(slog.Int("dropped", s.dropped))
+		_ = target.Handle(ctx, marker)
+	}
+	for _, rec := range s.records {
+		next := rec.handler.resolve(target)
+		if next.Enabled(rec.ctx, rec.record.Level) {
+			_ = next.Handle(rec.ctx, rec.record)
+		}
+	}
+	s.records = nil
+}
+
+// resolve returns target with this handler's attrs and groups applied
+func (h *ReplayHandler) resolve(target slog.Handler) slog.Handler {
+	h.once.Do(func() {
+		derived := target
+		for _, step := range h.steps {
+			if step.group != "" {
+				derived = derived.WithGroup(step.group)
+			} else {
+				derived = derived.WithAttrs(step.attrs)
+			}
+		}
+		h.derived = derived
+	})
+	return h.derived
+}
+
+// Enabled reports true for every level until Redirect, since the
+// target's level is not known yet, and defers to the target after.
+func (h *ReplayHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	h.state.mu.Lock()
+	target := h.state.target
+	h.state.mu.Unlock()
+	if target == nil {
+		return true
+	}
+	return h.resolve(target).Enabled(ctx, level)
+}
+
+func (h *ReplayHandler) Handle(ctx context.Context, r slog.Record) error {
+	s := h.state
+	s.mu.Lock()
+	if s.target != nil {
+		target := s.target
+		s.mu.Unlock()
+		return h.resolve(target).Handle(ctx, r)
+	}
+	defer s.mu.Unlock()
+
+	if s.capacity <= 0 {
+		s.drop(r.Time)
+		return nil
+	}
+	if len(s.records) == s.capacity {
+		s.drop(s.records[0].record.Time)
+		s.records = s.records[1:]
+	}
+	s.records = append(s.records, replayRecord{ctx: context.WithoutCancel(ctx), handler: h, record: r.Clone()})
+	return nil
+}
+
+// drop counts a discarded record logged at t
+func (s *replayState) drop(t time.Time) {
+	if s.dropped == 0 {
+		s.firstAt = t
+	}
+	s.dropped++
+}
+
+func (h *ReplayHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.derive(replayStep{attrs: attrs})
+}
+
+func (h *ReplayHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return h.derive(replayStep{group: name})
+}
+
+// derive returns a child sharing the buffer with step added
+func (h *ReplayHandler) derive(step replayStep) *ReplayHandler {
+	steps := make([]replayStep, len(h.steps), len(h.steps)+1)
+	copy(steps, h.steps)
+	return &ReplayHandler{state: h.state, steps: append(steps, step)}
+}
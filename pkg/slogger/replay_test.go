@@ -0,0 +1,118 @@
+package slogger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-examples/pkg/slogger"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// decodeJSONRecords decodes every JSON record written to buf
+func decodeJSONRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid JSON record %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// messages returns the msg of each record
+func messages(records []map[string]any) []string {
+	var msgs []string
+	for _, r := range records {
+		msgs = append(msgs, r["msg"].(string))
+	}
+	return msgs
+}
+
+// TestReplayHandler tests replay order and pass-through after Redirect
+func TestReplayHandler(t *testing.T) {
+	replay := slogger.NewReplayHandler(10)
+	log := slog.New(replay)
+
+	log.Info("parsing flags")
+	log.Warn("unknown env value", "env", "stagin")
+	log.Info("config loaded")
+
+	var buf bytes.Buffer
+	replay.Redirect(slog.NewJSONHandler(&buf, nil))
+	if got := strings.Join(messages(decodeJSONRecords(t, &buf)), ","); got != "parsing flags,unknown env value,config loaded" {
+		t.Errorf("expected buffered records in order, got %s", got)
+	}
+
+	buf.Reset()
+	log.Info("serving")
+	records := decodeJSONRecords(t, &buf)
+	if len(records) != 1 || records[0]["msg"] != "serving" {
+		t.Errorf("expected later records to stream directly, got %v", records)
+	}
+}
+
+// TestReplayHandlerAttrsAndLevels tests that children keep their attrs and
+// groups and that the target's level applies on replay
+func TestReplayHandlerAttrsAndLevels(t *testing.T) {
+	replay := slogger.NewReplayHandler(10)
+	log := slog.New(replay)
+
+	log.With("phase", "startup").WithGroup("config").Info("loaded", "file", "app.yaml")
+	log.Debug("noisy detail")
+
+	var buf bytes.Buffer
+	replay.Redirect(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	records := decodeJSONRecords(t, &buf)
+	if len(records) != 1 {
+		t.Fatalf("expected the debug record filtered by the target, got %v", records)
+	}
+	if records[0]["phase"] != "startup" || records[0]["config"].(map[string]any)["file"] != "app.yaml" {
+		t.Errorf("expected attrs and groups applied on replay, got %v", records[0])
+	}
+	if replay.Enabled(t.Context(), slog.LevelDebug) {
+		t.Error("expected Enabled to follow the target after Redirect")
+	}
+}
+
+// TestReplayHandlerOverflow tests that overflow drops the oldest with a marker
+func TestReplayHandlerOverflow(t *testing.T) {
+	replay := slogger.NewReplayHandler(2)
+	log := slog.New(replay)
+	for _, msg := range []string{"one", "two", "three", "four"} {
+		log.Info(msg)
+	}
+
+	var buf bytes.Buffer
+	replay.Redirect(slog.NewJSONHandler(&buf, nil))
+
+	records := decodeJSONRecords(t, &buf)
+	if got := strings.Join(messages(records), ","); got != "dropped early log records,three,four" {
+		t.Errorf("expected a marker then the newest records, got %s", got)
+	}
+	if records[0]["dropped"] != float64(2) || records[0]["level"] != "WARN" {
+		t.Errorf("unexpected marker %v", records[0])
+	}
+}
+
+// TestLoggerHandler tests redirecting to a Logger's handler
+func TestLoggerHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slogger.New(slogger.WithWriter(&buf), slogger.WithLevel(slog.LevelWarn))
+
+	replay := slogger.NewReplayHandler(4)
+	slog.New(replay).Info("hidden at warn")
+	slog.New(replay).Error("kept")
+	replay.Redirect(logger.Handler())
+
+	if strings.Contains(buf.String(), "hidden") || !strings.Contains(buf.String(), "msg=kept") {
+		t.Errorf("expected the Logger's level to apply, got: %s", buf.String())
+	}
+}
@@ -0,0 +1,102 @@
+package slogger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"go-examples/pkg/slogger"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// installFakeClock replaces slogger.Now with a clock advanced by the returned function
+func installFakeClock(t *testing.T) func(time.Duration) {
+	t.Helper()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	origNow := slogger.Now
+	slogger.Now = func() time.Time { return now }
+	t.Cleanup(func() { slogger.Now = origNow })
+	return func(d time.Duration) { now = now.Add(d) }
+}
+
+// decodeJSONRecord decodes the single JSON record written to buf
+func decodeJSONRecord(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("output is not a single JSON record: %v (%s)", err, buf.String())
+	}
+	return record
+}
+
+// TestResponseDurationAndBytes tests duration and size reporting with a fake clock
+func TestResponseDurationAndBytes(t *testing.T) {
+	advance := installFakeClock(t)
+	var buf bytes.Buffer
+	logger := slogger.New(slogger.WithWriter(&buf), slogger.WithJSON())
+
+	req := httptest.NewRequest("POST", "/calculate", nil)
+	rw := slogger.WrapResponseWriter(httptest.NewRecorder())
+	rl := logger.NewResponseLogger("req-1").Start().Track(rw)
+
+	payload := []byte(`{"result":8,"success":true}`)
+	advance(42 * time.Millisecond)
+	if _, err := rw.Write(payload); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	advance(8 * time.Millisecond)
+	rl.Response(http.StatusOK, req)
+
+	record := decodeJSONRecord(t, &buf)
+	if record["duration_ms"] != float64(50) {
+		t.Errorf("duration_ms = %v; want 50", record["duration_ms"])
+	}
+	if record["bytes_written"] != float64(len(payload)) {
+		t.Errorf("bytes_written = %v; want %d", record["bytes_written"], len(payload))
+	}
+	if record["method"] != "POST" || record["uri"] != "/calculate" || record["code"] != float64(200) {
+		t.Errorf("unexpected request fields: %v", record)
+	}
+}
+
+// TestResponseWithoutStartOrTrack tests that optional fields are omitted
+func TestResponseWithoutStartOrTrack(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slogger.New(slogger.WithWriter(&buf), slogger.WithJSON())
+
+	logger.NewResponseLogger("req-2").Response(http.StatusNoContent, httptest.NewRequest("GET", "/health", nil))
+
+	record := decodeJSONRecord(t, &buf)
+	if _, ok := record["duration_ms"]; ok {
+		t.Errorf("expected no duration_ms without Start, got: %v", record)
+	}
+	if _, ok := record["bytes_written"]; ok {
+		t.Errorf("expected no bytes_written without Track, got: %v", record)
+	}
+	if record["method"] != "GET" {
+		t.Errorf("method = %v; want GET", record["method"])
+	}
+}
+
+// TestResponseErrorAndSendTracksWriter tests exact sizes for error responses
+func TestResponseErrorAndSendTracksWriter(t *testing.T) {
+	advance := installFakeClock(t)
+	var buf bytes.Buffer
+	logger := slogger.New(slogger.WithWriter(&buf), slogger.WithJSON())
+
+	rec := httptest.NewRecorder()
+	rw := slogger.WrapResponseWriter(rec)
+	rl := logger.NewResponseLogger("req-3").Start()
+	advance(3 * time.Millisecond)
+	rl.ResponseErrorAndSend(http.StatusBadRequest, errors.New("Bad Request"), httptest.NewRequest("POST", "/calculate", nil), rw)
+
+	record := decodeJSONRecord(t, &buf)
+	if record["bytes_written"] != float64(rec.Body.Len()) {
+		t.Errorf("bytes_written = %v; want %d", record["bytes_written"], rec.Body.Len())
+	}
+	if record["duration_ms"] != float64(3) {
+		t.Errorf("duration_ms = %v; want 3", record["duration_ms"])
+	}
+}
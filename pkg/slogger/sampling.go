@@ -0,0 +1,203 @@
+package slogger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// SamplingOption configures a handler created by SamplingHandler.
+type SamplingOption func(*samplingState)
+
+// WithSamplingClock reads the time from now instead of time.Now, so
+// tests can end a tick without waiting for it. A nil now is ignored.
+func WithSamplingClock(now func() time.Time) SamplingOption {
+	return func(s *samplingState) {
+		if now != nil {
+			s.now = now
+		}
+	}
+}
+
+// SamplingHandler wraps next so that, per tick, the first initial records
+// with a given level and message pass, then every thereafter-th one.
+// Records at Warn and above are never sampled. When a tick with dropped
+// records ends, one summary record per sampled message, with the message
+// and its dropped count, is passed to next: from a timer, or by the
+// first record after the tick if that comes first. Close passes the
+// summaries of the current tick and stops the timer.
+func SamplingHandler(next slog.Handler, initial, thereafter int, tick time.Duration, opts ...SamplingOption) slog.Handler {
+	s := &samplingState{
+		initial:    initial,
+		thereafter: thereafter,
+		tick:       tick,
+		summary:    next,
+		now:        time.Now,
+		counts:     map[sampleKey]*sampleCount{},
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(s)
+		}
+	}
+	s.start = s.now()
+	return &samplingHandler{next: next, state: s}
+}
+
+// samplingHandler applies a sampling state shared with its children
+type samplingHandler struct {
+	next  slog.Handler
+	state *samplingState
+}
+
+// samplingState counts records per message within the current tick
+type samplingState struct {
+	mu         sync.Mutex
+	initial    int
+	thereafter int
+	tick       time.Duration
+	summary    slog.Handler // receives summaries without child attrs or groups
+	now        func() time.Time
+	start      time.Time
+	counts     map[sampleKey]*sampleCount
+	timer      *time.Timer // ends a tick with dropped records
+	armed      int         // counts the timers, to ignore stopped ones that fired
+	closed     bool
+}
+
+type sampleKey struct {
+	level slog.Level
+	msg   string
+}
+
+type sampleCount struct {
+	seen    int
+	dropped int
+}
+
+func (h *samplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *samplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= slog.LevelWarn {
+		return h.next.Handle(ctx, r)
+	}
+	keep, summaries := h.state.sample(sampleKey{level: r.Level, msg: r.Message})
+	if err := h.state.emit(ctx, summaries); err != nil {
+		return err
+	}
+	if !keep {
+		return nil
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{next: h.next.WithAttrs(attrs), state: h.state}
+}
+
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{next: h.next.WithGroup(name), state: h.state}
+}
+
+// sample reports whether a record with key passes, and returns the
+// summaries of the previous tick when a new one has started
+func (s *samplingState) sample(key sampleKey) (bool, []slog.Record) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var summaries []slog.Record
+	if now.Sub(s.start) >= s.tick {
+		summaries = s.rollover(now)
+	}
+
+	c, ok := s.counts[key]
+	if !ok {
+		c = &sampleCount{}
+		s.counts[key] = c
+	}
+	c.seen++
+	if c.seen <= s.initial || (s.thereafter > 0 && (c.seen-s.initial)%s.thereafter == 0) {
+		return true, summaries
+	}
+	c.dropped++
+	if s.timer == nil && !s.closed {
+		s.arm(s.tick - now.Sub(s.start))
+	}
+	return false, summaries
+}
+
+// rollover returns the summaries of the current tick and starts a new
+// one at now. The caller holds s.mu.
+func (s *samplingState) rollover(now time.Time) []slog.Record {
+	var summaries []slog.Record
+	for k, c := range s.counts {
+		if c.dropped > 0 {
+			r := slog.NewRecord(now, k.level, "dropped sampled messages", 0)
+			r.AddAttrs(slog.String("message", k.msg), slog.Int("dropped", c.dropped))
+			summaries = append(summaries, r)
+		}
+	}
+	s.start = now
+	s.counts = map[sampleKey]*sampleCount{}
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	return summaries
+}
+
+// arm starts the timer ending the current tick after d. The caller
+// holds s.mu.
+func (s *samplingState) arm(d time.Duration) {
+	s.armed++
+	armed := s.armed
+	s.timer = time.AfterFunc(d, func() { s.expire(armed) })
+}
+
+// expire passes the summaries of a tick that has ended without a record
+// to end it, or waits for the rest of the tick by the clock. armed
+// identifies the timer that fired.
+func (s *samplingState) expire(armed int) {
+	s.mu.Lock()
+	if s.closed || s.timer == nil || armed != s.armed {
+		s.mu.Unlock()
+		return
+	}
+	now := s.now()
+	if left := s.tick - now.Sub(s.start); left > 0 {
+		s.arm(left)
+		s.mu.Unlock()
+		return
+	}
+	summaries := s.rollover(now)
+	s.mu.Unlock()
+	_ = s.emit(context.Background(), summaries)
+}
+
+// close passes the summaries of the current tick and stops the timer
+func (s *samplingState) close(ctx context.Context) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	summaries := s.rollover(s.now())
+	s.mu.Unlock()
+	return s.emit(ctx, summaries)
+}
+
+// emit passes summaries to the summary handler, stopping at the first
+// error
+func (s *samplingState) emit(ctx context.Context, summaries []slog.Record) error {
+	for _, r := range summaries {
+		if err := s.summary.Handle(ctx, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package slogger_test
+
+import (
+	"context"
+	"go-examples/pkg/slogger"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingHandler counts records per message and keeps the attrs of the last one
+type countingHandler struct {
+	mu     sync.Mutex
+	counts map[string]int
+	last   map[string]map[string]any
+}
+
+func newCountingHandler() *countingHandler {
+	return &countingHandler{counts: map[string]int{}, last: map[string]map[string]any{}}
+}
+
+func (h *countingHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *countingHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.counts[r.Message]++
+	attrs := map[string]any{}
+	r.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value.Any()
+		return true
+	})
+	h.last[r.Message] = attrs
+	return nil
+}
+
+func (h *countingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *countingHandler) WithGroup(string) slog.Handler      { return h }
+
+// samplingClock returns a clock for WithSamplingClock and a function
+// advancing it
+func samplingClock() (func() time.Time, func(time.Duration)) {
+	var mu sync.Mutex
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	return func() time.Time {
+			mu.Lock()
+			defer mu.Unlock()
+			return now
+		}, func(d time.Duration) {
+			mu.Lock()
+			defer mu.Unlock()
+			now = now.Add(d)
+		}
+}
+
+// TestSamplingHandler tests pass-through counts, the summary and unsampled errors
+func TestSamplingHandler(t *testing.T) {
+	now, advance := samplingClock()
+	counter := newCountingHandler()
+	handler := slogger.SamplingHandler(counter, 100, 100, time.Minute, slogger.WithSamplingClock(now))
+	defer slogger.Close(context.Background(), handler)
+	log := slog.New(handler)
+
+	for i := 0; i < 10000; i++ {
+		log.Info("cache miss", "i", i)
+		log.Error("backend down")
+	}
+
+	// 100 initial records, then every 100th of the remaining 9900
+	if got := counter.counts["cache miss"]; got != 199 {
+		t.Errorf("expected 199 sampled info records, got %d", got)
+	}
+	if got := counter.counts["backend down"]; got != 10000 {
+		t.Errorf("expected every error record delivered, got %d", got)
+	}
+	if got := counter.counts["dropped sampled messages"]; got != 0 {
+		t.Errorf("expected no summary within the tick, got %d", got)
+	}
+
+	advance(time.Minute)
+	log.Info("cache miss")
+
+	if got := counter.counts["dropped sampled messages"]; got != 1 {
+		t.Fatalf("expected one summary after the tick, got %d", got)
+	}
+	summary := counter.last["dropped sampled messages"]
+	if summary["message"] != "cache miss" || summary["dropped"] != int64(9801) {
+		t.Errorf("expected 9801 dropped for cache miss, got %v", summary)
+	}
+	if got := counter.counts["cache miss"]; got != 200 {
+		t.Errorf("expected the counts to reset with the new tick, got %d", got)
+	}
+}
+
+// TestSamplingHandlerSharedAcrossChildren tests that With children share the budget
+func TestSamplingHandlerSharedAcrossChildren(t *testing.T) {
+	now, _ := samplingClock()
+	counter := newCountingHandler()
+	log := slog.New(slogger.SamplingHandler(counter, 2, 0, time.Minute, slogger.WithSamplingClock(now)))
+
+	log.Info("tick")
+	log.With("child", 1).Info("tick")
+	log.WithGroup("g").Info("tick")
+
+	if got := counter.counts["tick"]; got != 2 {
+		t.Errorf("expected children to share the initial budget of 2, got %d", got)
+	}
+}
+
+// TestWithSampling tests the constructor option
+func TestWithSampling(t *testing.T) {
+	now, advance := samplingClock()
+	counter := newCountingHandler()
+	logger := slogger.New(slogger.WithWriter(io.Discard),
+		slogger.WithAdditionalHandler(counter), slogger.WithSampling(1, 0, time.Hour, slogger.WithSamplingClock(now)))
+
+	for i := 0; i < 5; i++ {
+		logger.Info("retrying")
+	}
+	advance(time.Hour)
+	logger.Warn("giving up")
+	// Debug is sampled too, so it starts the new tick and flushes the summary
+	logger.Debug("state")
+
+	if counter.counts["retrying"] != 1 {
+		t.Errorf("expected one retrying record, got %d", counter.counts["retrying"])
+	}
+	if counter.counts["giving up"] != 1 {
+		t.Errorf("expected the warn record delivered, got %d", counter.counts["giving up"])
+	}
+	if counter.last["dropped sampled messages"]["dropped"] != int64(4) {
+		t.Errorf("expected a summary of 4 dropped records, got %v", counter.last["dropped sampled messages"])
+	}
+}
+
+// TestSamplingHandlerClose tests that Close passes the summary of the
+// current tick without waiting for another record
+func TestSamplingHandlerClose(t *testing.T) {
+	now, _ := samplingClock()
+	counter := newCountingHandler()
+	handler := slogger.SamplingHandler(counter, 1, 0, time.Hour, slogger.WithSamplingClock(now))
+	log := slog.New(handler.WithAttrs([]slog.Attr{slog.Int("a", 1)}))
+	for i := 0; i < 3; i++ {
+		log.Info("polling")
+	}
+
+	if err := slogger.Close(context.Background(), handler); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if got := counter.last["dropped sampled messages"]; got["message"] != "polling" || got["dropped"] != int64(2) {
+		t.Errorf("expected a summary of 2 dropped polling records, got %v", got)
+	}
+	if err := slogger.Close(context.Background(), handler); err != nil || counter.counts["dropped sampled messages"] != 1 {
+		t.Errorf("second Close = %v with %d summaries, want nil and 1", err, counter.counts["dropped sampled messages"])
+	}
+}
+
+// TestSamplingHandlerTimer tests that the summary of a tick is passed
+// when the tick ends, though no record follows
+func TestSamplingHandlerTimer(t *testing.T) {
+	counter := newCountingHandler()
+	handler := slogger.SamplingHandler(counter, 1, 0, 20*time.Millisecond)
+	defer slogger.Close(context.Background(), handler)
+	log := slog.New(handler)
+	log.Info("polling")
+	log.Info("polling")
+
+	summaries := func() int {
+		counter.mu.Lock()
+		defer counter.mu.Unlock()
+		return counter.counts["dropped sampled messages"]
+	}
+	for deadline := time.Now().Add(5 * time.Second); summaries() == 0; time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("no summary after the tick ended")
+		}
+	}
+}
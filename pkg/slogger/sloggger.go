@@ -8,36 +8,178 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"runtime"
+	"time"
 )
 
 // Logger is a wrapper around slog that provides simpler methods for common logging levels.
-type Logger struct{}
+// The zero value logs through the global slog default.
+type Logger struct {
+	logger *slog.Logger    // nil means slog.Default()
+	level  *slog.LevelVar // shared with children; nil when not created by New
+	// groupHTTP places ResponseLogger fields under an "http" group
+	groupHTTP bool
+}
+
+// base returns the underlying slog.Logger
+func (l *Logger) base() *slog.Logger {
+	if l.logger == nil {
+		return slog.Default()
+	}
+	return l.logger
+}
+
+// With returns a child Logger that includes the given attributes in every
+// message. Attributes accumulate across nested With calls. Values
+// created with slog.Group are kept nested.
+func (l Logger) With(args ...any) Logger {
+	return Logger{logger: l.base().With(args...), level: l.level, groupHTTP: l.groupHTTP}
+}
+
+// WithGroup returns a child Logger that qualifies the attributes of every
+// later message with name: text output uses dotted keys such as
+// calc.op=add, and JSON output nests them in a "calc" object. Attributes
+// added to l before the call stay at their original level.
+func (l Logger) WithGroup(name string) Logger {
+	return Logger{logger: l.base().WithGroup(name), level: l.level, groupHTTP: l.groupHTTP}
+}
+
+// Handler returns the slog.Handler that l logs through, for use with
+// code that takes a handler or to redirect a ReplayHandler to it.
+func (l Logger) Handler() slog.Handler {
+	return l.base().Handler()
+}
+
+// SetLevel changes the minimum level at runtime. The change applies to
+// the Logger and every child derived from it with With. It has no effect
+// on loggers that were not created by New.
+func (l *Logger) SetLevel(level slog.Level) {
+	if l.level != nil {
+		l.level.Set(level)
+	}
+}
+
+// Level returns the current minimum level.
+func (l *Logger) Level() slog.Level {
+	if l.level == nil {
+		for _, level := range []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn} {
+			if l.base().Enabled(context.Background(), level) {
+				return level
+			}
+		}
+		return slog.LevelError
+	}
+	return l.level.Level()
+}
+
+// Now returns the current time. It is a variable to allow tests to
+// control the durations reported by ResponseLogger.
+var Now = time.Now
 
 // OsExit is a variable that points to os.Exit to allow for testing
 // without actually exiting the program.
 var OsExit = os.Exit
 
 // Fatal logs a message at fatal level and then exits the program with status code 1.
-func (l *Logger) Fatal(msg string, args ...any) {
-	slog.Log(context.Background(), slog.LevelError, msg, args...)
-	OsExit(1)
+// Callbacks registered with RegisterOnFatal run before the exit.
+func (l Logger) Fatal(msg string, args ...any) {
+	l.fatal(1, msg, args...)
 }
 
 // Error logs a message at error level.
-func (l *Logger) Error(msg string, args ...any) {
-	slog.Log(context.Background(), slog.LevelError, msg, args...)
+func (l Logger) Error(msg string, args ...any) {
+	l.log(context.Background(), 0, slog.LevelError, msg, args...)
+}
+
+// Warn logs a message at warn level.
+func (l Logger) Warn(msg string, args ...any) {
+	l.log(context.Background(), 0, slog.LevelWarn, msg, args...)
 }
 
 // Info logs a message at info level.
-func (l *Logger) Info(msg string, args ...any) {
-	slog.Log(context.Background(), slog.LevelInfo, msg, args...)
+func (l Logger) Info(msg string, args ...any) {
+	l.log(context.Background(), 0, slog.LevelInfo, msg, args...)
+}
+
+// Debug logs a message at debug level.
+func (l Logger) Debug(msg string, args ...any) {
+	l.log(context.Background(), 0, slog.LevelDebug, msg, args...)
+}
+
+// ErrorContext logs a message at error level, passing ctx to the handler.
+func (l Logger) ErrorContext(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, 0, slog.LevelError, msg, args...)
+}
+
+// WarnContext logs a message at warn level, passing ctx to the handler.
+func (l Logger) WarnContext(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, 0, slog.LevelWarn, msg, args...)
+}
+
+// InfoContext logs a message at info level, passing ctx to the handler.
+func (l Logger) InfoContext(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, 0, slog.LevelInfo, msg, args...)
+}
+
+// DebugContext logs a message at debug level, passing ctx to the handler.
+func (l Logger) DebugContext(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, 0, slog.LevelDebug, msg, args...)
+}
+
+// Errorf logs a formatted message at error level.
+func (l Logger) Errorf(format string, args ...any) {
+	l.logf(slog.LevelError, format, args...)
+}
+
+// Warnf logs a formatted message at warn level.
+func (l Logger) Warnf(format string, args ...any) {
+	l.logf(slog.LevelWarn, format, args...)
+}
+
+// Infof logs a formatted message at info level.
+func (l Logger) Infof(format string, args ...any) {
+	l.logf(slog.LevelInfo, format, args...)
+}
+
+// Debugf logs a formatted message at debug level.
+func (l Logger) Debugf(format string, args ...any) {
+	l.logf(slog.LevelDebug, format, args...)
 }
 
-// InitLogging initializes the structured logger with DEBUG level
-// and returns a new Logger instance.
+// logf formats the message only when level is enabled
+func (l Logger) logf(level slog.Level, format string, args ...any) {
+	if !l.base().Enabled(context.Background(), level) {
+		return
+	}
+	l.log(context.Background(), 1, level, fmt.Sprintf(format, args...))
+}
+
+// log emits a record whose source is the caller of the exported logging
+// method. skip is the number of frames this package adds between that
+// method and log, so AddSource never points into slogger itself.
+func (l Logger) log(ctx context.Context, skip int, level slog.Level, msg string, args ...any) {
+	logger := l.base()
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+	var pcs [1]uintptr
+	// Skip runtime.Callers, log, the exported method and skip more frames
+	runtime.Callers(3+skip, pcs[:])
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	r.Add(args...)
+	_ = logger.Handler().Handle(ctx, r)
+}
+
+// InitLogging creates a Logger at DEBUG level writing text to stderr.
+// It is kept for compatibility; New offers full control.
 func InitLogging() Logger {
-	slog.SetLogLoggerLevel(slog.LevelDebug)
-	return Logger{}
+	return New(WithLevel(slog.LevelDebug))
+}
+
+// InitLoggingLevel creates a Logger that discards messages below level.
+// It is kept for compatibility; New offers full control.
+func InitLoggingLevel(level slog.Level) Logger {
+	return New(WithLevel(level))
 }
 
 // ResponseLogger provides logging utilities specifically for HTTP responses
@@ -45,24 +187,80 @@ func InitLogging() Logger {
 type ResponseLogger struct {
 	requestID string // Unique ID for the request
 	logger    *Logger
+	start     time.Time        // Set by Start; zero means duration is not reported
+	writer    *RecordingWriter // Set by Track; nil means size is not reported
 }
 
-// Response logs information about an HTTP response including status code and URI.
+// RequestID returns the ID of the request this logger is bound to.
+func (l *ResponseLogger) RequestID() string {
+	return l.requestID
+}
+
+// Logger returns the child Logger carrying the request_id attribute, for
+// logging that is not about the response itself.
+func (l *ResponseLogger) Logger() Logger {
+	return *l.logger
+}
+
+// Start records the current time so that later responses report their
+// duration_ms. It returns l for chaining.
+func (l *ResponseLogger) Start() *ResponseLogger {
+	l.start = Now()
+	return l
+}
+
+// Track associates the writer used for the response so that later
+// responses report bytes_written. It returns l for chaining.
+func (l *ResponseLogger) Track(w *RecordingWriter) *ResponseLogger {
+	l.writer = w
+	return l
+}
+
+// Response logs information about an HTTP response including status code,
+// method and URI, plus duration_ms after Start and bytes_written after Track.
+// With WithGroupedFields these are nested under an "http" group, while
+// args stay at the top level.
 func (l *ResponseLogger) Response(code int, r *http.Request, args ...any) {
-	params := append([]any{"code", code, "uri", r.RequestURI}, args...)
-	l.logger.Info(l.requestID, params...)
+	l.response(1, code, r, args...)
 }
 
-// ResponseErrorAndSend logs an error response and sends it to the client.
-func (l *ResponseLogger) ResponseErrorAndSend(code int, msg string, r *http.Request, w http.ResponseWriter, args ...any) {
-	l.Response(code, r, append([]any{"message", msg}, args...)...)
+// response logs the response record, attributing it to the caller skip
+// frames above
+func (l *ResponseLogger) response(skip int, code int, r *http.Request, args ...any) {
+	params := []any{"code", code, "method", r.Method, "uri", r.RequestURI}
+	if !l.start.IsZero() {
+		params = append(params, "duration_ms", float64(Now().Sub(l.start))/float64(time.Millisecond))
+	}
+	if l.writer != nil {
+		params = append(params, "bytes_written", l.writer.BytesWritten())
+	}
+	if l.logger.groupHTTP {
+		params = []any{slog.Group("http", params...)}
+	}
+	l.logger.log(r.Context(), skip+1, slog.LevelInfo, "response", append(params, args...)...)
+}
+
+// ResponseErrorAndSend sends err as an error response to the client and
+// logs it with Err. When w is a RecordingWriter it is tracked so the
+// logged size is exact.
+func (l *ResponseLogger) ResponseErrorAndSend(code int, err error, r *http.Request, w http.ResponseWriter, args ...any) {
+	if rw, ok := w.(*RecordingWriter); ok && l.writer == nil {
+		l.Track(rw)
+	}
+	msg := http.StatusText(code)
+	if err != nil {
+		msg = err.Error()
+	}
 	http.Error(w, fmt.Sprintf("%d %s", code, msg), code)
+	l.response(1, code, r, append([]any{Err(err)}, args...)...)
 }
 
 // NewResponseLogger creates a new ResponseLogger with the specified request ID.
+// The request ID is bound to every message as the request_id attribute.
 func (l *Logger) NewResponseLogger(requestID string) *ResponseLogger {
+	child := l.With("request_id", requestID)
 	return &ResponseLogger{
 		requestID: requestID,
-		logger:    l,
+		logger:    &child,
 	}
 }
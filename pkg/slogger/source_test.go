@@ -0,0 +1,81 @@
+package slogger_test
+
+import (
+	"bytes"
+	"errors"
+	"go-examples/pkg/slogger"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestSourceReportsCaller tests that records point at the code calling slogger
+func TestSourceReportsCaller(t *testing.T) {
+	installExit(t)
+	req := httptest.NewRequest("GET", "/calculate", nil)
+
+	testCases := []struct {
+		name string
+		log  func(slogger.Logger)
+	}{
+		{"Direct", func(l slogger.Logger) { l.Error("failed", "op", "divide") }},
+		{"Context", func(l slogger.Logger) { l.WarnContext(req.Context(), "slow") }},
+		{"Formatted", func(l slogger.Logger) { l.Errorf("failed to %s", "divide") }},
+		{"Fatal", func(l slogger.Logger) { l.Fatal("giving up") }},
+		{"FatalCode", func(l slogger.Logger) { l.FatalCode(2, "giving up") }},
+		{"Response", func(l slogger.Logger) { l.NewResponseLogger("req-1").Response(http.StatusOK, req) }},
+		{"ResponseErrorAndSend", func(l slogger.Logger) {
+			l.NewResponseLogger("req-1").ResponseErrorAndSend(http.StatusBadRequest, errors.New("bad"), req, httptest.NewRecorder())
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tc.log(slogger.New(slogger.WithWriter(&buf), slogger.WithJSON(), slogger.WithSource(true)))
+
+			source, ok := decodeJSONRecord(t, &buf)["source"].(map[string]any)
+			if !ok {
+				t.Fatalf("expected a source object, got: %s", buf.String())
+			}
+			if file, _ := source["file"].(string); filepath.Base(file) != "source_test.go" {
+				t.Errorf("expected source in source_test.go, got %v", source["file"])
+			}
+			if fn, _ := source["function"].(string); !strings.Contains(fn, "TestSourceReportsCaller") {
+				t.Errorf("expected the test function as source, got %v", source["function"])
+			}
+		})
+	}
+}
+
+// TestSourceDisabledByDefault tests that records carry no source unless enabled
+func TestSourceDisabledByDefault(t *testing.T) {
+	var buf bytes.Buffer
+	slogger.New(slogger.WithWriter(&buf), slogger.WithJSON()).Infof("%d", 1)
+	if _, ok := decodeJSONRecord(t, &buf)["source"]; ok {
+		t.Errorf("expected no source attr, got: %s", buf.String())
+	}
+}
+
+// TestFormattedVariants tests the f-suffixed logging methods
+func TestFormattedVariants(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slogger.New(slogger.WithWriter(&buf))
+
+	logger.Debugf("hidden %d", 1)
+	logger.Infof("sum is %d", 8)
+	logger.Warnf("took %s", "long")
+	logger.Errorf("cannot divide %d by %d", 1, 0)
+
+	out := buf.String()
+	if strings.Contains(out, "hidden") {
+		t.Errorf("expected Debugf to be filtered at info, got: %s", out)
+	}
+	for _, want := range []string{`level=INFO msg="sum is 8"`, `level=WARN msg="took long"`, `level=ERROR msg="cannot divide 1 by 0"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got: %s", want, out)
+		}
+	}
+}
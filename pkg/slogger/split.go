@@ -0,0 +1,43 @@
+package slogger
+
+import (
+	"context"
+	"log/slog"
+)
+
+// NewSplit returns a handler that sends records at or above threshold to
+// high and all other records to low. Attributes and groups added with
+// With or WithGroup apply to both branches. It is enabled for a level
+// when either branch is.
+func NewSplit(low, high slog.Handler, threshold slog.Level) slog.Handler {
+	return &splitHandler{low: low, high: high, threshold: threshold}
+}
+
+// splitHandler routes records to one of two handlers by level
+type splitHandler struct {
+	low, high slog.Handler
+	threshold slog.Level
+}
+
+func (h *splitHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.low.Enabled(ctx, level) || h.high.Enabled(ctx, level)
+}
+
+func (h *splitHandler) Handle(ctx context.Context, r slog.Record) error {
+	target := h.low
+	if r.Level >= h.threshold {
+		target = h.high
+	}
+	if !target.Enabled(ctx, r.Level) {
+		return nil
+	}
+	return target.Handle(ctx, r)
+}
+
+func (h *splitHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &splitHandler{low: h.low.WithAttrs(attrs), high: h.high.WithAttrs(attrs), threshold: h.threshold}
+}
+
+func (h *splitHandler) WithGroup(name string) slog.Handler {
+	return &splitHandler{low: h.low.WithGroup(name), high: h.high.WithGroup(name), threshold: h.threshold}
+}
@@ -0,0 +1,116 @@
+package slogger_test
+
+import (
+	"bytes"
+	"context"
+	"go-examples/pkg/slogger"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestNewSplitRouting tests that each level reaches exactly one branch
+func TestNewSplitRouting(t *testing.T) {
+	var low, high bytes.Buffer
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	log := slog.New(slogger.NewSplit(slog.NewTextHandler(&low, opts), slog.NewTextHandler(&high, opts), slog.LevelWarn))
+
+	log.Debug("debug line")
+	log.Info("info line")
+	log.Warn("warn line")
+	log.Error("error line")
+
+	for _, msg := range []string{"debug line", "info line"} {
+		if !strings.Contains(low.String(), msg) || strings.Contains(high.String(), msg) {
+			t.Errorf("expected %q only in the low branch", msg)
+		}
+	}
+	for _, msg := range []string{"warn line", "error line"} {
+		if !strings.Contains(high.String(), msg) || strings.Contains(low.String(), msg) {
+			t.Errorf("expected %q only in the high branch", msg)
+		}
+	}
+}
+
+// TestNewSplitAttrs tests that attrs and groups reach both branches
+func TestNewSplitAttrs(t *testing.T) {
+	var low, high bytes.Buffer
+	log := slog.New(slogger.NewSplit(slog.NewTextHandler(&low, nil), slog.NewTextHandler(&high, nil), slog.LevelError)).
+		With("service", "calc").WithGroup("op")
+
+	log.Info("ok", "name", "add")
+	log.Error("failed", "name", "divide")
+
+	if !strings.Contains(low.String(), "service=calc op.name=add") {
+		t.Errorf("expected attrs in the low branch, got: %s", low.String())
+	}
+	if !strings.Contains(high.String(), "service=calc op.name=divide") {
+		t.Errorf("expected attrs in the high branch, got: %s", high.String())
+	}
+}
+
+// TestNewSplitEnabled tests that Enabled is the union of both branches
+func TestNewSplitEnabled(t *testing.T) {
+	split := slogger.NewSplit(
+		slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelInfo}),
+		slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelDebug}),
+		slog.LevelError,
+	)
+	if !split.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("expected debug enabled through the high branch")
+	}
+
+	quiet := slogger.NewSplit(
+		slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelWarn}),
+		slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError}),
+		slog.LevelError,
+	)
+	if quiet.Enabled(context.Background(), slog.LevelInfo) {
+		t.Error("expected info disabled when neither branch accepts it")
+	}
+}
+
+// captureStd replaces os.Stdout and os.Stderr with pipes for the duration of fn
+func captureStd(t *testing.T, fn func()) (stdout, stderr string) {
+	t.Helper()
+	capture := func(target **os.File) func() string {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		orig := *target
+		*target = w
+		done := make(chan string)
+		go func() {
+			data, _ := io.ReadAll(r)
+			done <- string(data)
+		}()
+		return func() string {
+			*target = orig
+			w.Close()
+			return <-done
+		}
+	}
+	restoreOut := capture(&os.Stdout)
+	restoreErr := capture(&os.Stderr)
+	fn()
+	return restoreOut(), restoreErr()
+}
+
+// TestWithSplitStreams tests the constructor option against the real streams
+func TestWithSplitStreams(t *testing.T) {
+	stdout, stderr := captureStd(t, func() {
+		logger := slogger.New(slogger.WithSplitStreams())
+		logger.Info("to stdout")
+		logger.Error("to stderr")
+	})
+
+	if !strings.Contains(stdout, "to stdout") || strings.Contains(stdout, "to stderr") {
+		t.Errorf("unexpected stdout: %s", stdout)
+	}
+	if !strings.Contains(stderr, "to stderr") || strings.Contains(stderr, "to stdout") {
+		t.Errorf("unexpected stderr: %s", stderr)
+	}
+}
@@ -0,0 +1,187 @@
+// Package validate checks request structs field by field without
+// reflection. A request lists its fields with accessor closures, and
+// rules are chained on a Validator:
+//
+//	errs := validate.Struct(req).
+//		Require("operation").
+//		OneOf("operation", "add", "subtract").
+//		Range("a", -1000, 1000).
+//		MutuallyExclusive("operands", "a").
+//		Errors()
+//
+// Every rule but Require skips fields that are absent, so one missing
+// field yields one error.
+package validate
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rule codes reported in FieldError.Code
+const (
+	CodeRequired          = "required"
+	CodeOneOf             = "one_of"
+	CodeOutOfRange        = "out_of_range"
+	CodeMutuallyExclusive = "mutually_exclusive"
+)
+
+// Fields maps field names, as they appear in the JSON body, to functions
+// returning their values. Supported values are string, int, *int, []int
+// and bool; a nil pointer or slice, an empty slice, an empty string and
+// false are absent, and an int is always present.
+type Fields map[string]func() any
+
+// Validatable is implemented by request types that can be validated
+type Validatable interface {
+	ValidationFields() Fields
+}
+
+// Messages maps each rule code to the fmt format of its English message.
+// Translations use the key "validate." + code.
+var Messages = map[string]string{
+	CodeRequired:          "is required",
+	CodeOneOf:             "must be one of %s",
+	CodeOutOfRange:        "must be between %d and %d",
+	CodeMutuallyExclusive: "cannot be used with %s",
+}
+
+// FieldError describes a field that failed a rule. It marshals to the
+// entries of the "fields" list in error responses.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Args    []any  `json:"-"` // arguments of the message format; not part of the body
+}
+
+func (e FieldError) Error() string {
+	return e.Field + " " + e.Message
+}
+
+// Validator collects the errors of the rules applied to one value
+type Validator struct {
+	fields Fields
+	errs   []FieldError
+}
+
+// Struct starts validating v
+func Struct(v Validatable) *Validator {
+	return &Validator{fields: v.ValidationFields()}
+}
+
+// Require reports field when it is absent
+func (v *Validator) Require(field string) *Validator {
+	if !present(v.get(field)) {
+		v.add(field, CodeRequired)
+	}
+	return v
+}
+
+// OneOf reports a string field whose value is not one of allowed
+func (v *Validator) OneOf(field string, allowed ...string) *Validator {
+	value := v.get(field)
+	if !present(value) {
+		return v
+	}
+	s, ok := value.(string)
+	if !ok {
+		panic(fmt.Sprintf("validate: OneOf on non-string field %q", field))
+	}
+	for _, a := range allowed {
+		if s == a {
+			return v
+		}
+	}
+	v.add(field, CodeOneOf, strings.Join(allowed, ", "))
+	return v
+}
+
+// Range reports an integer field, or any element of an integer list,
+// outside [min, max]
+func (v *Validator) Range(field string, min, max int) *Validator {
+	value := v.get(field)
+	if !present(value) {
+		return v
+	}
+	var values []int
+	switch x := value.(type) {
+	case int:
+		values = []int{x}
+	case *int:
+		values = []int{*x}
+	case []int:
+		values = x
+	default:
+		panic(fmt.Sprintf("validate: Range on non-integer field %q", field))
+	}
+	for _, n := range values {
+		if n < min || n > max {
+			v.add(field, CodeOutOfRange, min, max)
+			break
+		}
+	}
+	return v
+}
+
+// MutuallyExclusive reports every field after the first present one
+// among fields, so at most one of them may be set
+func (v *Validator) MutuallyExclusive(fields ...string) *Validator {
+	first := ""
+	for _, field := range fields {
+		if !present(v.get(field)) {
+			continue
+		}
+		if first == "" {
+			first = field
+			continue
+		}
+		v.add(field, CodeMutuallyExclusive, first)
+	}
+	return v
+}
+
+// Errors returns the errors found, in the order the rules ran, or nil
+// when the value is valid
+func (v *Validator) Errors() []FieldError {
+	return v.errs
+}
+
+// get returns the value of field. Rules naming a field the value does
+// not declare are programming errors.
+func (v *Validator) get(field string) any {
+	accessor, ok := v.fields[field]
+	if !ok {
+		panic(fmt.Sprintf("validate: unknown field %q", field))
+	}
+	return accessor()
+}
+
+// add records a failed rule, with args for the message of code
+func (v *Validator) add(field, code string, args ...any) {
+	message := Messages[code]
+	if len(args) > 0 {
+		message = fmt.Sprintf(message, args...)
+	}
+	v.errs = append(v.errs, FieldError{Field: field, Code: code, Message: message, Args: args})
+}
+
+// present reports whether value counts as set
+func present(value any) bool {
+	switch x := value.(type) {
+	case nil:
+		return false
+	case string:
+		return x != ""
+	case *int:
+		return x != nil
+	case []int:
+		return len(x) > 0
+	case int:
+		return true
+	case bool:
+		return x
+	default:
+		panic(fmt.Sprintf("validate: unsupported field type %T", value))
+	}
+}
@@ -0,0 +1,189 @@
+package validate_test
+
+import (
+	"encoding/json"
+	"go-examples/pkg/validate"
+	"reflect"
+	"testing"
+)
+
+// request is a request with one field of each supported type
+type request struct {
+	Operation string
+	A         int
+	B         *int
+	Operands  []int
+	Explain   bool
+}
+
+func (r request) ValidationFields() validate.Fields {
+	return validate.Fields{
+		"operation": func() any { return r.Operation },
+		"a":         func() any { return r.A },
+		"b":         func() any { return r.B },
+		"operands":  func() any { return r.Operands },
+		"explain":   func() any { return r.Explain },
+	}
+}
+
+// intPtr returns a pointer to n
+func intPtr(n int) *int {
+	return &n
+}
+
+// fe builds a FieldError with the message each rule produces in these tests
+func fe(field, code string) validate.FieldError {
+	messages := map[string]string{
+		validate.CodeRequired:          "is required",
+		validate.CodeOneOf:             "must be one of add, subtract",
+		validate.CodeOutOfRange:        "must be between -10 and 10",
+		validate.CodeMutuallyExclusive: "cannot be used with operands",
+	}
+	args := map[string][]any{
+		validate.CodeOneOf:             {"add, subtract"},
+		validate.CodeOutOfRange:        {-10, 10},
+		validate.CodeMutuallyExclusive: {"operands"},
+	}
+	return validate.FieldError{Field: field, Code: code, Message: messages[code], Args: args[code]}
+}
+
+// rule applies rules to a Validator
+type rule = func(*validate.Validator) *validate.Validator
+
+func require(field string) rule {
+	return func(v *validate.Validator) *validate.Validator { return v.Require(field) }
+}
+
+func oneOf(field string) rule {
+	return func(v *validate.Validator) *validate.Validator { return v.OneOf(field, "add", "subtract") }
+}
+
+func inRange(field string) rule {
+	return func(v *validate.Validator) *validate.Validator { return v.Range(field, -10, 10) }
+}
+
+func exclusive(fields ...string) rule {
+	return func(v *validate.Validator) *validate.Validator { return v.MutuallyExclusive(fields...) }
+}
+
+// TestRules tests each rule on present, absent and invalid values
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		req   request
+		rules rule
+		want  []validate.FieldError
+	}{
+		// Require
+		{"require string present", request{Operation: "add"}, require("operation"), nil},
+		{"require string empty", request{}, require("operation"), []validate.FieldError{fe("operation", validate.CodeRequired)}},
+		{"require int zero", request{}, require("a"), nil},
+		{"require pointer nil", request{}, require("b"), []validate.FieldError{fe("b", validate.CodeRequired)}},
+		{"require pointer to zero", request{B: intPtr(0)}, require("b"), nil},
+		{"require list empty", request{Operands: []int{}}, require("operands"), []validate.FieldError{fe("operands", validate.CodeRequired)}},
+		{"require list set", request{Operands: []int{1}}, require("operands"), nil},
+
+		// OneOf
+		{"one of allowed", request{Operation: "subtract"}, oneOf("operation"), nil},
+		{"one of other", request{Operation: "modulo"}, oneOf("operation"), []validate.FieldError{fe("operation", validate.CodeOneOf)}},
+		{"one of is case-sensitive", request{Operation: "ADD"}, oneOf("operation"), []validate.FieldError{fe("operation", validate.CodeOneOf)}},
+		{"one of absent", request{}, oneOf("operation"), nil},
+
+		// Range
+		{"range int inside", request{A: 10}, inRange("a"), nil},
+		{"range int below", request{A: -11}, inRange("a"), []validate.FieldError{fe("a", validate.CodeOutOfRange)}},
+		{"range int above", request{A: 11}, inRange("a"), []validate.FieldError{fe("a", validate.CodeOutOfRange)}},
+		{"range pointer inside", request{B: intPtr(-10)}, inRange("b"), nil},
+		{"range pointer outside", request{B: intPtr(100)}, inRange("b"), []validate.FieldError{fe("b", validate.CodeOutOfRange)}},
+		{"range pointer absent", request{}, inRange("b"), nil},
+		{"range list inside", request{Operands: []int{-10, 0, 10}}, inRange("operands"), nil},
+		{"range list one outside", request{Operands: []int{1, 11, 12}}, inRange("operands"), []validate.FieldError{fe("operands", validate.CodeOutOfRange)}},
+
+		// MutuallyExclusive
+		{"exclusive neither", request{}, exclusive("operands", "b"), nil},
+		{"exclusive first only", request{Operands: []int{1}}, exclusive("operands", "b"), nil},
+		{"exclusive second only", request{B: intPtr(1)}, exclusive("operands", "b"), nil},
+		{"exclusive both", request{Operands: []int{1}, B: intPtr(1)}, exclusive("operands", "b"), []validate.FieldError{fe("b", validate.CodeMutuallyExclusive)}},
+		{"exclusive flag unset", request{Operands: []int{1}}, exclusive("operands", "explain"), nil},
+		{"exclusive flag set", request{Operands: []int{1}, Explain: true}, exclusive("operands", "explain"), []validate.FieldError{fe("explain", validate.CodeMutuallyExclusive)}},
+		{"exclusive three", request{Operands: []int{1}, B: intPtr(1), Operation: "add"}, exclusive("operands", "b", "operation"), []validate.FieldError{fe("b", validate.CodeMutuallyExclusive), fe("operation", validate.CodeMutuallyExclusive)}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.rules(validate.Struct(tc.req)).Errors()
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Errors() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestCombinations tests chained rules: errors keep rule order, and a
+// missing field is only reported by Require
+func TestCombinations(t *testing.T) {
+	chain := func(r request) []validate.FieldError {
+		return validate.Struct(r).
+			Require("operation").
+			OneOf("operation", "add", "subtract").
+			Range("a", -10, 10).
+			Range("b", -10, 10).
+			MutuallyExclusive("operands", "b").
+			Errors()
+	}
+	tests := []struct {
+		name string
+		req  request
+		want []validate.FieldError
+	}{
+		{"valid", request{Operation: "add", A: 1, B: intPtr(2)}, nil},
+		{"missing operation reported once", request{A: 1}, []validate.FieldError{fe("operation", validate.CodeRequired)}},
+		{"every rule fails", request{Operation: "pow", A: 50, B: intPtr(50), Operands: []int{1}}, []validate.FieldError{
+			fe("operation", validate.CodeOneOf),
+			fe("a", validate.CodeOutOfRange),
+			fe("b", validate.CodeOutOfRange),
+			fe("b", validate.CodeMutuallyExclusive),
+		}},
+		{"valid operands instead of b", request{Operation: "subtract", Operands: []int{1, 2}}, nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := chain(tc.req); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Errors() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestFieldErrorJSON tests the wire format of a field error
+func TestFieldErrorJSON(t *testing.T) {
+	data, err := json.Marshal(fe("operation", validate.CodeRequired))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"field":"operation","code":"required","message":"is required"}`; string(data) != want {
+		t.Errorf("JSON = %s, want %s", data, want)
+	}
+	if got := fe("operation", validate.CodeRequired).Error(); got != "operation is required" {
+		t.Errorf("Error() = %q", got)
+	}
+}
+
+// TestMisuse tests that rules naming unknown fields or using the wrong
+// field type panic, as they are programming errors
+func TestMisuse(t *testing.T) {
+	tests := map[string]func(*validate.Validator){
+		"unknown field":     func(v *validate.Validator) { v.Require("c") },
+		"one of on int":     func(v *validate.Validator) { v.OneOf("a", "1") },
+		"range on a string": func(v *validate.Validator) { v.Range("operation", 0, 1) },
+	}
+	for name, misuse := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic")
+				}
+			}()
+			misuse(validate.Struct(request{Operation: "add"}))
+		})
+	}
+}